@@ -26,6 +26,10 @@ func RunConsoleMode(parser parser.ParserService, mb backend.MemoryBackendService
 	}
 	defer l.Close()
 
+	// Консоль - одна сессия: BEGIN и COMMIT можно вводить в разных строках
+	session := mb.NewSession()
+	defer session.Close()
+
 	fmt.Println("Welcome to custom-database.")
 
 repl:
@@ -63,19 +67,28 @@ repl:
 			continue repl
 		}
 
-		results, err := mb.ExecuteStatement(result)
+		results, err := session.ExecuteStatement(result)
 		if err != nil {
 			fmt.Println(err)
 			continue repl
 		}
 
 		if isDebug {
-			debugTable(results)
+			if results == nil {
+				debugTable(nil)
+			} else {
+				debugTable(results.Table)
+			}
+			continue repl
+		}
+
+		if results != nil && results.Table != nil {
+			printTable(results.Table)
 			continue repl
 		}
 
-		if results != nil {
-			printTable(results)
+		if results != nil && results.Command != "" {
+			fmt.Printf("%s %d\n", results.Command, results.RowsAffected)
 			continue repl
 		}
 
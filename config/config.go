@@ -1,25 +1,72 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	DBPath      string
-	Environment string
-	Port        string
+	DBPath        string
+	Environment   string
+	Port          string
+	StorageEngine string
+	// BufferPoolSize - количество страниц по 4KB в буферном пуле disk_manager, 0 - размер по умолчанию
+	BufferPoolSize int
+	// IndexFanout - максимальное количество ключей в узле B+ дерева индекса, 0 - сколько помещается на странице
+	IndexFanout int
+	// IndexFillFactor - заполненность в процентах (50-100) узлов дерева, которое CREATE INDEX строит
+	// по заполненной таблице, 0 - 90%. Запас позволяет вставлять строки, не разделяя сразу все узлы
+	IndexFillFactor int
+	// WorkMemory - память в KB, в которой ORDER BY сортирует строки, а GROUP BY собирает группы, 0 - 4096.
+	// Данные сверх нее записываются во временные файлы
+	WorkMemory int
 }
 
 func Load() (*Config, error) {
-	if err := godotenv.Load(); err != nil {
+	// .env необязателен: без него используются переменные окружения и значения по умолчанию
+	if err := godotenv.Load(); err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
 
+	bufferPoolSize, err := strconv.Atoi(getEnv("BUFFER_POOL_SIZE", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("Load(): invalid BUFFER_POOL_SIZE: %w", err)
+	}
+
+	indexFanout, err := strconv.Atoi(getEnv("INDEX_FANOUT", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("Load(): invalid INDEX_FANOUT: %w", err)
+	}
+
+	indexFillFactor, err := strconv.Atoi(getEnv("INDEX_FILL_FACTOR", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("Load(): invalid INDEX_FILL_FACTOR: %w", err)
+	}
+
+	workMemory, err := strconv.Atoi(getEnv("WORK_MEMORY", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("Load(): invalid WORK_MEMORY: %w", err)
+	}
+
 	return &Config{
-		DBPath:      os.Getenv("DB_PATH"),
-		Environment: os.Getenv("ENV"),
-		Port:        os.Getenv("PORT"),
+		DBPath:          getEnv("DB_PATH", "tables"),
+		Environment:     os.Getenv("ENV"),
+		Port:            os.Getenv("PORT"),
+		StorageEngine:   getEnv("STORAGE_ENGINE", "disk"),
+		BufferPoolSize:  bufferPoolSize,
+		IndexFanout:     indexFanout,
+		IndexFillFactor: indexFillFactor,
+		WorkMemory:      workMemory,
 	}, nil
 }
+
+func getEnv(key string, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+
+	return defaultValue
+}
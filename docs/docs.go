@@ -54,6 +54,26 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/stats": {
+            "get": {
+                "description": "Возвращает счетчики движка хранения: попадания, промахи, вытеснения и записи буферного пула disk_manager",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stats"
+                ],
+                "summary": "Счетчики движка хранения",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StatsResponse"
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
@@ -80,11 +100,28 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Query executed successfully"
                 },
+                "rows_affected": {
+                    "description": "RowsAffected - количество строк, затронутых INSERT/UPDATE/DELETE",
+                    "type": "integer",
+                    "example": 3
+                },
                 "success": {
                     "type": "boolean",
                     "example": true
                 }
             }
+        },
+        "handlers.StatsResponse": {
+            "type": "object",
+            "properties": {
+                "stats": {
+                    "description": "Stats - счетчики движка хранения, например попадания и промахи буферного пула",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                }
+            }
         }
     }
 }`
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"os/exec"
+	"sync"
 	"testing"
 	"time"
 
@@ -23,8 +24,9 @@ type QueryRequest struct {
 }
 
 type QueryResponse struct {
-	Result interface{} `json:"result"`
-	Error  string      `json:"error,omitempty"`
+	Result       interface{} `json:"result"`
+	RowsAffected *int        `json:"rows_affected,omitempty"`
+	Error        string      `json:"error,omitempty"`
 }
 
 func waitForServer(url string, timeout time.Duration) error {
@@ -225,6 +227,28 @@ func TestWhereClause(t *testing.T) {
 		assert.Equal(t, want, response.Result)
 	})
 
+	// NOT без EXISTS и IN - синтаксическая ошибка, которая не роняет сервер и ничего не меняет
+	t.Run("NOT Condition", func(t *testing.T) {
+		queries := []string{
+			"SELECT * FROM test_table_2 WHERE NOT age = 25;",
+			"SELECT * FROM test_table_2 WHERE id = 1 AND NOT id = 2;",
+			"DELETE FROM test_table_2 WHERE NOT id = 1;",
+			"DELETE FROM test_table_2 WHERE id > 0 AND NOT id = 1;",
+			"UPDATE test_table_2 SET age = 0 WHERE NOT id = 1;",
+			"UPDATE test_table_2 SET age = 0 WHERE id > 0 AND NOT id = 1;",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.NotEmpty(t, response.Error, query)
+		}
+
+		response := executeQuery(t, "SELECT id, age FROM test_table_2;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_2","columns":[{"name":"id","type":1},{"name":"age","type":1}],"rows":[[1,25],[2,30],[3,35],[4,40]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
 	// Очистка
 	t.Run("Cleanup", func(t *testing.T) {
 		query := "DROP TABLE test_table_2;"
@@ -232,3 +256,1481 @@ func TestWhereClause(t *testing.T) {
 		assert.Empty(t, response.Error)
 	})
 }
+
+func TestDelete(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_3 (id INT, name TEXT, age INT);",
+			"INSERT INTO test_table_3 VALUES (1, 'Alice', 25);",
+			"INSERT INTO test_table_3 VALUES (2, 'Bob', 30);",
+			"INSERT INTO test_table_3 VALUES (3, 'Charlie', 35);",
+			"INSERT INTO test_table_3 VALUES (4, 'David', 40);",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+
+	t.Run("Delete with WHERE", func(t *testing.T) {
+		response := executeQuery(t, "DELETE FROM test_table_3 WHERE age > 25 AND age < 40;")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 2, *response.RowsAffected)
+
+		response = executeQuery(t, "SELECT id, name FROM test_table_3;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_3","columns":[{"name":"id","type":1},{"name":"name","type":0}],"rows":[[1,"Alice"],[4,"David"]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Delete without matches", func(t *testing.T) {
+		response := executeQuery(t, "DELETE FROM test_table_3 WHERE id = 100;")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 0, *response.RowsAffected)
+	})
+
+	t.Run("Delete all rows", func(t *testing.T) {
+		response := executeQuery(t, "DELETE FROM test_table_3;")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 2, *response.RowsAffected)
+
+		response = executeQuery(t, "SELECT id FROM test_table_3;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_3","columns":[{"name":"id","type":1}],"rows":[]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		response := executeQuery(t, "DROP TABLE test_table_3;")
+		assert.Empty(t, response.Error)
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_4 (id INT, name TEXT, age INT, is_admin BOOLEAN);",
+			"INSERT INTO test_table_4 VALUES (1, 'Alice', 25, true);",
+			"INSERT INTO test_table_4 VALUES (2, 'Bob', 30, false);",
+			"INSERT INTO test_table_4 VALUES (3, 'Charlie', 35, true);",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+
+	t.Run("Update with WHERE", func(t *testing.T) {
+		response := executeQuery(t, "UPDATE test_table_4 SET age = 31, is_admin = true WHERE name = 'Bob';")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 1, *response.RowsAffected)
+
+		response = executeQuery(t, "SELECT * FROM test_table_4 WHERE id = 2;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_4","columns":[{"name":"id","type":1},{"name":"name","type":0},{"name":"age","type":1},{"name":"is_admin","type":2}],"rows":[[2,"Bob",31,true]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Update row to a longer value", func(t *testing.T) {
+		response := executeQuery(t, "UPDATE test_table_4 SET name = 'Alice Cooper the Second' WHERE id = 1;")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 1, *response.RowsAffected)
+
+		response = executeQuery(t, "SELECT id, name FROM test_table_4 WHERE id = 1;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_4","columns":[{"name":"id","type":1},{"name":"name","type":0}],"rows":[[1,"Alice Cooper the Second"]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Update all rows with NULL", func(t *testing.T) {
+		response := executeQuery(t, "UPDATE test_table_4 SET age = null;")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 3, *response.RowsAffected)
+
+		response = executeQuery(t, "SELECT age FROM test_table_4;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_4","columns":[{"name":"age","type":1}],"rows":[[null],[null],[null]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Update with wrong value type", func(t *testing.T) {
+		response := executeQuery(t, "UPDATE test_table_4 SET age = 'old';")
+		assert.NotEmpty(t, response.Error)
+	})
+
+	t.Run("Update unknown column", func(t *testing.T) {
+		response := executeQuery(t, "UPDATE test_table_4 SET unknown = 1;")
+		assert.NotEmpty(t, response.Error)
+	})
+
+	t.Run("Update with arithmetic", func(t *testing.T) {
+		response := executeQuery(t, "UPDATE test_table_4 SET age = id * 10 + 5 WHERE id < 3;")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 2, *response.RowsAffected)
+
+		response = executeQuery(t, "UPDATE test_table_4 SET age = (age - 5) / 2 - 1 WHERE id = 2;")
+		assert.Empty(t, response.Error)
+
+		// NULL в операнде дает NULL
+		response = executeQuery(t, "UPDATE test_table_4 SET age = age + 1 WHERE id = 3;")
+		assert.Empty(t, response.Error)
+
+		response = executeQuery(t, "SELECT id, age FROM test_table_4;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_4","columns":[{"name":"id","type":1},{"name":"age","type":1}],"rows":[[1,15],[2,9],[3,null]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Update with invalid arithmetic", func(t *testing.T) {
+		queries := map[string]string{
+			"UPDATE test_table_4 SET age = age / 0 WHERE id = 1;":          "division by zero",
+			"UPDATE test_table_4 SET age = 2147483647 + age WHERE id = 1;": "integer out of range",
+			"UPDATE test_table_4 SET age = name + 1;":                      "operator does not exist: text + int",
+			"UPDATE test_table_4 SET name = id + 1;":                       "column name is of type text but expression is of type int",
+		}
+
+		for query, want := range queries {
+			response := executeQuery(t, query)
+			assert.Equal(t, want, response.Error, query)
+		}
+
+		response := executeQuery(t, "SELECT id, age FROM test_table_4 WHERE id = 1;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_4","columns":[{"name":"id","type":1},{"name":"age","type":1}],"rows":[[1,15]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		response := executeQuery(t, "DROP TABLE test_table_4;")
+		assert.Empty(t, response.Error)
+	})
+}
+
+func TestTransactions(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		response := executeQuery(t, "CREATE TABLE test_table_5 (id INT, name TEXT);")
+		assert.Empty(t, response.Error)
+	})
+
+	t.Run("Commit applies all statements", func(t *testing.T) {
+		response := executeQuery(t, "BEGIN; INSERT INTO test_table_5 VALUES (1, 'Alice'); CREATE TABLE test_table_6 (id INT); INSERT INTO test_table_6 VALUES (1); COMMIT;")
+		assert.Empty(t, response.Error)
+		assert.Nil(t, response.RowsAffected)
+
+		response = executeQuery(t, "SELECT * FROM test_table_5;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_5","columns":[{"name":"id","type":1},{"name":"name","type":0}],"rows":[[1,"Alice"]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT * FROM test_table_6;")
+		assert.Empty(t, response.Error)
+	})
+
+	t.Run("Rollback discards all statements", func(t *testing.T) {
+		response := executeQuery(t, "BEGIN; INSERT INTO test_table_5 VALUES (2, 'Bob'); DELETE FROM test_table_5; DROP TABLE test_table_6; ROLLBACK;")
+		assert.Empty(t, response.Error)
+		assert.Nil(t, response.RowsAffected)
+		assert.Equal(t, "Query executed successfully", response.Result)
+
+		response = executeQuery(t, "SELECT id FROM test_table_5;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_5","columns":[{"name":"id","type":1}],"rows":[[1]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT * FROM test_table_6;")
+		assert.Empty(t, response.Error)
+	})
+
+	t.Run("Error rolls back the whole transaction", func(t *testing.T) {
+		response := executeQuery(t, "BEGIN; INSERT INTO test_table_5 VALUES (2, 'Bob'); UPDATE test_table_5 SET unknown = 1; COMMIT;")
+		assert.NotEmpty(t, response.Error)
+
+		response = executeQuery(t, "SELECT id FROM test_table_5;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_5","columns":[{"name":"id","type":1}],"rows":[[1]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Transaction without COMMIT is rolled back", func(t *testing.T) {
+		response := executeQuery(t, "BEGIN; INSERT INTO test_table_5 VALUES (2, 'Bob');")
+		assert.NotEmpty(t, response.Error)
+
+		response = executeQuery(t, "SELECT id FROM test_table_5;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_5","columns":[{"name":"id","type":1}],"rows":[[1]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Commit without transaction", func(t *testing.T) {
+		response := executeQuery(t, "COMMIT;")
+		assert.NotEmpty(t, response.Error)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		response := executeQuery(t, "DROP TABLE test_table_5; DROP TABLE test_table_6;")
+		assert.Empty(t, response.Error)
+	})
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	const writers, inserts = 4, 25
+
+	t.Run("Setup Test Data", func(t *testing.T) {
+		response := executeQuery(t, "CREATE TABLE test_table_7 (id INT, name TEXT);")
+		assert.Empty(t, response.Error)
+	})
+
+	t.Run("Concurrent inserts and selects", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(2)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < inserts; i++ {
+					response := executeQuery(t, fmt.Sprintf("INSERT INTO test_table_7 VALUES (%d, 'writer-%d');", w*inserts+i, w))
+					assert.Empty(t, response.Error)
+				}
+			}(w)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < inserts; i++ {
+					response := executeQuery(t, "SELECT id FROM test_table_7;")
+					assert.Empty(t, response.Error)
+				}
+			}()
+		}
+		wg.Wait()
+
+		response := executeQuery(t, "SELECT id FROM test_table_7;")
+		assert.Empty(t, response.Error)
+
+		var table struct {
+			Rows [][]interface{} `json:"rows"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(response.Result.(string)), &table))
+		assert.Len(t, table.Rows, writers*inserts)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		response := executeQuery(t, "DROP TABLE test_table_7;")
+		assert.Empty(t, response.Error)
+	})
+}
+
+func TestIndexes(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_8 (id INT, name TEXT, age INT);",
+			"INSERT INTO test_table_8 VALUES (3, 'Charlie', 35);",
+			"INSERT INTO test_table_8 VALUES (1, 'Alice', 25);",
+			"INSERT INTO test_table_8 VALUES (10, 'Judy', 40);",
+			"INSERT INTO test_table_8 VALUES (2, 'Bob', NULL);",
+			"CREATE INDEX test_table_8_id ON test_table_8 (id);",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+
+	t.Run("Create duplicate index", func(t *testing.T) {
+		response := executeQuery(t, "CREATE INDEX test_table_8_id ON test_table_8 (age);")
+		assert.NotEmpty(t, response.Error)
+
+		response = executeQuery(t, "CREATE INDEX test_table_8_name ON test_table_8 (unknown);")
+		assert.NotEmpty(t, response.Error)
+	})
+
+	t.Run("Select by indexed equality", func(t *testing.T) {
+		response := executeQuery(t, "SELECT id, name FROM test_table_8 WHERE id = 10;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_8","columns":[{"name":"id","type":1},{"name":"name","type":0}],"rows":[[10,"Judy"]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Select by indexed range compares numbers", func(t *testing.T) {
+		response := executeQuery(t, "SELECT id FROM test_table_8 WHERE id > 1 AND 10 > id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_8","columns":[{"name":"id","type":1}],"rows":[[2],[3]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT id FROM test_table_8 WHERE id > 2 AND name = 'Judy';")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_8","columns":[{"name":"id","type":1}],"rows":[[10]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Update and delete through index", func(t *testing.T) {
+		response := executeQuery(t, "UPDATE test_table_8 SET id = 20 WHERE id = 10;")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 1, *response.RowsAffected)
+
+		response = executeQuery(t, "DELETE FROM test_table_8 WHERE id < 3;")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 2, *response.RowsAffected)
+
+		response = executeQuery(t, "SELECT id FROM test_table_8 WHERE id > 0;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_8","columns":[{"name":"id","type":1}],"rows":[[3],[20]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Drop index", func(t *testing.T) {
+		response := executeQuery(t, "DROP INDEX test_table_8_id;")
+		assert.Empty(t, response.Error)
+
+		response = executeQuery(t, "DROP INDEX test_table_8_id;")
+		assert.NotEmpty(t, response.Error)
+
+		response = executeQuery(t, "SELECT id FROM test_table_8 WHERE id = 20;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_8","columns":[{"name":"id","type":1}],"rows":[[20]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		response := executeQuery(t, "DROP TABLE test_table_8;")
+		assert.Empty(t, response.Error)
+	})
+}
+
+func TestCompositeIndexes(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_9 (tenant_id INT, created_at TIMESTAMP, title TEXT);",
+			"INSERT INTO test_table_9 VALUES (2, '2024-05-03 10:00:00', 'deploy');",
+			"INSERT INTO test_table_9 VALUES (1, '2024-05-02 10:00:00', 'login');",
+			"INSERT INTO test_table_9 VALUES (1, NULL, 'draft');",
+			"INSERT INTO test_table_9 VALUES (1, '2024-05-01 10:00:00', 'signup');",
+			"INSERT INTO test_table_9 VALUES (1, '2024-05-04 10:00:00', 'logout');",
+			"CREATE INDEX test_table_9_tenant_time ON test_table_9 (tenant_id, created_at);",
+			"CREATE INDEX test_table_9_title ON test_table_9 (title);",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+
+	t.Run("Create index on repeated column", func(t *testing.T) {
+		response := executeQuery(t, "CREATE INDEX test_table_9_bad ON test_table_9 (tenant_id, tenant_id);")
+		assert.NotEmpty(t, response.Error)
+	})
+
+	t.Run("Select by equality prefix returns rows in index order", func(t *testing.T) {
+		response := executeQuery(t, "SELECT title FROM test_table_9 WHERE tenant_id = 1;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_9","columns":[{"name":"title","type":0}],"rows":[["signup"],["login"],["logout"],["draft"]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Select by equality and range on the next column", func(t *testing.T) {
+		response := executeQuery(t, "SELECT title FROM test_table_9 WHERE tenant_id = 1 AND created_at > '2024-05-01 10:00:00' AND created_at < '2024-05-04 10:00:00';")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_9","columns":[{"name":"title","type":0}],"rows":[["login"]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT title FROM test_table_9 WHERE tenant_id > 1;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_9","columns":[{"name":"title","type":0}],"rows":[["deploy"]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Select by text index", func(t *testing.T) {
+		response := executeQuery(t, "SELECT tenant_id, title FROM test_table_9 WHERE title > 'd' AND title < 'login';")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_9","columns":[{"name":"tenant_id","type":1},{"name":"title","type":0}],"rows":[[2,"deploy"],[1,"draft"]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "UPDATE test_table_9 SET title = 'sign-up' WHERE title = 'signup';")
+		assert.Empty(t, response.Error)
+
+		response = executeQuery(t, "SELECT created_at FROM test_table_9 WHERE title = 'sign-up';")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_9","columns":[{"name":"created_at","type":3}],"rows":[["2024-05-01 10:00:00"]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		response := executeQuery(t, "DROP TABLE test_table_9;")
+		assert.Empty(t, response.Error)
+	})
+}
+
+func TestOrderBy(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_10 (id INT, score INT, name TEXT);",
+			"INSERT INTO test_table_10 VALUES (1, 30, 'Carol');",
+			"INSERT INTO test_table_10 VALUES (2, NULL, 'Eve');",
+			"INSERT INTO test_table_10 VALUES (3, 10, 'Alice');",
+			"INSERT INTO test_table_10 VALUES (4, 50, 'Bob');",
+			"INSERT INTO test_table_10 VALUES (5, 20, 'Dave');",
+			"INSERT INTO test_table_10 VALUES (6, 40, 'Alice');",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+
+	// Одни и те же запросы без индекса сортируются в памяти, с индексом - читаются в порядке индекса
+	checkOrder := func(t *testing.T) {
+		response := executeQuery(t, "SELECT id FROM test_table_10 ORDER BY score LIMIT 3;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_10","columns":[{"name":"id","type":1}],"rows":[[3],[5],[1]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT id FROM test_table_10 ORDER BY score DESC LIMIT 2 OFFSET 1;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_10","columns":[{"name":"id","type":1}],"rows":[[4],[6]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT id FROM test_table_10 WHERE score > 10 AND score < 50 ORDER BY score DESC;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_10","columns":[{"name":"id","type":1}],"rows":[[6],[1],[5]]}`
+		assert.Equal(t, want, response.Result)
+
+		// Без ORDER BY порядок не определен, но LIMIT после WHERE ограничивает результат
+		response = executeQuery(t, "SELECT id FROM test_table_10 WHERE score > 10 LIMIT 2;")
+		assert.Empty(t, response.Error)
+		var table struct {
+			Rows [][]interface{} `json:"rows"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(response.Result.(string)), &table))
+		assert.Len(t, table.Rows, 2)
+
+		response = executeQuery(t, "SELECT id FROM test_table_10 WHERE name = 'Alice' ORDER BY score DESC;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_10","columns":[{"name":"id","type":1}],"rows":[[6],[3]]}`
+		assert.Equal(t, want, response.Result)
+	}
+
+	t.Run("Order without index", checkOrder)
+
+	t.Run("NULL goes last ascending and first descending", func(t *testing.T) {
+		response := executeQuery(t, "SELECT id FROM test_table_10 ORDER BY score;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_10","columns":[{"name":"id","type":1}],"rows":[[3],[5],[1],[6],[4],[2]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT id FROM test_table_10 ORDER BY score DESC LIMIT 2;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_10","columns":[{"name":"id","type":1}],"rows":[[2],[4]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Order with index", func(t *testing.T) {
+		response := executeQuery(t, "CREATE INDEX test_table_10_score ON test_table_10 (score);")
+		assert.Empty(t, response.Error)
+
+		checkOrder(t)
+	})
+
+	t.Run("Order by second column of composite index", func(t *testing.T) {
+		response := executeQuery(t, "CREATE INDEX test_table_10_name_id ON test_table_10 (name, id);")
+		assert.Empty(t, response.Error)
+
+		response = executeQuery(t, "SELECT id FROM test_table_10 WHERE name = 'Alice' ORDER BY id DESC LIMIT 1;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_10","columns":[{"name":"id","type":1}],"rows":[[6]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT name FROM test_table_10 ORDER BY name ASC LIMIT 3;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_10","columns":[{"name":"name","type":0}],"rows":[["Alice"],["Alice"],["Bob"]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Order by unknown column", func(t *testing.T) {
+		response := executeQuery(t, "SELECT id FROM test_table_10 ORDER BY unknown;")
+		assert.NotEmpty(t, response.Error)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		response := executeQuery(t, "DROP TABLE test_table_10;")
+		assert.Empty(t, response.Error)
+	})
+}
+
+func TestConstraints(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_11 (id INT PRIMARY KEY, email TEXT UNIQUE, tenant_id INT, login TEXT, CONSTRAINT test_table_11_login UNIQUE (tenant_id, login));",
+			"INSERT INTO test_table_11 VALUES (1, 'ann@example.com', 1, 'ann');",
+			"INSERT INTO test_table_11 VALUES (2, NULL, 1, 'bob');",
+			"INSERT INTO test_table_11 VALUES (3, NULL, 2, 'ann');",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+
+	t.Run("Duplicate primary key", func(t *testing.T) {
+		response := executeQuery(t, "INSERT INTO test_table_11 VALUES (1, 'other@example.com', 3, 'other');")
+		assert.Equal(t, `duplicate key value violates unique constraint "test_table_11_pkey": key (id)=(1) already exists`, response.Error)
+
+		response = executeQuery(t, "INSERT INTO test_table_11 VALUES (NULL, 'other@example.com', 3, 'other');")
+		assert.Equal(t, `null value in column "id" violates primary key constraint "test_table_11_pkey"`, response.Error)
+	})
+
+	t.Run("Duplicate unique column and composite key", func(t *testing.T) {
+		response := executeQuery(t, "INSERT INTO test_table_11 VALUES (4, 'ann@example.com', 3, 'other');")
+		assert.Equal(t, `duplicate key value violates unique constraint "test_table_11_email_key": key (email)=(ann@example.com) already exists`, response.Error)
+
+		response = executeQuery(t, "INSERT INTO test_table_11 VALUES (4, NULL, 2, 'ann');")
+		assert.Equal(t, `duplicate key value violates unique constraint "test_table_11_login": key (tenant_id, login)=(2, ann) already exists`, response.Error)
+
+		response = executeQuery(t, "UPDATE test_table_11 SET email = 'ann@example.com' WHERE id = 2;")
+		assert.Equal(t, `duplicate key value violates unique constraint "test_table_11_email_key": key (email)=(ann@example.com) already exists`, response.Error)
+	})
+
+	t.Run("Failed statement leaves the table unchanged", func(t *testing.T) {
+		response := executeQuery(t, "UPDATE test_table_11 SET login = 'ann' WHERE tenant_id = 1;")
+		assert.NotEmpty(t, response.Error)
+
+		response = executeQuery(t, "SELECT id, login FROM test_table_11 ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_11","columns":[{"name":"id","type":1},{"name":"login","type":0}],"rows":[[1,"ann"],[2,"bob"],[3,"ann"]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "UPDATE test_table_11 SET email = 'bob@example.com' WHERE id = 2;")
+		assert.Empty(t, response.Error)
+	})
+
+	t.Run("Constraint index cannot be dropped", func(t *testing.T) {
+		response := executeQuery(t, "DROP INDEX test_table_11_pkey;")
+		assert.NotEmpty(t, response.Error)
+	})
+
+	t.Run("Multiple primary keys", func(t *testing.T) {
+		response := executeQuery(t, "CREATE TABLE test_table_11_invalid (id INT PRIMARY KEY, code INT, PRIMARY KEY (code));")
+		assert.NotEmpty(t, response.Error)
+
+		response = executeQuery(t, "SELECT id FROM test_table_11_invalid;")
+		assert.NotEmpty(t, response.Error)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		response := executeQuery(t, "DROP TABLE test_table_11;")
+		assert.Empty(t, response.Error)
+	})
+}
+
+func TestNotNullDefaultCheck(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_12 (id INT NOT NULL, age INT DEFAULT 18 CHECK (age > 0), name TEXT DEFAULT 'guest', created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, CONSTRAINT adult_or_named CHECK (age > 17 OR name != 'guest'));",
+			"INSERT INTO test_table_12 VALUES (1, 30, 'ann', '2024-05-01 10:00:00');",
+			"INSERT INTO test_table_12 VALUES (2, DEFAULT, 'bob');",
+			"INSERT INTO test_table_12 VALUES (3);",
+			"INSERT INTO test_table_12 VALUES (4, NULL, NULL, NULL);",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+
+	t.Run("Defaults are applied", func(t *testing.T) {
+		response := executeQuery(t, "SELECT id, age, name FROM test_table_12 ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_12","columns":[{"name":"id","type":1},{"name":"age","type":1},{"name":"name","type":0}],"rows":[[1,30,"ann"],[2,18,"bob"],[3,18,"guest"],[4,null,null]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT id FROM test_table_12 WHERE created_at > '2025-01-01 00:00:00' ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_12","columns":[{"name":"id","type":1}],"rows":[[2],[3]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Not null violation", func(t *testing.T) {
+		response := executeQuery(t, "INSERT INTO test_table_12 VALUES (NULL, 20);")
+		assert.Equal(t, `null value in column "id" of relation "test_table_12" violates not-null constraint`, response.Error)
+
+		response = executeQuery(t, "UPDATE test_table_12 SET id = NULL WHERE id = 1;")
+		assert.Equal(t, `null value in column "id" of relation "test_table_12" violates not-null constraint`, response.Error)
+	})
+
+	t.Run("Check violation", func(t *testing.T) {
+		response := executeQuery(t, "INSERT INTO test_table_12 VALUES (5, 0, 'eve');")
+		assert.Equal(t, `new row for relation "test_table_12" violates check constraint "test_table_12_age_check"`, response.Error)
+
+		response = executeQuery(t, "INSERT INTO test_table_12 VALUES (5, 10);")
+		assert.Equal(t, `new row for relation "test_table_12" violates check constraint "adult_or_named"`, response.Error)
+
+		response = executeQuery(t, "UPDATE test_table_12 SET age = 5, name = DEFAULT WHERE id = 1;")
+		assert.Equal(t, `new row for relation "test_table_12" violates check constraint "adult_or_named"`, response.Error)
+
+		response = executeQuery(t, "UPDATE test_table_12 SET age = DEFAULT WHERE id = 1;")
+		assert.Empty(t, response.Error)
+	})
+
+	t.Run("Invalid definitions", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_12_invalid (id INT DEFAULT 'one');",
+			"CREATE TABLE test_table_12_invalid (id INT DEFAULT CURRENT_TIMESTAMP);",
+			"CREATE TABLE test_table_12_invalid (id INT CHECK (missing > 0));",
+			"INSERT INTO test_table_12 VALUES (6, 20, 'kim', '2024-05-01 10:00:00', 1);",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.NotEmpty(t, response.Error, query)
+		}
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		response := executeQuery(t, "DROP TABLE test_table_12;")
+		assert.Empty(t, response.Error)
+	})
+}
+
+func TestForeignKeys(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_13 (id INT PRIMARY KEY, code TEXT UNIQUE, manager_id INT REFERENCES test_table_13 ON DELETE SET NULL);",
+			"CREATE TABLE test_table_13_items (id INT PRIMARY KEY, owner_id INT REFERENCES test_table_13 (id) ON DELETE CASCADE ON UPDATE CASCADE, owner_code TEXT, CONSTRAINT items_code FOREIGN KEY (owner_code) REFERENCES test_table_13 (code));",
+			"INSERT INTO test_table_13 VALUES (1, 'a', NULL);",
+			"INSERT INTO test_table_13 VALUES (2, 'b', 1);",
+			"INSERT INTO test_table_13 VALUES (3, 'c', 3);",
+			"INSERT INTO test_table_13_items VALUES (10, 1, 'a');",
+			"INSERT INTO test_table_13_items VALUES (11, 2, NULL);",
+			"INSERT INTO test_table_13_items VALUES (12, 2, 'c');",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error, query)
+		}
+	})
+
+	t.Run("Missing referenced row", func(t *testing.T) {
+		response := executeQuery(t, "INSERT INTO test_table_13_items VALUES (13, 5, NULL);")
+		assert.Equal(t, `insert or update on table "test_table_13_items" violates foreign key constraint "test_table_13_items_owner_id_fkey": key (owner_id)=(5) is not present in table "test_table_13"`, response.Error)
+
+		response = executeQuery(t, "UPDATE test_table_13_items SET owner_code = 'z' WHERE id = 11;")
+		assert.Equal(t, `insert or update on table "test_table_13_items" violates foreign key constraint "items_code": key (owner_code)=(z) is not present in table "test_table_13"`, response.Error)
+	})
+
+	t.Run("Restrict", func(t *testing.T) {
+		response := executeQuery(t, "DELETE FROM test_table_13 WHERE id = 3;")
+		assert.Equal(t, `update or delete on table "test_table_13" violates foreign key constraint "items_code" on table "test_table_13_items": key (code)=(c) is still referenced from table "test_table_13_items"`, response.Error)
+
+		response = executeQuery(t, "UPDATE test_table_13 SET code = 'd' WHERE id = 3;")
+		assert.NotEmpty(t, response.Error)
+	})
+
+	t.Run("Cascade update", func(t *testing.T) {
+		response := executeQuery(t, "UPDATE test_table_13 SET id = 20 WHERE id = 2;")
+		assert.Empty(t, response.Error)
+
+		response = executeQuery(t, "SELECT id, owner_id FROM test_table_13_items ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_13_items","columns":[{"name":"id","type":1},{"name":"owner_id","type":1}],"rows":[[10,1],[11,20],[12,20]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Cascade delete and set null", func(t *testing.T) {
+		response := executeQuery(t, "DELETE FROM test_table_13 WHERE id = 1;")
+		assert.Empty(t, response.Error)
+
+		response = executeQuery(t, "SELECT id, manager_id FROM test_table_13 ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_13","columns":[{"name":"id","type":1},{"name":"manager_id","type":1}],"rows":[[3,3],[20,null]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT id FROM test_table_13_items ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_13_items","columns":[{"name":"id","type":1}],"rows":[[11],[12]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Invalid definitions", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_13_invalid (id INT REFERENCES missing_table);",
+			"CREATE TABLE test_table_13_invalid (id TEXT REFERENCES test_table_13);",
+			"CREATE TABLE test_table_13_invalid (id INT REFERENCES test_table_13 (manager_id));",
+			"CREATE TABLE test_table_13_invalid (id INT, FOREIGN KEY (id) REFERENCES test_table_13 (id, code));",
+			"DROP INDEX test_table_13_items_owner_id_fkey;",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.NotEmpty(t, response.Error, query)
+		}
+	})
+
+	t.Run("Drop referenced table", func(t *testing.T) {
+		response := executeQuery(t, "DROP TABLE test_table_13;")
+		assert.Equal(t, "cannot drop table test_table_13 because other objects depend on it: constraint items_code on table test_table_13_items", response.Error)
+
+		response = executeQuery(t, "DROP TABLE test_table_13 CASCADE;")
+		assert.Empty(t, response.Error)
+
+		response = executeQuery(t, "INSERT INTO test_table_13_items VALUES (13, 5, 'z');")
+		assert.Empty(t, response.Error)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		response := executeQuery(t, "DROP TABLE test_table_13_items;")
+		assert.Empty(t, response.Error)
+	})
+}
+
+func TestAlterTable(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_14 (id INT PRIMARY KEY, name TEXT CHECK (name != ''), score TEXT);",
+			"CREATE TABLE test_table_14_items (id INT, owner_id INT REFERENCES test_table_14);",
+			"INSERT INTO test_table_14 VALUES (1, 'ann', '10');",
+			"INSERT INTO test_table_14 VALUES (2, 'bob', NULL);",
+			"INSERT INTO test_table_14_items VALUES (100, 2);",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+
+	t.Run("Add column with default", func(t *testing.T) {
+		response := executeQuery(t, "ALTER TABLE test_table_14 ADD COLUMN level INT NOT NULL DEFAULT 1 CHECK (level > 0);")
+		assert.Empty(t, response.Error)
+
+		response = executeQuery(t, "INSERT INTO test_table_14 VALUES (3, 'cid', '7', 5);")
+		assert.Empty(t, response.Error)
+
+		response = executeQuery(t, "SELECT id, level FROM test_table_14 ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_14","columns":[{"name":"id","type":1},{"name":"level","type":1}],"rows":[[1,1],[2,1],[3,5]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "UPDATE test_table_14 SET level = 0 WHERE id = 1;")
+		assert.Equal(t, `new row for relation "test_table_14" violates check constraint "test_table_14_level_check"`, response.Error)
+
+		response = executeQuery(t, "ALTER TABLE test_table_14 ADD COLUMN note TEXT NOT NULL;")
+		assert.Equal(t, `null value in column "note" of relation "test_table_14" violates not-null constraint`, response.Error)
+	})
+
+	t.Run("Rename column and table", func(t *testing.T) {
+		queries := []string{
+			"ALTER TABLE test_table_14 RENAME COLUMN name TO login;",
+			"ALTER TABLE test_table_14 RENAME TO test_table_14_users;",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+
+		response := executeQuery(t, "SELECT id FROM test_table_14;")
+		assert.NotEmpty(t, response.Error)
+
+		response = executeQuery(t, "SELECT id, login FROM test_table_14_users WHERE id = 2;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_14_users","columns":[{"name":"id","type":1},{"name":"login","type":0}],"rows":[[2,"bob"]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "INSERT INTO test_table_14_users VALUES (4, '', '1', 1);")
+		assert.Equal(t, `new row for relation "test_table_14_users" violates check constraint "test_table_14_name_check"`, response.Error)
+
+		response = executeQuery(t, "INSERT INTO test_table_14_users VALUES (2, 'dan', '1', 1);")
+		assert.Equal(t, `duplicate key value violates unique constraint "test_table_14_pkey": key (id)=(2) already exists`, response.Error)
+
+		response = executeQuery(t, "DELETE FROM test_table_14_users WHERE id = 2;")
+		assert.Equal(t, `update or delete on table "test_table_14_users" violates foreign key constraint "test_table_14_items_owner_id_fkey" on table "test_table_14_items": key (id)=(2) is still referenced from table "test_table_14_items"`, response.Error)
+	})
+
+	t.Run("Alter column type", func(t *testing.T) {
+		response := executeQuery(t, "ALTER TABLE test_table_14_users ALTER COLUMN score TYPE INT;")
+		assert.Empty(t, response.Error)
+
+		response = executeQuery(t, "SELECT id FROM test_table_14_users WHERE score > 8;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_14_users","columns":[{"name":"id","type":1}],"rows":[[1]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "ALTER TABLE test_table_14_users ALTER COLUMN login TYPE INT;")
+		assert.NotEmpty(t, response.Error)
+	})
+
+	t.Run("Drop column", func(t *testing.T) {
+		response := executeQuery(t, "ALTER TABLE test_table_14_users DROP COLUMN score;")
+		assert.Empty(t, response.Error)
+
+		response = executeQuery(t, "SELECT * FROM test_table_14_users ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_14_users","columns":[{"name":"id","type":1},{"name":"login","type":0},{"name":"level","type":1}],"rows":[[1,"ann",1],[2,"bob",1],[3,"cid",5]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Invalid alterations", func(t *testing.T) {
+		response := executeQuery(t, "ALTER TABLE test_table_14_users DROP COLUMN id;")
+		assert.Equal(t, "cannot drop column id of table test_table_14_users because index test_table_14_pkey depends on it", response.Error)
+
+		response = executeQuery(t, "ALTER TABLE test_table_14_users DROP COLUMN login;")
+		assert.Equal(t, "cannot drop column login of table test_table_14_users because check constraint test_table_14_name_check depends on it", response.Error)
+
+		queries := []string{
+			"ALTER TABLE test_table_14_users ADD COLUMN level INT;",
+			"ALTER TABLE test_table_14_users RENAME COLUMN missing TO other;",
+			"ALTER TABLE test_table_14_users RENAME TO test_table_14_items;",
+			"ALTER TABLE test_table_14_users ALTER COLUMN level TYPE TIMESTAMP;",
+			"ALTER TABLE test_table_14_items ALTER COLUMN owner_id TYPE TEXT;",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.NotEmpty(t, response.Error, query)
+		}
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		queries := []string{
+			"DROP TABLE test_table_14_items;",
+			"DROP TABLE test_table_14_users;",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+}
+
+func TestInsertColumnsAndRows(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_15 (id INT PRIMARY KEY, name TEXT NOT NULL, level INT DEFAULT 1, parent_id INT REFERENCES test_table_15);",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+
+	t.Run("Insert several rows with column list", func(t *testing.T) {
+		response := executeQuery(t, "INSERT INTO test_table_15 (name, id) VALUES ('ann', 1), ('bob', 2), ('cid', 3);")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 3, *response.RowsAffected)
+
+		// Строки одного INSERT могут ссылаться друг на друга
+		response = executeQuery(t, "INSERT INTO test_table_15 (id, parent_id, name, level) VALUES (5, 4, 'eve', DEFAULT), (4, 1, 'dan', 7);")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 2, *response.RowsAffected)
+
+		response = executeQuery(t, "SELECT * FROM test_table_15 ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_15","columns":[{"name":"id","type":1},{"name":"name","type":0},{"name":"level","type":1},{"name":"parent_id","type":1}],"rows":[[1,"ann",1,null],[2,"bob",1,null],[3,"cid",1,null],[4,"dan",7,1],[5,"eve",1,4]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Failed row rolls back the whole statement", func(t *testing.T) {
+		response := executeQuery(t, "INSERT INTO test_table_15 VALUES (6, 'fay'), (1, 'gus');")
+		assert.Equal(t, `duplicate key value violates unique constraint "test_table_15_pkey": key (id)=(1) already exists`, response.Error)
+
+		response = executeQuery(t, "SELECT id FROM test_table_15 WHERE id = 6;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_15","columns":[{"name":"id","type":1}],"rows":[]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Invalid column lists", func(t *testing.T) {
+		response := executeQuery(t, "INSERT INTO test_table_15 (id, missing) VALUES (7, 1);")
+		assert.Equal(t, "column missing of relation test_table_15 does not exist", response.Error)
+
+		response = executeQuery(t, "INSERT INTO test_table_15 (id, name, id) VALUES (7, 'hal', 8);")
+		assert.Equal(t, "column id specified more than once", response.Error)
+
+		response = executeQuery(t, "INSERT INTO test_table_15 (id, name) VALUES (7, 'hal', 1);")
+		assert.Equal(t, "INSERT has more expressions than target columns: 3 > 2", response.Error)
+
+		response = executeQuery(t, "INSERT INTO test_table_15 (id, name) VALUES (7);")
+		assert.Equal(t, "INSERT has more target columns than expressions: 2 > 1", response.Error)
+
+		response = executeQuery(t, "INSERT INTO test_table_15 (id) VALUES (7);")
+		assert.Equal(t, `null value in column "name" of relation "test_table_15" violates not-null constraint`, response.Error)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		response := executeQuery(t, "DROP TABLE test_table_15;")
+		assert.Empty(t, response.Error)
+	})
+}
+
+func TestInsertSelect(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_16 (id INT PRIMARY KEY, name TEXT, is_admin BOOLEAN);",
+			"INSERT INTO test_table_16 VALUES (1, 'ann', true), (2, 'bob', false), (3, 'cid', true);",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+
+	t.Run("Create table as select", func(t *testing.T) {
+		response := executeQuery(t, "CREATE TABLE test_table_16_admins AS SELECT name, id FROM test_table_16 WHERE is_admin = true;")
+		assert.Empty(t, response.Error)
+
+		response = executeQuery(t, "SELECT * FROM test_table_16_admins ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_16_admins","columns":[{"name":"name","type":0},{"name":"id","type":1}],"rows":[["ann",1],["cid",3]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Insert select", func(t *testing.T) {
+		response := executeQuery(t, "INSERT INTO test_table_16_admins (id, name) SELECT id, name FROM test_table_16 WHERE is_admin = false;")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 1, *response.RowsAffected)
+
+		// Строки SELECT читаются до записи, поэтому копия таблицы в саму себя удваивает ее
+		response = executeQuery(t, "INSERT INTO test_table_16_admins SELECT name, id FROM test_table_16_admins;")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 3, *response.RowsAffected)
+
+		response = executeQuery(t, "SELECT id FROM test_table_16_admins ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_16_admins","columns":[{"name":"id","type":1}],"rows":[[1],[1],[2],[2],[3],[3]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "INSERT INTO test_table_16 SELECT name, id FROM test_table_16_admins WHERE id = 1;")
+		assert.Equal(t, "column id is of type int but expression is of type text", response.Error)
+
+		response = executeQuery(t, "INSERT INTO test_table_16 (name, id) SELECT name, id FROM test_table_16_admins WHERE id = 1;")
+		assert.Equal(t, `duplicate key value violates unique constraint "test_table_16_pkey": key (id)=(1) already exists`, response.Error)
+	})
+
+	t.Run("Invalid create table as select", func(t *testing.T) {
+		response := executeQuery(t, "CREATE TABLE test_table_16_copy AS SELECT id, id FROM test_table_16;")
+		assert.Equal(t, "column id specified more than once", response.Error)
+
+		response = executeQuery(t, "CREATE TABLE test_table_16_copy AS SELECT missing FROM test_table_16;")
+		assert.Equal(t, "column not found: missing", response.Error)
+
+		response = executeQuery(t, "CREATE TABLE test_table_16_admins AS SELECT id FROM test_table_16;")
+		assert.NotEmpty(t, response.Error)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		queries := []string{
+			"DROP TABLE test_table_16_admins;",
+			"DROP TABLE test_table_16;",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+}
+
+func TestOrderByKeys(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_17 (id INT, team TEXT, score INT);",
+			"INSERT INTO test_table_17 VALUES (1, 'a', 10), (2, 'b', NULL), (3, 'a', NULL), (4, 'b', 30), (5, 'a', 30), (6, NULL, 20), (7, 'b', 30);",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+
+	// Одни и те же запросы без индекса сортируются, а с индексом (team, score) часть из них читается в его порядке
+	checkOrder := func(t *testing.T) {
+		response := executeQuery(t, "SELECT id FROM test_table_17 ORDER BY team, score DESC, id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_17","columns":[{"name":"id","type":1}],"rows":[[3],[5],[1],[2],[4],[7],[6]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT id FROM test_table_17 ORDER BY team NULLS FIRST, score ASC NULLS FIRST, id DESC LIMIT 4 OFFSET 1;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_17","columns":[{"name":"id","type":1}],"rows":[[3],[1],[5],[2]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT id FROM test_table_17 ORDER BY score DESC NULLS LAST, id;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_17","columns":[{"name":"id","type":1}],"rows":[[4],[5],[7],[6],[1],[2],[3]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT id FROM test_table_17 WHERE team = 'a' ORDER BY team DESC, score;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_17","columns":[{"name":"id","type":1}],"rows":[[1],[5],[3]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT id FROM test_table_17 ORDER BY team DESC, score DESC LIMIT 2;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_17","columns":[{"name":"id","type":1}],"rows":[[6],[2]]}`
+		assert.Equal(t, want, response.Result)
+
+		// Ключ ORDER BY может быть именем колонки результата из AS
+		response = executeQuery(t, "SELECT id, team AS t, score AS s FROM test_table_17 WHERE team = 'a' ORDER BY t DESC, s;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_17","columns":[{"name":"id","type":1},{"name":"t","type":0},{"name":"s","type":1}],"rows":[[1,"a",10],[5,"a",30],[3,"a",null]]}`
+		assert.Equal(t, want, response.Result)
+	}
+
+	t.Run("Order without index", checkOrder)
+
+	t.Run("Order with index", func(t *testing.T) {
+		response := executeQuery(t, "CREATE INDEX test_table_17_team_score ON test_table_17 (team, score);")
+		assert.Empty(t, response.Error)
+
+		checkOrder(t)
+	})
+
+	t.Run("Invalid order", func(t *testing.T) {
+		response := executeQuery(t, "SELECT id FROM test_table_17 ORDER BY team, unknown;")
+		assert.Equal(t, "column not found: unknown", response.Error)
+
+		response = executeQuery(t, "SELECT id FROM test_table_17 ORDER BY team NULLS;")
+		assert.NotEmpty(t, response.Error)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		response := executeQuery(t, "DROP TABLE test_table_17;")
+		assert.Empty(t, response.Error)
+	})
+}
+
+func TestAggregates(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_18 (id INT, team TEXT, score INT);",
+			"INSERT INTO test_table_18 VALUES (1, 'a', 10), (2, 'a', 20), (3, 'a', 20), (4, 'b', 5), (5, 'b', NULL), (6, NULL, 7);",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+
+	t.Run("Aggregates without GROUP BY", func(t *testing.T) {
+		response := executeQuery(t, "SELECT COUNT(*), COUNT(score), SUM(score), AVG(score), MIN(score), MAX(team) FROM test_table_18;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_18","columns":[{"name":"count","type":1},{"name":"count","type":1},{"name":"sum","type":1},{"name":"avg","type":1},{"name":"min","type":1},{"name":"max","type":0}],"rows":[[6,5,62,12,5,"b"]]}`
+		assert.Equal(t, want, response.Result)
+
+		// Без GROUP BY группа одна даже без строк
+		response = executeQuery(t, "SELECT COUNT(*) AS total, SUM(score) FROM test_table_18 WHERE id > 100;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_18","columns":[{"name":"total","type":1},{"name":"sum","type":1}],"rows":[[0,null]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Group by", func(t *testing.T) {
+		response := executeQuery(t, "SELECT team, COUNT(*) AS games, COUNT(DISTINCT score), SUM(score) FROM test_table_18 GROUP BY team ORDER BY team NULLS FIRST;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_18","columns":[{"name":"team","type":0},{"name":"games","type":1},{"name":"count","type":1},{"name":"sum","type":1}],"rows":[[null,1,1,7],["a",3,2,50],["b",2,1,5]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT team, score, COUNT(*) AS n FROM test_table_18 WHERE score > 0 GROUP BY team, score ORDER BY n DESC, team LIMIT 2;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_18","columns":[{"name":"team","type":0},{"name":"score","type":1},{"name":"n","type":1}],"rows":[["a",20,2],["a",10,1]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT team FROM test_table_18 WHERE id > 100 GROUP BY team;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_18","columns":[{"name":"team","type":0}],"rows":[]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Having", func(t *testing.T) {
+		response := executeQuery(t, "SELECT team, MAX(score) FROM test_table_18 GROUP BY team HAVING COUNT(*) > 1 AND SUM(score) > 10 ORDER BY team;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_18","columns":[{"name":"team","type":0},{"name":"max","type":1}],"rows":[["a",20]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT team FROM test_table_18 GROUP BY team HAVING team = 'b' OR MIN(score) = 7;")
+		assert.Empty(t, response.Error)
+		var table struct {
+			Rows [][]interface{} `json:"rows"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(response.Result.(string)), &table))
+		assert.ElementsMatch(t, [][]interface{}{{"b"}, {nil}}, table.Rows)
+	})
+
+	t.Run("Create table from groups", func(t *testing.T) {
+		response := executeQuery(t, "CREATE TABLE test_table_18_teams AS SELECT team, COUNT(*) AS games FROM test_table_18 GROUP BY team;")
+		assert.Empty(t, response.Error)
+
+		response = executeQuery(t, "SELECT games FROM test_table_18_teams WHERE team = 'a';")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_18_teams","columns":[{"name":"games","type":1}],"rows":[[3]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Invalid aggregates", func(t *testing.T) {
+		response := executeQuery(t, "SELECT team, score FROM test_table_18 GROUP BY team;")
+		assert.Equal(t, "column score must appear in the GROUP BY clause or be used in an aggregate function", response.Error)
+
+		response = executeQuery(t, "SELECT team FROM test_table_18 GROUP BY team HAVING score > 1;")
+		assert.Equal(t, "column score must appear in the GROUP BY clause or be used in an aggregate function", response.Error)
+
+		response = executeQuery(t, "SELECT SUM(team) FROM test_table_18;")
+		assert.Equal(t, "function sum(text) does not exist", response.Error)
+
+		response = executeQuery(t, "SELECT COUNT(missing) FROM test_table_18;")
+		assert.Equal(t, "column not found: missing", response.Error)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		queries := []string{
+			"DROP TABLE test_table_18_teams;",
+			"DROP TABLE test_table_18;",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+}
+
+func TestJoins(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_19_users (id INT PRIMARY KEY, name TEXT, team INT);",
+			"CREATE TABLE test_table_19_orders (id INT PRIMARY KEY, user_id INT, total INT);",
+			"CREATE INDEX test_table_19_orders_user ON test_table_19_orders (user_id);",
+			"CREATE TABLE test_table_19_teams (team INT, title TEXT);",
+			"INSERT INTO test_table_19_users VALUES (1, 'ann', 10), (2, 'bob', 20), (3, 'cid', NULL);",
+			"INSERT INTO test_table_19_orders VALUES (100, 1, 5), (101, 1, 7), (102, 2, 3), (103, 9, 1), (104, NULL, 2);",
+			"INSERT INTO test_table_19_teams VALUES (10, 'red'), (30, 'blue');",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+
+	t.Run("Inner join", func(t *testing.T) {
+		response := executeQuery(t, "SELECT u.name, o.total FROM test_table_19_users u JOIN test_table_19_orders AS o ON u.id = o.user_id WHERE o.total > 2 ORDER BY o.total;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_19_users","columns":[{"name":"name","type":0},{"name":"total","type":1}],"rows":[["bob",3],["ann",5],["ann",7]]}`
+		assert.Equal(t, want, response.Result)
+
+		// Колонку без имени таблицы можно писать, если она есть только в одной таблице
+		response = executeQuery(t, "SELECT name, total FROM test_table_19_users INNER JOIN test_table_19_orders ON test_table_19_users.id = user_id WHERE name = 'bob';")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_19_users","columns":[{"name":"name","type":0},{"name":"total","type":1}],"rows":[["bob",3]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Outer joins", func(t *testing.T) {
+		response := executeQuery(t, "SELECT u.name, o.id FROM test_table_19_users u LEFT JOIN test_table_19_orders o ON o.user_id = u.id ORDER BY u.id, o.id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_19_users","columns":[{"name":"name","type":0},{"name":"id","type":1}],"rows":[["ann",100],["ann",101],["bob",102],["cid",null]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT u.name, o.id FROM test_table_19_users u RIGHT OUTER JOIN test_table_19_orders o ON o.user_id = u.id ORDER BY o.id;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_19_users","columns":[{"name":"name","type":0},{"name":"id","type":1}],"rows":[["ann",100],["ann",101],["bob",102],[null,103],[null,104]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT u.name, t.title FROM test_table_19_users u FULL JOIN test_table_19_teams t ON u.team = t.team ORDER BY u.name, t.title;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_19_users","columns":[{"name":"name","type":0},{"name":"title","type":0}],"rows":[["ann","red"],["bob",null],["cid",null],[null,"blue"]]}`
+		assert.Equal(t, want, response.Result)
+
+		// Условие ON отбирает пары, а строка без пары все равно остается в LEFT JOIN
+		response = executeQuery(t, "SELECT u.name, o.id FROM test_table_19_users u LEFT JOIN test_table_19_orders o ON o.user_id = u.id AND o.total > 6 ORDER BY u.id;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_19_users","columns":[{"name":"name","type":0},{"name":"id","type":1}],"rows":[["ann",101],["bob",null],["cid",null]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Cross join", func(t *testing.T) {
+		response := executeQuery(t, "SELECT COUNT(*) FROM test_table_19_users CROSS JOIN test_table_19_teams;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_19_users","columns":[{"name":"count","type":1}],"rows":[[6]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT a.name, b.name FROM test_table_19_users a, test_table_19_users b WHERE a.id < b.id ORDER BY a.id, b.id;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_19_users","columns":[{"name":"name","type":0},{"name":"name","type":0}],"rows":[["ann","bob"],["ann","cid"],["bob","cid"]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Join using", func(t *testing.T) {
+		// Общая колонка USING идет первой и одна, а в FULL JOIN берет значение той стороны, где оно есть
+		response := executeQuery(t, "SELECT * FROM test_table_19_users FULL JOIN test_table_19_teams USING (team) ORDER BY team;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_19_users","columns":[{"name":"team","type":1},{"name":"id","type":1},{"name":"name","type":0},{"name":"title","type":0}],"rows":[[10,1,"ann","red"],[20,2,"bob",null],[30,null,null,"blue"],[null,3,"cid",null]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Join algorithms", func(t *testing.T) {
+		// Равенство по индексам сливается, без индекса - соединяется хешем, а условие OR проверяется
+		// на каждой паре. Результат от этого не зависит, и NULL не равен NULL
+		queries := []string{
+			"SELECT u.id, o.id FROM test_table_19_orders o FULL JOIN test_table_19_users u ON u.id = o.user_id ORDER BY u.id, o.id;",
+			"SELECT u.id, o.id FROM test_table_19_orders o FULL JOIN test_table_19_users u ON u.id = o.user_id AND o.total = o.total ORDER BY u.id, o.id;",
+			"SELECT u.id, o.id FROM test_table_19_orders o FULL JOIN test_table_19_users u ON u.id = o.user_id OR u.id = o.user_id ORDER BY u.id, o.id;",
+			"SELECT u.id, o.id FROM test_table_19_orders o FULL JOIN test_table_19_users u ON u.team = o.user_id OR u.id = o.user_id ORDER BY u.id, o.id;",
+		}
+		want := `{"name":"test_table_19_orders","columns":[{"name":"id","type":1},{"name":"id","type":1}],"rows":[[1,100],[1,101],[2,102],[3,null],[null,103],[null,104]]}`
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+			assert.Equal(t, want, response.Result)
+		}
+	})
+
+	t.Run("Join several tables with groups", func(t *testing.T) {
+		response := executeQuery(t, "SELECT t.title, COUNT(o.id) AS placed, SUM(o.total) FROM test_table_19_users u JOIN test_table_19_teams t ON t.team = u.team LEFT JOIN test_table_19_orders o ON o.user_id = u.id GROUP BY t.title;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_19_users","columns":[{"name":"title","type":0},{"name":"placed","type":1},{"name":"sum","type":1}],"rows":[["red",2,12]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Invalid joins", func(t *testing.T) {
+		response := executeQuery(t, "SELECT team FROM test_table_19_users u JOIN test_table_19_teams t ON u.team = t.team;")
+		assert.Equal(t, "column reference team is ambiguous", response.Error)
+
+		response = executeQuery(t, "SELECT x.name FROM test_table_19_users u;")
+		assert.Equal(t, "missing FROM-clause entry for table x", response.Error)
+
+		response = executeQuery(t, "SELECT * FROM test_table_19_users JOIN test_table_19_users ON id = id;")
+		assert.Equal(t, "table name test_table_19_users specified more than once", response.Error)
+
+		response = executeQuery(t, "SELECT * FROM test_table_19_users JOIN test_table_19_orders USING (user_id);")
+		assert.Equal(t, "column user_id specified in USING clause does not exist in left table", response.Error)
+
+		response = executeQuery(t, "SELECT * FROM test_table_19_users u JOIN test_table_19_orders o ON u.missing = o.id;")
+		assert.Equal(t, "column not found: u.missing", response.Error)
+	})
+
+	t.Run("Comparisons with NULL", func(t *testing.T) {
+		// Сравнение с NULL неизвестно для любого оператора, и строка под него не подходит
+		response := executeQuery(t, "SELECT name FROM test_table_19_users WHERE team != 10 ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_19_users","columns":[{"name":"name","type":0}],"rows":[["bob"]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT name FROM test_table_19_users WHERE team = NULL OR team != NULL;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_19_users","columns":[{"name":"name","type":0}],"rows":[]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT u.id, o.id FROM test_table_19_users u JOIN test_table_19_orders o ON u.id != o.user_id WHERE o.id = 104;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_19_users","columns":[{"name":"id","type":1},{"name":"id","type":1}],"rows":[]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "DELETE FROM test_table_19_orders WHERE user_id != 9;")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 3, *response.RowsAffected)
+
+		response = executeQuery(t, "SELECT id FROM test_table_19_orders ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_19_orders","columns":[{"name":"id","type":1}],"rows":[[103],[104]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		queries := []string{
+			"DROP TABLE test_table_19_teams;",
+			"DROP TABLE test_table_19_orders;",
+			"DROP TABLE test_table_19_users;",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+}
+
+func TestSubqueries(t *testing.T) {
+	t.Run("Setup Test Data", func(t *testing.T) {
+		queries := []string{
+			"CREATE TABLE test_table_20_users (id INT PRIMARY KEY, name TEXT, team INT);",
+			"CREATE TABLE test_table_20_orders (id INT PRIMARY KEY, user_id INT, total INT);",
+			"CREATE TABLE test_table_20_bans (user_id INT);",
+			"INSERT INTO test_table_20_users VALUES (1, 'ann', 10), (2, 'bob', 20), (3, 'cid', NULL), (4, 'dan', 10);",
+			"INSERT INTO test_table_20_orders VALUES (100, 1, 5), (101, 1, 7), (102, 2, 3), (103, 9, 1), (104, NULL, 2);",
+			"INSERT INTO test_table_20_bans VALUES (2), (NULL);",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+
+	t.Run("In and not in", func(t *testing.T) {
+		response := executeQuery(t, "SELECT name FROM test_table_20_users WHERE id IN (SELECT user_id FROM test_table_20_orders) ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_20_users","columns":[{"name":"name","type":0}],"rows":[["ann"],["bob"]]}`
+		assert.Equal(t, want, response.Result)
+
+		// NULL среди значений подзапроса делает NOT IN неизвестным для всех строк, кроме найденных
+		response = executeQuery(t, "SELECT name FROM test_table_20_users WHERE id NOT IN (SELECT user_id FROM test_table_20_bans);")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_20_users","columns":[{"name":"name","type":0}],"rows":[]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT name FROM test_table_20_users WHERE id NOT IN (SELECT user_id FROM test_table_20_bans WHERE user_id > 0) ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_20_users","columns":[{"name":"name","type":0}],"rows":[["ann"],["cid"],["dan"]]}`
+		assert.Equal(t, want, response.Result)
+
+		// Строка с NULL не подходит ни под IN, ни под NOT IN
+		response = executeQuery(t, "SELECT id FROM test_table_20_orders WHERE user_id NOT IN (SELECT id FROM test_table_20_users) ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_20_orders","columns":[{"name":"id","type":1}],"rows":[[103]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Exists and not exists", func(t *testing.T) {
+		response := executeQuery(t, "SELECT name FROM test_table_20_users u WHERE EXISTS (SELECT * FROM test_table_20_orders o WHERE o.user_id = u.id) ORDER BY name;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_20_users","columns":[{"name":"name","type":0}],"rows":[["ann"],["bob"]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT name FROM test_table_20_users u WHERE NOT EXISTS (SELECT * FROM test_table_20_orders o WHERE o.user_id = u.id) ORDER BY name;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_20_users","columns":[{"name":"name","type":0}],"rows":[["cid"],["dan"]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT COUNT(*) FROM test_table_20_users WHERE EXISTS (SELECT * FROM test_table_20_bans WHERE user_id = 7);")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_20_users","columns":[{"name":"count","type":1}],"rows":[[0]]}`
+		assert.Equal(t, want, response.Result)
+
+		// Подзапрос видит колонки всех внешних запросов
+		response = executeQuery(t, "SELECT name FROM test_table_20_users u WHERE EXISTS (SELECT * FROM test_table_20_orders o WHERE o.user_id = u.id AND EXISTS (SELECT * FROM test_table_20_bans b WHERE b.user_id = u.id));")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_20_users","columns":[{"name":"name","type":0}],"rows":[["bob"]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Correlated subqueries with and without semi join", func(t *testing.T) {
+		// Подзапрос, связанный с запросом только равенствами, выполняется один раз, а с LIMIT
+		// или другим условием по колонкам запроса - для каждой строки. Результат от этого не зависит
+		queries := []string{
+			"SELECT id FROM test_table_20_orders o WHERE o.user_id IN (SELECT id FROM test_table_20_users u WHERE u.id = o.user_id AND u.team = 10) ORDER BY id;",
+			"SELECT id FROM test_table_20_orders o WHERE o.user_id IN (SELECT id FROM test_table_20_users u WHERE u.id = o.user_id AND u.team = 10 LIMIT 10) ORDER BY id;",
+			"SELECT id FROM test_table_20_orders o WHERE EXISTS (SELECT * FROM test_table_20_users u WHERE u.id = o.user_id AND u.team = 10) ORDER BY id;",
+			"SELECT id FROM test_table_20_orders o WHERE EXISTS (SELECT * FROM test_table_20_users u WHERE u.id = o.user_id AND u.team = 10 AND o.total > 0) ORDER BY id;",
+		}
+		want := `{"name":"test_table_20_orders","columns":[{"name":"id","type":1}],"rows":[[100],[101]]}`
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+			assert.Equal(t, want, response.Result)
+		}
+	})
+
+	t.Run("Scalar subqueries", func(t *testing.T) {
+		response := executeQuery(t, "SELECT name, (SELECT SUM(total) FROM test_table_20_orders o WHERE o.user_id = u.id) AS spent FROM test_table_20_users u ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_20_users","columns":[{"name":"name","type":0},{"name":"spent","type":1}],"rows":[["ann",12],["bob",3],["cid",null],["dan",null]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT id FROM test_table_20_orders WHERE total > (SELECT AVG(total) FROM test_table_20_orders) ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_20_orders","columns":[{"name":"id","type":1}],"rows":[[100],[101]]}`
+		assert.Equal(t, want, response.Result)
+
+		// Без строк скалярный подзапрос равен NULL, а колонка называется по колонке подзапроса
+		response = executeQuery(t, "SELECT id, (SELECT name FROM test_table_20_users WHERE id = 100) FROM test_table_20_orders WHERE id = 100;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_20_orders","columns":[{"name":"id","type":1},{"name":"name","type":0}],"rows":[[100,null]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT user_id, SUM(total) FROM test_table_20_orders GROUP BY user_id HAVING user_id IN (SELECT id FROM test_table_20_users WHERE team = 10);")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_20_orders","columns":[{"name":"user_id","type":1},{"name":"sum","type":1}],"rows":[[1,12]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Subqueries in FROM and JOIN", func(t *testing.T) {
+		response := executeQuery(t, "SELECT t.user_id, t.spent FROM (SELECT user_id, SUM(total) AS spent FROM test_table_20_orders GROUP BY user_id) t WHERE t.spent > 2 ORDER BY t.user_id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"","columns":[{"name":"user_id","type":1},{"name":"spent","type":1}],"rows":[[1,12],[2,3]]}`
+		assert.Equal(t, want, response.Result)
+
+		response = executeQuery(t, "SELECT u.name, s.spent FROM test_table_20_users u JOIN (SELECT user_id, SUM(total) AS spent FROM test_table_20_orders GROUP BY user_id) AS s ON s.user_id = u.id ORDER BY u.name;")
+		assert.Empty(t, response.Error)
+		want = `{"name":"test_table_20_users","columns":[{"name":"name","type":0},{"name":"spent","type":1}],"rows":[["ann",12],["bob",3]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Update and delete with subqueries", func(t *testing.T) {
+		response := executeQuery(t, "UPDATE test_table_20_users SET team = 30 WHERE id IN (SELECT user_id FROM test_table_20_orders WHERE total > 6);")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 1, *response.RowsAffected)
+
+		response = executeQuery(t, "DELETE FROM test_table_20_users WHERE NOT EXISTS (SELECT * FROM test_table_20_orders WHERE test_table_20_orders.user_id = test_table_20_users.id);")
+		assert.Empty(t, response.Error)
+		require.NotNil(t, response.RowsAffected)
+		assert.Equal(t, 2, *response.RowsAffected)
+
+		response = executeQuery(t, "SELECT id, team FROM test_table_20_users ORDER BY id;")
+		assert.Empty(t, response.Error)
+		want := `{"name":"test_table_20_users","columns":[{"name":"id","type":1},{"name":"team","type":1}],"rows":[[1,30],[2,20]]}`
+		assert.Equal(t, want, response.Result)
+	})
+
+	t.Run("Invalid subqueries", func(t *testing.T) {
+		response := executeQuery(t, "SELECT (SELECT id FROM test_table_20_orders) FROM test_table_20_users;")
+		assert.Equal(t, "more than one row returned by a subquery used as an expression", response.Error)
+
+		response = executeQuery(t, "SELECT id FROM test_table_20_users WHERE id = (SELECT id, name FROM test_table_20_users WHERE id = 1);")
+		assert.Equal(t, "subquery must return only one column", response.Error)
+
+		response = executeQuery(t, "SELECT id FROM test_table_20_users WHERE id IN (SELECT id, name FROM test_table_20_users);")
+		assert.Equal(t, "subquery has too many columns", response.Error)
+
+		response = executeQuery(t, "SELECT * FROM (SELECT id FROM test_table_20_users);")
+		assert.NotEmpty(t, response.Error)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		queries := []string{
+			"DROP TABLE test_table_20_bans;",
+			"DROP TABLE test_table_20_orders;",
+			"DROP TABLE test_table_20_users;",
+		}
+
+		for _, query := range queries {
+			response := executeQuery(t, query)
+			assert.Empty(t, response.Error)
+		}
+	})
+}
@@ -0,0 +1,405 @@
+package backend
+
+import (
+	"custom-database/internal/data_structures/external_sort"
+	"custom-database/internal/models"
+	"custom-database/internal/parser/ast"
+	"custom-database/internal/parser/lex"
+	"custom-database/internal/storage"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// aggregateSpec - агрегатная функция запроса
+type aggregateSpec struct {
+	call *ast.AggregateCall
+	// column - колонка аргумента в строке таблицы, -1 для COUNT(*)
+	column     int
+	resultType models.ColumnType
+}
+
+// aggregateState - накопленное значение агрегатной функции в одной группе
+type aggregateState struct {
+	// count - количество учтенных значений без NULL, для COUNT(*) - строк
+	count int64
+	sum   int64
+	// value - текущий минимум или максимум
+	value interface{}
+	// seen - закодированные значения, уже учтенные в функции с DISTINCT
+	seen map[string]struct{}
+}
+
+// newAggregateSpec находит колонку аргумента и тип результата функции. COUNT возвращает INT,
+// SUM и AVG считаются только по INT и возвращают INT, MIN и MAX - значение типа колонки
+func newAggregateSpec(columns []models.Column, call *ast.AggregateCall) (aggregateSpec, error) {
+	spec := aggregateSpec{call: call, column: -1, resultType: models.IntType}
+	if call.Argument == nil {
+		return spec, nil
+	}
+
+	spec.column = findColumnIndex(columns, call.Argument.Value)
+	if spec.column == -1 {
+		return aggregateSpec{}, fmt.Errorf("column not found: %s", call.Argument.Value)
+	}
+	columnType := columns[spec.column].Type
+
+	switch lex.Keyword(call.Function.Value) {
+	case lex.SumKeyword, lex.AvgKeyword:
+		if columnType != models.IntType {
+			return aggregateSpec{}, fmt.Errorf("function %s(%s) does not exist", call.Function.Value, columnTypeName(columnType))
+		}
+	case lex.MinKeyword, lex.MaxKeyword:
+		spec.resultType = columnType
+	}
+
+	return spec, nil
+}
+
+// add учитывает строку в состоянии функции и возвращает, на сколько байт выросло состояние.
+// NULL не учитывается ни одной функцией, кроме COUNT(*)
+func (spec aggregateSpec) add(state *aggregateState, row []interface{}) int {
+	if spec.column == -1 {
+		state.count++
+		return 0
+	}
+
+	value := row[spec.column]
+	if value == nil {
+		return 0
+	}
+
+	grown := 0
+	if spec.call.Distinct {
+		key := encodeValues([]interface{}{value})
+		if _, ok := state.seen[key]; ok {
+			return 0
+		}
+		if state.seen == nil {
+			state.seen = map[string]struct{}{}
+		}
+		state.seen[key] = struct{}{}
+		grown = len(key) + 16
+	}
+
+	state.count++
+	switch lex.Keyword(spec.call.Function.Value) {
+	case lex.SumKeyword, lex.AvgKeyword:
+		state.sum += int64(value.(int32))
+	case lex.MinKeyword:
+		if state.value == nil || storage.CompareValues(value, state.value) < 0 {
+			state.value = value
+		}
+	case lex.MaxKeyword:
+		if state.value == nil || storage.CompareValues(value, state.value) > 0 {
+			state.value = value
+		}
+	}
+
+	return grown
+}
+
+// result возвращает значение функции по накопленному состоянию. SUM, AVG, MIN и MAX без значений
+// возвращают NULL, а AVG округляет среднее до целого
+func (spec aggregateSpec) result(state *aggregateState) (interface{}, error) {
+	function := lex.Keyword(spec.call.Function.Value)
+	if function != lex.CountKeyword && state.count == 0 {
+		return nil, nil
+	}
+
+	switch function {
+	case lex.CountKeyword:
+		return toInt32(state.count)
+	case lex.SumKeyword:
+		return toInt32(state.sum)
+	case lex.AvgKeyword:
+		return int32(math.Round(float64(state.sum) / float64(state.count))), nil
+	}
+
+	return state.value, nil
+}
+
+func toInt32(value int64) (interface{}, error) {
+	if value > math.MaxInt32 || value < math.MinInt32 {
+		return nil, fmt.Errorf("integer out of range")
+	}
+
+	return int32(value), nil
+}
+
+// encodeValues кодирует значения в строку, равную у равных значений одного типа: ключ группы или DISTINCT
+func encodeValues(values []interface{}) string {
+	var b strings.Builder
+	for _, value := range values {
+		switch value := value.(type) {
+		case nil:
+			b.WriteString("n;")
+		case int32:
+			b.WriteString("i" + strconv.Itoa(int(value)) + ";")
+		case bool:
+			b.WriteString("b" + strconv.FormatBool(value) + ";")
+		case string:
+			b.WriteString("s" + strconv.Itoa(len(value)) + ":" + value)
+		}
+	}
+
+	return b.String()
+}
+
+// isAggregateQuery - в SELECT есть агрегатные функции, GROUP BY или HAVING, и строки результата - группы
+func isAggregateQuery(statement *ast.SelectStatement) bool {
+	if len(statement.GroupBy) > 0 || statement.Having != nil {
+		return true
+	}
+	for _, exp := range statement.SelectedColumns {
+		if exp.Kind == ast.AggregateKind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// groupPlan описывает строку группы: значения колонок GROUP BY, затем значения агрегатных функций.
+// Функции называются в ней текстом вызова, как в условии HAVING, поэтому HAVING проверяется
+// на строке группы тем же filterRow, что и WHERE
+type groupPlan struct {
+	groupBy    []int
+	aggregates []aggregateSpec
+	columns    []models.Column
+	// outputs - номера значений строки группы, которые попадают в результат, или -1 для скалярного
+	// подзапроса из scalars: он выполняется на строке группы
+	outputs []int
+	scalars []*ast.SelectStatement
+	// result - колонки результата по именам колонок строки FROM или AS, по ним ищутся ключи ORDER BY,
+	// names - имена колонок результата для клиента
+	result []models.Column
+	names  []string
+}
+
+func newGroupPlan(scope *fromScope, statement *ast.SelectStatement) (*groupPlan, error) {
+	allColumns := scope.columns
+	plan := &groupPlan{}
+	for _, token := range statement.GroupBy {
+		column := findColumnIndex(allColumns, token.Value)
+		if column == -1 {
+			return nil, fmt.Errorf("column not found: %s", token.Value)
+		}
+
+		plan.groupBy = append(plan.groupBy, column)
+		plan.columns = append(plan.columns, models.Column{Name: allColumns[column].Name, Type: allColumns[column].Type})
+	}
+
+	selected := statement.SelectedColumns
+	if len(selected) == 0 {
+		for _, i := range scope.star {
+			selected = append(selected, &ast.Expression{Kind: ast.LiteralKind, Literal: &lex.Token{Kind: lex.IdentifierToken, Value: allColumns[i].Name}})
+		}
+	}
+
+	for _, exp := range selected {
+		var value int
+		var name, outputName string
+		if exp.Kind == ast.SubqueryKind {
+			plan.outputs = append(plan.outputs, -1)
+			plan.scalars = append(plan.scalars, exp.Subquery)
+			if exp.Alias != nil {
+				name = exp.Alias.Value
+			}
+			plan.result = append(plan.result, models.Column{Name: name})
+			plan.names = append(plan.names, name)
+			continue
+		}
+
+		if exp.Kind == ast.AggregateKind {
+			i, err := plan.addAggregate(allColumns, exp.Aggregate)
+			if err != nil {
+				return nil, err
+			}
+			value, name, outputName = i, exp.Aggregate.Function.Value, exp.Aggregate.Function.Value
+		} else {
+			i, err := plan.groupColumn(allColumns, exp.Literal.Value)
+			if err != nil {
+				return nil, err
+			}
+			value, name, outputName = i, exp.Literal.Value, scope.refs[plan.groupBy[i]].name
+		}
+		if exp.Alias != nil {
+			name, outputName = exp.Alias.Value, exp.Alias.Value
+		}
+
+		plan.outputs = append(plan.outputs, value)
+		plan.scalars = append(plan.scalars, nil)
+		plan.result = append(plan.result, models.Column{Name: name, Type: plan.columns[value].Type})
+		plan.names = append(plan.names, outputName)
+	}
+
+	if err := plan.addHaving(allColumns, statement.Having); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// addAggregate добавляет функцию в строку группы, если ее там еще нет, и возвращает номер ее значения
+func (plan *groupPlan) addAggregate(allColumns []models.Column, call *ast.AggregateCall) (int, error) {
+	name := call.String()
+	for i := len(plan.groupBy); i < len(plan.columns); i++ {
+		if plan.columns[i].Name == name {
+			return i, nil
+		}
+	}
+
+	spec, err := newAggregateSpec(allColumns, call)
+	if err != nil {
+		return 0, err
+	}
+	plan.aggregates = append(plan.aggregates, spec)
+	plan.columns = append(plan.columns, models.Column{Name: name, Type: spec.resultType})
+
+	return len(plan.columns) - 1, nil
+}
+
+// groupColumn возвращает номер значения колонки GROUP BY в строке группы. Другие колонки таблицы
+// в группе не определены, и их можно использовать только внутри агрегатных функций
+func (plan *groupPlan) groupColumn(allColumns []models.Column, name string) (int, error) {
+	for i := range plan.groupBy {
+		if plan.columns[i].Name == name {
+			return i, nil
+		}
+	}
+
+	if findColumnIndex(allColumns, name) == -1 {
+		return 0, fmt.Errorf("column not found: %s", name)
+	}
+
+	return 0, fmt.Errorf("column %s must appear in the GROUP BY clause or be used in an aggregate function", name)
+}
+
+// addHaving добавляет в строку группы функции из HAVING и проверяет, что остальные колонки условия есть в GROUP BY
+func (plan *groupPlan) addHaving(allColumns []models.Column, condition *ast.WhereClause) error {
+	if condition == nil {
+		return nil
+	}
+
+	if condition.Aggregate != nil {
+		_, err := plan.addAggregate(allColumns, condition.Aggregate)
+		return err
+	}
+	if condition.Token.Kind == lex.IdentifierToken {
+		_, err := plan.groupColumn(allColumns, condition.Token.Value)
+		return err
+	}
+
+	if err := plan.addHaving(allColumns, condition.Left); err != nil {
+		return err
+	}
+
+	return plan.addHaving(allColumns, condition.Right)
+}
+
+// selectGroups выполняет SELECT с группировкой: подходящие под WHERE строки собираются в группы
+// по GROUP BY, группы отбираются по HAVING, а ORDER BY, LIMIT и OFFSET применяются к результату.
+// Ключи ORDER BY - колонки результата по имени или AS
+func (mb *memoryBackend) selectGroups(tx storage.Transaction, statement *ast.SelectStatement, source *selectSource, sq *subqueries) ([]models.Column, [][]interface{}, error) {
+	allColumns := source.scope.columns
+	plan, err := newGroupPlan(source.scope, statement)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys, err := orderKeys(plan.result, statement.OrderBy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	it, _, err := mb.sourceRows(tx, source, statement.Where, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups := newHashAggregate(plan.groupBy, plan.aggregates, mb.workMemory, 0)
+	defer groups.Close()
+	for it.Next() {
+		row := it.Row()
+		ok, err := sq.filterRow(allColumns, row, statement.Where)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		if err := groups.Add(row); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var sorter *external_sort.Sorter[[]interface{}]
+	if len(keys) > 0 {
+		sorter = external_sort.NewSorter(compareRows(keys), rowSize, mb.workMemory, "")
+		defer sorter.Close()
+	}
+
+	rows := [][]interface{}{}
+	err = groups.Groups(func(group []interface{}) error {
+		ok, err := sq.filterRow(plan.columns, group, statement.Having)
+		if err != nil || !ok {
+			return err
+		}
+
+		row := make([]interface{}, len(plan.outputs))
+		for i, value := range plan.outputs {
+			if value == -1 {
+				scalar, _, err := sq.scalar(plan.scalars[i], plan.columns, group)
+				if err != nil {
+					return err
+				}
+				row[i] = scalar
+				continue
+			}
+			row[i] = group[value]
+		}
+
+		if sorter != nil {
+			return sorter.Add(row)
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if sorter != nil {
+		sorted, err := sorter.Sort()
+		if err != nil {
+			return nil, nil, err
+		}
+		for sorted.Next() {
+			rows = append(rows, sorted.Item())
+		}
+		if err := sorted.Err(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	rows = rows[min(statement.Offset, len(rows)):]
+	if statement.Limit > 0 && len(rows) > statement.Limit {
+		rows = rows[:statement.Limit]
+	}
+
+	columns := make([]models.Column, len(plan.result))
+	for i, column := range plan.result {
+		columns[i] = models.Column{Name: plan.names[i], Type: column.Type}
+	}
+	if err := sq.fillColumns(columns, statement.SelectedColumns); err != nil {
+		return nil, nil, err
+	}
+
+	return columns, rows, nil
+}
@@ -0,0 +1,352 @@
+package backend
+
+import (
+	"custom-database/internal/models"
+	"custom-database/internal/parser/ast"
+	"custom-database/internal/parser/lex"
+	"custom-database/internal/storage"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// alterTable выполняет одно изменение ALTER TABLE. Новая схема собирается из текущей и передается
+// движку целиком: он сам решает, переписывать ли строки
+func (mb *memoryBackend) alterTable(tx storage.Transaction, statement *ast.AlterTableStatement) error {
+	tableName := statement.Table.Value
+
+	columns, err := tx.GetTableColumns(tableName)
+	if err != nil {
+		return err
+	}
+	checks, err := tx.GetTableChecks(tableName)
+	if err != nil {
+		return err
+	}
+
+	alteration := storage.Alteration{Name: tableName, Checks: checks}
+	for i, column := range columns {
+		alteration.Columns = append(alteration.Columns, storage.AlteredColumn{Column: column, Source: i})
+	}
+
+	if statement.Action == ast.AddColumnAction {
+		return mb.addColumn(tx, statement, columns, alteration)
+	}
+	if statement.Action == ast.RenameTableAction {
+		alteration.Name = statement.NewName.Value
+		return tx.AlterTable(tableName, alteration)
+	}
+
+	columnName := statement.ColumnName.Value
+	i := findColumnIndex(columns, columnName)
+	if i == -1 {
+		return fmt.Errorf("column %s of relation %s does not exist", columnName, tableName)
+	}
+
+	switch statement.Action {
+	case ast.DropColumnAction:
+		if len(columns) == 1 {
+			return fmt.Errorf("cannot drop the last column of table %s", tableName)
+		}
+		if err := checkColumnDependencies(tx, tableName, columnName, checks, "drop column"); err != nil {
+			return err
+		}
+
+		alteration.Columns = append(alteration.Columns[:i], alteration.Columns[i+1:]...)
+	case ast.RenameColumnAction:
+		newName := statement.NewName.Value
+		if findColumnIndex(columns, newName) != -1 {
+			return fmt.Errorf("column %s of relation %s already exists", newName, tableName)
+		}
+
+		alteration.Columns[i].Name = newName
+		alteration.Checks = make([]models.Check, len(checks))
+		for j, check := range checks {
+			expression, err := ast.RenameConditionColumn(check.Expression, columnName, newName)
+			if err != nil {
+				return fmt.Errorf("check constraint %s: %w", check.Name, err)
+			}
+			alteration.Checks[j] = models.Check{Name: check.Name, Expression: expression}
+		}
+	case ast.AlterColumnTypeAction:
+		to, err := columnType(statement.Datatype)
+		if err != nil {
+			return err
+		}
+
+		column := columns[i]
+		if column.Type == to {
+			return nil
+		}
+		if !canConvert(column.Type, to) {
+			return fmt.Errorf("column %s cannot be cast automatically from %s to type %s",
+				columnName, columnTypeName(column.Type), columnTypeName(to))
+		}
+		if err := checkColumnDependencies(tx, tableName, columnName, checks, "alter type of column"); err != nil {
+			return err
+		}
+
+		column.Type = to
+		column.Default, err = convertDefault(columns[i], to)
+		if err != nil {
+			return fmt.Errorf("default for column %s cannot be cast automatically to type %s", columnName, columnTypeName(to))
+		}
+
+		alteration.Columns[i].Column = column
+		alteration.Columns[i].Convert = func(value interface{}) (interface{}, error) {
+			return convertValue(value, to)
+		}
+	}
+
+	return tx.AlterTable(tableName, alteration)
+}
+
+// addColumn добавляет колонку. Уже записанные строки получают значение DEFAULT колонки или NULL,
+// а ограничения новой колонки проверяются на них так же, как на новых строках
+func (mb *memoryBackend) addColumn(tx storage.Transaction, statement *ast.AlterTableStatement, columns []models.Column, alteration storage.Alteration) error {
+	tableName := statement.Table.Value
+	// Ограничения колонки разбираются так же, как в CREATE TABLE из одной этой колонки
+	definition := &ast.CreateTableStatement{Name: statement.Table, Cols: &[]*ast.ColumnDefinition{statement.Column}}
+
+	added, err := tableColumns(definition)
+	if err != nil {
+		return err
+	}
+	column := added[0]
+	if findColumnIndex(columns, column.Name) != -1 {
+		return fmt.Errorf("column %s of relation %s already exists", column.Name, tableName)
+	}
+
+	missing, err := defaultValue(column)
+	if err != nil {
+		return err
+	}
+	alteration.Columns = append(alteration.Columns, storage.AlteredColumn{Column: column, Source: -1, Missing: missing})
+	columns = append(columns, column)
+
+	indexes, err := constraintIndexes(definition)
+	if err != nil {
+		return err
+	}
+
+	checks, err := tableChecks(definition, columns)
+	if err != nil {
+		return err
+	}
+	for _, check := range checks {
+		for _, existing := range alteration.Checks {
+			if existing.Name == check.Name {
+				return fmt.Errorf("constraint %s for relation %s already exists", check.Name, tableName)
+			}
+		}
+	}
+	alteration.Checks = append(alteration.Checks, checks...)
+
+	if err := tx.AlterTable(tableName, alteration); err != nil {
+		return err
+	}
+
+	if column.NotNull || len(checks) > 0 {
+		if err := mb.validateRows(tx, tableName, columns); err != nil {
+			return err
+		}
+	}
+
+	existing, err := tx.GetTableIndexes(tableName)
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		for _, other := range existing {
+			if index.Primary && other.Primary {
+				return fmt.Errorf("multiple primary keys for table %s are not allowed", tableName)
+			}
+		}
+		if err := tx.CreateIndex(tableName, index); err != nil {
+			return err
+		}
+	}
+
+	foreignKeys := foreignKeyConstraints(definition)
+	for _, constraint := range foreignKeys {
+		index, err := foreignKeyIndex(tx, tableName, columns, constraint)
+		if err != nil {
+			return err
+		}
+		if err := tx.CreateIndex(tableName, index); err != nil {
+			return err
+		}
+	}
+	if len(foreignKeys) == 0 {
+		return nil
+	}
+
+	// Значение по умолчанию у всех строк одно, но ссылаться оно должно на существующую строку
+	plan := newReferentialPlan(mb, tx)
+	return scanRows(tx, tableName, func(row []interface{}) error {
+		return plan.checkInserted(tableName, row)
+	})
+}
+
+// validateRows проверяет NOT NULL и CHECK всех строк таблицы
+func (mb *memoryBackend) validateRows(tx storage.Transaction, tableName string, columns []models.Column) error {
+	checks, err := loadChecks(tx, tableName)
+	if err != nil {
+		return err
+	}
+
+	return scanRows(tx, tableName, func(row []interface{}) error {
+		return mb.checkConstraints(tableName, columns, checks, row)
+	})
+}
+
+func scanRows(tx storage.Transaction, tableName string, fn func(row []interface{}) error) error {
+	it, err := tx.Scan(tableName)
+	if err != nil {
+		return err
+	}
+
+	for it.Next() {
+		if err := fn(it.Row()); err != nil {
+			return err
+		}
+	}
+
+	return it.Err()
+}
+
+// checkColumnDependencies запрещает удалять колонку и менять ее тип, пока от нее зависят индексы,
+// ограничения таблицы или внешние ключи других таблиц
+func checkColumnDependencies(tx storage.Transaction, tableName, columnName string, checks []models.Check, action string) error {
+	dependencyError := func(dependency string) error {
+		return fmt.Errorf("cannot %s %s of table %s because %s depends on it", action, columnName, tableName, dependency)
+	}
+
+	indexes, err := tx.GetTableIndexes(tableName)
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if slices.Contains(index.Columns, columnName) {
+			return dependencyError("index " + index.Name)
+		}
+	}
+
+	references, err := tx.GetReferences(tableName)
+	if err != nil {
+		return err
+	}
+	for _, reference := range references {
+		if slices.Contains(reference.Index.ForeignKey.RefColumns, columnName) {
+			return dependencyError(fmt.Sprintf("constraint %s on table %s", reference.Index.Name, reference.Table))
+		}
+	}
+
+	for _, check := range checks {
+		condition, err := ast.ParseCondition(check.Expression)
+		if err != nil {
+			return fmt.Errorf("check constraint %s: %w", check.Name, err)
+		}
+		if conditionUsesColumn(condition, columnName) {
+			return dependencyError("check constraint " + check.Name)
+		}
+	}
+
+	return nil
+}
+
+func conditionUsesColumn(condition *ast.WhereClause, columnName string) bool {
+	if condition == nil {
+		return false
+	}
+	if condition.Token.Kind == lex.IdentifierToken && condition.Token.Value == columnName {
+		return true
+	}
+
+	return conditionUsesColumn(condition.Left, columnName) || conditionUsesColumn(condition.Right, columnName)
+}
+
+// convertDefault переводит значение DEFAULT колонки в тип to и записывает его литералом этого типа.
+// CURRENT_TIMESTAMP и NULL не переводятся: они либо подходят новому типу, либо нет
+func convertDefault(column models.Column, to models.ColumnType) (string, error) {
+	value, err := defaultValue(column)
+	if err != nil {
+		return "", err
+	}
+	if value == nil || column.Default == string(lex.CurrentTimestampKeyword) {
+		column.Type = to
+		_, err := defaultValue(column)
+		return column.Default, err
+	}
+
+	converted, err := convertValue(value, to)
+	if err != nil {
+		return "", err
+	}
+	if to == models.TextType || to == models.TimestampType {
+		return ast.FormatToken(&lex.Token{Kind: lex.StringToken, Value: converted.(string)}), nil
+	}
+
+	return fmt.Sprint(converted), nil
+}
+
+// canConvert сообщает, можно ли перевести значения колонки из типа from в тип to.
+// В TEXT переводится все, INT и BOOLEAN - друг в друга, а из TEXT - значения, записанные как литералы типа to
+func canConvert(from, to models.ColumnType) bool {
+	switch {
+	case from == to, to == models.TextType, from == models.TextType:
+		return true
+	case from == models.IntType && to == models.BoolType, from == models.BoolType && to == models.IntType:
+		return true
+	}
+
+	return false
+}
+
+// convertValue переводит значение колонки в тип to. Ошибка - значение TEXT, которое не читается как to
+func convertValue(value interface{}, to models.ColumnType) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch value := value.(type) {
+	case int32:
+		switch to {
+		case models.TextType:
+			return strconv.Itoa(int(value)), nil
+		case models.BoolType:
+			return value != 0, nil
+		}
+	case bool:
+		switch to {
+		case models.TextType:
+			return strconv.FormatBool(value), nil
+		case models.IntType:
+			if value {
+				return int32(1), nil
+			}
+			return int32(0), nil
+		}
+	case string:
+		// TIMESTAMP тоже хранится строкой, и в TEXT он переходит как есть
+		switch to {
+		case models.TextType:
+			return value, nil
+		case models.TimestampType:
+			if _, err := time.Parse(models.TimestampFormat, value); err == nil {
+				return value, nil
+			}
+		case models.IntType:
+			if number, err := strconv.ParseInt(value, 10, 32); err == nil {
+				return int32(number), nil
+			}
+		case models.BoolType:
+			if value == string(lex.TrueKeyword) || value == string(lex.FalseKeyword) {
+				return value == string(lex.TrueKeyword), nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("invalid input syntax for type %s: %q", columnTypeName(to), fmt.Sprint(value))
+}
@@ -4,61 +4,93 @@ import (
 	"custom-database/config"
 	"custom-database/internal/models"
 	"custom-database/internal/parser/ast"
+	"custom-database/internal/storage"
+	"custom-database/internal/storage/disk_storage"
 	"custom-database/internal/storage/memory_storage"
 	"custom-database/internal/storage/persistent_storage"
+	"fmt"
 )
 
 type MemoryBackendService interface {
-	ExecuteStatement(*ast.Ast) (*models.Table, error)
+	// ExecuteStatement выполняет выражения в отдельной сессии: транзакция, открытая BEGIN,
+	// должна закончиться COMMIT или ROLLBACK в том же вызове, иначе она откатывается
+	ExecuteStatement(*ast.Ast) (*models.Result, error)
+	// NewSession открывает сессию, в которой транзакция может продолжаться между вызовами
+	NewSession() Session
+	Stats() map[string]uint64
 }
 
+// defaultWorkMemory - память ORDER BY и GROUP BY в KB, если WORK_MEMORY не задан
+const defaultWorkMemory = 4096
+
+// memoryBackend не хранит общего состояния транзакций: каждая сессия держит свою транзакцию
+// движка хранения, а изоляцию параллельных сессий обеспечивает движок
 type memoryBackend struct {
-	memoryStorage     memory_storage.MemoryStorageService
-	persistentStorage persistent_storage.PersistentStorageService
+	storage storage.StorageService
+	// workMemory - память в байтах, в которой ORDER BY и GROUP BY обходятся без временных файлов
+	workMemory int
 }
 
 func NewMemoryBackend(config *config.Config) (MemoryBackendService, error) {
-	memoryStorage := memory_storage.NewMemoryStorage()
-	persistentStorage, err := persistent_storage.NewPersistentStorage(config)
+	storage, err := newStorage(config)
 	if err != nil {
 		return nil, err
 	}
 
+	workMemory := config.WorkMemory
+	if workMemory <= 0 {
+		workMemory = defaultWorkMemory
+	}
+
 	return &memoryBackend{
-		memoryStorage:     memoryStorage,
-		persistentStorage: persistentStorage,
+		storage:    storage,
+		workMemory: workMemory * 1024,
 	}, nil
 }
 
-func (mb *memoryBackend) ExecuteStatement(a *ast.Ast) (*models.Table, error) {
-	var err error
+// newStorage выбирает движок хранения по STORAGE_ENGINE
+func newStorage(config *config.Config) (storage.StorageService, error) {
+	switch config.StorageEngine {
+	case storage.DiskEngine:
+		return disk_storage.NewDiskStorage(config)
+	case storage.JsonEngine:
+		return persistent_storage.NewPersistentStorage(config)
+	case storage.MemoryEngine:
+		return memory_storage.NewMemoryStorage(), nil
+	}
+
+	return nil, fmt.Errorf("NewMemoryBackend(): unknown storage engine: %s", config.StorageEngine)
+}
+
+// Stats возвращает счетчики движка хранения. Движки без счетчиков отдают пустой набор
+func (mb *memoryBackend) Stats() map[string]uint64 {
+	if provider, ok := mb.storage.(storage.StatsProvider); ok {
+		return provider.Stats()
+	}
+
+	return map[string]uint64{}
+}
 
-	for _, stmt := range a.Statements {
-		switch stmt.Kind {
-		case ast.CreateTableKind:
-			err = mb.createTable(stmt.CreateTableStatement)
-			if err != nil {
-				return nil, err
-			}
-		case ast.DropTableKind:
-			err = mb.dropTable(stmt.DropTableStatement)
-			if err != nil {
-				return nil, err
-			}
-		case ast.InsertKind:
-			err = mb.insertIntoTable(stmt.InsertStatement)
-			if err != nil {
-				return nil, err
-			}
-		case ast.SelectKind:
-			results, err := mb.selectFromTable(stmt.SelectStatement)
-			if err != nil {
-				return nil, err
-			}
+func (mb *memoryBackend) ExecuteStatement(a *ast.Ast) (*models.Result, error) {
+	s := &session{mb: mb}
 
-			return results, nil
+	result, err := s.ExecuteStatement(a)
+	if s.tx != nil {
+		closeErr := s.Close()
+		if err == nil {
+			err = fmt.Errorf("transaction is not committed, changes are rolled back")
 		}
+		if closeErr != nil {
+			err = fmt.Errorf("%w; %w", err, closeErr)
+		}
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, nil
+	return result, nil
+}
+
+func (mb *memoryBackend) NewSession() Session {
+	return &session{mb: mb}
 }
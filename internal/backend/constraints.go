@@ -0,0 +1,131 @@
+package backend
+
+import (
+	"custom-database/internal/models"
+	"custom-database/internal/parser/ast"
+	"custom-database/internal/parser/lex"
+	"custom-database/internal/storage"
+	"fmt"
+	"time"
+)
+
+// tableCheck - ограничение CHECK с уже разобранным условием
+type tableCheck struct {
+	name      string
+	condition *ast.WhereClause
+}
+
+// loadChecks читает ограничения CHECK таблицы и разбирает их условия один раз на выражение
+func loadChecks(tx storage.Transaction, tableName string) ([]tableCheck, error) {
+	checks, err := tx.GetTableChecks(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]tableCheck, len(checks))
+	for i, check := range checks {
+		condition, err := ast.ParseCondition(check.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("check constraint %s: %w", check.Name, err)
+		}
+		result[i] = tableCheck{name: check.Name, condition: condition}
+	}
+
+	return result, nil
+}
+
+// checkConstraints проверяет NOT NULL и CHECK для новой версии строки values.
+// Уникальность проверяет движок хранения при записи
+func (mb *memoryBackend) checkConstraints(tableName string, columns []models.Column, checks []tableCheck, values []interface{}) error {
+	for i, column := range columns {
+		if column.NotNull && values[i] == nil {
+			return &storage.ConstraintError{
+				Message: fmt.Sprintf("null value in column %q of relation %q violates not-null constraint", column.Name, tableName),
+			}
+		}
+	}
+
+	for _, check := range checks {
+		result, known := mb.checkCondition(columns, values, check.condition)
+		// Как в SQL, строка нарушает CHECK, только если условие ложно: с NULL оно неизвестно и строка проходит
+		if known && !result {
+			return &storage.ConstraintError{
+				Constraint: check.name,
+				Message:    fmt.Sprintf("new row for relation %q violates check constraint %q", tableName, check.name),
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkCondition вычисляет условие CHECK в трехзначной логике. known = false - значение неизвестно (NULL)
+func (mb *memoryBackend) checkCondition(columns []models.Column, row []interface{}, condition *ast.WhereClause) (bool, bool) {
+	if condition.Token.Kind == lex.LogicalOperatorToken {
+		left, leftKnown := mb.checkCondition(columns, row, condition.Left)
+		right, rightKnown := mb.checkCondition(columns, row, condition.Right)
+
+		if condition.Token.Value == string(lex.AndOperator) {
+			if (leftKnown && !left) || (rightKnown && !right) {
+				return false, true
+			}
+			return true, leftKnown && rightKnown
+		}
+
+		if (leftKnown && left) || (rightKnown && right) {
+			return true, true
+		}
+		return false, leftKnown && rightKnown
+	}
+
+	left, err := mb.getValueFromExpression(columns, row, condition.Left)
+	if err != nil {
+		return false, true
+	}
+	right, err := mb.getValueFromExpression(columns, row, condition.Right)
+	if err != nil {
+		return false, true
+	}
+	if left == nil || right == nil || condition.Left.Token.Kind == lex.NullToken || condition.Right.Token.Kind == lex.NullToken {
+		return false, false
+	}
+
+	return mb.evaluateCondition(left, right, condition.Token.Value), true
+}
+
+// checkColumns проверяет, что условие CHECK ссылается только на колонки таблицы
+func checkColumns(columns []models.Column, condition *ast.WhereClause) error {
+	if condition == nil {
+		return nil
+	}
+	if condition.Token.Kind == lex.IdentifierToken && findColumnIndex(columns, condition.Token.Value) == -1 {
+		return fmt.Errorf("column not found: %s", condition.Token.Value)
+	}
+
+	if err := checkColumns(columns, condition.Left); err != nil {
+		return err
+	}
+	return checkColumns(columns, condition.Right)
+}
+
+// defaultValue возвращает значение DEFAULT колонки или NULL, если оно не задано.
+// CURRENT_TIMESTAMP вычисляется при каждой записи
+func defaultValue(column models.Column) (interface{}, error) {
+	if column.Default == "" {
+		return nil, nil
+	}
+	if column.Default == string(lex.CurrentTimestampKeyword) {
+		if column.Type != models.TimestampType {
+			return nil, fmt.Errorf("current_timestamp cannot be the default of column %s of type %s",
+				column.Name, columnTypeName(column.Type))
+		}
+		return time.Now().Format(models.TimestampFormat), nil
+	}
+
+	tokens, err := lex.NewLexer().Lex(column.Default)
+	if err != nil || len(tokens) != 1 {
+		return nil, fmt.Errorf("invalid default %q for column %s", column.Default, column.Name)
+	}
+
+	return tokenToValue(column, tokens[0])
+}
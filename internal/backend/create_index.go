@@ -0,0 +1,15 @@
+package backend
+
+import (
+	"custom-database/internal/parser/ast"
+	"custom-database/internal/storage"
+)
+
+func (mb *memoryBackend) createIndex(tx storage.Transaction, statement *ast.CreateIndexStatement) error {
+	columnNames := make([]string, len(statement.Columns))
+	for i, column := range statement.Columns {
+		columnNames[i] = column.Value
+	}
+
+	return tx.CreateIndex(statement.Table.Value, storage.Index{Name: statement.Name.Value, Columns: columnNames})
+}
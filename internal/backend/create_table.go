@@ -4,42 +4,347 @@ import (
 	"custom-database/internal/models"
 	"custom-database/internal/parser/ast"
 	"custom-database/internal/parser/lex"
+	"custom-database/internal/storage"
 	"fmt"
+	"strings"
 )
 
-func (mb *memoryBackend) createTable(statement *ast.CreateTableStatement) error {
+func (mb *memoryBackend) createTable(tx storage.Transaction, statement *ast.CreateTableStatement) error {
+	if statement.AsSelect != nil {
+		return mb.createTableAs(tx, statement)
+	}
 	if statement.Cols == nil {
 		return nil
 	}
 
+	columns, err := tableColumns(statement)
+	if err != nil {
+		return err
+	}
+
+	indexes, err := constraintIndexes(statement)
+	if err != nil {
+		return err
+	}
+
+	checks, err := tableChecks(statement, columns)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.CreateTable(statement.Name.Value, columns, checks); err != nil {
+		return err
+	}
+
+	// Индексы ограничений создаются в той же транзакции, что и таблица
+	for _, index := range indexes {
+		if err := tx.CreateIndex(statement.Name.Value, index); err != nil {
+			return err
+		}
+	}
+
+	// Внешние ключи создаются последними: таблица может ссылаться на свой же первичный ключ
+	for _, constraint := range foreignKeyConstraints(statement) {
+		index, err := foreignKeyIndex(tx, statement.Name.Value, columns, constraint)
+		if err != nil {
+			return err
+		}
+		if err := tx.CreateIndex(statement.Name.Value, index); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createTableAs создает таблицу из CREATE TABLE ... AS SELECT с колонками результата SELECT
+// и записывает в нее его строки. Ограничения колонок исходной таблицы не переносятся
+func (mb *memoryBackend) createTableAs(tx storage.Transaction, statement *ast.CreateTableStatement) error {
+	columns, rows, err := mb.selectRows(tx, statement.AsSelect, nil)
+	if err != nil {
+		return err
+	}
+
+	for i, column := range columns {
+		if findColumnIndex(columns[:i], column.Name) != -1 {
+			return fmt.Errorf("column %s specified more than once", column.Name)
+		}
+	}
+
+	if err := tx.CreateTable(statement.Name.Value, columns, nil); err != nil {
+		return err
+	}
+
+	_, err = mb.insertRows(tx, statement.Name.Value, columns, rows)
+	return err
+}
+
+// tableColumns переводит колонки CREATE TABLE в models.Column с NOT NULL и DEFAULT
+func tableColumns(statement *ast.CreateTableStatement) ([]models.Column, error) {
 	columns := []models.Column{}
 	for _, col := range *statement.Cols {
-		var dt models.ColumnType
-
-		switch col.Datatype.Value {
-		case string(lex.IntKeyword):
-			dt = models.IntType
-		case string(lex.TextKeyword):
-			dt = models.TextType
-		case string(lex.BooleanTypeKeyword):
-			dt = models.BoolType
-		case string(lex.TimestampTypeKeyword):
-			dt = models.TimestampType
-		default:
-			return fmt.Errorf("Invalid datatype: %s", col.Datatype.Value)
+		dt, err := columnType(col.Datatype)
+		if err != nil {
+			return nil, err
 		}
 
-		columns = append(columns, models.Column{
+		column := models.Column{
 			Name: col.Name.Value,
 			Type: dt,
-		})
+		}
+		for _, constraint := range col.Constraints {
+			switch constraint.Kind {
+			case ast.NotNullConstraint:
+				column.NotNull = true
+			case ast.DefaultConstraint:
+				column.Default = ast.FormatToken(constraint.Default)
+			}
+		}
+
+		// Значение DEFAULT проверяется сразу, а не при первой вставке
+		if _, err := defaultValue(column); err != nil {
+			return nil, err
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+func columnType(datatype lex.Token) (models.ColumnType, error) {
+	switch datatype.Value {
+	case string(lex.IntKeyword):
+		return models.IntType, nil
+	case string(lex.TextKeyword):
+		return models.TextType, nil
+	case string(lex.BooleanTypeKeyword):
+		return models.BoolType, nil
+	case string(lex.TimestampTypeKeyword):
+		return models.TimestampType, nil
 	}
 
-	err := mb.persistentStorage.CreateTable(statement.Name.Value, columns)
+	return 0, fmt.Errorf("Invalid datatype: %s", datatype.Value)
+}
+
+// foreignKeyConstraints собирает внешние ключи колонок и таблицы. У внешнего ключа колонки
+// Columns заполняется самой колонкой
+func foreignKeyConstraints(statement *ast.CreateTableStatement) []*ast.ConstraintDefinition {
+	constraints := []*ast.ConstraintDefinition{}
+	for _, col := range *statement.Cols {
+		for _, constraint := range col.Constraints {
+			if constraint.Kind == ast.ForeignKeyConstraint {
+				columnConstraint := *constraint
+				columnConstraint.Columns = []lex.Token{col.Name}
+				constraints = append(constraints, &columnConstraint)
+			}
+		}
+	}
+	for _, constraint := range statement.Constraints {
+		if constraint.Kind == ast.ForeignKeyConstraint {
+			constraints = append(constraints, constraint)
+		}
+	}
+
+	return constraints
+}
+
+// foreignKeyIndex проверяет внешний ключ и описывает индекс, через который ищутся ссылающиеся строки.
+// Внешний ключ без списка колонок ссылается на первичный ключ таблицы, а на указанные колонки
+// у таблицы должен быть уникальный индекс. Ограничение без имени называется <table>_<columns>_fkey
+func foreignKeyIndex(tx storage.Transaction, tableName string, columns []models.Column, constraint *ast.ConstraintDefinition) (storage.Index, error) {
+	refTable := constraint.References.Value
+
+	columnNames := make([]string, len(constraint.Columns))
+	for i, column := range constraint.Columns {
+		columnNames[i] = column.Value
+	}
+	positions, err := storage.IndexColumns(columns, columnNames)
 	if err != nil {
-		return err
+		return storage.Index{}, err
 	}
 
-	// return mb.memoryStorage.CreateTable(statement.Name.Value, columns)
-	return nil
+	refColumns, err := tx.GetTableColumns(refTable)
+	if err != nil {
+		return storage.Index{}, err
+	}
+	refIndexes, err := tx.GetTableIndexes(refTable)
+	if err != nil {
+		return storage.Index{}, err
+	}
+
+	refColumnNames := make([]string, len(constraint.ReferencedColumns))
+	for i, column := range constraint.ReferencedColumns {
+		refColumnNames[i] = column.Value
+	}
+	if len(refColumnNames) == 0 {
+		for _, index := range refIndexes {
+			if index.Primary {
+				refColumnNames = index.Columns
+			}
+		}
+		if len(refColumnNames) == 0 {
+			return storage.Index{}, fmt.Errorf("there is no primary key for referenced table %s", refTable)
+		}
+	}
+
+	if len(refColumnNames) != len(columnNames) {
+		return storage.Index{}, fmt.Errorf("number of referencing and referenced columns for foreign key disagree")
+	}
+	refPositions, err := storage.IndexColumns(refColumns, refColumnNames)
+	if err != nil {
+		return storage.Index{}, err
+	}
+	for i := range positions {
+		column, refColumn := columns[positions[i]], refColumns[refPositions[i]]
+		if column.Type != refColumn.Type {
+			return storage.Index{}, fmt.Errorf("foreign key columns %s and %s are of incompatible types: %s and %s",
+				column.Name, refColumn.Name, columnTypeName(column.Type), columnTypeName(refColumn.Type))
+		}
+	}
+	if refIndex, _ := matchingUniqueIndex(refIndexes, refColumnNames); refIndex == nil {
+		return storage.Index{}, fmt.Errorf("there is no unique constraint matching given keys for referenced table %s", refTable)
+	}
+
+	index := storage.Index{
+		Name:    tableName + "_" + strings.Join(columnNames, "_") + "_fkey",
+		Columns: columnNames,
+		ForeignKey: &storage.ForeignKey{
+			RefTable:   refTable,
+			RefColumns: refColumnNames,
+			OnDelete:   referentialAction(constraint.OnDelete),
+			OnUpdate:   referentialAction(constraint.OnUpdate),
+		},
+	}
+	if constraint.Name != nil {
+		index.Name = constraint.Name.Value
+	}
+
+	return index, nil
+}
+
+func referentialAction(action ast.ReferentialAction) storage.ReferentialAction {
+	switch action {
+	case ast.CascadeAction:
+		return storage.CascadeAction
+	case ast.SetNullAction:
+		return storage.SetNullAction
+	}
+
+	return storage.RestrictAction
+}
+
+// constraintIndexes собирает ограничения PRIMARY KEY и UNIQUE колонок и таблицы в уникальные индексы.
+// Ограничение без имени называется как в PostgreSQL: <table>_pkey или <table>_<columns>_key
+func constraintIndexes(statement *ast.CreateTableStatement) ([]storage.Index, error) {
+	constraints := []*ast.ConstraintDefinition{}
+	for _, col := range *statement.Cols {
+		for _, constraint := range col.Constraints {
+			constraints = append(constraints, &ast.ConstraintDefinition{
+				Name:    constraint.Name,
+				Kind:    constraint.Kind,
+				Columns: []lex.Token{col.Name},
+			})
+		}
+	}
+	constraints = append(constraints, statement.Constraints...)
+
+	indexes := []storage.Index{}
+	hasPrimaryKey := false
+	for _, constraint := range constraints {
+		if constraint.Kind != ast.PrimaryKeyConstraint && constraint.Kind != ast.UniqueConstraint {
+			continue
+		}
+
+		columnNames := make([]string, len(constraint.Columns))
+		for i, column := range constraint.Columns {
+			columnNames[i] = column.Value
+		}
+
+		index := storage.Index{
+			Columns: columnNames,
+			Unique:  true,
+			Primary: constraint.Kind == ast.PrimaryKeyConstraint,
+		}
+
+		if index.Primary {
+			if hasPrimaryKey {
+				return nil, fmt.Errorf("multiple primary keys for table %s are not allowed", statement.Name.Value)
+			}
+			hasPrimaryKey = true
+		}
+
+		switch {
+		case constraint.Name != nil:
+			index.Name = constraint.Name.Value
+		case index.Primary:
+			index.Name = statement.Name.Value + "_pkey"
+		default:
+			index.Name = statement.Name.Value + "_" + strings.Join(columnNames, "_") + "_key"
+		}
+
+		indexes = append(indexes, index)
+	}
+
+	return indexes, nil
+}
+
+// tableChecks собирает ограничения CHECK колонок и таблицы. Ограничение без имени называется
+// как в PostgreSQL: <table>_<column>_check для колонки и <table>_check для таблицы, а при повторе
+// к имени добавляется номер
+func tableChecks(statement *ast.CreateTableStatement, columns []models.Column) ([]models.Check, error) {
+	type namedCheck struct {
+		constraint  *ast.ConstraintDefinition
+		defaultName string
+	}
+
+	tableName := statement.Name.Value
+	candidates := []namedCheck{}
+	for _, col := range *statement.Cols {
+		for _, constraint := range col.Constraints {
+			if constraint.Kind == ast.CheckConstraint {
+				candidates = append(candidates, namedCheck{constraint, tableName + "_" + col.Name.Value + "_check"})
+			}
+		}
+	}
+	for _, constraint := range statement.Constraints {
+		if constraint.Kind == ast.CheckConstraint {
+			candidates = append(candidates, namedCheck{constraint, tableName + "_check"})
+		}
+	}
+
+	names := map[string]bool{}
+	for _, candidate := range candidates {
+		if candidate.constraint.Name == nil {
+			continue
+		}
+
+		name := candidate.constraint.Name.Value
+		if names[name] {
+			return nil, fmt.Errorf("constraint %s for table %s already exists", name, tableName)
+		}
+		names[name] = true
+	}
+
+	checks := []models.Check{}
+	for _, candidate := range candidates {
+		if err := checkColumns(columns, candidate.constraint.Check); err != nil {
+			return nil, err
+		}
+
+		var name string
+		if candidate.constraint.Name != nil {
+			name = candidate.constraint.Name.Value
+		} else {
+			name = candidate.defaultName
+			for i := 1; names[name]; i++ {
+				name = fmt.Sprintf("%s%d", candidate.defaultName, i)
+			}
+			names[name] = true
+		}
+
+		checks = append(checks, models.Check{Name: name, Expression: candidate.constraint.CheckText})
+	}
+
+	return checks, nil
 }
@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"custom-database/internal/parser/ast"
+	"custom-database/internal/storage"
+)
+
+// deleteFromTable удаляет строки, подходящие под WHERE, и возвращает их количество.
+// Сначала собираются все строки, потом они удаляются вместе с тем, что следует из внешних ключей,
+// чтобы не менять таблицу во время сканирования. Строки, удаленные по ON DELETE CASCADE, в количество не входят
+func (mb *memoryBackend) deleteFromTable(tx storage.Transaction, statement *ast.DeleteStatement) (int, error) {
+	columns, err := tx.GetTableColumns(statement.Table.Value)
+	if err != nil {
+		return 0, err
+	}
+
+	it, _, err := mb.scanTable(tx, statement.Table.Value, columns, statement.Where, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	sq := mb.newSubqueries(tx, newTableScope(&fromTable{table: statement.Table.Value, name: statement.Table.Value, columns: columns}, false))
+	plan := newReferentialPlan(mb, tx)
+	count := 0
+	for it.Next() {
+		ok, err := sq.filterRow(columns, it.Row(), statement.Where)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			plan.add(statement.Table.Value, it.RowID(), it.Row(), nil)
+			count++
+		}
+	}
+	if err := it.Err(); err != nil {
+		return 0, err
+	}
+
+	if count == 0 {
+		return 0, nil
+	}
+
+	err = plan.execute()
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,10 @@
+package backend
+
+import (
+	"custom-database/internal/parser/ast"
+	"custom-database/internal/storage"
+)
+
+func (mb *memoryBackend) dropIndex(tx storage.Transaction, statement *ast.DropIndexStatement) error {
+	return tx.DropIndex(statement.Name.Value)
+}
@@ -1,13 +1,35 @@
 package backend
 
-import "custom-database/internal/parser/ast"
+import (
+	"custom-database/internal/parser/ast"
+	"custom-database/internal/storage"
+	"fmt"
+)
 
-func (mb *memoryBackend) dropTable(statement *ast.DropTableStatement) error {
-	err := mb.persistentStorage.DropTable(statement.Table.Value)
+// dropTable удаляет таблицу. Если на нее ссылаются внешние ключи других таблиц, без CASCADE
+// удаление запрещено, а с CASCADE эти внешние ключи удаляются вместе с таблицей
+func (mb *memoryBackend) dropTable(tx storage.Transaction, statement *ast.DropTableStatement) error {
+	tableName := statement.Table.Value
+
+	references, err := tx.GetReferences(tableName)
 	if err != nil {
 		return err
 	}
 
-	return nil
-	// return mb.memoryStorage.DropTable(statement.Table.Value)
+	for _, reference := range references {
+		// Внешние ключи самой таблицы удаляются вместе с ней
+		if reference.Table == tableName {
+			continue
+		}
+
+		if !statement.Cascade {
+			return fmt.Errorf("cannot drop table %s because other objects depend on it: constraint %s on table %s",
+				tableName, reference.Index.Name, reference.Table)
+		}
+		if err := tx.DropForeignKey(reference.Index.Name); err != nil {
+			return err
+		}
+	}
+
+	return tx.DropTable(tableName)
 }
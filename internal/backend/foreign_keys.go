@@ -0,0 +1,488 @@
+package backend
+
+import (
+	"custom-database/internal/models"
+	"custom-database/internal/storage"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// foreignKey - внешний ключ таблицы table на таблицу refTable с номерами колонок в строках обеих таблиц
+type foreignKey struct {
+	name           string
+	table          string
+	columns        []int
+	columnNames    []string
+	refTable       string
+	refColumns     []int
+	refColumnNames []string
+	// refIndex - уникальный индекс refTable по колонкам ключа, refIndexOrder[i] - номер колонки
+	// внешнего ключа, которая стоит в refIndex на месте i
+	refIndex      string
+	refIndexOrder []int
+	onDelete      storage.ReferentialAction
+	onUpdate      storage.ReferentialAction
+}
+
+// referencedTable - то, что нужно знать о таблице для проверки ограничений ее строк
+type referencedTable struct {
+	columns []models.Column
+	checks  []tableCheck
+	// foreignKeys - внешние ключи самой таблицы, references - внешние ключи других таблиц, которые ссылаются на нее
+	foreignKeys []*foreignKey
+	references  []*foreignKey
+}
+
+// rowChange - запланированное изменение строки: новые значения values или удаление, если values = nil
+type rowChange struct {
+	table  string
+	rowID  storage.RowID
+	old    []interface{}
+	values []interface{}
+}
+
+// referenceCheck - ключ строки, которую удалили или изменили при RESTRICT: после записи
+// на него не должны ссылаться строки дочерней таблицы
+type referenceCheck struct {
+	fk  *foreignKey
+	key []interface{}
+}
+
+// referentialPlan собирает изменения строк одного запроса вместе с тем, что из них следует
+// по внешним ключам (CASCADE и SET NULL), и записывает их. Все ссылки проверяются после записи,
+// поэтому запрос может, например, удалить сразу всю цепочку строк, ссылающихся друг на друга
+type referentialPlan struct {
+	mb      *memoryBackend
+	tx      storage.Transaction
+	tables  map[string]*referencedTable
+	changes []*rowChange
+	rows    map[string]map[storage.RowID]*rowChange
+	// queue - изменения, следствия которых по внешним ключам еще не разобраны
+	queue  []*rowChange
+	checks []referenceCheck
+}
+
+func newReferentialPlan(mb *memoryBackend, tx storage.Transaction) *referentialPlan {
+	return &referentialPlan{
+		mb:     mb,
+		tx:     tx,
+		tables: map[string]*referencedTable{},
+		rows:   map[string]map[storage.RowID]*rowChange{},
+	}
+}
+
+// table читает колонки, CHECK и внешние ключи таблицы один раз на запрос
+func (p *referentialPlan) table(tableName string) (*referencedTable, error) {
+	if table, ok := p.tables[tableName]; ok {
+		return table, nil
+	}
+
+	columns, err := p.tx.GetTableColumns(tableName)
+	if err != nil {
+		return nil, err
+	}
+	checks, err := loadChecks(p.tx, tableName)
+	if err != nil {
+		return nil, err
+	}
+	table := &referencedTable{columns: columns, checks: checks}
+	p.tables[tableName] = table
+
+	indexes, err := p.tx.GetTableIndexes(tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range indexes {
+		if index.ForeignKey == nil {
+			continue
+		}
+
+		fk, err := p.loadForeignKey(tableName, columns, index)
+		if err != nil {
+			return nil, err
+		}
+		table.foreignKeys = append(table.foreignKeys, fk)
+	}
+
+	references, err := p.tx.GetReferences(tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, reference := range references {
+		childColumns := columns
+		if reference.Table != tableName {
+			childColumns, err = p.tx.GetTableColumns(reference.Table)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		fk, err := p.loadForeignKey(reference.Table, childColumns, reference.Index)
+		if err != nil {
+			return nil, err
+		}
+		table.references = append(table.references, fk)
+	}
+
+	return table, nil
+}
+
+// loadForeignKey находит колонки внешнего ключа index таблицы tableName и уникальный индекс, на который он ссылается
+func (p *referentialPlan) loadForeignKey(tableName string, columns []models.Column, index storage.Index) (*foreignKey, error) {
+	fk := &foreignKey{
+		name:           index.Name,
+		table:          tableName,
+		columnNames:    index.Columns,
+		refTable:       index.ForeignKey.RefTable,
+		refColumnNames: index.ForeignKey.RefColumns,
+		onDelete:       index.ForeignKey.OnDelete,
+		onUpdate:       index.ForeignKey.OnUpdate,
+	}
+
+	var err error
+	fk.columns, err = storage.IndexColumns(columns, fk.columnNames)
+	if err != nil {
+		return nil, err
+	}
+
+	refColumns, err := p.tx.GetTableColumns(fk.refTable)
+	if err != nil {
+		return nil, err
+	}
+	fk.refColumns, err = storage.IndexColumns(refColumns, fk.refColumnNames)
+	if err != nil {
+		return nil, err
+	}
+
+	refIndexes, err := p.tx.GetTableIndexes(fk.refTable)
+	if err != nil {
+		return nil, err
+	}
+	refIndex, order := matchingUniqueIndex(refIndexes, fk.refColumnNames)
+	if refIndex == nil {
+		return nil, fmt.Errorf("there is no unique constraint matching given keys for referenced table %s", fk.refTable)
+	}
+	fk.refIndex, fk.refIndexOrder = refIndex.Name, order
+
+	return fk, nil
+}
+
+// matchingUniqueIndex ищет уникальный индекс ровно по колонкам columnNames в любом порядке
+// и возвращает его вместе с номерами колонок columnNames в порядке колонок индекса
+func matchingUniqueIndex(indexes []storage.Index, columnNames []string) (*storage.Index, []int) {
+	for i, index := range indexes {
+		if !index.Unique || len(index.Columns) != len(columnNames) {
+			continue
+		}
+
+		order := make([]int, len(index.Columns))
+		for j, column := range index.Columns {
+			order[j] = slices.Index(columnNames, column)
+			if order[j] == -1 {
+				order = nil
+				break
+			}
+		}
+		if order != nil {
+			return &indexes[i], order
+		}
+	}
+
+	return nil, nil
+}
+
+// checkInserted проверяет ссылки вставленной строки values. Проверка идет после записи, чтобы строка
+// могла ссылаться сама на себя
+func (p *referentialPlan) checkInserted(tableName string, values []interface{}) error {
+	table, err := p.table(tableName)
+	if err != nil {
+		return err
+	}
+
+	for _, fk := range table.foreignKeys {
+		if err := p.checkParent(fk, values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// add планирует изменение строки rowID таблицы tableName: новые значения values или удаление при values = nil
+func (p *referentialPlan) add(tableName string, rowID storage.RowID, old, values []interface{}) {
+	change := &rowChange{table: tableName, rowID: rowID, old: append([]interface{}{}, old...), values: values}
+	if p.rows[tableName] == nil {
+		p.rows[tableName] = map[storage.RowID]*rowChange{}
+	}
+	p.rows[tableName][rowID] = change
+
+	p.changes = append(p.changes, change)
+	p.queue = append(p.queue, change)
+}
+
+// execute разбирает следствия запланированных изменений по внешним ключам, записывает все изменения
+// и проверяет ограничения измененных строк и ссылки на них
+func (p *referentialPlan) execute() error {
+	for len(p.queue) > 0 {
+		change := p.queue[0]
+		p.queue = p.queue[1:]
+
+		if err := p.propagate(change); err != nil {
+			return err
+		}
+	}
+
+	// Изменения пишутся раньше удалений: в движках со срезом строк удаление сдвигает номера строк таблицы
+	deleted := map[string][]storage.RowID{}
+	deletedTables := []string{}
+	for _, change := range p.changes {
+		if change.values == nil {
+			if deleted[change.table] == nil {
+				deletedTables = append(deletedTables, change.table)
+			}
+			deleted[change.table] = append(deleted[change.table], change.rowID)
+			continue
+		}
+
+		table, err := p.table(change.table)
+		if err != nil {
+			return err
+		}
+		if err := p.mb.checkConstraints(change.table, table.columns, table.checks, change.values); err != nil {
+			return err
+		}
+		if _, err := p.tx.Update(change.table, change.rowID, change.values); err != nil {
+			return err
+		}
+	}
+	for _, tableName := range deletedTables {
+		if err := p.tx.Delete(tableName, deleted[tableName]); err != nil {
+			return err
+		}
+	}
+
+	for _, change := range p.changes {
+		if change.values == nil {
+			continue
+		}
+
+		table, err := p.table(change.table)
+		if err != nil {
+			return err
+		}
+		for _, fk := range table.foreignKeys {
+			if keysEqual(rowKey(change.old, fk.columns), rowKey(change.values, fk.columns)) {
+				continue
+			}
+			if err := p.checkParent(fk, change.values); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, check := range p.checks {
+		if err := p.checkChildren(check.fk, check.key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// propagate планирует изменения строк, которые ссылаются на строку change, по действиям их внешних ключей
+func (p *referentialPlan) propagate(change *rowChange) error {
+	table, err := p.table(change.table)
+	if err != nil {
+		return err
+	}
+
+	for _, fk := range table.references {
+		oldKey := rowKey(change.old, fk.refColumns)
+		if slices.Contains(oldKey, nil) {
+			continue
+		}
+
+		action := fk.onDelete
+		var newKey []interface{}
+		if change.values != nil {
+			newKey = rowKey(change.values, fk.refColumns)
+			if keysEqual(oldKey, newKey) {
+				continue
+			}
+			action = fk.onUpdate
+		}
+
+		if action == storage.RestrictAction {
+			p.checks = append(p.checks, referenceCheck{fk: fk, key: oldKey})
+			continue
+		}
+
+		it, err := p.tx.IndexScan(fk.table, fk.name, keyBound(oldKey), keyBound(oldKey), false)
+		if err != nil {
+			return err
+		}
+		for it.Next() {
+			switch {
+			case action == storage.CascadeAction && change.values == nil:
+				p.remove(fk.table, it.RowID(), it.Row())
+			case action == storage.CascadeAction:
+				p.set(fk.table, it.RowID(), it.Row(), fk.columns, newKey)
+			case action == storage.SetNullAction:
+				p.set(fk.table, it.RowID(), it.Row(), fk.columns, make([]interface{}, len(fk.columns)))
+			}
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// remove планирует удаление строки. Уже запланированное изменение строки заменяется удалением
+func (p *referentialPlan) remove(tableName string, rowID storage.RowID, row []interface{}) {
+	change, ok := p.rows[tableName][rowID]
+	if !ok {
+		p.add(tableName, rowID, row, nil)
+		return
+	}
+
+	if change.values != nil {
+		change.values = nil
+		p.queue = append(p.queue, change)
+	}
+}
+
+// set планирует запись значений key в колонки columns строки поверх уже запланированных изменений
+func (p *referentialPlan) set(tableName string, rowID storage.RowID, row []interface{}, columns []int, key []interface{}) {
+	change, ok := p.rows[tableName][rowID]
+	if ok && change.values == nil {
+		return
+	}
+
+	current := row
+	if ok {
+		current = change.values
+	}
+	if keysEqual(rowKey(current, columns), key) {
+		return
+	}
+
+	values := append([]interface{}{}, current...)
+	for i, column := range columns {
+		values[column] = key[i]
+	}
+
+	if !ok {
+		p.add(tableName, rowID, row, values)
+		return
+	}
+	change.values = values
+	p.queue = append(p.queue, change)
+}
+
+// checkParent проверяет, что в таблице refTable есть строка, на которую ссылается строка values
+func (p *referentialPlan) checkParent(fk *foreignKey, values []interface{}) error {
+	key := rowKey(values, fk.columns)
+	// Как в SQL, ключ с NULL ни на что не ссылается и не проверяется
+	if slices.Contains(key, nil) {
+		return nil
+	}
+
+	found, err := p.parentExists(fk, key)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return &storage.ConstraintError{
+			Constraint: fk.name,
+			Message: fmt.Sprintf("insert or update on table %q violates foreign key constraint %q: key (%s)=(%s) is not present in table %q",
+				fk.table, fk.name, strings.Join(fk.columnNames, ", "), formatKey(key), fk.refTable),
+		}
+	}
+
+	return nil
+}
+
+// checkChildren проверяет, что на удаленный или измененный при RESTRICT ключ key больше никто не ссылается
+func (p *referentialPlan) checkChildren(fk *foreignKey, key []interface{}) error {
+	it, err := p.tx.IndexScan(fk.table, fk.name, keyBound(key), keyBound(key), false)
+	if err != nil {
+		return err
+	}
+	referenced := it.Next()
+	if err := it.Err(); err != nil {
+		return err
+	}
+	if !referenced {
+		return nil
+	}
+
+	// Запрос мог записать тот же ключ в другую строку, тогда ссылки остаются верными
+	found, err := p.parentExists(fk, key)
+	if err != nil || found {
+		return err
+	}
+
+	return &storage.ConstraintError{
+		Constraint: fk.name,
+		Message: fmt.Sprintf("update or delete on table %q violates foreign key constraint %q on table %q: key (%s)=(%s) is still referenced from table %q",
+			fk.refTable, fk.name, fk.table, strings.Join(fk.refColumnNames, ", "), formatKey(key), fk.table),
+	}
+}
+
+// parentExists ищет ключ key внешнего ключа fk по уникальному индексу таблицы refTable
+func (p *referentialPlan) parentExists(fk *foreignKey, key []interface{}) (bool, error) {
+	indexKey := make([]interface{}, len(key))
+	for i, position := range fk.refIndexOrder {
+		indexKey[i] = key[position]
+	}
+
+	it, err := p.tx.IndexScan(fk.refTable, fk.refIndex, keyBound(indexKey), keyBound(indexKey), false)
+	if err != nil {
+		return false, err
+	}
+	found := it.Next()
+
+	return found, it.Err()
+}
+
+// rowKey возвращает значения колонок columns строки row
+func rowKey(row []interface{}, columns []int) []interface{} {
+	key := make([]interface{}, len(columns))
+	for i, column := range columns {
+		key[i] = row[column]
+	}
+
+	return key
+}
+
+func keysEqual(a, b []interface{}) bool {
+	for i := range a {
+		if a[i] == nil || b[i] == nil {
+			if a[i] != b[i] {
+				return false
+			}
+			continue
+		}
+		if storage.CompareValues(a[i], b[i]) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func keyBound(key []interface{}) *storage.IndexBound {
+	return &storage.IndexBound{Values: key, Inclusive: true}
+}
+
+func formatKey(key []interface{}) string {
+	formatted := make([]string, len(key))
+	for i, value := range key {
+		formatted[i] = storage.FormatValue(value)
+	}
+
+	return strings.Join(formatted, ", ")
+}
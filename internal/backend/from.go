@@ -0,0 +1,424 @@
+package backend
+
+import (
+	"custom-database/internal/models"
+	"custom-database/internal/parser/ast"
+	"custom-database/internal/parser/lex"
+	"custom-database/internal/storage"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// fromTable - таблица FROM и имя, под которым на нее ссылаются колонки запроса: псевдоним или имя таблицы
+type fromTable struct {
+	table   string
+	name    string
+	columns []models.Column
+	// offset - номер первой колонки таблицы в строке соединения
+	offset int
+	// rows - строки подзапроса из FROM, который читается вместо таблицы, или nil
+	rows [][]interface{}
+}
+
+// selectSource - строки FROM: одна таблица или таблицы, соединенные слева направо.
+// steps[i] соединяет строки таблиц tables[:i+1] с таблицей tables[i+1]
+type selectSource struct {
+	scope  *fromScope
+	tables []*fromTable
+	steps  []*joinStep
+}
+
+// scopeColumn - колонка строки FROM: имя или псевдоним ее таблицы и имя колонки.
+// У общей колонки USING таблицы нет
+type scopeColumn struct {
+	table string
+	name  string
+}
+
+// fromScope - колонки строки FROM и имена, по которым их находят колонки запроса. В строке одной
+// таблицы колонки называются как в таблице, а в строке соединения - table.column, поэтому колонки
+// запроса переписываются в имена колонок строки, и дальше SELECT работает с ними так же,
+// как с колонками одной таблицы
+type fromScope struct {
+	// tables - имена и псевдонимы таблиц FROM
+	tables  []string
+	columns []models.Column
+	refs    []scopeColumn
+	// star - колонки строки, которые попадают в SELECT *
+	star []int
+	// outer - строка внешнего запроса, если запрос - подзапрос условия или списка SELECT, иначе nil
+	outer *outerRow
+}
+
+// newSelectSource описывает строки FROM запроса. outer - строка внешнего запроса для подзапроса
+// или nil: подзапросы FROM ее не видят и выполняются без нее
+func (mb *memoryBackend) newSelectSource(tx storage.Transaction, statement *ast.SelectStatement, outer *outerRow) (*selectSource, error) {
+	base, err := mb.newFromTable(tx, statement.From, statement.FromAlias, statement.FromSubquery)
+	if err != nil {
+		return nil, err
+	}
+
+	source := &selectSource{tables: []*fromTable{base}}
+	source.scope = newTableScope(base, len(statement.Joins) > 0)
+	source.scope.outer = outer
+
+	for _, clause := range statement.Joins {
+		table, err := mb.newFromTable(tx, clause.Table, clause.Alias, clause.Subquery)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(source.scope.tables, table.name) {
+			return nil, fmt.Errorf("table name %s specified more than once", table.name)
+		}
+		table.offset = len(source.scope.columns)
+
+		step, scope, err := source.scope.join(table, clause)
+		if err != nil {
+			return nil, err
+		}
+
+		source.tables = append(source.tables, table)
+		source.steps = append(source.steps, step)
+		source.scope = scope
+	}
+
+	return source, nil
+}
+
+// newFromTable описывает таблицу FROM. Подзапрос FROM выполняется сразу, и его строки читаются
+// вместо строк таблицы под именем псевдонима
+func (mb *memoryBackend) newFromTable(tx storage.Transaction, table lex.Token, alias *lex.Token, subquery *ast.SelectStatement) (*fromTable, error) {
+	if subquery != nil {
+		columns, rows, err := mb.selectRows(tx, subquery, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &fromTable{name: alias.Value, columns: columns, rows: rows}, nil
+	}
+
+	columns, err := tx.GetTableColumns(table.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	name := table.Value
+	if alias != nil {
+		name = alias.Value
+	}
+
+	return &fromTable{table: table.Value, name: name, columns: columns}, nil
+}
+
+// newTableScope описывает колонки строки одной таблицы. С qualified колонки называются table.column,
+// как в строке соединения
+func newTableScope(table *fromTable, qualified bool) *fromScope {
+	scope := &fromScope{tables: []string{table.name}}
+	for i, column := range table.columns {
+		ref := scopeColumn{table: table.name, name: column.Name}
+		if qualified {
+			column.Name = table.name + "." + column.Name
+		}
+
+		scope.columns = append(scope.columns, column)
+		scope.refs = append(scope.refs, ref)
+		scope.star = append(scope.star, i)
+	}
+
+	return scope
+}
+
+// join описывает соединение строк scope со строками table: возвращает шаг соединения и колонки
+// соединенной строки. Строка соединения - левая строка, затем строка таблицы, затем общие колонки USING.
+// Общая колонка USING скрывает колонки с тем же именем без имени таблицы и идет первой в SELECT *
+// вместо них
+func (s *fromScope) join(table *fromTable, clause *ast.JoinClause) (*joinStep, *fromScope, error) {
+	right := newTableScope(table, true)
+	joined := &fromScope{
+		tables:  append(slices.Clone(s.tables), table.name),
+		columns: append(slices.Clone(s.columns), right.columns...),
+		refs:    append(slices.Clone(s.refs), right.refs...),
+		outer:   s.outer,
+	}
+	step := &joinStep{kind: clause.Kind, table: table}
+
+	merged := []int{}
+	for _, column := range clause.Using {
+		left, err := s.resolve(column.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+		if left == -1 {
+			return nil, nil, fmt.Errorf("column %s specified in USING clause does not exist in left table", column.Value)
+		}
+		rightColumn := findColumnIndex(table.columns, column.Value)
+		if rightColumn == -1 {
+			return nil, nil, fmt.Errorf("column %s specified in USING clause does not exist in right table", column.Value)
+		}
+		if s.columns[left].Type != table.columns[rightColumn].Type {
+			return nil, nil, fmt.Errorf("JOIN/USING types %s and %s cannot be matched",
+				columnTypeName(s.columns[left].Type), columnTypeName(table.columns[rightColumn].Type))
+		}
+
+		step.leftKeys = append(step.leftKeys, left)
+		step.rightKeys = append(step.rightKeys, rightColumn)
+		step.using = append(step.using, [2]int{left, table.offset + rightColumn})
+
+		// Общая колонка прошлого USING с тем же именем больше не находится по имени
+		if joined.refs[left].table == "" {
+			joined.refs[left].name = ""
+			joined.columns[left].Name = ""
+		}
+
+		merged = append(merged, len(joined.columns))
+		joined.refs = append(joined.refs, scopeColumn{name: column.Value})
+		joined.columns = append(joined.columns, models.Column{Name: column.Value, Type: s.columns[left].Type})
+	}
+
+	joined.star = merged
+	for _, i := range s.star {
+		if !slices.Contains(step.leftKeys, i) {
+			joined.star = append(joined.star, i)
+		}
+	}
+	for _, i := range right.star {
+		if !slices.Contains(step.rightKeys, i) {
+			joined.star = append(joined.star, table.offset+i)
+		}
+	}
+
+	if clause.On != nil {
+		on, err := joined.qualifyCondition(clause.On, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		step.leftKeys, step.rightKeys, step.condition = joinKeys(joined.columns, on, table.offset, len(table.columns))
+	}
+	step.columns = joined.columns
+
+	return step, joined, nil
+}
+
+// resolve находит колонку строки по колонке запроса: column или table.column. Возвращает -1,
+// если такой колонки нет, и ошибку, если таблицы нет в FROM или колонка без имени таблицы
+// есть в нескольких таблицах
+func (s *fromScope) resolve(reference string) (int, error) {
+	table, name, qualified := strings.Cut(reference, ".")
+	if !qualified {
+		table, name = "", reference
+	} else if !slices.Contains(s.tables, table) {
+		return -1, fmt.Errorf("missing FROM-clause entry for table %s", table)
+	}
+
+	if !qualified {
+		for i, ref := range s.refs {
+			if ref.table == "" && ref.name == name {
+				return i, nil
+			}
+		}
+	}
+
+	found := -1
+	for i, ref := range s.refs {
+		if ref.table == "" || ref.name != name || (qualified && ref.table != table) {
+			continue
+		}
+		if found != -1 {
+			return -1, fmt.Errorf("column reference %s is ambiguous", reference)
+		}
+		found = i
+	}
+
+	return found, nil
+}
+
+// declares сообщает, относится ли колонка запроса к FROM: есть ли в нем таблица table.column
+// или колонка column. Колонка, которой нет в FROM, ищется во внешнем запросе
+func (s *fromScope) declares(reference string) bool {
+	table, name, qualified := strings.Cut(reference, ".")
+	if qualified {
+		return slices.Contains(s.tables, table)
+	}
+
+	return slices.ContainsFunc(s.refs, func(ref scopeColumn) bool { return ref.name == name })
+}
+
+// qualifyToken возвращает колонку запроса с именем колонки строки. Колонку, которой нет в строке,
+// с strict считает ошибкой, а без него оставляет как есть - ошибку о ней вернет код, который ее ищет
+func (s *fromScope) qualifyToken(token *lex.Token, strict bool) (*lex.Token, error) {
+	i, err := s.resolve(token.Value)
+	if err != nil {
+		return nil, err
+	}
+	if i == -1 {
+		if strict {
+			return nil, fmt.Errorf("column not found: %s", token.Value)
+		}
+		return token, nil
+	}
+
+	qualified := *token
+	qualified.Value = s.columns[i].Name
+
+	return &qualified, nil
+}
+
+func (s *fromScope) qualifyCall(call *ast.AggregateCall) (*ast.AggregateCall, error) {
+	if call.Argument == nil {
+		return call, nil
+	}
+
+	argument, err := s.qualifyToken(call.Argument, false)
+	if err != nil {
+		return nil, err
+	}
+
+	qualified := *call
+	qualified.Argument = argument
+
+	return &qualified, nil
+}
+
+// qualifyCondition копирует условие с колонками строки. Лист с агрегатной функцией получает
+// текст вызова с новым аргументом
+func (s *fromScope) qualifyCondition(condition *ast.WhereClause, strict bool) (*ast.WhereClause, error) {
+	if condition == nil {
+		return nil, nil
+	}
+
+	qualified := *condition
+	switch {
+	case condition.Aggregate != nil:
+		call, err := s.qualifyCall(condition.Aggregate)
+		if err != nil {
+			return nil, err
+		}
+		token := *condition.Token
+		token.Value = call.String()
+		qualified.Aggregate, qualified.Token = call, &token
+	case condition.Token != nil && condition.Token.Kind == lex.IdentifierToken:
+		token, err := s.qualifyColumn(condition.Token, strict)
+		if err != nil {
+			return nil, err
+		}
+		qualified.Token = token
+	}
+
+	left, err := s.qualifyCondition(condition.Left, strict)
+	if err != nil {
+		return nil, err
+	}
+	right, err := s.qualifyCondition(condition.Right, strict)
+	if err != nil {
+		return nil, err
+	}
+	qualified.Left, qualified.Right = left, right
+
+	return &qualified, nil
+}
+
+// qualifyColumn возвращает колонку условия с именем колонки строки, а колонку внешнего запроса
+// подзапроса - ее значением в строке внешнего запроса
+func (s *fromScope) qualifyColumn(token *lex.Token, strict bool) (*lex.Token, error) {
+	if s.outer != nil && !s.declares(token.Value) {
+		value, ok, err := s.outer.lookup(token)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return value, nil
+		}
+	}
+
+	return s.qualifyToken(token, strict)
+}
+
+// qualifyStatement возвращает копию SELECT, в которой колонки списка SELECT, WHERE, GROUP BY, HAVING
+// и ORDER BY переписаны в имена колонок строки FROM. Ключ ORDER BY, совпадающий с AS из списка SELECT,
+// остается как есть: он ссылается на колонку результата
+func (s *fromScope) qualifyStatement(statement *ast.SelectStatement) (*ast.SelectStatement, error) {
+	qualified := *statement
+
+	qualified.SelectedColumns = make([]*ast.Expression, len(statement.SelectedColumns))
+	aliases := []string{}
+	for i, exp := range statement.SelectedColumns {
+		copied := *exp
+		switch {
+		case exp.Kind == ast.SubqueryKind:
+			// Подзапрос разбирает свои колонки сам, когда выполняется
+		case exp.Kind == ast.AggregateKind:
+			call, err := s.qualifyCall(exp.Aggregate)
+			if err != nil {
+				return nil, err
+			}
+			copied.Aggregate = call
+		case exp.Literal != nil && exp.Literal.Kind == lex.IdentifierToken:
+			token, err := s.qualifyToken(exp.Literal, false)
+			if err != nil {
+				return nil, err
+			}
+			copied.Literal = token
+		}
+		if exp.Alias != nil {
+			aliases = append(aliases, exp.Alias.Value)
+		}
+
+		qualified.SelectedColumns[i] = &copied
+	}
+
+	where, err := s.qualifyCondition(statement.Where, false)
+	if err != nil {
+		return nil, err
+	}
+	qualified.Where = where
+
+	qualified.GroupBy = make([]lex.Token, len(statement.GroupBy))
+	for i := range statement.GroupBy {
+		token, err := s.qualifyToken(&statement.GroupBy[i], false)
+		if err != nil {
+			return nil, err
+		}
+		qualified.GroupBy[i] = *token
+	}
+
+	having, err := s.qualifyCondition(statement.Having, false)
+	if err != nil {
+		return nil, err
+	}
+	qualified.Having = having
+
+	qualified.OrderBy = make([]*ast.OrderByClause, len(statement.OrderBy))
+	for i, clause := range statement.OrderBy {
+		copied := *clause
+		if !slices.Contains(aliases, clause.Column.Value) {
+			token, err := s.qualifyToken(&clause.Column, false)
+			if err != nil {
+				return nil, err
+			}
+			copied.Column = *token
+		}
+
+		qualified.OrderBy[i] = &copied
+	}
+
+	return &qualified, nil
+}
+
+// sourceRows возвращает строки FROM, среди которых есть все подходящие под WHERE: строки таблицы
+// по scanTable или строки соединения таблиц. Строки соединения не упорядочены по orderBy
+func (mb *memoryBackend) sourceRows(tx storage.Transaction, source *selectSource, where *ast.WhereClause, orderBy []*ast.OrderByClause) (storage.RowIterator, bool, error) {
+	if len(source.steps) == 0 {
+		table := source.tables[0]
+		if table.rows != nil {
+			return storage.NewSliceIterator(table.rows), false, nil
+		}
+		return mb.scanTable(tx, table.table, table.columns, where, orderBy)
+	}
+
+	rows, err := mb.joinRows(tx, source, where)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return storage.NewSliceIterator(rows), false, nil
+}
@@ -0,0 +1,200 @@
+package backend
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+)
+
+// aggregatePartitions - количество разделов, на которые делятся строки групп, не поместившихся в память
+const aggregatePartitions = 8
+
+// hashAggregate собирает строки в группы по значениям колонок groupBy и считает в каждой группе
+// агрегатные функции. Группы хранятся в хеш-таблице, пока она помещается в memoryLimit байт.
+// После этого новые группы в таблицу не добавляются: их строки раскладываются по хешу ключа
+// во временные файлы-разделы, а строки групп из таблицы по-прежнему учитываются в памяти.
+// Поэтому группа целиком лежит либо в таблице, либо в одном разделе, и каждый раздел
+// потом собирается отдельно так же, с другим хешом на следующем уровне
+type hashAggregate struct {
+	groupBy     []int
+	aggregates  []aggregateSpec
+	memoryLimit int
+	// level - уровень разделения, от него зависит хеш ключа
+	level int
+
+	groups map[string]*aggregateGroup
+	used   int
+	// full - таблица заполнена, и строки новых групп записываются в разделы
+	full       bool
+	partitions []*aggregatePartition
+}
+
+type aggregateGroup struct {
+	key    []interface{}
+	states []aggregateState
+}
+
+// aggregatePartition - временный файл со строками групп, не поместившихся в таблицу
+type aggregatePartition struct {
+	file    *os.File
+	writer  *bufio.Writer
+	encoder *gob.Encoder
+}
+
+// newHashAggregate создает хеш-таблицу групп. Без колонок GROUP BY все строки - одна группа,
+// и она есть в результате даже без строк
+func newHashAggregate(groupBy []int, aggregates []aggregateSpec, memoryLimit int, level int) *hashAggregate {
+	h := &hashAggregate{
+		groupBy:     groupBy,
+		aggregates:  aggregates,
+		memoryLimit: memoryLimit,
+		level:       level,
+		groups:      map[string]*aggregateGroup{},
+		partitions:  make([]*aggregatePartition, aggregatePartitions),
+	}
+
+	if len(groupBy) == 0 && level == 0 {
+		h.groups[""] = &aggregateGroup{key: []interface{}{}, states: make([]aggregateState, len(aggregates))}
+	}
+
+	return h
+}
+
+// Add учитывает строку таблицы в ее группе
+func (h *hashAggregate) Add(row []interface{}) error {
+	key := make([]interface{}, len(h.groupBy))
+	for i, column := range h.groupBy {
+		key[i] = row[column]
+	}
+	encoded := encodeValues(key)
+
+	group, ok := h.groups[encoded]
+	if !ok {
+		if h.full {
+			return h.spill(encoded, row)
+		}
+
+		group = &aggregateGroup{key: key, states: make([]aggregateState, len(h.aggregates))}
+		h.groups[encoded] = group
+		h.used += len(encoded) + rowSize(key) + 48*len(h.aggregates)
+	}
+
+	for i, spec := range h.aggregates {
+		h.used += spec.add(&group.states[i], row)
+	}
+	if h.used > h.memoryLimit {
+		h.full = true
+	}
+
+	return nil
+}
+
+// spill записывает строку в раздел по хешу ключа ее группы
+func (h *hashAggregate) spill(encoded string, row []interface{}) error {
+	hash := fnv.New32a()
+	hash.Write([]byte{byte(h.level)})
+	hash.Write([]byte(encoded))
+	i := hash.Sum32() % aggregatePartitions
+
+	if h.partitions[i] == nil {
+		file, err := os.CreateTemp("", "aggregate-*.part")
+		if err != nil {
+			return fmt.Errorf("spill(): %w", err)
+		}
+		writer := bufio.NewWriter(file)
+		h.partitions[i] = &aggregatePartition{file: file, writer: writer, encoder: gob.NewEncoder(writer)}
+	}
+
+	if err := h.partitions[i].encoder.Encode(&row); err != nil {
+		return fmt.Errorf("spill(): %w", err)
+	}
+
+	return nil
+}
+
+// Groups передает fn строку каждой группы: значения ключа, затем значения агрегатных функций.
+// Сначала отдаются группы из памяти, затем группы из разделов
+func (h *hashAggregate) Groups(fn func(group []interface{}) error) error {
+	for _, group := range h.groups {
+		row := append([]interface{}{}, group.key...)
+		for i, spec := range h.aggregates {
+			value, err := spec.result(&group.states[i])
+			if err != nil {
+				return err
+			}
+			row = append(row, value)
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	h.groups = nil
+
+	for i, partition := range h.partitions {
+		if partition == nil {
+			continue
+		}
+
+		if err := h.mergePartition(partition, fn); err != nil {
+			return err
+		}
+		h.partitions[i] = nil
+		if err := partition.remove(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergePartition собирает группы раздела в новой хеш-таблице следующего уровня
+func (h *hashAggregate) mergePartition(partition *aggregatePartition, fn func(group []interface{}) error) error {
+	if err := partition.writer.Flush(); err != nil {
+		return fmt.Errorf("mergePartition(): %w", err)
+	}
+	if _, err := partition.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("mergePartition(): %w", err)
+	}
+
+	next := newHashAggregate(h.groupBy, h.aggregates, h.memoryLimit, h.level+1)
+	defer next.Close()
+
+	decoder := gob.NewDecoder(bufio.NewReader(partition.file))
+	for {
+		var row []interface{}
+		if err := decoder.Decode(&row); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("mergePartition(): %w", err)
+		}
+
+		if err := next.Add(row); err != nil {
+			return err
+		}
+	}
+
+	return next.Groups(fn)
+}
+
+// Close удаляет временные файлы разделов
+func (h *hashAggregate) Close() error {
+	var errs []error
+	for i, partition := range h.partitions {
+		if partition != nil {
+			errs = append(errs, partition.remove())
+			h.partitions[i] = nil
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p *aggregatePartition) remove() error {
+	return errors.Join(p.file.Close(), os.Remove(p.file.Name()))
+}
@@ -0,0 +1,139 @@
+package backend
+
+import (
+	"custom-database/internal/models"
+	"custom-database/internal/parser/ast"
+	"custom-database/internal/parser/lex"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// groupResult - ожидаемые COUNT(*), SUM, MIN и MAX группы
+type groupResult struct {
+	count, sum, min, max int32
+}
+
+// newTestAggregate создает хеш-таблицу групп по team с COUNT(*), SUM(score), MIN(score) и MAX(score).
+// Временные файлы разделов создаются в dir
+func newTestAggregate(t *testing.T, dir string, memoryLimit int) *hashAggregate {
+	t.Helper()
+	t.Setenv("TMPDIR", dir)
+
+	columns := []models.Column{{Name: "team", Type: models.TextType}, {Name: "score", Type: models.IntType}}
+	score := &lex.Token{Kind: lex.IdentifierToken, Value: "score"}
+	calls := []*ast.AggregateCall{
+		{Function: lex.Token{Kind: lex.KeywordToken, Value: string(lex.CountKeyword)}},
+		{Function: lex.Token{Kind: lex.KeywordToken, Value: string(lex.SumKeyword)}, Argument: score},
+		{Function: lex.Token{Kind: lex.KeywordToken, Value: string(lex.MinKeyword)}, Argument: score},
+		{Function: lex.Token{Kind: lex.KeywordToken, Value: string(lex.MaxKeyword)}, Argument: score},
+	}
+
+	aggregates := make([]aggregateSpec, len(calls))
+	for i, call := range calls {
+		spec, err := newAggregateSpec(columns, call)
+		require.NoError(t, err)
+		aggregates[i] = spec
+	}
+
+	return newHashAggregate([]int{0}, aggregates, memoryLimit, 0)
+}
+
+// addTestRows добавляет строки groups групп по perGroup строк в каждой и возвращает ожидаемые результаты
+func addTestRows(t *testing.T, h *hashAggregate, groups, perGroup int) map[string]groupResult {
+	t.Helper()
+
+	want := map[string]groupResult{}
+	for i := 0; i < perGroup; i++ {
+		for g := 0; g < groups; g++ {
+			team := fmt.Sprintf("team-%d", g)
+			score := int32(g*1000 + i)
+			require.NoError(t, h.Add([]interface{}{team, score}))
+
+			result, ok := want[team]
+			if !ok {
+				result = groupResult{min: score, max: score}
+			}
+			result.count++
+			result.sum += score
+			result.min = min(result.min, score)
+			result.max = max(result.max, score)
+			want[team] = result
+		}
+	}
+
+	return want
+}
+
+func partitionFiles(t *testing.T, dir string) int {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	return len(entries)
+}
+
+func TestHashAggregate(t *testing.T) {
+	t.Run("группы в памяти", func(t *testing.T) {
+		dir := t.TempDir()
+		h := newTestAggregate(t, dir, 1<<20)
+		defer h.Close()
+
+		want := addTestRows(t, h, 10, 5)
+		require.Equal(t, 0, partitionFiles(t, dir))
+
+		got := map[string]groupResult{}
+		require.NoError(t, h.Groups(func(group []interface{}) error {
+			got[group[0].(string)] = groupResult{group[1].(int32), group[2].(int32), group[3].(int32), group[4].(int32)}
+			return nil
+		}))
+		require.Equal(t, want, got)
+	})
+
+	t.Run("группы сверх памяти собираются из разделов", func(t *testing.T) {
+		dir := t.TempDir()
+		// В память помещается только первая группа, строки остальных раскладываются по разделам
+		// и при сборке разделов снова не помещаются, уходя на следующие уровни
+		h := newTestAggregate(t, dir, 1)
+		defer h.Close()
+
+		want := addTestRows(t, h, 200, 3)
+
+		spilled := 0
+		for _, partition := range h.partitions {
+			if partition != nil {
+				spilled++
+			}
+		}
+		require.Greater(t, spilled, 1)
+		require.Equal(t, spilled, partitionFiles(t, dir))
+
+		got := map[string]groupResult{}
+		require.NoError(t, h.Groups(func(group []interface{}) error {
+			team := group[0].(string)
+			_, ok := got[team]
+			require.False(t, ok, "группа %s отдана дважды", team)
+
+			got[team] = groupResult{group[1].(int32), group[2].(int32), group[3].(int32), group[4].(int32)}
+			return nil
+		}))
+		require.Equal(t, want, got)
+
+		// Разделы всех уровней удаляются, как только собраны
+		require.Equal(t, 0, partitionFiles(t, dir))
+		require.NoError(t, h.Close())
+	})
+
+	t.Run("Close удаляет несобранные разделы", func(t *testing.T) {
+		dir := t.TempDir()
+		h := newTestAggregate(t, dir, 1)
+
+		addTestRows(t, h, 50, 2)
+		require.Greater(t, partitionFiles(t, dir), 1)
+
+		require.NoError(t, h.Close())
+		require.Equal(t, 0, partitionFiles(t, dir))
+	})
+}
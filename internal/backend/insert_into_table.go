@@ -1,120 +1,200 @@
 package backend
 
 import (
-	"bytes"
 	"custom-database/internal/models"
 	"custom-database/internal/parser/ast"
 	"custom-database/internal/parser/lex"
-	"encoding/binary"
+	"custom-database/internal/storage"
 	"fmt"
-	"strconv"
 )
 
-func (mb *memoryBackend) insertIntoTable(statement *ast.InsertStatement) error {
-	if statement.Values == nil {
-		return nil
-	}
-
-	row := []MemoryCell{}
-	for _, value := range *statement.Values {
-		if value.Kind != ast.LiteralKind {
-			fmt.Println("Skipping non-literal.")
-			continue
-		}
+// insertIntoTable записывает строки VALUES или результат SELECT и возвращает их количество
+func (mb *memoryBackend) insertIntoTable(tx storage.Transaction, statement *ast.InsertStatement) (int, error) {
+	tableName := statement.Table.Value
 
-		row = append(row, mb.tokenToCell(value.Literal))
+	columns, err := tx.GetTableColumns(tableName)
+	if err != nil {
+		return 0, err
 	}
 
-	interfaceCells := make([]interface{}, len(row))
-	columns, err := mb.persistentStorage.GetTableColumns(statement.Table.Value)
+	targets, err := insertColumns(tableName, columns, statement.Columns)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	explicit := statement.Columns != nil
 
-	for i, cell := range row {
-		// if cell == nil {
-		// 	return fmt.Errorf("failed to convert cell to interface: nil not allowed")
-		// }
-
-		column := columns[i]
-
-		if column.Type == models.IntType {
-			if cell.IsNull() {
-				interfaceCells[i] = nil
-			} else {
-				interfaceCells[i] = cell.AsInt()
+	var rows [][]interface{}
+	if statement.Select != nil {
+		rows, err = mb.selectedValues(tx, statement.Select, columns, targets, explicit)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		for _, row := range statement.Rows {
+			values, err := insertValues(columns, targets, *row, explicit)
+			if err != nil {
+				return 0, err
 			}
+			rows = append(rows, values)
 		}
+	}
 
-		if column.Type == models.TextType {
-			if cell.IsNull() {
-				interfaceCells[i] = nil
-			} else {
-				interfaceCells[i] = cell.AsText()
-			}
+	return mb.insertRows(tx, tableName, columns, rows)
+}
+
+// insertRows проверяет ограничения строк и записывает их. Ссылки внешних ключей проверяются
+// после записи всех строк, поэтому строки одного INSERT могут ссылаться друг на друга
+func (mb *memoryBackend) insertRows(tx storage.Transaction, tableName string, columns []models.Column, rows [][]interface{}) (int, error) {
+	checks, err := loadChecks(tx, tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, values := range rows {
+		if err := mb.checkConstraints(tableName, columns, checks, values); err != nil {
+			return 0, err
 		}
 
-		if column.Type == models.BoolType {
-			if cell.IsNull() {
-				interfaceCells[i] = nil
-			} else {
-				interfaceCells[i] = cell.AsBoolean()
-			}
+		if err := tx.Insert(tableName, values); err != nil {
+			return 0, err
 		}
+	}
 
-		if column.Type == models.TimestampType {
-			if cell.IsNull() {
-				interfaceCells[i] = nil
-			} else {
-				interfaceCells[i] = cell.AsText()
-			}
+	plan := newReferentialPlan(mb, tx)
+	for _, values := range rows {
+		if err := plan.checkInserted(tableName, values); err != nil {
+			return 0, err
 		}
 	}
 
-	err = mb.persistentStorage.Insert(statement.Table.Value, interfaceCells)
+	return len(rows), nil
+}
+
+// selectedValues выполняет SELECT из INSERT ... SELECT и строит из его строк строки таблицы.
+// Колонки результата сопоставляются колонкам targets по порядку и должны совпадать с ними по типу.
+// Все строки читаются до записи, поэтому INSERT INTO table SELECT ... FROM table не видит своих же строк
+func (mb *memoryBackend) selectedValues(tx storage.Transaction, statement *ast.SelectStatement, columns []models.Column, targets []int, explicit bool) ([][]interface{}, error) {
+	selected, rows, err := mb.selectRows(tx, statement, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	cells := make([]models.Cell, len(row))
-	for i, cell := range row {
-		cells[i] = cell
+	if err := checkInsertLength(len(selected), len(targets), explicit); err != nil {
+		return nil, err
+	}
+	for i, column := range selected {
+		target := columns[targets[i]]
+		if column.Type != target.Type {
+			return nil, fmt.Errorf("column %s is of type %s but expression is of type %s",
+				target.Name, columnTypeName(target.Type), columnTypeName(column.Type))
+		}
 	}
 
-	return nil
-	// return mb.memoryStorage.Insert(statement.Table.Value, cells)
-}
-
-func (mb *memoryBackend) tokenToCell(t *lex.Token) MemoryCell {
-	if t.Kind == lex.NumericToken {
-		buf := new(bytes.Buffer)
-		i, err := strconv.Atoi(t.Value)
+	values := make([][]interface{}, 0, len(rows))
+	for _, row := range rows {
+		tableRow, err := targetRow(columns, targets, row)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
+		values = append(values, tableRow)
+	}
 
-		err = binary.Write(buf, binary.BigEndian, int32(i))
-		if err != nil {
-			panic(err)
+	return values, nil
+}
+
+// insertColumns возвращает номера колонок, которым по порядку соответствуют значения строк:
+// колонки из списка INSERT или, без списка, все колонки таблицы
+func insertColumns(tableName string, columns []models.Column, columnNames []lex.Token) ([]int, error) {
+	if columnNames == nil {
+		targets := make([]int, len(columns))
+		for i := range columns {
+			targets[i] = i
 		}
-		return MemoryCell(buf.Bytes())
+		return targets, nil
 	}
 
-	if t.Kind == lex.StringToken {
-		return MemoryCell(t.Value)
+	targets := make([]int, len(columnNames))
+	for i, columnName := range columnNames {
+		targets[i] = findColumnIndex(columns, columnName.Value)
+		if targets[i] == -1 {
+			return nil, fmt.Errorf("column %s of relation %s does not exist", columnName.Value, tableName)
+		}
+		for _, previous := range targets[:i] {
+			if previous == targets[i] {
+				return nil, fmt.Errorf("column %s specified more than once", columnName.Value)
+			}
+		}
 	}
 
-	if t.Kind == lex.BooleanToken {
-		return MemoryCell(t.Value)
+	return targets, nil
+}
+
+// insertValues строит строку таблицы из значений row для колонок targets. Значение DEFAULT
+// заменяется значением по умолчанию колонки или NULL
+func insertValues(columns []models.Column, targets []int, row []*ast.Expression, explicit bool) ([]interface{}, error) {
+	if err := checkInsertLength(len(row), len(targets), explicit); err != nil {
+		return nil, err
 	}
 
-	if t.Kind == lex.NullToken {
-		return MemoryCell(t.Value)
+	values := make([]interface{}, len(row))
+	for i, value := range row {
+		column := columns[targets[i]]
+
+		switch {
+		case value.Kind == ast.DefaultKind:
+			defaultValue, err := defaultValue(column)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = defaultValue
+		case value.Kind == ast.LiteralKind && value.Literal.Kind != lex.IdentifierToken:
+			literal, err := tokenToValue(column, value.Literal)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = literal
+		default:
+			return nil, fmt.Errorf("invalid value for column %s", column.Name)
+		}
 	}
 
-	if t.Kind == lex.DateToken {
-		return MemoryCell(t.Value)
+	return targetRow(columns, targets, values)
+}
+
+// checkInsertLength сравнивает количество значений строки с количеством колонок, которым они записываются.
+// Со списком колонок значений должно быть ровно столько, сколько колонок в списке, без него - не больше
+func checkInsertLength(values, targets int, explicit bool) error {
+	if values > targets {
+		return fmt.Errorf("INSERT has more expressions than target columns: %d > %d", values, targets)
+	}
+	if explicit && values < targets {
+		return fmt.Errorf("INSERT has more target columns than expressions: %d > %d", targets, values)
 	}
 
 	return nil
 }
+
+// targetRow раскладывает values по колонкам targets, а колонки без значения получают
+// значение по умолчанию или NULL
+func targetRow(columns []models.Column, targets []int, values []interface{}) ([]interface{}, error) {
+	row := make([]interface{}, len(columns))
+	assigned := make([]bool, len(columns))
+	for i, value := range values {
+		row[targets[i]] = value
+		assigned[targets[i]] = true
+	}
+
+	for i, column := range columns {
+		if assigned[i] {
+			continue
+		}
+
+		value, err := defaultValue(column)
+		if err != nil {
+			return nil, err
+		}
+		row[i] = value
+	}
+
+	return row, nil
+}
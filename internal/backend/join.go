@@ -0,0 +1,504 @@
+package backend
+
+import (
+	"custom-database/internal/models"
+	"custom-database/internal/parser/ast"
+	"custom-database/internal/parser/lex"
+	"custom-database/internal/storage"
+)
+
+// joinStep - соединение строк предыдущих таблиц FROM (левая сторона) со строками следующей таблицы (правая сторона)
+type joinStep struct {
+	kind  ast.JoinKind
+	table *fromTable
+	// leftKeys и rightKeys - колонки равенств из ON или USING в левой строке и в строке таблицы.
+	// По ним строки соединяются хешем или слиянием, а строка с NULL в них не соединяется ни с какой
+	leftKeys, rightKeys []int
+	// condition - остальное условие ON, которое проверяется на соединенной строке
+	condition *ast.WhereClause
+	// using - колонки USING в соединенной строке: слева и справа. Общая колонка получает значение
+	// левой колонки, а если там NULL - правой
+	using [][2]int
+	// columns - колонки соединенной строки
+	columns []models.Column
+}
+
+// joinRows соединяет таблицы FROM слева направо: результат прошлых соединений - левая сторона следующего.
+// Соединение без равенств колонок идет вложенными циклами, с равенствами - хешем по строкам таблицы
+// справа, а если обе стороны читаются из индексов в порядке колонок равенств - слиянием.
+// Условия WHERE по колонкам одной таблицы проверяются еще при чтении таблицы
+func (mb *memoryBackend) joinRows(tx storage.Transaction, source *selectSource, where *ast.WhereClause) ([][]interface{}, error) {
+	filters := source.tableFilters(where)
+
+	var rows [][]interface{}
+	for i, step := range source.steps {
+		var left storage.RowIterator
+		leftOrdered := false
+		if i == 0 {
+			var err error
+			left, leftOrdered, err = mb.scanFromTable(tx, source.tables[0], filters[0], keysOrder(source.tables[0].columns, step.leftKeys))
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			left = storage.NewSliceIterator(rows)
+		}
+
+		right, rightOrdered, err := mb.scanFromTable(tx, step.table, filters[i+1], keysOrder(step.table.columns, step.rightKeys))
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case len(step.leftKeys) == 0:
+			rows, err = mb.nestedLoopJoin(step, left, right)
+		case leftOrdered && rightOrdered:
+			rows, err = mb.mergeJoin(step, left, right)
+		default:
+			rows, err = mb.hashJoin(step, left, right)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rows, nil
+}
+
+// scanFromTable читает строки таблицы FROM, подходящие под filter, по возможности в порядке orderBy
+func (mb *memoryBackend) scanFromTable(tx storage.Transaction, table *fromTable, filter *ast.WhereClause, orderBy []*ast.OrderByClause) (storage.RowIterator, bool, error) {
+	var it storage.RowIterator
+	ordered := false
+	if table.rows != nil {
+		it = storage.NewSliceIterator(table.rows)
+	} else {
+		var err error
+		it, ordered, err = mb.scanTable(tx, table.table, table.columns, filter, orderBy)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	if filter == nil {
+		return it, ordered, nil
+	}
+
+	return &filterIterator{RowIterator: it, mb: mb, columns: table.columns, condition: filter}, ordered, nil
+}
+
+// keysOrder - ORDER BY по колонкам ключей соединения, в котором их сливает mergeJoin
+func keysOrder(columns []models.Column, keys []int) []*ast.OrderByClause {
+	var orderBy []*ast.OrderByClause
+	for _, key := range keys {
+		orderBy = append(orderBy, &ast.OrderByClause{Column: lex.Token{Kind: lex.IdentifierToken, Value: columns[key].Name}})
+	}
+
+	return orderBy
+}
+
+// filterIterator пропускает строки, которые не подходят под условие
+type filterIterator struct {
+	storage.RowIterator
+	mb        *memoryBackend
+	columns   []models.Column
+	condition *ast.WhereClause
+}
+
+func (it *filterIterator) Next() bool {
+	for it.RowIterator.Next() {
+		if it.mb.filterRow(it.columns, it.Row(), it.condition) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tableFilters раскладывает условия WHERE, связанные через AND, по таблицам FROM: условие только
+// по колонкам одной таблицы проверяется при ее чтении, с именами колонок таблицы. Таблицам, строки
+// которых соединение может дополнить NULL (правая сторона LEFT JOIN, левая сторона RIGHT JOIN и обе
+// стороны FULL JOIN), условия не передаются: строка с NULL вместо отброшенной строки могла бы
+// подойти под WHERE, под который не подошла бы соединенная строка
+func (source *selectSource) tableFilters(where *ast.WhereClause) []*ast.WhereClause {
+	filters := make([]*ast.WhereClause, len(source.tables))
+	if where == nil {
+		return filters
+	}
+
+	for i, table := range source.tables {
+		if source.nullable(i) {
+			continue
+		}
+
+		var conditions []*ast.WhereClause
+		for _, condition := range conjuncts(where) {
+			if filter, ok := tableCondition(source.scope.columns, table, condition); ok {
+				conditions = append(conditions, filter)
+			}
+		}
+		filters[i] = joinConditions(conditions)
+	}
+
+	return filters
+}
+
+// nullable сообщает, может ли соединение дополнить NULL вместо строк таблицы tables[i]
+func (source *selectSource) nullable(i int) bool {
+	for j, step := range source.steps {
+		if (step.kind == ast.RightJoin || step.kind == ast.FullJoin) && i <= j {
+			return true
+		}
+		if (step.kind == ast.LeftJoin || step.kind == ast.FullJoin) && i == j+1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tableCondition копирует условие с именами колонок таблицы, если все его колонки из этой таблицы
+// и в нем нет подзапросов
+func tableCondition(columns []models.Column, table *fromTable, condition *ast.WhereClause) (*ast.WhereClause, bool) {
+	if condition == nil {
+		return nil, true
+	}
+	if condition.Token == nil || condition.Aggregate != nil || condition.Subquery != nil {
+		return nil, false
+	}
+
+	copied := *condition
+	if condition.Token.Kind == lex.IdentifierToken {
+		column := findColumnIndex(columns, condition.Token.Value)
+		if column < table.offset || column >= table.offset+len(table.columns) {
+			return nil, false
+		}
+
+		token := *condition.Token
+		token.Value = table.columns[column-table.offset].Name
+		copied.Token = &token
+	}
+
+	left, ok := tableCondition(columns, table, condition.Left)
+	if !ok {
+		return nil, false
+	}
+	right, ok := tableCondition(columns, table, condition.Right)
+	if !ok {
+		return nil, false
+	}
+	copied.Left, copied.Right = left, right
+
+	return &copied, true
+}
+
+// conjuncts возвращает условия, которые связаны с корнем условия через AND
+func conjuncts(condition *ast.WhereClause) []*ast.WhereClause {
+	if condition.Token != nil && condition.Token.Kind == lex.LogicalOperatorToken && condition.Token.Value == string(lex.AndOperator) {
+		return append(conjuncts(condition.Left), conjuncts(condition.Right)...)
+	}
+
+	return []*ast.WhereClause{condition}
+}
+
+// joinConditions связывает условия через AND, nil - условий нет
+func joinConditions(conditions []*ast.WhereClause) *ast.WhereClause {
+	if len(conditions) == 0 {
+		return nil
+	}
+
+	condition := conditions[0]
+	for _, next := range conditions[1:] {
+		condition = &ast.WhereClause{
+			Token: &lex.Token{Kind: lex.LogicalOperatorToken, Value: string(lex.AndOperator)},
+			Left:  condition,
+			Right: next,
+		}
+	}
+
+	return condition
+}
+
+// joinKeys выделяет из условия ON равенства колонки левой стороны с колонкой таблицы справа того же типа.
+// Таблица справа занимает в соединенной строке width колонок с offset. Остальные условия, связанные
+// с равенствами через AND, возвращаются одним условием
+func joinKeys(columns []models.Column, on *ast.WhereClause, offset, width int) ([]int, []int, *ast.WhereClause) {
+	var leftKeys, rightKeys []int
+	var rest []*ast.WhereClause
+	for _, condition := range conjuncts(on) {
+		if condition.Token.Value == string(lex.EqualOperator) && condition.Left.Token.Kind == lex.IdentifierToken && condition.Right.Token.Kind == lex.IdentifierToken {
+			left := findColumnIndex(columns, condition.Left.Token.Value)
+			right := findColumnIndex(columns, condition.Right.Token.Value)
+			if left >= offset {
+				left, right = right, left
+			}
+
+			if left < offset && right >= offset && right < offset+width && columns[left].Type == columns[right].Type {
+				leftKeys = append(leftKeys, left)
+				rightKeys = append(rightKeys, right-offset)
+				continue
+			}
+		}
+
+		rest = append(rest, condition)
+	}
+
+	return leftKeys, rightKeys, joinConditions(rest)
+}
+
+// joinedRow собирает соединенную строку: левую строку, строку таблицы и общие колонки USING.
+// Отсутствующая сторона внешнего соединения заполняется NULL
+func (step *joinStep) joinedRow(left, right []interface{}) []interface{} {
+	row := make([]interface{}, 0, len(step.columns))
+	if left == nil {
+		left = make([]interface{}, step.table.offset)
+	}
+	if right == nil {
+		right = make([]interface{}, len(step.table.columns))
+	}
+	row = append(append(row, left...), right...)
+
+	for _, columns := range step.using {
+		value := row[columns[0]]
+		if value == nil {
+			value = row[columns[1]]
+		}
+		row = append(row, value)
+	}
+
+	return row
+}
+
+// keepsLeft - левые строки без пары остаются в результате с NULL справа, keepsRight - наоборот
+func (step *joinStep) keepsLeft() bool {
+	return step.kind == ast.LeftJoin || step.kind == ast.FullJoin
+}
+
+func (step *joinStep) keepsRight() bool {
+	return step.kind == ast.RightJoin || step.kind == ast.FullJoin
+}
+
+// appendUnmatched добавляет строки таблицы справа, которые ни с чем не соединились, если их нужно сохранить
+func (step *joinStep) appendUnmatched(rows [][]interface{}, rightRows [][]interface{}, matched []bool) [][]interface{} {
+	if !step.keepsRight() {
+		return rows
+	}
+
+	for i, right := range rightRows {
+		if !matched[i] {
+			rows = append(rows, step.joinedRow(nil, right))
+		}
+	}
+
+	return rows
+}
+
+// nestedLoopJoin проверяет условие на каждой паре строк. Строки таблицы справа держатся в памяти
+func (mb *memoryBackend) nestedLoopJoin(step *joinStep, left, right storage.RowIterator) ([][]interface{}, error) {
+	rightRows, err := collectRows(right)
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]bool, len(rightRows))
+
+	rows := [][]interface{}{}
+	for left.Next() {
+		leftRow := left.Row()
+
+		found := false
+		for i, rightRow := range rightRows {
+			row := step.joinedRow(leftRow, rightRow)
+			if !mb.filterRow(step.columns, row, step.condition) {
+				continue
+			}
+
+			rows = append(rows, row)
+			found, matched[i] = true, true
+		}
+
+		if !found && step.keepsLeft() {
+			rows = append(rows, step.joinedRow(leftRow, nil))
+		}
+	}
+	if err := left.Err(); err != nil {
+		return nil, err
+	}
+
+	return step.appendUnmatched(rows, rightRows, matched), nil
+}
+
+// hashJoin раскладывает строки таблицы справа по значениям ключей в хеш-таблицу в памяти и ищет
+// в ней пару каждой левой строке
+func (mb *memoryBackend) hashJoin(step *joinStep, left, right storage.RowIterator) ([][]interface{}, error) {
+	rightRows := [][]interface{}{}
+	buckets := map[string][]int{}
+	for right.Next() {
+		row := right.Row()
+		rightRows = append(rightRows, row)
+
+		if key, ok := joinKey(row, step.rightKeys); ok {
+			buckets[key] = append(buckets[key], len(rightRows)-1)
+		}
+	}
+	if err := right.Err(); err != nil {
+		return nil, err
+	}
+	matched := make([]bool, len(rightRows))
+
+	rows := [][]interface{}{}
+	for left.Next() {
+		leftRow := left.Row()
+
+		found := false
+		if key, ok := joinKey(leftRow, step.leftKeys); ok {
+			for _, i := range buckets[key] {
+				row := step.joinedRow(leftRow, rightRows[i])
+				if !mb.filterRow(step.columns, row, step.condition) {
+					continue
+				}
+
+				rows = append(rows, row)
+				found, matched[i] = true, true
+			}
+		}
+
+		if !found && step.keepsLeft() {
+			rows = append(rows, step.joinedRow(leftRow, nil))
+		}
+	}
+	if err := left.Err(); err != nil {
+		return nil, err
+	}
+
+	return step.appendUnmatched(rows, rightRows, matched), nil
+}
+
+// mergeJoin сливает стороны, отсортированные по ключам: в памяти держатся только строки таблицы
+// справа с одним значением ключа. Строки с NULL в ключе ни с чем не соединяются
+func (mb *memoryBackend) mergeJoin(step *joinStep, left, right storage.RowIterator) ([][]interface{}, error) {
+	rows := [][]interface{}{}
+
+	leftRow, leftOk := nextRow(left)
+	rightRow, rightOk := nextRow(right)
+	for leftOk || rightOk {
+		_, leftKeyed := joinKey(leftRow, step.leftKeys)
+		_, rightKeyed := joinKey(rightRow, step.rightKeys)
+
+		cmp := 0
+		switch {
+		case !rightOk || (leftOk && !leftKeyed):
+			cmp = -1
+		case !leftOk || !rightKeyed:
+			cmp = 1
+		default:
+			cmp = compareKeys(leftRow, step.leftKeys, rightRow, step.rightKeys)
+		}
+
+		if cmp < 0 {
+			if step.keepsLeft() {
+				rows = append(rows, step.joinedRow(leftRow, nil))
+			}
+			leftRow, leftOk = nextRow(left)
+			continue
+		}
+		if cmp > 0 {
+			if step.keepsRight() {
+				rows = append(rows, step.joinedRow(nil, rightRow))
+			}
+			rightRow, rightOk = nextRow(right)
+			continue
+		}
+
+		// Строки справа с тем же ключом соединяются со всеми левыми строками с этим ключом
+		group := [][]interface{}{rightRow}
+		for {
+			rightRow, rightOk = nextRow(right)
+			if !rightOk {
+				break
+			}
+			if _, ok := joinKey(rightRow, step.rightKeys); !ok || compareKeys(rightRow, step.rightKeys, group[0], step.rightKeys) != 0 {
+				break
+			}
+			group = append(group, rightRow)
+		}
+		matched := make([]bool, len(group))
+
+		for leftOk {
+			if _, ok := joinKey(leftRow, step.leftKeys); !ok || compareKeys(leftRow, step.leftKeys, group[0], step.rightKeys) != 0 {
+				break
+			}
+
+			found := false
+			for i, groupRow := range group {
+				row := step.joinedRow(leftRow, groupRow)
+				if !mb.filterRow(step.columns, row, step.condition) {
+					continue
+				}
+
+				rows = append(rows, row)
+				found, matched[i] = true, true
+			}
+			if !found && step.keepsLeft() {
+				rows = append(rows, step.joinedRow(leftRow, nil))
+			}
+
+			leftRow, leftOk = nextRow(left)
+		}
+
+		rows = step.appendUnmatched(rows, group, matched)
+	}
+	if err := left.Err(); err != nil {
+		return nil, err
+	}
+	if err := right.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func nextRow(it storage.RowIterator) ([]interface{}, bool) {
+	if !it.Next() {
+		return nil, false
+	}
+
+	return it.Row(), true
+}
+
+func collectRows(it storage.RowIterator) ([][]interface{}, error) {
+	rows := [][]interface{}{}
+	for it.Next() {
+		rows = append(rows, it.Row())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// joinKey кодирует значения ключей строки. false - в ключе есть NULL или строки нет
+func joinKey(row []interface{}, keys []int) (string, bool) {
+	if row == nil {
+		return "", false
+	}
+
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		if row[key] == nil {
+			return "", false
+		}
+		values[i] = row[key]
+	}
+
+	return encodeValues(values), true
+}
+
+// compareKeys сравнивает ключи двух строк без NULL по колонкам слева направо
+func compareKeys(a []interface{}, aKeys []int, b []interface{}, bKeys []int) int {
+	for i := range aKeys {
+		if cmp := storage.CompareValues(a[aKeys[i]], b[bKeys[i]]); cmp != 0 {
+			return cmp
+		}
+	}
+
+	return 0
+}
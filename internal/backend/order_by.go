@@ -0,0 +1,96 @@
+package backend
+
+import (
+	"custom-database/internal/models"
+	"custom-database/internal/parser/ast"
+	"custom-database/internal/storage"
+	"fmt"
+)
+
+// orderKey - ключ ORDER BY: номер колонки в строке таблицы и порядок значений
+type orderKey struct {
+	column     int
+	desc       bool
+	nullsFirst bool
+}
+
+// orderByAliases заменяет ключи ORDER BY, совпадающие с AS колонки из списка SELECT, на саму колонку.
+// В запросе без группировки строки упорядочиваются до выбора колонок результата, поэтому ключ по AS
+// сортирует по колонке строки FROM и может использовать индекс
+func orderByAliases(statement *ast.SelectStatement) []*ast.OrderByClause {
+	orderBy := make([]*ast.OrderByClause, len(statement.OrderBy))
+	for i, clause := range statement.OrderBy {
+		orderBy[i] = clause
+		for _, exp := range statement.SelectedColumns {
+			if exp.Alias == nil || exp.Alias.Value != clause.Column.Value || exp.Kind != ast.LiteralKind {
+				continue
+			}
+
+			copied := *clause
+			copied.Column = *exp.Literal
+			orderBy[i] = &copied
+			break
+		}
+	}
+
+	return orderBy
+}
+
+// orderKeys находит колонки ключей ORDER BY в строке таблицы
+func orderKeys(columns []models.Column, orderBy []*ast.OrderByClause) ([]orderKey, error) {
+	keys := make([]orderKey, len(orderBy))
+	for i, clause := range orderBy {
+		column := findColumnIndex(columns, clause.Column.Value)
+		if column == -1 {
+			return nil, fmt.Errorf("column not found: %s", clause.Column.Value)
+		}
+
+		keys[i] = orderKey{column: column, desc: clause.Desc, nullsFirst: clause.NullsFirst}
+	}
+
+	return keys, nil
+}
+
+// compareRows возвращает сравнение строк по ключам: при равенстве первого ключа решает второй и т.д.
+// Значения сравниваются по типу колонки, а NULL идет до или после всех значений по nullsFirst
+// независимо от направления
+func compareRows(keys []orderKey) func(a, b []interface{}) int {
+	return func(a, b []interface{}) int {
+		for _, key := range keys {
+			x, y := a[key.column], b[key.column]
+
+			switch {
+			case x == nil && y == nil:
+				continue
+			case x == nil || y == nil:
+				if (x == nil) == key.nullsFirst {
+					return -1
+				}
+				return 1
+			}
+
+			cmp := storage.CompareValues(x, y)
+			if key.desc {
+				cmp = -cmp
+			}
+			if cmp != 0 {
+				return cmp
+			}
+		}
+
+		return 0
+	}
+}
+
+// rowSize оценивает, сколько байт памяти занимает строка: по интерфейсу на значение и байты строк
+func rowSize(row []interface{}) int {
+	size := 24
+	for _, value := range row {
+		size += 16
+		if text, ok := value.(string); ok {
+			size += len(text)
+		}
+	}
+
+	return size
+}
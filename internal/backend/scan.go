@@ -0,0 +1,245 @@
+package backend
+
+import (
+	"custom-database/internal/models"
+	"custom-database/internal/parser/ast"
+	"custom-database/internal/parser/lex"
+	"custom-database/internal/storage"
+	"slices"
+)
+
+// columnRange - диапазон значений одной колонки, который следует из условий WHERE.
+// Границы содержат по одному значению
+type columnRange struct {
+	from, to *storage.IndexBound
+}
+
+// scanTable возвращает строки таблицы, среди которых есть все подходящие под WHERE.
+// Если в WHERE через AND связаны условия col = значение, col > значение или col < значение
+// по первым колонкам индекса, строки читаются через индекс, иначе - сканом всей таблицы.
+// Условие WHERE целиком проверяет вызывающий код. Для orderBy предпочитается индекс, который
+// отдает строки в нужном порядке, - тогда scanTable возвращает true и сортировать строки не нужно
+func (mb *memoryBackend) scanTable(tx storage.Transaction, tableName string, columns []models.Column, where *ast.WhereClause, orderBy []*ast.OrderByClause) (storage.RowIterator, bool, error) {
+	if where == nil && len(orderBy) == 0 {
+		it, err := tx.Scan(tableName)
+		return it, false, err
+	}
+
+	indexes, err := tx.GetTableIndexes(tableName)
+	if err != nil {
+		return nil, false, err
+	}
+
+	ranges := conditionRanges(columns, where)
+
+	bestScore, bestOrdered, bestDesc := 0, false, false
+	var best storage.Index
+	var from, to *storage.IndexBound
+	for _, index := range indexes {
+		score, equal, indexFrom, indexTo := indexBounds(index, ranges)
+		ordered, desc := indexOrder(index, equal, orderBy)
+		if score == 0 && !ordered {
+			continue
+		}
+
+		if best.Name == "" || score > bestScore || (score == bestScore && ordered && !bestOrdered) {
+			bestScore, bestOrdered, bestDesc, best, from, to = score, ordered, desc, index, indexFrom, indexTo
+		}
+	}
+	if best.Name == "" {
+		it, err := tx.Scan(tableName)
+		return it, false, err
+	}
+
+	it, err := tx.IndexScan(tableName, best.Name, from, to, bestOrdered && bestDesc)
+	return it, bestOrdered, err
+}
+
+// indexOrder сообщает, отдает ли индекс строки в порядке orderBy и нужен ли для этого обратный обход.
+// Колонки с равенством одинаковы у всех строк диапазона, поэтому ключи по ним не влияют на порядок,
+// а остальные ключи должны идти по следующим колонкам индекса в одном направлении. NULL в индексе
+// больше любого значения, поэтому у этих ключей NULL должен идти последним по возрастанию
+// и первым по убыванию
+func indexOrder(index storage.Index, equal int, orderBy []*ast.OrderByClause) (bool, bool) {
+	if len(orderBy) == 0 {
+		return false, false
+	}
+
+	next := equal
+	desc, directed := false, false
+	for _, key := range orderBy {
+		if slices.Contains(index.Columns[:equal], key.Column.Value) {
+			continue
+		}
+
+		if next >= len(index.Columns) || index.Columns[next] != key.Column.Value {
+			return false, false
+		}
+		next++
+
+		if key.NullsFirst != key.Desc {
+			return false, false
+		}
+		if !directed {
+			desc, directed = key.Desc, true
+		} else if key.Desc != desc {
+			return false, false
+		}
+	}
+
+	return true, desc
+}
+
+// conditionRanges собирает диапазоны значений колонок из условий WHERE, связанных через AND
+func conditionRanges(columns []models.Column, where *ast.WhereClause) map[string]*columnRange {
+	ranges := map[string]*columnRange{}
+	if where == nil {
+		return ranges
+	}
+
+	for _, condition := range andConditions(where) {
+		columnName, operator, literal, ok := splitCondition(condition)
+		if !ok {
+			continue
+		}
+
+		column := findColumnIndex(columns, columnName)
+		if column == -1 {
+			continue
+		}
+
+		value, err := tokenToValue(columns[column], literal)
+		if err != nil || value == nil {
+			continue
+		}
+
+		r, ok := ranges[columnName]
+		if !ok {
+			r = &columnRange{}
+			ranges[columnName] = r
+		}
+		r.add(operator, value)
+	}
+
+	return ranges
+}
+
+// indexBounds строит границы IndexScan по индексу: колонки с равенством, начиная с первой,
+// и диапазон на следующей за ними колонке. Оценка тем выше, чем больше колонок ограничено,
+// равенство ценится выше диапазона. Оценка 0 - условия не сужают индекс. Второе значение -
+// количество колонок с равенством
+func indexBounds(index storage.Index, ranges map[string]*columnRange) (int, int, *storage.IndexBound, *storage.IndexBound) {
+	score := 0
+	prefix := []interface{}{}
+	var last *columnRange
+	for _, columnName := range index.Columns {
+		r, ok := ranges[columnName]
+		if !ok {
+			break
+		}
+		if !r.isEquality() {
+			last = r
+			break
+		}
+
+		score += 2
+		prefix = append(prefix, r.from.Values[0])
+	}
+
+	bound := func(b *storage.IndexBound) *storage.IndexBound {
+		if b == nil {
+			if len(prefix) == 0 {
+				return nil
+			}
+			return &storage.IndexBound{Values: prefix, Inclusive: true}
+		}
+
+		values := append(append([]interface{}{}, prefix...), b.Values...)
+		return &storage.IndexBound{Values: values, Inclusive: b.Inclusive}
+	}
+
+	if last == nil {
+		return score, len(prefix), bound(nil), bound(nil)
+	}
+
+	return score + 1, len(prefix), bound(last.from), bound(last.to)
+}
+
+// andConditions возвращает сравнения, которые связаны с корнем WHERE только через AND:
+// каждое из них должно выполняться для подходящей строки. Сравнения с подзапросами не берутся:
+// их значения известны только при проверке строки
+func andConditions(where *ast.WhereClause) []*ast.WhereClause {
+	if where.Token.Kind == lex.LogicalOperatorToken {
+		if where.Token.Value == string(lex.AndOperator) {
+			return append(andConditions(where.Left), andConditions(where.Right)...)
+		}
+		return nil
+	}
+
+	if where.Token.Kind == lex.MathOperatorToken && where.Left.Subquery == nil && where.Right.Subquery == nil {
+		return []*ast.WhereClause{where}
+	}
+
+	return nil
+}
+
+// splitCondition разбирает сравнение колонки с литералом. Для литерала слева оператор
+// переворачивается, чтобы колонка всегда была слева: 5 < id - то же, что id > 5
+func splitCondition(condition *ast.WhereClause) (string, string, *lex.Token, bool) {
+	left, right := condition.Left.Token, condition.Right.Token
+	operator := condition.Token.Value
+
+	if left.Kind != lex.IdentifierToken && right.Kind == lex.IdentifierToken {
+		left, right = right, left
+		switch operator {
+		case string(lex.GreaterThanOperator):
+			operator = string(lex.LessThanOperator)
+		case string(lex.LessThanOperator):
+			operator = string(lex.GreaterThanOperator)
+		}
+	}
+
+	if left.Kind != lex.IdentifierToken || right.Kind == lex.IdentifierToken {
+		return "", "", nil, false
+	}
+
+	switch operator {
+	case string(lex.EqualOperator), string(lex.GreaterThanOperator), string(lex.LessThanOperator):
+		return left.Value, operator, right, true
+	}
+
+	return "", "", nil, false
+}
+
+// add сужает диапазон условием operator value
+func (r *columnRange) add(operator string, value interface{}) {
+	switch operator {
+	case string(lex.EqualOperator):
+		r.from = tighterBound(r.from, &storage.IndexBound{Values: []interface{}{value}, Inclusive: true}, 1)
+		r.to = tighterBound(r.to, &storage.IndexBound{Values: []interface{}{value}, Inclusive: true}, -1)
+	case string(lex.GreaterThanOperator):
+		r.from = tighterBound(r.from, &storage.IndexBound{Values: []interface{}{value}}, 1)
+	case string(lex.LessThanOperator):
+		r.to = tighterBound(r.to, &storage.IndexBound{Values: []interface{}{value}}, -1)
+	}
+}
+
+// isEquality - диапазон сужен до одного значения
+func (r *columnRange) isEquality() bool {
+	return r.from != nil && r.to != nil && r.from.Inclusive && r.to.Inclusive &&
+		storage.CompareValues(r.from.Values[0], r.to.Values[0]) == 0
+}
+
+// tighterBound выбирает из двух границ более узкую: для нижней границы direction = 1, для верхней -1
+func tighterBound(current, bound *storage.IndexBound, direction int) *storage.IndexBound {
+	if current == nil {
+		return bound
+	}
+
+	cmp := storage.CompareValues(bound.Values[0], current.Values[0]) * direction
+	if cmp > 0 || (cmp == 0 && !bound.Inclusive) {
+		return bound
+	}
+
+	return current
+}
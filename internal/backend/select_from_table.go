@@ -2,77 +2,197 @@ package backend
 
 import (
 	"bytes"
+	"custom-database/internal/data_structures/external_sort"
 	"custom-database/internal/models"
 	"custom-database/internal/parser/ast"
+	"custom-database/internal/storage"
 	"encoding/binary"
 	"fmt"
-	"slices"
 )
 
-func (mb *memoryBackend) selectFromTable(statement *ast.SelectStatement) (*models.Table, error) {
-	table, err := mb.persistentStorage.Select(statement.From.Value)
+func (mb *memoryBackend) selectFromTable(tx storage.Transaction, statement *ast.SelectStatement) (*models.Table, error) {
+	columns, rows, err := mb.selectRows(tx, statement, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if statement.Where != nil {
-		table.Rows = mb.filterRows(table.Columns, table.Rows, statement.Where)
+	cells, err := mb.convertRowsToCells(rows, columns)
+	if err != nil {
+		return nil, err
 	}
 
-	table.Rows, table.Columns = mb.getOnlySelectedColumns(table.Rows, table.Columns, statement)
+	return &models.Table{
+		Name:    statement.From.Value,
+		Columns: columns,
+		Rows:    cells,
+	}, nil
+}
 
-	if statement.Limit != 0 {
-		table.Rows = table.Rows[:statement.Limit]
+// selectRows выполняет SELECT и возвращает колонки результата и строки значений. Из него же берут строки
+// INSERT ... SELECT, CREATE TABLE ... AS SELECT и подзапросы. outer - строка внешнего запроса
+// для подзапроса условия или списка SELECT, иначе nil
+func (mb *memoryBackend) selectRows(tx storage.Transaction, statement *ast.SelectStatement, outer *outerRow) ([]models.Column, [][]interface{}, error) {
+	source, err := mb.newSelectSource(tx, statement, outer)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if statement.Offset != 0 {
-		table.Rows = table.Rows[statement.Offset:]
+	statement, err = source.scope.qualifyStatement(statement)
+	if err != nil {
+		return nil, nil, err
 	}
+	allColumns := source.scope.columns
+	sq := mb.newSubqueries(tx, source.scope)
 
-	rows, err := mb.convertRowsToCells(table.Rows, table.Columns)
+	if isAggregateQuery(statement) {
+		return mb.selectGroups(tx, statement, source, sq)
+	}
+	statement.OrderBy = orderByAliases(statement)
+
+	keys, err := orderKeys(allColumns, statement.OrderBy)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return &models.Table{
-		Name:    table.Name,
-		Columns: table.Columns,
-		Rows:    rows,
-	}, nil
-}
+	it, ordered, err := mb.sourceRows(tx, source, statement.Where, statement.OrderBy)
+	if err != nil {
+		return nil, nil, err
+	}
 
-func (mb *memoryBackend) getOnlySelectedColumns(allRows [][]interface{}, allColumns []models.Column, statement *ast.SelectStatement) ([][]interface{}, []models.Column) {
-	rows := [][]interface{}{}
-	columns := []models.Column{}
+	selectedIndexes, columns, err := mb.getOnlySelectedColumns(source.scope, statement)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if statement.SelectedColumns != nil && len(statement.SelectedColumns) != 0 {
-		selectedColumnNames := []string{}
-		for _, value := range statement.SelectedColumns {
-			selectedColumnNames = append(selectedColumnNames, value.Literal.Value)
+	rows := [][]interface{}{}
+	skipped := 0
+	// add добавляет строку в результат с учетом OFFSET и сообщает, нужны ли еще строки.
+	// Скалярные подзапросы списка SELECT выполняются только для строк результата
+	add := func(row []interface{}) (bool, error) {
+		if skipped < statement.Offset {
+			skipped++
+			return true, nil
 		}
 
-		for _, row := range allRows {
-			resultRow := []interface{}{}
-			for i, column := range allColumns {
-				if slices.Contains(selectedColumnNames, column.Name) {
-					resultRow = append(resultRow, row[i])
+		resultRow := make([]interface{}, len(selectedIndexes))
+		for i, index := range selectedIndexes {
+			if index == -1 {
+				value, _, err := sq.scalar(statement.SelectedColumns[i].Subquery, allColumns, row)
+				if err != nil {
+					return false, err
 				}
+				resultRow[i] = value
+				continue
+			}
+			resultRow[i] = row[index]
+		}
+		rows = append(rows, resultRow)
+
+		return statement.Limit == 0 || len(rows) < statement.Limit, nil
+	}
+
+	// Строки читаются по одной: в памяти остаются только попавшие в результат. Если порядок ORDER BY
+	// не дает индекс, все подходящие строки сортируются: в памяти, пока помещаются в workMemory,
+	// а дальше - частями во временных файлах
+	var sorter *external_sort.Sorter[[]interface{}]
+	if len(keys) > 0 && !ordered {
+		sorter = external_sort.NewSorter(compareRows(keys), rowSize, mb.workMemory, "")
+		defer sorter.Close()
+	}
+	for it.Next() {
+		row := it.Row()
+
+		ok, err := sq.filterRow(allColumns, row, statement.Where)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		if sorter != nil {
+			if err := sorter.Add(row); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		more, err := add(row)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !more {
+			break
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if sorter != nil {
+		sorted, err := sorter.Sort()
+		if err != nil {
+			return nil, nil, err
+		}
+		for sorted.Next() {
+			more, err := add(sorted.Item())
+			if err != nil {
+				return nil, nil, err
+			}
+			if !more {
+				break
 			}
+		}
+		if err := sorted.Err(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := sq.fillColumns(columns, statement.SelectedColumns); err != nil {
+		return nil, nil, err
+	}
+
+	return columns, rows, nil
+}
 
-			rows = append(rows, resultRow)
+// getOnlySelectedColumns возвращает индексы выбранных колонок в строке FROM и колонки результата
+// в порядке списка SELECT, а для SELECT * - в порядке таблиц. Колонка результата называется
+// по AS, если он указан, иначе - по имени колонки без имени таблицы. Скалярный подзапрос получает
+// индекс -1: его колонку заполняет subqueries.fillColumns
+func (mb *memoryBackend) getOnlySelectedColumns(scope *fromScope, statement *ast.SelectStatement) ([]int, []models.Column, error) {
+	indexes := []int{}
+	columns := []models.Column{}
+	// В результат попадают только имя и тип: ограничения колонки клиенту не нужны
+	if len(statement.SelectedColumns) == 0 {
+		for _, i := range scope.star {
+			indexes = append(indexes, i)
+			columns = append(columns, models.Column{Name: scope.refs[i].name, Type: scope.columns[i].Type})
 		}
-		newColumns := []models.Column{}
-		for _, column := range allColumns {
-			if slices.Contains(selectedColumnNames, column.Name) {
-				newColumns = append(newColumns, column)
+	}
+	for _, value := range statement.SelectedColumns {
+		if value.Kind == ast.SubqueryKind {
+			column := models.Column{}
+			if value.Alias != nil {
+				column.Name = value.Alias.Value
 			}
+			indexes = append(indexes, -1)
+			columns = append(columns, column)
+			continue
+		}
+
+		i := findColumnIndex(scope.columns, value.Literal.Value)
+		if i == -1 {
+			return nil, nil, fmt.Errorf("column not found: %s", value.Literal.Value)
+		}
+
+		column := models.Column{Name: scope.refs[i].name, Type: scope.columns[i].Type}
+		if value.Alias != nil {
+			column.Name = value.Alias.Value
 		}
-		columns = newColumns
-	} else {
-		return allRows, allColumns
+		indexes = append(indexes, i)
+		columns = append(columns, column)
 	}
 
-	return rows, columns
+	return indexes, columns, nil
 }
 
 func (mb *memoryBackend) convertRowsToCells(rows [][]interface{}, columns []models.Column) ([][]models.Cell, error) {
@@ -89,7 +209,7 @@ func (mb *memoryBackend) convertRowsToCells(rows [][]interface{}, columns []mode
 					memoryCell = MemoryCell("null")
 				} else {
 					buf := new(bytes.Buffer)
-					err := binary.Write(buf, binary.BigEndian, int32(cell.(float64)))
+					err := binary.Write(buf, binary.BigEndian, cell.(int32))
 					if err != nil {
 						return nil, fmt.Errorf("failed to convert int: %w", err)
 					}
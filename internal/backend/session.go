@@ -0,0 +1,242 @@
+package backend
+
+import (
+	"custom-database/internal/models"
+	"custom-database/internal/parser/ast"
+	"custom-database/internal/storage"
+	"errors"
+	"fmt"
+)
+
+// Session - последовательность запросов одного клиента. Между BEGIN и COMMIT/ROLLBACK
+// все выражения выполняются в одной транзакции, вне ее каждое выражение
+// выполняется в своей неявной транзакции
+type Session interface {
+	ExecuteStatement(*ast.Ast) (*models.Result, error)
+	// Close откатывает незавершенную транзакцию
+	Close() error
+}
+
+type session struct {
+	mb *memoryBackend
+	// tx - транзакция, открытая BEGIN; nil вне явной транзакции
+	tx storage.Transaction
+}
+
+// ExecuteStatement выполняет выражения по порядку и останавливается на первой ошибке.
+// Возвращается результат последнего выражения, у которого он есть: таблица SELECT
+// или количество строк, затронутых INSERT/UPDATE/DELETE. BEGIN, COMMIT и ROLLBACK
+// своего результата не имеют и сбрасывают результат выражений до них: количество строк
+// внутри транзакции не описывает то, что она зафиксировала или отменила
+func (s *session) ExecuteStatement(a *ast.Ast) (*models.Result, error) {
+	var result *models.Result
+
+	for _, stmt := range a.Statements {
+		switch stmt.Kind {
+		case ast.BeginKind:
+			err := s.begin()
+			if err != nil {
+				return nil, err
+			}
+			result = nil
+		case ast.CommitKind:
+			err := s.commit()
+			if err != nil {
+				return nil, err
+			}
+			result = nil
+		case ast.RollbackKind:
+			err := s.rollback()
+			if err != nil {
+				return nil, err
+			}
+			result = nil
+		case ast.SelectKind:
+			var table *models.Table
+			err := s.read(func(tx storage.Transaction) error {
+				var err error
+				table, err = s.mb.selectFromTable(tx, stmt.SelectStatement)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			result = &models.Result{Table: table}
+		default:
+			var statementResult *models.Result
+			err := s.write(func(tx storage.Transaction) error {
+				var err error
+				statementResult, err = s.mb.executeWrite(tx, stmt)
+				return userError(err)
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			if statementResult != nil {
+				result = statementResult
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (s *session) Close() error {
+	if s.tx == nil {
+		return nil
+	}
+
+	return s.rollback()
+}
+
+func (s *session) begin() error {
+	if s.tx != nil {
+		return fmt.Errorf("BEGIN: transaction is already in progress")
+	}
+
+	tx, err := s.mb.storage.Begin()
+	if err != nil {
+		return fmt.Errorf("BEGIN: %w", err)
+	}
+
+	s.tx = tx
+	return nil
+}
+
+func (s *session) commit() error {
+	if s.tx == nil {
+		return fmt.Errorf("COMMIT: no transaction in progress")
+	}
+	tx := s.tx
+	s.tx = nil
+
+	err := tx.Commit()
+	if err != nil {
+		return fmt.Errorf("COMMIT: %w", err)
+	}
+
+	return nil
+}
+
+func (s *session) rollback() error {
+	if s.tx == nil {
+		return fmt.Errorf("ROLLBACK: no transaction in progress")
+	}
+	tx := s.tx
+	s.tx = nil
+
+	err := tx.Rollback()
+	if err != nil {
+		return fmt.Errorf("ROLLBACK: %w", err)
+	}
+
+	return nil
+}
+
+// read выполняет чтение в открытой транзакции или в неявной транзакции из одного выражения.
+// Ошибка чтения открытую транзакцию не прерывает
+func (s *session) read(fn func(tx storage.Transaction) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+
+	return s.implicit(fn)
+}
+
+// write выполняет изменение в открытой транзакции или в неявной транзакции из одного выражения.
+// Ошибка внутри открытой транзакции откатывает ее целиком
+func (s *session) write(fn func(tx storage.Transaction) error) error {
+	if s.tx == nil {
+		return s.implicit(fn)
+	}
+
+	err := fn(s.tx)
+	if err != nil {
+		rollbackErr := s.rollback()
+		if rollbackErr != nil {
+			return fmt.Errorf("%w; %w", err, rollbackErr)
+		}
+		return fmt.Errorf("%w; transaction is rolled back", err)
+	}
+
+	return nil
+}
+
+// implicit выполняет одно выражение в своей транзакции: фиксирует ее при успехе и откатывает при ошибке
+func (s *session) implicit(fn func(tx storage.Transaction) error) error {
+	err := s.begin()
+	if err != nil {
+		return err
+	}
+
+	err = fn(s.tx)
+	if err != nil {
+		rollbackErr := s.rollback()
+		if rollbackErr != nil {
+			return fmt.Errorf("%w; %w", err, rollbackErr)
+		}
+		return err
+	}
+
+	return s.commit()
+}
+
+// userError оставляет от ошибки нарушения ограничения, конфликта записи или ограничения движка только ее текст без цепочки
+// вызовов движка: его увидит пользователь в консоли и в SqlQueryResponse.Error
+func userError(err error) error {
+	var constraintErr *storage.ConstraintError
+	if errors.As(err, &constraintErr) {
+		return constraintErr
+	}
+	var serializationErr *storage.SerializationError
+	if errors.As(err, &serializationErr) {
+		return serializationErr
+	}
+	var limitErr *storage.LimitError
+	if errors.As(err, &limitErr) {
+		return limitErr
+	}
+
+	return err
+}
+
+// executeWrite выполняет изменяющее выражение. Для DDL результата нет
+func (mb *memoryBackend) executeWrite(tx storage.Transaction, stmt *ast.Statement) (*models.Result, error) {
+	switch stmt.Kind {
+	case ast.CreateTableKind:
+		return nil, mb.createTable(tx, stmt.CreateTableStatement)
+	case ast.DropTableKind:
+		return nil, mb.dropTable(tx, stmt.DropTableStatement)
+	case ast.CreateIndexKind:
+		return nil, mb.createIndex(tx, stmt.CreateIndexStatement)
+	case ast.DropIndexKind:
+		return nil, mb.dropIndex(tx, stmt.DropIndexStatement)
+	case ast.AlterTableKind:
+		return nil, mb.alterTable(tx, stmt.AlterTableStatement)
+	case ast.InsertKind:
+		inserted, err := mb.insertIntoTable(tx, stmt.InsertStatement)
+		if err != nil {
+			return nil, err
+		}
+
+		return &models.Result{Command: models.InsertCommand, RowsAffected: inserted}, nil
+	case ast.DeleteKind:
+		deleted, err := mb.deleteFromTable(tx, stmt.DeleteStatement)
+		if err != nil {
+			return nil, err
+		}
+
+		return &models.Result{Command: models.DeleteCommand, RowsAffected: deleted}, nil
+	case ast.UpdateKind:
+		updated, err := mb.updateTable(tx, stmt.UpdateStatement)
+		if err != nil {
+			return nil, err
+		}
+
+		return &models.Result{Command: models.UpdateCommand, RowsAffected: updated}, nil
+	}
+
+	return nil, fmt.Errorf("unknown statement kind: %d", stmt.Kind)
+}
@@ -0,0 +1,515 @@
+package backend
+
+import (
+	"custom-database/internal/models"
+	"custom-database/internal/parser/ast"
+	"custom-database/internal/parser/lex"
+	"custom-database/internal/storage"
+	"fmt"
+	"strconv"
+)
+
+// outerRow - строка внешнего запроса, которую видит подзапрос. Колонка условия подзапроса, которой нет
+// в его FROM, ищется в scope внешнего запроса, а ее значение берется из row с колонками columns:
+// строки FROM или строки группы. parent - строка запроса, внешнего уже для внешнего запроса
+type outerRow struct {
+	scope   *fromScope
+	columns []models.Column
+	// row - nil, если подзапрос выполняется без строки внешнего запроса: его колонки тогда равны NULL
+	row    []interface{}
+	parent *outerRow
+	// correlated - подзапрос сослался на колонку этой строки, и его результат от нее зависит
+	correlated bool
+}
+
+// lookup находит колонку в ближайшем внешнем запросе, к FROM которого она относится, и возвращает
+// ее значение литералом. false - колонки нет ни в одном внешнем запросе
+func (o *outerRow) lookup(token *lex.Token) (*lex.Token, bool, error) {
+	for ; o != nil; o = o.parent {
+		if !o.scope.declares(token.Value) {
+			continue
+		}
+
+		i, err := o.scope.resolve(token.Value)
+		if err != nil {
+			return nil, false, err
+		}
+		if i == -1 {
+			return nil, false, fmt.Errorf("column not found: %s", token.Value)
+		}
+		o.correlated = true
+
+		column := o.scope.columns[i]
+		var value interface{}
+		if o.row != nil {
+			j := findColumnIndex(o.columns, column.Name)
+			if j == -1 {
+				return nil, false, fmt.Errorf("column %s must appear in the GROUP BY clause or be used in an aggregate function", token.Value)
+			}
+			value = o.row[j]
+		}
+
+		return valueToken(value, column.Type, token.Loc), true, nil
+	}
+
+	return nil, false, nil
+}
+
+// valueToken записывает значение колонки литералом, который условие сравнивает так же, как колонку
+func valueToken(value interface{}, columnType models.ColumnType, loc lex.Location) *lex.Token {
+	token := &lex.Token{Loc: loc}
+	switch value := value.(type) {
+	case nil:
+		token.Kind, token.Value = lex.NullToken, string(lex.NullValueKeyword)
+	case int32:
+		token.Kind, token.Value = lex.NumericToken, strconv.Itoa(int(value))
+	case bool:
+		token.Kind, token.Value = lex.BooleanToken, strconv.FormatBool(value)
+	case string:
+		token.Kind, token.Value = lex.StringToken, value
+		if columnType == models.TimestampType {
+			token.Kind = lex.DateToken
+		}
+	}
+
+	return token
+}
+
+// subqueries выполняет подзапросы условий и списка SELECT во время одного выполнения запроса.
+// Подзапрос выполняется для строки запроса, а результат запоминается, если подзапрос на нее
+// не сослался. [NOT] IN и [NOT] EXISTS, связанные с запросом только равенствами колонок,
+// выполняются один раз как полусоединение
+type subqueries struct {
+	mb    *memoryBackend
+	tx    storage.Transaction
+	scope *fromScope
+	// results - результаты подзапросов, которые не зависят от строки запроса
+	results map[*ast.SelectStatement]*subqueryResult
+	// columns - колонки результатов скалярных подзапросов, которые уже выполнялись
+	columns map[*ast.SelectStatement]models.Column
+	// semiJoins - полусоединения подзапросов IN и EXISTS, nil - подзапрос выполняется для каждой строки
+	semiJoins map[*ast.Subquery]*semiJoin
+}
+
+type subqueryResult struct {
+	columns []models.Column
+	rows    [][]interface{}
+}
+
+func (mb *memoryBackend) newSubqueries(tx storage.Transaction, scope *fromScope) *subqueries {
+	return &subqueries{
+		mb:        mb,
+		tx:        tx,
+		scope:     scope,
+		results:   map[*ast.SelectStatement]*subqueryResult{},
+		columns:   map[*ast.SelectStatement]models.Column{},
+		semiJoins: map[*ast.Subquery]*semiJoin{},
+	}
+}
+
+// filterRow проверяет строку условием так же, как memoryBackend.filterRow, и выполняет подзапросы условия
+func (sq *subqueries) filterRow(columns []models.Column, row []interface{}, where *ast.WhereClause) (bool, error) {
+	if !hasSubquery(where) {
+		return sq.mb.filterRow(columns, row, where), nil
+	}
+	if where.Subquery != nil {
+		return sq.predicate(columns, row, where)
+	}
+
+	switch where.Token.Kind {
+	case lex.LogicalOperatorToken:
+		left, err := sq.filterRow(columns, row, where.Left)
+		if err != nil {
+			return false, err
+		}
+		if where.Token.Value == string(lex.AndOperator) && !left {
+			return false, nil
+		}
+		if where.Token.Value == string(lex.OrOperator) && left {
+			return true, nil
+		}
+
+		return sq.filterRow(columns, row, where.Right)
+	case lex.MathOperatorToken:
+		left, ok, err := sq.operand(columns, row, where.Left)
+		if err != nil || !ok {
+			return false, err
+		}
+		right, ok, err := sq.operand(columns, row, where.Right)
+		if err != nil || !ok {
+			return false, err
+		}
+
+		return sq.mb.evaluateCondition(left, right, where.Token.Value), nil
+	}
+
+	return false, nil
+}
+
+// hasSubquery - в условии есть подзапрос
+func hasSubquery(condition *ast.WhereClause) bool {
+	return condition != nil && (condition.Subquery != nil || hasSubquery(condition.Left) || hasSubquery(condition.Right))
+}
+
+// operand возвращает значение операнда сравнения: колонки, литерала или скалярного подзапроса.
+// false - колонки нет в строке, и строка, как в filterRow, не подходит под условие
+func (sq *subqueries) operand(columns []models.Column, row []interface{}, operand *ast.WhereClause) (interface{}, bool, error) {
+	if operand.Subquery != nil {
+		value, _, err := sq.scalar(operand.Subquery.Select, columns, row)
+		return value, err == nil, err
+	}
+
+	value, err := sq.mb.getValueFromExpression(columns, row, operand)
+	return value, err == nil, nil
+}
+
+// run выполняет подзапрос для строки row с колонками columns. limit - сколько строк результата
+// достаточно, 0 - все
+func (sq *subqueries) run(statement *ast.SelectStatement, columns []models.Column, row []interface{}, limit int) (*subqueryResult, error) {
+	if result, ok := sq.results[statement]; ok {
+		return result, nil
+	}
+
+	limited := *statement
+	if limit > 0 && (limited.Limit == 0 || limited.Limit > limit) {
+		limited.Limit = limit
+	}
+
+	outer := &outerRow{scope: sq.scope, columns: columns, row: row, parent: sq.scope.outer}
+	resultColumns, rows, err := sq.mb.selectRows(sq.tx, &limited, outer)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &subqueryResult{columns: resultColumns, rows: rows}
+	if !outer.correlated {
+		sq.results[statement] = result
+	}
+
+	return result, nil
+}
+
+// scalar возвращает значение скалярного подзапроса - единственной колонки единственной строки
+// или NULL, если строк нет, - и колонку его результата
+func (sq *subqueries) scalar(statement *ast.SelectStatement, columns []models.Column, row []interface{}) (interface{}, models.Column, error) {
+	result, err := sq.run(statement, columns, row, 2)
+	if err != nil {
+		return nil, models.Column{}, err
+	}
+	if len(result.columns) != 1 {
+		return nil, models.Column{}, fmt.Errorf("subquery must return only one column")
+	}
+	if len(result.rows) > 1 {
+		return nil, models.Column{}, fmt.Errorf("more than one row returned by a subquery used as an expression")
+	}
+
+	column := models.Column{Name: result.columns[0].Name, Type: result.columns[0].Type}
+	sq.columns[statement] = column
+	if len(result.rows) == 0 {
+		return nil, column, nil
+	}
+
+	return result.rows[0][0], column, nil
+}
+
+// scalarColumn возвращает колонку результата скалярного подзапроса из списка SELECT. Если запрос
+// не вернул строк и подзапрос ни разу не выполнялся, он выполняется без строки запроса
+func (sq *subqueries) scalarColumn(statement *ast.SelectStatement) (models.Column, error) {
+	if column, ok := sq.columns[statement]; ok {
+		return column, nil
+	}
+
+	result, err := sq.run(statement, nil, nil, 2)
+	if err != nil {
+		return models.Column{}, err
+	}
+	if len(result.columns) != 1 {
+		return models.Column{}, fmt.Errorf("subquery must return only one column")
+	}
+
+	return models.Column{Name: result.columns[0].Name, Type: result.columns[0].Type}, nil
+}
+
+// fillColumns заполняет колонки результата скалярных подзапросов списка SELECT: тип - по результату
+// подзапроса, имя без AS - по его колонке
+func (sq *subqueries) fillColumns(columns []models.Column, selected []*ast.Expression) error {
+	for i, exp := range selected {
+		if exp.Kind != ast.SubqueryKind {
+			continue
+		}
+
+		column, err := sq.scalarColumn(exp.Subquery)
+		if err != nil {
+			return err
+		}
+		columns[i].Type = column.Type
+		if exp.Alias == nil {
+			columns[i].Name = column.Name
+		}
+	}
+
+	return nil
+}
+
+// predicate вычисляет [NOT] EXISTS и [NOT] IN. IN истинен, если значение равно одному из значений
+// подзапроса. Если равного нет, но значение или одно из значений подзапроса - NULL, результат
+// неизвестен, и строка не подходит ни под IN, ни под NOT IN. Без строк подзапроса IN ложен
+func (sq *subqueries) predicate(columns []models.Column, row []interface{}, where *ast.WhereClause) (bool, error) {
+	join, err := sq.semiJoin(where)
+	if err != nil {
+		return false, err
+	}
+
+	var found, unknown bool
+	if join != nil {
+		found, unknown, err = join.match(sq.mb, columns, row, where)
+	} else {
+		found, unknown, err = sq.match(columns, row, where)
+	}
+	if err != nil || unknown {
+		return false, err
+	}
+
+	return found != where.Subquery.Not, nil
+}
+
+// match выполняет подзапрос IN или EXISTS для строки и возвращает, нашлось ли значение или строка,
+// и неизвестен ли результат IN
+func (sq *subqueries) match(columns []models.Column, row []interface{}, where *ast.WhereClause) (bool, bool, error) {
+	if where.Subquery.Type == ast.ExistsSubquery {
+		result, err := sq.run(where.Subquery.Select, columns, row, 1)
+		if err != nil {
+			return false, false, err
+		}
+		return len(result.rows) > 0, false, nil
+	}
+
+	result, err := sq.run(where.Subquery.Select, columns, row, 0)
+	if err != nil {
+		return false, false, err
+	}
+	if len(result.columns) != 1 {
+		return false, false, fmt.Errorf("subquery has too many columns")
+	}
+	if len(result.rows) == 0 {
+		return false, false, nil
+	}
+
+	value, ok, err := sq.operand(columns, row, where.Left)
+	if err != nil || !ok {
+		return false, true, err
+	}
+	if value == nil {
+		return false, true, nil
+	}
+
+	unknown := false
+	for _, resultRow := range result.rows {
+		if resultRow[0] == nil {
+			unknown = true
+			continue
+		}
+		if sq.mb.evaluateCondition(value, resultRow[0], string(lex.EqualOperator)) {
+			return true, false, nil
+		}
+	}
+
+	return false, unknown, nil
+}
+
+// semiJoin - подзапрос IN или EXISTS, связанный с внешним запросом только равенствами колонок
+// в WHERE через AND. Он выполняется один раз без этих равенств, его строки раскладываются по значениям
+// колонок подзапроса из равенств, а строка внешнего запроса находит свою группу по значениям своих
+// колонок. Строка с NULL в этих колонках ни с какой не соединяется
+type semiJoin struct {
+	// outer - колонки внешнего запроса из равенств
+	outer  []string
+	groups map[string]*semiJoinGroup
+}
+
+// semiJoinGroup - значения колонки IN в строках подзапроса с одинаковыми колонками равенств
+type semiJoinGroup struct {
+	values map[string]struct{}
+	null   bool
+}
+
+// semiJoin возвращает полусоединение подзапроса условия или nil, если подзапрос так не выполняется
+func (sq *subqueries) semiJoin(where *ast.WhereClause) (*semiJoin, error) {
+	if join, ok := sq.semiJoins[where.Subquery]; ok {
+		return join, nil
+	}
+
+	join, err := sq.newSemiJoin(where)
+	if err != nil {
+		return nil, err
+	}
+	sq.semiJoins[where.Subquery] = join
+
+	return join, nil
+}
+
+// newSemiJoin строит полусоединение. Подзапрос с группировкой, LIMIT, OFFSET или подзапросами в FROM,
+// IN не по одной колонке того же типа, что и проверяемая колонка, и подзапрос, который ссылается
+// на внешний запрос не только равенствами, выполняются для каждой строки. EXISTS без равенств
+// не зависит от строки и без того выполняется один раз
+func (sq *subqueries) newSemiJoin(where *ast.WhereClause) (*semiJoin, error) {
+	statement := where.Subquery.Select
+	in := where.Subquery.Type == ast.InSubquery
+	if isAggregateQuery(statement) || statement.Limit > 0 || statement.Offset > 0 ||
+		statement.From.Value == "" || statement.FromSubquery != nil {
+		return nil, nil
+	}
+	for _, clause := range statement.Joins {
+		if clause.Subquery != nil {
+			return nil, nil
+		}
+	}
+	if in && (len(statement.SelectedColumns) != 1 || statement.SelectedColumns[0].Kind != ast.LiteralKind ||
+		where.Left.Token.Kind != lex.IdentifierToken) {
+		return nil, nil
+	}
+
+	source, err := sq.mb.newSelectSource(sq.tx, statement, nil)
+	if err != nil {
+		return nil, err
+	}
+	inner := source.scope
+
+	selected := []*ast.Expression{}
+	if in {
+		column, err := inner.resolve(statement.SelectedColumns[0].Literal.Value)
+		if err != nil || column == -1 {
+			return nil, nil
+		}
+		value := findColumnIndex(sq.scope.columns, where.Left.Token.Value)
+		if value == -1 || inner.columns[column].Type != sq.scope.columns[value].Type {
+			return nil, nil
+		}
+		selected = append(selected, statement.SelectedColumns[0])
+	}
+
+	join := &semiJoin{groups: map[string]*semiJoinGroup{}}
+	var rest []*ast.WhereClause
+	if statement.Where != nil {
+		for _, condition := range conjuncts(statement.Where) {
+			innerToken, outerToken, ok := correlation(inner, sq.scope, condition)
+			if !ok {
+				rest = append(rest, condition)
+				continue
+			}
+
+			innerColumn, err := inner.resolve(innerToken.Value)
+			if err != nil || innerColumn == -1 {
+				return nil, nil
+			}
+			outerColumn, err := sq.scope.resolve(outerToken.Value)
+			if err != nil || outerColumn == -1 || inner.columns[innerColumn].Type != sq.scope.columns[outerColumn].Type {
+				return nil, nil
+			}
+
+			join.outer = append(join.outer, sq.scope.columns[outerColumn].Name)
+			selected = append(selected, &ast.Expression{Kind: ast.LiteralKind, Literal: innerToken})
+		}
+	}
+	if !in && len(join.outer) == 0 {
+		return nil, nil
+	}
+
+	decorrelated := *statement
+	decorrelated.SelectedColumns = selected
+	decorrelated.Where = joinConditions(rest)
+	decorrelated.OrderBy = nil
+
+	// Подзапрос выполняется без строки внешнего запроса: если он все же сослался на нее,
+	// его результат зависит от строки, и полусоединение не подходит
+	outer := &outerRow{scope: sq.scope, parent: sq.scope.outer}
+	_, rows, err := sq.mb.selectRows(sq.tx, &decorrelated, outer)
+	if err != nil {
+		return nil, err
+	}
+	if outer.correlated {
+		return nil, nil
+	}
+
+	keys := make([]int, len(join.outer))
+	for i := range keys {
+		keys[i] = len(selected) - len(join.outer) + i
+	}
+	for _, row := range rows {
+		key, ok := joinKey(row, keys)
+		if !ok {
+			continue
+		}
+
+		group, ok := join.groups[key]
+		if !ok {
+			group = &semiJoinGroup{values: map[string]struct{}{}}
+			join.groups[key] = group
+		}
+		if !in {
+			continue
+		}
+		if row[0] == nil {
+			group.null = true
+			continue
+		}
+		group.values[encodeValues(row[:1])] = struct{}{}
+	}
+
+	return join, nil
+}
+
+// correlation разбирает равенство колонки подзапроса с колонкой внешнего запроса
+func correlation(inner, outer *fromScope, condition *ast.WhereClause) (*lex.Token, *lex.Token, bool) {
+	if condition.Token == nil || condition.Token.Kind != lex.MathOperatorToken || condition.Token.Value != string(lex.EqualOperator) ||
+		condition.Left == nil || condition.Right == nil {
+		return nil, nil, false
+	}
+
+	left, right := condition.Left.Token, condition.Right.Token
+	if left.Kind != lex.IdentifierToken || right.Kind != lex.IdentifierToken {
+		return nil, nil, false
+	}
+	if !inner.declares(left.Value) {
+		left, right = right, left
+	}
+	if !inner.declares(left.Value) || inner.declares(right.Value) || !outer.declares(right.Value) {
+		return nil, nil, false
+	}
+
+	return left, right, true
+}
+
+// match находит группу строки внешнего запроса и возвращает, нашлось ли значение или строка,
+// и неизвестен ли результат IN
+func (join *semiJoin) match(mb *memoryBackend, columns []models.Column, row []interface{}, where *ast.WhereClause) (bool, bool, error) {
+	key := make([]interface{}, len(join.outer))
+	for i, name := range join.outer {
+		column := findColumnIndex(columns, name)
+		if column == -1 {
+			return false, false, fmt.Errorf("column %s must appear in the GROUP BY clause or be used in an aggregate function", name)
+		}
+		if row[column] == nil {
+			return false, false, nil
+		}
+		key[i] = row[column]
+	}
+
+	group, ok := join.groups[encodeValues(key)]
+	if !ok {
+		return false, false, nil
+	}
+	if where.Subquery.Type == ast.ExistsSubquery {
+		return true, false, nil
+	}
+
+	value, err := mb.getValueFromExpression(columns, row, where.Left)
+	if err != nil || value == nil {
+		return false, true, nil
+	}
+	if _, ok := group.values[encodeValues([]interface{}{value})]; ok {
+		return true, false, nil
+	}
+
+	return false, group.null, nil
+}
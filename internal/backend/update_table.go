@@ -0,0 +1,205 @@
+package backend
+
+import (
+	"custom-database/internal/models"
+	"custom-database/internal/parser/ast"
+	"custom-database/internal/parser/lex"
+	"custom-database/internal/storage"
+	"fmt"
+)
+
+// updateTable обновляет строки, подходящие под WHERE, и возвращает их количество.
+// Новые значения вычисляются во время сканирования, а записываются после него:
+// строка, переехавшая в конец таблицы, не должна попасться скану второй раз. Вместе с ними
+// записываются изменения, которые следуют из внешних ключей, но в количество они не входят
+func (mb *memoryBackend) updateTable(tx storage.Transaction, statement *ast.UpdateStatement) (int, error) {
+	columns, err := tx.GetTableColumns(statement.Table.Value)
+	if err != nil {
+		return 0, err
+	}
+
+	setIndexes, err := getSetColumnIndexes(columns, statement.Set)
+	if err != nil {
+		return 0, err
+	}
+
+	it, _, err := mb.scanTable(tx, statement.Table.Value, columns, statement.Where, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	sq := mb.newSubqueries(tx, newTableScope(&fromTable{table: statement.Table.Value, name: statement.Table.Value, columns: columns}, false))
+	plan := newReferentialPlan(mb, tx)
+	count := 0
+	for it.Next() {
+		row := it.Row()
+
+		ok, err := sq.filterRow(columns, row, statement.Where)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			continue
+		}
+
+		values, err := applySetClauses(columns, row, statement.Set, setIndexes)
+		if err != nil {
+			return 0, err
+		}
+
+		plan.add(statement.Table.Value, it.RowID(), row, values)
+		count++
+	}
+	if err := it.Err(); err != nil {
+		return 0, err
+	}
+
+	if err := plan.execute(); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// getSetColumnIndexes находит индексы колонок из SET и проверяет, что каждая колонка указана один раз
+func getSetColumnIndexes(columns []models.Column, set []*ast.SetClause) ([]int, error) {
+	indexes := make([]int, len(set))
+	assigned := map[int]bool{}
+
+	for i, clause := range set {
+		index := findColumnIndex(columns, clause.Column.Value)
+		if index == -1 {
+			return nil, fmt.Errorf("column not found: %s", clause.Column.Value)
+		}
+		if assigned[index] {
+			return nil, fmt.Errorf("multiple assignments to same column: %s", clause.Column.Value)
+		}
+
+		assigned[index] = true
+		indexes[i] = index
+	}
+
+	return indexes, nil
+}
+
+// applySetClauses возвращает копию строки с новыми значениями. Значение может быть литералом,
+// DEFAULT, другой колонкой той же строки или арифметикой над INT (SET a = b + 1 берет b до обновления)
+func applySetClauses(columns []models.Column, row []interface{}, set []*ast.SetClause, setIndexes []int) ([]interface{}, error) {
+	values := append([]interface{}{}, row...)
+
+	for i, clause := range set {
+		value, err := evaluateSetValue(columns, row, columns[setIndexes[i]], clause.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		values[setIndexes[i]] = value
+	}
+
+	return values, nil
+}
+
+// evaluateSetValue вычисляет значение SET для колонки column по строке row
+func evaluateSetValue(columns []models.Column, row []interface{}, column models.Column, exp *ast.Expression) (interface{}, error) {
+	token := exp.Literal
+
+	switch {
+	case exp.Kind == ast.DefaultKind:
+		return defaultValue(column)
+	case exp.Kind == ast.ArithmeticKind:
+		if column.Type != models.IntType {
+			return nil, fmt.Errorf("column %s is of type %s but expression is of type int",
+				column.Name, columnTypeName(column.Type))
+		}
+		value, _, err := evaluateArithmetic(columns, row, exp)
+		return value, err
+	case token.Kind != lex.IdentifierToken:
+		return tokenToValue(column, token)
+	}
+
+	sourceIndex := findColumnIndex(columns, token.Value)
+	if sourceIndex == -1 {
+		return nil, fmt.Errorf("column not found: %s", token.Value)
+	}
+	if columns[sourceIndex].Type != column.Type {
+		return nil, fmt.Errorf("cannot assign column %s of type %s to column %s of type %s",
+			token.Value, columnTypeName(columns[sourceIndex].Type), column.Name, columnTypeName(column.Type))
+	}
+
+	return row[sourceIndex], nil
+}
+
+// evaluateArithmetic вычисляет операнд или операцию + - * / и возвращает значение с именем его типа.
+// Операции определены только для INT: NULL в операнде дает NULL, деление целое с отбрасыванием остатка
+func evaluateArithmetic(columns []models.Column, row []interface{}, exp *ast.Expression) (interface{}, string, error) {
+	token := exp.Literal
+
+	if exp.Kind != ast.ArithmeticKind {
+		switch token.Kind {
+		case lex.NullToken:
+			return nil, "int", nil
+		case lex.NumericToken:
+			value, err := tokenToValue(models.Column{Type: models.IntType}, token)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid input syntax for type int: %q", token.Value)
+			}
+			return value, "int", nil
+		case lex.IdentifierToken:
+			index := findColumnIndex(columns, token.Value)
+			if index == -1 {
+				return nil, "", fmt.Errorf("column not found: %s", token.Value)
+			}
+			return row[index], columnTypeName(columns[index].Type), nil
+		case lex.StringToken:
+			return nil, "text", nil
+		case lex.BooleanToken:
+			return nil, "boolean", nil
+		}
+
+		return nil, "timestamp", nil
+	}
+
+	left, leftType, err := evaluateArithmetic(columns, row, exp.Arithmetic.Left)
+	if err != nil {
+		return nil, "", err
+	}
+	right, rightType, err := evaluateArithmetic(columns, row, exp.Arithmetic.Right)
+	if err != nil {
+		return nil, "", err
+	}
+	if leftType != "int" || rightType != "int" {
+		return nil, "", fmt.Errorf("operator does not exist: %s %s %s", leftType, token.Value, rightType)
+	}
+	if left == nil || right == nil {
+		return nil, "int", nil
+	}
+
+	a, b := int64(left.(int32)), int64(right.(int32))
+	var result int64
+	switch lex.Symbol(token.Value) {
+	case lex.PlusSymbol:
+		result = a + b
+	case lex.MinusSymbol:
+		result = a - b
+	case lex.AsteriskSymbol:
+		result = a * b
+	case lex.SlashSymbol:
+		if b == 0 {
+			return nil, "", fmt.Errorf("division by zero")
+		}
+		result = a / b
+	}
+
+	value, err := toInt32(result)
+	return value, "int", err
+}
+
+func findColumnIndex(columns []models.Column, name string) int {
+	for i, column := range columns {
+		if column.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,59 @@
+package backend
+
+import (
+	"custom-database/internal/models"
+	"custom-database/internal/parser/lex"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// tokenToValue переводит литерал из запроса в значение колонки для storage
+// и проверяет, что тип литерала подходит колонке
+func tokenToValue(column models.Column, token *lex.Token) (interface{}, error) {
+	if token.Kind == lex.NullToken {
+		return nil, nil
+	}
+
+	switch column.Type {
+	case models.IntType:
+		if token.Kind == lex.NumericToken {
+			number, err := strconv.ParseInt(token.Value, 10, 32)
+			if err == nil && number >= math.MinInt32 && number <= math.MaxInt32 {
+				return int32(number), nil
+			}
+		}
+	case models.TextType:
+		if token.Kind == lex.StringToken {
+			return token.Value, nil
+		}
+	case models.BoolType:
+		if token.Kind == lex.BooleanToken {
+			return token.Value == string(lex.TrueKeyword), nil
+		}
+	case models.TimestampType:
+		if token.Kind == lex.DateToken || token.Kind == lex.StringToken {
+			if _, err := time.Parse(models.TimestampFormat, token.Value); err == nil {
+				return token.Value, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("invalid value %q for column %s of type %s", token.Value, column.Name, columnTypeName(column.Type))
+}
+
+func columnTypeName(columnType models.ColumnType) string {
+	switch columnType {
+	case models.IntType:
+		return "int"
+	case models.TextType:
+		return "text"
+	case models.BoolType:
+		return "boolean"
+	case models.TimestampType:
+		return "timestamp"
+	}
+
+	return "unknown"
+}
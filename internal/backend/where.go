@@ -5,19 +5,9 @@ import (
 	"custom-database/internal/parser/ast"
 	"custom-database/internal/parser/lex"
 	"fmt"
+	"strconv"
 )
 
-func (mb *memoryBackend) filterRows(columns []models.Column, rows [][]interface{}, whereClause *ast.WhereClause) [][]interface{} {
-	filteredRows := [][]interface{}{}
-	for _, row := range rows {
-		if mb.filterRow(columns, row, whereClause) {
-			filteredRows = append(filteredRows, row)
-		}
-	}
-
-	return filteredRows
-}
-
 func (mb *memoryBackend) filterRow(columns []models.Column, row []interface{}, whereClause *ast.WhereClause) bool {
 	if whereClause == nil {
 		return true
@@ -47,7 +37,8 @@ func (mb *memoryBackend) filterRow(columns []models.Column, row []interface{}, w
 		return mb.evaluateCondition(leftVal, rightVal, whereClause.Token.Value)
 	}
 
-	return mb.filterRow(columns, row, whereClause)
+	// Парсер пропускает в условие только сравнения, AND и OR: другой узел строке не подходит
+	return false
 }
 
 func (mb *memoryBackend) getValueFromExpression(columns []models.Column, row []interface{}, expr *ast.WhereClause) (interface{}, error) {
@@ -59,10 +50,35 @@ func (mb *memoryBackend) getValueFromExpression(columns []models.Column, row []i
 		}
 		return nil, fmt.Errorf("column not found: %s", expr.Token.Value)
 	}
+	if expr.Token.Kind == lex.NullToken {
+		return nil, nil
+	}
 	return expr.Token.Value, nil
 }
 
+// evaluateCondition сравнивает значения. INT сравнивается с числом как число, иначе значения
+// сравниваются как строки. Сравнение с NULL любым оператором неизвестно, и строка под него
+// не подходит, поэтому строки с NULL не соединяются и по условию ON
 func (mb *memoryBackend) evaluateCondition(left, right interface{}, operator string) bool {
+	if left == nil || right == nil {
+		return false
+	}
+
+	if leftNumber, rightNumber, ok := asNumbers(left, right); ok {
+		switch operator {
+		case string(lex.EqualOperator):
+			return leftNumber == rightNumber
+		case string(lex.NotEqualOperator):
+			return leftNumber != rightNumber
+		case string(lex.GreaterThanOperator):
+			return leftNumber > rightNumber
+		case string(lex.LessThanOperator):
+			return leftNumber < rightNumber
+		default:
+			return false
+		}
+	}
+
 	switch operator {
 	case string(lex.EqualOperator):
 		return fmt.Sprintf("%v", left) == fmt.Sprintf("%v", right)
@@ -76,3 +92,35 @@ func (mb *memoryBackend) evaluateCondition(left, right interface{}, operator str
 		return false
 	}
 }
+
+// asNumbers возвращает оба значения как числа, если одно из них INT, а другое - INT или числовой литерал
+func asNumbers(left, right interface{}) (int64, int64, bool) {
+	_, leftInt := left.(int32)
+	_, rightInt := right.(int32)
+	if !leftInt && !rightInt {
+		return 0, 0, false
+	}
+
+	leftNumber, ok := asNumber(left)
+	if !ok {
+		return 0, 0, false
+	}
+	rightNumber, ok := asNumber(right)
+	if !ok {
+		return 0, 0, false
+	}
+
+	return leftNumber, rightNumber, true
+}
+
+func asNumber(value interface{}) (int64, bool) {
+	switch value := value.(type) {
+	case int32:
+		return int64(value), true
+	case string:
+		number, err := strconv.ParseInt(value, 10, 64)
+		return number, err == nil
+	}
+
+	return 0, false
+}
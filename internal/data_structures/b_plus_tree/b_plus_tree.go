@@ -1,5 +1,10 @@
 package b_plus_tree
 
+import (
+	"cmp"
+	"sync"
+)
+
 const (
 	// Минимальная степень B+ дерева
 	minDegree = 2
@@ -9,45 +14,59 @@ const (
 	maxChildren = 2 * minDegree
 )
 
-type BPlusTree struct {
-	root *Node
+// BPlusTree хранит ключи любого типа K, порядок которых задает compare. Для ключей из нескольких
+// колонок или значений разных типов удобно сравнивать закодированные байты (bytes.Compare).
+// BPlusTree не синхронизирован: для доступа из нескольких горутин есть ConcurrentBPlusTree
+type BPlusTree[K any] struct {
+	root    *Node[K]
+	compare func(a, b K) int
 }
 
-type Node struct {
-	keys     []int
+type Node[K any] struct {
+	keys     []K
 	values   []interface{} // только для листовых узлов
-	children []*Node
+	children []*Node[K]
 	isLeaf   bool
-	next     *Node // указатель на следующий листовой узел
+	next     *Node[K]     // указатель на следующий листовой узел
+	prev     *Node[K]     // указатель на предыдущий листовой узел
+	latch    sync.RWMutex // защищает узел в ConcurrentBPlusTree
+}
+
+// NewBPlusTree создает дерево с ключами int
+func NewBPlusTree() *BPlusTree[int] {
+	return NewBPlusTreeFunc(cmp.Compare[int])
 }
 
-func NewBPlusTree() *BPlusTree {
-	return &BPlusTree{
-		root: nil,
+// NewBPlusTreeFunc создает дерево, ключи которого сравнивает compare: отрицательное число, если a < b,
+// ноль, если ключи равны, и положительное, если a > b
+func NewBPlusTreeFunc[K any](compare func(a, b K) int) *BPlusTree[K] {
+	return &BPlusTree[K]{
+		root:    nil,
+		compare: compare,
 	}
 }
 
 // Создание нового узла
-func newNode() *Node {
-	return &Node{
-		keys:     make([]int, 0, maxKeys),
+func newNode[K any]() *Node[K] {
+	return &Node[K]{
+		keys:     make([]K, 0, maxKeys),
 		values:   make([]interface{}, 0, maxKeys),
-		children: make([]*Node, 0, maxChildren),
+		children: make([]*Node[K], 0, maxChildren),
 		isLeaf:   true,
 	}
 }
 
 // Insert добавляет новую пару ключ-значение в B+ дерево
-func (t *BPlusTree) Insert(key int, value interface{}) {
+func (t *BPlusTree[K]) Insert(key K, value interface{}) {
 	if t.root == nil {
-		t.root = newNode()
+		t.root = newNode[K]()
 		t.root.keys = append(t.root.keys, key)
 		t.root.values = append(t.root.values, value)
 		return
 	}
 
 	if len(t.root.keys) == maxKeys {
-		newRoot := newNode()
+		newRoot := newNode[K]()
 		newRoot.isLeaf = false
 		newRoot.children = append(newRoot.children, t.root)
 		t.root = newRoot
@@ -57,20 +76,21 @@ func (t *BPlusTree) Insert(key int, value interface{}) {
 }
 
 // insertNonFull вставляет ключ в неполный узел
-func (t *BPlusTree) insertNonFull(node *Node, key int, value interface{}) {
+func (t *BPlusTree[K]) insertNonFull(node *Node[K], key K, value interface{}) {
 	if node.isLeaf {
 		// Найти позицию для вставки
 		i := 0
-		for i < len(node.keys) && node.keys[i] < key {
+		for i < len(node.keys) && t.compare(node.keys[i], key) < 0 {
 			i++
 		}
 		// Если ключ уже есть — обновить значение
-		if i < len(node.keys) && node.keys[i] == key {
+		if i < len(node.keys) && t.compare(node.keys[i], key) == 0 {
 			node.values[i] = value
 			return
 		}
 		// Вставить ключ и значение
-		node.keys = append(node.keys, 0)
+		var zero K
+		node.keys = append(node.keys, zero)
 		node.values = append(node.values, nil)
 		copy(node.keys[i+1:], node.keys[i:])
 		copy(node.values[i+1:], node.values[i:])
@@ -79,14 +99,11 @@ func (t *BPlusTree) insertNonFull(node *Node, key int, value interface{}) {
 		return
 	}
 	// Внутренний узел: найти потомка
-	i := 0
-	for i < len(node.keys) && key >= node.keys[i] {
-		i++
-	}
+	i := t.childIndex(node, key)
 	// Если потомок переполнен — разделить и пересчитать индекс
 	if len(node.children[i].keys) == maxKeys {
 		t.splitChild(node, i)
-		if key >= node.keys[i] {
+		if t.compare(key, node.keys[i]) >= 0 {
 			i++
 		}
 	}
@@ -94,9 +111,9 @@ func (t *BPlusTree) insertNonFull(node *Node, key int, value interface{}) {
 }
 
 // splitChild разделяет заполненный дочерний узел
-func (t *BPlusTree) splitChild(parent *Node, childIndex int) {
+func (t *BPlusTree[K]) splitChild(parent *Node[K], childIndex int) {
 	child := parent.children[childIndex]
-	newNode := newNode()
+	newNode := newNode[K]()
 	newNode.isLeaf = child.isLeaf
 
 	mid := len(child.keys) / 2
@@ -109,14 +126,19 @@ func (t *BPlusTree) splitChild(parent *Node, childIndex int) {
 		child.values = child.values[:mid]
 		// Связный список листьев
 		newNode.next = child.next
+		newNode.prev = child
+		if child.next != nil {
+			child.next.prev = newNode
+		}
 		child.next = newNode
 		// В родителя — первый ключ нового листа
-		parent.keys = append(parent.keys, 0)
+		var zero K
+		parent.keys = append(parent.keys, zero)
 		copy(parent.keys[childIndex+1:], parent.keys[childIndex:])
 		parent.keys[childIndex] = newNode.keys[0]
 		// Для листа у newNode нет детей
 		if len(parent.children) == 0 {
-			parent.children = []*Node{child, newNode}
+			parent.children = []*Node[K]{child, newNode}
 		} else {
 			parent.children = append(parent.children, nil)
 			copy(parent.children[childIndex+2:], parent.children[childIndex+1:])
@@ -130,7 +152,8 @@ func (t *BPlusTree) splitChild(parent *Node, childIndex int) {
 		child.keys = child.keys[:mid] // средний ключ не остается в child
 		child.children = child.children[:mid+1]
 		// В родителя — средний ключ
-		parent.keys = append(parent.keys, 0)
+		var zero K
+		parent.keys = append(parent.keys, zero)
 		copy(parent.keys[childIndex+1:], parent.keys[childIndex:])
 		parent.keys[childIndex] = midKey
 		parent.children = append(parent.children, nil)
@@ -140,7 +163,7 @@ func (t *BPlusTree) splitChild(parent *Node, childIndex int) {
 }
 
 // Search ищет значение по ключу
-func (t *BPlusTree) Search(key int) (interface{}, bool) {
+func (t *BPlusTree[K]) Search(key K) (interface{}, bool) {
 	if t.root == nil {
 		return nil, false
 	}
@@ -148,24 +171,30 @@ func (t *BPlusTree) Search(key int) (interface{}, bool) {
 }
 
 // searchNode рекурсивно ищет ключ в узле
-func (t *BPlusTree) searchNode(node *Node, key int) (interface{}, bool) {
+func (t *BPlusTree[K]) searchNode(node *Node[K], key K) (interface{}, bool) {
 	if node.isLeaf {
 		for i, k := range node.keys {
-			if k == key {
+			if t.compare(k, key) == 0 {
 				return node.values[i], true
 			}
 		}
 		return nil, false
 	}
-	i := 0
-	for i < len(node.keys) && key >= node.keys[i] {
-		i++
+	return t.searchNode(node.children[t.childIndex(node, key)], key)
+}
+
+// Range вызывает fn для пар с ключами от from до to включительно в порядке возрастания ключей.
+// Обход останавливается, если fn вернула false
+func (t *BPlusTree[K]) Range(from, to K, fn func(key K, value interface{}) bool) {
+	if t.compare(from, to) > 0 {
+		return
 	}
-	return t.searchNode(node.children[i], key)
+
+	t.Scan(&Bound[K]{Key: from, Inclusive: true}, &Bound[K]{Key: to, Inclusive: true}, false, fn)
 }
 
 // Delete удаляет ключ из B+ дерева
-func (t *BPlusTree) Delete(key int) bool {
+func (t *BPlusTree[K]) Delete(key K) bool {
 	if t.root == nil {
 		return false
 	}
@@ -187,7 +216,7 @@ func (t *BPlusTree) Delete(key int) bool {
 }
 
 // fill заполняет дочерний узел, который имеет меньше t-1 ключей
-func (t *BPlusTree) fill(parent *Node, idx int) {
+func (t *BPlusTree[K]) fill(parent *Node[K], idx int) {
 	if idx != 0 && len(parent.children[idx-1].keys) >= minDegree {
 		t.borrowFromPrev(parent, idx)
 	} else if idx != len(parent.children)-1 && len(parent.children[idx+1].keys) >= minDegree {
@@ -201,12 +230,15 @@ func (t *BPlusTree) fill(parent *Node, idx int) {
 	}
 }
 
-// Обновляем метод deleteKey, добавляя вызов fill
-func (t *BPlusTree) deleteKey(node *Node, key int) bool {
+// deleteKey удаляет ключ из поддерева node за один проход сверху вниз: перед спуском
+// в потомка с минимальным числом ключей он пополняется, поэтому удаление из листа
+// не нарушает баланс. Разделители во внутренних узлах могут остаться равными удаленному ключу:
+// они только направляют поиск, а порядок ключей при этом не нарушается
+func (t *BPlusTree[K]) deleteKey(node *Node[K], key K) bool {
 	if node.isLeaf {
 		idx := -1
 		for i, k := range node.keys {
-			if k == key {
+			if t.compare(k, key) == 0 {
 				idx = i
 				break
 			}
@@ -225,68 +257,53 @@ func (t *BPlusTree) deleteKey(node *Node, key int) bool {
 	}
 
 	// Во внутреннем узле ищем подходящего потомка
-	i := 0
-	for i < len(node.keys) && key >= node.keys[i] {
-		i++
-	}
-	if i > 0 {
-		i--
-	}
+	i := t.childIndex(node, key)
 
-	// Проверяем, нужно ли заполнить дочерний узел
+	// Если у потомка минимум ключей, пополняем его. После слияния индекс может измениться
 	if len(node.children[i].keys) < minDegree {
 		t.fill(node, i)
-		// После fill индекс может измениться
-		if i > 0 && key < node.keys[i-1] {
-			i--
-		}
-	}
-
-	// Если нашли ключ во внутреннем узле, заменяем его на преемника
-	if i < len(node.keys) && node.keys[i] == key {
-		successor := t.getSuccessor(node.children[i+1])
-		node.keys[i] = successor
-		return t.deleteKey(node.children[i+1], successor)
+		i = t.childIndex(node, key)
 	}
 
 	return t.deleteKey(node.children[i], key)
 }
 
-// getSuccessor находит преемника ключа
-func (t *BPlusTree) getSuccessor(node *Node) int {
-	for !node.isLeaf {
-		node = node.children[0]
+// childIndex возвращает индекс потомка внутреннего узла, в поддереве которого лежит ключ
+func (t *BPlusTree[K]) childIndex(node *Node[K], key K) int {
+	i := 0
+	for i < len(node.keys) && t.compare(key, node.keys[i]) >= 0 {
+		i++
 	}
-	return node.keys[0]
+	return i
 }
 
 // borrowFromPrev заимствует ключ у предыдущего дочернего узла
-func (t *BPlusTree) borrowFromPrev(parent *Node, idx int) {
+func (t *BPlusTree[K]) borrowFromPrev(parent *Node[K], idx int) {
 	child := parent.children[idx]
 	sibling := parent.children[idx-1]
 
 	if child.isLeaf {
 		// Для листового узла
-		child.keys = append([]int{sibling.keys[len(sibling.keys)-1]}, child.keys...)
+		child.keys = append([]K{sibling.keys[len(sibling.keys)-1]}, child.keys...)
 		child.values = append([]interface{}{sibling.values[len(sibling.values)-1]}, child.values...)
 		sibling.keys = sibling.keys[:len(sibling.keys)-1]
 		sibling.values = sibling.values[:len(sibling.values)-1]
 		parent.keys[idx-1] = child.keys[0]
 	} else {
 		// Для внутреннего узла
-		child.keys = append([]int{parent.keys[idx-1]}, child.keys...)
+		child.keys = append([]K{parent.keys[idx-1]}, child.keys...)
 		parent.keys[idx-1] = sibling.keys[len(sibling.keys)-1]
 		sibling.keys = sibling.keys[:len(sibling.keys)-1]
 
 		if !child.isLeaf {
-			child.children = append([]*Node{sibling.children[len(sibling.children)-1]}, child.children...)
+			child.children = append([]*Node[K]{sibling.children[len(sibling.children)-1]}, child.children...)
 			sibling.children = sibling.children[:len(sibling.children)-1]
 		}
 	}
 }
 
 // borrowFromNext заимствует ключ у следующего дочернего узла
-func (t *BPlusTree) borrowFromNext(parent *Node, idx int) {
+func (t *BPlusTree[K]) borrowFromNext(parent *Node[K], idx int) {
 	child := parent.children[idx]
 	sibling := parent.children[idx+1]
 
@@ -311,7 +328,7 @@ func (t *BPlusTree) borrowFromNext(parent *Node, idx int) {
 }
 
 // merge объединяет два дочерних узла node[idx] и node[idx+1]
-func (t *BPlusTree) merge(parent *Node, idx int) {
+func (t *BPlusTree[K]) merge(parent *Node[K], idx int) {
 	child := parent.children[idx]
 	sibling := parent.children[idx+1]
 
@@ -320,6 +337,9 @@ func (t *BPlusTree) merge(parent *Node, idx int) {
 		child.keys = append(child.keys, sibling.keys...)
 		child.values = append(child.values, sibling.values...)
 		child.next = sibling.next
+		if sibling.next != nil {
+			sibling.next.prev = child
+		}
 	} else {
 		// Для внутренних узлов
 		child.keys = append(child.keys, parent.keys[idx])
@@ -1,6 +1,11 @@
 package b_plus_tree
 
 import (
+	"bytes"
+	"cmp"
+	"math"
+	"math/rand"
+	"sync"
 	"testing"
 )
 
@@ -113,7 +118,7 @@ func TestLeafNodeLinking(t *testing.T) {
 	}
 
 	// Находим первый листовой узел
-	var firstLeaf *Node
+	var firstLeaf *Node[int]
 	node := tree.root
 	for !node.isLeaf {
 		node = node.children[0]
@@ -158,6 +163,244 @@ func TestLeafNodeLinking(t *testing.T) {
 	}
 }
 
+// TestRange проверяет обход ключей из диапазона
+func TestRange(t *testing.T) {
+	tree := NewBPlusTree()
+	for _, key := range []int{40, 10, 30, 20, 50, 70, 60, 0, -10} {
+		tree.Insert(key, key*2)
+	}
+
+	collect := func(from, to int, limit int) []int {
+		keys := []int{}
+		tree.Range(from, to, func(key int, value interface{}) bool {
+			if value != key*2 {
+				t.Errorf("Для ключа %d ожидалось значение %d, получено %v", key, key*2, value)
+			}
+			keys = append(keys, key)
+			return len(keys) < limit
+		})
+		return keys
+	}
+
+	testCases := []struct {
+		from, to, limit int
+		expected        []int
+	}{
+		{20, 50, 100, []int{20, 30, 40, 50}},
+		{15, 55, 100, []int{20, 30, 40, 50}},
+		{-100, 100, 100, []int{-10, 0, 10, 20, 30, 40, 50, 60, 70}},
+		{-100, 100, 3, []int{-10, 0, 10}},
+		{71, 100, 100, []int{}},
+		{30, 20, 100, []int{}},
+	}
+
+	for _, tc := range testCases {
+		keys := collect(tc.from, tc.to, tc.limit)
+		if len(keys) != len(tc.expected) {
+			t.Errorf("Диапазон [%d, %d]: ожидались ключи %v, получено %v", tc.from, tc.to, tc.expected, keys)
+			continue
+		}
+		for i := range keys {
+			if keys[i] != tc.expected[i] {
+				t.Errorf("Диапазон [%d, %d]: ожидались ключи %v, получено %v", tc.from, tc.to, tc.expected, keys)
+				break
+			}
+		}
+	}
+}
+
+// TestIterator проверяет прямой и обратный обход и Seek
+func TestIterator(t *testing.T) {
+	tree := NewBPlusTree()
+	for key := 0; key < 100; key += 10 {
+		tree.Insert(key, key*2)
+	}
+
+	collect := func(it *Iterator[int], limit int) []int {
+		keys := []int{}
+		for len(keys) < limit && it.Next() {
+			if it.Value() != it.Key()*2 {
+				t.Errorf("Для ключа %d ожидалось значение %d, получено %v", it.Key(), it.Key()*2, it.Value())
+			}
+			keys = append(keys, it.Key())
+		}
+		return keys
+	}
+	seek := func(it *Iterator[int], key int) *Iterator[int] {
+		it.Seek(key)
+		return it
+	}
+
+	testCases := []struct {
+		name     string
+		it       *Iterator[int]
+		expected []int
+	}{
+		{"с начала", tree.Iterator(), []int{0, 10, 20, 30}},
+		{"с конца", tree.ReverseIterator(), []int{90, 80, 70, 60}},
+		{"Seek на ключ", seek(tree.Iterator(), 50), []int{50, 60, 70, 80}},
+		{"Seek между ключами", seek(tree.Iterator(), 55), []int{60, 70, 80, 90}},
+		{"Seek после всех ключей", seek(tree.Iterator(), 95), []int{}},
+		{"обратный Seek на ключ", seek(tree.ReverseIterator(), 50), []int{50, 40, 30, 20}},
+		{"обратный Seek между ключами", seek(tree.ReverseIterator(), 55), []int{50, 40, 30, 20}},
+		{"обратный Seek до всех ключей", seek(tree.ReverseIterator(), -5), []int{}},
+		{"пустое дерево", NewBPlusTree().Iterator(), []int{}},
+	}
+
+	for _, tc := range testCases {
+		keys := collect(tc.it, 4)
+		if len(keys) != len(tc.expected) {
+			t.Errorf("%s: ожидались ключи %v, получено %v", tc.name, tc.expected, keys)
+			continue
+		}
+		for i := range keys {
+			if keys[i] != tc.expected[i] {
+				t.Errorf("%s: ожидались ключи %v, получено %v", tc.name, tc.expected, keys)
+				break
+			}
+		}
+	}
+}
+
+// TestScan проверяет включающие и исключающие границы в обе стороны
+func TestScan(t *testing.T) {
+	tree := NewBPlusTree()
+	for key := 0; key < 100; key += 10 {
+		tree.Insert(key, key)
+	}
+
+	bound := func(key int, inclusive bool) *Bound[int] {
+		return &Bound[int]{Key: key, Inclusive: inclusive}
+	}
+
+	testCases := []struct {
+		from, to *Bound[int]
+		reverse  bool
+		expected []int
+	}{
+		{bound(20, true), bound(50, true), false, []int{20, 30, 40, 50}},
+		{bound(20, false), bound(50, false), false, []int{30, 40}},
+		{bound(15, false), bound(45, false), false, []int{20, 30, 40}},
+		{bound(20, true), bound(50, true), true, []int{50, 40, 30, 20}},
+		{bound(20, false), bound(50, false), true, []int{40, 30}},
+		{nil, bound(20, false), false, []int{0, 10}},
+		{bound(70, false), nil, false, []int{80, 90}},
+		{bound(70, false), nil, true, []int{90, 80}},
+		{nil, bound(10, true), true, []int{10, 0}},
+		{bound(50, false), bound(50, true), false, []int{}},
+	}
+
+	for i, tc := range testCases {
+		keys := []int{}
+		tree.Scan(tc.from, tc.to, tc.reverse, func(key int, value interface{}) bool {
+			keys = append(keys, key)
+			return true
+		})
+		if len(keys) != len(tc.expected) {
+			t.Errorf("Случай %d: ожидались ключи %v, получено %v", i, tc.expected, keys)
+			continue
+		}
+		for j := range keys {
+			if keys[j] != tc.expected[j] {
+				t.Errorf("Случай %d: ожидались ключи %v, получено %v", i, tc.expected, keys)
+				break
+			}
+		}
+	}
+}
+
+// TestPrefixScan проверяет обход ключей-байтов с общим префиксом
+func TestPrefixScan(t *testing.T) {
+	tree := NewBPlusTreeFunc(bytes.Compare)
+	for _, key := range []string{"a", "ab", "abc", "abd", "ac", "b", "\xff", "\xff\x01", "\xff\xff"} {
+		tree.Insert([]byte(key), key)
+	}
+
+	testCases := []struct {
+		prefix   string
+		reverse  bool
+		expected []string
+	}{
+		{"ab", false, []string{"ab", "abc", "abd"}},
+		{"ab", true, []string{"abd", "abc", "ab"}},
+		{"a", true, []string{"ac", "abd", "abc", "ab", "a"}},
+		{"\xff", true, []string{"\xff\xff", "\xff\x01", "\xff"}},
+		{"abe", false, []string{}},
+		{"", false, []string{"a", "ab", "abc", "abd", "ac", "b", "\xff", "\xff\x01", "\xff\xff"}},
+	}
+
+	for _, tc := range testCases {
+		keys := []string{}
+		PrefixScan(tree, []byte(tc.prefix), tc.reverse, func(key []byte, value interface{}) bool {
+			keys = append(keys, value.(string))
+			return true
+		})
+		if len(keys) != len(tc.expected) {
+			t.Errorf("Префикс %q: ожидались ключи %q, получено %q", tc.prefix, tc.expected, keys)
+			continue
+		}
+		for i := range keys {
+			if keys[i] != tc.expected[i] {
+				t.Errorf("Префикс %q: ожидались ключи %q, получено %q", tc.prefix, tc.expected, keys)
+				break
+			}
+		}
+	}
+}
+
+// TestRandomInsertAndDelete сверяет дерево с map на случайной последовательности вставок и удалений
+func TestRandomInsertAndDelete(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	tree := NewBPlusTree()
+	expected := map[int]int{}
+
+	for i := 0; i < 5000; i++ {
+		key := random.Intn(300)
+		if random.Intn(2) == 0 {
+			tree.Insert(key, i)
+			expected[key] = i
+			continue
+		}
+
+		_, exists := expected[key]
+		if tree.Delete(key) != exists {
+			t.Fatalf("Шаг %d: удаление ключа %d должно вернуть %t", i, key, exists)
+		}
+		delete(expected, key)
+	}
+
+	for key, value := range expected {
+		if found, ok := tree.Search(key); !ok || found != value {
+			t.Errorf("Для ключа %d ожидалось значение %d, получено %v", key, value, found)
+		}
+	}
+
+	keys := []int{}
+	tree.Range(math.MinInt, math.MaxInt, func(key int, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if len(keys) != len(expected) {
+		t.Errorf("Ожидалось %d ключей в листьях, получено %d", len(expected), len(keys))
+	}
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] >= keys[i] {
+			t.Errorf("Нарушен порядок ключей в листьях: %d -> %d", keys[i-1], keys[i])
+		}
+	}
+
+	// Ссылки prev после слияний листьев ведут по тем же ключам в обратном порядке
+	it := tree.ReverseIterator()
+	for i := len(keys) - 1; i >= 0; i-- {
+		if !it.Next() || it.Key() != keys[i] {
+			t.Fatalf("Обратный обход: ожидался ключ %d, получен %d", keys[i], it.Key())
+		}
+	}
+	if it.Next() {
+		t.Errorf("Обратный обход: лишний ключ %d", it.Key())
+	}
+}
+
 // TestInsertWithDuplicates проверяет вставку дубликатов
 func TestInsertWithDuplicates(t *testing.T) {
 	tree := NewBPlusTree()
@@ -259,8 +502,8 @@ func TestTreeBalancing(t *testing.T) {
 	}
 
 	// Проверяем, что все листовые узлы находятся на одном уровне
-	var checkLeafLevel func(*Node, int, *int) bool
-	checkLeafLevel = func(node *Node, currentLevel int, leafLevel *int) bool {
+	var checkLeafLevel func(*Node[int], int, *int) bool
+	checkLeafLevel = func(node *Node[int], currentLevel int, leafLevel *int) bool {
 		if node.isLeaf {
 			if *leafLevel == -1 {
 				*leafLevel = currentLevel
@@ -282,3 +525,243 @@ func TestTreeBalancing(t *testing.T) {
 		t.Error("Листовые узлы находятся на разных уровнях")
 	}
 }
+
+// TestCustomKeys проверяет дерево с составными ключами и ключами-байтами
+func TestCustomKeys(t *testing.T) {
+	type eventKey struct {
+		tenant    int
+		createdAt int
+	}
+	tree := NewBPlusTreeFunc(func(a, b eventKey) int {
+		if a.tenant != b.tenant {
+			return cmp.Compare(a.tenant, b.tenant)
+		}
+		return cmp.Compare(a.createdAt, b.createdAt)
+	})
+
+	for i := 0; i < 100; i++ {
+		tree.Insert(eventKey{tenant: i % 4, createdAt: 100 - i}, i)
+	}
+
+	// Все события tenant 2 с createdAt от 10 до 50 включительно в порядке createdAt
+	got := []eventKey{}
+	tree.Range(eventKey{tenant: 2, createdAt: 10}, eventKey{tenant: 2, createdAt: 50}, func(key eventKey, value interface{}) bool {
+		if value != 100-key.createdAt {
+			t.Errorf("Для ключа %v ожидалось значение %d, получено %v", key, 100-key.createdAt, value)
+		}
+		got = append(got, key)
+		return true
+	})
+	if len(got) != 11 || got[0] != (eventKey{tenant: 2, createdAt: 10}) || got[10] != (eventKey{tenant: 2, createdAt: 50}) {
+		t.Errorf("Ожидались события tenant 2 с createdAt 10..50, получено %v", got)
+	}
+
+	if !tree.Delete(eventKey{tenant: 3, createdAt: 1}) {
+		t.Error("Ключ {3 1} должен удаляться")
+	}
+	if _, found := tree.Search(eventKey{tenant: 3, createdAt: 1}); found {
+		t.Error("Ключ {3 1} не должен существовать после удаления")
+	}
+
+	byteTree := NewBPlusTreeFunc(bytes.Compare)
+	for _, word := range []string{"pear", "apple", "", "banana", "apple pie"} {
+		byteTree.Insert([]byte(word), word)
+	}
+	words := []string{}
+	byteTree.Range([]byte("apple"), []byte("b"), func(key []byte, value interface{}) bool {
+		words = append(words, value.(string))
+		return true
+	})
+	if len(words) != 2 || words[0] != "apple" || words[1] != "apple pie" {
+		t.Errorf("Ожидались ключи [apple apple pie], получено %v", words)
+	}
+}
+
+// TestBulkLoad проверяет построение дерева снизу вверх из отсортированных ключей
+func TestBulkLoad(t *testing.T) {
+	// checkNodes проверяет заполненность узлов и одинаковую глубину листьев, возвращает количество листьев
+	var checkNodes func(node *Node[int], isRoot bool, level int, leafLevel *int) int
+	checkNodes = func(node *Node[int], isRoot bool, level int, leafLevel *int) int {
+		if len(node.keys) > maxKeys || (!isRoot && len(node.keys) < minDegree-1) {
+			t.Errorf("В узле %d ключей", len(node.keys))
+		}
+		if node.isLeaf {
+			if *leafLevel == -1 {
+				*leafLevel = level
+			} else if *leafLevel != level {
+				t.Error("Листовые узлы находятся на разных уровнях")
+			}
+			return 1
+		}
+
+		if len(node.children) != len(node.keys)+1 {
+			t.Errorf("У внутреннего узла %d ключей и %d потомков", len(node.keys), len(node.children))
+		}
+		leaves := 0
+		for _, child := range node.children {
+			leaves += checkNodes(child, false, level+1, leafLevel)
+		}
+		return leaves
+	}
+
+	for _, fillFactor := range []int{50, 70, 100} {
+		for _, count := range []int{0, 1, 2, 3, 4, 5, 17, 100, 1000} {
+			keys := make([]int, count)
+			values := make([]interface{}, count)
+			for i := range keys {
+				keys[i] = i * 2
+				values[i] = i
+			}
+
+			tree, err := BulkLoad(cmp.Compare[int], keys, values, fillFactor)
+			if err != nil {
+				t.Fatalf("Заполненность %d, %d ключей: %v", fillFactor, count, err)
+			}
+			if count == 0 {
+				if tree.root != nil {
+					t.Error("Корень пустого дерева должен быть nil")
+				}
+				continue
+			}
+
+			leafLevel := -1
+			leaves := checkNodes(tree.root, true, 0, &leafLevel)
+			perLeaf := max(1, maxKeys*fillFactor/100)
+			if expected := (count + perLeaf - 1) / perLeaf; leaves != expected {
+				t.Errorf("Заполненность %d, %d ключей: ожидалось %d листьев, получено %d", fillFactor, count, expected, leaves)
+			}
+
+			// Ключи читаются в обе стороны по связному списку листьев
+			it := tree.Iterator()
+			for i := 0; i < count; i++ {
+				if !it.Next() || it.Key() != i*2 || it.Value() != i {
+					t.Fatalf("Прямой обход: ожидался ключ %d", i*2)
+				}
+			}
+			reverse := tree.ReverseIterator()
+			for i := count - 1; i >= 0; i-- {
+				if !reverse.Next() || reverse.Key() != i*2 {
+					t.Fatalf("Обратный обход: ожидался ключ %d", i*2)
+				}
+			}
+
+			// После построения дерево меняется как обычно
+			tree.Insert(1, "новый")
+			if !tree.Delete(0) {
+				t.Error("Ключ 0 должен удаляться")
+			}
+			if value, found := tree.Search(1); !found || value != "новый" {
+				t.Errorf("Для ключа 1 ожидалось значение новый, получено %v", value)
+			}
+			leafLevel = -1
+			checkNodes(tree.root, true, 0, &leafLevel)
+		}
+	}
+
+	if _, err := BulkLoad(cmp.Compare[int], []int{2, 1}, []interface{}{2, 1}, 100); err == nil {
+		t.Error("Неупорядоченные ключи должны давать ошибку")
+	}
+	if _, err := BulkLoad(cmp.Compare[int], []int{1, 1}, []interface{}{1, 1}, 100); err == nil {
+		t.Error("Повторяющиеся ключи должны давать ошибку")
+	}
+	if _, err := BulkLoad(cmp.Compare[int], []int{1}, []interface{}{1}, 40); err == nil {
+		t.Error("Заполненность меньше 50% должна давать ошибку")
+	}
+}
+
+// TestConcurrentAccess проверяет ConcurrentBPlusTree под нагрузкой из нескольких горутин.
+// Тест стоит запускать с -race: детектор гонок находит доступ к узлам без блокировок
+func TestConcurrentAccess(t *testing.T) {
+	const (
+		writers = 8
+		readers = 4
+		steps   = 3000
+		keys    = 400
+	)
+	tree := NewConcurrentBPlusTree()
+	// Каждый писатель меняет только свои ключи (key % writers), поэтому знает, что должен найти Search
+	models := make([]map[int]int, writers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		models[w] = map[int]int{}
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			random := rand.New(rand.NewSource(int64(w)))
+			model := models[w]
+
+			for i := 0; i < steps; i++ {
+				key := random.Intn(keys/writers)*writers + w
+				switch random.Intn(3) {
+				case 0:
+					_, exists := model[key]
+					if tree.Delete(key) != exists {
+						t.Errorf("Писатель %d: удаление ключа %d должно вернуть %t", w, key, exists)
+					}
+					delete(model, key)
+				case 1:
+					tree.Insert(key, key*10)
+					model[key] = key * 10
+				default:
+					value, found := tree.Search(key)
+					if _, exists := model[key]; found != exists || (found && value != key*10) {
+						t.Errorf("Писатель %d: для ключа %d найдено %v, %t", w, key, value, found)
+					}
+				}
+			}
+		}(w)
+	}
+
+	done := make(chan struct{})
+	var readersWg sync.WaitGroup
+	for r := 0; r < readers; r++ {
+		readersWg.Add(1)
+		go func() {
+			defer readersWg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				previous := math.MinInt
+				tree.Range(math.MinInt, math.MaxInt, func(key int, value interface{}) bool {
+					if key <= previous {
+						t.Errorf("Нарушен порядок ключей при обходе: %d -> %d", previous, key)
+					}
+					if value != key*10 {
+						t.Errorf("Для ключа %d при обходе получено значение %v", key, value)
+					}
+					previous = key
+					return true
+				})
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	readersWg.Wait()
+
+	expected := []int{}
+	for key := 0; key < keys; key++ {
+		if _, exists := models[key%writers][key]; exists {
+			expected = append(expected, key)
+		}
+	}
+	actual := []int{}
+	tree.Range(math.MinInt, math.MaxInt, func(key int, value interface{}) bool {
+		actual = append(actual, key)
+		return true
+	})
+	if len(actual) != len(expected) {
+		t.Fatalf("Ожидалось %d ключей, получено %d", len(expected), len(actual))
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Fatalf("На позиции %d ожидался ключ %d, получен %d", i, expected[i], actual[i])
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package b_plus_tree
+
+import "fmt"
+
+// BulkLoad строит дерево снизу вверх из ключей, отсортированных по возрастанию без повторов, и их значений.
+// В отличие от вставки по одной, узлы не разделяются: листья заполняются на fillFactor процентов
+// от maxKeys (от 50 до 100), а внутренние узлы - полностью. Ключи распределяются между узлами поровну,
+// поэтому ни один узел не остается заполненным меньше минимума. Это загрузчик для деревьев этого пакета:
+// CREATE INDEX движка disk строит страничное дерево тем же способом через btree.Builder
+func BulkLoad[K any](compare func(a, b K) int, keys []K, values []interface{}, fillFactor int) (*BPlusTree[K], error) {
+	if len(keys) != len(values) {
+		return nil, fmt.Errorf("BulkLoad(): got %d keys and %d values", len(keys), len(values))
+	}
+	if fillFactor < 50 || fillFactor > 100 {
+		return nil, fmt.Errorf("BulkLoad(): fill factor must be between 50 and 100, got %d", fillFactor)
+	}
+	for i := 1; i < len(keys); i++ {
+		if compare(keys[i-1], keys[i]) >= 0 {
+			return nil, fmt.Errorf("BulkLoad(): keys must be sorted in ascending order without duplicates")
+		}
+	}
+
+	tree := NewBPlusTreeFunc(compare)
+	if len(keys) == 0 {
+		return tree, nil
+	}
+
+	// Уровень дерева: узлы и первые ключи их поддеревьев, которые станут разделителями в родителе
+	level := []*Node[K]{}
+	firstKeys := []K{}
+	perLeaf := max(1, maxKeys*fillFactor/100)
+	var prev *Node[K]
+	for _, size := range evenSizes(len(keys), (len(keys)+perLeaf-1)/perLeaf) {
+		leaf := newNode[K]()
+		leaf.keys = append(leaf.keys, keys[:size]...)
+		leaf.values = append(leaf.values, values[:size]...)
+		keys, values = keys[size:], values[size:]
+
+		leaf.prev = prev
+		if prev != nil {
+			prev.next = leaf
+		}
+		prev = leaf
+
+		level = append(level, leaf)
+		firstKeys = append(firstKeys, leaf.keys[0])
+	}
+
+	for len(level) > 1 {
+		parents := []*Node[K]{}
+		parentKeys := []K{}
+		for _, size := range evenSizes(len(level), (len(level)+maxChildren-1)/maxChildren) {
+			node := newNode[K]()
+			node.isLeaf = false
+			node.children = append(node.children, level[:size]...)
+			node.keys = append(node.keys, firstKeys[1:size]...)
+
+			parents = append(parents, node)
+			parentKeys = append(parentKeys, firstKeys[0])
+			level, firstKeys = level[size:], firstKeys[size:]
+		}
+		level, firstKeys = parents, parentKeys
+	}
+	tree.root = level[0]
+
+	return tree, nil
+}
+
+// evenSizes делит count элементов на parts частей, размеры которых отличаются не больше чем на один
+func evenSizes(count, parts int) []int {
+	sizes := make([]int, parts)
+	for i := range sizes {
+		sizes[i] = count / parts
+		if i < count%parts {
+			sizes[i]++
+		}
+	}
+
+	return sizes
+}
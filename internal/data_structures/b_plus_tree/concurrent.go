@@ -0,0 +1,208 @@
+package b_plus_tree
+
+import (
+	"cmp"
+	"sync"
+)
+
+// ConcurrentBPlusTree - B+ дерево, с которым можно работать из нескольких горутин. Узлы защищены
+// собственными блокировками (latch crabbing): операция спускается от корня, захватывая потомка
+// до освобождения родителя, и отпускает родителя, как только потомок не может разделиться или
+// слиться с соседом. Вставка заранее разделяет полных потомков, а удаление заранее пополняет
+// потомков с минимумом ключей, поэтому изменения не поднимаются вверх и писателю достаточно
+// держать два уровня дерева. Читатели берут узлы на чтение и не мешают друг другу.
+// Блокировки берутся сверху вниз, а на одном уровне - слева направо, поэтому взаимных блокировок нет.
+// Это самостоятельная структура данных: движки ее не используют, индексы memory и json не хранят деревьев,
+// а disk хранит свое страничное дерево из disk_manager/btree
+type ConcurrentBPlusTree[K any] struct {
+	tree *BPlusTree[K]
+	// rootLatch защищает указатель на корень: корень захватывается до ее освобождения
+	rootLatch sync.RWMutex
+}
+
+// NewConcurrentBPlusTree создает потокобезопасное дерево с ключами int
+func NewConcurrentBPlusTree() *ConcurrentBPlusTree[int] {
+	return NewConcurrentBPlusTreeFunc(cmp.Compare[int])
+}
+
+// NewConcurrentBPlusTreeFunc создает потокобезопасное дерево, ключи которого сравнивает compare
+func NewConcurrentBPlusTreeFunc[K any](compare func(a, b K) int) *ConcurrentBPlusTree[K] {
+	return &ConcurrentBPlusTree[K]{tree: NewBPlusTreeFunc(compare)}
+}
+
+// Insert добавляет новую пару ключ-значение или обновляет значение существующего ключа
+func (t *ConcurrentBPlusTree[K]) Insert(key K, value interface{}) {
+	t.rootLatch.Lock()
+	if t.tree.root == nil {
+		t.tree.Insert(key, value)
+		t.rootLatch.Unlock()
+		return
+	}
+
+	node := t.tree.root
+	node.latch.Lock()
+	if len(node.keys) == maxKeys {
+		// Новый корень недоступен другим операциям, пока не освобождена rootLatch
+		newRoot := newNode[K]()
+		newRoot.isLeaf = false
+		newRoot.children = append(newRoot.children, node)
+		newRoot.latch.Lock()
+		t.tree.root = newRoot
+		t.tree.splitChild(newRoot, 0)
+		node.latch.Unlock()
+		node = newRoot
+	}
+	t.rootLatch.Unlock()
+
+	for !node.isLeaf {
+		i := t.tree.childIndex(node, key)
+		child := node.children[i]
+		child.latch.Lock()
+		if len(child.keys) == maxKeys {
+			// Новый узел после разделения виден только через родителя и child, которые захвачены
+			t.tree.splitChild(node, i)
+			if t.tree.compare(key, node.keys[i]) >= 0 {
+				sibling := node.children[i+1]
+				sibling.latch.Lock()
+				child.latch.Unlock()
+				child = sibling
+			}
+		}
+		node.latch.Unlock()
+		node = child
+	}
+
+	t.tree.insertNonFull(node, key, value)
+	node.latch.Unlock()
+}
+
+// Search ищет значение по ключу
+func (t *ConcurrentBPlusTree[K]) Search(key K) (interface{}, bool) {
+	node := t.readRoot()
+	if node == nil {
+		return nil, false
+	}
+
+	for !node.isLeaf {
+		child := node.children[t.tree.childIndex(node, key)]
+		child.latch.RLock()
+		node.latch.RUnlock()
+		node = child
+	}
+	defer node.latch.RUnlock()
+
+	return t.tree.searchNode(node, key)
+}
+
+// Range вызывает fn для пар с ключами от from до to включительно в порядке возрастания ключей,
+// пока fn возвращает true. Лист захватывается на чтение до захвата следующего, поэтому обход
+// не пропускает пары при разделении и слиянии листьев. fn вызывается под блокировкой листа
+// и не должна менять дерево
+func (t *ConcurrentBPlusTree[K]) Range(from, to K, fn func(key K, value interface{}) bool) {
+	node := t.readRoot()
+	if node == nil {
+		return
+	}
+
+	for !node.isLeaf {
+		child := node.children[t.tree.childIndex(node, from)]
+		child.latch.RLock()
+		node.latch.RUnlock()
+		node = child
+	}
+
+	for {
+		for i, key := range node.keys {
+			if t.tree.compare(key, from) < 0 {
+				continue
+			}
+			if t.tree.compare(key, to) > 0 || !fn(key, node.values[i]) {
+				node.latch.RUnlock()
+				return
+			}
+		}
+
+		next := node.next
+		if next == nil {
+			node.latch.RUnlock()
+			return
+		}
+		next.latch.RLock()
+		node.latch.RUnlock()
+		node = next
+	}
+}
+
+// Delete удаляет ключ и сообщает, был ли он в дереве
+func (t *ConcurrentBPlusTree[K]) Delete(key K) bool {
+	t.rootLatch.Lock()
+	node := t.tree.root
+	if node == nil {
+		t.rootLatch.Unlock()
+		return false
+	}
+	node.latch.Lock()
+
+	// Корень с одним ключом может опустеть после слияния его потомков: тогда корнем становится
+	// потомок, и rootLatch держится до этого шага
+	rootLocked := !node.isLeaf && len(node.keys) == 1
+	if !rootLocked {
+		t.rootLatch.Unlock()
+	}
+
+	for !node.isLeaf {
+		i := t.tree.childIndex(node, key)
+		child := node.children[i]
+		child.latch.Lock()
+
+		if len(child.keys) < minDegree {
+			// Соседи захватываются слева направо: потомок отпускается и захватывается снова вместе
+			// с ними. Пока захвачен родитель, другие писатели не могут изменить потомков
+			child.latch.Unlock()
+			first, last := max(0, i-1), min(len(node.children)-1, i+1)
+			locked := append([]*Node[K]{}, node.children[first:last+1]...)
+			for _, n := range locked {
+				n.latch.Lock()
+			}
+
+			t.tree.fill(node, i)
+			child = node.children[t.tree.childIndex(node, key)]
+			for _, n := range locked {
+				if n != child {
+					n.latch.Unlock()
+				}
+			}
+		}
+
+		if rootLocked {
+			if len(node.keys) == 0 {
+				t.tree.root = child
+			}
+			t.rootLatch.Unlock()
+			rootLocked = false
+		}
+		node.latch.Unlock()
+		node = child
+	}
+
+	found := t.tree.deleteKey(node, key)
+	node.latch.Unlock()
+	if rootLocked {
+		t.rootLatch.Unlock()
+	}
+
+	return found
+}
+
+// readRoot захватывает корень на чтение, пока указатель на него не может измениться
+func (t *ConcurrentBPlusTree[K]) readRoot() *Node[K] {
+	t.rootLatch.RLock()
+	defer t.rootLatch.RUnlock()
+
+	root := t.tree.root
+	if root != nil {
+		root.latch.RLock()
+	}
+
+	return root
+}
@@ -0,0 +1,159 @@
+package b_plus_tree
+
+import "bytes"
+
+// Bound - граница диапазона Scan. Inclusive - пара с ключом Key попадает в диапазон
+type Bound[K any] struct {
+	Key       K
+	Inclusive bool
+}
+
+// Iterator обходит пары дерева по ссылкам между листьями в порядке возрастания ключей
+// или, для обратного итератора, в порядке убывания. После изменения дерева итератор использовать нельзя
+type Iterator[K any] struct {
+	tree    *BPlusTree[K]
+	reverse bool
+	node    *Node[K]
+	index   int // позиция следующей пары в node
+	key     K
+	value   interface{}
+}
+
+// Iterator возвращает итератор, который начинает с наименьшего ключа
+func (t *BPlusTree[K]) Iterator() *Iterator[K] {
+	it := &Iterator[K]{tree: t, node: t.root}
+	for it.node != nil && !it.node.isLeaf {
+		it.node = it.node.children[0]
+	}
+
+	return it
+}
+
+// ReverseIterator возвращает итератор, который начинает с наибольшего ключа
+func (t *BPlusTree[K]) ReverseIterator() *Iterator[K] {
+	it := &Iterator[K]{tree: t, reverse: true, node: t.root}
+	for it.node != nil && !it.node.isLeaf {
+		it.node = it.node.children[len(it.node.children)-1]
+	}
+	if it.node != nil {
+		it.index = len(it.node.keys) - 1
+	}
+
+	return it
+}
+
+// Seek переставляет итератор: прямой продолжит с первого ключа >= key, обратный - с последнего ключа <= key
+func (it *Iterator[K]) Seek(key K) {
+	it.node = it.tree.root
+	if it.node == nil {
+		return
+	}
+	for !it.node.isLeaf {
+		it.node = it.node.children[it.tree.childIndex(it.node, key)]
+	}
+
+	// Если в листе нет подходящего ключа, Next перейдет к соседнему листу
+	it.index = 0
+	for it.index < len(it.node.keys) && it.tree.compare(it.node.keys[it.index], key) < 0 {
+		it.index++
+	}
+	if it.reverse && (it.index == len(it.node.keys) || it.tree.compare(it.node.keys[it.index], key) > 0) {
+		it.index--
+	}
+}
+
+// Next переходит к следующей паре и сообщает, есть ли она
+func (it *Iterator[K]) Next() bool {
+	for it.node != nil {
+		if it.index >= 0 && it.index < len(it.node.keys) {
+			it.key, it.value = it.node.keys[it.index], it.node.values[it.index]
+			if it.reverse {
+				it.index--
+			} else {
+				it.index++
+			}
+			return true
+		}
+
+		if it.reverse {
+			it.node = it.node.prev
+			if it.node != nil {
+				it.index = len(it.node.keys) - 1
+			}
+		} else {
+			it.node = it.node.next
+			it.index = 0
+		}
+	}
+
+	return false
+}
+
+func (it *Iterator[K]) Key() K {
+	return it.key
+}
+
+func (it *Iterator[K]) Value() interface{} {
+	return it.value
+}
+
+// Scan вызывает fn для пар с ключами между from и to в порядке возрастания ключей, а при reverse - убывания.
+// nil вместо границы - диапазон с этой стороны не ограничен. Обход останавливается, если fn вернула false
+func (t *BPlusTree[K]) Scan(from, to *Bound[K], reverse bool, fn func(key K, value interface{}) bool) {
+	// start - граница, с которой начинается обход, end - на которой он заканчивается
+	it, start, end, direction := t.Iterator(), from, to, 1
+	if reverse {
+		it, start, end, direction = t.ReverseIterator(), to, from, -1
+	}
+	if start != nil {
+		it.Seek(start.Key)
+	}
+
+	for it.Next() {
+		if start != nil && !start.Inclusive && t.compare(it.Key(), start.Key) == 0 {
+			continue
+		}
+		if end != nil {
+			cmp := t.compare(it.Key(), end.Key) * direction
+			if cmp > 0 || (cmp == 0 && !end.Inclusive) {
+				return
+			}
+		}
+		if !fn(it.Key(), it.Value()) {
+			return
+		}
+	}
+}
+
+// PrefixScan вызывает fn для пар дерева с ключами-байтами, которые начинаются с prefix,
+// в порядке возрастания ключей, а при reverse - убывания
+func PrefixScan(t *BPlusTree[[]byte], prefix []byte, reverse bool, fn func(key []byte, value interface{}) bool) {
+	if !reverse {
+		t.Scan(&Bound[[]byte]{Key: prefix, Inclusive: true}, nil, false, func(key []byte, value interface{}) bool {
+			return bytes.HasPrefix(key, prefix) && fn(key, value)
+		})
+		return
+	}
+
+	// Обратный обход начинается с наименьшего ключа больше всех ключей с префиксом
+	var to *Bound[[]byte]
+	if end := prefixEnd(prefix); end != nil {
+		to = &Bound[[]byte]{Key: end}
+	}
+	t.Scan(nil, to, true, func(key []byte, value interface{}) bool {
+		return bytes.HasPrefix(key, prefix) && fn(key, value)
+	})
+}
+
+// prefixEnd возвращает наименьший ключ, который больше всех ключей с префиксом prefix, или nil, если его нет
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xFF {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+
+	return nil
+}
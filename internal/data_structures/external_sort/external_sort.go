@@ -0,0 +1,234 @@
+package external_sort
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// Sorter сортирует элементы типа T, которые не обязаны помещаться в память. Пока оценка размера
+// накопленных элементов не превышает memoryLimit байт, они лежат в памяти. Когда лимит превышен,
+// накопленные элементы сортируются и сбрасываются во временный файл - отсортированный отрезок (run),
+// а Sort сливает отрезки слиянием k путей. Элементы записываются через encoding/gob, поэтому
+// значения внутри interface{} должны быть типами, которые gob знает. Сортировка устойчивая:
+// равные элементы возвращаются в порядке Add
+type Sorter[T any] struct {
+	compare     func(a, b T) int
+	size        func(item T) int
+	memoryLimit int
+	// dir - каталог временных файлов, "" - os.TempDir()
+	dir string
+
+	items []T
+	used  int
+	runs  []*os.File
+}
+
+// NewSorter создает сортировщик с порядком compare. size оценивает, сколько байт памяти занимает элемент
+func NewSorter[T any](compare func(a, b T) int, size func(item T) int, memoryLimit int, dir string) *Sorter[T] {
+	return &Sorter[T]{
+		compare:     compare,
+		size:        size,
+		memoryLimit: memoryLimit,
+		dir:         dir,
+	}
+}
+
+// Add добавляет элемент и сбрасывает накопленные элементы на диск, если они превысили лимит памяти
+func (s *Sorter[T]) Add(item T) error {
+	s.items = append(s.items, item)
+	s.used += s.size(item)
+
+	if s.used > s.memoryLimit {
+		return s.spill()
+	}
+
+	return nil
+}
+
+// Spilled - количество отрезков, сброшенных на диск
+func (s *Sorter[T]) Spilled() int {
+	return len(s.runs)
+}
+
+// spill сортирует накопленные элементы и записывает их в новый временный файл
+func (s *Sorter[T]) spill() error {
+	slices.SortStableFunc(s.items, s.compare)
+
+	file, err := os.CreateTemp(s.dir, "sort-*.run")
+	if err != nil {
+		return fmt.Errorf("spill(): %w", err)
+	}
+	s.runs = append(s.runs, file)
+
+	writer := bufio.NewWriter(file)
+	encoder := gob.NewEncoder(writer)
+	for _, item := range s.items {
+		if err := encoder.Encode(&item); err != nil {
+			return fmt.Errorf("spill(): %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("spill(): %w", err)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("spill(): %w", err)
+	}
+
+	s.items = nil
+	s.used = 0
+
+	return nil
+}
+
+// Sort возвращает итератор по всем добавленным элементам в порядке compare. Если на диск ничего
+// не сбрасывалось, элементы сортируются в памяти. После Sort элементы добавлять нельзя
+func (s *Sorter[T]) Sort() (*Iterator[T], error) {
+	if len(s.runs) == 0 {
+		slices.SortStableFunc(s.items, s.compare)
+		return &Iterator[T]{items: s.items, position: -1}, nil
+	}
+
+	if len(s.items) > 0 {
+		if err := s.spill(); err != nil {
+			return nil, err
+		}
+	}
+
+	merge := &mergeHeap[T]{compare: s.compare}
+	for i, file := range s.runs {
+		run := &run[T]{decoder: gob.NewDecoder(bufio.NewReader(file)), index: i}
+		ok, err := run.next()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			merge.runs = append(merge.runs, run)
+		}
+	}
+	heap.Init(merge)
+
+	return &Iterator[T]{merge: merge, position: -1}, nil
+}
+
+// Close удаляет временные файлы отрезков
+func (s *Sorter[T]) Close() error {
+	var errs []error
+	for _, file := range s.runs {
+		errs = append(errs, file.Close(), os.Remove(file.Name()))
+	}
+	s.runs = nil
+	s.items = nil
+
+	return errors.Join(errs...)
+}
+
+// Iterator отдает отсортированные элементы по одному: из памяти или слиянием отрезков
+type Iterator[T any] struct {
+	items    []T
+	position int
+
+	merge   *mergeHeap[T]
+	current T
+	err     error
+}
+
+func (it *Iterator[T]) Next() bool {
+	if it.merge == nil {
+		it.position++
+		return it.position < len(it.items)
+	}
+
+	if it.err != nil || it.merge.Len() == 0 {
+		return false
+	}
+
+	run := it.merge.runs[0]
+	it.current = run.current
+
+	ok, err := run.next()
+	if err != nil {
+		it.err = err
+		return false
+	}
+	if ok {
+		heap.Fix(it.merge, 0)
+	} else {
+		heap.Pop(it.merge)
+	}
+
+	return true
+}
+
+func (it *Iterator[T]) Item() T {
+	if it.merge == nil {
+		return it.items[it.position]
+	}
+
+	return it.current
+}
+
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// run читает элементы одного отрезка
+type run[T any] struct {
+	decoder *gob.Decoder
+	current T
+	// index - номер отрезка: из равных элементов первым отдается элемент более раннего отрезка
+	index int
+}
+
+// next читает следующий элемент отрезка. false - отрезок закончился
+func (r *run[T]) next() (bool, error) {
+	var item T
+	if err := r.decoder.Decode(&item); err != nil {
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		return false, fmt.Errorf("next(): %w", err)
+	}
+	r.current = item
+
+	return true, nil
+}
+
+// mergeHeap - куча отрезков по их текущему элементу
+type mergeHeap[T any] struct {
+	runs    []*run[T]
+	compare func(a, b T) int
+}
+
+func (h *mergeHeap[T]) Len() int {
+	return len(h.runs)
+}
+
+func (h *mergeHeap[T]) Less(i, j int) bool {
+	if cmp := h.compare(h.runs[i].current, h.runs[j].current); cmp != 0 {
+		return cmp < 0
+	}
+
+	return h.runs[i].index < h.runs[j].index
+}
+
+func (h *mergeHeap[T]) Swap(i, j int) {
+	h.runs[i], h.runs[j] = h.runs[j], h.runs[i]
+}
+
+func (h *mergeHeap[T]) Push(x any) {
+	h.runs = append(h.runs, x.(*run[T]))
+}
+
+func (h *mergeHeap[T]) Pop() any {
+	last := h.runs[len(h.runs)-1]
+	h.runs = h.runs[:len(h.runs)-1]
+
+	return last
+}
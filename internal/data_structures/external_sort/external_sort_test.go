@@ -0,0 +1,161 @@
+package external_sort
+
+import (
+	"cmp"
+	"math/rand"
+	"os"
+	"slices"
+	"testing"
+)
+
+// pair - элемент с ключом сортировки и порядковым номером для проверки устойчивости
+type pair struct {
+	Key   int
+	Order int
+}
+
+func comparePairs(a, b pair) int {
+	return cmp.Compare(a.Key, b.Key)
+}
+
+func pairSize(pair) int {
+	return 16
+}
+
+// collect читает все элементы итератора
+func collect[T any](t *testing.T, it *Iterator[T]) []T {
+	t.Helper()
+
+	items := []T{}
+	for it.Next() {
+		items = append(items, it.Item())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Ошибка итератора: %v", err)
+	}
+
+	return items
+}
+
+// checkSorted проверяет, что элементы отсортированы по ключу, а равные идут в порядке добавления
+func checkSorted(t *testing.T, items []pair, count int) {
+	t.Helper()
+
+	if len(items) != count {
+		t.Fatalf("Ожидалось %d элементов, получено %d", count, len(items))
+	}
+	for i := 1; i < len(items); i++ {
+		prev, item := items[i-1], items[i]
+		if prev.Key > item.Key || (prev.Key == item.Key && prev.Order > item.Order) {
+			t.Fatalf("Нарушен порядок на позиции %d: %v после %v", i, item, prev)
+		}
+	}
+}
+
+// TestSortInMemory проверяет сортировку, которая помещается в лимит памяти
+func TestSortInMemory(t *testing.T) {
+	dir := t.TempDir()
+	sorter := NewSorter(comparePairs, pairSize, 1<<20, dir)
+	defer sorter.Close()
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		if err := sorter.Add(pair{Key: rng.Intn(50), Order: i}); err != nil {
+			t.Fatalf("Ошибка добавления: %v", err)
+		}
+	}
+
+	it, err := sorter.Sort()
+	if err != nil {
+		t.Fatalf("Ошибка сортировки: %v", err)
+	}
+
+	if sorter.Spilled() != 0 {
+		t.Errorf("Элементы не должны сбрасываться на диск, сброшено отрезков: %d", sorter.Spilled())
+	}
+	checkSorted(t, collect(t, it), 1000)
+}
+
+// TestSortSpillsToDisk проверяет слияние отрезков, сброшенных на диск, и удаление их файлов
+func TestSortSpillsToDisk(t *testing.T) {
+	dir := t.TempDir()
+	// В память помещается 100 элементов
+	sorter := NewSorter(comparePairs, pairSize, 100*16, dir)
+
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 1050; i++ {
+		if err := sorter.Add(pair{Key: rng.Intn(50), Order: i}); err != nil {
+			t.Fatalf("Ошибка добавления: %v", err)
+		}
+	}
+
+	it, err := sorter.Sort()
+	if err != nil {
+		t.Fatalf("Ошибка сортировки: %v", err)
+	}
+
+	if sorter.Spilled() != 11 {
+		t.Errorf("Ожидалось 11 отрезков, сброшено %d", sorter.Spilled())
+	}
+	checkSorted(t, collect(t, it), 1050)
+
+	if err := sorter.Close(); err != nil {
+		t.Fatalf("Ошибка закрытия: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Файлы отрезков должны быть удалены, осталось %d", len(entries))
+	}
+}
+
+// TestSortRows проверяет, что строки со значениями разных типов и NULL переживают запись на диск
+func TestSortRows(t *testing.T) {
+	compare := func(a, b []interface{}) int {
+		return cmp.Compare(a[0].(int32), b[0].(int32))
+	}
+	size := func(row []interface{}) int {
+		return 64
+	}
+
+	sorter := NewSorter(compare, size, 64, t.TempDir())
+	defer sorter.Close()
+
+	rows := [][]interface{}{
+		{int32(3), "c", nil},
+		{int32(1), nil, true},
+		{int32(2), "b", false},
+	}
+	for _, row := range rows {
+		if err := sorter.Add(row); err != nil {
+			t.Fatalf("Ошибка добавления: %v", err)
+		}
+	}
+
+	it, err := sorter.Sort()
+	if err != nil {
+		t.Fatalf("Ошибка сортировки: %v", err)
+	}
+
+	got := collect(t, it)
+	want := [][]interface{}{rows[1], rows[2], rows[0]}
+	if !slices.EqualFunc(got, want, slices.Equal[[]interface{}]) {
+		t.Errorf("Ожидалось %v, получено %v", want, got)
+	}
+}
+
+// TestSortEmpty проверяет сортировку без элементов
+func TestSortEmpty(t *testing.T) {
+	sorter := NewSorter(comparePairs, pairSize, 0, t.TempDir())
+	defer sorter.Close()
+
+	it, err := sorter.Sort()
+	if err != nil {
+		t.Fatalf("Ошибка сортировки: %v", err)
+	}
+	if it.Next() {
+		t.Error("Итератор пустой сортировки не должен отдавать элементы")
+	}
+}
@@ -0,0 +1,439 @@
+package btree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// TreeService - B+ дерево, узлы которого хранятся страницами по 4KB в отдельном файле.
+// Первая страница - заголовок с корнем дерева и списком свободных страниц.
+// Одна операция пишет несколько страниц (при разделении и слиянии узлов), поэтому дерево
+// должно работать через файл disk_manager: все страницы операции попадают в журнал одной
+// физической транзакцией и после падения применяются либо все, либо ни одной.
+// Ключи - байты, которые сравниваются побайтово (см. EncodeKey). Синхронизацию операций обеспечивает вызывающий код
+type TreeService interface {
+	// Insert добавляет запись. Повторная вставка той же пары ключ-значение ничего не меняет
+	Insert(key []byte, value uint64) error
+	// Delete удаляет запись и сообщает, была ли она в дереве
+	Delete(key []byte, value uint64) (bool, error)
+	// Search возвращает значения записей с ключом key
+	Search(key []byte) ([]uint64, error)
+	// Range вызывает fn для записей с ключами from <= key < to в порядке ключей, пока fn возвращает true.
+	// nil вместо границы - диапазон с этой стороны не ограничен
+	Range(from, to []byte, fn func(key []byte, value uint64) bool) error
+	// Iterator возвращает курсор по записям в порядке ключей, а при reverse - в обратном
+	Iterator(reverse bool) Iterator
+	Fanout() int
+	// KeySize - максимальная длина ключа в байтах
+	KeySize() int
+}
+
+// ErrKeyTooLong возвращает Insert для ключа длиннее KeySize
+var ErrKeyTooLong = errors.New("key is too long")
+
+// File - файл дерева, как и data.File: *os.File или файл disk_manager с журналом
+type File interface {
+	io.ReaderAt
+	io.WriterAt
+	Sync() error
+}
+
+type tree struct {
+	file    File
+	fanout  int
+	keySize int
+}
+
+// NewTree открывает дерево в файле. В пустом файле создается пустое дерево для ключей
+// не длиннее keySize байт, у которого в узле не больше fanout ключей (0 - сколько помещается
+// на странице). У существующего дерева fanout и keySize берутся из заголовка
+func NewTree(file File, fanout, keySize int) (TreeService, error) {
+	if file == nil {
+		return nil, fmt.Errorf("NewTree(): file is nil")
+	}
+
+	t := &tree{file: file}
+
+	_, err := file.ReadAt(make([]byte, 1), 0)
+	if err == nil {
+		h, err := t.readHeader()
+		if err != nil {
+			return nil, fmt.Errorf("NewTree(): %w", err)
+		}
+		t.fanout = h.fanout
+		t.keySize = h.keySize
+		return t, nil
+	}
+	if !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("NewTree(): file.ReadAt: %w", err)
+	}
+
+	fanout, err = checkParams(fanout, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("NewTree(): %w", err)
+	}
+
+	// Пустой лист пишется раньше заголовка, который на него ссылается
+	h := &header{root: HEADER_PAGE_ID + 1, pageCount: HEADER_PAGE_ID + 2, fanout: fanout, keySize: keySize}
+	err = t.writeNode(&node{id: h.root, leaf: true})
+	if err != nil {
+		return nil, fmt.Errorf("NewTree(): %w", err)
+	}
+
+	err = t.writeHeader(h)
+	if err != nil {
+		return nil, fmt.Errorf("NewTree(): %w", err)
+	}
+	t.fanout = fanout
+	t.keySize = keySize
+
+	return t, nil
+}
+
+// checkParams проверяет параметры нового дерева и возвращает fanout, где 0 заменен на максимальный
+func checkParams(fanout, keySize int) (int, error) {
+	if keySize < 1 || keySize > MAX_KEY_SIZE {
+		return 0, fmt.Errorf("key size must be between 1 and %d, got %d", MAX_KEY_SIZE, keySize)
+	}
+	if fanout == 0 {
+		fanout = MaxFanout(keySize)
+	}
+	if fanout < MIN_FANOUT || fanout > MaxFanout(keySize) {
+		return 0, fmt.Errorf("fanout must be between %d and %d, got %d", MIN_FANOUT, MaxFanout(keySize), fanout)
+	}
+
+	return fanout, nil
+}
+
+func (t *tree) Fanout() int {
+	return t.fanout
+}
+
+func (t *tree) KeySize() int {
+	return t.keySize
+}
+
+// Search читает диапазон [key, key+0x00): следующего за key ключа меньше него нет
+func (t *tree) Search(key []byte) ([]uint64, error) {
+	values := []uint64{}
+	next := append(append([]byte{}, key...), 0)
+	err := t.Range(key, next, func(_ []byte, value uint64) bool {
+		values = append(values, value)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Search(): %w", err)
+	}
+
+	return values, nil
+}
+
+// Range обходит записи курсором, начиная с первой записи ключа from
+func (t *tree) Range(from, to []byte, fn func(key []byte, value uint64) bool) error {
+	if from != nil && to != nil && bytes.Compare(from, to) >= 0 {
+		return nil
+	}
+
+	it := t.Iterator(false)
+	err := it.Seek(from)
+	if err != nil {
+		return fmt.Errorf("Range(): %w", err)
+	}
+
+	for it.Next() {
+		if (to != nil && bytes.Compare(it.Key(), to) >= 0) || !fn(it.Key(), it.Value()) {
+			return nil
+		}
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("Range(): %w", err)
+	}
+
+	return nil
+}
+
+// split - результат разделения узла: разделитель и новый правый узел для родителя
+type split struct {
+	separator Entry
+	right     uint32
+}
+
+func (t *tree) Insert(key []byte, value uint64) error {
+	h, err := t.readHeader()
+	if err != nil {
+		return fmt.Errorf("Insert(): %w", err)
+	}
+	if len(key) > h.keySize {
+		return fmt.Errorf("Insert(): %w: %d bytes, max %d", ErrKeyTooLong, len(key), h.keySize)
+	}
+
+	s, err := t.insert(h, h.root, Entry{Key: key, Value: value})
+	if err != nil {
+		return fmt.Errorf("Insert(): %w", err)
+	}
+
+	// Разделился корень: дерево растет на уровень вверх
+	if s != nil {
+		root, err := t.allocate(h, false)
+		if err != nil {
+			return fmt.Errorf("Insert(): %w", err)
+		}
+		root.entries = []Entry{s.separator}
+		root.children = []uint32{h.root, s.right}
+
+		err = t.writeNode(root)
+		if err != nil {
+			return fmt.Errorf("Insert(): %w", err)
+		}
+		h.root = root.id
+		h.dirty = true
+	}
+
+	if h.dirty {
+		err = t.writeHeader(h)
+		if err != nil {
+			return fmt.Errorf("Insert(): %w", err)
+		}
+	}
+
+	return nil
+}
+
+// insert вставляет запись в поддерево узла id и разделяет переполненные узлы на обратном пути
+func (t *tree) insert(h *header, id uint32, entry Entry) (*split, error) {
+	n, err := t.readNode(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.leaf {
+		i := sort.Search(len(n.entries), func(i int) bool { return n.entries[i].Compare(entry) >= 0 })
+		if i < len(n.entries) && n.entries[i].Compare(entry) == 0 {
+			return nil, nil
+		}
+		n.entries = insertAt(n.entries, i, entry)
+	} else {
+		i := childIndex(n, entry)
+		s, err := t.insert(h, n.children[i], entry)
+		if err != nil || s == nil {
+			return nil, err
+		}
+		n.entries = insertAt(n.entries, i, s.separator)
+		n.children = insertAt(n.children, i+1, s.right)
+	}
+
+	if len(n.entries) <= h.fanout {
+		return nil, t.writeNode(n)
+	}
+
+	right, err := t.allocate(h, n.leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	mid := len(n.entries) / 2
+	var separator Entry
+	if n.leaf {
+		right.entries = append([]Entry{}, n.entries[mid:]...)
+		n.entries = n.entries[:mid]
+		right.next = n.next
+		n.next = right.id
+		separator = right.entries[0]
+	} else {
+		// Средний ключ поднимается в родителя и не остается в узлах
+		separator = n.entries[mid]
+		right.entries = append([]Entry{}, n.entries[mid+1:]...)
+		right.children = append([]uint32{}, n.children[mid+1:]...)
+		n.entries = n.entries[:mid]
+		n.children = n.children[:mid+1]
+	}
+
+	// Сначала пишется новый узел, потом ссылающиеся на него
+	err = t.writeNode(right)
+	if err != nil {
+		return nil, err
+	}
+	err = t.writeNode(n)
+	if err != nil {
+		return nil, err
+	}
+
+	return &split{separator: separator, right: right.id}, nil
+}
+
+func (t *tree) Delete(key []byte, value uint64) (bool, error) {
+	h, err := t.readHeader()
+	if err != nil {
+		return false, fmt.Errorf("Delete(): %w", err)
+	}
+
+	found, _, err := t.delete(h, h.root, Entry{Key: key, Value: value})
+	if err != nil {
+		return false, fmt.Errorf("Delete(): %w", err)
+	}
+
+	// У корня остался один потомок: дерево становится ниже на уровень
+	root, err := t.readNode(h.root)
+	if err != nil {
+		return false, fmt.Errorf("Delete(): %w", err)
+	}
+	if !root.leaf && len(root.entries) == 0 {
+		err = t.free(h, root.id)
+		if err != nil {
+			return false, fmt.Errorf("Delete(): %w", err)
+		}
+		h.root = root.children[0]
+	}
+
+	if h.dirty {
+		err = t.writeHeader(h)
+		if err != nil {
+			return false, fmt.Errorf("Delete(): %w", err)
+		}
+	}
+
+	return found, nil
+}
+
+// delete удаляет запись из поддерева узла id. Возвращает, найдена ли запись
+// и осталось ли в узле меньше минимума ключей
+func (t *tree) delete(h *header, id uint32, entry Entry) (bool, bool, error) {
+	n, err := t.readNode(id)
+	if err != nil {
+		return false, false, err
+	}
+
+	if n.leaf {
+		i := sort.Search(len(n.entries), func(i int) bool { return n.entries[i].Compare(entry) >= 0 })
+		if i == len(n.entries) || n.entries[i].Compare(entry) != 0 {
+			return false, false, nil
+		}
+		n.entries = append(n.entries[:i], n.entries[i+1:]...)
+
+		return true, len(n.entries) < minKeys(h), t.writeNode(n)
+	}
+
+	i := childIndex(n, entry)
+	found, underflow, err := t.delete(h, n.children[i], entry)
+	if err != nil || !underflow {
+		return found, false, err
+	}
+
+	err = t.rebalance(h, n, i)
+	if err != nil {
+		return false, false, err
+	}
+
+	return true, len(n.entries) < minKeys(h), t.writeNode(n)
+}
+
+// rebalance пополняет потомка i, в котором меньше минимума ключей: занимает ключ у соседа,
+// а если у соседей ключей тоже минимум - сливает потомка с соседом. Родитель пишет вызывающий код
+func (t *tree) rebalance(h *header, parent *node, i int) error {
+	child, err := t.readNode(parent.children[i])
+	if err != nil {
+		return err
+	}
+
+	var left, right *node
+	if i > 0 {
+		left, err = t.readNode(parent.children[i-1])
+		if err != nil {
+			return err
+		}
+	}
+	if i < len(parent.children)-1 {
+		right, err = t.readNode(parent.children[i+1])
+		if err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case left != nil && len(left.entries) > minKeys(h):
+		last := len(left.entries) - 1
+		if child.leaf {
+			child.entries = insertAt(child.entries, 0, left.entries[last])
+			parent.entries[i-1] = left.entries[last]
+		} else {
+			child.entries = insertAt(child.entries, 0, parent.entries[i-1])
+			child.children = insertAt(child.children, 0, left.children[last+1])
+			parent.entries[i-1] = left.entries[last]
+			left.children = left.children[:last+1]
+		}
+		left.entries = left.entries[:last]
+
+		return t.writeNodes(left, child)
+	case right != nil && len(right.entries) > minKeys(h):
+		if child.leaf {
+			child.entries = append(child.entries, right.entries[0])
+			parent.entries[i] = right.entries[1]
+		} else {
+			child.entries = append(child.entries, parent.entries[i])
+			child.children = append(child.children, right.children[0])
+			parent.entries[i] = right.entries[0]
+			right.children = right.children[1:]
+		}
+		right.entries = right.entries[1:]
+
+		return t.writeNodes(right, child)
+	case left != nil:
+		return t.merge(h, parent, i-1, left, child)
+	default:
+		return t.merge(h, parent, i, child, right)
+	}
+}
+
+// merge переносит записи right в left и удаляет из родителя разделитель k между ними.
+// Страница right освобождается
+func (t *tree) merge(h *header, parent *node, k int, left, right *node) error {
+	if left.leaf {
+		left.entries = append(left.entries, right.entries...)
+		left.next = right.next
+	} else {
+		left.entries = append(append(left.entries, parent.entries[k]), right.entries...)
+		left.children = append(left.children, right.children...)
+	}
+
+	parent.entries = append(parent.entries[:k], parent.entries[k+1:]...)
+	parent.children = append(parent.children[:k+1], parent.children[k+2:]...)
+
+	err := t.writeNode(left)
+	if err != nil {
+		return err
+	}
+
+	return t.free(h, right.id)
+}
+
+func (t *tree) writeNodes(nodes ...*node) error {
+	for _, n := range nodes {
+		err := t.writeNode(n)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// childIndex возвращает номер потомка внутреннего узла, в поддереве которого лежит запись
+func childIndex(n *node, entry Entry) int {
+	return sort.Search(len(n.entries), func(i int) bool { return n.entries[i].Compare(entry) > 0 })
+}
+
+// minKeys - минимальное количество ключей в узле, кроме корня
+func minKeys(h *header) int {
+	return h.fanout / 2
+}
+
+func insertAt[T any](items []T, i int, item T) []T {
+	items = append(items, item)
+	copy(items[i+1:], items[i:])
+	items[i] = item
+
+	return items
+}
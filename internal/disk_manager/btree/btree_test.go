@@ -0,0 +1,443 @@
+package btree
+
+import (
+	"custom-database/internal/disk_manager/binary_serializer"
+	"custom-database/internal/disk_manager/data"
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTree(t *testing.T) {
+	// Ключи в тестах - один int64, как у индекса по колонке BIGINT
+	keySize := KeySize([]data.Column{{Type: data.TypeInt64}})
+	key := func(k int64) []byte {
+		encoded, err := EncodeKey([]data.DataCell{{Value: k, Type: data.TypeInt64}})
+		require.NoError(t, err)
+		return encoded
+	}
+	decode := func(encoded []byte) int64 {
+		return int64(binary.BigEndian.Uint64(encoded[MARKER_SIZE:]) ^ (1 << 63))
+	}
+	openTree := func(t *testing.T, path string, fanout int) TreeService {
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+		require.NoError(t, err)
+		t.Cleanup(func() { file.Close() })
+
+		bt, err := NewTree(file, fanout, keySize)
+		require.NoError(t, err)
+
+		return bt
+	}
+	// pair - запись с раскодированным ключом
+	type pair struct {
+		key   int64
+		value uint64
+	}
+	rangeEntries := func(t *testing.T, bt TreeService, from, to []byte) []pair {
+		entries := []pair{}
+		err := bt.Range(from, to, func(k []byte, value uint64) bool {
+			entries = append(entries, pair{key: decode(k), value: value})
+			return true
+		})
+		require.NoError(t, err)
+
+		return entries
+	}
+	// iterate читает limit записей курсора, поставленного на from
+	iterate := func(t *testing.T, bt TreeService, reverse bool, from []byte, limit int) []pair {
+		it := bt.Iterator(reverse)
+		require.NoError(t, it.Seek(from))
+
+		entries := []pair{}
+		for len(entries) < limit && it.Next() {
+			entries = append(entries, pair{key: decode(it.Key()), value: it.Value()})
+		}
+		require.NoError(t, it.Err())
+
+		return entries
+	}
+	// checkTree проверяет структуру дерева: порядок записей, заполненность узлов,
+	// одинаковую глубину листьев и связный список листьев
+	checkTree := func(t *testing.T, bt TreeService) {
+		tr := bt.(*tree)
+		h, err := tr.readHeader()
+		require.NoError(t, err)
+
+		leaves := []uint32{}
+		depth := -1
+		var walk func(id uint32, level int, low, high *Entry)
+		walk = func(id uint32, level int, low, high *Entry) {
+			n, err := tr.readNode(id)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(n.entries), h.fanout)
+			if id != h.root {
+				require.GreaterOrEqual(t, len(n.entries), minKeys(h), "node %d", id)
+			}
+
+			for i, entry := range n.entries {
+				if i > 0 {
+					require.Negative(t, n.entries[i-1].Compare(entry))
+				}
+				if low != nil {
+					require.GreaterOrEqual(t, entry.Compare(*low), 0)
+				}
+				if high != nil {
+					require.Negative(t, entry.Compare(*high))
+				}
+			}
+
+			if n.leaf {
+				if depth == -1 {
+					depth = level
+				}
+				require.Equal(t, depth, level, "leaves must be on the same level")
+				leaves = append(leaves, id)
+				return
+			}
+
+			require.Len(t, n.children, len(n.entries)+1)
+			for i, child := range n.children {
+				childLow, childHigh := low, high
+				if i > 0 {
+					childLow = &n.entries[i-1]
+				}
+				if i < len(n.entries) {
+					childHigh = &n.entries[i]
+				}
+				walk(child, level+1, childLow, childHigh)
+			}
+		}
+		walk(h.root, 0, nil, nil)
+
+		for i, id := range leaves {
+			n, err := tr.readNode(id)
+			require.NoError(t, err)
+			if i == len(leaves)-1 {
+				require.Zero(t, n.next)
+			} else {
+				require.Equal(t, leaves[i+1], n.next)
+			}
+		}
+	}
+
+	t.Run("вставка, поиск и дубликаты ключей", func(t *testing.T) {
+		bt := openTree(t, filepath.Join(t.TempDir(), "index.data"), 4)
+
+		for i := 0; i < 100; i++ {
+			require.NoError(t, bt.Insert(key(int64(i%10)), uint64(i)))
+		}
+		// Повторная вставка той же записи ничего не меняет
+		require.NoError(t, bt.Insert(key(3), 3))
+		checkTree(t, bt)
+
+		values, err := bt.Search(key(3))
+		require.NoError(t, err)
+		require.Equal(t, []uint64{3, 13, 23, 33, 43, 53, 63, 73, 83, 93}, values)
+
+		values, err = bt.Search(key(42))
+		require.NoError(t, err)
+		require.Empty(t, values)
+
+		entries := rangeEntries(t, bt, key(8), nil)
+		require.Len(t, entries, 20)
+		require.Equal(t, pair{key: 8, value: 8}, entries[0])
+		require.Equal(t, pair{key: 9, value: 99}, entries[19])
+
+		// Верхняя граница не входит в диапазон
+		require.Len(t, rangeEntries(t, bt, key(2), key(4)), 20)
+	})
+
+	t.Run("диапазон останавливается, когда fn возвращает false", func(t *testing.T) {
+		bt := openTree(t, filepath.Join(t.TempDir(), "index.data"), 0)
+		require.Equal(t, MaxFanout(keySize), bt.Fanout())
+
+		for i := int64(-50); i < 50; i++ {
+			require.NoError(t, bt.Insert(key(i), uint64(i+50)))
+		}
+
+		keys := []int64{}
+		err := bt.Range(key(-3), key(100), func(k []byte, value uint64) bool {
+			keys = append(keys, decode(k))
+			return len(keys) < 5
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int64{-3, -2, -1, 0, 1}, keys)
+		require.Empty(t, rangeEntries(t, bt, key(5), key(4)))
+		require.Empty(t, rangeEntries(t, bt, key(5), key(5)))
+	})
+
+	t.Run("курсор обходит записи в обе стороны от Seek", func(t *testing.T) {
+		bt := openTree(t, filepath.Join(t.TempDir(), "index.data"), 3)
+		for i := int64(0); i < 50; i++ {
+			require.NoError(t, bt.Insert(key(i*2), uint64(i)))
+			require.NoError(t, bt.Insert(key(i*2), uint64(i+100)))
+		}
+
+		require.Equal(t, []pair{{0, 0}, {0, 100}, {2, 1}}, iterate(t, bt, false, nil, 3))
+		require.Equal(t, []pair{{98, 149}, {98, 49}, {96, 148}}, iterate(t, bt, true, nil, 3))
+
+		// Прямой курсор начинает с первой записи ключа, обратный - с последней записи меньшего ключа
+		require.Equal(t, []pair{{40, 20}, {40, 120}, {42, 21}}, iterate(t, bt, false, key(40), 3))
+		require.Equal(t, []pair{{38, 119}, {38, 19}, {36, 118}}, iterate(t, bt, true, key(40), 3))
+		require.Equal(t, []pair{{42, 21}, {42, 121}}, iterate(t, bt, false, key(41), 2))
+		require.Equal(t, []pair{{40, 120}, {40, 20}}, iterate(t, bt, true, key(41), 2))
+
+		require.Empty(t, iterate(t, bt, false, key(99), 10))
+		require.Empty(t, iterate(t, bt, true, key(0), 10))
+		require.Len(t, iterate(t, bt, true, key(1000), 1000), 100)
+
+		// Курсор без Seek обходит дерево с начала, повторный Seek переставляет его
+		it := bt.Iterator(false)
+		require.True(t, it.Next())
+		require.Equal(t, int64(0), decode(it.Key()))
+		require.NoError(t, it.Seek(key(90)))
+		require.True(t, it.Next())
+		require.Equal(t, int64(90), decode(it.Key()))
+
+		empty := openTree(t, filepath.Join(t.TempDir(), "empty.data"), 3)
+		require.Empty(t, iterate(t, empty, true, nil, 10))
+	})
+
+	t.Run("дерево читается из файла после переоткрытия", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "index.data")
+		bt := openTree(t, path, 5)
+		for i := 0; i < 300; i++ {
+			require.NoError(t, bt.Insert(key(int64(i)), uint64(i)))
+		}
+
+		// fanout и длина ключа существующего дерева берутся из заголовка
+		file, err := os.OpenFile(path, os.O_RDWR, 0644)
+		require.NoError(t, err)
+		defer file.Close()
+		reopened, err := NewTree(file, 0, MAX_KEY_SIZE)
+		require.NoError(t, err)
+		require.Equal(t, 5, reopened.Fanout())
+		require.Equal(t, keySize, reopened.KeySize())
+		require.Len(t, rangeEntries(t, reopened, nil, nil), 300)
+		checkTree(t, reopened)
+	})
+
+	t.Run("недопустимые fanout и длина ключа", func(t *testing.T) {
+		file, err := os.Create(filepath.Join(t.TempDir(), "index.data"))
+		require.NoError(t, err)
+		defer file.Close()
+
+		_, err = NewTree(file, 2, keySize)
+		require.Error(t, err)
+		_, err = NewTree(file, MaxFanout(keySize)+1, keySize)
+		require.Error(t, err)
+		_, err = NewTree(file, 0, MAX_KEY_SIZE+1)
+		require.Error(t, err)
+		_, err = NewTree(file, 0, 0)
+		require.Error(t, err)
+	})
+
+	t.Run("ключи переменной длины и слишком длинный ключ", func(t *testing.T) {
+		file, err := os.Create(filepath.Join(t.TempDir(), "index.data"))
+		require.NoError(t, err)
+		defer file.Close()
+
+		columns := []data.Column{{Type: data.TypeText}, {Type: data.TypeInt32}}
+		bt, err := NewTree(file, 4, KeySize(columns))
+		require.NoError(t, err)
+
+		textKey := func(text string, number int32) []byte {
+			encoded, err := EncodeKey([]data.DataCell{{Value: text, Type: data.TypeText}, {Value: number, Type: data.TypeInt32}})
+			require.NoError(t, err)
+			return encoded
+		}
+
+		words := []string{"pear", "apple", "", "apple pie", "banana", "a\x00b", "a"}
+		for i, word := range words {
+			require.NoError(t, bt.Insert(textKey(word, int32(i)), uint64(i)))
+			require.NoError(t, bt.Insert(textKey(word, -int32(i)), uint64(100+i)))
+		}
+		checkTree(t, bt)
+
+		values := []uint64{}
+		err = bt.Range(nil, nil, func(_ []byte, value uint64) bool {
+			values = append(values, value)
+			return true
+		})
+		require.NoError(t, err)
+		// "", "a", "a\x00b", "apple", "apple pie", "banana", "pear", внутри строки - по числу, затем по значению
+		require.Equal(t, []uint64{102, 2, 106, 6, 105, 5, 101, 1, 103, 3, 104, 4, 0, 100}, values)
+
+		// Все записи со строкой "apple" - диапазон по префиксу ключа
+		prefix, err := EncodeKey([]data.DataCell{{Value: "apple", Type: data.TypeText}})
+		require.NoError(t, err)
+		values = []uint64{}
+		err = bt.Range(prefix, PrefixEnd(prefix), func(_ []byte, value uint64) bool {
+			values = append(values, value)
+			return true
+		})
+		require.NoError(t, err)
+		require.Equal(t, []uint64{101, 1}, values)
+
+		long := textKey(string(make([]byte, TEXT_KEY_SIZE)), 1)
+		require.ErrorIs(t, bt.Insert(long, 1), ErrKeyTooLong)
+	})
+
+	t.Run("построение снизу вверх из отсортированных записей", func(t *testing.T) {
+		build := func(t *testing.T, fanout, fillFactor, count int) TreeService {
+			file, err := os.Create(filepath.Join(t.TempDir(), "index.data"))
+			require.NoError(t, err)
+			t.Cleanup(func() { file.Close() })
+
+			builder, err := NewBuilder(file, fanout, keySize, fillFactor)
+			require.NoError(t, err)
+			// Каждый ключ повторяется дважды с разными значениями
+			for i := 0; i < count; i++ {
+				require.NoError(t, builder.Add(key(int64(i/2)), uint64(i%2)))
+			}
+			bt, err := builder.Finish()
+			require.NoError(t, err)
+
+			return bt
+		}
+		leafCount := func(t *testing.T, bt TreeService) int {
+			tr := bt.(*tree)
+			h, err := tr.readHeader()
+			require.NoError(t, err)
+			n, err := tr.readNode(h.root)
+			require.NoError(t, err)
+			for !n.leaf {
+				n, err = tr.readNode(n.children[0])
+				require.NoError(t, err)
+			}
+
+			count := 1
+			for n.next != 0 {
+				n, err = tr.readNode(n.next)
+				require.NoError(t, err)
+				count++
+			}
+			return count
+		}
+
+		for _, fanout := range []int{3, 4, 7, MaxFanout(keySize)} {
+			for _, fillFactor := range []int{MIN_FILL_FACTOR, 70, MAX_FILL_FACTOR} {
+				for _, count := range []int{0, 1, fanout, fanout + 1, 1000} {
+					bt := build(t, fanout, fillFactor, count)
+					checkTree(t, bt)
+
+					entries := rangeEntries(t, bt, nil, nil)
+					require.Len(t, entries, count, "fanout %d, fill factor %d", fanout, fillFactor)
+					for i, entry := range entries {
+						require.Equal(t, pair{key: int64(i / 2), value: uint64(i % 2)}, entry)
+					}
+
+					// После построения дерево меняется как обычно
+					require.NoError(t, bt.Insert(key(-1), 0))
+					found, err := bt.Delete(key(0), 1)
+					require.NoError(t, err)
+					require.Equal(t, count > 1, found)
+					checkTree(t, bt)
+				}
+			}
+		}
+
+		// Листья заполнены на fillFactor процентов: при 100% их вдвое меньше, чем при 50%
+		require.Equal(t, 100, leafCount(t, build(t, 10, MAX_FILL_FACTOR, 1000)))
+		require.Equal(t, 200, leafCount(t, build(t, 10, MIN_FILL_FACTOR, 1000)))
+	})
+
+	t.Run("построение из неупорядоченных записей и в непустом файле", func(t *testing.T) {
+		file, err := os.Create(filepath.Join(t.TempDir(), "index.data"))
+		require.NoError(t, err)
+		defer file.Close()
+
+		_, err = NewBuilder(file, 4, keySize, MIN_FILL_FACTOR-1)
+		require.Error(t, err)
+		_, err = NewBuilder(file, 4, keySize, MAX_FILL_FACTOR+1)
+		require.Error(t, err)
+
+		builder, err := NewBuilder(file, 4, keySize, 0)
+		require.NoError(t, err)
+		require.NoError(t, builder.Add(key(2), 0))
+		require.Error(t, builder.Add(key(1), 0))
+		require.Error(t, builder.Add(key(2), 0))
+		require.NoError(t, builder.Add(key(2), 1))
+		_, err = builder.Finish()
+		require.NoError(t, err)
+
+		_, err = NewBuilder(file, 4, keySize, 0)
+		require.Error(t, err)
+	})
+
+	t.Run("случайные вставки и удаления совпадают с моделью", func(t *testing.T) {
+		random := rand.New(rand.NewSource(42))
+
+		for _, fanout := range []int{3, 4, 7, MaxFanout(keySize)} {
+			bt := openTree(t, filepath.Join(t.TempDir(), "index.data"), fanout)
+			model := map[pair]bool{}
+
+			for i := 0; i < 3000; i++ {
+				entry := pair{key: int64(random.Intn(200) - 100), value: uint64(random.Intn(5))}
+
+				if random.Intn(3) == 0 {
+					found, err := bt.Delete(key(entry.key), entry.value)
+					require.NoError(t, err)
+					require.Equal(t, model[entry], found)
+					delete(model, entry)
+				} else {
+					require.NoError(t, bt.Insert(key(entry.key), entry.value))
+					model[entry] = true
+				}
+			}
+			checkTree(t, bt)
+
+			expected := []pair{}
+			for entry := range model {
+				expected = append(expected, entry)
+			}
+			sort.Slice(expected, func(i, j int) bool {
+				if expected[i].key != expected[j].key {
+					return expected[i].key < expected[j].key
+				}
+				return expected[i].value < expected[j].value
+			})
+			require.Equal(t, expected, rangeEntries(t, bt, nil, nil), "fanout %d", fanout)
+
+			reversed := iterate(t, bt, true, nil, len(expected)+1)
+			for i := range reversed {
+				require.Equal(t, expected[len(expected)-1-i], reversed[i], "fanout %d", fanout)
+			}
+			require.Len(t, reversed, len(expected))
+
+			// После удаления всех записей страницы возвращаются в список свободных и используются снова
+			for _, entry := range expected {
+				found, err := bt.Delete(key(entry.key), entry.value)
+				require.NoError(t, err)
+				require.True(t, found)
+			}
+			checkTree(t, bt)
+			require.Empty(t, rangeEntries(t, bt, nil, nil))
+
+			tr := bt.(*tree)
+			h, err := tr.readHeader()
+			require.NoError(t, err)
+			free := 0
+			for id := h.freePage; id != 0; free++ {
+				page, err := tr.readPage(id)
+				require.NoError(t, err)
+				id = binary_serializer.ReadUint32(page, PAGE_ID_SIZE+NODE_TYPE_SIZE+KEYS_COUNT_SIZE)
+			}
+			require.Equal(t, int(h.pageCount)-2, free, "fanout %d: all pages except header and root are free", fanout)
+
+			// Разделение листа берет страницу из списка свободных, а не из конца файла
+			for i := 0; i <= fanout; i++ {
+				require.NoError(t, bt.Insert(key(int64(i)), 0))
+			}
+			after, err := tr.readHeader()
+			require.NoError(t, err)
+			require.Equal(t, h.pageCount, after.pageCount, "fanout %d", fanout)
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Builder строит дерево в пустом файле снизу вверх из записей, которые добавляются в порядке
+// возрастания (Key, Value). В отличие от вставки по одной, узлы не разделяются: листья заполняются
+// подряд на fillFactor процентов и пишутся по мере заполнения, а внутренние узлы строятся в Finish
+// по первым записям листьев. Каждая страница пишется один раз, поэтому построение индекса
+// по заполненной таблице не читает и не переписывает одни и те же страницы
+type Builder struct {
+	tree    *tree
+	h       *header
+	perNode int
+	// Два последних листа еще не записаны: в Finish последний лист, в котором меньше минимума записей,
+	// объединяется с предыдущим или забирает у него часть записей
+	prev, cur *node
+	// Первая запись и страница каждого листа - по ним строятся внутренние узлы
+	children []child
+	last     *Entry
+	finished bool
+}
+
+// child - узел следующего уровня дерева и нижняя граница его записей
+type child struct {
+	first Entry
+	id    uint32
+}
+
+// NewBuilder начинает построение дерева в пустом файле. fanout и keySize - как у NewTree,
+// fillFactor - заполненность узлов в процентах от MIN_FILL_FACTOR до MAX_FILL_FACTOR, 0 - DEFAULT_FILL_FACTOR
+func NewBuilder(file File, fanout, keySize, fillFactor int) (*Builder, error) {
+	if file == nil {
+		return nil, fmt.Errorf("NewBuilder(): file is nil")
+	}
+
+	_, err := file.ReadAt(make([]byte, 1), 0)
+	if err == nil {
+		return nil, fmt.Errorf("NewBuilder(): file is not empty")
+	}
+	if !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("NewBuilder(): file.ReadAt: %w", err)
+	}
+
+	fanout, err = checkParams(fanout, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("NewBuilder(): %w", err)
+	}
+	if fillFactor == 0 {
+		fillFactor = DEFAULT_FILL_FACTOR
+	}
+	if fillFactor < MIN_FILL_FACTOR || fillFactor > MAX_FILL_FACTOR {
+		return nil, fmt.Errorf("NewBuilder(): fill factor must be between %d and %d, got %d", MIN_FILL_FACTOR, MAX_FILL_FACTOR, fillFactor)
+	}
+
+	h := &header{pageCount: HEADER_PAGE_ID + 1, fanout: fanout, keySize: keySize}
+
+	return &Builder{
+		tree:    &tree{file: file, fanout: fanout, keySize: keySize},
+		h:       h,
+		perNode: max(minKeys(h), fanout*fillFactor/100),
+	}, nil
+}
+
+// Add добавляет запись после всех добавленных ранее. Запись, которая не больше предыдущей, - ошибка
+func (b *Builder) Add(key []byte, value uint64) error {
+	if b.finished {
+		return fmt.Errorf("Add(): tree is already built")
+	}
+	if len(key) > b.h.keySize {
+		return fmt.Errorf("Add(): %w: %d bytes, max %d", ErrKeyTooLong, len(key), b.h.keySize)
+	}
+
+	entry := Entry{Key: append([]byte{}, key...), Value: value}
+	if b.last != nil && entry.Compare(*b.last) <= 0 {
+		return fmt.Errorf("Add(): entries must be added in ascending order")
+	}
+
+	if b.cur == nil {
+		b.cur = b.newNode(true)
+	} else if len(b.cur.entries) == b.perNode {
+		if b.prev != nil {
+			err := b.tree.writeNode(b.prev)
+			if err != nil {
+				return fmt.Errorf("Add(): %w", err)
+			}
+		}
+
+		next := b.newNode(true)
+		b.cur.next = next.id
+		b.prev, b.cur = b.cur, next
+	}
+
+	if len(b.cur.entries) == 0 {
+		b.children = append(b.children, child{first: entry, id: b.cur.id})
+	}
+	b.cur.entries = append(b.cur.entries, entry)
+	b.last = &entry
+
+	return nil
+}
+
+// Finish дописывает листья, строит внутренние узлы и записывает заголовок. Заголовок пишется последним:
+// до него файл не считается деревом
+func (b *Builder) Finish() (TreeService, error) {
+	if b.finished {
+		return nil, fmt.Errorf("Finish(): tree is already built")
+	}
+	b.finished = true
+
+	if b.cur == nil {
+		b.cur = b.newNode(true)
+		b.children = append(b.children, child{id: b.cur.id})
+	}
+
+	if b.prev != nil && len(b.cur.entries) < minKeys(b.h) {
+		entries := append(b.prev.entries, b.cur.entries...)
+		if len(entries) <= b.h.fanout {
+			// Последний лист занял последнюю страницу файла, она больше не нужна
+			b.prev.entries = entries
+			b.prev.next = 0
+			b.cur = nil
+			b.h.pageCount--
+			b.children = b.children[:len(b.children)-1]
+		} else {
+			half := len(entries) / 2
+			b.prev.entries = entries[:half]
+			b.cur.entries = entries[half:]
+			b.children[len(b.children)-1].first = b.cur.entries[0]
+		}
+	}
+
+	for _, n := range []*node{b.prev, b.cur} {
+		if n == nil {
+			continue
+		}
+		err := b.tree.writeNode(n)
+		if err != nil {
+			return nil, fmt.Errorf("Finish(): %w", err)
+		}
+	}
+
+	level := b.children
+	for len(level) > 1 {
+		parents := []child{}
+		for _, size := range groupSizes(len(level), b.perNode+1, minKeys(b.h)+1, b.h.fanout+1) {
+			n := b.newNode(false)
+			for i, c := range level[:size] {
+				n.children = append(n.children, c.id)
+				if i > 0 {
+					n.entries = append(n.entries, c.first)
+				}
+			}
+
+			err := b.tree.writeNode(n)
+			if err != nil {
+				return nil, fmt.Errorf("Finish(): %w", err)
+			}
+			parents = append(parents, child{first: level[0].first, id: n.id})
+			level = level[size:]
+		}
+		level = parents
+	}
+
+	b.h.root = level[0].id
+	err := b.tree.writeHeader(b.h)
+	if err != nil {
+		return nil, fmt.Errorf("Finish(): %w", err)
+	}
+
+	return b.tree, nil
+}
+
+// newNode выдает узлу следующую страницу в конце файла: у нового дерева нет свободных страниц
+func (b *Builder) newNode(leaf bool) *node {
+	n := &node{id: b.h.pageCount, leaf: leaf}
+	b.h.pageCount++
+
+	return n
+}
+
+// groupSizes делит count потомков между узлами по capacity. Если в последнем узле меньше minimum,
+// он объединяется с предыдущим, а если вместе они больше maximum - потомки делятся между ними поровну
+func groupSizes(count, capacity, minimum, maximum int) []int {
+	sizes := []int{}
+	for count > 0 {
+		size := min(capacity, count)
+		sizes = append(sizes, size)
+		count -= size
+	}
+
+	if n := len(sizes); n > 1 && sizes[n-1] < minimum {
+		total := sizes[n-2] + sizes[n-1]
+		if total <= maximum {
+			sizes = append(sizes[:n-2], total)
+		} else {
+			sizes[n-2], sizes[n-1] = total/2, total-total/2
+		}
+	}
+
+	return sizes
+}
@@ -0,0 +1,59 @@
+package btree
+
+import "custom-database/internal/disk_manager/data"
+
+// Страницы дерева имеют тот же размер, что и страницы таблиц
+const PAGE_SIZE = data.PAGE_SIZE // 4KB
+
+// Header page: первая страница файла
+const (
+	HEADER_PAGE_ID = 0
+	MAGIC          = 0x42505432 // "BPT2"
+
+	MAGIC_SIZE      = 4 // uint32
+	ROOT_PAGE_SIZE  = 4 // Номер корневой страницы в uint32
+	PAGE_COUNT_SIZE = 4 // Количество страниц в файле, включая заголовок, в uint32
+	FANOUT_SIZE     = 2 // Максимальное количество ключей в узле в uint16
+	KEY_SIZE_SIZE   = 2 // Максимальная длина ключа в байтах в uint16
+	FREE_PAGE_SIZE  = 4 // Первая страница списка свободных страниц в uint32, 0 - список пуст
+)
+
+// Node page: [page id][тип узла][количество ключей][следующая страница], затем ключи
+const (
+	PAGE_ID_SIZE     = 4
+	NODE_TYPE_SIZE   = 1
+	KEYS_COUNT_SIZE  = 2
+	NEXT_PAGE_SIZE   = 4 // Лист: правый сосед, свободная страница: следующая свободная
+	NODE_HEADER_SIZE = PAGE_ID_SIZE + NODE_TYPE_SIZE + KEYS_COUNT_SIZE + NEXT_PAGE_SIZE
+
+	KEY_LENGTH_SIZE = 2 // Длина ключа в uint16, сам ключ - байты переменной длины
+	VALUE_SIZE      = 8 // uint64
+	CHILD_SIZE      = 4 // Номер страницы потомка в uint32
+
+	// Лист: [длина ключа, ключ, значение] * n. Внутренний узел: [потомок] + [длина ключа, ключ, значение, потомок] * n
+	MIN_FANOUT   = 3
+	MAX_KEY_SIZE = 1024 // Узел с MIN_FANOUT ключами такой длины помещается на странице
+)
+
+// Заполненность узлов дерева, которое строит Builder, в процентах от fanout
+const (
+	// Запас на странице позволяет вставлять записи после построения, не разделяя сразу все узлы
+	DEFAULT_FILL_FACTOR = 90
+	// Узел, заполненный меньше чем наполовину, нарушил бы минимальную заполненность дерева
+	MIN_FILL_FACTOR = 50
+	MAX_FILL_FACTOR = 100
+)
+
+// MaxFanout - сколько ключей длиной до keySize байт помещается в узле. Для ключа из одного
+// int64 (9 байт с маркером NULL) это 177
+func MaxFanout(keySize int) int {
+	return (PAGE_SIZE - NODE_HEADER_SIZE - CHILD_SIZE) / (KEY_LENGTH_SIZE + keySize + VALUE_SIZE + CHILD_SIZE)
+}
+
+type nodeType uint8
+
+const (
+	leafNode nodeType = iota + 1
+	internalNode
+	freePage
+)
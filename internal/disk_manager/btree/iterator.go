@@ -0,0 +1,167 @@
+package btree
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Iterator - курсор по записям дерева в порядке ключей или, для обратного курсора, в обратном порядке.
+// Страницы читаются по мере обхода, поэтому пока курсор используется, дерево менять нельзя
+type Iterator interface {
+	// Seek ставит курсор на границу key: прямой обход продолжится с первой записи с ключом >= key,
+	// обратный - с последней записи с ключом < key. nil - с начала или с конца дерева
+	Seek(key []byte) error
+	Next() bool
+	Key() []byte
+	Value() uint64
+	Err() error
+}
+
+// position - узел на пути от корня к листу и номер потомка или записи в нем
+type position struct {
+	node  *node
+	index int
+}
+
+type iterator struct {
+	tree    *tree
+	reverse bool
+	// path - путь от корня к текущему листу. В листе index - следующая запись обхода
+	path  []position
+	done  bool
+	entry Entry
+	err   error
+}
+
+// Iterator возвращает курсор, который начинает с начала дерева, а при reverse - с конца
+func (t *tree) Iterator(reverse bool) Iterator {
+	return &iterator{tree: t, reverse: reverse}
+}
+
+func (it *iterator) Seek(key []byte) error {
+	it.path, it.done, it.err = nil, false, nil
+
+	h, err := it.tree.readHeader()
+	if err != nil {
+		it.err = fmt.Errorf("Seek(): %w", err)
+		return it.err
+	}
+
+	// Самая маленькая запись с ключом key: значения записей сравниваются после ключей
+	start := Entry{Key: key}
+	id := h.root
+	for {
+		n, err := it.tree.readNode(id)
+		if err != nil {
+			it.err = fmt.Errorf("Seek(): %w", err)
+			return it.err
+		}
+
+		var index int
+		switch {
+		case key == nil:
+			index = it.first(n)
+		case n.leaf:
+			index = sort.Search(len(n.entries), func(i int) bool { return n.entries[i].Compare(start) >= 0 })
+			if it.reverse {
+				index--
+			}
+		default:
+			index = childIndex(n, start)
+		}
+
+		it.path = append(it.path, position{node: n, index: index})
+		if n.leaf {
+			return nil
+		}
+		id = n.children[index]
+	}
+}
+
+// first - номер первого в порядке обхода потомка или записи узла
+func (it *iterator) first(n *node) int {
+	if !it.reverse {
+		return 0
+	}
+	if n.leaf {
+		return len(n.entries) - 1
+	}
+	return len(n.children) - 1
+}
+
+func (it *iterator) step() int {
+	if it.reverse {
+		return -1
+	}
+	return 1
+}
+
+func (it *iterator) Next() bool {
+	if it.err != nil || it.done {
+		return false
+	}
+	if it.path == nil && it.Seek(nil) != nil {
+		return false
+	}
+
+	for {
+		leaf := &it.path[len(it.path)-1]
+		if leaf.index >= 0 && leaf.index < len(leaf.node.entries) {
+			it.entry = leaf.node.entries[leaf.index]
+			leaf.index += it.step()
+			return true
+		}
+
+		ok, err := it.nextLeaf()
+		if err != nil {
+			it.err = fmt.Errorf("Next(): %w", err)
+			return false
+		}
+		if !ok {
+			it.done = true
+			return false
+		}
+	}
+}
+
+// nextLeaf переходит к соседнему листу в направлении обхода: поднимается до узла,
+// у которого есть следующий потомок, и спускается от него. false - листьев больше нет
+func (it *iterator) nextLeaf() (bool, error) {
+	level := len(it.path) - 2
+	for ; level >= 0; level-- {
+		p := &it.path[level]
+		p.index += it.step()
+		if p.index >= 0 && p.index < len(p.node.children) {
+			break
+		}
+	}
+	if level < 0 {
+		return false, nil
+	}
+
+	it.path = it.path[:level+1]
+	for {
+		p := it.path[len(it.path)-1]
+		if p.node.leaf {
+			return true, nil
+		}
+
+		n, err := it.tree.readNode(p.node.children[p.index])
+		if err != nil {
+			return false, err
+		}
+		it.path = append(it.path, position{node: n, index: it.first(n)})
+	}
+}
+
+func (it *iterator) Key() []byte {
+	return it.entry.Key
+}
+
+func (it *iterator) Value() uint64 {
+	return it.entry.Value
+}
+
+func (it *iterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,125 @@
+package btree
+
+import (
+	"custom-database/internal/disk_manager/data"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// Ключ индекса - значения колонок подряд, закодированные так, что побайтовое сравнение ключей
+// совпадает со сравнением значений: сначала по первой колонке, при равенстве - по второй и т.д.
+// Перед каждым значением стоит маркер: NULL больше любого значения (NULLS LAST, как в PostgreSQL)
+const (
+	VALUE_MARKER byte = 0x01
+	NULL_MARKER  byte = 0x02
+
+	MARKER_SIZE = 1
+	// TEXT_KEY_SIZE - место под одну текстовую колонку в ключе вместе с маркером и терминатором.
+	// Строки, которые не помещаются, нельзя записать в индекс
+	TEXT_KEY_SIZE = 256
+)
+
+// Строка экранируется: 0x00 -> 0x00 0xFF, а в конце ставится 0x00 0x01. Так строка-префикс
+// меньше любого своего продолжения, а следующая колонка не смешивается со строкой
+const (
+	textEscape     byte = 0x00
+	textEscaped    byte = 0xFF
+	textTerminator byte = 0x01
+)
+
+// EncodeKey кодирует значения колонок в ключ дерева
+func EncodeKey(cells []data.DataCell) ([]byte, error) {
+	key := []byte{}
+	for _, cell := range cells {
+		var err error
+		key, err = AppendKey(key, cell)
+		if err != nil {
+			return nil, fmt.Errorf("EncodeKey(): %w", err)
+		}
+	}
+
+	return key, nil
+}
+
+// AppendKey дописывает к ключу значение следующей колонки
+func AppendKey(key []byte, cell data.DataCell) ([]byte, error) {
+	if cell.IsNull || cell.Value == nil {
+		return append(key, NULL_MARKER), nil
+	}
+	key = append(key, VALUE_MARKER)
+
+	switch value := cell.Value.(type) {
+	case int32:
+		if cell.Type == data.TypeInt32 {
+			// Старший бит инвертируется, чтобы отрицательные числа шли раньше положительных
+			return binary.BigEndian.AppendUint32(key, uint32(value)^(1<<31)), nil
+		}
+	case int64:
+		if cell.Type == data.TypeInt64 {
+			return binary.BigEndian.AppendUint64(key, uint64(value)^(1<<63)), nil
+		}
+	case uint32:
+		if cell.Type == data.TypeUint32 {
+			return binary.BigEndian.AppendUint32(key, value), nil
+		}
+	case uint64:
+		if cell.Type == data.TypeUint64 {
+			return binary.BigEndian.AppendUint64(key, value), nil
+		}
+	case bool:
+		if cell.Type == data.TypeBoolean {
+			if value {
+				return append(key, 1), nil
+			}
+			return append(key, 0), nil
+		}
+	case string:
+		if cell.Type == data.TypeText {
+			for i := 0; i < len(value); i++ {
+				key = append(key, value[i])
+				if value[i] == textEscape {
+					key = append(key, textEscaped)
+				}
+			}
+			return append(key, textEscape, textTerminator), nil
+		}
+	case time.Time:
+		// Таблицы хранят TIMESTAMP с точностью до секунды
+		if cell.Type == data.TypeTimestamp {
+			return binary.BigEndian.AppendUint64(key, uint64(value.Unix())^(1<<63)), nil
+		}
+	}
+
+	return nil, fmt.Errorf("AppendKey(): unexpected value %T for column type %d", cell.Value, cell.Type)
+}
+
+// KeySize - наибольшая длина ключа по колонкам columns, не больше MAX_KEY_SIZE
+func KeySize(columns []data.Column) int {
+	size := 0
+	for _, column := range columns {
+		size += MARKER_SIZE
+		switch column.Type {
+		case data.TypeText:
+			size += TEXT_KEY_SIZE - MARKER_SIZE
+		default:
+			size += data.СalculateColumnSize(column.Type)
+		}
+	}
+
+	return min(size, MAX_KEY_SIZE)
+}
+
+// PrefixEnd возвращает наименьший ключ, который больше всех ключей, начинающихся с prefix.
+// nil - такого ключа нет, диапазон не ограничен сверху
+func PrefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xFF {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package btree
+
+import (
+	"bytes"
+	"custom-database/internal/disk_manager/data"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeKey(t *testing.T) {
+	null := func(columnType data.ColumnType) data.DataCell {
+		return data.DataCell{Type: columnType, IsNull: true}
+	}
+	cell := func(columnType data.ColumnType, value interface{}) data.DataCell {
+		return data.DataCell{Type: columnType, Value: value}
+	}
+	// requireOrdered проверяет, что ключи значений идут строго по возрастанию
+	requireOrdered := func(t *testing.T, rows ...[]data.DataCell) {
+		var previous []byte
+		for i, row := range rows {
+			key, err := EncodeKey(row)
+			require.NoError(t, err)
+			if i > 0 {
+				require.Negative(t, bytes.Compare(previous, key), "row %d: %v", i, row)
+			}
+			previous = key
+		}
+	}
+
+	t.Run("ключи упорядочены как значения, NULL - последним", func(t *testing.T) {
+		requireOrdered(t,
+			[]data.DataCell{cell(data.TypeInt32, int32(math.MinInt32))},
+			[]data.DataCell{cell(data.TypeInt32, int32(-1))},
+			[]data.DataCell{cell(data.TypeInt32, int32(0))},
+			[]data.DataCell{cell(data.TypeInt32, int32(7))},
+			[]data.DataCell{cell(data.TypeInt32, int32(math.MaxInt32))},
+			[]data.DataCell{null(data.TypeInt32)},
+		)
+		requireOrdered(t,
+			[]data.DataCell{cell(data.TypeInt64, int64(math.MinInt64))},
+			[]data.DataCell{cell(data.TypeInt64, int64(-300))},
+			[]data.DataCell{cell(data.TypeInt64, int64(300))},
+			[]data.DataCell{null(data.TypeInt64)},
+		)
+		requireOrdered(t,
+			[]data.DataCell{cell(data.TypeUint32, uint32(0))},
+			[]data.DataCell{cell(data.TypeUint32, uint32(math.MaxUint32))},
+			[]data.DataCell{cell(data.TypeUint64, uint64(math.MaxUint64))},
+		)
+		requireOrdered(t,
+			[]data.DataCell{cell(data.TypeBoolean, false)},
+			[]data.DataCell{cell(data.TypeBoolean, true)},
+			[]data.DataCell{null(data.TypeBoolean)},
+		)
+		requireOrdered(t,
+			[]data.DataCell{cell(data.TypeText, "")},
+			[]data.DataCell{cell(data.TypeText, "a")},
+			[]data.DataCell{cell(data.TypeText, "a\x00")},
+			[]data.DataCell{cell(data.TypeText, "a\x00\x00")},
+			[]data.DataCell{cell(data.TypeText, "a\x01")},
+			[]data.DataCell{cell(data.TypeText, "ab")},
+			[]data.DataCell{cell(data.TypeText, "b")},
+			[]data.DataCell{cell(data.TypeText, "\xff")},
+			[]data.DataCell{null(data.TypeText)},
+		)
+		requireOrdered(t,
+			[]data.DataCell{cell(data.TypeTimestamp, time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC))},
+			[]data.DataCell{cell(data.TypeTimestamp, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))},
+			[]data.DataCell{cell(data.TypeTimestamp, time.Date(2024, 5, 1, 10, 0, 1, 0, time.UTC))},
+			[]data.DataCell{null(data.TypeTimestamp)},
+		)
+	})
+
+	t.Run("составной ключ сравнивается по колонкам слева направо", func(t *testing.T) {
+		day := func(d int) data.DataCell {
+			return cell(data.TypeTimestamp, time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC))
+		}
+		requireOrdered(t,
+			[]data.DataCell{cell(data.TypeInt32, int32(1)), day(20)},
+			[]data.DataCell{cell(data.TypeInt32, int32(1)), day(21)},
+			[]data.DataCell{cell(data.TypeInt32, int32(1)), null(data.TypeTimestamp)},
+			[]data.DataCell{cell(data.TypeInt32, int32(2)), day(1)},
+			[]data.DataCell{null(data.TypeInt32), day(1)},
+		)
+		// Строка в первой колонке не смешивается со второй: "a" + 'z' < "ab" + 'a'
+		requireOrdered(t,
+			[]data.DataCell{cell(data.TypeText, "a"), cell(data.TypeText, "z")},
+			[]data.DataCell{cell(data.TypeText, "a"), null(data.TypeText)},
+			[]data.DataCell{cell(data.TypeText, "ab"), cell(data.TypeText, "a")},
+		)
+	})
+
+	t.Run("ключ префикса ограничивает все ключи с этим префиксом", func(t *testing.T) {
+		prefix, err := EncodeKey([]data.DataCell{cell(data.TypeInt32, int32(1))})
+		require.NoError(t, err)
+		end := PrefixEnd(prefix)
+
+		for _, second := range []data.DataCell{cell(data.TypeText, ""), cell(data.TypeText, "\xff\xff"), null(data.TypeText)} {
+			key, err := EncodeKey([]data.DataCell{cell(data.TypeInt32, int32(1)), second})
+			require.NoError(t, err)
+			require.True(t, bytes.HasPrefix(key, prefix))
+			require.Negative(t, bytes.Compare(key, end))
+		}
+
+		next, err := EncodeKey([]data.DataCell{cell(data.TypeInt32, int32(2))})
+		require.NoError(t, err)
+		require.LessOrEqual(t, bytes.Compare(end, next), 0)
+
+		require.Nil(t, PrefixEnd([]byte{0xFF, 0xFF}))
+		require.Equal(t, []byte{0x01, 0x03}, PrefixEnd([]byte{0x01, 0x02, 0xFF}))
+	})
+
+	t.Run("значение другого типа и длина ключа", func(t *testing.T) {
+		_, err := EncodeKey([]data.DataCell{cell(data.TypeInt64, int32(1))})
+		require.Error(t, err)
+
+		columns := []data.Column{{Type: data.TypeInt32}, {Type: data.TypeTimestamp}, {Type: data.TypeBoolean}}
+		require.Equal(t, 1+4+1+8+1+1, KeySize(columns))
+		require.Equal(t, TEXT_KEY_SIZE+1+4, KeySize([]data.Column{{Type: data.TypeText}, {Type: data.TypeInt32}}))
+		texts := []data.Column{{Type: data.TypeText}, {Type: data.TypeText}, {Type: data.TypeText}, {Type: data.TypeText}, {Type: data.TypeText}}
+		require.Equal(t, MAX_KEY_SIZE, KeySize(texts))
+	})
+}
@@ -0,0 +1,251 @@
+package btree
+
+import (
+	"bytes"
+	bs "custom-database/internal/disk_manager/binary_serializer"
+	"fmt"
+)
+
+// header - заголовок дерева. Читается в начале каждой операции, поэтому дерево, открытое
+// поверх файла только с зафиксированными страницами, всегда видит актуальный корень
+type header struct {
+	root      uint32
+	pageCount uint32
+	fanout    int
+	keySize   int
+	freePage  uint32
+	dirty     bool
+}
+
+// Entry - ключ и значение. Ключи сравниваются как байты, одинаковые ключи допустимы:
+// записи упорядочены по паре (Key, Value)
+type Entry struct {
+	Key   []byte
+	Value uint64
+}
+
+// Compare сравнивает записи в порядке дерева: -1, 0 или 1
+func (e Entry) Compare(other Entry) int {
+	if cmp := bytes.Compare(e.Key, other.Key); cmp != 0 {
+		return cmp
+	}
+
+	switch {
+	case e.Value < other.Value:
+		return -1
+	case e.Value > other.Value:
+		return 1
+	}
+
+	return 0
+}
+
+// node - узел дерева. Во внутреннем узле entries[i] - нижняя граница записей в children[i+1],
+// все записи children[i] меньше entries[i]
+type node struct {
+	id       uint32
+	leaf     bool
+	entries  []Entry
+	children []uint32
+	next     uint32
+}
+
+func (t *tree) readHeader() (*header, error) {
+	page, err := t.readPage(HEADER_PAGE_ID)
+	if err != nil {
+		return nil, fmt.Errorf("readHeader(): %w", err)
+	}
+
+	if bs.ReadUint32(page, 0) != MAGIC {
+		return nil, fmt.Errorf("readHeader(): file is not a B+ tree")
+	}
+
+	offset := MAGIC_SIZE
+	h := &header{}
+	h.root = bs.ReadUint32(page, offset)
+	offset += ROOT_PAGE_SIZE
+	h.pageCount = bs.ReadUint32(page, offset)
+	offset += PAGE_COUNT_SIZE
+	h.fanout = int(bs.ReadUint16(page, offset))
+	offset += FANOUT_SIZE
+	h.keySize = int(bs.ReadUint16(page, offset))
+	offset += KEY_SIZE_SIZE
+	h.freePage = bs.ReadUint32(page, offset)
+
+	return h, nil
+}
+
+func (t *tree) writeHeader(h *header) error {
+	page := make([]byte, PAGE_SIZE)
+
+	offset := 0
+	bs.WriteUint32(page, offset, MAGIC)
+	offset += MAGIC_SIZE
+	bs.WriteUint32(page, offset, h.root)
+	offset += ROOT_PAGE_SIZE
+	bs.WriteUint32(page, offset, h.pageCount)
+	offset += PAGE_COUNT_SIZE
+	bs.WriteUint16(page, offset, uint16(h.fanout))
+	offset += FANOUT_SIZE
+	bs.WriteUint16(page, offset, uint16(h.keySize))
+	offset += KEY_SIZE_SIZE
+	bs.WriteUint32(page, offset, h.freePage)
+
+	err := t.writePage(HEADER_PAGE_ID, page)
+	if err != nil {
+		return fmt.Errorf("writeHeader(): %w", err)
+	}
+	h.dirty = false
+
+	return nil
+}
+
+func (t *tree) readNode(id uint32) (*node, error) {
+	page, err := t.readPage(id)
+	if err != nil {
+		return nil, fmt.Errorf("readNode(): %w", err)
+	}
+
+	offset := PAGE_ID_SIZE
+	kind := nodeType(bs.ReadUint8(page, offset))
+	offset += NODE_TYPE_SIZE
+	count := int(bs.ReadUint16(page, offset))
+	offset += KEYS_COUNT_SIZE
+
+	if kind != leafNode && kind != internalNode {
+		return nil, fmt.Errorf("readNode(): page %d is not a tree node", id)
+	}
+
+	n := &node{
+		id:      id,
+		leaf:    kind == leafNode,
+		next:    bs.ReadUint32(page, offset),
+		entries: make([]Entry, count),
+	}
+	offset += NEXT_PAGE_SIZE
+
+	if !n.leaf {
+		n.children = make([]uint32, count+1)
+		n.children[0] = bs.ReadUint32(page, offset)
+		offset += CHILD_SIZE
+	}
+
+	for i := 0; i < count; i++ {
+		size := int(bs.ReadUint16(page, offset))
+		offset += KEY_LENGTH_SIZE
+		n.entries[i].Key = append([]byte{}, page[offset:offset+size]...)
+		offset += size
+		n.entries[i].Value = bs.ReadUint64(page, offset)
+		offset += VALUE_SIZE
+
+		if !n.leaf {
+			n.children[i+1] = bs.ReadUint32(page, offset)
+			offset += CHILD_SIZE
+		}
+	}
+
+	return n, nil
+}
+
+func (t *tree) writeNode(n *node) error {
+	page := make([]byte, PAGE_SIZE)
+
+	kind := internalNode
+	if n.leaf {
+		kind = leafNode
+	}
+
+	offset := 0
+	bs.WriteUint32(page, offset, n.id)
+	offset += PAGE_ID_SIZE
+	bs.WriteUint8(page, offset, uint8(kind))
+	offset += NODE_TYPE_SIZE
+	bs.WriteUint16(page, offset, uint16(len(n.entries)))
+	offset += KEYS_COUNT_SIZE
+	bs.WriteUint32(page, offset, n.next)
+	offset += NEXT_PAGE_SIZE
+
+	if !n.leaf {
+		bs.WriteUint32(page, offset, n.children[0])
+		offset += CHILD_SIZE
+	}
+
+	for i, entry := range n.entries {
+		bs.WriteUint16(page, offset, uint16(len(entry.Key)))
+		offset += KEY_LENGTH_SIZE
+		offset += copy(page[offset:], entry.Key)
+		bs.WriteUint64(page, offset, entry.Value)
+		offset += VALUE_SIZE
+
+		if !n.leaf {
+			bs.WriteUint32(page, offset, n.children[i+1])
+			offset += CHILD_SIZE
+		}
+	}
+
+	err := t.writePage(n.id, page)
+	if err != nil {
+		return fmt.Errorf("writeNode(): %w", err)
+	}
+
+	return nil
+}
+
+// allocate выдает страницу для нового узла: из списка свободных или в конце файла
+func (t *tree) allocate(h *header, leaf bool) (*node, error) {
+	n := &node{leaf: leaf}
+
+	if h.freePage != 0 {
+		page, err := t.readPage(h.freePage)
+		if err != nil {
+			return nil, fmt.Errorf("allocate(): %w", err)
+		}
+
+		n.id = h.freePage
+		h.freePage = bs.ReadUint32(page, PAGE_ID_SIZE+NODE_TYPE_SIZE+KEYS_COUNT_SIZE)
+	} else {
+		n.id = h.pageCount
+		h.pageCount++
+	}
+	h.dirty = true
+
+	return n, nil
+}
+
+// free возвращает страницу узла в список свободных страниц
+func (t *tree) free(h *header, id uint32) error {
+	page := make([]byte, PAGE_SIZE)
+	bs.WriteUint32(page, 0, id)
+	bs.WriteUint8(page, PAGE_ID_SIZE, uint8(freePage))
+	bs.WriteUint32(page, PAGE_ID_SIZE+NODE_TYPE_SIZE+KEYS_COUNT_SIZE, h.freePage)
+
+	err := t.writePage(id, page)
+	if err != nil {
+		return fmt.Errorf("free(): %w", err)
+	}
+
+	h.freePage = id
+	h.dirty = true
+
+	return nil
+}
+
+func (t *tree) readPage(id uint32) ([]byte, error) {
+	page := make([]byte, PAGE_SIZE)
+
+	_, err := t.file.ReadAt(page, int64(id)*PAGE_SIZE)
+	if err != nil {
+		return nil, fmt.Errorf("page %d: file.ReadAt: %w", id, err)
+	}
+
+	return page, nil
+}
+
+func (t *tree) writePage(id uint32, page []byte) error {
+	_, err := t.file.WriteAt(page, int64(id)*PAGE_SIZE)
+	if err != nil {
+		return fmt.Errorf("page %d: file.WriteAt: %w", id, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,237 @@
+package buffer_pool
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// PAGE_SIZE - размер кадра пула. Страницы выровнены по началу файла
+const PAGE_SIZE = 4096
+
+// DEFAULT_FRAMES_AMOUNT - размер пула по умолчанию (4MB)
+const DEFAULT_FRAMES_AMOUNT = 1024
+
+// ErrNoFreeFrames - все кадры закреплены и вытеснить некого
+var ErrNoFreeFrames = errors.New("buffer pool has no unpinned frames")
+
+// File - файл, страницы которого кеширует пул
+type File interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// PageID - страница файла: имя файла в пуле и номер страницы от начала файла
+type PageID struct {
+	File string
+	Page int64
+}
+
+// Frame - кадр пула с содержимым одной страницы.
+// Data можно читать и менять, пока страница закреплена
+type Frame struct {
+	ID   PageID
+	Data []byte
+	// Size - сколько байт страницы есть в файле: у последней страницы файла он меньше PAGE_SIZE
+	Size int
+
+	file     File
+	pinCount int
+	dirty    bool
+	// referenced - бит обращения для алгоритма часов: кадр переживает один проход стрелки
+	referenced bool
+}
+
+// Stats - счетчики пула, по которым подбирается количество кадров
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Flushes   uint64
+}
+
+type BufferPoolService interface {
+	// FetchPage закрепляет страницу в пуле, при промахе читая ее из файла.
+	// Каждому FetchPage должен соответствовать UnpinPage
+	FetchPage(file File, id PageID) (*Frame, error)
+	// UnpinPage снимает закрепление. dirty отмечает, что Data изменена и ее нужно записать в файл
+	UnpinPage(id PageID, dirty bool) error
+	// FlushFile записывает в файл все измененные страницы файла
+	FlushFile(name string) error
+	// DropFile выбрасывает страницы файла без записи, например после удаления таблицы
+	DropFile(name string)
+	Stats() Stats
+}
+
+type bufferPool struct {
+	mu     sync.Mutex
+	frames []*Frame
+	pages  map[PageID]int // страница -> индекс кадра
+	hand   int            // стрелка часов
+	stats  Stats
+}
+
+func NewBufferPool(framesAmount int) BufferPoolService {
+	if framesAmount <= 0 {
+		framesAmount = DEFAULT_FRAMES_AMOUNT
+	}
+
+	frames := make([]*Frame, framesAmount)
+	for i := range frames {
+		frames[i] = &Frame{Data: make([]byte, PAGE_SIZE)}
+	}
+
+	return &bufferPool{
+		frames: frames,
+		pages:  map[PageID]int{},
+	}
+}
+
+func (bp *bufferPool) FetchPage(file File, id PageID) (*Frame, error) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	if index, ok := bp.pages[id]; ok {
+		frame := bp.frames[index]
+		frame.pinCount++
+		frame.referenced = true
+		bp.stats.Hits++
+		return frame, nil
+	}
+	bp.stats.Misses++
+
+	index, err := bp.findVictim()
+	if err != nil {
+		return nil, fmt.Errorf("FetchPage(): %w", err)
+	}
+	frame := bp.frames[index]
+
+	n, err := file.ReadAt(frame.Data, id.Page*PAGE_SIZE)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("FetchPage(): file.ReadAt: %w", err)
+	}
+	clear(frame.Data[n:])
+
+	frame.ID = id
+	frame.Size = n
+	frame.file = file
+	frame.pinCount = 1
+	frame.dirty = false
+	frame.referenced = true
+	bp.pages[id] = index
+
+	return frame, nil
+}
+
+func (bp *bufferPool) UnpinPage(id PageID, dirty bool) error {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	index, ok := bp.pages[id]
+	if !ok || bp.frames[index].pinCount == 0 {
+		return fmt.Errorf("UnpinPage(): page %d of %s is not pinned", id.Page, id.File)
+	}
+
+	frame := bp.frames[index]
+	frame.pinCount--
+	if dirty {
+		frame.dirty = true
+	}
+
+	return nil
+}
+
+func (bp *bufferPool) FlushFile(name string) error {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	for _, index := range bp.pages {
+		frame := bp.frames[index]
+		if frame.ID.File != name || !frame.dirty {
+			continue
+		}
+
+		err := bp.flush(frame)
+		if err != nil {
+			return fmt.Errorf("FlushFile(): %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (bp *bufferPool) DropFile(name string) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	for id, index := range bp.pages {
+		if id.File != name {
+			continue
+		}
+
+		frame := bp.frames[index]
+		frame.file = nil
+		frame.dirty = false
+		frame.pinCount = 0
+		frame.referenced = false
+		delete(bp.pages, id)
+	}
+}
+
+func (bp *bufferPool) Stats() Stats {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	return bp.stats
+}
+
+// findVictim выбирает кадр под новую страницу алгоритмом часов: стрелка обходит кадры,
+// снимая бит обращения, и останавливается на первом незакрепленном кадре без него.
+// Измененная страница перед вытеснением записывается в файл
+func (bp *bufferPool) findVictim() (int, error) {
+	// За два оборота стрелка снимет все биты обращения, поэтому дальше искать бессмысленно
+	for i := 0; i < 2*len(bp.frames); i++ {
+		index := bp.hand
+		bp.hand = (bp.hand + 1) % len(bp.frames)
+
+		frame := bp.frames[index]
+		if frame.file == nil {
+			return index, nil
+		}
+		if frame.pinCount > 0 {
+			continue
+		}
+		if frame.referenced {
+			frame.referenced = false
+			continue
+		}
+
+		if frame.dirty {
+			err := bp.flush(frame)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		delete(bp.pages, frame.ID)
+		frame.file = nil
+		bp.stats.Evictions++
+
+		return index, nil
+	}
+
+	return 0, ErrNoFreeFrames
+}
+
+func (bp *bufferPool) flush(frame *Frame) error {
+	_, err := frame.file.WriteAt(frame.Data[:frame.Size], frame.ID.Page*PAGE_SIZE)
+	if err != nil {
+		return fmt.Errorf("flush page %d of %s: %w", frame.ID.Page, frame.ID.File, err)
+	}
+
+	frame.dirty = false
+	bp.stats.Flushes++
+
+	return nil
+}
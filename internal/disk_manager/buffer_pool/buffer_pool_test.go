@@ -0,0 +1,215 @@
+package buffer_pool
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memFile - файл в памяти, считающий операции записи
+type memFile struct {
+	data   []byte
+	writes int
+	err    error
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(f.data)) {
+		return 0, nil
+	}
+	n := copy(p, f.data[off:])
+	return n, nil
+}
+
+func (f *memFile) WriteAt(p []byte, off int64) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	if end := int(off) + len(p); end > len(f.data) {
+		f.data = append(f.data, make([]byte, end-len(f.data))...)
+	}
+	f.writes++
+	return copy(f.data[off:], p), nil
+}
+
+func pageOf(b byte) []byte {
+	return bytes.Repeat([]byte{b}, PAGE_SIZE)
+}
+
+func TestBufferPool(t *testing.T) {
+	t.Run("повторное чтение страницы берется из пула", func(t *testing.T) {
+		file := &memFile{data: append(pageOf(1), pageOf(2)...)}
+		bp := NewBufferPool(4)
+
+		for i := 0; i < 3; i++ {
+			frame, err := bp.FetchPage(file, PageID{File: "t", Page: 1})
+			require.NoError(t, err)
+			require.Equal(t, pageOf(2), frame.Data)
+			require.Equal(t, PAGE_SIZE, frame.Size)
+			require.NoError(t, bp.UnpinPage(frame.ID, false))
+		}
+
+		require.Equal(t, Stats{Hits: 2, Misses: 1}, bp.Stats())
+	})
+
+	t.Run("неполная последняя страница читается с размером", func(t *testing.T) {
+		file := &memFile{data: append(pageOf(1), 7, 7, 7)}
+		bp := NewBufferPool(4)
+
+		frame, err := bp.FetchPage(file, PageID{File: "t", Page: 1})
+		require.NoError(t, err)
+		require.Equal(t, 3, frame.Size)
+		require.Equal(t, []byte{7, 7, 7, 0}, frame.Data[:4])
+	})
+
+	t.Run("вытеснение записывает измененную страницу", func(t *testing.T) {
+		file := &memFile{data: bytes.Repeat(pageOf(0), 3)}
+		bp := NewBufferPool(2)
+
+		frame, err := bp.FetchPage(file, PageID{File: "t", Page: 0})
+		require.NoError(t, err)
+		copy(frame.Data, pageOf(9))
+		require.NoError(t, bp.UnpinPage(frame.ID, true))
+		require.Equal(t, 0, file.writes)
+
+		for _, page := range []int64{1, 2} {
+			frame, err := bp.FetchPage(file, PageID{File: "t", Page: page})
+			require.NoError(t, err)
+			require.NoError(t, bp.UnpinPage(frame.ID, false))
+		}
+
+		require.Equal(t, 1, file.writes)
+		require.Equal(t, pageOf(9), file.data[:PAGE_SIZE])
+		require.Equal(t, uint64(1), bp.Stats().Evictions)
+		require.Equal(t, uint64(1), bp.Stats().Flushes)
+	})
+
+	t.Run("алгоритм часов сохраняет недавно прочитанную страницу", func(t *testing.T) {
+		file := &memFile{data: bytes.Repeat(pageOf(0), 4)}
+		bp := NewBufferPool(2)
+
+		fetch := func(page int64) {
+			frame, err := bp.FetchPage(file, PageID{File: "t", Page: page})
+			require.NoError(t, err)
+			require.NoError(t, bp.UnpinPage(frame.ID, false))
+		}
+
+		fetch(0)
+		fetch(1)
+		// Страница 2 вытесняет 0, стрелка остается на кадре страницы 1 со снятым битом
+		fetch(2)
+		// Обращение к 2 выставляет ее бит, поэтому страница 3 вытесняет 1
+		fetch(2)
+		fetch(3)
+
+		misses := bp.Stats().Misses
+		fetch(2)
+		require.Equal(t, misses, bp.Stats().Misses)
+		fetch(1)
+		require.Equal(t, misses+1, bp.Stats().Misses)
+	})
+
+	t.Run("закрепленные страницы не вытесняются", func(t *testing.T) {
+		file := &memFile{data: bytes.Repeat(pageOf(0), 3)}
+		bp := NewBufferPool(2)
+
+		_, err := bp.FetchPage(file, PageID{File: "t", Page: 0})
+		require.NoError(t, err)
+		_, err = bp.FetchPage(file, PageID{File: "t", Page: 1})
+		require.NoError(t, err)
+
+		_, err = bp.FetchPage(file, PageID{File: "t", Page: 2})
+		require.ErrorIs(t, err, ErrNoFreeFrames)
+
+		require.NoError(t, bp.UnpinPage(PageID{File: "t", Page: 1}, false))
+		_, err = bp.FetchPage(file, PageID{File: "t", Page: 2})
+		require.NoError(t, err)
+	})
+
+	t.Run("ошибка при снятии незакрепленной страницы", func(t *testing.T) {
+		file := &memFile{data: pageOf(0)}
+		bp := NewBufferPool(2)
+
+		require.Error(t, bp.UnpinPage(PageID{File: "t", Page: 0}, false))
+
+		frame, err := bp.FetchPage(file, PageID{File: "t", Page: 0})
+		require.NoError(t, err)
+		require.NoError(t, bp.UnpinPage(frame.ID, false))
+		require.Error(t, bp.UnpinPage(frame.ID, false))
+	})
+
+	t.Run("FlushFile записывает только страницы своего файла", func(t *testing.T) {
+		users := &memFile{data: pageOf(0)}
+		orders := &memFile{data: pageOf(0)}
+		bp := NewBufferPool(4)
+
+		for name, file := range map[string]*memFile{"users": users, "orders": orders} {
+			frame, err := bp.FetchPage(file, PageID{File: name, Page: 0})
+			require.NoError(t, err)
+			copy(frame.Data, pageOf(5))
+			require.NoError(t, bp.UnpinPage(frame.ID, true))
+		}
+
+		require.NoError(t, bp.FlushFile("users"))
+		require.Equal(t, pageOf(5), users.data)
+		require.Equal(t, pageOf(0), orders.data)
+
+		// Записанная страница больше не считается измененной
+		require.NoError(t, bp.FlushFile("users"))
+		require.Equal(t, 1, users.writes)
+	})
+
+	t.Run("ошибка записи при FlushFile", func(t *testing.T) {
+		file := &memFile{data: pageOf(0)}
+		bp := NewBufferPool(2)
+
+		frame, err := bp.FetchPage(file, PageID{File: "t", Page: 0})
+		require.NoError(t, err)
+		require.NoError(t, bp.UnpinPage(frame.ID, true))
+
+		file.err = errors.New("disk is full")
+		require.Error(t, bp.FlushFile("t"))
+	})
+
+	t.Run("DropFile выбрасывает страницы без записи", func(t *testing.T) {
+		file := &memFile{data: pageOf(0)}
+		bp := NewBufferPool(2)
+
+		frame, err := bp.FetchPage(file, PageID{File: "t", Page: 0})
+		require.NoError(t, err)
+		copy(frame.Data, pageOf(3))
+		require.NoError(t, bp.UnpinPage(frame.ID, true))
+
+		bp.DropFile("t")
+		require.NoError(t, bp.FlushFile("t"))
+		require.Equal(t, 0, file.writes)
+
+		frame, err = bp.FetchPage(file, PageID{File: "t", Page: 0})
+		require.NoError(t, err)
+		require.Equal(t, pageOf(0), frame.Data)
+	})
+
+	t.Run("работа с файлом на диске", func(t *testing.T) {
+		file, err := os.Create(filepath.Join(t.TempDir(), "test.data"))
+		require.NoError(t, err)
+		defer file.Close()
+
+		bp := NewBufferPool(1)
+		frame, err := bp.FetchPage(file, PageID{File: "t", Page: 0})
+		require.NoError(t, err)
+		require.Equal(t, 0, frame.Size)
+
+		copy(frame.Data, pageOf(4))
+		frame.Size = PAGE_SIZE
+		require.NoError(t, bp.UnpinPage(frame.ID, true))
+		require.NoError(t, bp.FlushFile("t"))
+
+		info, err := file.Stat()
+		require.NoError(t, err)
+		require.Equal(t, int64(PAGE_SIZE), info.Size())
+	})
+}
@@ -0,0 +1,222 @@
+package disk_manager
+
+import (
+	"bytes"
+	"custom-database/internal/disk_manager/data"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConstraintError - нарушение ограничения уникального индекса или NOT NULL. Текст ошибки предназначен пользователю
+type ConstraintError struct {
+	// Constraint - имя индекса нарушенного ограничения, для NOT NULL - пустое
+	Constraint string
+	Message    string
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Message
+}
+
+func uniqueViolation(idx *index, s *schema, row []data.DataCell) *ConstraintError {
+	names, _ := s.columnNames(idx.ids)
+	values := make([]string, len(idx.ids))
+	for i, id := range idx.ids {
+		values[i] = formatCell(row[s.position(id)])
+	}
+
+	return &ConstraintError{
+		Constraint: idx.Name,
+		Message: fmt.Sprintf("duplicate key value violates unique constraint %q: key (%s)=(%s) already exists",
+			idx.Name, strings.Join(names, ", "), strings.Join(values, ", ")),
+	}
+}
+
+func primaryKeyNullViolation(idx *index, columnName string) *ConstraintError {
+	return &ConstraintError{
+		Constraint: idx.Name,
+		Message:    fmt.Sprintf("null value in column %q violates primary key constraint %q", columnName, idx.Name),
+	}
+}
+
+func notNullViolation(tableName, columnName string) *ConstraintError {
+	return &ConstraintError{
+		Message: fmt.Sprintf("null value in column %q of relation %q violates not-null constraint", columnName, tableName),
+	}
+}
+
+// checkNotNull не дает записать NULL в колонку без IsNullable: страница не хранит для нее бит NULL,
+// и строка прочиталась бы с нулевым значением вместо NULL
+func checkNotNull(s *schema, row []data.DataCell) error {
+	for i, column := range s.columns {
+		if i < len(row) && row[i].IsNull && !column.IsNullable {
+			return notNullViolation(s.name, column.Name)
+		}
+	}
+
+	return nil
+}
+
+// formatCell печатает значение ячейки так же, как его показывает backend
+func formatCell(cell data.DataCell) string {
+	if cell.IsNull {
+		return "NULL"
+	}
+	if timestamp, ok := cell.Value.(time.Time); ok {
+		return timestamp.Format(time.DateTime)
+	}
+
+	return fmt.Sprint(cell.Value)
+}
+
+// uniqueKey кодирует ключ строки для проверки уникальности. false - в ключе есть NULL:
+// такие строки не конфликтуют друг с другом, а в первичном ключе NULL запрещен
+func (idx *index) uniqueKey(s *schema, row []data.DataCell) ([]byte, bool, error) {
+	for _, id := range idx.ids {
+		position := s.position(id)
+		if position != -1 && row[position].IsNull {
+			if idx.Primary {
+				return nil, false, primaryKeyNullViolation(idx, s.columns[position].Name)
+			}
+			return nil, false, nil
+		}
+	}
+
+	key, err := idx.key(s, row)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return key, true, nil
+}
+
+// checkUnique проверяет, что новую версию строки row по адресу address можно добавить в уникальный индекс.
+// Вызывается внутри физической транзакции, поэтому видит все версии с тем же ключом, в том числе чужие
+// незафиксированные: с ними транзакция конфликтует, а не ждет их завершения
+func (tx *mvccTransaction) checkUnique(table *tableFile, idx *index, s *schema, row []data.DataCell, address rowAddress) error {
+	if !idx.Unique {
+		return nil
+	}
+
+	key, ok, err := idx.uniqueKey(s, row)
+	if err != nil || !ok {
+		return err
+	}
+
+	values, err := idx.tree.Search(key)
+	if err != nil {
+		return fmt.Errorf("index %s: %w", idx.Name, err)
+	}
+
+	for _, value := range values {
+		other := indexAddress(value)
+		if other == address {
+			continue
+		}
+
+		version, err := table.data.ReadRow(other.pageID, other.slotID)
+		if errors.Is(err, data.ErrRowNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("data.ReadRow: %w", err)
+		}
+
+		occupied, err := tx.occupies(version.Xmin, version.Xmax)
+		if err != nil {
+			return fmt.Errorf("index %s: %w", idx.Name, err)
+		}
+		if occupied {
+			return uniqueViolation(idx, s, row)
+		}
+	}
+
+	return nil
+}
+
+// occupies сообщает, занимает ли версия строки, созданная xmin и удаленная xmax, значение уникального ключа.
+// Версию без ключа делают только откат создавшей ее транзакции или фиксация удалившей, а также удаление
+// самой транзакцией. Если исход еще не известен, возвращается ErrWriteConflict
+func (tx *mvccTransaction) occupies(xmin, xmax uint64) (bool, error) {
+	own := tx.snapshot.xid
+
+	if xmin != own {
+		switch tx.dm.status(xmin) {
+		case txAborted:
+			return false, nil
+		case txInProgress:
+			return false, writeConflict("key is inserted by a concurrent transaction")
+		}
+	}
+
+	if xmax == 0 {
+		return true, nil
+	}
+	if xmax == own {
+		return false, nil
+	}
+
+	switch tx.dm.status(xmax) {
+	case txCommitted:
+		return false, nil
+	case txInProgress:
+		return false, writeConflict("key is deleted by a concurrent transaction")
+	}
+
+	return true, nil
+}
+
+// validateUnique проверяет, что версии строк, которые уже есть в таблице, не нарушают новый уникальный индекс
+func (tx *mvccTransaction) validateUnique(table *tableFile, idx *index) error {
+	type version struct {
+		key    []byte
+		row    []data.DataCell
+		schema *schema
+	}
+
+	it, err := table.data.Scan()
+	if err != nil {
+		return fmt.Errorf("data.Scan: %w", err)
+	}
+
+	versions := []version{}
+	for it.Next() {
+		row := it.Row()
+		occupied, err := tx.occupies(row.Xmin, row.Xmax)
+		if err != nil {
+			return fmt.Errorf("index %s: %w", idx.Name, err)
+		}
+		if !occupied {
+			continue
+		}
+
+		cells, s, err := tx.dm.currentRow(table, row)
+		if err != nil {
+			return err
+		}
+		key, ok, err := idx.uniqueKey(s, cells)
+		if err != nil {
+			return err
+		}
+		if ok {
+			versions = append(versions, version{key: key, row: cells, schema: s})
+		}
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("data.Scan: %w", err)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return bytes.Compare(versions[i].key, versions[j].key) < 0
+	})
+	for i := 1; i < len(versions); i++ {
+		if bytes.Equal(versions[i-1].key, versions[i].key) {
+			return uniqueViolation(idx, versions[i].schema, versions[i].row)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,347 @@
+package disk_manager
+
+import (
+	"custom-database/config"
+	"custom-database/internal/disk_manager/data"
+	"custom-database/internal/disk_manager/wal"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errCrash = errors.New("simulated crash")
+
+// crashFS имитирует падение процесса на заданной операции записи (WriteAt, Sync, Truncate)
+// во всех файлах, которые открыл disk_manager. Падающий WriteAt успевает записать
+// только половину данных, а после падения ни одна запись уже не доходит до диска
+type crashFS struct {
+	mu      sync.Mutex
+	crashAt int // номер операции записи, на которой процесс падает; 0 - никогда
+	writes  int
+	crashed bool
+	files   []*os.File
+}
+
+type crashFile struct {
+	*os.File
+	fs *crashFS
+}
+
+func (fs *crashFS) open(name string, flag int, perm os.FileMode) (wal.File, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.crashed {
+		return nil, errCrash
+	}
+
+	file, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	fs.files = append(fs.files, file)
+
+	return &crashFile{File: file, fs: fs}, nil
+}
+
+// step отсчитывает очередную операцию записи и сообщает, должен ли процесс упасть на ней
+func (fs *crashFS) step() (bool, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.crashed {
+		return false, errCrash
+	}
+
+	fs.writes++
+	if fs.writes == fs.crashAt {
+		fs.crashed = true
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// closeAll закрывает файлы "упавшего" процесса перед восстановлением
+func (fs *crashFS) closeAll() {
+	for _, file := range fs.files {
+		file.Close()
+	}
+}
+
+func (f *crashFile) WriteAt(p []byte, off int64) (int, error) {
+	crash, err := f.fs.step()
+	if err != nil {
+		return 0, err
+	}
+	if crash {
+		n, _ := f.File.WriteAt(p[:len(p)/2], off)
+		return n, errCrash
+	}
+
+	return f.File.WriteAt(p, off)
+}
+
+func (f *crashFile) Sync() error {
+	crash, err := f.fs.step()
+	if err != nil {
+		return err
+	}
+	if crash {
+		return errCrash
+	}
+
+	return f.File.Sync()
+}
+
+func (f *crashFile) Truncate(size int64) error {
+	crash, err := f.fs.step()
+	if err != nil {
+		return err
+	}
+	if crash {
+		return errCrash
+	}
+
+	return f.File.Truncate(size)
+}
+
+// crashWorkload - операции над таблицей users. expected хранит строки (id -> name),
+// которые должны быть в таблице после всех подтвержденных операций
+type crashWorkload struct {
+	dm        *diskManager
+	addresses map[int32]*data.InsertRowResult
+	expected  map[int32]string
+	created   bool
+	dropped   bool
+	indexed   bool
+}
+
+// run выполняет операции по порядку до первой ошибки. Возвращает ожидаемое состояние
+// с примененной упавшей операцией: после восстановления таблица должна совпасть
+// либо с ним, либо с w.expected
+func (w *crashWorkload) run() (map[int32]string, error) {
+	columns := []data.Column{
+		{Name: "id", Type: data.TypeInt32, IsNullable: false},
+		{Name: "name", Type: data.TypeText, IsNullable: true},
+	}
+
+	// Таблица, удаление которой попадает в журнал посреди нагрузки
+	if err := w.dm.CreateTable("tmp", columns, nil); err != nil {
+		return nil, err
+	}
+	if _, err := w.dm.InsertRow("tmp", w.row(0, "tmp")); err != nil {
+		return nil, err
+	}
+	if err := w.dm.DropTable("tmp"); err != nil {
+		return nil, err
+	}
+	w.dropped = true
+
+	if err := w.dm.CreateTable("users", columns, nil); err != nil {
+		return map[int32]string{}, err
+	}
+	w.created = true
+
+	// Индекс меняется в тех же физических транзакциях, что и строки
+	tx := w.dm.Begin()
+	if err := tx.CreateIndex(Index{Name: "users_id", Table: "users", Columns: []string{"id"}}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	w.indexed = true
+
+	for i := int32(0); i < 40; i++ {
+		name := fmt.Sprintf("name-%d", i)
+		result, err := w.dm.InsertRow("users", w.row(i, name))
+		if err != nil {
+			return w.with(i, &name), err
+		}
+		w.addresses[i] = result
+		w.expected[i] = name
+	}
+
+	for i := int32(0); i < 40; i += 5 {
+		// Обновление создает новую версию строки с новым адресом
+		name := strings.Repeat(fmt.Sprintf("renamed-%d-", i), 4)
+		address := w.addresses[i]
+		result, err := w.dm.UpdateRow("users", address.PageID, address.SlotID, w.row(i, name))
+		if err != nil {
+			return w.with(i, &name), err
+		}
+		w.addresses[i] = result
+		w.expected[i] = name
+	}
+
+	for i := int32(0); i < 40; i += 3 {
+		address := w.addresses[i]
+		err := w.dm.DeleteRow("users", address.PageID, address.SlotID)
+		if err != nil {
+			return w.with(i, nil), err
+		}
+		delete(w.expected, i)
+	}
+
+	// Транзакция из нескольких операций: после восстановления видны либо все ее изменения, либо ни одного
+	committed := w.with(1, nil)
+	for i := int32(40); i < 45; i++ {
+		committed[i] = fmt.Sprintf("batch-%d", i)
+	}
+
+	tx = w.dm.Begin()
+	for i := int32(40); i < 45; i++ {
+		if _, err := tx.InsertRow("users", w.row(i, committed[i])); err != nil {
+			return committed, err
+		}
+	}
+	address := w.addresses[1]
+	if err := tx.DeleteRow("users", address.PageID, address.SlotID); err != nil {
+		return committed, err
+	}
+	if err := tx.Commit(); err != nil {
+		return committed, err
+	}
+	w.expected = committed
+
+	return nil, nil
+}
+
+func (w *crashWorkload) row(id int32, name string) []data.DataCell {
+	return []data.DataCell{
+		{Value: id, Type: data.TypeInt32},
+		{Value: name, Type: data.TypeText},
+	}
+}
+
+// with возвращает копию ожидаемого состояния с записанной (name != nil) или удаленной строкой
+func (w *crashWorkload) with(id int32, name *string) map[int32]string {
+	result := map[int32]string{}
+	for k, v := range w.expected {
+		result[k] = v
+	}
+
+	if name == nil {
+		delete(result, id)
+	} else {
+		result[id] = *name
+	}
+
+	return result
+}
+
+func scanUsers(t *testing.T, dm DiskManagerService) map[int32]string {
+	it, err := dm.ScanRows("users")
+	require.NoError(t, err)
+
+	rows := map[int32]string{}
+	for it.Next() {
+		row := it.Row().Row
+		id := row[0].Value.(int32)
+		_, duplicate := rows[id]
+		require.False(t, duplicate, "row %d is returned twice", id)
+		rows[id] = row[1].Value.(string)
+	}
+	require.NoError(t, it.Err())
+
+	return rows
+}
+
+// indexUsers читает строки users через индекс и проверяет, что ключи идут по возрастанию
+func indexUsers(t *testing.T, dm *diskManager) map[int32]string {
+	tx := dm.Begin()
+	defer tx.Rollback()
+
+	it, err := tx.IndexScan("users_id", nil, nil, false)
+	require.NoError(t, err)
+
+	rows := map[int32]string{}
+	last := int32(-1)
+	for it.Next() {
+		row := it.Row().Row
+		id := row[0].Value.(int32)
+		require.Greater(t, id, last)
+		last = id
+		rows[id] = row[1].Value.(string)
+	}
+	require.NoError(t, it.Err())
+
+	return rows
+}
+
+func TestCrashRecovery(t *testing.T) {
+	// Прогон без падения считает, сколько операций записи делает нагрузка
+	dry := &crashFS{}
+	dm, err := newDiskManager(&config.Config{DBPath: t.TempDir(), BufferPoolSize: 3, IndexFanout: 3}, dry.open)
+	require.NoError(t, err)
+	workload := &crashWorkload{dm: dm, addresses: map[int32]*data.InsertRowResult{}, expected: map[int32]string{}}
+	_, err = workload.run()
+	require.NoError(t, err)
+	dry.closeAll()
+
+	totalWrites := dry.writes
+	require.Greater(t, totalWrites, 100)
+
+	for crashAt := 1; crashAt <= totalWrites; crashAt++ {
+		cfg := &config.Config{DBPath: t.TempDir(), BufferPoolSize: 3, IndexFanout: 3}
+		fs := &crashFS{crashAt: crashAt}
+
+		workload := &crashWorkload{addresses: map[int32]*data.InsertRowResult{}, expected: map[int32]string{}}
+		var withFailed map[int32]string
+
+		// Восстановление при старте тоже пишет в файлы, поэтому падение возможно и в нем
+		dm, err := newDiskManager(cfg, fs.open)
+		if err == nil {
+			workload.dm = dm
+			withFailed, err = workload.run()
+		}
+		require.ErrorIs(t, err, errCrash, "crash at write %d", crashAt)
+		fs.closeAll()
+
+		recovered, err := newDiskManager(cfg, wal.OpenOSFile)
+		require.NoError(t, err, "crash at write %d", crashAt)
+
+		if workload.dropped {
+			_, err = recovered.GetTableColumns("tmp")
+			require.Error(t, err, "crash at write %d: dropped table is back", crashAt)
+		}
+
+		_, err = recovered.GetTableColumns("users")
+		if err != nil {
+			require.False(t, workload.created, "crash at write %d: created table is lost", crashAt)
+			continue
+		}
+
+		rows := scanUsers(t, recovered)
+		if !reflect.DeepEqual(workload.expected, rows) {
+			require.Equal(t, withFailed, rows, "crash at write %d", crashAt)
+		}
+
+		// Дерево индекса восстанавливается вместе с таблицей
+		tx := recovered.Begin()
+		indexes, err := tx.GetTableIndexes("users")
+		require.NoError(t, err, "crash at write %d", crashAt)
+		require.NoError(t, tx.Rollback())
+		if workload.indexed {
+			require.Len(t, indexes, 1, "crash at write %d: created index is lost", crashAt)
+		}
+		if len(indexes) > 0 {
+			require.Equal(t, rows, indexUsers(t, recovered), "crash at write %d", crashAt)
+		}
+
+		// После восстановления таблица остается рабочей
+		_, err = recovered.InsertRow("users", workload.row(100, "after crash"))
+		require.NoError(t, err, "crash at write %d", crashAt)
+		require.Equal(t, "after crash", scanUsers(t, recovered)[100])
+		if len(indexes) > 0 {
+			require.Equal(t, "after crash", indexUsers(t, recovered)[100])
+		}
+	}
+}
@@ -6,6 +6,20 @@ const (
 	NULL_BITMAP_SIZE  = 4 // Размер null_bitmap в uint32
 	COLUMN_COUNT_SIZE = 1 // Размер количества колонок в uint8
 	DATA_TYPE_SIZE    = 1 // Размер типа данных в uint8
+	XID_SIZE          = 8 // Размер номера транзакции в uint64
+	CHECK_COUNT_SIZE  = 1 // Размер количества ограничений CHECK в uint8
+
+	// META_EXTENSION_FLAG в байте количества колонок означает, что за колонками записаны их значения
+	// DEFAULT и ограничения CHECK. Колонок не больше 32 (размер null_bitmap), поэтому старший бит свободен,
+	// а метаданные таблиц без расширения записываются и читаются как раньше
+	META_EXTENSION_FLAG = 0x80
+)
+
+// Row header: версия строки начинается с номеров транзакций, которые ее создали и удалили
+const (
+	ROW_XMIN_SIZE   = XID_SIZE
+	ROW_XMAX_SIZE   = XID_SIZE
+	ROW_HEADER_SIZE = ROW_XMIN_SIZE + ROW_XMAX_SIZE
 )
 
 // Page data
@@ -23,7 +37,9 @@ const (
 	SLOT_OFFSET_SIZE     = 2
 	SLOT_SIZE_SIZE       = 2
 	SLOT_IS_DELETED_SIZE = 1
-	ONE_SLOT_SIZE        = SLOT_ROW_ID_SIZE + SLOT_OFFSET_SIZE + SLOT_SIZE_SIZE + SLOT_IS_DELETED_SIZE + 1
+	// SLOT_VERSION_SIZE - версия схемы строки. В файлах, записанных до ALTER TABLE, этот байт нулевой
+	SLOT_VERSION_SIZE = 1
+	ONE_SLOT_SIZE     = SLOT_ROW_ID_SIZE + SLOT_OFFSET_SIZE + SLOT_SIZE_SIZE + SLOT_IS_DELETED_SIZE + SLOT_VERSION_SIZE
 
 	SLOTS_SPACE = ONE_SLOT_SIZE * MAX_SLOTS                  // 8 * 32 = 256 bytes
 	DATA_SPACE  = PAGE_SIZE - PAGE_HEADER_SIZE - SLOTS_SPACE // 4096 - 8 - 256 = 3834 bytes
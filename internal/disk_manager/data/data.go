@@ -4,87 +4,161 @@ import (
 	"fmt"
 )
 
-func (ds *dataService) insertDataRow(row []DataCell) (*InsertRowResult, error) {
+func (ds *dataService) insertDataRow(xmin uint64, version uint8, row []DataCell) (*InsertRowResult, error) {
 	pageHeaders, err := ds.ParsePageHeaders()
 	if err != nil {
 		return nil, fmt.Errorf("insertDataRow(): ds.ParsePageHeaders: %w", err)
 	}
 
 	rowSize := CalculateDataRowSize(row)
-	currentPageID := uint32(0)
+	if rowSize > DATA_SPACE {
+		return nil, fmt.Errorf("insertDataRow(): row size %d exceeds page data space %d", rowSize, DATA_SPACE)
+	}
 
+	var currentPage *PageHeader
 	for _, pageHeader := range pageHeaders {
-		if uint32(pageHeader.FreeSpace) >= rowSize {
-			currentPageID = pageHeader.PageId
+		if uint32(pageHeader.FreeSpace) >= rowSize && pageHeader.SlotsAmount < MAX_SLOTS {
+			currentPage = pageHeader
 			break
 		}
 	}
-	if currentPageID == 0 {
-		// TODO: handle case when no free space is found, and we need a new page
+	if currentPage == nil {
+		// Во всех страницах нет места - добавляем новую в конец файла
+		currentPage, err = ds.allocatePage()
+		if err != nil {
+			return nil, fmt.Errorf("insertDataRow(): ds.allocatePage: %w", err)
+		}
 	}
 
-	serializedRow := serializeDataRow(row)
+	serializedRow := append(serializeRowHeader(RowHeader{Xmin: xmin}), serializeDataRow(row)...)
 
-	slot, err := ds.insertPageSlot(currentPageID, uint16(rowSize))
+	slot, err := ds.insertPageSlot(currentPage.PageId, uint16(rowSize), version)
 	if err != nil {
 		return nil, fmt.Errorf("Insert(): InsertPageSlot: %w", err)
 	}
 
-	writeDataPosition := slot.Offset
+	writeDataPosition := ds.CalculateDataRowPosition(currentPage.PageId, slot.Offset)
 	_, err = ds.file.WriteAt(serializedRow, int64(writeDataPosition))
 	if err != nil {
 		return nil, fmt.Errorf("Insert(): file.WriteAt: %w", err)
 	}
 
-	return &InsertRowResult{SlotID: slot.SlotId, PageID: currentPageID}, nil
+	currentPage.FreeSpace -= uint16(rowSize)
+	currentPage.SlotsAmount++
+	err = ds.writePageHeader(currentPage)
+	if err != nil {
+		return nil, fmt.Errorf("Insert(): ds.writePageHeader: %w", err)
+	}
+
+	return &InsertRowResult{SlotID: slot.SlotId, PageID: currentPage.PageId}, nil
 }
 
-func (ds *dataService) insertPageSlot(pageID uint32, rowSize uint16) (*PageSlot, error) {
-	pageStartingPosition := ds.CalculatePageStartingPosition(pageID)
+// allocatePage дописывает пустую страницу в конец файла и увеличивает PageCount в метаданных.
+// Сначала пишется сама страница, потом счетчик: если процесс упадет между ними,
+// недописанная страница просто будет перезаписана при следующей аллокации
+func (ds *dataService) allocatePage() (*PageHeader, error) {
+	pageHeader := &PageHeader{
+		PageId:      ds.meta.PageCount + 1,
+		FreeSpace:   DATA_SPACE,
+		SlotsAmount: 0,
+	}
 
+	err := ds.writeEmptyPage(pageHeader)
+	if err != nil {
+		return nil, fmt.Errorf("allocatePage(): %w", err)
+	}
+
+	err = ds.writePageCount(pageHeader.PageId)
+	if err != nil {
+		return nil, fmt.Errorf("allocatePage(): %w", err)
+	}
+
+	err = ds.file.Sync()
+	if err != nil {
+		return nil, fmt.Errorf("allocatePage(): file.Sync: %w", err)
+	}
+
+	return pageHeader, nil
+}
+
+// writePageHeader перезаписывает заголовок страницы (свободное место и количество слотов)
+func (ds *dataService) writePageHeader(pageHeader *PageHeader) error {
+	position := ds.CalculatePageStartingPosition(pageHeader.PageId)
+
+	_, err := ds.file.WriteAt(serializePageHeader(pageHeader), int64(position))
+	if err != nil {
+		return fmt.Errorf("writePageHeader(): file.WriteAt: %w", err)
+	}
+
+	return nil
+}
+
+// insertPageSlot занимает слот под строку размера rowSize версии схемы version. Слот, освобожденный удалением,
+// используется повторно вместе со своим SlotId, иначе слот добавляется в конец массива.
+// Данные живых строк прижаты к концу страницы, поэтому новая строка ложится перед самой нижней из них
+func (ds *dataService) insertPageSlot(pageID uint32, rowSize uint16, version uint8) (*PageSlot, error) {
 	slots, err := ds.ParsePageSlots(pageID)
 	if err != nil {
 		return nil, fmt.Errorf("insertPageSlot(): ds.ParsePageSlots: %w", err)
 	}
 
-	lastSlotOffset := uint16(PAGE_SIZE)
-	if len(slots) > 0 {
-		lastSlotOffset = slots[len(slots)-1].Offset
+	dataStart := uint16(PAGE_SIZE)
+	index := len(slots)
+	for i, slot := range slots {
+		if slot.IsDeleted {
+			index = min(index, i)
+			continue
+		}
+		dataStart = min(dataStart, slot.Offset)
 	}
 
-	newSlotID := len(slots) + 1
+	slotID := uint16(index + 1)
+	if index < len(slots) {
+		slotID = slots[index].SlotId
+	}
 
 	slot := PageSlot{
-		SlotId:    uint16(newSlotID),
-		Offset:    lastSlotOffset - rowSize,
+		SlotId:    slotID,
+		Offset:    dataStart - rowSize,
 		RowSize:   rowSize,
 		IsDeleted: false,
+		Version:   version,
 	}
 
-	serializedSlot := serializePageSlots([]PageSlot{slot})
-
-	writeSlotPosition := pageStartingPosition + PAGE_HEADER_SIZE + uint32(len(slots)*ONE_SLOT_SIZE)
-	ds.file.WriteAt(serializedSlot, int64(writeSlotPosition))
+	err = ds.writePageSlot(pageID, index, slot)
+	if err != nil {
+		return nil, fmt.Errorf("insertPageSlot(): %w", err)
+	}
 
 	return &slot, nil
 }
 
 func (fc *dataService) writeInitialPageData() error {
+	// Записываем начальную страницу после метаданных
+	err := fc.writeEmptyPage(&PageHeader{
+		PageId:      INITIAL_PAGE_ID,
+		FreeSpace:   DATA_SPACE,
+		SlotsAmount: 0,
+	})
+	if err != nil {
+		return fmt.Errorf("writeInitialPageData(): %w", err)
+	}
+
+	return nil
+}
+
+// writeEmptyPage записывает страницу без слотов и данных на ее место в файле
+func (fc *dataService) writeEmptyPage(pageHeader *PageHeader) error {
 	page := &Page{
-		Header: PageHeader{
-			PageId:      INITIAL_PAGE_ID,
-			FreeSpace:   DATA_SPACE,
-			SlotsAmount: 0,
-		},
-		Slots: make([]PageSlot, MAX_SLOTS),
-		Data:  make([]DataRow, 0),
+		Header: *pageHeader,
+		Slots:  make([]PageSlot, MAX_SLOTS),
+		Data:   make([]DataRow, 0),
 	}
 	data := serializePage(page)
 
-	// Записываем начальную страницу после метаданных
-	_, err := fc.file.WriteAt(data, int64(fc.metaDataSpace))
+	_, err := fc.file.WriteAt(data, int64(fc.CalculatePageStartingPosition(pageHeader.PageId)))
 	if err != nil {
-		return fmt.Errorf("writeInitialPageData(): file.WriteAt: %w", err)
+		return fmt.Errorf("writeEmptyPage(): file.WriteAt: %w", err)
 	}
 
 	return nil
@@ -1,8 +1,10 @@
 package data
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,8 +12,7 @@ import (
 
 func TestInsertDataRow(t *testing.T) {
 	// Создаем временный файл для тестов
-	// tempDir := t.TempDir()
-	tempDir := "./test"
+	tempDir := t.TempDir()
 	tempFile := filepath.Join(tempDir, "test.db")
 
 	t.Run("успешная вставка строки после инициализации", func(t *testing.T) {
@@ -40,7 +41,7 @@ func TestInsertDataRow(t *testing.T) {
 			},
 		}
 
-		metaData, metaSize, err := ds.writeMetaData("test_table", columns)
+		metaData, metaSize, err := ds.writeMetaData("test_table", columns, nil, 0)
 		assert.NoError(t, err)
 		assert.NotNil(t, metaData)
 		assert.Greater(t, metaSize, 0)
@@ -56,7 +57,7 @@ func TestInsertDataRow(t *testing.T) {
 			{Value: "test", Type: TypeText, IsNull: false},
 		}
 
-		result, err := ds.insertDataRow(row)
+		result, err := ds.insertDataRow(0, 0, row)
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
 		assert.Equal(t, uint32(INITIAL_PAGE_ID), result.PageID)
@@ -97,7 +98,7 @@ func TestInsertDataRow(t *testing.T) {
 			},
 		}
 
-		metaData, metaSize, err := ds.writeMetaData("test_table", columns)
+		metaData, metaSize, err := ds.writeMetaData("test_table", columns, nil, 0)
 		assert.NoError(t, err)
 		assert.NotNil(t, metaData)
 		assert.Greater(t, metaSize, 0)
@@ -113,7 +114,7 @@ func TestInsertDataRow(t *testing.T) {
 			{Value: nil, Type: TypeText, IsNull: true},
 		}
 
-		result, err := ds.insertDataRow(row)
+		result, err := ds.insertDataRow(0, 0, row)
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
 		assert.Equal(t, uint32(INITIAL_PAGE_ID), result.PageID)
@@ -140,7 +141,7 @@ func TestInsertDataRow(t *testing.T) {
 			{Value: int32(1), Type: TypeInt32, IsNull: false},
 		}
 
-		result, err := ds.insertDataRow(row)
+		result, err := ds.insertDataRow(0, 0, row)
 		assert.Error(t, err)
 		assert.Nil(t, result)
 	})
@@ -161,7 +162,7 @@ func TestInsertDataRow(t *testing.T) {
 			{Value: int32(1), Type: TypeInt32, IsNull: false},
 		}
 
-		result, err := ds.insertDataRow(row)
+		result, err := ds.insertDataRow(0, 0, row)
 		assert.Error(t, err)
 		assert.Nil(t, result)
 	})
@@ -192,7 +193,7 @@ func TestInsertDataRow(t *testing.T) {
 			},
 		}
 
-		metaData, metaSize, err := ds.writeMetaData("test_table", columns)
+		metaData, metaSize, err := ds.writeMetaData("test_table", columns, nil, 0)
 		assert.NoError(t, err)
 		assert.NotNil(t, metaData)
 		assert.Greater(t, metaSize, 0)
@@ -219,7 +220,7 @@ func TestInsertDataRow(t *testing.T) {
 		}
 
 		for i, row := range rows {
-			result, err := ds.insertDataRow(row)
+			result, err := ds.insertDataRow(0, 0, row)
 			assert.NoError(t, err)
 			assert.NotNil(t, result)
 			assert.Equal(t, uint32(INITIAL_PAGE_ID), result.PageID)
@@ -234,4 +235,107 @@ func TestInsertDataRow(t *testing.T) {
 			assert.Equal(t, row[1].Value, parsedRow[1].Value)
 		}
 	})
+
+	t.Run("вставка строк сверх одной страницы выделяет новые страницы", func(t *testing.T) {
+		file, err := os.Create(tempFile)
+		assert.NoError(t, err)
+		defer file.Close()
+
+		columns := []Column{
+			{Name: "id", Type: TypeInt32, IsNullable: false},
+			{Name: "name", Type: TypeText, IsNullable: true},
+		}
+
+		_, err = InitTableData(file, "test_table", columns, nil, 0)
+		assert.NoError(t, err)
+
+		ds, err := NewDataService(file, "test_table")
+		assert.NoError(t, err)
+
+		rowsAmount := MAX_SLOTS*3 + 5
+		results := make([]*InsertRowResult, 0, rowsAmount)
+		for i := 0; i < rowsAmount; i++ {
+			result, err := ds.InsertDataRow(0, 0, []DataCell{
+				{Value: int32(i), Type: TypeInt32},
+				{Value: fmt.Sprintf("name-%d", i), Type: TypeText},
+			})
+			assert.NoError(t, err)
+			results = append(results, result)
+		}
+
+		assert.Equal(t, uint32(INITIAL_PAGE_ID), results[0].PageID)
+		assert.Equal(t, uint32(INITIAL_PAGE_ID+1), results[MAX_SLOTS].PageID)
+		assert.Equal(t, uint16(1), results[MAX_SLOTS].SlotID)
+		assert.Equal(t, uint32(4), ds.GetMetaData().PageCount)
+
+		// Количество страниц сохранилось в заголовке файла
+		_, err = file.Seek(0, 0)
+		assert.NoError(t, err)
+		reopened, err := NewDataService(file, "test_table")
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(4), reopened.GetMetaData().PageCount)
+
+		headers, err := reopened.ParsePageHeaders()
+		assert.NoError(t, err)
+		assert.Len(t, headers, 4)
+		assert.Equal(t, uint16(MAX_SLOTS), headers[0].SlotsAmount)
+		assert.Equal(t, uint16(5), headers[3].SlotsAmount)
+
+		usedSpace := uint32(0)
+		for i := 0; i < MAX_SLOTS; i++ {
+			usedSpace += CalculateDataRowSize([]DataCell{
+				{Value: int32(i), Type: TypeInt32},
+				{Value: fmt.Sprintf("name-%d", i), Type: TypeText},
+			})
+		}
+		assert.Equal(t, uint16(DATA_SPACE-usedSpace), headers[0].FreeSpace)
+
+		for i, result := range results {
+			row, err := reopened.ParseDataRow(result.PageID, result.SlotID)
+			assert.NoError(t, err)
+			assert.Equal(t, int32(i), row[0].Value)
+			assert.Equal(t, fmt.Sprintf("name-%d", i), row[1].Value)
+		}
+	})
+
+	t.Run("новая страница выделяется, когда кончается место под данные", func(t *testing.T) {
+		file, err := os.Create(tempFile)
+		assert.NoError(t, err)
+		defer file.Close()
+
+		_, err = InitTableData(file, "test_table", []Column{{Name: "name", Type: TypeText}}, nil, 0)
+		assert.NoError(t, err)
+
+		ds, err := NewDataService(file, "test_table")
+		assert.NoError(t, err)
+
+		// Три строки по ~1200 байт помещаются в DATA_SPACE, четвертая - уже нет
+		pageIDs := []uint32{}
+		for i := 0; i < 4; i++ {
+			result, err := ds.InsertDataRow(0, 0, []DataCell{
+				{Value: strings.Repeat("a", 1200), Type: TypeText},
+			})
+			assert.NoError(t, err)
+			pageIDs = append(pageIDs, result.PageID)
+		}
+
+		assert.Equal(t, []uint32{1, 1, 1, 2}, pageIDs)
+	})
+
+	t.Run("ошибка при вставке строки больше страницы", func(t *testing.T) {
+		file, err := os.Create(tempFile)
+		assert.NoError(t, err)
+		defer file.Close()
+
+		_, err = InitTableData(file, "test_table", []Column{{Name: "name", Type: TypeText}}, nil, 0)
+		assert.NoError(t, err)
+
+		ds, err := NewDataService(file, "test_table")
+		assert.NoError(t, err)
+
+		_, err = ds.InsertDataRow(0, 0, []DataCell{
+			{Value: strings.Repeat("a", DATA_SPACE), Type: TypeText},
+		})
+		assert.Error(t, err)
+	})
 }
@@ -0,0 +1,119 @@
+package data
+
+import "fmt"
+
+// deleteDataRow помечает слот строки удаленным и уплотняет страницу: данные остальных строк
+// сдвигаются к концу страницы, а освободившееся место и слот достаются следующим вставкам
+func (ds *dataService) deleteDataRow(pageID uint32, slotID uint16) error {
+	_, index, err := ds.findLiveSlot(pageID, slotID)
+	if err != nil {
+		return fmt.Errorf("deleteDataRow(): %w", err)
+	}
+
+	page, err := ds.readPage(pageID)
+	if err != nil {
+		return fmt.Errorf("deleteDataRow(): %w", err)
+	}
+
+	header, err := deserializePageHeader(page)
+	if err != nil {
+		return fmt.Errorf("deleteDataRow(): %w", err)
+	}
+
+	slots, err := deserializePageSlots(page[PAGE_HEADER_SIZE : PAGE_HEADER_SIZE+SLOTS_SPACE])
+	if err != nil {
+		return fmt.Errorf("deleteDataRow(): %w", err)
+	}
+	slots[index].IsDeleted = true
+
+	// Страница переписывается одной записью, чтобы читатели не увидели ее наполовину уплотненной
+	_, err = ds.file.WriteAt(compactPage(page, header, slots), int64(ds.CalculatePageStartingPosition(pageID)))
+	if err != nil {
+		return fmt.Errorf("deleteDataRow(): file.WriteAt: %w", err)
+	}
+
+	return nil
+}
+
+// compactPage собирает страницу заново: данные живых строк лежат вплотную к концу страницы,
+// SlotId не меняются, а в заголовке пересчитаны свободное место и количество живых слотов
+func compactPage(page []byte, header *PageHeader, slots []PageSlot) []byte {
+	result := make([]byte, PAGE_SIZE)
+
+	header.FreeSpace = DATA_SPACE
+	header.SlotsAmount = 0
+
+	dataStart := PAGE_SIZE
+	for i, slot := range slots {
+		if slot.IsDeleted {
+			continue
+		}
+
+		dataStart -= int(slot.RowSize)
+		copy(result[dataStart:], page[slot.Offset:int(slot.Offset)+int(slot.RowSize)])
+
+		slots[i].Offset = uint16(dataStart)
+		header.FreeSpace -= slot.RowSize
+		header.SlotsAmount++
+	}
+
+	copy(result, serializePageHeader(header))
+	copy(result[PAGE_HEADER_SIZE:], serializePageSlots(slots))
+
+	return result
+}
+
+// setRowXmax записывает в заголовок версии строки транзакцию, которая ее удалила
+func (ds *dataService) setRowXmax(pageID uint32, slotID uint16, xmax uint64) error {
+	slot, _, err := ds.findLiveSlot(pageID, slotID)
+	if err != nil {
+		return fmt.Errorf("setRowXmax(): %w", err)
+	}
+
+	buffer := serializeRowHeader(RowHeader{Xmax: xmax})[ROW_XMIN_SIZE:]
+	position := ds.CalculateDataRowPosition(pageID, slot.Offset) + ROW_XMIN_SIZE
+
+	_, err = ds.file.WriteAt(buffer, int64(position))
+	if err != nil {
+		return fmt.Errorf("setRowXmax(): file.WriteAt: %w", err)
+	}
+
+	return nil
+}
+
+// findLiveSlot ищет неудаленный слот и возвращает его вместе с позицией в массиве слотов страницы
+func (ds *dataService) findLiveSlot(pageID uint32, slotID uint16) (PageSlot, int, error) {
+	if pageID < INITIAL_PAGE_ID || pageID > ds.meta.PageCount {
+		return PageSlot{}, 0, fmt.Errorf("page %d does not exist", pageID)
+	}
+
+	slots, err := ds.ParsePageSlots(pageID)
+	if err != nil {
+		return PageSlot{}, 0, fmt.Errorf("ds.ParsePageSlots: %w", err)
+	}
+
+	for i, slot := range slots {
+		if slot.SlotId != slotID {
+			continue
+		}
+		if slot.IsDeleted {
+			return PageSlot{}, 0, fmt.Errorf("slot %d on page %d is already deleted", slotID, pageID)
+		}
+
+		return slot, i, nil
+	}
+
+	return PageSlot{}, 0, fmt.Errorf("slot %d not found on page %d", slotID, pageID)
+}
+
+// writePageSlot перезаписывает слот, стоящий на позиции index в массиве слотов страницы
+func (ds *dataService) writePageSlot(pageID uint32, index int, slot PageSlot) error {
+	writeSlotPosition := ds.CalculatePageStartingPosition(pageID) + PAGE_HEADER_SIZE + uint32(index*ONE_SLOT_SIZE)
+
+	_, err := ds.file.WriteAt(serializePageSlots([]PageSlot{slot}), int64(writeSlotPosition))
+	if err != nil {
+		return fmt.Errorf("writePageSlot(): file.WriteAt: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteDataRow(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "test.db")
+	columns := []Column{
+		{Name: "id", Type: TypeInt32, IsNullable: false},
+		{Name: "name", Type: TypeText, IsNullable: true},
+	}
+
+	newTable := func(t *testing.T, rowsAmount int) (*dataService, []*InsertRowResult) {
+		file, err := os.Create(tempFile)
+		require.NoError(t, err)
+		t.Cleanup(func() { file.Close() })
+
+		ds, err := InitTableData(file, "test_table", columns, nil, 0)
+		require.NoError(t, err)
+
+		inserted := []*InsertRowResult{}
+		for i := 0; i < rowsAmount; i++ {
+			result, err := ds.InsertDataRow(0, 0, []DataCell{
+				{Value: int32(i), Type: TypeInt32},
+				{Value: fmt.Sprintf("name-%d", i), Type: TypeText},
+			})
+			require.NoError(t, err)
+			inserted = append(inserted, result)
+		}
+
+		return ds.(*dataService), inserted
+	}
+
+	scanIDs := func(t *testing.T, ds Service) []int32 {
+		it, err := ds.Scan()
+		require.NoError(t, err)
+
+		ids := []int32{}
+		for it.Next() {
+			ids = append(ids, it.Row().Row[0].Value.(int32))
+		}
+		require.NoError(t, it.Err())
+
+		return ids
+	}
+
+	t.Run("удаленная строка пропускается при сканировании", func(t *testing.T) {
+		ds, inserted := newTable(t, 3)
+
+		err := ds.DeleteDataRow(inserted[1].PageID, inserted[1].SlotID)
+		require.NoError(t, err)
+
+		assert.Equal(t, []int32{0, 2}, scanIDs(t, ds))
+
+		_, err = ds.ParseDataRow(inserted[1].PageID, inserted[1].SlotID)
+		assert.Error(t, err)
+	})
+
+	t.Run("удаление на второй странице сохраняется после переоткрытия", func(t *testing.T) {
+		ds, inserted := newTable(t, MAX_SLOTS+2)
+
+		last := inserted[len(inserted)-1]
+		require.Equal(t, uint32(2), last.PageID)
+		require.NoError(t, ds.DeleteDataRow(last.PageID, last.SlotID))
+
+		reopened, err := NewDataService(ds.file, "test_table")
+		require.NoError(t, err)
+
+		ids := scanIDs(t, reopened)
+		assert.Len(t, ids, MAX_SLOTS+1)
+		assert.NotContains(t, ids, int32(MAX_SLOTS+1))
+	})
+
+	t.Run("повторное удаление возвращает ошибку", func(t *testing.T) {
+		ds, inserted := newTable(t, 1)
+
+		require.NoError(t, ds.DeleteDataRow(inserted[0].PageID, inserted[0].SlotID))
+		assert.Error(t, ds.DeleteDataRow(inserted[0].PageID, inserted[0].SlotID))
+	})
+
+	t.Run("несуществующий слот или страница", func(t *testing.T) {
+		ds, _ := newTable(t, 1)
+
+		assert.Error(t, ds.DeleteDataRow(INITIAL_PAGE_ID, 999))
+		assert.Error(t, ds.DeleteDataRow(5, 1))
+	})
+	t.Run("место удаленной строки и ее слот используются повторно", func(t *testing.T) {
+		ds, inserted := newTable(t, 3)
+
+		headers, err := ds.ParsePageHeaders()
+		require.NoError(t, err)
+		freeSpace := headers[0].FreeSpace
+
+		require.NoError(t, ds.DeleteDataRow(inserted[1].PageID, inserted[1].SlotID))
+
+		headers, err = ds.ParsePageHeaders()
+		require.NoError(t, err)
+		assert.Equal(t, uint16(2), headers[0].SlotsAmount)
+		assert.Greater(t, headers[0].FreeSpace, freeSpace)
+
+		result, err := ds.InsertDataRow(0, 0, []DataCell{
+			{Value: int32(9), Type: TypeInt32},
+			{Value: "name-9", Type: TypeText},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, inserted[1], result)
+
+		headers, err = ds.ParsePageHeaders()
+		require.NoError(t, err)
+		assert.Equal(t, freeSpace, headers[0].FreeSpace)
+		assert.Equal(t, []int32{0, 9, 2}, scanIDs(t, ds))
+	})
+
+	t.Run("после уплотнения страницы строки читаются по прежним адресам", func(t *testing.T) {
+		ds, inserted := newTable(t, 5)
+
+		require.NoError(t, ds.DeleteDataRow(inserted[0].PageID, inserted[0].SlotID))
+		require.NoError(t, ds.DeleteDataRow(inserted[3].PageID, inserted[3].SlotID))
+
+		for _, i := range []int{1, 2, 4} {
+			row, err := ds.ParseDataRow(inserted[i].PageID, inserted[i].SlotID)
+			require.NoError(t, err)
+			assert.Equal(t, int32(i), row[0].Value)
+			assert.Equal(t, fmt.Sprintf("name-%d", i), row[1].Value)
+		}
+	})
+}
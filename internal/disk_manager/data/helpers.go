@@ -3,7 +3,7 @@ package data
 import (
 	bs "custom-database/internal/disk_manager/binary_serializer"
 	"fmt"
-	"io"
+	"time"
 )
 
 func (ds *dataService) CalculatePageStartingPosition(pageID uint32) uint32 {
@@ -18,9 +18,12 @@ func (fc *dataService) CalculateDataRowPosition(pageID uint32, offset uint16) ui
 }
 
 func (fc *dataService) ReadFileRange(start uint32, end uint32) ([]byte, error) {
-	fc.file.Seek(int64(start), io.SeekStart)
+	if fc.file == nil {
+		return nil, fmt.Errorf("ReadFileRange(): file is nil")
+	}
+
 	result := make([]byte, end-start)
-	_, err := fc.file.Read(result)
+	_, err := fc.file.ReadAt(result, int64(start))
 	if err != nil {
 		return nil, fmt.Errorf("ReadFileRange(): file.Read in range %d-%d: %w", start, end, err)
 	}
@@ -28,7 +31,13 @@ func (fc *dataService) ReadFileRange(start uint32, end uint32) ([]byte, error) {
 	return result, nil
 }
 
+// CalculateDataRowSize возвращает место, которое версия строки занимает на странице, вместе с заголовком
 func CalculateDataRowSize(row []DataCell) uint32 {
+	return ROW_HEADER_SIZE + calculateCellsSize(row)
+}
+
+// calculateCellsSize возвращает размер значений строки вместе с null bitmap
+func calculateCellsSize(row []DataCell) uint32 {
 	rowSize := NULL_BITMAP_SIZE
 
 	for _, cell := range row {
@@ -51,11 +60,38 @@ func calculateFileSize(metaFile *MetaData) int {
 		columnSize += bs.TEXT_TYPE_HEADER + len(column.Name) + DATA_TYPE_SIZE
 	}
 
+	extensionSize := 0
+	if hasMetaExtension(metaFile) {
+		for _, column := range metaFile.Columns {
+			extensionSize += bs.TEXT_TYPE_HEADER + len(column.Default)
+		}
+		extensionSize += CHECK_COUNT_SIZE
+		for _, check := range metaFile.Checks {
+			extensionSize += 2*bs.TEXT_TYPE_HEADER + len(check.Name) + len(check.Expression)
+		}
+	}
+
 	return bs.TEXT_TYPE_HEADER + len(metaFile.Name) +
 		PAGE_COUNT_SIZE + // количество страниц в uint32
+		2*XID_SIZE + // транзакции, создавшая и удалившая таблицу
 		COLUMN_COUNT_SIZE + // количество колонок в uint8
 		NULL_BITMAP_SIZE + // null_bitmap size в uint32
-		columnSize
+		columnSize +
+		extensionSize
+}
+
+// hasMetaExtension сообщает, нужно ли записывать за колонками значения DEFAULT и ограничения CHECK
+func hasMetaExtension(metaData *MetaData) bool {
+	if len(metaData.Checks) > 0 {
+		return true
+	}
+	for _, column := range metaData.Columns {
+		if column.Default != "" {
+			return true
+		}
+	}
+
+	return false
 }
 
 func СalculateColumnSize(columnType ColumnType) int {
@@ -72,6 +108,8 @@ func СalculateColumnSize(columnType ColumnType) int {
 		return 1
 	case TypeText:
 		return 0
+	case TypeTimestamp:
+		return 8
 	}
 
 	panic("CalculateColumnSize(): unknown column type")
@@ -91,6 +129,9 @@ func ConvertValueToType(data []byte, offset int, columnType ColumnType) (interfa
 		return bs.ReadBool(data, offset), СalculateColumnSize(columnType)
 	case TypeText:
 		return bs.ReadString(data, offset)
+	case TypeTimestamp:
+		// Timestamp хранится как количество секунд Unix в int64
+		return time.Unix(bs.ReadInt64(data, offset), 0).UTC(), СalculateColumnSize(columnType)
 	}
 
 	panic("ConvertValueToType(): unknown column type")
@@ -121,6 +162,9 @@ func ConvertValueToBuffer(columnType ColumnType, value interface{}) []byte {
 		buffer := make([]byte, bs.TEXT_TYPE_HEADER+len(value.(string)))
 		bs.WriteString(buffer, 0, value.(string))
 		return buffer
+	case TypeTimestamp:
+		bs.WriteInt64(buffer8, 0, value.(time.Time).Unix())
+		return buffer8
 	}
 
 	panic("ConvertValueToBuffer(): unknown column type")
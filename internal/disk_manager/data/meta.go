@@ -3,23 +3,26 @@ package data
 import (
 	"fmt"
 	"io"
+	"math"
 
 	bs "custom-database/internal/disk_manager/binary_serializer"
 	helpers "custom-database/internal/disk_manager/helpers"
 )
 
-func (fc *dataService) writeMetaData(tableName string, columns []Column) (*MetaData, int, error) {
+func (fc *dataService) writeMetaData(tableName string, columns []Column, checks []Check, xmin uint64) (*MetaData, int, error) {
 	metaData := &MetaData{
 		Name:      tableName,
 		PageCount: 1,
+		Xmin:      xmin,
 		Columns:   columns,
+		Checks:    checks,
 	}
 
 	data := serializeMetaData(metaData)
 
-	_, err := fc.file.Write(data)
+	_, err := fc.file.WriteAt(data, 0)
 	if err != nil {
-		return nil, 0, fmt.Errorf("WriteMetaData(): file.Write: %w", err)
+		return nil, 0, fmt.Errorf("WriteMetaData(): file.WriteAt: %w", err)
 	}
 
 	fc.metaDataSpace = len(data)
@@ -28,11 +31,61 @@ func (fc *dataService) writeMetaData(tableName string, columns []Column) (*MetaD
 	return metaData, len(data), nil
 }
 
+// writePageCount обновляет количество страниц в уже записанных метаданных.
+// PageCount лежит сразу после имени таблицы, поэтому перезаписываются только его 4 байта
+func (fc *dataService) writePageCount(pageCount uint32) error {
+	buffer := make([]byte, PAGE_COUNT_SIZE)
+	bs.WriteUint32(buffer, 0, pageCount)
+
+	position := bs.TEXT_TYPE_HEADER + len(fc.meta.Name)
+	_, err := fc.file.WriteAt(buffer, int64(position))
+	if err != nil {
+		return fmt.Errorf("writePageCount(): file.WriteAt: %w", err)
+	}
+
+	fc.meta.PageCount = pageCount
+
+	return nil
+}
+
+// readPageCount читает количество страниц из файла, а не из загруженных метаданных:
+// так читатель видит страницы, выделенные после открытия таблицы
+func (fc *dataService) readPageCount() (uint32, error) {
+	position := uint32(bs.TEXT_TYPE_HEADER + len(fc.meta.Name))
+
+	buffer, err := fc.ReadFileRange(position, position+PAGE_COUNT_SIZE)
+	if err != nil {
+		return 0, fmt.Errorf("readPageCount(): %w", err)
+	}
+
+	return bs.ReadUint32(buffer, 0), nil
+}
+
+// writeTableXmax записывает в метаданные транзакцию, удалившую таблицу
+func (fc *dataService) writeTableXmax(xmax uint64) error {
+	buffer := make([]byte, XID_SIZE)
+	bs.WriteUint64(buffer, 0, xmax)
+
+	position := bs.TEXT_TYPE_HEADER + len(fc.meta.Name) + PAGE_COUNT_SIZE + XID_SIZE
+	_, err := fc.file.WriteAt(buffer, int64(position))
+	if err != nil {
+		return fmt.Errorf("writeTableXmax(): file.WriteAt: %w", err)
+	}
+
+	fc.meta.Xmax = xmax
+
+	return nil
+}
+
 func (fc *dataService) loadMetaData() (*MetaData, int, error) {
-	data, err := io.ReadAll(fc.file)
+	// Метаданные всегда в начале файла, независимо от текущей позиции
+	data, err := io.ReadAll(io.NewSectionReader(fc.file, 0, math.MaxInt64))
 	if err != nil {
 		return nil, 0, fmt.Errorf("loadMetaData(): io.ReadAll: %w", err)
 	}
+	if len(data) == 0 {
+		return nil, 0, fmt.Errorf("loadMetaData(): table file is empty")
+	}
 
 	metaData, offset := deserializeMetaData(data)
 
@@ -51,11 +104,22 @@ func serializeMetaData(metaData *MetaData) []byte {
 	bs.WriteUint32(buffer, offset, metaData.PageCount)
 	offset += PAGE_COUNT_SIZE
 
-	// 3. Сериализуем количество колонок
-	bs.WriteUint8(buffer, offset, uint8(len(metaData.Columns)))
+	// 3. Сериализуем транзакции, создавшую и удалившую таблицу
+	bs.WriteUint64(buffer, offset, metaData.Xmin)
+	offset += XID_SIZE
+	bs.WriteUint64(buffer, offset, metaData.Xmax)
+	offset += XID_SIZE
+
+	// 4. Сериализуем количество колонок и флаг расширения
+	columnsCount := uint8(len(metaData.Columns))
+	extension := hasMetaExtension(metaData)
+	if extension {
+		columnsCount |= META_EXTENSION_FLAG
+	}
+	bs.WriteUint8(buffer, offset, columnsCount)
 	offset += COLUMN_COUNT_SIZE
 
-	// 4. Сериализуем null nullBitmap
+	// 5. Сериализуем null nullBitmap
 	nullBitmap := uint32(0)
 	for i := 0; i < len(metaData.Columns); i++ {
 		if metaData.Columns[i].IsNullable {
@@ -67,7 +131,7 @@ func serializeMetaData(metaData *MetaData) []byte {
 	bs.WriteUint32(buffer, offset, nullBitmap)
 	offset += NULL_BITMAP_SIZE
 
-	// 5. Сериализуем каждую колонку
+	// 6. Сериализуем каждую колонку
 	for _, column := range metaData.Columns {
 		// [N байт] имя колонки
 		offset += bs.WriteString(buffer, offset, column.Name)
@@ -75,6 +139,24 @@ func serializeMetaData(metaData *MetaData) []byte {
 		bs.WriteUint8(buffer, offset, uint8(column.Type))
 		offset += DATA_TYPE_SIZE
 	}
+
+	if !extension {
+		return buffer
+	}
+
+	// 7. Сериализуем значения DEFAULT колонок
+	for _, column := range metaData.Columns {
+		offset += bs.WriteString(buffer, offset, column.Default)
+	}
+
+	// 8. Сериализуем ограничения CHECK
+	bs.WriteUint8(buffer, offset, uint8(len(metaData.Checks)))
+	offset += CHECK_COUNT_SIZE
+	for _, check := range metaData.Checks {
+		offset += bs.WriteString(buffer, offset, check.Name)
+		offset += bs.WriteString(buffer, offset, check.Expression)
+	}
+
 	return buffer
 }
 
@@ -90,16 +172,23 @@ func deserializeMetaData(data []byte) (*MetaData, int) {
 	metaData.PageCount = bs.ReadUint32(data, offset)
 	offset += PAGE_COUNT_SIZE
 
-	// 3. Читаем количество колонок
+	// 3. Читаем транзакции, создавшую и удалившую таблицу
+	metaData.Xmin = bs.ReadUint64(data, offset)
+	offset += XID_SIZE
+	metaData.Xmax = bs.ReadUint64(data, offset)
+	offset += XID_SIZE
+
+	// 4. Читаем количество колонок
 	columnsCount := bs.ReadUint8(data, offset)
-	metaData.Columns = make([]Column, columnsCount)
+	extension := columnsCount&META_EXTENSION_FLAG != 0
+	metaData.Columns = make([]Column, columnsCount&^META_EXTENSION_FLAG)
 	offset += COLUMN_COUNT_SIZE
 
-	// 4. Читаем bitmap для nullable колонок
+	// 5. Читаем bitmap для nullable колонок
 	nullBitmap := bs.ReadUint32(data, offset)
 	offset += NULL_BITMAP_SIZE
 
-	// 5. Читаем информацию о колонках
+	// 6. Читаем информацию о колонках
 	for i := 0; i < len(metaData.Columns); i++ {
 		columnName, columnNameOffset := bs.ReadString(data, offset)
 
@@ -113,5 +202,28 @@ func deserializeMetaData(data []byte) (*MetaData, int) {
 		}
 	}
 
+	if !extension {
+		return metaData, offset
+	}
+
+	// 7. Читаем значения DEFAULT колонок
+	for i := range metaData.Columns {
+		columnDefault, size := bs.ReadString(data, offset)
+		metaData.Columns[i].Default = columnDefault
+		offset += size
+	}
+
+	// 8. Читаем ограничения CHECK
+	checksCount := int(bs.ReadUint8(data, offset))
+	offset += CHECK_COUNT_SIZE
+	for i := 0; i < checksCount; i++ {
+		name, nameSize := bs.ReadString(data, offset)
+		offset += nameSize
+		expression, expressionSize := bs.ReadString(data, offset)
+		offset += expressionSize
+
+		metaData.Checks = append(metaData.Checks, Check{Name: name, Expression: expression})
+	}
+
 	return metaData, offset
 }
@@ -1,9 +1,13 @@
 package data
 
 import (
+	bs "custom-database/internal/disk_manager/binary_serializer"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSerializeAndDeserializeMetaData(t *testing.T) {
@@ -12,6 +16,8 @@ func TestSerializeAndDeserializeMetaData(t *testing.T) {
 		metaData := &MetaData{
 			Name:      "test_table",
 			PageCount: 1,
+			Xmin:      3,
+			Xmax:      5,
 			Columns: []Column{
 				{
 					Name:       "id",
@@ -38,6 +44,8 @@ func TestSerializeAndDeserializeMetaData(t *testing.T) {
 		// Проверка полей
 		assert.Equal(t, metaData.Name, deserialized.Name, "имя таблицы должно совпадать")
 		assert.Equal(t, metaData.PageCount, deserialized.PageCount, "количество страниц должно совпадать")
+		assert.Equal(t, metaData.Xmin, deserialized.Xmin, "транзакция создания должна совпадать")
+		assert.Equal(t, metaData.Xmax, deserialized.Xmax, "транзакция удаления должна совпадать")
 		assert.Len(t, deserialized.Columns, len(metaData.Columns), "количество колонок должно совпадать")
 
 		// Проверка первой колонки
@@ -126,4 +134,61 @@ func TestSerializeAndDeserializeMetaData(t *testing.T) {
 		assert.Equal(t, TypeBoolean, deserialized.Columns[3].Type)
 		assert.True(t, deserialized.Columns[3].IsNullable)
 	})
+
+	t.Run("значения DEFAULT и ограничения CHECK", func(t *testing.T) {
+		metaData := &MetaData{
+			Name:      "checked_table",
+			PageCount: 2,
+			Columns: []Column{
+				{Name: "age", Type: TypeInt32, Default: "18"},
+				{Name: "created_at", Type: TypeTimestamp, IsNullable: true, Default: "current_timestamp"},
+				{Name: "name", Type: TypeText, IsNullable: true},
+			},
+			Checks: []Check{
+				{Name: "checked_table_age_check", Expression: "age > 0"},
+				{Name: "named", Expression: "name != 'it''s'"},
+			},
+		}
+
+		serialized := serializeMetaData(metaData)
+		require.Len(t, serialized, calculateFileSize(metaData))
+
+		deserialized, offset := deserializeMetaData(serialized)
+		require.Equal(t, len(serialized), offset)
+		require.Equal(t, metaData, deserialized)
+	})
+
+	t.Run("метаданные без расширения не меняют формат", func(t *testing.T) {
+		metaData := &MetaData{
+			Name:    "plain_table",
+			Columns: []Column{{Name: "id", Type: TypeInt32}},
+		}
+
+		serialized := serializeMetaData(metaData)
+		columnsCount := serialized[len(serialized)-len("id")-bs.TEXT_TYPE_HEADER-DATA_TYPE_SIZE-NULL_BITMAP_SIZE-COLUMN_COUNT_SIZE]
+		require.Equal(t, uint8(1), columnsCount)
+
+		deserialized, offset := deserializeMetaData(serialized)
+		require.Equal(t, len(serialized), offset)
+		require.Nil(t, deserialized.Checks)
+		require.Empty(t, deserialized.Columns[0].Default)
+	})
+}
+
+func TestWriteTableXmax(t *testing.T) {
+	t.Run("транзакция удаления таблицы сохраняется в метаданных", func(t *testing.T) {
+		file, err := os.Create(filepath.Join(t.TempDir(), "test.db"))
+		require.NoError(t, err)
+		defer file.Close()
+
+		ds, err := InitTableData(file, "test_table", []Column{{Name: "id", Type: TypeInt32}}, nil, 4)
+		require.NoError(t, err)
+		require.NoError(t, ds.SetTableXmax(9))
+
+		reopened, err := NewDataService(file, "test_table")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(4), reopened.GetMetaData().Xmin)
+		assert.Equal(t, uint64(9), reopened.GetMetaData().Xmax)
+		assert.Equal(t, []Column{{Name: "id", Type: TypeInt32}}, reopened.GetMetaData().Columns)
+	})
 }
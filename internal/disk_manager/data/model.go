@@ -11,6 +11,8 @@ type PageSlot struct {
 	RowSize   uint16
 	Offset    uint16
 	IsDeleted bool
+	// Version - версия схемы таблицы, с колонками которой записана строка
+	Version uint8
 }
 
 type Page struct {
@@ -25,10 +27,21 @@ type DataCell struct {
 	IsNull bool
 }
 
+// RowHeader - заголовок версии строки. Xmin - транзакция, создавшая версию,
+// Xmax - транзакция, удалившая ее (0 - версия не удалена)
+type RowHeader struct {
+	Xmin uint64
+	Xmax uint64
+}
+
+// DataRow - версия строки. Row разобран по колонкам версии схемы Version, с которой строка записана
 type DataRow struct {
-	PageId uint32
-	SlotId uint16
-	Row    []DataCell
+	PageId  uint32
+	SlotId  uint16
+	Version uint8
+	Xmin    uint64
+	Xmax    uint64
+	Row     []DataCell
 }
 
 // Meta data
@@ -42,16 +55,29 @@ const (
 	TypeUint64
 	TypeBoolean
 	TypeText
+	TypeTimestamp
 )
 
 type Column struct {
 	Name       string
 	Type       ColumnType
 	IsNullable bool
+	// Default - значение DEFAULT колонки текстом, как его передал вызывающий. Страницы его не используют
+	Default string
+}
+
+// Check - ограничение CHECK таблицы: имя и текст условия. Данные хранят его, а проверяет вызывающий
+type Check struct {
+	Name       string
+	Expression string
 }
 
+// MetaData - метаданные таблицы. Xmin и Xmax - транзакции, создавшая и удалившая таблицу
 type MetaData struct {
 	Name      string
 	PageCount uint32
+	Xmin      uint64
+	Xmax      uint64
 	Columns   []Column
+	Checks    []Check
 }
@@ -1,6 +1,9 @@
 package data
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 func (ds *dataService) ParsePageHeader(pageID uint32) (*PageHeader, error) {
 	start := uint32(ds.metaDataSpace) + PAGE_SIZE*(pageID-1)
@@ -20,6 +23,10 @@ func (ds *dataService) ParsePageHeader(pageID uint32) (*PageHeader, error) {
 }
 
 func (ds *dataService) ParsePageHeaders() ([]*PageHeader, error) {
+	if ds.meta == nil {
+		return nil, fmt.Errorf("ParsePageHeaders(): table meta data is not loaded")
+	}
+
 	result := make([]*PageHeader, 0)
 	for i := 1; i <= int(ds.meta.PageCount); i++ {
 		start := uint32(ds.metaDataSpace) + PAGE_SIZE*(uint32(i)-1)
@@ -59,37 +66,144 @@ func (ds *dataService) ParsePageSlots(pageID uint32) ([]PageSlot, error) {
 }
 
 func (ds *dataService) ParseDataRow(pageID uint32, slotID uint16) ([]DataCell, error) {
-	pageSlots, err := ds.ParsePageSlots(pageID)
+	slot, err := ds.findSlot(pageID, slotID)
 	if err != nil {
 		return nil, err
 	}
 
-	slotOffsetStart := 0
-	slotOffsetEnd := 0
+	return ds.parseSlotData(pageID, slot)
+}
+
+// ReadRow читает версию строки вместе с заголовком одним чтением страницы, поэтому версия
+// не может оказаться наполовину измененной параллельной записью. Если страницы или слота нет
+// или слот удален, возвращается ErrRowNotFound
+func (ds *dataService) ReadRow(pageID uint32, slotID uint16) (DataRow, error) {
+	pageCount, err := ds.readPageCount()
+	if err != nil {
+		return DataRow{}, fmt.Errorf("ReadRow(): %w", err)
+	}
+	if pageID == 0 || pageID > pageCount {
+		return DataRow{}, fmt.Errorf("ReadRow(): page %d: %w", pageID, ErrRowNotFound)
+	}
+
+	page, err := ds.readPage(pageID)
+	if err != nil {
+		return DataRow{}, fmt.Errorf("ReadRow(): readPage %d: %w", pageID, err)
+	}
+
+	rows, err := ds.parsePageRows(pageID, page)
+	if err != nil {
+		return DataRow{}, fmt.Errorf("ReadRow(): parsePageRows page %d: %w", pageID, err)
+	}
+
+	for _, row := range rows {
+		if row.SlotId == slotID {
+			return row, nil
+		}
+	}
+
+	return DataRow{}, fmt.Errorf("ReadRow(): row %d:%d: %w", pageID, slotID, ErrRowNotFound)
+}
+
+// ParseRowHeader читает заголовок версии строки: транзакции, которые ее создали и удалили
+func (ds *dataService) ParseRowHeader(pageID uint32, slotID uint16) (RowHeader, error) {
+	slot, err := ds.findSlot(pageID, slotID)
+	if err != nil {
+		return RowHeader{}, err
+	}
+
+	start := ds.CalculateDataRowPosition(pageID, slot.Offset)
+	headerData, err := ds.ReadFileRange(start, start+ROW_HEADER_SIZE)
+	if err != nil {
+		return RowHeader{}, err
+	}
+
+	return deserializeRowHeader(headerData), nil
+}
+
+func (ds *dataService) findSlot(pageID uint32, slotID uint16) (PageSlot, error) {
+	pageSlots, err := ds.ParsePageSlots(pageID)
+	if err != nil {
+		return PageSlot{}, err
+	}
+
 	for _, slot := range pageSlots {
 		if slot.SlotId == slotID {
-			slotOffsetStart = int(slot.Offset)
-			slotOffsetEnd = int(slot.Offset) + int(slot.RowSize)
-			break
+			if slot.IsDeleted {
+				return PageSlot{}, errors.New("slot is deleted")
+			}
+			return slot, nil
 		}
 	}
 
-	if slotOffsetStart == 0 || slotOffsetEnd == 0 {
-		return nil, errors.New("slot not found")
-	}
+	return PageSlot{}, errors.New("slot not found")
+}
 
-	start := uint32(ds.metaDataSpace) + PAGE_SIZE*(pageID-1) + uint32(slotOffsetStart)
-	end := start + uint32(slotOffsetEnd)
+// parseSlotData читает строку, на которую указывает уже прочитанный слот страницы
+func (ds *dataService) parseSlotData(pageID uint32, slot PageSlot) ([]DataCell, error) {
+	start := ds.CalculateDataRowPosition(pageID, slot.Offset)
+	end := start + uint32(slot.RowSize)
 
 	pageData, err := ds.ReadFileRange(start, end)
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := deserializeDataRow(pageData, ds.meta.Columns)
+	columns, err := ds.columns(slot.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := deserializeDataRow(pageData[ROW_HEADER_SIZE:], columns)
 	if err != nil {
 		return nil, err
 	}
 
 	return result, nil
 }
+
+// readPage читает страницу целиком одним чтением
+func (ds *dataService) readPage(pageID uint32) ([]byte, error) {
+	start := ds.CalculatePageStartingPosition(pageID)
+
+	return ds.ReadFileRange(start, start+PAGE_SIZE)
+}
+
+// parsePageRows разбирает версии строк из уже прочитанной страницы, пропуская удаленные слоты
+func (ds *dataService) parsePageRows(pageID uint32, page []byte) ([]DataRow, error) {
+	slots, err := deserializePageSlots(page[PAGE_HEADER_SIZE : PAGE_HEADER_SIZE+SLOTS_SPACE])
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]DataRow, 0, len(slots))
+	for _, slot := range slots {
+		if slot.IsDeleted {
+			continue
+		}
+
+		rowData := page[slot.Offset : int(slot.Offset)+int(slot.RowSize)]
+		header := deserializeRowHeader(rowData)
+
+		columns, err := ds.columns(slot.Version)
+		if err != nil {
+			return nil, fmt.Errorf("slot %d: %w", slot.SlotId, err)
+		}
+
+		row, err := deserializeDataRow(rowData[ROW_HEADER_SIZE:], columns)
+		if err != nil {
+			return nil, fmt.Errorf("slot %d: %w", slot.SlotId, err)
+		}
+
+		rows = append(rows, DataRow{
+			PageId:  pageID,
+			SlotId:  slot.SlotId,
+			Version: slot.Version,
+			Xmin:    header.Xmin,
+			Xmax:    header.Xmax,
+			Row:     row,
+		})
+	}
+
+	return rows, nil
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParsePageHeader(t *testing.T) {
@@ -39,7 +40,7 @@ func TestParsePageHeader(t *testing.T) {
 			},
 		}
 
-		metaData, metaSize, err := ds.writeMetaData("test_table", columns)
+		metaData, metaSize, err := ds.writeMetaData("test_table", columns, nil, 0)
 		assert.NoError(t, err)
 		assert.NotNil(t, metaData)
 		assert.Greater(t, metaSize, 0)
@@ -120,7 +121,7 @@ func TestParsePageSlots(t *testing.T) {
 			},
 		}
 
-		metaData, metaSize, err := ds.writeMetaData("test_table", columns)
+		metaData, metaSize, err := ds.writeMetaData("test_table", columns, nil, 0)
 		assert.NoError(t, err)
 		assert.NotNil(t, metaData)
 		assert.Greater(t, metaSize, 0)
@@ -204,7 +205,7 @@ func TestParseDataRow(t *testing.T) {
 			},
 		}
 
-		metaData, metaSize, err := ds.writeMetaData("test_table", columns)
+		metaData, metaSize, err := ds.writeMetaData("test_table", columns, nil, 0)
 		assert.NoError(t, err)
 		assert.NotNil(t, metaData)
 		assert.Greater(t, metaSize, 0)
@@ -220,3 +221,113 @@ func TestParseDataRow(t *testing.T) {
 		assert.Nil(t, row)
 	})
 }
+
+func TestReadRow(t *testing.T) {
+	columns := []Column{
+		{Name: "id", Type: TypeInt32, IsNullable: false},
+		{Name: "name", Type: TypeText, IsNullable: true},
+	}
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	ds, err := InitTableData(file, "test_table", columns, nil, 1)
+	require.NoError(t, err)
+
+	first, err := ds.InsertDataRow(7, 0, []DataCell{{Value: int32(1), Type: TypeInt32}, {Value: "first", Type: TypeText}})
+	require.NoError(t, err)
+	second, err := ds.InsertDataRow(8, 0, []DataCell{{Value: int32(2), Type: TypeInt32}, {IsNull: true, Type: TypeText}})
+	require.NoError(t, err)
+	require.NoError(t, ds.SetRowXmax(second.PageID, second.SlotID, 9))
+
+	t.Run("строка читается вместе с заголовком", func(t *testing.T) {
+		row, err := ds.ReadRow(first.PageID, first.SlotID)
+		require.NoError(t, err)
+		assert.Equal(t, first.PageID, row.PageId)
+		assert.Equal(t, first.SlotID, row.SlotId)
+		assert.Equal(t, uint64(7), row.Xmin)
+		assert.Equal(t, uint64(0), row.Xmax)
+		assert.Equal(t, "first", row.Row[1].Value)
+
+		row, err = ds.ReadRow(second.PageID, second.SlotID)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(8), row.Xmin)
+		assert.Equal(t, uint64(9), row.Xmax)
+		assert.True(t, row.Row[1].IsNull)
+	})
+
+	t.Run("удаленный или несуществующий слот", func(t *testing.T) {
+		require.NoError(t, ds.DeleteDataRow(first.PageID, first.SlotID))
+
+		_, err := ds.ReadRow(first.PageID, first.SlotID)
+		assert.ErrorIs(t, err, ErrRowNotFound)
+
+		_, err = ds.ReadRow(first.PageID, 999)
+		assert.ErrorIs(t, err, ErrRowNotFound)
+
+		_, err = ds.ReadRow(0, first.SlotID)
+		assert.ErrorIs(t, err, ErrRowNotFound)
+
+		_, err = ds.ReadRow(100, first.SlotID)
+		assert.ErrorIs(t, err, ErrRowNotFound)
+	})
+}
+
+func TestReadRowSchemaVersions(t *testing.T) {
+	columns := []Column{
+		{Name: "id", Type: TypeInt32, IsNullable: false},
+	}
+	extended := []Column{
+		{Name: "id", Type: TypeInt32, IsNullable: false},
+		{Name: "name", Type: TypeText, IsNullable: true},
+	}
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	ds, err := InitTableData(file, "test_table", columns, nil, 1)
+	require.NoError(t, err)
+
+	old, err := ds.InsertDataRow(1, 0, []DataCell{{Value: int32(1), Type: TypeInt32}})
+	require.NoError(t, err)
+	current, err := ds.InsertDataRow(2, 1, []DataCell{{Value: int32(2), Type: TypeInt32}, {Value: "second", Type: TypeText}})
+	require.NoError(t, err)
+
+	t.Run("без версий схемы строку новой версии не разобрать", func(t *testing.T) {
+		_, err := ds.ReadRow(current.PageID, current.SlotID)
+		assert.Error(t, err)
+	})
+
+	ds.SetLayouts(func(version uint8) ([]Column, bool) {
+		switch version {
+		case 0:
+			return columns, true
+		case 1:
+			return extended, true
+		}
+		return nil, false
+	})
+
+	t.Run("каждая строка разбирается колонками своей версии", func(t *testing.T) {
+		row, err := ds.ReadRow(old.PageID, old.SlotID)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(0), row.Version)
+		assert.Len(t, row.Row, 1)
+
+		row, err = ds.ReadRow(current.PageID, current.SlotID)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(1), row.Version)
+		assert.Equal(t, "second", row.Row[1].Value)
+	})
+
+	t.Run("версия сохраняется при уплотнении страницы", func(t *testing.T) {
+		require.NoError(t, ds.DeleteDataRow(old.PageID, old.SlotID))
+
+		row, err := ds.ReadRow(current.PageID, current.SlotID)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(1), row.Version)
+		assert.Equal(t, "second", row.Row[1].Value)
+	})
+}
@@ -0,0 +1,81 @@
+package data
+
+import "fmt"
+
+// RowIterator построчно отдает строки таблицы. Использование:
+//
+//	for it.Next() {
+//		row := it.Row()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type RowIterator interface {
+	Next() bool
+	Row() DataRow
+	Err() error
+}
+
+type scanIterator struct {
+	ds        *dataService
+	pageCount uint32
+	pageID    uint32
+	rows      []DataRow
+	rowIndex  int
+	current   DataRow
+	err       error
+}
+
+// Scan возвращает итератор по всем неудаленным версиям строк таблицы.
+// Страницы читаются по одной по мере продвижения итератора, каждая - одним чтением,
+// поэтому итератор видит страницу целиком до или после параллельного изменения, но не посередине
+func (ds *dataService) Scan() (RowIterator, error) {
+	pageCount, err := ds.readPageCount()
+	if err != nil {
+		return nil, fmt.Errorf("Scan(): %w", err)
+	}
+
+	return &scanIterator{
+		ds:        ds,
+		pageCount: pageCount,
+	}, nil
+}
+
+func (it *scanIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	for it.rowIndex >= len(it.rows) {
+		if it.pageID >= it.pageCount {
+			return false
+		}
+		it.pageID++
+
+		page, err := it.ds.readPage(it.pageID)
+		if err != nil {
+			it.err = fmt.Errorf("Next(): readPage %d: %w", it.pageID, err)
+			return false
+		}
+
+		rows, err := it.ds.parsePageRows(it.pageID, page)
+		if err != nil {
+			it.err = fmt.Errorf("Next(): parsePageRows page %d: %w", it.pageID, err)
+			return false
+		}
+
+		it.rows = rows
+		it.rowIndex = 0
+	}
+
+	it.current = it.rows[it.rowIndex]
+	it.rowIndex++
+
+	return true
+}
+
+func (it *scanIterator) Row() DataRow {
+	return it.current
+}
+
+func (it *scanIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,101 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScan(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "test.db")
+	columns := []Column{
+		{Name: "id", Type: TypeInt32, IsNullable: false},
+		{Name: "name", Type: TypeText, IsNullable: true},
+	}
+
+	newTable := func(t *testing.T) *dataService {
+		file, err := os.Create(tempFile)
+		require.NoError(t, err)
+		t.Cleanup(func() { file.Close() })
+
+		ds, err := InitTableData(file, "test_table", columns, nil, 0)
+		require.NoError(t, err)
+
+		return ds.(*dataService)
+	}
+
+	t.Run("пустая таблица", func(t *testing.T) {
+		ds := newTable(t)
+
+		it, err := ds.Scan()
+		require.NoError(t, err)
+		assert.False(t, it.Next())
+		assert.NoError(t, it.Err())
+	})
+
+	t.Run("строки со всех страниц в порядке вставки", func(t *testing.T) {
+		ds := newTable(t)
+
+		rowsAmount := MAX_SLOTS*2 + 3
+		inserted := []*InsertRowResult{}
+		for i := 0; i < rowsAmount; i++ {
+			result, err := ds.InsertDataRow(0, 0, []DataCell{
+				{Value: int32(i), Type: TypeInt32},
+				{Value: fmt.Sprintf("name-%d", i), Type: TypeText},
+			})
+			require.NoError(t, err)
+			inserted = append(inserted, result)
+		}
+
+		it, err := ds.Scan()
+		require.NoError(t, err)
+
+		i := 0
+		for it.Next() {
+			row := it.Row()
+			assert.Equal(t, inserted[i].PageID, row.PageId)
+			assert.Equal(t, inserted[i].SlotID, row.SlotId)
+			assert.Equal(t, int32(i), row.Row[0].Value)
+			assert.Equal(t, fmt.Sprintf("name-%d", i), row.Row[1].Value)
+			i++
+		}
+		require.NoError(t, it.Err())
+		assert.Equal(t, rowsAmount, i)
+	})
+
+	t.Run("удаленные слоты пропускаются", func(t *testing.T) {
+		ds := newTable(t)
+
+		for i := 0; i < 3; i++ {
+			_, err := ds.InsertDataRow(0, 0, []DataCell{
+				{Value: int32(i), Type: TypeInt32},
+				{Value: nil, Type: TypeText, IsNull: true},
+			})
+			require.NoError(t, err)
+		}
+
+		// Помечаем второй слот первой страницы удаленным
+		slots, err := ds.ParsePageSlots(INITIAL_PAGE_ID)
+		require.NoError(t, err)
+		slot := slots[1]
+		slot.IsDeleted = true
+		position := ds.CalculatePageStartingPosition(INITIAL_PAGE_ID) + PAGE_HEADER_SIZE + ONE_SLOT_SIZE
+		_, err = ds.file.WriteAt(serializePageSlots([]PageSlot{slot}), int64(position))
+		require.NoError(t, err)
+
+		it, err := ds.Scan()
+		require.NoError(t, err)
+
+		ids := []int32{}
+		for it.Next() {
+			ids = append(ids, it.Row().Row[0].Value.(int32))
+			assert.True(t, it.Row().Row[1].IsNull)
+		}
+		require.NoError(t, it.Err())
+		assert.Equal(t, []int32{0, 2}, ids)
+	})
+}
@@ -48,6 +48,7 @@ func serializePageSlots(pageSlots []PageSlot) []byte {
 		bs.WriteUint16(buffer, offset+SLOT_ROW_ID_SIZE, slot.Offset)
 		bs.WriteUint16(buffer, offset+SLOT_ROW_ID_SIZE+SLOT_OFFSET_SIZE, slot.RowSize)
 		bs.WriteBool(buffer, offset+SLOT_ROW_ID_SIZE+SLOT_OFFSET_SIZE+SLOT_SIZE_SIZE, slot.IsDeleted)
+		bs.WriteUint8(buffer, offset+SLOT_ROW_ID_SIZE+SLOT_OFFSET_SIZE+SLOT_SIZE_SIZE+SLOT_IS_DELETED_SIZE, slot.Version)
 	}
 
 	return buffer
@@ -72,6 +73,7 @@ func deserializePageSlots(data []byte) ([]PageSlot, error) {
 				Offset:    bs.ReadUint16(data, offset+SLOT_ROW_ID_SIZE),
 				RowSize:   bs.ReadUint16(data, offset+SLOT_ROW_ID_SIZE+SLOT_OFFSET_SIZE),
 				IsDeleted: bs.ReadBool(data, offset+SLOT_ROW_ID_SIZE+SLOT_OFFSET_SIZE+SLOT_SIZE_SIZE),
+				Version:   bs.ReadUint8(data, offset+SLOT_ROW_ID_SIZE+SLOT_OFFSET_SIZE+SLOT_SIZE_SIZE+SLOT_IS_DELETED_SIZE),
 			}
 			slots = append(slots, *slot)
 		}
@@ -80,9 +82,27 @@ func deserializePageSlots(data []byte) ([]PageSlot, error) {
 	return slots, nil
 }
 
+// serializeRowHeader сериализует заголовок версии строки
+func serializeRowHeader(header RowHeader) []byte {
+	buffer := make([]byte, ROW_HEADER_SIZE)
+
+	bs.WriteUint64(buffer, 0, header.Xmin)
+	bs.WriteUint64(buffer, ROW_XMIN_SIZE, header.Xmax)
+
+	return buffer
+}
+
+// deserializeRowHeader десериализует заголовок версии строки
+func deserializeRowHeader(data []byte) RowHeader {
+	return RowHeader{
+		Xmin: bs.ReadUint64(data, 0),
+		Xmax: bs.ReadUint64(data, ROW_XMIN_SIZE),
+	}
+}
+
 // serializeDataRow сериализует данные строки
 func serializeDataRow(dataRow []DataCell) []byte {
-	rowSize := CalculateDataRowSize(dataRow)
+	rowSize := calculateCellsSize(dataRow)
 	buffer := make([]byte, rowSize)
 
 	nullBitmap := uint32(0)
@@ -2,6 +2,7 @@ package data
 
 import (
 	"testing"
+	"time"
 
 	bs "custom-database/internal/disk_manager/binary_serializer"
 	helpers "custom-database/internal/disk_manager/helpers"
@@ -355,4 +356,25 @@ func TestDeserializeDataRow(t *testing.T) {
 			assert.Equal(t, originalRow[i].IsNull, deserializedRow[i].IsNull)
 		}
 	})
+
+	t.Run("Round trip serialization with timestamp", func(t *testing.T) {
+		columns := []Column{
+			{Name: "id", Type: TypeInt32, IsNullable: false},
+			{Name: "registered_at", Type: TypeTimestamp, IsNullable: true},
+		}
+
+		registeredAt := time.Date(2024, 3, 20, 15, 30, 45, 0, time.UTC)
+		originalRow := []DataCell{
+			{Value: int32(1), Type: TypeInt32, IsNull: false},
+			{Value: registeredAt, Type: TypeTimestamp, IsNull: false},
+		}
+
+		buf := serializeDataRow(originalRow)
+		assert.Equal(t, NULL_BITMAP_SIZE+4+8, len(buf))
+
+		deserializedRow, err := deserializeDataRow(buf, columns)
+		assert.NoError(t, err)
+		assert.Equal(t, registeredAt, deserializedRow[1].Value)
+		assert.Equal(t, TypeTimestamp, deserializedRow[1].Type)
+	})
 }
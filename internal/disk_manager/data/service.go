@@ -1,23 +1,55 @@
 package data
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"io"
 )
 
+// Service работает с версиями строк: каждая строка на странице хранит заголовок RowHeader
+// с транзакциями, которые ее создали и удалили. Какие версии видны читателю, решает вызывающий код
 type Service interface {
-	InsertDataRow(row []DataCell) (*InsertRowResult, error)
+	InsertDataRow(xmin uint64, version uint8, row []DataCell) (*InsertRowResult, error)
+	DeleteDataRow(pageID uint32, slotID uint16) error
+	UpdateDataRow(xid uint64, version uint8, pageID uint32, slotID uint16, row []DataCell) (*InsertRowResult, error)
+	SetRowXmax(pageID uint32, slotID uint16, xmax uint64) error
+	SetTableXmax(xmax uint64) error
+	ParsePageHeaders() ([]*PageHeader, error)
+	ParsePageSlots(pageID uint32) ([]PageSlot, error)
+	ParseRowHeader(pageID uint32, slotID uint16) (RowHeader, error)
+	ParseDataRow(pageID uint32, slotID uint16) ([]DataCell, error)
+	ReadRow(pageID uint32, slotID uint16) (DataRow, error)
+	Scan() (RowIterator, error)
+	GetMetaData() *MetaData
+	// SetLayouts задает колонки версий схемы, с которыми записаны строки. Без него
+	// все строки разбираются по колонкам из метаданных, как строки версии 0
+	SetLayouts(layouts Layouts)
+}
+
+// Layouts возвращает колонки версии схемы version, false - такой версии нет
+type Layouts func(version uint8) ([]Column, bool)
+
+// ErrRowNotFound - по адресу нет версии строки: слот удален или его никогда не было
+var ErrRowNotFound = errors.New("row not found")
+
+// File - файл таблицы. Кроме *os.File сюда передается файл disk_manager,
+// который копит изменения в транзакции и пишет их на диск через журнал
+type File interface {
+	io.ReaderAt
+	io.WriterAt
+	Sync() error
 }
 
 type dataService struct {
-	file          *os.File
+	file          File
 	tableName     string
 	meta          *MetaData
 	metaDataSpace int
+	layouts       Layouts
 }
 
 // NewDataService на вход получает файл, который будет использоваться для работы с данными
-func NewDataService(file *os.File, tableName string) (Service, error) {
+func NewDataService(file File, tableName string) (Service, error) {
 	if file == nil {
 		return nil, fmt.Errorf("NewDataService(): file is nil")
 	}
@@ -37,14 +69,15 @@ func NewDataService(file *os.File, tableName string) (Service, error) {
 	return ds, nil
 }
 
-// InitTableData создает новую таблицу в файле
-func InitTableData(file *os.File, tableName string, columns []Column) (Service, error) {
+// InitTableData создает новую таблицу в файле. checks - ограничения CHECK, которые хранятся в метаданных,
+// xmin - транзакция, которая создает таблицу
+func InitTableData(file File, tableName string, columns []Column, checks []Check, xmin uint64) (Service, error) {
 	ds := &dataService{
 		file:      file,
 		tableName: tableName,
 	}
 
-	metaData, metaDataSpace, err := ds.writeMetaData(ds.tableName, columns)
+	metaData, metaDataSpace, err := ds.writeMetaData(ds.tableName, columns, checks, xmin)
 	if err != nil {
 		return nil, fmt.Errorf("InitTableData(): WriteMetaData: %w", err)
 	}
@@ -64,6 +97,58 @@ type InsertRowResult struct {
 	PageID uint32
 }
 
-func (ds *dataService) InsertDataRow(row []DataCell) (*InsertRowResult, error) {
-	return ds.insertDataRow(row)
+// InsertDataRow вставляет новую версию строки, созданную транзакцией xmin
+// с колонками версии схемы version
+func (ds *dataService) InsertDataRow(xmin uint64, version uint8, row []DataCell) (*InsertRowResult, error) {
+	return ds.insertDataRow(xmin, version, row)
+}
+
+// DeleteDataRow физически удаляет версию строки (pageID, slotID) и освобождает ее место на странице.
+// Версию удаляют, только когда ее уже не может увидеть ни одна транзакция
+func (ds *dataService) DeleteDataRow(pageID uint32, slotID uint16) error {
+	return ds.deleteDataRow(pageID, slotID)
+}
+
+// UpdateDataRow создает от имени транзакции xid новую версию строки (pageID, slotID)
+// и возвращает ее адрес. Старая версия остается на месте с Xmax = xid
+func (ds *dataService) UpdateDataRow(xid uint64, version uint8, pageID uint32, slotID uint16, row []DataCell) (*InsertRowResult, error) {
+	return ds.updateDataRow(xid, version, pageID, slotID, row)
+}
+
+// SetRowXmax помечает версию строки удаленной транзакцией xmax. Место версии не освобождается:
+// пока транзакция не завершена, а другие транзакции видят версию, она нужна
+func (ds *dataService) SetRowXmax(pageID uint32, slotID uint16, xmax uint64) error {
+	return ds.setRowXmax(pageID, slotID, xmax)
+}
+
+// SetTableXmax помечает таблицу удаленной транзакцией xmax
+func (ds *dataService) SetTableXmax(xmax uint64) error {
+	return ds.writeTableXmax(xmax)
+}
+
+// GetMetaData возвращает метаданные таблицы (имя, количество страниц, колонки)
+func (ds *dataService) GetMetaData() *MetaData {
+	return ds.meta
+}
+
+// SetLayouts вызывается до первого чтения строк
+func (ds *dataService) SetLayouts(layouts Layouts) {
+	ds.layouts = layouts
+}
+
+// columns возвращает колонки, по которым разбирается строка версии схемы version
+func (ds *dataService) columns(version uint8) ([]Column, error) {
+	if ds.layouts == nil {
+		if version != 0 {
+			return nil, fmt.Errorf("unknown schema version %d", version)
+		}
+		return ds.meta.Columns, nil
+	}
+
+	columns, ok := ds.layouts(version)
+	if !ok {
+		return nil, fmt.Errorf("unknown schema version %d", version)
+	}
+
+	return columns, nil
 }
@@ -2,18 +2,17 @@ package data
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestInitTableData(t *testing.T) {
-	t.Run("Создание таблицы", func(t *testing.T) {
-		// Создаем тестовую директорию
-		err := os.MkdirAll("./test_data", 0755)
-		assert.NoError(t, err)
+	tableFile := filepath.Join(t.TempDir(), "test_table.data")
 
-		file, err := os.Create("./test_data/test_table.data")
+	t.Run("Создание таблицы", func(t *testing.T) {
+		file, err := os.Create(tableFile)
 		assert.NoError(t, err)
 
 		// Создаем тестовые колонки
@@ -22,27 +21,33 @@ func TestInitTableData(t *testing.T) {
 			{Name: "name", Type: TypeText, IsNullable: true},
 		}
 
-		_, err = InitTableData(file, "test_table", columns)
+		_, err = InitTableData(file, "test_table", columns, nil, 0)
 		assert.NoError(t, err)
 	})
 
 	t.Run("Вставка данных в таблицу", func(t *testing.T) {
-		file, err := os.Open("./test_data/test_table.data")
+		file, err := os.OpenFile(tableFile, os.O_RDWR, 0644)
 		assert.NoError(t, err)
 
 		ds, err := NewDataService(file, "test_table")
 		assert.NoError(t, err)
 
-		ds.InsertDataRow([]DataCell{
+		_, err = ds.InsertDataRow(0, 0, []DataCell{
 			{Value: int32(1), Type: TypeInt32},
 			{Value: "test", Type: TypeText},
 		})
 		assert.NoError(t, err)
 
-		ds.InsertDataRow([]DataCell{
+		result, err := ds.InsertDataRow(0, 0, []DataCell{
 			{Value: int32(2), Type: TypeInt32},
 			{Value: "test2", Type: TypeText},
 		})
 		assert.NoError(t, err)
+
+		row, err := ds.ParseDataRow(result.PageID, result.SlotID)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), row[0].Value)
+		assert.Equal(t, "test2", row[1].Value)
+		assert.Equal(t, "test_table", ds.GetMetaData().Name)
 	})
 }
@@ -0,0 +1,26 @@
+package data
+
+import "fmt"
+
+// updateDataRow создает новую версию строки (pageID, slotID) от имени транзакции xid.
+// Старая версия не переписывается на месте: ее еще могут читать другие транзакции,
+// поэтому она только получает Xmax = xid, а место освобождает сборщик мусора
+func (ds *dataService) updateDataRow(xid uint64, version uint8, pageID uint32, slotID uint16, row []DataCell) (*InsertRowResult, error) {
+	_, _, err := ds.findLiveSlot(pageID, slotID)
+	if err != nil {
+		return nil, fmt.Errorf("updateDataRow(): %w", err)
+	}
+
+	// Сначала вставляем новую версию: если вставка не удалась, старая строка остается как была
+	result, err := ds.insertDataRow(xid, version, row)
+	if err != nil {
+		return nil, fmt.Errorf("updateDataRow(): %w", err)
+	}
+
+	err = ds.setRowXmax(pageID, slotID, xid)
+	if err != nil {
+		return nil, fmt.Errorf("updateDataRow(): %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,129 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateDataRow(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "test.db")
+	columns := []Column{
+		{Name: "id", Type: TypeInt32, IsNullable: false},
+		{Name: "name", Type: TypeText, IsNullable: true},
+	}
+
+	newTable := func(t *testing.T, names ...string) (*dataService, []*InsertRowResult) {
+		file, err := os.Create(tempFile)
+		require.NoError(t, err)
+		t.Cleanup(func() { file.Close() })
+
+		ds, err := InitTableData(file, "test_table", columns, nil, 0)
+		require.NoError(t, err)
+
+		inserted := []*InsertRowResult{}
+		for i, name := range names {
+			result, err := ds.InsertDataRow(0, 0, []DataCell{
+				{Value: int32(i), Type: TypeInt32},
+				{Value: name, Type: TypeText},
+			})
+			require.NoError(t, err)
+			inserted = append(inserted, result)
+		}
+
+		return ds.(*dataService), inserted
+	}
+
+	scanRows := func(t *testing.T, ds Service) []DataRow {
+		it, err := ds.Scan()
+		require.NoError(t, err)
+
+		rows := []DataRow{}
+		for it.Next() {
+			rows = append(rows, it.Row())
+		}
+		require.NoError(t, it.Err())
+
+		return rows
+	}
+
+	t.Run("обновление создает новую версию строки", func(t *testing.T) {
+		ds, inserted := newTable(t, "Rick", "Morty")
+
+		result, err := ds.UpdateDataRow(7, 0, inserted[0].PageID, inserted[0].SlotID, []DataCell{
+			{Value: int32(0), Type: TypeInt32},
+			{Value: "Bob", Type: TypeText},
+		})
+		require.NoError(t, err)
+		assert.NotEqual(t, inserted[0], result)
+
+		oldHeader, err := ds.ParseRowHeader(inserted[0].PageID, inserted[0].SlotID)
+		require.NoError(t, err)
+		assert.Equal(t, RowHeader{Xmin: 0, Xmax: 7}, oldHeader)
+
+		newHeader, err := ds.ParseRowHeader(result.PageID, result.SlotID)
+		require.NoError(t, err)
+		assert.Equal(t, RowHeader{Xmin: 7, Xmax: 0}, newHeader)
+
+		// Старая версия остается читаемой, пока ее не уберет сборщик мусора
+		oldRow, err := ds.ParseDataRow(inserted[0].PageID, inserted[0].SlotID)
+		require.NoError(t, err)
+		assert.Equal(t, "Rick", oldRow[1].Value)
+
+		newRow, err := ds.ParseDataRow(result.PageID, result.SlotID)
+		require.NoError(t, err)
+		assert.Equal(t, "Bob", newRow[1].Value)
+	})
+
+	t.Run("обновление на NULL", func(t *testing.T) {
+		ds, inserted := newTable(t, "Rick")
+
+		result, err := ds.UpdateDataRow(1, 0, inserted[0].PageID, inserted[0].SlotID, []DataCell{
+			{Value: int32(0), Type: TypeInt32},
+			{Type: TypeText, IsNull: true},
+		})
+		require.NoError(t, err)
+
+		row, err := ds.ParseDataRow(result.PageID, result.SlotID)
+		require.NoError(t, err)
+		assert.True(t, row[1].IsNull)
+	})
+
+	t.Run("скан отдает обе версии, пока старая не удалена физически", func(t *testing.T) {
+		ds, inserted := newTable(t, "Rick", "Morty")
+
+		result, err := ds.UpdateDataRow(3, 0, inserted[0].PageID, inserted[0].SlotID, []DataCell{
+			{Value: int32(0), Type: TypeInt32},
+			{Value: "Rick Sanchez from dimension C-137", Type: TypeText},
+		})
+		require.NoError(t, err)
+
+		rows := scanRows(t, ds)
+		require.Len(t, rows, 3)
+		assert.Equal(t, uint64(3), rows[0].Xmax)
+		assert.Equal(t, uint64(3), rows[2].Xmin)
+
+		require.NoError(t, ds.DeleteDataRow(inserted[0].PageID, inserted[0].SlotID))
+
+		rows = scanRows(t, ds)
+		require.Len(t, rows, 2)
+		assert.Equal(t, "Morty", rows[0].Row[1].Value)
+		assert.Equal(t, "Rick Sanchez from dimension C-137", rows[1].Row[1].Value)
+		assert.Equal(t, result.SlotID, rows[1].SlotId)
+	})
+
+	t.Run("удаленную строку обновить нельзя", func(t *testing.T) {
+		ds, inserted := newTable(t, "Rick")
+
+		require.NoError(t, ds.DeleteDataRow(inserted[0].PageID, inserted[0].SlotID))
+
+		_, err := ds.UpdateDataRow(1, 0, inserted[0].PageID, inserted[0].SlotID, []DataCell{
+			{Value: int32(0), Type: TypeInt32},
+			{Value: "Bob", Type: TypeText},
+		})
+		assert.Error(t, err)
+	})
+}
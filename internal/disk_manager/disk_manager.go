@@ -2,51 +2,399 @@ package disk_manager
 
 import (
 	"custom-database/config"
+	"custom-database/internal/disk_manager/buffer_pool"
 	"custom-database/internal/disk_manager/data"
+	"custom-database/internal/disk_manager/wal"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 )
 
+// WAL_FILE_NAME - файл журнала предзаписи в корне DBPath
+const WAL_FILE_NAME = "wal.log"
+
+// DiskManagerService хранит таблицы в файлах страниц. Строки хранятся версиями (MVCC):
+// каждая транзакция читает снимок данных на момент Begin, поэтому читатели не ждут писателей
 type DiskManagerService interface {
-	CreateTable(tableName string, columns []data.Column) error
+	// Begin открывает транзакцию со снимком данных на момент вызова
+	Begin() Transaction
+	// Методы ниже выполняются каждый в своей транзакции, которая сразу фиксируется
+	CreateTable(tableName string, columns []data.Column, checks []data.Check) error
+	DropTable(tableName string) error
+	GetTableColumns(tableName string) ([]data.Column, error)
+	InsertRow(tableName string, row []data.DataCell) (*data.InsertRowResult, error)
+	DeleteRow(tableName string, pageID uint32, slotID uint16) error
+	UpdateRow(tableName string, pageID uint32, slotID uint16, row []data.DataCell) (*data.InsertRowResult, error)
+	ScanRows(tableName string) (data.RowIterator, error)
+	// Vacuum удаляет версии строк и таблицы, которые уже не видит ни одна транзакция
+	Vacuum() error
+	BufferPoolStats() buffer_pool.Stats
+}
+
+// Transaction - транзакция MVCC. Она видит изменения, зафиксированные до Begin, и свои собственные.
+// Изменение строки или таблицы, которую уже изменила другая незавершенная транзакция
+// или транзакция, зафиксированная после Begin, сразу возвращает ErrWriteConflict
+type Transaction interface {
+	// CreateTable создает таблицу. Ограничения CHECK хранятся в ее метаданных, но проверяет их вызывающий,
+	// а NULL в колонке без IsNullable отклоняется при каждой записи
+	CreateTable(tableName string, columns []data.Column, checks []data.Check) error
+	DropTable(tableName string) error
+	GetTableColumns(tableName string) ([]data.Column, error)
+	GetTableChecks(tableName string) ([]data.Check, error)
+	InsertRow(tableName string, row []data.DataCell) (*data.InsertRowResult, error)
+	DeleteRow(tableName string, pageID uint32, slotID uint16) error
+	// UpdateRow создает новую версию строки и возвращает ее адрес
+	UpdateRow(tableName string, pageID uint32, slotID uint16, row []data.DataCell) (*data.InsertRowResult, error)
+	// ScanRows возвращает итератор по версиям строк, видимым в транзакции
+	ScanRows(tableName string) (data.RowIterator, error)
+	// CreateIndex строит индекс по колонкам таблицы, DropIndex удаляет его.
+	// Индекс ограничения PRIMARY KEY или UNIQUE удаляется только вместе с таблицей,
+	// а индекс внешнего ключа - вместе с таблицей или через DropForeignKey
+	CreateIndex(description Index) error
+	DropIndex(indexName string) error
+	DropForeignKey(indexName string) error
+	// GetTableIndexes возвращает индексы таблицы, видимые в транзакции, в порядке имен
+	GetTableIndexes(tableName string) ([]Index, error)
+	// GetReferences возвращает видимые в транзакции внешние ключи всех таблиц, которые ссылаются на tableName
+	GetReferences(tableName string) ([]Index, error)
+	// AlterTable заменяет схему таблицы: добавляет, удаляет, переименовывает колонки, меняет их тип
+	// и переименовывает таблицу. Индексы и внешние ключи должны пережить изменение: их колонки нельзя удалить
+	AlterTable(tableName string, alteration Alteration) error
+	// IndexScan возвращает видимые версии строк со значениями колонок индекса между from и to
+	// в порядке значений, а при reverse - в обратном. nil вместо границы - диапазон с этой стороны не ограничен.
+	// Индекс читается по мере обхода, поэтому итератор, который не дочитали до конца, не читает лишнего
+	IndexScan(indexName string, from, to *IndexBound, reverse bool) (data.RowIterator, error)
+	Commit() error
+	Rollback() error
 }
 
 type diskManager struct {
-	cfg *config.Config
+	cfg      *config.Config
+	openFile wal.OpenFileFunc
+	log      wal.LogService
+	pool     buffer_pool.BufferPoolService
+
+	mu     sync.Mutex
+	files  map[string]wal.File   // открытые файлы таблиц
+	tables map[string]*tableFile // таблицы с загруженными метаданными
+
+	// writeMu выполняет изменяющие операции по одной, каждая - в своей физической транзакции
+	writeMu  sync.Mutex
+	txMu     sync.RWMutex
+	tx       *transaction
+	nextTxID uint64
+	dirty    map[string]bool // таблицы, изменения которых еще не сброшены на диск через fsync
+	// failed - ошибка после записи транзакции в журнал. Файлы таблиц отстают от журнала,
+	// поэтому до перезапуска и восстановления изменения не принимаются
+	failed error
+
+	// xactMu защищает состояние транзакций MVCC
+	xactMu   sync.RWMutex
+	statuses []txStatus // статус транзакции по ее номеру
+	nextXid  uint64
+	running  map[uint64]bool            // транзакции с номером, которые еще не завершились
+	active   map[*mvccTransaction]bool  // все открытые транзакции, в том числе только читающие
+	writers  map[string]map[uint64]bool // таблица -> незавершенные транзакции, менявшие ее строки
+	// deadVersions - таблица -> количество версий, ставших мусором после последней сборки
+	deadVersions map[string]int
+	// droppedTables - удаленные таблицы -> транзакция удаления, файлы которых еще не удалены
+	droppedTables map[string]uint64
+
+	// statusFile и xidLimit меняются только внутри физических транзакций, под writeMu
+	statusFile *loggedFile
+	xidLimit   uint64 // номера транзакций меньше xidLimit уже зарезервированы в файле статусов
+
+	vacuumMu sync.Mutex // сборка мусора идет в одной таблице за раз
+
+	// indexMu защищает набор индексов и их xmin/xmax. indexFile меняется только внутри физических транзакций
+	indexMu   sync.RWMutex
+	indexes   map[string]*index
+	indexFile *loggedFile
+	// treeMu не дает читать деревья индексов, пока применяются страницы зафиксированной операции
+	treeMu sync.RWMutex
+
+	// schemaMu защищает версии схем таблиц: папка таблицы -> версии по порядку номеров.
+	// Таблицы, которые не меняли через ALTER TABLE, в schemas нет. schemaFile меняется только внутри физических транзакций
+	schemaMu   sync.RWMutex
+	schemas    map[string][]*schema
+	schemaFile *loggedFile
+}
+
+// tableFile - открытая таблица и сервисы для работы со страницами в ней
+type tableFile struct {
+	// name - папка таблицы. Имя, под которым таблицу видят транзакции, хранит версия схемы
+	name string
+	// base - версия схемы 0 из метаданных
+	base *schema
+	file *loggedFile
+	// data видит изменения текущей физической транзакции, через него таблица меняется
+	data data.Service
+	// reader видит только зафиксированные страницы, через него читают транзакции
+	reader data.Service
+	// xmin и xmax - транзакции, создавшая и удалившая таблицу. Защищены diskManager.mu
+	xmin uint64
+	xmax uint64
 }
 
+// errTableDoesNotExist возвращается, если таблицы нет или она не видна в транзакции
+var errTableDoesNotExist = errors.New("table does not exist")
+
+// NewDiskManager открывает журнал и восстанавливает таблицы после падения:
+// зафиксированные транзакции повторяются, незафиксированные отбрасываются
 func NewDiskManager(cfg *config.Config) (DiskManagerService, error) {
+	return newDiskManager(cfg, wal.OpenOSFile)
+}
+
+func newDiskManager(cfg *config.Config, openFile wal.OpenFileFunc) (*diskManager, error) {
 	if err := os.MkdirAll(cfg.DBPath, 0755); err != nil {
 		return nil, fmt.Errorf("NewDiskManager(): failed to create folder in disk_manager: %v", err)
 	}
 
-	return &diskManager{
-		cfg: cfg,
-	}, nil
-}
+	logFile, err := openFile(filepath.Join(cfg.DBPath, WAL_FILE_NAME), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("NewDiskManager(): open wal: %w", err)
+	}
+
+	log, err := wal.NewLog(logFile)
+	if err != nil {
+		logFile.Close()
+		return nil, fmt.Errorf("NewDiskManager(): %w", err)
+	}
+
+	dm := &diskManager{
+		cfg:           cfg,
+		openFile:      openFile,
+		log:           log,
+		pool:          buffer_pool.NewBufferPool(cfg.BufferPoolSize),
+		files:         map[string]wal.File{},
+		tables:        map[string]*tableFile{},
+		dirty:         map[string]bool{},
+		running:       map[uint64]bool{},
+		active:        map[*mvccTransaction]bool{},
+		writers:       map[string]map[uint64]bool{},
+		deadVersions:  map[string]int{},
+		droppedTables: map[string]uint64{},
+		indexes:       map[string]*index{},
+		schemas:       map[string][]*schema{},
+	}
 
-func (dm *diskManager) CreateTable(tableName string, columns []data.Column) error {
-	filePath := filepath.Join(dm.cfg.DBPath, tableName)
-	if _, err := os.Stat(filePath); err == nil {
-		return fmt.Errorf("CreateTable(): table already exists: %w", err)
+	err = dm.recover()
+	if err != nil {
+		log.Close()
+		return nil, fmt.Errorf("NewDiskManager(): %w", err)
 	}
 
-	if err := os.MkdirAll(filePath, 0755); err != nil {
-		return fmt.Errorf("CreateTable(): os.MkdirAll: %w", err)
+	err = dm.loadTransactions()
+	if err != nil {
+		log.Close()
+		return nil, fmt.Errorf("NewDiskManager(): %w", err)
 	}
 
-	file, err := os.Create(filepath.Join(filePath, tableName+".data"))
+	err = dm.loadSchemas()
 	if err != nil {
-		return fmt.Errorf("CreateTable(): os.Create: %w", err)
+		log.Close()
+		return nil, fmt.Errorf("NewDiskManager(): %w", err)
 	}
 
-	_, err = data.InitTableData(file, tableName, columns)
+	err = dm.loadIndexes()
 	if err != nil {
-		return fmt.Errorf("CreateTable(): data.InitTableData: %w", err)
+		log.Close()
+		return nil, fmt.Errorf("NewDiskManager(): %w", err)
 	}
 
-	file.Close()
+	return dm, nil
+}
+
+func (dm *diskManager) CreateTable(tableName string, columns []data.Column, checks []data.Check) error {
+	return dm.autocommit(func(tx Transaction) error {
+		return tx.CreateTable(tableName, columns, checks)
+	})
+}
+
+// createTableFile создает файл таблицы. Метаданные и первая страница пишутся
+// в текущую физическую транзакцию, а пустой файл без них удаляется при восстановлении
+func (dm *diskManager) createTableFile(tableName string, columns []data.Column, checks []data.Check, xmin uint64) error {
+	file, err := dm.openFile(dm.tableDataPath(tableName), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("os.OpenFile: %w", err)
+	}
+
+	dm.mu.Lock()
+	dm.files[tableName] = file
+	dm.mu.Unlock()
+
+	logged := &loggedFile{dm: dm, table: tableName, file: file}
+	_, err = data.InitTableData(logged, tableName, columns, checks, xmin)
+	if err != nil {
+		dm.closeTableFile(tableName)
+		return fmt.Errorf("data.InitTableData: %w", err)
+	}
 
 	return nil
 }
+
+func (dm *diskManager) DropTable(tableName string) error {
+	return dm.autocommit(func(tx Transaction) error {
+		return tx.DropTable(tableName)
+	})
+}
+
+func (dm *diskManager) GetTableColumns(tableName string) ([]data.Column, error) {
+	var columns []data.Column
+	err := dm.autocommit(func(tx Transaction) error {
+		var err error
+		columns, err = tx.GetTableColumns(tableName)
+		return err
+	})
+
+	return columns, err
+}
+
+func (dm *diskManager) InsertRow(tableName string, row []data.DataCell) (*data.InsertRowResult, error) {
+	var result *data.InsertRowResult
+	err := dm.autocommit(func(tx Transaction) error {
+		var err error
+		result, err = tx.InsertRow(tableName, row)
+		return err
+	})
+
+	return result, err
+}
+
+func (dm *diskManager) DeleteRow(tableName string, pageID uint32, slotID uint16) error {
+	return dm.autocommit(func(tx Transaction) error {
+		return tx.DeleteRow(tableName, pageID, slotID)
+	})
+}
+
+func (dm *diskManager) UpdateRow(tableName string, pageID uint32, slotID uint16, row []data.DataCell) (*data.InsertRowResult, error) {
+	var result *data.InsertRowResult
+	err := dm.autocommit(func(tx Transaction) error {
+		var err error
+		result, err = tx.UpdateRow(tableName, pageID, slotID, row)
+		return err
+	})
+
+	return result, err
+}
+
+// ScanRows возвращает итератор по строкам, зафиксированным на момент вызова
+func (dm *diskManager) ScanRows(tableName string) (data.RowIterator, error) {
+	var it data.RowIterator
+	err := dm.autocommit(func(tx Transaction) error {
+		var err error
+		it, err = tx.ScanRows(tableName)
+		return err
+	})
+
+	return it, err
+}
+
+// autocommit выполняет fn в отдельной транзакции: фиксирует ее, если fn выполнилась без ошибок, иначе откатывает
+func (dm *diskManager) autocommit(fn func(tx Transaction) error) error {
+	tx := dm.Begin()
+
+	err := fn(tx)
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return fmt.Errorf("%w; %w", err, rollbackErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// BufferPoolStats возвращает счетчики буферного пула страниц
+func (dm *diskManager) BufferPoolStats() buffer_pool.Stats {
+	return dm.pool.Stats()
+}
+
+// openTable возвращает открытую таблицу из кеша или открывает ее файл с диска.
+// Видна ли таблица в транзакции, проверяет вызывающий код
+func (dm *diskManager) openTable(tableName string) (*tableFile, error) {
+	if isSystemTable(tableName) {
+		return nil, fmt.Errorf("%w: %s", errTableDoesNotExist, tableName)
+	}
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if table, ok := dm.tables[tableName]; ok {
+		return table, nil
+	}
+
+	file, ok := dm.files[tableName]
+	if !ok {
+		var err error
+		file, err = dm.openFile(dm.tableDataPath(tableName), os.O_RDWR, 0644)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("%w: %s", errTableDoesNotExist, tableName)
+			}
+			return nil, fmt.Errorf("os.OpenFile: %w", err)
+		}
+		dm.files[tableName] = file
+	}
+
+	logged := &loggedFile{dm: dm, table: tableName, file: file}
+	ds, err := data.NewDataService(logged, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("data.NewDataService: %w", err)
+	}
+
+	committed := &loggedFile{dm: dm, table: tableName, file: file, committed: true}
+	reader, err := data.NewDataService(committed, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("data.NewDataService: %w", err)
+	}
+
+	table := &tableFile{
+		name:   tableName,
+		base:   baseSchema(tableName, reader.GetMetaData()),
+		file:   logged,
+		data:   ds,
+		reader: reader,
+		xmin:   reader.GetMetaData().Xmin,
+		xmax:   reader.GetMetaData().Xmax,
+	}
+	ds.SetLayouts(dm.layouts(table))
+	reader.SetLayouts(dm.layouts(table))
+	dm.tables[tableName] = table
+
+	return table, nil
+}
+
+// versions возвращает транзакции, создавшую и удалившую таблицу
+func (dm *diskManager) versions(table *tableFile) (uint64, uint64) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	return table.xmin, table.xmax
+}
+
+// isSystemTable сообщает, что имя занято служебным файлом, а не таблицей
+func isSystemTable(tableName string) bool {
+	return strings.HasPrefix(tableName, "_")
+}
+
+func (dm *diskManager) closeTableFile(tableName string) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if file, ok := dm.files[tableName]; ok {
+		file.Close()
+		delete(dm.files, tableName)
+	}
+	dm.pool.DropFile(tableName)
+	delete(dm.tables, tableName)
+	delete(dm.dirty, tableName)
+}
+
+func (dm *diskManager) tableDataPath(tableName string) string {
+	return filepath.Join(dm.cfg.DBPath, tableName, tableName+".data")
+}
@@ -0,0 +1,420 @@
+package disk_manager
+
+import (
+	"custom-database/config"
+	"custom-database/internal/disk_manager/data"
+	"custom-database/internal/disk_manager/wal"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskManager(t *testing.T) {
+	cfg := &config.Config{DBPath: t.TempDir()}
+	columns := []data.Column{
+		{Name: "id", Type: data.TypeInt32, IsNullable: true},
+		{Name: "name", Type: data.TypeText, IsNullable: true},
+	}
+
+	t.Run("создание, вставка и чтение после перезапуска", func(t *testing.T) {
+		dm, err := NewDiskManager(cfg)
+		require.NoError(t, err)
+
+		require.NoError(t, dm.CreateTable("users", columns, nil))
+		require.Error(t, dm.CreateTable("users", columns, nil))
+
+		_, err = dm.InsertRow("users", []data.DataCell{
+			{Value: int32(1), Type: data.TypeInt32},
+			{Value: "Rick", Type: data.TypeText},
+		})
+		require.NoError(t, err)
+		_, err = dm.InsertRow("users", []data.DataCell{
+			{Value: int32(2), Type: data.TypeInt32},
+			{Type: data.TypeText, IsNull: true},
+		})
+		require.NoError(t, err)
+
+		// Новый менеджер читает таблицу с диска, а не из кеша
+		reopened, err := NewDiskManager(cfg)
+		require.NoError(t, err)
+
+		gotColumns, err := reopened.GetTableColumns("users")
+		require.NoError(t, err)
+		require.Equal(t, columns, gotColumns)
+
+		it, err := reopened.ScanRows("users")
+		require.NoError(t, err)
+
+		rows := []data.DataRow{}
+		for it.Next() {
+			rows = append(rows, it.Row())
+		}
+		require.NoError(t, it.Err())
+		require.Len(t, rows, 2)
+		require.Equal(t, int32(1), rows[0].Row[0].Value)
+		require.Equal(t, "Rick", rows[0].Row[1].Value)
+		require.Equal(t, int32(2), rows[1].Row[0].Value)
+		require.True(t, rows[1].Row[1].IsNull)
+	})
+
+	t.Run("удаление строки", func(t *testing.T) {
+		dm, err := NewDiskManager(cfg)
+		require.NoError(t, err)
+
+		require.NoError(t, dm.DeleteRow("users", 1, 1))
+		require.Error(t, dm.DeleteRow("missing", 1, 1))
+
+		reopened, err := NewDiskManager(cfg)
+		require.NoError(t, err)
+
+		it, err := reopened.ScanRows("users")
+		require.NoError(t, err)
+
+		rows := []data.DataRow{}
+		for it.Next() {
+			rows = append(rows, it.Row())
+		}
+		require.NoError(t, it.Err())
+		require.Len(t, rows, 1)
+		require.Equal(t, int32(2), rows[0].Row[0].Value)
+	})
+
+	t.Run("обновление строки", func(t *testing.T) {
+		dm, err := NewDiskManager(cfg)
+		require.NoError(t, err)
+
+		result, err := dm.UpdateRow("users", 1, 2, []data.DataCell{
+			{Value: int32(2), Type: data.TypeInt32},
+			{Value: "Morty", Type: data.TypeText},
+		})
+		require.NoError(t, err)
+
+		reopened, err := NewDiskManager(cfg)
+		require.NoError(t, err)
+
+		it, err := reopened.ScanRows("users")
+		require.NoError(t, err)
+
+		rows := []data.DataRow{}
+		for it.Next() {
+			rows = append(rows, it.Row())
+		}
+		require.NoError(t, it.Err())
+		require.Len(t, rows, 1)
+		require.Equal(t, result.PageID, rows[0].PageId)
+		require.Equal(t, result.SlotID, rows[0].SlotId)
+		require.Equal(t, "Morty", rows[0].Row[1].Value)
+	})
+
+	t.Run("транзакция по нескольким таблицам", func(t *testing.T) {
+		cfg := &config.Config{DBPath: t.TempDir()}
+
+		dm, err := NewDiskManager(cfg)
+		require.NoError(t, err)
+		require.NoError(t, dm.CreateTable("users", columns, nil))
+		_, err = dm.InsertRow("users", []data.DataCell{
+			{Value: int32(1), Type: data.TypeInt32},
+			{Value: "Rick", Type: data.TypeText},
+		})
+		require.NoError(t, err)
+
+		// Rollback отменяет создание таблицы, вставку и удаление другой таблицы
+		tx := dm.Begin()
+		require.NoError(t, tx.CreateTable("orders", columns, nil))
+		_, err = tx.InsertRow("users", []data.DataCell{
+			{Value: int32(2), Type: data.TypeInt32},
+			{Value: "Morty", Type: data.TypeText},
+		})
+		require.NoError(t, err)
+		require.NoError(t, tx.DropTable("users"))
+		_, err = tx.ScanRows("users")
+		require.Error(t, err)
+		require.NoError(t, tx.Rollback())
+		require.Error(t, tx.Commit())
+
+		_, err = dm.GetTableColumns("orders")
+		require.Error(t, err)
+		require.Len(t, scanNames(t, dm, "users"), 1)
+
+		// Commit применяет изменения всех таблиц разом
+		tx = dm.Begin()
+		require.NoError(t, tx.CreateTable("orders", columns, nil))
+		_, err = tx.InsertRow("orders", []data.DataCell{
+			{Value: int32(1), Type: data.TypeInt32},
+			{Value: "order", Type: data.TypeText},
+		})
+		require.NoError(t, err)
+		require.NoError(t, tx.DropTable("users"))
+		require.NoError(t, tx.Commit())
+
+		reopened, err := NewDiskManager(cfg)
+		require.NoError(t, err)
+		_, err = reopened.GetTableColumns("users")
+		require.Error(t, err)
+		require.Equal(t, []string{"order"}, scanNames(t, reopened, "orders"))
+	})
+
+	t.Run("удаление таблицы", func(t *testing.T) {
+		dm, err := NewDiskManager(cfg)
+		require.NoError(t, err)
+
+		require.NoError(t, dm.DropTable("users"))
+		require.Error(t, dm.DropTable("users"))
+
+		_, err = dm.ScanRows("users")
+		require.Error(t, err)
+	})
+
+	t.Run("значения по умолчанию, CHECK и NOT NULL", func(t *testing.T) {
+		dm, err := NewDiskManager(cfg)
+		require.NoError(t, err)
+
+		checkedColumns := []data.Column{
+			{Name: "id", Type: data.TypeInt32},
+			{Name: "age", Type: data.TypeInt32, IsNullable: true, Default: "18"},
+		}
+		checks := []data.Check{{Name: "people_age_check", Expression: "age > 0"}}
+		require.NoError(t, dm.CreateTable("people", checkedColumns, checks))
+
+		_, err = dm.InsertRow("people", []data.DataCell{
+			{Type: data.TypeInt32, IsNull: true},
+			{Value: int32(20), Type: data.TypeInt32},
+		})
+		var constraintErr *ConstraintError
+		require.ErrorAs(t, err, &constraintErr)
+		require.Equal(t, `null value in column "id" of relation "people" violates not-null constraint`, constraintErr.Message)
+
+		result, err := dm.InsertRow("people", []data.DataCell{
+			{Value: int32(1), Type: data.TypeInt32},
+			{Type: data.TypeInt32, IsNull: true},
+		})
+		require.NoError(t, err)
+
+		_, err = dm.UpdateRow("people", result.PageID, result.SlotID, []data.DataCell{
+			{Type: data.TypeInt32, IsNull: true},
+			{Type: data.TypeInt32, IsNull: true},
+		})
+		require.ErrorAs(t, err, &constraintErr)
+
+		reopened, err := NewDiskManager(cfg)
+		require.NoError(t, err)
+
+		gotColumns, err := reopened.GetTableColumns("people")
+		require.NoError(t, err)
+		require.Equal(t, checkedColumns, gotColumns)
+
+		tx := reopened.Begin()
+		gotChecks, err := tx.GetTableChecks("people")
+		require.NoError(t, err)
+		require.Equal(t, checks, gotChecks)
+		require.NoError(t, tx.Commit())
+	})
+}
+
+func scanNames(t *testing.T, dm DiskManagerService, tableName string) []string {
+	it, err := dm.ScanRows(tableName)
+	require.NoError(t, err)
+
+	names := []string{}
+	for it.Next() {
+		names = append(names, it.Row().Row[1].Value.(string))
+	}
+	require.NoError(t, it.Err())
+
+	return names
+}
+
+func TestMVCC(t *testing.T) {
+	columns := []data.Column{
+		{Name: "id", Type: data.TypeInt32, IsNullable: true},
+		{Name: "name", Type: data.TypeText, IsNullable: true},
+	}
+	row := func(id int32, name string) []data.DataCell {
+		return []data.DataCell{
+			{Value: id, Type: data.TypeInt32},
+			{Value: name, Type: data.TypeText},
+		}
+	}
+	newTable := func(t *testing.T, names ...string) (*diskManager, []*data.InsertRowResult) {
+		dm, err := newDiskManager(&config.Config{DBPath: t.TempDir()}, wal.OpenOSFile)
+		require.NoError(t, err)
+		require.NoError(t, dm.CreateTable("users", columns, nil))
+
+		inserted := []*data.InsertRowResult{}
+		for i, name := range names {
+			result, err := dm.InsertRow("users", row(int32(i), name))
+			require.NoError(t, err)
+			inserted = append(inserted, result)
+		}
+
+		return dm, inserted
+	}
+	scanTx := func(t *testing.T, tx Transaction) []string {
+		it, err := tx.ScanRows("users")
+		require.NoError(t, err)
+
+		names := []string{}
+		for it.Next() {
+			names = append(names, it.Row().Row[1].Value.(string))
+		}
+		require.NoError(t, it.Err())
+
+		return names
+	}
+	// versionsAmount считает все версии строк в таблице, включая невидимые
+	versionsAmount := func(t *testing.T, dm *diskManager) int {
+		table, err := dm.openTable("users")
+		require.NoError(t, err)
+
+		it, err := table.reader.Scan()
+		require.NoError(t, err)
+
+		amount := 0
+		for it.Next() {
+			amount++
+		}
+		require.NoError(t, it.Err())
+
+		return amount
+	}
+
+	t.Run("снимок не видит незафиксированные и более поздние изменения", func(t *testing.T) {
+		dm, inserted := newTable(t, "Rick", "Morty")
+
+		reader := dm.Begin()
+		writer := dm.Begin()
+
+		_, err := writer.InsertRow("users", row(2, "Summer"))
+		require.NoError(t, err)
+		_, err = writer.UpdateRow("users", inserted[0].PageID, inserted[0].SlotID, row(0, "Pickle Rick"))
+		require.NoError(t, err)
+		require.NoError(t, writer.DeleteRow("users", inserted[1].PageID, inserted[1].SlotID))
+
+		// Писатель видит свои изменения, читатель и новая транзакция - нет
+		require.Equal(t, []string{"Summer", "Pickle Rick"}, scanTx(t, writer))
+		require.Equal(t, []string{"Rick", "Morty"}, scanTx(t, reader))
+		require.Equal(t, []string{"Rick", "Morty"}, scanNames(t, dm, "users"))
+
+		require.NoError(t, writer.Commit())
+
+		// Снимок читателя сделан до фиксации
+		require.Equal(t, []string{"Rick", "Morty"}, scanTx(t, reader))
+		require.Equal(t, []string{"Summer", "Pickle Rick"}, scanNames(t, dm, "users"))
+		require.NoError(t, reader.Commit())
+	})
+
+	t.Run("конфликт записи сразу возвращает ошибку", func(t *testing.T) {
+		dm, inserted := newTable(t, "Rick")
+
+		first := dm.Begin()
+		second := dm.Begin()
+
+		_, err := first.UpdateRow("users", inserted[0].PageID, inserted[0].SlotID, row(0, "first"))
+		require.NoError(t, err)
+
+		_, err = second.UpdateRow("users", inserted[0].PageID, inserted[0].SlotID, row(0, "second"))
+		require.ErrorIs(t, err, ErrWriteConflict)
+		var conflict *WriteConflictError
+		require.ErrorAs(t, err, &conflict)
+		require.Equal(t, "could not serialize access due to concurrent update", conflict.Error())
+		require.ErrorIs(t, second.DeleteRow("users", inserted[0].PageID, inserted[0].SlotID), ErrWriteConflict)
+		require.NoError(t, second.Rollback())
+
+		// После фиксации первой транзакции строку нельзя изменить и в снимке, сделанном до нее
+		third := dm.Begin()
+		require.NoError(t, first.Commit())
+		require.ErrorIs(t, third.DeleteRow("users", inserted[0].PageID, inserted[0].SlotID), ErrWriteConflict)
+		require.NoError(t, third.Rollback())
+
+		require.Equal(t, []string{"first"}, scanNames(t, dm, "users"))
+	})
+
+	t.Run("строку, удаленную откатившейся транзакцией, можно изменить", func(t *testing.T) {
+		dm, inserted := newTable(t, "Rick")
+
+		tx := dm.Begin()
+		require.NoError(t, tx.DeleteRow("users", inserted[0].PageID, inserted[0].SlotID))
+		require.NoError(t, tx.Rollback())
+
+		require.NoError(t, dm.DeleteRow("users", inserted[0].PageID, inserted[0].SlotID))
+		require.Empty(t, scanNames(t, dm, "users"))
+	})
+
+	t.Run("удаление таблицы конфликтует с незавершенными изменениями ее строк", func(t *testing.T) {
+		dm, _ := newTable(t, "Rick")
+
+		writer := dm.Begin()
+		_, err := writer.InsertRow("users", row(1, "Morty"))
+		require.NoError(t, err)
+
+		require.ErrorIs(t, dm.DropTable("users"), ErrWriteConflict)
+
+		dropper := dm.Begin()
+		require.NoError(t, writer.Commit())
+		require.NoError(t, dropper.DropTable("users"))
+
+		_, err = dm.InsertRow("users", row(2, "Summer"))
+		require.ErrorIs(t, err, ErrWriteConflict)
+		require.NoError(t, dropper.Commit())
+
+		_, err = dm.GetTableColumns("users")
+		require.ErrorIs(t, err, errTableDoesNotExist)
+		require.NoError(t, dm.CreateTable("users", columns, nil))
+		require.Empty(t, scanNames(t, dm, "users"))
+	})
+
+	t.Run("незафиксированная транзакция не видна после перезапуска", func(t *testing.T) {
+		dm, _ := newTable(t, "Rick")
+
+		tx := dm.Begin()
+		_, err := tx.InsertRow("users", row(1, "Morty"))
+		require.NoError(t, err)
+
+		reopened, err := NewDiskManager(dm.cfg)
+		require.NoError(t, err)
+		require.Equal(t, []string{"Rick"}, scanNames(t, reopened, "users"))
+
+		// Номер незафиксированной транзакции не достается новой
+		_, err = reopened.InsertRow("users", row(2, "Summer"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"Rick", "Summer"}, scanNames(t, reopened, "users"))
+	})
+
+	t.Run("сборка мусора удаляет версии, которые никто не видит", func(t *testing.T) {
+		dm, inserted := newTable(t, "Rick", "Morty")
+
+		reader := dm.Begin()
+		_, err := dm.UpdateRow("users", inserted[0].PageID, inserted[0].SlotID, row(0, "Pickle Rick"))
+		require.NoError(t, err)
+
+		tx := dm.Begin()
+		_, err = tx.InsertRow("users", row(2, "Summer"))
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+
+		// Старую версию строки еще видит читатель, а вставка откатившейся транзакции - мусор
+		require.NoError(t, dm.Vacuum())
+		require.Equal(t, 3, versionsAmount(t, dm))
+		require.Equal(t, []string{"Rick", "Morty"}, scanTx(t, reader))
+
+		require.NoError(t, reader.Commit())
+		require.NoError(t, dm.Vacuum())
+		require.Equal(t, 2, versionsAmount(t, dm))
+		require.Equal(t, []string{"Morty", "Pickle Rick"}, scanNames(t, dm, "users"))
+	})
+
+	t.Run("мусор собирается автоматически после завершения транзакций", func(t *testing.T) {
+		dm, inserted := newTable(t, "Rick")
+
+		address := inserted[0]
+		for i := 0; i < VACUUM_THRESHOLD; i++ {
+			result, err := dm.UpdateRow("users", address.PageID, address.SlotID, row(0, fmt.Sprintf("Rick-%d", i)))
+			require.NoError(t, err)
+			address = result
+		}
+
+		require.Less(t, versionsAmount(t, dm), VACUUM_THRESHOLD)
+		require.Equal(t, []string{fmt.Sprintf("Rick-%d", VACUUM_THRESHOLD-1)}, scanNames(t, dm, "users"))
+	})
+}
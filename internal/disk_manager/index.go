@@ -0,0 +1,1241 @@
+package disk_manager
+
+import (
+	"bytes"
+	"custom-database/internal/disk_manager/binary_serializer"
+	"custom-database/internal/disk_manager/btree"
+	"custom-database/internal/disk_manager/data"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// INDEXES_FILE - служебный файл с описаниями индексов
+const INDEXES_FILE = "_indexes"
+
+// INDEX_FILE_PREFIX - префикс служебного файла с B+ деревом индекса. Страницы дерева меняются
+// через журнал вместе со строками таблицы, поэтому после падения индекс совпадает с таблицей
+const INDEX_FILE_PREFIX = "_index_"
+
+// INDEX_BATCH_SIZE - сколько записей индекса IndexScan читает за раз. Между порциями дерево не заблокировано,
+// а запрос с LIMIT не читает индекс дальше, чем нужно
+const INDEX_BATCH_SIZE = 64
+
+// Старшие биты байта с количеством колонок в описании индекса хранят флаги ограничения.
+// В файлах, записанных до их появления, эти биты нулевые. В таблице не больше 32 колонок,
+// а индекс из 32 колонок не нужен ни одному ограничению, поэтому на количество остается 5 бит
+const (
+	INDEX_UNIQUE_FLAG      = 0x80
+	INDEX_PRIMARY_FLAG     = 0x40
+	INDEX_FOREIGN_KEY_FLAG = 0x20
+	INDEX_COLUMNS_MASK     = 0x1f
+)
+
+var errIndexDoesNotExist = errors.New("index does not exist")
+
+// KeyTooLongError - значения колонок строки не помещаются в ключ дерева индекса, например длинный TEXT.
+// Текст ошибки предназначен пользователю
+type KeyTooLongError struct {
+	Index string
+	Size  int
+	Max   int
+}
+
+func (e *KeyTooLongError) Error() string {
+	return fmt.Sprintf("index row size %d exceeds maximum %d for index %q", e.Size, e.Max, e.Index)
+}
+
+func (e *KeyTooLongError) Unwrap() error {
+	return btree.ErrKeyTooLong
+}
+
+// Index - вторичный индекс по одной или нескольким колонкам таблицы.
+// Unique-индекс не пускает в таблицу две живые версии строк с одинаковым ключом без NULL,
+// Primary-индекс вдобавок запрещает NULL в своих колонках.
+// Индекс с ForeignKey поддерживает внешний ключ: по нему ищутся строки, которые ссылаются на строку RefTable
+type Index struct {
+	Name       string
+	Table      string
+	Columns    []string
+	Unique     bool
+	Primary    bool
+	ForeignKey *ForeignKey
+}
+
+// ForeignKey - ссылка колонок индекса на колонки RefColumns таблицы RefTable. Ссылки проверяет
+// и действия OnDelete и OnUpdate выполняет вызывающий, disk_manager только хранит их вместе с индексом
+type ForeignKey struct {
+	RefTable   string
+	RefColumns []string
+	OnDelete   uint8
+	OnUpdate   uint8
+}
+
+// IndexBound - граница диапазона для IndexScan: значения первых колонок индекса.
+// Ключи сравниваются по колонкам слева направо, поэтому граница из части колонок
+// включает или исключает все ключи с этими значениями, например (tenant_id) для индекса (tenant_id, created_at)
+type IndexBound struct {
+	Values    []data.DataCell
+	Inclusive bool
+}
+
+// index ссылается на все неудаленные версии строк таблицы, в том числе невидимые части транзакций:
+// какие из них видны, проверяет читатель. Записи о версиях убирает сборщик мусора вместе с версиями
+type index struct {
+	// Index хранит папку таблицы в Table, а имена колонок в Columns и RefColumns - такими, какими
+	// они были при загрузке или создании индекса: после переименований их берут из версий схем по ids
+	Index
+	ids []uint8 // постоянные номера колонок ключа в схеме таблицы
+	// refIDs - номера колонок RefColumns в схеме таблицы, на которую ссылается внешний ключ
+	refIDs []uint8
+	// xmin и xmax - транзакции, создавшая и удалившая индекс. Защищены diskManager.indexMu
+	xmin uint64
+	xmax uint64
+
+	// tree - ключ из значений колонок -> адрес версии строки. Меняется только внутри физических транзакций
+	tree btree.TreeService
+	// committed - файл дерева, в котором видны только зафиксированные страницы
+	committed *loggedFile
+}
+
+type rowAddress struct {
+	pageID uint32
+	slotID uint16
+}
+
+// indexValue упаковывает адрес версии строки в значение записи дерева
+func indexValue(address rowAddress) uint64 {
+	return uint64(address.pageID)<<16 | uint64(address.slotID)
+}
+
+func indexAddress(value uint64) rowAddress {
+	return rowAddress{pageID: uint32(value >> 16), slotID: uint16(value)}
+}
+
+func indexFileName(indexName string) string {
+	return INDEX_FILE_PREFIX + indexName
+}
+
+// key кодирует значения колонок индекса из строки таблицы с колонками версии схемы s в ключ дерева.
+// NULL тоже попадает в индекс
+func (idx *index) key(s *schema, row []data.DataCell) ([]byte, error) {
+	cells := make([]data.DataCell, len(idx.ids))
+	for i, id := range idx.ids {
+		position := s.position(id)
+		if position == -1 {
+			return nil, fmt.Errorf("index %s: column is missing in schema version %d", idx.Name, s.version)
+		}
+		cells[i] = row[position]
+	}
+
+	key, err := btree.EncodeKey(cells)
+	if err != nil {
+		return nil, fmt.Errorf("index %s: %w", idx.Name, err)
+	}
+
+	return key, nil
+}
+
+// covers сообщает, что в версии схемы s есть все колонки индекса. Колонку удаляют только
+// вместе с индексом в той же транзакции, и до ее фиксации индекс без колонки не поддерживается
+func (idx *index) covers(s *schema) bool {
+	for _, id := range idx.ids {
+		if s.position(id) == -1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (idx *index) insert(s *schema, row []data.DataCell, address rowAddress) error {
+	key, err := idx.key(s, row)
+	if err != nil {
+		return err
+	}
+
+	err = idx.tree.Insert(key, indexValue(address))
+	if errors.Is(err, btree.ErrKeyTooLong) {
+		return &KeyTooLongError{Index: idx.Name, Size: len(key), Max: idx.tree.KeySize()}
+	}
+	if err != nil {
+		return fmt.Errorf("index %s: %w", idx.Name, err)
+	}
+
+	return nil
+}
+
+func (idx *index) remove(s *schema, row []data.DataCell, address rowAddress) error {
+	key, err := idx.key(s, row)
+	if err != nil {
+		return err
+	}
+
+	_, err = idx.tree.Delete(key, indexValue(address))
+	if err != nil {
+		return fmt.Errorf("index %s: %w", idx.Name, err)
+	}
+
+	return nil
+}
+
+// lookup возвращает до limit записей индекса с ключами start <= key < end в порядке ключей,
+// а при reverse - в обратном. Если after не nil, чтение продолжается со следующей за after записи.
+// Дерево читается из зафиксированных страниц под treeMu, чтобы не увидеть половину чужой операции
+func (dm *diskManager) lookup(idx *index, start, end []byte, reverse bool, after *btree.Entry, limit int) ([]btree.Entry, error) {
+	dm.treeMu.RLock()
+	defer dm.treeMu.RUnlock()
+
+	// Удаленный индекс мог уже лишиться файла
+	dm.indexMu.RLock()
+	current := dm.indexes[idx.Name]
+	dm.indexMu.RUnlock()
+	if current != idx {
+		return nil, fmt.Errorf("%w: %s", errIndexDoesNotExist, idx.Name)
+	}
+
+	reader, err := btree.NewTree(idx.committed, 0, btree.MAX_KEY_SIZE)
+	if err != nil {
+		return nil, fmt.Errorf("lookup(): %w", err)
+	}
+
+	// Курсор ставится на границу диапазона или на ключ after: обратный курсор начинает с ключей меньше
+	// границы, поэтому для after граница - следующий за его ключом key+0x00
+	seek := start
+	if reverse {
+		seek = end
+	}
+	if after != nil {
+		seek = after.Key
+		if reverse {
+			seek = append(append([]byte{}, after.Key...), 0)
+		}
+	}
+
+	it := reader.Iterator(reverse)
+	err = it.Seek(seek)
+	if err != nil {
+		return nil, fmt.Errorf("lookup(): %w", err)
+	}
+
+	result := []btree.Entry{}
+	for len(result) < limit && it.Next() {
+		entry := btree.Entry{Key: it.Key(), Value: it.Value()}
+		if after != nil {
+			cmp := entry.Compare(*after)
+			if (!reverse && cmp <= 0) || (reverse && cmp >= 0) {
+				continue
+			}
+		}
+		if (!reverse && end != nil && bytes.Compare(entry.Key, end) >= 0) || (reverse && bytes.Compare(entry.Key, start) < 0) {
+			break
+		}
+
+		result = append(result, entry)
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("lookup(): %w", err)
+	}
+
+	return result, nil
+}
+
+// buildIndex строит дерево индекса по всем неудаленным версиям строк таблицы. Записи сортируются
+// в памяти и пишутся в пустой файл снизу вверх (btree.Builder): каждая страница пишется один раз,
+// листья заполнены на INDEX_FILL_FACTOR процентов. INDEX_FANOUT ограничивает fanout сверху,
+// если длинные ключи не дают его достичь
+func (dm *diskManager) buildIndex(table *tableFile, idx *index, file btree.File, keySize int) (btree.TreeService, error) {
+	it, err := table.data.Scan()
+	if err != nil {
+		return nil, fmt.Errorf("buildIndex(): data.Scan: %w", err)
+	}
+
+	entries := []btree.Entry{}
+	for it.Next() {
+		row := it.Row()
+		cells, s, err := dm.currentRow(table, row)
+		if err != nil {
+			return nil, fmt.Errorf("buildIndex(): %w", err)
+		}
+		key, err := idx.key(s, cells)
+		if err != nil {
+			return nil, fmt.Errorf("buildIndex(): %w", err)
+		}
+		entries = append(entries, btree.Entry{Key: key, Value: indexValue(rowAddress{pageID: row.PageId, slotID: row.SlotId})})
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("buildIndex(): %w", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Compare(entries[j]) < 0
+	})
+
+	fanout := min(dm.cfg.IndexFanout, btree.MaxFanout(keySize))
+	builder, err := btree.NewBuilder(file, fanout, keySize, dm.cfg.IndexFillFactor)
+	if err != nil {
+		return nil, fmt.Errorf("buildIndex(): %w", err)
+	}
+	for _, entry := range entries {
+		err = builder.Add(entry.Key, entry.Value)
+		if errors.Is(err, btree.ErrKeyTooLong) {
+			return nil, &KeyTooLongError{Index: idx.Name, Size: len(entry.Key), Max: keySize}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("buildIndex(): index %s: %w", idx.Name, err)
+		}
+	}
+
+	tree, err := builder.Finish()
+	if err != nil {
+		return nil, fmt.Errorf("buildIndex(): %w", err)
+	}
+
+	return tree, nil
+}
+
+// indexColumns находит колонки индекса в версии схемы таблицы
+func indexColumns(s *schema, columnNames []string) ([]data.Column, []uint8, error) {
+	if len(columnNames) == 0 {
+		return nil, nil, fmt.Errorf("index must have at least one column")
+	}
+	if len(columnNames) > INDEX_COLUMNS_MASK {
+		return nil, nil, fmt.Errorf("index can have at most %d columns", INDEX_COLUMNS_MASK)
+	}
+
+	ids, err := s.columnIDs(columnNames)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := make([]data.Column, 0, len(columnNames))
+	for i, id := range ids {
+		for _, previous := range ids[:i] {
+			if previous == id {
+				return nil, nil, fmt.Errorf("column %s is listed in the index twice", columnNames[i])
+			}
+		}
+
+		columns = append(columns, s.columns[s.position(id)])
+	}
+
+	return columns, ids, nil
+}
+
+// openIndexTree открывает файл дерева индекса и регистрирует его среди файлов таблиц,
+// чтобы зафиксированные страницы попадали в него через буферный пул. open открывает дерево
+// в файле существующего индекса или строит дерево нового
+func (dm *diskManager) openIndexTree(idx *index, flag int, open func(file btree.File) (btree.TreeService, error)) error {
+	fileName := indexFileName(idx.Name)
+	file, err := dm.openFile(dm.tableDataPath(fileName), os.O_RDWR|flag, 0644)
+	if err != nil {
+		return fmt.Errorf("os.OpenFile: %w", err)
+	}
+
+	dm.mu.Lock()
+	dm.files[fileName] = file
+	dm.mu.Unlock()
+
+	idx.tree, err = open(&loggedFile{dm: dm, table: fileName, file: file})
+	if err != nil {
+		dm.closeTableFile(fileName)
+		return err
+	}
+	idx.committed = &loggedFile{dm: dm, table: fileName, file: file, committed: true}
+
+	return nil
+}
+
+// newIndex создает файл индекса по колонкам версии схемы s и строит дерево по таблице внутри
+// текущей физической транзакции. Пустой файл без зафиксированных страниц удаляется при восстановлении
+func (dm *diskManager) newIndex(table *tableFile, s *schema, description Index, refIDs []uint8, xmin uint64) (*index, error) {
+	columns, ids, err := indexColumns(s, description.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &index{
+		Index:  description,
+		ids:    ids,
+		refIDs: refIDs,
+		xmin:   xmin,
+	}
+
+	fileName := indexFileName(description.Name)
+	dirPath := filepath.Join(dm.cfg.DBPath, fileName)
+	err = os.MkdirAll(dirPath, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("os.MkdirAll: %w", err)
+	}
+	dm.tx.onAbort = append(dm.tx.onAbort, func() {
+		dm.closeTableFile(fileName)
+		os.RemoveAll(dirPath)
+	})
+
+	err = dm.openIndexTree(idx, os.O_CREATE|os.O_EXCL, func(file btree.File) (btree.TreeService, error) {
+		return dm.buildIndex(table, idx, file, btree.KeySize(columns))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return idx, nil
+}
+
+// loadIndexes читает описания индексов и открывает их деревья. Индексы прерванных транзакций
+// и индексы, удаление которых зафиксировано, удаляются вместе с файлами
+func (dm *diskManager) loadIndexes() error {
+	err := os.MkdirAll(filepath.Join(dm.cfg.DBPath, INDEXES_FILE), 0755)
+	if err != nil {
+		return fmt.Errorf("loadIndexes(): os.MkdirAll: %w", err)
+	}
+
+	file, err := dm.openFile(dm.tableDataPath(INDEXES_FILE), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("loadIndexes(): os.OpenFile: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("loadIndexes(): file.Stat: %w", err)
+	}
+
+	content := make([]byte, info.Size())
+	if len(content) > 0 {
+		_, err = file.ReadAt(content, 0)
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("loadIndexes(): file.ReadAt: %w", err)
+		}
+	}
+
+	dm.files[INDEXES_FILE] = file
+	dm.indexFile = &loggedFile{dm: dm, table: INDEXES_FILE, file: file}
+
+	indexes, err := deserializeIndexes(content)
+	if err != nil {
+		return fmt.Errorf("loadIndexes(): %w", err)
+	}
+
+	stale := []string{}
+	for _, idx := range indexes {
+		if dm.status(idx.xmin) == txAborted || (idx.xmax != 0 && dm.status(idx.xmax) == txCommitted) {
+			stale = append(stale, idx.Name)
+			continue
+		}
+		idx.xmax = 0
+
+		table, err := dm.openTable(idx.Table)
+		if errors.Is(err, errTableDoesNotExist) {
+			stale = append(stale, idx.Name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("loadIndexes(): %w", err)
+		}
+
+		idx.ids, err = dm.loadColumnIDs(table, idx.Columns)
+		if err != nil {
+			return fmt.Errorf("loadIndexes(): index %s: %w", idx.Name, err)
+		}
+		if fk := idx.ForeignKey; fk != nil {
+			refTable, err := dm.openTable(fk.RefTable)
+			if errors.Is(err, errTableDoesNotExist) {
+				stale = append(stale, idx.Name)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("loadIndexes(): %w", err)
+			}
+
+			idx.refIDs, err = dm.loadColumnIDs(refTable, fk.RefColumns)
+			if err != nil {
+				return fmt.Errorf("loadIndexes(): index %s: %w", idx.Name, err)
+			}
+		}
+
+		err = dm.openIndexTree(idx, 0, func(file btree.File) (btree.TreeService, error) {
+			tree, err := btree.NewTree(file, 0, btree.MAX_KEY_SIZE)
+			if err != nil {
+				return nil, fmt.Errorf("btree.NewTree: %w", err)
+			}
+			return tree, nil
+		})
+		if err != nil {
+			return fmt.Errorf("loadIndexes(): index %s: %w", idx.Name, err)
+		}
+		dm.indexes[idx.Name] = idx
+	}
+
+	if len(stale) == 0 {
+		return nil
+	}
+
+	// Описания ненужных индексов убираются из файла, а их деревья удаляются
+	err = dm.inTransaction(func() error {
+		dm.txMu.Lock()
+		for _, name := range stale {
+			dm.tx.dropped[indexFileName(name)] = true
+		}
+		dm.txMu.Unlock()
+
+		return dm.writeIndexes(0)
+	})
+	if err != nil {
+		return fmt.Errorf("loadIndexes(): %w", err)
+	}
+
+	return nil
+}
+
+// writeIndexes переписывает файл с описаниями индексов, вызывается внутри физической транзакции.
+// Формат: [размер описаний uint32][описания: имя, папка таблицы, флаги и количество колонок uint8, колонки,
+// у внешнего ключа - папка таблицы, количество колонок uint8, колонки и действия uint8 ON DELETE и ON UPDATE, xmin, xmax].
+// Имена колонок берутся из зафиксированных версий схем, xid - транзакция, которая сейчас фиксируется
+func (dm *diskManager) writeIndexes(xid uint64) error {
+	dm.indexMu.RLock()
+	indexes := make([]*index, 0, len(dm.indexes))
+	for _, idx := range dm.indexes {
+		indexes = append(indexes, idx)
+	}
+	dm.indexMu.RUnlock()
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+
+	for _, idx := range indexes {
+		dm.refreshColumnNames(idx, xid)
+	}
+
+	dm.indexMu.RLock()
+	size := 0
+	for _, idx := range indexes {
+		size += 2*binary_serializer.TEXT_TYPE_HEADER + len(idx.Name) + len(idx.Table) + data.COLUMN_COUNT_SIZE + 2*data.XID_SIZE
+		for _, column := range idx.Columns {
+			size += binary_serializer.TEXT_TYPE_HEADER + len(column)
+		}
+		if fk := idx.ForeignKey; fk != nil {
+			size += binary_serializer.TEXT_TYPE_HEADER + len(fk.RefTable) + data.COLUMN_COUNT_SIZE + 2
+			for _, column := range fk.RefColumns {
+				size += binary_serializer.TEXT_TYPE_HEADER + len(column)
+			}
+		}
+	}
+
+	content := make([]byte, 4+size)
+	binary_serializer.WriteUint32(content, 0, uint32(size))
+	offset := 4
+	for _, idx := range indexes {
+		offset += binary_serializer.WriteString(content, offset, idx.Name)
+		offset += binary_serializer.WriteString(content, offset, idx.Table)
+		binary_serializer.WriteUint8(content, offset, idx.flags()|uint8(len(idx.Columns)))
+		offset += data.COLUMN_COUNT_SIZE
+		for _, column := range idx.Columns {
+			offset += binary_serializer.WriteString(content, offset, column)
+		}
+		if fk := idx.ForeignKey; fk != nil {
+			offset += binary_serializer.WriteString(content, offset, fk.RefTable)
+			binary_serializer.WriteUint8(content, offset, uint8(len(fk.RefColumns)))
+			offset += data.COLUMN_COUNT_SIZE
+			for _, column := range fk.RefColumns {
+				offset += binary_serializer.WriteString(content, offset, column)
+			}
+			binary_serializer.WriteUint8(content, offset, fk.OnDelete)
+			binary_serializer.WriteUint8(content, offset+1, fk.OnUpdate)
+			offset += 2
+		}
+		binary_serializer.WriteUint64(content, offset, idx.xmin)
+		binary_serializer.WriteUint64(content, offset+data.XID_SIZE, idx.xmax)
+		offset += 2 * data.XID_SIZE
+	}
+	dm.indexMu.RUnlock()
+
+	_, err := dm.indexFile.WriteAt(content, 0)
+	if err != nil {
+		return fmt.Errorf("writeIndexes(): %w", err)
+	}
+
+	return nil
+}
+
+func deserializeIndexes(content []byte) ([]*index, error) {
+	if len(content) < 4 {
+		return nil, nil
+	}
+
+	size := int(binary_serializer.ReadUint32(content, 0))
+	if 4+size > len(content) {
+		return nil, fmt.Errorf("deserializeIndexes(): indexes file is truncated")
+	}
+
+	indexes := []*index{}
+	offset := 4
+	for offset < 4+size {
+		idx := &index{}
+
+		var n int
+		idx.Name, n = binary_serializer.ReadString(content, offset)
+		offset += n
+		idx.Table, n = binary_serializer.ReadString(content, offset)
+		offset += n
+		flags := binary_serializer.ReadUint8(content, offset)
+		offset += data.COLUMN_COUNT_SIZE
+		idx.Unique = flags&INDEX_UNIQUE_FLAG != 0
+		idx.Primary = flags&INDEX_PRIMARY_FLAG != 0
+		count := int(flags & INDEX_COLUMNS_MASK)
+		for i := 0; i < count; i++ {
+			var column string
+			column, n = binary_serializer.ReadString(content, offset)
+			offset += n
+			idx.Columns = append(idx.Columns, column)
+		}
+		if flags&INDEX_FOREIGN_KEY_FLAG != 0 {
+			fk := &ForeignKey{}
+			fk.RefTable, n = binary_serializer.ReadString(content, offset)
+			offset += n
+			refCount := int(binary_serializer.ReadUint8(content, offset))
+			offset += data.COLUMN_COUNT_SIZE
+			for i := 0; i < refCount; i++ {
+				var column string
+				column, n = binary_serializer.ReadString(content, offset)
+				offset += n
+				fk.RefColumns = append(fk.RefColumns, column)
+			}
+			fk.OnDelete = binary_serializer.ReadUint8(content, offset)
+			fk.OnUpdate = binary_serializer.ReadUint8(content, offset+1)
+			offset += 2
+			idx.ForeignKey = fk
+		}
+		idx.xmin = binary_serializer.ReadUint64(content, offset)
+		idx.xmax = binary_serializer.ReadUint64(content, offset+data.XID_SIZE)
+		offset += 2 * data.XID_SIZE
+
+		indexes = append(indexes, idx)
+	}
+
+	return indexes, nil
+}
+
+// flags возвращает флаги ограничения индекса для файла описаний
+func (idx *index) flags() uint8 {
+	var flags uint8
+	if idx.Unique {
+		flags |= INDEX_UNIQUE_FLAG
+	}
+	if idx.Primary {
+		flags |= INDEX_PRIMARY_FLAG
+	}
+	if idx.ForeignKey != nil {
+		flags |= INDEX_FOREIGN_KEY_FLAG
+	}
+
+	return flags
+}
+
+// refreshColumnNames обновляет имена колонок индекса по версиям схем перед записью в файл описаний
+func (dm *diskManager) refreshColumnNames(idx *index, xid uint64) {
+	rename := func(tableName string, ids []uint8, names []string) []string {
+		table, err := dm.openTable(tableName)
+		if err != nil {
+			return names
+		}
+
+		result := make([]string, len(ids))
+		for i, id := range ids {
+			result[i] = dm.columnName(table, id, xid)
+		}
+		return result
+	}
+
+	columns := rename(idx.Table, idx.ids, idx.Columns)
+	var refColumns []string
+	if fk := idx.ForeignKey; fk != nil {
+		refColumns = rename(fk.RefTable, idx.refIDs, fk.RefColumns)
+	}
+
+	dm.indexMu.Lock()
+	idx.Columns = columns
+	if fk := idx.ForeignKey; fk != nil {
+		updated := *fk
+		updated.RefColumns = refColumns
+		idx.ForeignKey = &updated
+	}
+	dm.indexMu.Unlock()
+}
+
+// tableIndexes возвращает индексы таблицы, которые нужно поддерживать при изменении строк
+func (dm *diskManager) tableIndexes(tableName string) []*index {
+	dm.indexMu.RLock()
+	defer dm.indexMu.RUnlock()
+
+	result := []*index{}
+	for _, idx := range dm.indexes {
+		if idx.Table == tableName {
+			result = append(result, idx)
+		}
+	}
+
+	return result
+}
+
+// indexRow добавляет новую версию строки с колонками версии схемы s в индексы таблицы внутри
+// физической транзакции, которая пишет саму версию. Уникальные индексы сначала проверяют, что ключ не занят
+func (tx *mvccTransaction) indexRow(table *tableFile, s *schema, result *data.InsertRowResult, row []data.DataCell) error {
+	address := rowAddress{pageID: result.PageID, slotID: result.SlotID}
+	for _, idx := range tx.dm.tableIndexes(table.name) {
+		if !idx.covers(s) {
+			continue
+		}
+
+		err := tx.checkUnique(table, idx, s, row, address)
+		if err != nil {
+			return err
+		}
+
+		err = idx.insert(s, row, address)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unindexRows убирает из индексов таблицы версии строк, которые удаляет сборщик мусора
+func (dm *diskManager) unindexRows(table *tableFile, rows []data.DataRow) error {
+	for _, idx := range dm.tableIndexes(table.name) {
+		for _, row := range rows {
+			cells, s, err := dm.currentRow(table, row)
+			if err != nil {
+				return err
+			}
+			if !idx.covers(s) {
+				continue
+			}
+
+			err = idx.remove(s, cells, rowAddress{pageID: row.PageId, slotID: row.SlotId})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeIndexes убирает индексы из памяти и файла описаний внутри физической транзакции.
+// Файлы их деревьев удаляются после фиксации
+func (dm *diskManager) removeIndexes(match func(idx *index) bool) error {
+	removed := []*index{}
+
+	dm.indexMu.Lock()
+	for name, idx := range dm.indexes {
+		if match(idx) {
+			removed = append(removed, idx)
+			delete(dm.indexes, name)
+		}
+	}
+	dm.indexMu.Unlock()
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	dm.txMu.Lock()
+	for _, idx := range removed {
+		dm.tx.dropped[indexFileName(idx.Name)] = true
+	}
+	dm.txMu.Unlock()
+
+	dm.tx.onAbort = append(dm.tx.onAbort, func() {
+		dm.indexMu.Lock()
+		for _, idx := range removed {
+			dm.indexes[idx.Name] = idx
+		}
+		dm.indexMu.Unlock()
+	})
+
+	return dm.writeIndexes(0)
+}
+
+// CreateIndex строит индекс по колонкам таблицы description.Table. Индекс сразу начинают поддерживать все транзакции,
+// а если транзакция откатится, он будет удален. Уникальный индекс проверяет версии строк, которые уже есть в таблице
+func (tx *mvccTransaction) CreateIndex(description Index) error {
+	if tx.finished {
+		return fmt.Errorf("CreateIndex(): transaction is already finished")
+	}
+
+	dm := tx.dm
+	err := dm.inTransaction(func() error {
+		indexName := description.Name
+		table, err := tx.writableTable(description.Table)
+		if err != nil {
+			return err
+		}
+		description.Table = table.name
+
+		// Внешний ключ хранит папку таблицы, на которую ссылается, и номера ее колонок
+		var refIDs []uint8
+		if fk := description.ForeignKey; fk != nil {
+			refTable, err := tx.visibleTable(fk.RefTable)
+			if err != nil {
+				return err
+			}
+			refIDs, err = tx.schema(refTable).columnIDs(fk.RefColumns)
+			if err != nil {
+				return err
+			}
+
+			stored := *fk
+			stored.RefTable = refTable.name
+			description.ForeignKey = &stored
+		}
+
+		dm.indexMu.RLock()
+		existing, ok := dm.indexes[indexName]
+		dm.indexMu.RUnlock()
+		if ok {
+			if existing.xmin != tx.snapshot.xid && dm.status(existing.xmin) == txInProgress {
+				return writeConflict("index %s is created by a concurrent transaction", indexName)
+			}
+			return fmt.Errorf("index already exists: %s", indexName)
+		}
+
+		idx, err := dm.newIndex(table, tx.schema(table), description, refIDs, tx.snapshot.xid)
+		if err != nil {
+			return err
+		}
+
+		if idx.Unique {
+			err = tx.validateUnique(table, idx)
+			if err != nil {
+				return err
+			}
+		}
+
+		dm.indexMu.Lock()
+		dm.indexes[indexName] = idx
+		dm.indexMu.Unlock()
+		dm.tx.onAbort = append(dm.tx.onAbort, func() {
+			dm.indexMu.Lock()
+			delete(dm.indexes, indexName)
+			dm.indexMu.Unlock()
+		})
+
+		return dm.writeIndexes(0)
+	})
+	if err != nil {
+		return fmt.Errorf("CreateIndex(): %w", err)
+	}
+
+	tx.createdIndexes = append(tx.createdIndexes, description.Name)
+
+	return nil
+}
+
+// DropIndex помечает индекс удаленным. Из памяти и файла описаний он убирается при фиксации
+func (tx *mvccTransaction) DropIndex(indexName string) error {
+	if tx.finished {
+		return fmt.Errorf("DropIndex(): transaction is already finished")
+	}
+
+	err := tx.dropIndex(indexName, false)
+	if err != nil {
+		return fmt.Errorf("DropIndex(): %w", err)
+	}
+
+	return nil
+}
+
+// DropForeignKey удаляет внешний ключ вместе с его индексом так же, как DropIndex удаляет индекс
+func (tx *mvccTransaction) DropForeignKey(indexName string) error {
+	if tx.finished {
+		return fmt.Errorf("DropForeignKey(): transaction is already finished")
+	}
+
+	err := tx.dropIndex(indexName, true)
+	if err != nil {
+		return fmt.Errorf("DropForeignKey(): %w", err)
+	}
+
+	return nil
+}
+
+// dropIndex помечает удаленным обычный индекс или, при foreignKey, индекс внешнего ключа
+func (tx *mvccTransaction) dropIndex(indexName string, foreignKey bool) error {
+	dm := tx.dm
+	err := dm.inTransaction(func() error {
+		dm.indexMu.RLock()
+		idx, ok := dm.indexes[indexName]
+		dm.indexMu.RUnlock()
+		if !ok {
+			return fmt.Errorf("%w: %s", errIndexDoesNotExist, indexName)
+		}
+		if foreignKey && idx.ForeignKey == nil {
+			return fmt.Errorf("index %s is not a foreign key", indexName)
+		}
+		if !foreignKey && (idx.Unique || idx.ForeignKey != nil) {
+			description, _ := tx.describe(idx)
+			return fmt.Errorf("index %s belongs to a constraint of table %s", indexName, description.Table)
+		}
+
+		dm.indexMu.RLock()
+		xmin, xmax := idx.xmin, idx.xmax
+		dm.indexMu.RUnlock()
+		if xmax == tx.snapshot.xid && xmax != 0 {
+			return fmt.Errorf("%w: %s", errIndexDoesNotExist, indexName)
+		}
+		if xmax != 0 && dm.status(xmax) != txAborted {
+			return writeConflict("index %s is dropped by a concurrent transaction", indexName)
+		}
+		if xmin != tx.snapshot.xid && dm.status(xmin) == txInProgress {
+			return writeConflict("index %s is created by a concurrent transaction", indexName)
+		}
+
+		err := tx.assignXid()
+		if err != nil {
+			return err
+		}
+
+		dm.indexMu.Lock()
+		idx.xmax = tx.snapshot.xid
+		dm.indexMu.Unlock()
+		dm.tx.onAbort = append(dm.tx.onAbort, func() {
+			dm.indexMu.Lock()
+			idx.xmax = xmax
+			dm.indexMu.Unlock()
+		})
+
+		return dm.writeIndexes(0)
+	})
+	if err != nil {
+		return err
+	}
+
+	tx.droppedIndexes = append(tx.droppedIndexes, indexName)
+
+	return nil
+}
+
+// GetTableIndexes возвращает индексы таблицы, которыми может пользоваться транзакция:
+// созданные зафиксированными транзакциями или ею самой и не удаленные ею
+func (tx *mvccTransaction) GetTableIndexes(tableName string) ([]Index, error) {
+	if tx.finished {
+		return nil, fmt.Errorf("GetTableIndexes(): transaction is already finished")
+	}
+
+	table, err := tx.visibleTable(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("GetTableIndexes(): %w", err)
+	}
+
+	result := []Index{}
+	for _, idx := range tx.dm.tableIndexes(table.name) {
+		if !tx.usable(idx) {
+			continue
+		}
+		if description, ok := tx.describe(idx); ok {
+			result = append(result, description)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result, nil
+}
+
+// GetReferences возвращает внешние ключи, которые ссылаются на таблицу tableName и видны транзакции,
+// в порядке имен таблиц и индексов
+func (tx *mvccTransaction) GetReferences(tableName string) ([]Index, error) {
+	if tx.finished {
+		return nil, fmt.Errorf("GetReferences(): transaction is already finished")
+	}
+
+	table, err := tx.visibleTable(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("GetReferences(): %w", err)
+	}
+
+	tx.dm.indexMu.RLock()
+	candidates := []*index{}
+	for _, idx := range tx.dm.indexes {
+		if idx.ForeignKey != nil && idx.ForeignKey.RefTable == table.name {
+			candidates = append(candidates, idx)
+		}
+	}
+	tx.dm.indexMu.RUnlock()
+
+	result := []Index{}
+	for _, idx := range candidates {
+		if !tx.usable(idx) {
+			continue
+		}
+		if description, ok := tx.describe(idx); ok {
+			result = append(result, description)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Table != result[j].Table {
+			return result[i].Table < result[j].Table
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// describe возвращает описание индекса так, как его видит транзакция: с именами таблиц и колонок
+// из ее версий схем. false - таблицы или колонки индекса в них нет
+func (tx *mvccTransaction) describe(idx *index) (Index, bool) {
+	dm := tx.dm
+
+	dm.indexMu.RLock()
+	description := idx.Index
+	dm.indexMu.RUnlock()
+
+	table, err := dm.openTable(idx.Table)
+	if err != nil {
+		return Index{}, false
+	}
+	s := tx.schema(table)
+	columns, ok := s.columnNames(idx.ids)
+	if !ok {
+		return Index{}, false
+	}
+	description.Table = s.name
+	description.Columns = columns
+
+	if fk := description.ForeignKey; fk != nil {
+		refTable, err := dm.openTable(fk.RefTable)
+		if err != nil {
+			return Index{}, false
+		}
+		refSchema := tx.schema(refTable)
+		refColumns, ok := refSchema.columnNames(idx.refIDs)
+		if !ok {
+			return Index{}, false
+		}
+
+		description.ForeignKey = &ForeignKey{
+			RefTable:   refSchema.name,
+			RefColumns: refColumns,
+			OnDelete:   fk.OnDelete,
+			OnUpdate:   fk.OnUpdate,
+		}
+	}
+
+	return description, true
+}
+
+// usable сообщает, может ли транзакция читать через индекс
+func (tx *mvccTransaction) usable(idx *index) bool {
+	tx.dm.indexMu.RLock()
+	xmin, xmax := idx.xmin, idx.xmax
+	tx.dm.indexMu.RUnlock()
+
+	if xmax != 0 && xmax == tx.snapshot.xid {
+		return false
+	}
+
+	return xmin == tx.snapshot.xid || tx.dm.status(xmin) == txCommitted
+}
+
+// IndexScan возвращает версии строк, видимые в транзакции, у которых значения колонок индекса
+// лежат между from и to, в порядке значений. nil вместо границы - диапазон с этой стороны не ограничен.
+// NULL идет после всех значений, а граница с NULL не пропускает ни одной строки
+func (tx *mvccTransaction) IndexScan(indexName string, from, to *IndexBound, reverse bool) (data.RowIterator, error) {
+	if tx.finished {
+		return nil, fmt.Errorf("IndexScan(): transaction is already finished")
+	}
+
+	tx.dm.indexMu.RLock()
+	idx, ok := tx.dm.indexes[indexName]
+	tx.dm.indexMu.RUnlock()
+	if !ok || !tx.usable(idx) {
+		return nil, fmt.Errorf("IndexScan(): %w: %s", errIndexDoesNotExist, indexName)
+	}
+
+	table, err := tx.dm.openTable(idx.Table)
+	if err != nil {
+		return nil, fmt.Errorf("IndexScan(): %w", err)
+	}
+	if !tx.visible(tx.dm.versions(table)) {
+		return nil, fmt.Errorf("IndexScan(): %w: %s", errTableDoesNotExist, idx.Table)
+	}
+
+	s := tx.schema(table)
+	start, end, empty, err := idx.keyRange(s, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("IndexScan(): %w", err)
+	}
+
+	it := &indexIterator{
+		tx:      tx,
+		table:   table,
+		schema:  s,
+		index:   idx,
+		start:   start,
+		end:     end,
+		reverse: reverse,
+		done:    empty,
+	}
+	// Первая порция читается сразу, чтобы ошибка индекса вернулась из IndexScan
+	if !empty {
+		err = it.fetch()
+		if err != nil {
+			return nil, fmt.Errorf("IndexScan(): %w", err)
+		}
+	}
+
+	return it, nil
+}
+
+// keyRange переводит границы IndexScan в диапазон ключей дерева start <= key < end.
+// Все ключи с префиксом включающей границы лежат в [префикс, PrefixEnd(префикс))
+func (idx *index) keyRange(s *schema, from, to *IndexBound) ([]byte, []byte, bool, error) {
+	if !idx.covers(s) {
+		return nil, nil, false, fmt.Errorf("index %s: column is missing in schema version %d", idx.Name, s.version)
+	}
+
+	prefix := func(bound *IndexBound) ([]byte, bool, error) {
+		if len(bound.Values) > len(idx.ids) {
+			return nil, false, fmt.Errorf("index %s has %d columns, got %d values", idx.Name, len(idx.ids), len(bound.Values))
+		}
+
+		for i, cell := range bound.Values {
+			if cell.IsNull {
+				return nil, true, nil
+			}
+			if column := s.columns[s.position(idx.ids[i])]; cell.Type != column.Type {
+				return nil, false, fmt.Errorf("column %s: unexpected value type %d", column.Name, cell.Type)
+			}
+		}
+
+		key, err := btree.EncodeKey(bound.Values)
+		return key, false, err
+	}
+
+	var start, end []byte
+	if from != nil && len(from.Values) > 0 {
+		key, null, err := prefix(from)
+		if err != nil || null {
+			return nil, nil, true, err
+		}
+
+		start = key
+		if !from.Inclusive {
+			start = btree.PrefixEnd(key)
+			if start == nil {
+				return nil, nil, true, nil
+			}
+		}
+	}
+	if to != nil && len(to.Values) > 0 {
+		key, null, err := prefix(to)
+		if err != nil || null {
+			return nil, nil, true, err
+		}
+
+		end = key
+		if to.Inclusive {
+			end = btree.PrefixEnd(key)
+		}
+	}
+
+	return start, end, end != nil && bytes.Compare(start, end) >= 0, nil
+}
+
+// indexIterator читает записи индекса порциями и версии строк по их адресам. Слот мог освободиться
+// и достаться другой строке, поэтому ключ каждой версии сверяется с ключом записи
+type indexIterator struct {
+	tx    *mvccTransaction
+	table *tableFile
+	// schema - версия схемы транзакции, в колонки которой переводятся строки
+	schema  *schema
+	index   *index
+	start   []byte
+	end     []byte
+	reverse bool
+	// entries - непрочитанная часть порции, last - последняя полученная запись индекса
+	entries []btree.Entry
+	last    *btree.Entry
+	done    bool
+	current data.DataRow
+	err     error
+}
+
+// fetch читает следующую порцию записей индекса. Неполная порция - последняя
+func (it *indexIterator) fetch() error {
+	entries, err := it.tx.dm.lookup(it.index, it.start, it.end, it.reverse, it.last, INDEX_BATCH_SIZE)
+	if err != nil {
+		return err
+	}
+
+	it.entries = entries
+	it.done = len(entries) < INDEX_BATCH_SIZE
+	if len(entries) > 0 {
+		it.last = &entries[len(entries)-1]
+	}
+
+	return nil
+}
+
+func (it *indexIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	for {
+		if len(it.entries) == 0 {
+			if it.done {
+				return false
+			}
+			if err := it.fetch(); err != nil {
+				it.err = fmt.Errorf("Next(): %w", err)
+				return false
+			}
+			continue
+		}
+
+		entry := it.entries[0]
+		it.entries = it.entries[1:]
+		address := indexAddress(entry.Value)
+
+		row, err := it.table.reader.ReadRow(address.pageID, address.slotID)
+		if errors.Is(err, data.ErrRowNotFound) {
+			continue
+		}
+		if err != nil {
+			it.err = fmt.Errorf("Next(): %w", err)
+			return false
+		}
+
+		cells, s, err := it.tx.dm.currentRow(it.table, row)
+		if err != nil {
+			it.err = fmt.Errorf("Next(): %w", err)
+			return false
+		}
+		key, err := it.index.key(s, cells)
+		if err != nil {
+			it.err = fmt.Errorf("Next(): %w", err)
+			return false
+		}
+		if !bytes.Equal(key, entry.Key) || !it.tx.visible(row.Xmin, row.Xmax) {
+			continue
+		}
+
+		from, _ := it.tx.dm.rowSchema(it.table, row.Version)
+		row.Row = it.schema.convert(from, row.Row)
+		row.Version = it.schema.version
+		it.current = row
+		return true
+	}
+}
+
+func (it *indexIterator) Row() data.DataRow {
+	return it.current
+}
+
+func (it *indexIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,556 @@
+package disk_manager
+
+import (
+	"custom-database/config"
+	"custom-database/internal/disk_manager/btree"
+	"custom-database/internal/disk_manager/data"
+	"custom-database/internal/disk_manager/wal"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexes(t *testing.T) {
+	columns := []data.Column{
+		{Name: "id", Type: data.TypeInt32, IsNullable: true},
+		{Name: "name", Type: data.TypeText, IsNullable: true},
+	}
+	row := func(id int32, name string) []data.DataCell {
+		return []data.DataCell{
+			{Value: id, Type: data.TypeInt32},
+			{Value: name, Type: data.TypeText},
+		}
+	}
+	bound := func(id int32, inclusive bool) *IndexBound {
+		return &IndexBound{Values: []data.DataCell{{Value: id, Type: data.TypeInt32}}, Inclusive: inclusive}
+	}
+	newTable := func(t *testing.T, cfg *config.Config, ids ...int32) (*diskManager, []*data.InsertRowResult) {
+		dm, err := newDiskManager(cfg, wal.OpenOSFile)
+		require.NoError(t, err)
+		require.NoError(t, dm.CreateTable("users", columns, nil))
+
+		inserted := []*data.InsertRowResult{}
+		for _, id := range ids {
+			result, err := dm.InsertRow("users", row(id, fmt.Sprintf("user-%d", id)))
+			require.NoError(t, err)
+			inserted = append(inserted, result)
+		}
+
+		return dm, inserted
+	}
+	createIndex := func(t *testing.T, dm *diskManager) {
+		tx := dm.Begin()
+		require.NoError(t, tx.CreateIndex(Index{Name: "users_id", Table: "users", Columns: []string{"id"}}))
+		require.NoError(t, tx.Commit())
+	}
+	indexScan := func(t *testing.T, tx Transaction, from, to *IndexBound) []int32 {
+		it, err := tx.IndexScan("users_id", from, to, false)
+		require.NoError(t, err)
+
+		ids := []int32{}
+		for it.Next() {
+			ids = append(ids, it.Row().Row[0].Value.(int32))
+		}
+		require.NoError(t, it.Err())
+
+		return ids
+	}
+
+	t.Run("поиск по индексу возвращает строки в порядке ключей", func(t *testing.T) {
+		dm, _ := newTable(t, &config.Config{DBPath: t.TempDir()}, 5, 3, 9, 1, 7)
+		createIndex(t, dm)
+
+		_, err := dm.InsertRow("users", row(4, "user-4"))
+		require.NoError(t, err)
+
+		tx := dm.Begin()
+		defer tx.Rollback()
+
+		require.Equal(t, []int32{1, 3, 4, 5, 7, 9}, indexScan(t, tx, nil, nil))
+		require.Equal(t, []int32{4}, indexScan(t, tx, bound(4, true), bound(4, true)))
+		require.Equal(t, []int32{4, 5, 7}, indexScan(t, tx, bound(3, false), bound(7, true)))
+		require.Equal(t, []int32{1, 3}, indexScan(t, tx, nil, bound(4, false)))
+		require.Empty(t, indexScan(t, tx, bound(6, true), bound(6, true)))
+
+		indexes, err := tx.GetTableIndexes("users")
+		require.NoError(t, err)
+		require.Equal(t, []Index{{Name: "users_id", Table: "users", Columns: []string{"id"}}}, indexes)
+	})
+
+	t.Run("обратный обход и чтение индекса порциями", func(t *testing.T) {
+		// Строк больше, чем INDEX_BATCH_SIZE, и у каждого ключа по две строки
+		ids := []int32{}
+		for i := int32(0); i < INDEX_BATCH_SIZE*2; i++ {
+			ids = append(ids, i%(INDEX_BATCH_SIZE+10))
+		}
+		dm, _ := newTable(t, &config.Config{DBPath: t.TempDir(), IndexFanout: 4}, ids...)
+		createIndex(t, dm)
+
+		tx := dm.Begin()
+		defer tx.Rollback()
+		scan := func(from, to *IndexBound, reverse bool) []int32 {
+			it, err := tx.IndexScan("users_id", from, to, reverse)
+			require.NoError(t, err)
+
+			result := []int32{}
+			for it.Next() {
+				result = append(result, it.Row().Row[0].Value.(int32))
+			}
+			require.NoError(t, it.Err())
+			return result
+		}
+
+		forward := scan(nil, nil, false)
+		require.Len(t, forward, len(ids))
+		reverse := scan(nil, nil, true)
+		require.Len(t, reverse, len(ids))
+		for i := range forward {
+			require.Equal(t, forward[i], reverse[len(reverse)-1-i])
+			if i > 0 {
+				require.LessOrEqual(t, forward[i-1], forward[i])
+			}
+		}
+
+		require.Equal(t, []int32{12, 12, 11, 11, 10, 10}, scan(bound(10, true), bound(12, true), true))
+		require.Equal(t, []int32{11, 11}, scan(bound(10, false), bound(12, false), true))
+		require.Equal(t, []int32{2, 2, 1, 1, 0, 0}, scan(nil, bound(3, false), true))
+		require.Empty(t, scan(bound(5, true), bound(4, true), true))
+
+		// Между порциями другие транзакции меняют дерево: обход продолжается с места остановки
+		it, err := tx.IndexScan("users_id", nil, nil, false)
+		require.NoError(t, err)
+		read := []int32{}
+		for len(read) < INDEX_BATCH_SIZE+1 && it.Next() {
+			read = append(read, it.Row().Row[0].Value.(int32))
+		}
+		for i := int32(0); i < 100; i++ {
+			_, err := dm.InsertRow("users", row(i%20, "other"))
+			require.NoError(t, err)
+		}
+		for it.Next() {
+			read = append(read, it.Row().Row[0].Value.(int32))
+		}
+		require.NoError(t, it.Err())
+		require.Equal(t, forward, read)
+	})
+
+	t.Run("индекс видит только версии строк из снимка", func(t *testing.T) {
+		dm, inserted := newTable(t, &config.Config{DBPath: t.TempDir()}, 1, 2, 3)
+		createIndex(t, dm)
+
+		reader := dm.Begin()
+		defer reader.Rollback()
+
+		writer := dm.Begin()
+		_, err := writer.UpdateRow("users", inserted[0].PageID, inserted[0].SlotID, row(10, "user-10"))
+		require.NoError(t, err)
+		require.NoError(t, writer.DeleteRow("users", inserted[1].PageID, inserted[1].SlotID))
+
+		require.Equal(t, []int32{3, 10}, indexScan(t, writer, nil, nil))
+		require.Equal(t, []int32{1, 2, 3}, indexScan(t, reader, nil, nil))
+		require.NoError(t, writer.Commit())
+
+		require.Equal(t, []int32{1, 2, 3}, indexScan(t, reader, nil, nil))
+
+		tx := dm.Begin()
+		defer tx.Rollback()
+		require.Equal(t, []int32{3, 10}, indexScan(t, tx, nil, nil))
+	})
+
+	t.Run("сборка мусора убирает версии из индекса", func(t *testing.T) {
+		dm, inserted := newTable(t, &config.Config{DBPath: t.TempDir()}, 1, 2)
+		createIndex(t, dm)
+
+		require.NoError(t, dm.DeleteRow("users", inserted[0].PageID, inserted[0].SlotID))
+		require.NoError(t, dm.Vacuum())
+
+		// Освободившийся слот занимает новая строка с другим ключом
+		_, err := dm.InsertRow("users", row(3, "user-3"))
+		require.NoError(t, err)
+
+		tx := dm.Begin()
+		defer tx.Rollback()
+		require.Equal(t, []int32{2, 3}, indexScan(t, tx, nil, nil))
+		require.Empty(t, indexScan(t, tx, bound(1, true), bound(1, true)))
+	})
+
+	t.Run("созданный в откатившейся транзакции индекс удаляется", func(t *testing.T) {
+		dm, _ := newTable(t, &config.Config{DBPath: t.TempDir()}, 1)
+
+		tx := dm.Begin()
+		require.NoError(t, tx.CreateIndex(Index{Name: "users_id", Table: "users", Columns: []string{"id"}}))
+		require.Equal(t, []int32{1}, indexScan(t, tx, nil, nil))
+
+		other := dm.Begin()
+		require.ErrorIs(t, other.CreateIndex(Index{Name: "users_id", Table: "users", Columns: []string{"id"}}), ErrWriteConflict)
+		indexes, err := other.GetTableIndexes("users")
+		require.NoError(t, err)
+		require.Empty(t, indexes)
+		require.NoError(t, other.Rollback())
+
+		require.NoError(t, tx.Rollback())
+
+		tx = dm.Begin()
+		defer tx.Rollback()
+		_, err = tx.IndexScan("users_id", nil, nil, false)
+		require.ErrorIs(t, err, errIndexDoesNotExist)
+		require.NoError(t, tx.CreateIndex(Index{Name: "users_id", Table: "users", Columns: []string{"id"}}))
+	})
+
+	t.Run("индекс нельзя создать по несуществующей или повторенной колонке", func(t *testing.T) {
+		dm, _ := newTable(t, &config.Config{DBPath: t.TempDir()})
+
+		tx := dm.Begin()
+		defer tx.Rollback()
+		require.Error(t, tx.CreateIndex(Index{Name: "users_age", Table: "users", Columns: []string{"age"}}))
+		require.Error(t, tx.CreateIndex(Index{Name: "users_id_id", Table: "users", Columns: []string{"id", "id"}}))
+		require.Error(t, tx.CreateIndex(Index{Name: "users_none", Table: "users"}))
+		require.Error(t, tx.CreateIndex(Index{Name: "missing_id", Table: "missing", Columns: []string{"id"}}))
+	})
+
+	t.Run("составной индекс, NULL и текстовые ключи", func(t *testing.T) {
+		dm, err := newDiskManager(&config.Config{DBPath: t.TempDir(), IndexFanout: 3}, wal.OpenOSFile)
+		require.NoError(t, err)
+		require.NoError(t, dm.CreateTable("events", []data.Column{
+			{Name: "tenant_id", Type: data.TypeInt32, IsNullable: true},
+			{Name: "created_at", Type: data.TypeTimestamp, IsNullable: true},
+			{Name: "title", Type: data.TypeText, IsNullable: true},
+		}, nil))
+
+		day := func(d int) data.DataCell {
+			return data.DataCell{Value: time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC), Type: data.TypeTimestamp}
+		}
+		tenant := func(id int32) data.DataCell {
+			return data.DataCell{Value: id, Type: data.TypeInt32}
+		}
+		title := func(text string) data.DataCell {
+			return data.DataCell{Value: text, Type: data.TypeText}
+		}
+		nullCell := func(columnType data.ColumnType) data.DataCell {
+			return data.DataCell{Type: columnType, IsNull: true}
+		}
+
+		rows := [][]data.DataCell{
+			{tenant(2), day(3), title("e")},
+			{tenant(1), day(5), title("b")},
+			{tenant(1), nullCell(data.TypeTimestamp), title("d")},
+			{tenant(1), day(1), title("a")},
+			{nullCell(data.TypeInt32), day(1), title("f")},
+			{tenant(1), day(9), title("c")},
+		}
+		for _, row := range rows {
+			_, err := dm.InsertRow("events", row)
+			require.NoError(t, err)
+		}
+
+		tx := dm.Begin()
+		require.NoError(t, tx.CreateIndex(Index{Name: "events_tenant_created", Table: "events", Columns: []string{"tenant_id", "created_at"}}))
+		require.NoError(t, tx.CreateIndex(Index{Name: "events_title", Table: "events", Columns: []string{"title"}}))
+		require.NoError(t, tx.Commit())
+
+		titles := func(t *testing.T, indexName string, from, to *IndexBound) string {
+			tx := dm.Begin()
+			defer tx.Rollback()
+
+			it, err := tx.IndexScan(indexName, from, to, false)
+			require.NoError(t, err)
+
+			result := ""
+			for it.Next() {
+				result += it.Row().Row[2].Value.(string)
+			}
+			require.NoError(t, it.Err())
+
+			return result
+		}
+		bound := func(inclusive bool, values ...data.DataCell) *IndexBound {
+			return &IndexBound{Values: values, Inclusive: inclusive}
+		}
+
+		// NULL идет после всех значений колонки
+		require.Equal(t, "abcdef", titles(t, "events_tenant_created", nil, nil))
+		// tenant_id = 1: граница из первой колонки включает все ее ключи
+		require.Equal(t, "abcd", titles(t, "events_tenant_created", bound(true, tenant(1)), bound(true, tenant(1))))
+		// tenant_id = 1 AND created_at > 1 AND created_at < 9
+		require.Equal(t, "b", titles(t, "events_tenant_created", bound(false, tenant(1), day(1)), bound(false, tenant(1), day(9))))
+		// tenant_id > 1
+		require.Equal(t, "ef", titles(t, "events_tenant_created", bound(false, tenant(1)), nil))
+		// Граница с NULL не пропускает строк
+		require.Empty(t, titles(t, "events_tenant_created", bound(true, nullCell(data.TypeInt32)), nil))
+
+		require.Equal(t, "bcd", titles(t, "events_title", bound(true, title("b")), bound(false, title("e"))))
+
+		tx = dm.Begin()
+		defer tx.Rollback()
+		_, err = tx.IndexScan("events_title", bound(true, tenant(1)), nil, false)
+		require.Error(t, err)
+		_, err = tx.IndexScan("events_tenant_created", bound(true, tenant(1), day(1), title("a")), nil, false)
+		require.Error(t, err)
+
+		// Строка, которая не помещается в ключ индекса, не вставляется
+		_, err = tx.InsertRow("events", []data.DataCell{tenant(3), day(1), title(strings.Repeat("x", btree.TEXT_KEY_SIZE))})
+		require.ErrorIs(t, err, btree.ErrKeyTooLong)
+		var keyErr *KeyTooLongError
+		require.ErrorAs(t, err, &keyErr)
+		require.Equal(t, "events_title", keyErr.Index)
+		require.Greater(t, keyErr.Size, keyErr.Max)
+		require.Equal(t, "abcdef", titles(t, "events_tenant_created", nil, nil))
+	})
+
+	t.Run("удаление индекса видно после фиксации и перезапуска", func(t *testing.T) {
+		cfg := &config.Config{DBPath: t.TempDir()}
+		dm, _ := newTable(t, cfg, 2, 1)
+		createIndex(t, dm)
+
+		// Дерево индекса читается из его файла
+		reopened, err := newDiskManager(cfg, wal.OpenOSFile)
+		require.NoError(t, err)
+
+		reader := reopened.Begin()
+		defer reader.Rollback()
+		require.Equal(t, []int32{1, 2}, indexScan(t, reader, nil, nil))
+
+		tx := reopened.Begin()
+		require.NoError(t, tx.DropIndex("users_id"))
+		require.Error(t, tx.DropIndex("users_id"))
+		_, err = tx.IndexScan("users_id", nil, nil, false)
+		require.ErrorIs(t, err, errIndexDoesNotExist)
+		require.NoError(t, tx.Rollback())
+
+		tx = reopened.Begin()
+		require.NoError(t, tx.DropIndex("users_id"))
+		require.NoError(t, tx.Commit())
+
+		reopened, err = newDiskManager(cfg, wal.OpenOSFile)
+		require.NoError(t, err)
+
+		tx = reopened.Begin()
+		defer tx.Rollback()
+		indexes, err := tx.GetTableIndexes("users")
+		require.NoError(t, err)
+		require.Empty(t, indexes)
+	})
+
+	t.Run("дерево индекса хранится на диске и переживает перезапуск", func(t *testing.T) {
+		cfg := &config.Config{DBPath: t.TempDir(), IndexFanout: 3}
+		ids := []int32{}
+		for i := int32(0); i < 300; i++ {
+			ids = append(ids, (i*37)%300)
+		}
+		dm, inserted := newTable(t, cfg, ids...)
+		createIndex(t, dm)
+
+		expected := []int32{}
+		for i, id := range ids {
+			if id%4 == 0 {
+				require.NoError(t, dm.DeleteRow("users", inserted[i].PageID, inserted[i].SlotID))
+			}
+		}
+		require.NoError(t, dm.Vacuum())
+		for id := int32(0); id < 300; id++ {
+			if id%4 != 0 {
+				expected = append(expected, id)
+			}
+		}
+
+		reopened, err := newDiskManager(cfg, wal.OpenOSFile)
+		require.NoError(t, err)
+
+		tx := reopened.Begin()
+		defer tx.Rollback()
+		require.Equal(t, expected, indexScan(t, tx, nil, nil))
+		require.Equal(t, []int32{101, 102, 103, 105}, indexScan(t, tx, bound(100, false), bound(105, true)))
+	})
+
+	t.Run("индекс по заполненной таблице строится снизу вверх", func(t *testing.T) {
+		ids := []int32{}
+		for i := int32(0); i < 500; i++ {
+			ids = append(ids, (i*37)%250)
+		}
+
+		for _, fillFactor := range []int{0, btree.MIN_FILL_FACTOR, btree.MAX_FILL_FACTOR} {
+			dm, _ := newTable(t, &config.Config{DBPath: t.TempDir(), IndexFanout: 5, IndexFillFactor: fillFactor}, ids...)
+			createIndex(t, dm)
+
+			tx := dm.Begin()
+			scanned := indexScan(t, tx, nil, nil)
+			require.Len(t, scanned, len(ids), "fill factor %d", fillFactor)
+			for i := range scanned {
+				require.Equal(t, int32(i/2), scanned[i])
+			}
+			require.NoError(t, tx.Rollback())
+
+			// После построения индекс меняется вместе с таблицей
+			_, err := dm.InsertRow("users", row(-1, "new"))
+			require.NoError(t, err)
+			tx = dm.Begin()
+			require.Equal(t, []int32{-1, 0, 0}, indexScan(t, tx, nil, bound(1, false)))
+			require.NoError(t, tx.Rollback())
+		}
+
+		dm, _ := newTable(t, &config.Config{DBPath: t.TempDir(), IndexFillFactor: btree.MIN_FILL_FACTOR - 1}, ids...)
+		tx := dm.Begin()
+		require.Error(t, tx.CreateIndex(Index{Name: "users_id", Table: "users", Columns: []string{"id"}}))
+		require.NoError(t, tx.Rollback())
+		tx = dm.Begin()
+		defer tx.Rollback()
+		indexes, err := tx.GetTableIndexes("users")
+		require.NoError(t, err)
+		require.Empty(t, indexes)
+	})
+
+	t.Run("уникальный индекс не пускает повтор ключа и переживает перезапуск", func(t *testing.T) {
+		cfg := &config.Config{DBPath: t.TempDir()}
+		dm, inserted := newTable(t, cfg, 1, 2)
+
+		tx := dm.Begin()
+		require.NoError(t, tx.CreateIndex(Index{Name: "users_pkey", Table: "users", Columns: []string{"id"}, Unique: true, Primary: true}))
+		require.NoError(t, tx.Commit())
+
+		var constraintErr *ConstraintError
+		_, err := dm.InsertRow("users", row(2, "user-2"))
+		require.ErrorAs(t, err, &constraintErr)
+		require.Equal(t, "users_pkey", constraintErr.Constraint)
+		require.Equal(t, `duplicate key value violates unique constraint "users_pkey": key (id)=(2) already exists`, constraintErr.Error())
+
+		_, err = dm.InsertRow("users", []data.DataCell{{Type: data.TypeInt32, IsNull: true}, {Value: "nobody", Type: data.TypeText}})
+		require.ErrorAs(t, err, &constraintErr)
+		require.Equal(t, `null value in column "id" violates primary key constraint "users_pkey"`, constraintErr.Error())
+
+		// Новая версия строки не конфликтует со старой, которую сама заменяет
+		tx = dm.Begin()
+		updated, err := tx.UpdateRow("users", inserted[0].PageID, inserted[0].SlotID, row(1, "first"))
+		require.NoError(t, err)
+		_, err = tx.UpdateRow("users", updated.PageID, updated.SlotID, row(2, "first"))
+		require.ErrorAs(t, err, &constraintErr)
+		require.NoError(t, tx.Rollback())
+
+		// Ключ удаленной в той же транзакции строки можно занять снова
+		tx = dm.Begin()
+		require.NoError(t, tx.DeleteRow("users", inserted[1].PageID, inserted[1].SlotID))
+		_, err = tx.InsertRow("users", row(2, "second"))
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		reopened, err := newDiskManager(cfg, wal.OpenOSFile)
+		require.NoError(t, err)
+
+		tx = reopened.Begin()
+		defer tx.Rollback()
+		indexes, err := tx.GetTableIndexes("users")
+		require.NoError(t, err)
+		require.Equal(t, []Index{{Name: "users_pkey", Table: "users", Columns: []string{"id"}, Unique: true, Primary: true}}, indexes)
+		_, err = tx.InsertRow("users", row(1, "user-1"))
+		require.ErrorAs(t, err, &constraintErr)
+		require.Error(t, tx.DropIndex("users_pkey"))
+	})
+
+	t.Run("вставка ключа, занятого незафиксированной транзакцией, конфликтует", func(t *testing.T) {
+		dm, _ := newTable(t, &config.Config{DBPath: t.TempDir()}, 1)
+
+		tx := dm.Begin()
+		require.NoError(t, tx.CreateIndex(Index{Name: "users_id_key", Table: "users", Columns: []string{"id"}, Unique: true}))
+		require.NoError(t, tx.Commit())
+
+		writer := dm.Begin()
+		_, err := writer.InsertRow("users", row(5, "user-5"))
+		require.NoError(t, err)
+
+		other := dm.Begin()
+		_, err = other.InsertRow("users", row(5, "other-5"))
+		require.ErrorIs(t, err, ErrWriteConflict)
+		require.NoError(t, other.Rollback())
+
+		require.NoError(t, writer.Rollback())
+
+		_, err = dm.InsertRow("users", row(5, "other-5"))
+		require.NoError(t, err)
+	})
+
+	t.Run("UNIQUE пропускает NULL и проверяет строки, которые уже есть в таблице", func(t *testing.T) {
+		dm, _ := newTable(t, &config.Config{DBPath: t.TempDir()}, 1, 2)
+		nameless := func(id int32) []data.DataCell {
+			return []data.DataCell{{Value: id, Type: data.TypeInt32}, {Type: data.TypeText, IsNull: true}}
+		}
+
+		tx := dm.Begin()
+		require.NoError(t, tx.CreateIndex(Index{Name: "users_name_key", Table: "users", Columns: []string{"name"}, Unique: true}))
+		require.NoError(t, tx.Commit())
+
+		_, err := dm.InsertRow("users", nameless(3))
+		require.NoError(t, err)
+		_, err = dm.InsertRow("users", nameless(3))
+		require.NoError(t, err)
+
+		var constraintErr *ConstraintError
+		tx = dm.Begin()
+		err = tx.CreateIndex(Index{Name: "users_id_key", Table: "users", Columns: []string{"id"}, Unique: true})
+		require.ErrorAs(t, err, &constraintErr)
+		require.Contains(t, err.Error(), "key (id)=(3) already exists")
+		require.NoError(t, tx.Rollback())
+
+		tx = dm.Begin()
+		defer tx.Rollback()
+		indexes, err := tx.GetTableIndexes("users")
+		require.NoError(t, err)
+		require.Len(t, indexes, 1)
+	})
+
+	t.Run("внешний ключ хранится с индексом и удаляется через DropForeignKey", func(t *testing.T) {
+		cfg := &config.Config{DBPath: t.TempDir()}
+		dm, _ := newTable(t, cfg, 1)
+		require.NoError(t, dm.CreateTable("posts", columns, nil))
+
+		fkey := Index{
+			Name:    "posts_id_fkey",
+			Table:   "posts",
+			Columns: []string{"id"},
+			ForeignKey: &ForeignKey{
+				RefTable:   "users",
+				RefColumns: []string{"id"},
+				OnDelete:   1,
+				OnUpdate:   2,
+			},
+		}
+		tx := dm.Begin()
+		require.NoError(t, tx.CreateIndex(Index{Name: "users_pkey", Table: "users", Columns: []string{"id"}, Unique: true, Primary: true}))
+		require.NoError(t, tx.CreateIndex(fkey))
+		require.NoError(t, tx.Commit())
+
+		reopened, err := newDiskManager(cfg, wal.OpenOSFile)
+		require.NoError(t, err)
+
+		tx = reopened.Begin()
+		references, err := tx.GetReferences("users")
+		require.NoError(t, err)
+		require.Equal(t, []Index{fkey}, references)
+		references, err = tx.GetReferences("posts")
+		require.NoError(t, err)
+		require.Empty(t, references)
+
+		require.Error(t, tx.DropIndex("posts_id_fkey"))
+		require.Error(t, tx.DropForeignKey("users_pkey"))
+		require.NoError(t, tx.DropForeignKey("posts_id_fkey"))
+		require.NoError(t, tx.Commit())
+
+		tx = reopened.Begin()
+		defer tx.Rollback()
+		references, err = tx.GetReferences("users")
+		require.NoError(t, err)
+		require.Empty(t, references)
+	})
+
+	t.Run("удаление таблицы удаляет ее индексы", func(t *testing.T) {
+		dm, _ := newTable(t, &config.Config{DBPath: t.TempDir()}, 1)
+		createIndex(t, dm)
+
+		require.NoError(t, dm.DropTable("users"))
+		require.NoError(t, dm.Vacuum())
+
+		require.NoError(t, dm.CreateTable("users", columns, nil))
+		createIndex(t, dm)
+	})
+}
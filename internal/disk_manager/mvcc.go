@@ -0,0 +1,765 @@
+package disk_manager
+
+import (
+	"custom-database/internal/disk_manager/data"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// TRANSACTIONS_FILE - служебный файл со статусами транзакций MVCC: по байту на номер транзакции.
+// Он лежит рядом с таблицами и меняется через журнал, поэтому имена таблиц не могут начинаться с "_"
+const TRANSACTIONS_FILE = "_transactions"
+
+// FROZEN_XID - номер транзакции у версий, которые видны всем
+const FROZEN_XID = 0
+
+// ErrWriteConflict - строку или таблицу уже изменила транзакция, изменения которой не видны в снимке.
+// Транзакция не ждет ее завершения: первый писатель побеждает, а второй должен начать заново
+var ErrWriteConflict = errors.New("could not serialize access due to concurrent update")
+
+// WriteConflictError - конфликт записи и его причина. Текст ошибки предназначен пользователю,
+// а errors.Is находит в ней ErrWriteConflict
+type WriteConflictError struct {
+	// Reason - что изменила другая транзакция или пусто, если строку
+	Reason string
+}
+
+func (e *WriteConflictError) Error() string {
+	if e.Reason == "" {
+		return ErrWriteConflict.Error()
+	}
+
+	return e.Reason + ": " + ErrWriteConflict.Error()
+}
+
+func (e *WriteConflictError) Unwrap() error {
+	return ErrWriteConflict
+}
+
+func writeConflict(format string, args ...interface{}) *WriteConflictError {
+	return &WriteConflictError{Reason: fmt.Sprintf(format, args...)}
+}
+
+type txStatus byte
+
+const (
+	// txAborted - транзакция не зафиксирована. В файле статусов так записаны и транзакции,
+	// прерванные падением процесса, и номера, которые не успели использовать
+	txAborted txStatus = iota
+	txCommitted
+	// txInProgress бывает только в памяти
+	txInProgress
+)
+
+// snapshot - снимок: изменения каких транзакций видны
+type snapshot struct {
+	xid    uint64          // собственная транзакция, 0 - она еще ничего не меняла
+	xmin   uint64          // все транзакции с меньшим номером завершились до снимка
+	xmax   uint64          // транзакции с номером от xmax начали менять данные после снимка
+	active map[uint64]bool // транзакции, которые не завершились к моменту снимка
+}
+
+type mvccTransaction struct {
+	dm       *diskManager
+	snapshot *snapshot
+	finished bool
+	created  []string
+	dropped  []string
+	// createdIndexes и droppedIndexes - индексы, созданные и удаленные транзакцией
+	createdIndexes []string
+	droppedIndexes []string
+	// altered - транзакция меняла схемы таблиц
+	altered bool
+	// inserted и deleted - таблица -> сколько версий транзакция создала и пометила удаленными.
+	// После фиксации мусором становятся удаленные версии, после отката - созданные
+	inserted map[string]int
+	deleted  map[string]int
+}
+
+// loadTransactions открывает файл статусов. Транзакции, которые не успели зафиксироваться
+// до перезапуска, записаны в нем как прерванные, а новые номера выдаются с конца файла:
+// номера из зарезервированного хвоста могли попасть в строки на диске
+func (dm *diskManager) loadTransactions() error {
+	err := os.MkdirAll(filepath.Join(dm.cfg.DBPath, TRANSACTIONS_FILE), 0755)
+	if err != nil {
+		return fmt.Errorf("loadTransactions(): os.MkdirAll: %w", err)
+	}
+
+	file, err := dm.openFile(dm.tableDataPath(TRANSACTIONS_FILE), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("loadTransactions(): os.OpenFile: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("loadTransactions(): file.Stat: %w", err)
+	}
+
+	statuses := make([]byte, info.Size())
+	if len(statuses) > 0 {
+		_, err = file.ReadAt(statuses, 0)
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("loadTransactions(): file.ReadAt: %w", err)
+		}
+	}
+
+	dm.files[TRANSACTIONS_FILE] = file
+	dm.statusFile = &loggedFile{dm: dm, table: TRANSACTIONS_FILE, file: file}
+
+	dm.statuses = make([]txStatus, len(statuses))
+	for xid, status := range statuses {
+		dm.statuses[xid] = txStatus(status)
+	}
+	dm.xidLimit = uint64(len(statuses))
+	dm.nextXid = max(FROZEN_XID+1, dm.xidLimit)
+
+	return nil
+}
+
+// Begin открывает транзакцию. Номер транзакции выдается при первом изменении,
+// поэтому только читающие транзакции не пишут в файл статусов
+func (dm *diskManager) Begin() Transaction {
+	dm.xactMu.Lock()
+	defer dm.xactMu.Unlock()
+
+	s := &snapshot{
+		xmin:   dm.nextXid,
+		xmax:   dm.nextXid,
+		active: make(map[uint64]bool, len(dm.running)),
+	}
+	for xid := range dm.running {
+		s.active[xid] = true
+		s.xmin = min(s.xmin, xid)
+	}
+
+	tx := &mvccTransaction{
+		dm:       dm,
+		snapshot: s,
+		inserted: map[string]int{},
+		deleted:  map[string]int{},
+	}
+	dm.active[tx] = true
+
+	return tx
+}
+
+func (tx *mvccTransaction) CreateTable(tableName string, columns []data.Column, checks []data.Check) error {
+	if tx.finished {
+		return fmt.Errorf("CreateTable(): transaction is already finished")
+	}
+	if isSystemTable(tableName) {
+		return fmt.Errorf("CreateTable(): table name cannot start with _: %s", tableName)
+	}
+
+	// Таблицу с тем же именем, которую уже никто не видит, удаляем до создания новой
+	err := tx.dm.removeDeadTable(tableName)
+	if err != nil {
+		return fmt.Errorf("CreateTable(): %w", err)
+	}
+
+	dm := tx.dm
+	var physical string
+	err = dm.inTransaction(func() error {
+		err := tx.checkNameFree(tableName)
+		if err != nil {
+			return err
+		}
+
+		err = tx.assignXid()
+		if err != nil {
+			return err
+		}
+
+		physical = dm.tableDirectory(tableName)
+		filePath := filepath.Join(dm.cfg.DBPath, physical)
+		if err := os.MkdirAll(filePath, 0755); err != nil {
+			return fmt.Errorf("os.MkdirAll: %w", err)
+		}
+		// Если физическая транзакция не будет зафиксирована, пустая папка таблицы не нужна
+		dm.tx.onAbort = append(dm.tx.onAbort, func() {
+			dm.closeTableFile(physical)
+			os.RemoveAll(filePath)
+		})
+
+		err = dm.createTableFile(physical, columns, checks, tx.snapshot.xid)
+		if err != nil || physical == tableName {
+			return err
+		}
+
+		// Имя таблицы в папке с другим именем хранит версия схемы 0. Файл таблицы до фиксации
+		// физической транзакции еще не прочитать, поэтому версия строится из тех же колонок
+		base := baseSchema(physical, &data.MetaData{Columns: columns, Checks: checks, Xmin: tx.snapshot.xid})
+		named := *base
+		named.name = tableName
+
+		return dm.addSchema(base, &named)
+	})
+	if err != nil {
+		return fmt.Errorf("CreateTable(): %w", err)
+	}
+
+	tx.created = append(tx.created, physical)
+
+	return nil
+}
+
+// DropTable помечает таблицу удаленной. Файлы таблицы удаляются после фиксации,
+// когда ее уже не видит ни один снимок
+func (tx *mvccTransaction) DropTable(tableName string) error {
+	if tx.finished {
+		return fmt.Errorf("DropTable(): transaction is already finished")
+	}
+
+	dm := tx.dm
+	var physical string
+	err := dm.inTransaction(func() error {
+		table, err := tx.visibleTable(tableName)
+		if err != nil {
+			return err
+		}
+
+		_, xmax := dm.versions(table)
+		if xmax != 0 && dm.status(xmax) != txAborted {
+			return writeConflict("table %s is dropped by a concurrent transaction", tableName)
+		}
+		if dm.hasConcurrentWriters(table.name, tx.snapshot.xid) || tx.schema(table) != dm.latestSchema(table) {
+			return writeConflict("table %s is changed by a concurrent transaction", tableName)
+		}
+
+		err = tx.assignXid()
+		if err != nil {
+			return err
+		}
+
+		err = table.data.SetTableXmax(tx.snapshot.xid)
+		if err != nil {
+			return fmt.Errorf("data.SetTableXmax: %w", err)
+		}
+
+		dm.mu.Lock()
+		table.xmax = tx.snapshot.xid
+		dm.mu.Unlock()
+
+		physical = table.name
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("DropTable(): %w", err)
+	}
+
+	tx.dropped = append(tx.dropped, physical)
+
+	return nil
+}
+
+func (tx *mvccTransaction) GetTableColumns(tableName string) ([]data.Column, error) {
+	if tx.finished {
+		return nil, fmt.Errorf("GetTableColumns(): transaction is already finished")
+	}
+
+	table, err := tx.visibleTable(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("GetTableColumns(): %w", err)
+	}
+
+	return tx.schema(table).columns, nil
+}
+
+func (tx *mvccTransaction) GetTableChecks(tableName string) ([]data.Check, error) {
+	if tx.finished {
+		return nil, fmt.Errorf("GetTableChecks(): transaction is already finished")
+	}
+
+	table, err := tx.visibleTable(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("GetTableChecks(): %w", err)
+	}
+
+	return tx.schema(table).checks, nil
+}
+
+func (tx *mvccTransaction) InsertRow(tableName string, row []data.DataCell) (*data.InsertRowResult, error) {
+	if tx.finished {
+		return nil, fmt.Errorf("InsertRow(): transaction is already finished")
+	}
+
+	var result *data.InsertRowResult
+	err := tx.dm.inTransaction(func() error {
+		table, err := tx.writableTable(tableName)
+		if err != nil {
+			return err
+		}
+
+		s := tx.schema(table)
+		err = checkNotNull(s, row)
+		if err != nil {
+			return err
+		}
+
+		result, err = table.data.InsertDataRow(tx.snapshot.xid, s.version, row)
+		if err != nil {
+			return fmt.Errorf("data.InsertDataRow: %w", err)
+		}
+
+		err = tx.indexRow(table, s, result, row)
+		if err != nil {
+			return err
+		}
+		tx.inserted[table.name]++
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("InsertRow(): %w", err)
+	}
+
+	return result, nil
+}
+
+// DeleteRow помечает версию строки (pageID, slotID) удаленной транзакцией.
+// Другие транзакции продолжают видеть ее, пока транзакция не зафиксирована
+func (tx *mvccTransaction) DeleteRow(tableName string, pageID uint32, slotID uint16) error {
+	if tx.finished {
+		return fmt.Errorf("DeleteRow(): transaction is already finished")
+	}
+
+	err := tx.dm.inTransaction(func() error {
+		table, err := tx.writableTable(tableName)
+		if err != nil {
+			return err
+		}
+
+		err = tx.checkVersion(table, pageID, slotID)
+		if err != nil {
+			return err
+		}
+
+		err = table.data.SetRowXmax(pageID, slotID, tx.snapshot.xid)
+		if err != nil {
+			return fmt.Errorf("data.SetRowXmax: %w", err)
+		}
+		tx.deleted[table.name]++
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteRow(): %w", err)
+	}
+
+	return nil
+}
+
+// UpdateRow создает новую версию строки (pageID, slotID), старая помечается удаленной
+func (tx *mvccTransaction) UpdateRow(tableName string, pageID uint32, slotID uint16, row []data.DataCell) (*data.InsertRowResult, error) {
+	if tx.finished {
+		return nil, fmt.Errorf("UpdateRow(): transaction is already finished")
+	}
+
+	var result *data.InsertRowResult
+	err := tx.dm.inTransaction(func() error {
+		table, err := tx.writableTable(tableName)
+		if err != nil {
+			return err
+		}
+
+		err = tx.checkVersion(table, pageID, slotID)
+		if err != nil {
+			return err
+		}
+
+		result, err = tx.writeVersion(table, tx.schema(table), pageID, slotID, row)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("UpdateRow(): %w", err)
+	}
+
+	return result, nil
+}
+
+// writeVersion заменяет версию строки (pageID, slotID) новой с колонками версии схемы s
+// внутри физической транзакции
+func (tx *mvccTransaction) writeVersion(table *tableFile, s *schema, pageID uint32, slotID uint16, row []data.DataCell) (*data.InsertRowResult, error) {
+	err := checkNotNull(s, row)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := table.data.UpdateDataRow(tx.snapshot.xid, s.version, pageID, slotID, row)
+	if err != nil {
+		return nil, fmt.Errorf("data.UpdateDataRow: %w", err)
+	}
+
+	err = tx.indexRow(table, s, result, row)
+	if err != nil {
+		return nil, err
+	}
+	tx.inserted[table.name]++
+	tx.deleted[table.name]++
+
+	return result, nil
+}
+
+// ScanRows возвращает итератор по версиям строк, видимым в транзакции. Страницы читаются лениво
+// и без блокировок: параллельные изменения не видны снимку, а писатели не ждут читателей
+func (tx *mvccTransaction) ScanRows(tableName string) (data.RowIterator, error) {
+	if tx.finished {
+		return nil, fmt.Errorf("ScanRows(): transaction is already finished")
+	}
+
+	table, err := tx.visibleTable(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("ScanRows(): %w", err)
+	}
+
+	it, err := table.reader.Scan()
+	if err != nil {
+		return nil, fmt.Errorf("ScanRows(): data.Scan: %w", err)
+	}
+
+	return &visibleRowIterator{tx: tx, it: it, table: table, schema: tx.schema(table)}, nil
+}
+
+// Commit записывает статус транзакции в файл статусов через журнал: с этого момента ее изменения
+// видны новым снимкам. Если записать статус не удалось, транзакция откатывается
+func (tx *mvccTransaction) Commit() error {
+	if tx.finished {
+		return fmt.Errorf("Commit(): transaction is already finished")
+	}
+
+	if xid := tx.snapshot.xid; xid != 0 {
+		err := tx.dm.inTransaction(func() error {
+			_, err := tx.dm.statusFile.WriteAt([]byte{byte(txCommitted)}, int64(xid))
+			if err != nil {
+				return err
+			}
+
+			// Удаленные транзакцией индексы убираются в той же физической транзакции, что и статус
+			err = tx.dm.removeIndexes(func(idx *index) bool { return idx.xmax == xid })
+			if err != nil || !tx.altered {
+				return err
+			}
+
+			// Файл описаний индексов хранит имена колонок из зафиксированных схем
+			return tx.dm.writeIndexes(xid)
+		})
+		if err != nil {
+			rollbackErr := tx.Rollback()
+			if rollbackErr != nil {
+				return fmt.Errorf("Commit(): %w; %w", err, rollbackErr)
+			}
+			return fmt.Errorf("Commit(): %w", err)
+		}
+	}
+
+	tx.finish(txCommitted)
+	tx.dm.collectGarbage()
+
+	return nil
+}
+
+// Rollback отменяет транзакцию. Статус в файл не пишется: незафиксированная транзакция
+// и так считается прерванной, а ее версии строк убирает сборщик мусора.
+// Созданные в транзакции таблицы никто больше не видит, поэтому их файлы удаляются сразу
+func (tx *mvccTransaction) Rollback() error {
+	if tx.finished {
+		return fmt.Errorf("Rollback(): transaction is already finished")
+	}
+
+	tx.finish(txAborted)
+
+	var errs []error
+	if xid := tx.snapshot.xid; xid != 0 && (len(tx.createdIndexes) > 0 || len(tx.droppedIndexes) > 0) {
+		err := tx.dm.inTransaction(func() error {
+			tx.dm.indexMu.Lock()
+			for _, idx := range tx.dm.indexes {
+				if idx.xmax == xid {
+					idx.xmax = 0
+				}
+			}
+			tx.dm.indexMu.Unlock()
+
+			return tx.dm.removeIndexes(func(idx *index) bool { return idx.xmin == xid })
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, table := range tx.created {
+		err := tx.dm.removeTable(table)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	tx.dm.collectGarbage()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Rollback(): %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// finish завершает транзакцию в памяти и передает сборщику мусора версии и таблицы,
+// которые она сделала ненужными
+func (tx *mvccTransaction) finish(status txStatus) {
+	tx.finished = true
+	dm := tx.dm
+
+	dm.xactMu.Lock()
+	defer dm.xactMu.Unlock()
+
+	delete(dm.active, tx)
+
+	xid := tx.snapshot.xid
+	if xid == 0 {
+		return
+	}
+
+	dm.setStatus(xid, status)
+	delete(dm.running, xid)
+	for table, writers := range dm.writers {
+		delete(writers, xid)
+		if len(writers) == 0 {
+			delete(dm.writers, table)
+		}
+	}
+
+	garbage := tx.deleted
+	if status == txAborted {
+		garbage = tx.inserted
+	}
+	for table, versions := range garbage {
+		dm.deadVersions[table] += versions
+	}
+
+	if status == txCommitted {
+		for _, table := range tx.dropped {
+			dm.droppedTables[table] = xid
+		}
+	}
+}
+
+// assignXid выдает транзакции номер при первом изменении и резервирует его в файле статусов.
+// Вызывается внутри физической транзакции
+func (tx *mvccTransaction) assignXid() error {
+	dm := tx.dm
+
+	if tx.snapshot.xid == 0 {
+		dm.xactMu.Lock()
+		xid := dm.nextXid
+		dm.nextXid++
+		dm.setStatus(xid, txInProgress)
+		dm.running[xid] = true
+		dm.xactMu.Unlock()
+
+		tx.snapshot.xid = xid
+	}
+
+	err := dm.reserveXid(tx.snapshot.xid)
+	if err != nil {
+		return fmt.Errorf("assignXid(): %w", err)
+	}
+
+	return nil
+}
+
+// reserveXid дописывает в файл статусов блок номеров, если xid в него еще не входит. Блок попадает
+// на диск в той же физической транзакции, что и первые строки с этим номером, поэтому после
+// перезапуска номер, которым помечены строки на диске, не достанется другой транзакции
+func (dm *diskManager) reserveXid(xid uint64) error {
+	for xid >= dm.xidLimit {
+		limit := dm.xidLimit
+
+		_, err := dm.statusFile.WriteAt(make([]byte, BLOCK_SIZE), int64(limit))
+		if err != nil {
+			return fmt.Errorf("reserveXid(): %w", err)
+		}
+
+		dm.xidLimit = limit + BLOCK_SIZE
+		dm.tx.onAbort = append(dm.tx.onAbort, func() { dm.xidLimit = limit })
+	}
+
+	return nil
+}
+
+// setStatus меняет статус транзакции, xactMu должен быть захвачен
+func (dm *diskManager) setStatus(xid uint64, status txStatus) {
+	for uint64(len(dm.statuses)) <= xid {
+		dm.statuses = append(dm.statuses, txAborted)
+	}
+	dm.statuses[xid] = status
+}
+
+func (dm *diskManager) status(xid uint64) txStatus {
+	if xid == FROZEN_XID {
+		return txCommitted
+	}
+
+	dm.xactMu.RLock()
+	defer dm.xactMu.RUnlock()
+
+	if xid >= uint64(len(dm.statuses)) {
+		return txAborted
+	}
+
+	return dm.statuses[xid]
+}
+
+// sees сообщает, видны ли в транзакции изменения транзакции xid
+func (tx *mvccTransaction) sees(xid uint64) bool {
+	s := tx.snapshot
+	if xid == FROZEN_XID || xid == s.xid {
+		return true
+	}
+	if xid >= s.xmax || s.active[xid] {
+		return false
+	}
+
+	return tx.dm.status(xid) == txCommitted
+}
+
+// visible сообщает, видна ли в транзакции версия строки или таблицы,
+// созданная транзакцией xmin и удаленная транзакцией xmax
+func (tx *mvccTransaction) visible(xmin, xmax uint64) bool {
+	return tx.sees(xmin) && (xmax == 0 || !tx.sees(xmax))
+}
+
+// visibleTable возвращает таблицу, которую транзакция видит под именем tableName
+func (tx *mvccTransaction) visibleTable(tableName string) (*tableFile, error) {
+	for _, physical := range tx.dm.tableCandidates(tableName) {
+		table, err := tx.dm.openTable(physical)
+		if errors.Is(err, errTableDoesNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if tx.visible(tx.dm.versions(table)) && tx.schema(table).name == tableName {
+			return table, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", errTableDoesNotExist, tableName)
+}
+
+// writableTable возвращает таблицу для изменения строк и записывает транзакцию в ее писатели,
+// чтобы параллельный DROP TABLE не удалил таблицу с незафиксированными строками.
+// Вызывается внутри физической транзакции
+func (tx *mvccTransaction) writableTable(tableName string) (*tableFile, error) {
+	dm := tx.dm
+
+	table, err := tx.visibleTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	_, xmax := dm.versions(table)
+	if xmax != 0 && dm.status(xmax) != txAborted {
+		return nil, writeConflict("table %s is dropped by a concurrent transaction", tableName)
+	}
+	if tx.schema(table) != dm.latestSchema(table) {
+		return nil, writeConflict("table %s is altered by a concurrent transaction", tableName)
+	}
+
+	err = tx.assignXid()
+	if err != nil {
+		return nil, err
+	}
+
+	dm.xactMu.Lock()
+	if dm.writers[table.name] == nil {
+		dm.writers[table.name] = map[uint64]bool{}
+	}
+	dm.writers[table.name][tx.snapshot.xid] = true
+	dm.xactMu.Unlock()
+
+	return table, nil
+}
+
+// hasConcurrentWriters сообщает, меняет ли строки таблицы другая незавершенная транзакция
+func (dm *diskManager) hasConcurrentWriters(tableName string, xid uint64) bool {
+	dm.xactMu.RLock()
+	defer dm.xactMu.RUnlock()
+
+	for writer := range dm.writers[tableName] {
+		if writer != xid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkVersion проверяет, что версию строки можно удалить или заменить: ее не удалила другая
+// транзакция, которая не прервана, и она видна в снимке
+func (tx *mvccTransaction) checkVersion(table *tableFile, pageID uint32, slotID uint16) error {
+	header, err := table.data.ParseRowHeader(pageID, slotID)
+	if err != nil {
+		return fmt.Errorf("data.ParseRowHeader: %w", err)
+	}
+
+	if header.Xmax != 0 && header.Xmax != tx.snapshot.xid && tx.dm.status(header.Xmax) != txAborted {
+		return &WriteConflictError{}
+	}
+	if !tx.visible(header.Xmin, header.Xmax) {
+		return fmt.Errorf("row %d:%d is not visible in the transaction", pageID, slotID)
+	}
+
+	return nil
+}
+
+// visibleRowIterator пропускает версии строк, которые не видны в транзакции,
+// и переводит видимые в колонки версии схемы транзакции
+type visibleRowIterator struct {
+	tx      *mvccTransaction
+	it      data.RowIterator
+	table   *tableFile
+	schema  *schema
+	current data.DataRow
+	err     error
+}
+
+func (it *visibleRowIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	for it.it.Next() {
+		row := it.it.Row()
+		if !it.tx.visible(row.Xmin, row.Xmax) {
+			continue
+		}
+
+		from, ok := it.tx.dm.rowSchema(it.table, row.Version)
+		if !ok {
+			it.err = fmt.Errorf("Next(): row %d:%d: unknown schema version %d", row.PageId, row.SlotId, row.Version)
+			return false
+		}
+		row.Row = it.schema.convert(from, row.Row)
+		row.Version = it.schema.version
+		it.current = row
+
+		return true
+	}
+
+	return false
+}
+
+func (it *visibleRowIterator) Row() data.DataRow {
+	return it.current
+}
+
+func (it *visibleRowIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+
+	return it.it.Err()
+}
@@ -0,0 +1,120 @@
+package disk_manager
+
+import (
+	"custom-database/internal/disk_manager/wal"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// recover повторяет из журнала изменения и удаления таблиц зафиксированных транзакций,
+// сбрасывает файлы таблиц на диск и очищает журнал.
+// Изменения без записи о фиксации пропускаются: до фиксации они не попадают в файлы таблиц
+func (dm *diskManager) recover() error {
+	records, err := dm.log.ReadAll()
+	if err != nil {
+		return fmt.Errorf("recover(): %w", err)
+	}
+
+	committed := map[uint64]bool{}
+	for _, record := range records {
+		if record.Type == wal.CommitRecord {
+			committed[record.TxID] = true
+		}
+	}
+
+	files := map[string]wal.File{}
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+
+	for _, record := range records {
+		if !committed[record.TxID] {
+			continue
+		}
+
+		if record.Type == wal.DropTableRecord {
+			if file, ok := files[record.Table]; ok {
+				file.Close()
+				delete(files, record.Table)
+			}
+
+			err := os.RemoveAll(filepath.Join(dm.cfg.DBPath, record.Table))
+			if err != nil {
+				return fmt.Errorf("recover(): drop table %s: %w", record.Table, err)
+			}
+			continue
+		}
+
+		if record.Type != wal.PageRecord {
+			continue
+		}
+
+		file, ok := files[record.Table]
+		if !ok {
+			err := os.MkdirAll(filepath.Join(dm.cfg.DBPath, record.Table), 0755)
+			if err != nil {
+				return fmt.Errorf("recover(): os.MkdirAll: %w", err)
+			}
+
+			file, err = dm.openFile(dm.tableDataPath(record.Table), os.O_RDWR|os.O_CREATE, 0644)
+			if err != nil {
+				return fmt.Errorf("recover(): open table %s: %w", record.Table, err)
+			}
+			files[record.Table] = file
+		}
+
+		_, err := file.WriteAt(record.Data, record.Offset)
+		if err != nil {
+			return fmt.Errorf("recover(): redo table %s: %w", record.Table, err)
+		}
+	}
+
+	for table, file := range files {
+		err := file.Sync()
+		if err != nil {
+			return fmt.Errorf("recover(): sync table %s: %w", table, err)
+		}
+	}
+
+	err = dm.log.Reset()
+	if err != nil {
+		return fmt.Errorf("recover(): %w", err)
+	}
+
+	err = dm.removeUncommittedTables()
+	if err != nil {
+		return fmt.Errorf("recover(): %w", err)
+	}
+
+	return nil
+}
+
+// removeUncommittedTables удаляет таблицы, создание которых не было зафиксировано:
+// их файл создан, но метаданные так и не попали в него
+func (dm *diskManager) removeUncommittedTables() error {
+	entries, err := os.ReadDir(dm.cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("os.ReadDir: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		info, err := os.Stat(dm.tableDataPath(entry.Name()))
+		if err != nil || info.Size() > 0 {
+			continue
+		}
+
+		err = os.RemoveAll(filepath.Join(dm.cfg.DBPath, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("os.RemoveAll: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,786 @@
+package disk_manager
+
+import (
+	"custom-database/internal/disk_manager/binary_serializer"
+	"custom-database/internal/disk_manager/data"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// SCHEMAS_FILE - служебный файл с версиями схем таблиц. Метаданные в файле таблицы пишутся один раз
+// и описывают версию 0, а ALTER TABLE добавляет в этот файл следующие версии
+const SCHEMAS_FILE = "_schemas"
+
+// MAX_SCHEMA_VERSION - номер версии схемы хранится в слоте строки одним байтом
+const MAX_SCHEMA_VERSION = math.MaxUint8
+
+// schema - версия схемы таблицы. Строки не переписываются при смене схемы: каждая хранит номер версии,
+// с колонками которой записана, а при чтении ее колонки сопоставляются с колонками нужной версии по ids.
+// Версии видны транзакциям так же, как версии строк: транзакция читает и пишет по последней версии,
+// созданной видимой ей транзакцией
+type schema struct {
+	table   string // папка таблицы, не меняется при переименовании
+	version uint8
+	name    string // имя таблицы в этой версии
+	columns []data.Column
+	// ids - постоянные номера колонок. Удаленная колонка уносит свой номер, а колонка,
+	// у которой сменился тип, получает новый: строки со старым типом под ним не прочитать
+	ids []uint8
+	// missing - значения колонок в строках версий, записанных до их добавления
+	missing []data.DataCell
+	checks  []data.Check
+	xmin    uint64
+}
+
+// Alteration - новая схема таблицы для AlterTable
+type Alteration struct {
+	// Name - имя таблицы в новой схеме
+	Name    string
+	Columns []AlteredColumn
+	Checks  []data.Check
+}
+
+// AlteredColumn - колонка новой схемы. Source - номер колонки в текущей схеме, -1 - колонка добавляется,
+// и в уже записанных строках она читается как Missing. Если Convert не nil, колонка получает новый номер,
+// а видимые транзакции строки переписываются со значением Convert от старого
+type AlteredColumn struct {
+	data.Column
+	Source  int
+	Missing data.DataCell
+	Convert func(cell data.DataCell) (data.DataCell, error)
+}
+
+// baseSchema - версия 0 из метаданных файла таблицы
+func baseSchema(tableName string, meta *data.MetaData) *schema {
+	s := &schema{
+		table:   tableName,
+		name:    tableName,
+		columns: meta.Columns,
+		ids:     make([]uint8, len(meta.Columns)),
+		missing: make([]data.DataCell, len(meta.Columns)),
+		checks:  meta.Checks,
+		xmin:    meta.Xmin,
+	}
+	for i, column := range meta.Columns {
+		s.ids[i] = uint8(i)
+		s.missing[i] = data.DataCell{Type: column.Type, IsNull: true}
+	}
+
+	return s
+}
+
+// position возвращает место колонки с номером id в строке этой версии или -1
+func (s *schema) position(id uint8) int {
+	for i, columnID := range s.ids {
+		if columnID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// convert переводит строку, записанную с колонками версии from, в колонки версии s
+func (s *schema) convert(from *schema, row []data.DataCell) []data.DataCell {
+	if from == s {
+		return row
+	}
+
+	result := make([]data.DataCell, len(s.columns))
+	for i, id := range s.ids {
+		if j := from.position(id); j != -1 {
+			result[i] = row[j]
+		} else {
+			result[i] = s.missing[i]
+		}
+	}
+
+	return result
+}
+
+// columnIDs находит номера колонок по именам
+func (s *schema) columnIDs(names []string) ([]uint8, error) {
+	ids := make([]uint8, len(names))
+	for i, name := range names {
+		position := -1
+		for j, column := range s.columns {
+			if column.Name == name {
+				position = j
+			}
+		}
+		if position == -1 {
+			return nil, fmt.Errorf("column %s does not exist in table %s", name, s.name)
+		}
+		ids[i] = s.ids[position]
+	}
+
+	return ids, nil
+}
+
+// columnNames возвращает имена колонок с номерами ids, false - какой-то колонки в версии нет
+func (s *schema) columnNames(ids []uint8) ([]string, bool) {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		position := s.position(id)
+		if position == -1 {
+			return nil, false
+		}
+		names[i] = s.columns[position].Name
+	}
+
+	return names, true
+}
+
+// tableSchemas возвращает версии схемы таблицы по порядку номеров. Пока таблицу не меняли,
+// в файле версий ее нет, и у нее одна версия 0 из метаданных
+func (dm *diskManager) tableSchemas(table *tableFile) []*schema {
+	dm.schemaMu.RLock()
+	defer dm.schemaMu.RUnlock()
+
+	if versions, ok := dm.schemas[table.name]; ok {
+		return versions
+	}
+
+	return []*schema{table.base}
+}
+
+// rowSchema возвращает версию схемы, с которой записана строка
+func (dm *diskManager) rowSchema(table *tableFile, version uint8) (*schema, bool) {
+	versions := dm.tableSchemas(table)
+	if int(version) >= len(versions) {
+		return nil, false
+	}
+
+	return versions[version], true
+}
+
+// schema возвращает версию схемы, которую видит транзакция
+func (tx *mvccTransaction) schema(table *tableFile) *schema {
+	versions := tx.dm.tableSchemas(table)
+	for i := len(versions) - 1; i > 0; i-- {
+		if tx.sees(versions[i].xmin) {
+			return versions[i]
+		}
+	}
+
+	return versions[0]
+}
+
+// latestSchema возвращает последнюю версию схемы, которую создала не прерванная транзакция.
+// По ней пишут строки и строят ключи индексов: писать в таблицу может только транзакция, которая ее видит
+func (dm *diskManager) latestSchema(table *tableFile) *schema {
+	versions := dm.tableSchemas(table)
+	for i := len(versions) - 1; i > 0; i-- {
+		if dm.status(versions[i].xmin) != txAborted {
+			return versions[i]
+		}
+	}
+
+	return versions[0]
+}
+
+// catalogSchema возвращает последнюю версию, созданную зафиксированной транзакцией или транзакцией xid,
+// которая сейчас фиксируется. По ней в файл описаний индексов пишутся имена колонок
+func (dm *diskManager) catalogSchema(table *tableFile, xid uint64) *schema {
+	versions := dm.tableSchemas(table)
+	for i := len(versions) - 1; i > 0; i-- {
+		if versions[i].xmin == xid || dm.status(versions[i].xmin) == txCommitted {
+			return versions[i]
+		}
+	}
+
+	return versions[0]
+}
+
+// columnName возвращает имя колонки id для файла описаний индексов: из catalogSchema,
+// а если колонку добавила незафиксированная транзакция - из последней версии с ней
+func (dm *diskManager) columnName(table *tableFile, id uint8, xid uint64) string {
+	catalog := dm.catalogSchema(table, xid)
+	if position := catalog.position(id); position != -1 {
+		return catalog.columns[position].Name
+	}
+
+	versions := dm.tableSchemas(table)
+	for i := len(versions) - 1; i >= 0; i-- {
+		if position := versions[i].position(id); position != -1 {
+			return versions[i].columns[position].Name
+		}
+	}
+
+	return ""
+}
+
+// loadColumnIDs находит при загрузке номера колонок, имена которых записаны в файле описаний индексов
+func (dm *diskManager) loadColumnIDs(table *tableFile, names []string) ([]uint8, error) {
+	ids, err := dm.latestSchema(table).columnIDs(names)
+	if err == nil {
+		return ids, nil
+	}
+
+	versions := dm.tableSchemas(table)
+	ids = make([]uint8, len(names))
+	for i, name := range names {
+		found := false
+		for j := len(versions) - 1; j >= 0 && !found; j-- {
+			for k, column := range versions[j].columns {
+				if column.Name == name {
+					ids[i], found = versions[j].ids[k], true
+					break
+				}
+			}
+		}
+		if !found {
+			return nil, err
+		}
+	}
+
+	return ids, nil
+}
+
+// layouts отдает сервису данных колонки версий схемы таблицы
+func (dm *diskManager) layouts(table *tableFile) data.Layouts {
+	return func(version uint8) ([]data.Column, bool) {
+		s, ok := dm.rowSchema(table, version)
+		if !ok {
+			return nil, false
+		}
+		return s.columns, true
+	}
+}
+
+// currentRow переводит версию строки в колонки последней версии схемы
+func (dm *diskManager) currentRow(table *tableFile, row data.DataRow) ([]data.DataCell, *schema, error) {
+	from, ok := dm.rowSchema(table, row.Version)
+	if !ok {
+		return nil, nil, fmt.Errorf("row %d:%d: unknown schema version %d", row.PageId, row.SlotId, row.Version)
+	}
+
+	latest := dm.latestSchema(table)
+
+	return latest.convert(from, row.Row), latest, nil
+}
+
+// tableCandidates возвращает папки таблиц, которые могут называться tableName: папку с этим именем
+// и таблицы, у которых это имя есть в какой-нибудь версии схемы
+func (dm *diskManager) tableCandidates(tableName string) []string {
+	candidates := []string{tableName}
+
+	dm.schemaMu.RLock()
+	for physical, versions := range dm.schemas {
+		if physical == tableName {
+			continue
+		}
+		for _, s := range versions {
+			if s.name == tableName {
+				candidates = append(candidates, physical)
+				break
+			}
+		}
+	}
+	dm.schemaMu.RUnlock()
+
+	sort.Strings(candidates[1:])
+
+	return candidates
+}
+
+// checkNameFree проверяет, что транзакция может дать таблице имя tableName: его не носит видимая ей таблица,
+// а параллельная транзакция не создавала, не удаляла и не переименовывала таблицу с этим именем
+func (tx *mvccTransaction) checkNameFree(tableName string) error {
+	dm := tx.dm
+
+	for _, physical := range dm.tableCandidates(tableName) {
+		table, err := dm.openTable(physical)
+		if errors.Is(err, errTableDoesNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		xmin, xmax := dm.versions(table)
+		if dm.status(xmin) == txAborted && xmin != tx.snapshot.xid {
+			continue
+		}
+		if tx.visible(xmin, xmax) && tx.schema(table).name == tableName {
+			return fmt.Errorf("table already exists: %s", tableName)
+		}
+
+		latest := dm.latestSchema(table)
+		concurrent := !tx.sees(xmin) || !tx.sees(latest.xmin) || (xmax != 0 && dm.status(xmax) != txAborted && !tx.sees(xmax))
+		if concurrent && latest.name == tableName {
+			return writeConflict("table %s is created, dropped or renamed by a concurrent transaction", tableName)
+		}
+	}
+
+	return nil
+}
+
+// tableDirectory выбирает папку для новой таблицы tableName. Обычно это само имя, но папка может быть
+// занята таблицей, которую переименовали или удаление которой еще видят старые снимки: тогда к имени
+// добавляется номер. Вызывается внутри физической транзакции
+func (dm *diskManager) tableDirectory(tableName string) string {
+	physical := tableName
+	for i := 1; ; i++ {
+		if _, err := os.Stat(filepath.Join(dm.cfg.DBPath, physical)); os.IsNotExist(err) {
+			return physical
+		}
+
+		physical = fmt.Sprintf("%s#%d", tableName, i)
+	}
+}
+
+// addSchema добавляет таблице версию схемы внутри физической транзакции. base - версия 0 из файла
+// таблицы, она попадает в каталог вместе с первой новой версией
+func (dm *diskManager) addSchema(base *schema, s *schema) error {
+	dm.schemaMu.Lock()
+	previous, ok := dm.schemas[base.table]
+	versions := previous
+	if !ok && s.version != 0 {
+		versions = []*schema{base}
+	}
+	dm.schemas[base.table] = append(append([]*schema{}, versions...), s)
+	dm.schemaMu.Unlock()
+
+	dm.tx.onAbort = append(dm.tx.onAbort, func() {
+		dm.schemaMu.Lock()
+		if ok {
+			dm.schemas[base.table] = previous
+		} else {
+			delete(dm.schemas, base.table)
+		}
+		dm.schemaMu.Unlock()
+	})
+
+	return dm.writeSchemas()
+}
+
+// removeSchemas убирает версии схемы удаляемой таблицы внутри физической транзакции
+func (dm *diskManager) removeSchemas(tableName string) error {
+	dm.schemaMu.Lock()
+	versions, ok := dm.schemas[tableName]
+	delete(dm.schemas, tableName)
+	dm.schemaMu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	dm.tx.onAbort = append(dm.tx.onAbort, func() {
+		dm.schemaMu.Lock()
+		dm.schemas[tableName] = versions
+		dm.schemaMu.Unlock()
+	})
+
+	return dm.writeSchemas()
+}
+
+// AlterTable создает новую версию схемы таблицы. Строки, которые уже есть в таблице, переписываются,
+// только если у колонки меняется тип, остальные читаются по своей версии схемы.
+// Транзакция, начатая до фиксации, продолжает видеть старую схему, а изменять таблицу параллельно нельзя
+func (tx *mvccTransaction) AlterTable(tableName string, alteration Alteration) error {
+	if tx.finished {
+		return fmt.Errorf("AlterTable(): transaction is already finished")
+	}
+
+	dm := tx.dm
+	err := dm.inTransaction(func() error {
+		table, err := tx.writableTable(tableName)
+		if err != nil {
+			return err
+		}
+		if dm.hasConcurrentWriters(table.name, tx.snapshot.xid) {
+			return writeConflict("table %s is changed by a concurrent transaction", tableName)
+		}
+		if alteration.Name != tableName {
+			if isSystemTable(alteration.Name) {
+				return fmt.Errorf("table name cannot start with _: %s", alteration.Name)
+			}
+			if err := tx.checkNameFree(alteration.Name); err != nil {
+				return err
+			}
+		}
+
+		current := tx.schema(table)
+		next, err := dm.nextSchema(table, current, alteration, tx.snapshot.xid)
+		if err != nil {
+			return err
+		}
+
+		err = dm.checkIndexedColumns(table, next)
+		if err != nil {
+			return err
+		}
+
+		// Строки для перезаписи собираются до новой версии схемы: их нужно прочитать по старой
+		var rows []data.DataRow
+		rewrite := false
+		for _, column := range alteration.Columns {
+			rewrite = rewrite || column.Convert != nil
+		}
+		if rewrite {
+			rows, err = tx.visibleRows(table, current)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = dm.addSchema(table.base, next)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			values := make([]data.DataCell, len(alteration.Columns))
+			for i, column := range alteration.Columns {
+				switch {
+				case column.Source == -1:
+					values[i] = column.Missing
+				case column.Convert != nil:
+					values[i], err = column.Convert(row.Row[column.Source])
+					if err != nil {
+						return err
+					}
+				default:
+					values[i] = row.Row[column.Source]
+				}
+			}
+
+			_, err = tx.writeVersion(table, next, row.PageId, row.SlotId, values)
+			if err != nil {
+				return err
+			}
+		}
+
+		tx.altered = true
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("AlterTable(): %w", err)
+	}
+
+	return nil
+}
+
+// nextSchema собирает следующую версию схемы таблицы из current и alteration
+func (dm *diskManager) nextSchema(table *tableFile, current *schema, alteration Alteration, xid uint64) (*schema, error) {
+	versions := dm.tableSchemas(table)
+	if len(versions) > MAX_SCHEMA_VERSION {
+		return nil, fmt.Errorf("table %s has too many schema versions", current.name)
+	}
+
+	nextID := 0
+	for _, s := range versions {
+		for _, id := range s.ids {
+			nextID = max(nextID, int(id)+1)
+		}
+	}
+
+	next := &schema{
+		table:   table.name,
+		version: uint8(len(versions)),
+		name:    alteration.Name,
+		checks:  alteration.Checks,
+		xmin:    xid,
+	}
+	for _, column := range alteration.Columns {
+		if column.Source >= len(current.columns) {
+			return nil, fmt.Errorf("column %d does not exist in table %s", column.Source, current.name)
+		}
+
+		if column.Source >= 0 && column.Convert == nil {
+			next.ids = append(next.ids, current.ids[column.Source])
+			next.missing = append(next.missing, current.missing[column.Source])
+		} else {
+			if nextID > math.MaxUint8 {
+				return nil, fmt.Errorf("table %s has too many column versions", current.name)
+			}
+			missing := column.Missing
+			if column.Source >= 0 || missing.IsNull {
+				missing = data.DataCell{Type: column.Type, IsNull: true}
+			}
+			next.ids = append(next.ids, uint8(nextID))
+			next.missing = append(next.missing, missing)
+			nextID++
+		}
+		next.columns = append(next.columns, column.Column)
+	}
+
+	return next, nil
+}
+
+// checkIndexedColumns не дает удалить или сменить тип колонки, по которой построен индекс
+// таблицы или на которую ссылается внешний ключ
+func (dm *diskManager) checkIndexedColumns(table *tableFile, next *schema) error {
+	current := dm.latestSchema(table)
+
+	dm.indexMu.RLock()
+	defer dm.indexMu.RUnlock()
+
+	for _, idx := range dm.indexes {
+		if idx.xmax != 0 && dm.status(idx.xmax) != txAborted {
+			continue
+		}
+
+		ids := idx.ids
+		if idx.Table != table.name {
+			if idx.ForeignKey == nil || idx.ForeignKey.RefTable != table.name {
+				continue
+			}
+			ids = idx.refIDs
+		}
+
+		for _, id := range ids {
+			if next.position(id) == -1 {
+				name := "?"
+				if position := current.position(id); position != -1 {
+					name = current.columns[position].Name
+				}
+				return fmt.Errorf("column %s of table %s is used by index %s", name, current.name, idx.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// visibleRows читает видимые транзакции версии строк таблицы в колонках версии схемы s
+func (tx *mvccTransaction) visibleRows(table *tableFile, s *schema) ([]data.DataRow, error) {
+	it, err := table.data.Scan()
+	if err != nil {
+		return nil, fmt.Errorf("data.Scan: %w", err)
+	}
+
+	rows := []data.DataRow{}
+	for it.Next() {
+		row := it.Row()
+		if !tx.visible(row.Xmin, row.Xmax) {
+			continue
+		}
+
+		from, ok := tx.dm.rowSchema(table, row.Version)
+		if !ok {
+			return nil, fmt.Errorf("row %d:%d: unknown schema version %d", row.PageId, row.SlotId, row.Version)
+		}
+		row.Row = s.convert(from, row.Row)
+		rows = append(rows, row)
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("data.Scan: %w", err)
+	}
+
+	return rows, nil
+}
+
+// loadSchemas читает файл версий схем. Версии прерванных транзакций остаются: их номера могли попасть
+// в строки на диске. Версии таблиц, файлов которых уже нет, убираются из файла
+func (dm *diskManager) loadSchemas() error {
+	err := os.MkdirAll(filepath.Join(dm.cfg.DBPath, SCHEMAS_FILE), 0755)
+	if err != nil {
+		return fmt.Errorf("loadSchemas(): os.MkdirAll: %w", err)
+	}
+
+	file, err := dm.openFile(dm.tableDataPath(SCHEMAS_FILE), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("loadSchemas(): os.OpenFile: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("loadSchemas(): file.Stat: %w", err)
+	}
+
+	content := make([]byte, info.Size())
+	if len(content) > 0 {
+		_, err = file.ReadAt(content, 0)
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("loadSchemas(): file.ReadAt: %w", err)
+		}
+	}
+
+	dm.files[SCHEMAS_FILE] = file
+	dm.schemaFile = &loggedFile{dm: dm, table: SCHEMAS_FILE, file: file}
+
+	schemas, err := deserializeSchemas(content)
+	if err != nil {
+		return fmt.Errorf("loadSchemas(): %w", err)
+	}
+
+	stale := false
+	for _, s := range schemas {
+		if _, err := os.Stat(dm.tableDataPath(s.table)); err != nil {
+			stale = true
+			continue
+		}
+		dm.schemas[s.table] = append(dm.schemas[s.table], s)
+	}
+	for table, versions := range dm.schemas {
+		sort.Slice(versions, func(i, j int) bool { return versions[i].version < versions[j].version })
+		for i, s := range versions {
+			if int(s.version) != i {
+				return fmt.Errorf("loadSchemas(): table %s: schema version %d is missing", table, i)
+			}
+		}
+	}
+
+	if !stale {
+		return nil
+	}
+
+	err = dm.inTransaction(dm.writeSchemas)
+	if err != nil {
+		return fmt.Errorf("loadSchemas(): %w", err)
+	}
+
+	return nil
+}
+
+// writeSchemas переписывает файл версий схем, вызывается внутри физической транзакции.
+// Формат: [размер версий uint32][версии: папка таблицы, имя таблицы, номер версии uint8, xmin,
+// количество колонок uint8, колонки: имя, тип uint8, IsNullable uint8, номер uint8, DEFAULT,
+// значение для старых строк (NULL uint8 и значение), количество CHECK uint8, CHECK: имя и условие]
+func (dm *diskManager) writeSchemas() error {
+	dm.schemaMu.RLock()
+	tables := make([]string, 0, len(dm.schemas))
+	for table := range dm.schemas {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	versions := []*schema{}
+	for _, table := range tables {
+		versions = append(versions, dm.schemas[table]...)
+	}
+	dm.schemaMu.RUnlock()
+
+	content := serializeSchemas(versions)
+	_, err := dm.schemaFile.WriteAt(content, 0)
+	if err != nil {
+		return fmt.Errorf("writeSchemas(): %w", err)
+	}
+
+	return nil
+}
+
+func serializeSchemas(versions []*schema) []byte {
+	text := func(value string) int { return binary_serializer.TEXT_TYPE_HEADER + len(value) }
+	missing := func(cell data.DataCell) []byte {
+		if cell.IsNull {
+			return []byte{1}
+		}
+		return append([]byte{0}, data.ConvertValueToBuffer(cell.Type, cell.Value)...)
+	}
+
+	size := 0
+	for _, s := range versions {
+		size += text(s.table) + text(s.name) + 1 + data.XID_SIZE + data.COLUMN_COUNT_SIZE + data.CHECK_COUNT_SIZE
+		for i, column := range s.columns {
+			size += text(column.Name) + data.DATA_TYPE_SIZE + 2 + text(column.Default) + len(missing(s.missing[i]))
+		}
+		for _, check := range s.checks {
+			size += text(check.Name) + text(check.Expression)
+		}
+	}
+
+	content := make([]byte, 4+size)
+	binary_serializer.WriteUint32(content, 0, uint32(size))
+	offset := 4
+	for _, s := range versions {
+		offset += binary_serializer.WriteString(content, offset, s.table)
+		offset += binary_serializer.WriteString(content, offset, s.name)
+		binary_serializer.WriteUint8(content, offset, s.version)
+		offset++
+		binary_serializer.WriteUint64(content, offset, s.xmin)
+		offset += data.XID_SIZE
+		binary_serializer.WriteUint8(content, offset, uint8(len(s.columns)))
+		offset += data.COLUMN_COUNT_SIZE
+		for i, column := range s.columns {
+			offset += binary_serializer.WriteString(content, offset, column.Name)
+			binary_serializer.WriteUint8(content, offset, uint8(column.Type))
+			binary_serializer.WriteBool(content, offset+1, column.IsNullable)
+			binary_serializer.WriteUint8(content, offset+2, s.ids[i])
+			offset += data.DATA_TYPE_SIZE + 2
+			offset += binary_serializer.WriteString(content, offset, column.Default)
+			offset += copy(content[offset:], missing(s.missing[i]))
+		}
+		binary_serializer.WriteUint8(content, offset, uint8(len(s.checks)))
+		offset += data.CHECK_COUNT_SIZE
+		for _, check := range s.checks {
+			offset += binary_serializer.WriteString(content, offset, check.Name)
+			offset += binary_serializer.WriteString(content, offset, check.Expression)
+		}
+	}
+
+	return content
+}
+
+func deserializeSchemas(content []byte) ([]*schema, error) {
+	if len(content) < 4 {
+		return nil, nil
+	}
+
+	size := int(binary_serializer.ReadUint32(content, 0))
+	if 4+size > len(content) {
+		return nil, fmt.Errorf("deserializeSchemas(): schemas file is truncated")
+	}
+
+	versions := []*schema{}
+	offset := 4
+	for offset < 4+size {
+		s := &schema{}
+
+		var n int
+		s.table, n = binary_serializer.ReadString(content, offset)
+		offset += n
+		s.name, n = binary_serializer.ReadString(content, offset)
+		offset += n
+		s.version = binary_serializer.ReadUint8(content, offset)
+		offset++
+		s.xmin = binary_serializer.ReadUint64(content, offset)
+		offset += data.XID_SIZE
+		count := int(binary_serializer.ReadUint8(content, offset))
+		offset += data.COLUMN_COUNT_SIZE
+		for i := 0; i < count; i++ {
+			column := data.Column{}
+			column.Name, n = binary_serializer.ReadString(content, offset)
+			offset += n
+			column.Type = data.ColumnType(binary_serializer.ReadUint8(content, offset))
+			column.IsNullable = binary_serializer.ReadBool(content, offset+1)
+			s.ids = append(s.ids, binary_serializer.ReadUint8(content, offset+2))
+			offset += data.DATA_TYPE_SIZE + 2
+			column.Default, n = binary_serializer.ReadString(content, offset)
+			offset += n
+
+			missing := data.DataCell{Type: column.Type, IsNull: binary_serializer.ReadBool(content, offset)}
+			offset++
+			if !missing.IsNull {
+				missing.Value, n = data.ConvertValueToType(content, offset, column.Type)
+				offset += n
+			}
+
+			s.columns = append(s.columns, column)
+			s.missing = append(s.missing, missing)
+		}
+		checks := int(binary_serializer.ReadUint8(content, offset))
+		offset += data.CHECK_COUNT_SIZE
+		for i := 0; i < checks; i++ {
+			check := data.Check{}
+			check.Name, n = binary_serializer.ReadString(content, offset)
+			offset += n
+			check.Expression, n = binary_serializer.ReadString(content, offset)
+			offset += n
+			s.checks = append(s.checks, check)
+		}
+
+		versions = append(versions, s)
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,231 @@
+package disk_manager
+
+import (
+	"custom-database/config"
+	"custom-database/internal/disk_manager/data"
+	"custom-database/internal/disk_manager/wal"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlterTable(t *testing.T) {
+	columns := []data.Column{
+		{Name: "id", Type: data.TypeInt32, IsNullable: false},
+		{Name: "name", Type: data.TypeText, IsNullable: true},
+	}
+	row := func(id int32, name string) []data.DataCell {
+		return []data.DataCell{
+			{Value: id, Type: data.TypeInt32},
+			{Value: name, Type: data.TypeText},
+		}
+	}
+	// keep возвращает колонки текущей схемы без изменений
+	keep := func(columns []data.Column) []AlteredColumn {
+		result := make([]AlteredColumn, len(columns))
+		for i, column := range columns {
+			result[i] = AlteredColumn{Column: column, Source: i}
+		}
+		return result
+	}
+	newTable := func(t *testing.T, cfg *config.Config, ids ...int32) *diskManager {
+		dm, err := newDiskManager(cfg, wal.OpenOSFile)
+		require.NoError(t, err)
+		require.NoError(t, dm.CreateTable("users", columns, nil))
+
+		for _, id := range ids {
+			_, err := dm.InsertRow("users", row(id, fmt.Sprintf("user-%d", id)))
+			require.NoError(t, err)
+		}
+
+		return dm
+	}
+	alter := func(t *testing.T, dm *diskManager, tableName string, alteration Alteration) {
+		tx := dm.Begin()
+		require.NoError(t, tx.AlterTable(tableName, alteration))
+		require.NoError(t, tx.Commit())
+	}
+	scan := func(t *testing.T, tx Transaction, tableName string) [][]interface{} {
+		it, err := tx.ScanRows(tableName)
+		require.NoError(t, err)
+
+		rows := [][]interface{}{}
+		for it.Next() {
+			values := []interface{}{}
+			for _, cell := range it.Row().Row {
+				if cell.IsNull {
+					values = append(values, nil)
+				} else {
+					values = append(values, cell.Value)
+				}
+			}
+			rows = append(rows, values)
+		}
+		require.NoError(t, it.Err())
+
+		return rows
+	}
+	addAge := func(dm *diskManager) Alteration {
+		age := AlteredColumn{
+			Column:  data.Column{Name: "age", Type: data.TypeInt32, IsNullable: true, Default: "18"},
+			Source:  -1,
+			Missing: data.DataCell{Value: int32(18), Type: data.TypeInt32},
+		}
+		return Alteration{Name: "users", Columns: append(keep(columns), age)}
+	}
+
+	t.Run("добавленная колонка читается в старых строках как значение по умолчанию", func(t *testing.T) {
+		dm := newTable(t, &config.Config{DBPath: t.TempDir()}, 1, 2)
+
+		old := dm.Begin()
+		defer old.Rollback()
+
+		alter(t, dm, "users", addAge(dm))
+
+		tx := dm.Begin()
+		_, err := tx.InsertRow("users", append(row(3, "user-3"), data.DataCell{Value: int32(30), Type: data.TypeInt32}))
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		tx = dm.Begin()
+		defer tx.Rollback()
+		require.Equal(t, [][]interface{}{
+			{int32(1), "user-1", int32(18)},
+			{int32(2), "user-2", int32(18)},
+			{int32(3), "user-3", int32(30)},
+		}, scan(t, tx, "users"))
+
+		// Транзакция, начатая до ALTER TABLE, видит старую схему и не видит новую строку
+		require.Equal(t, [][]interface{}{{int32(1), "user-1"}, {int32(2), "user-2"}}, scan(t, old, "users"))
+		oldColumns, err := old.GetTableColumns("users")
+		require.NoError(t, err)
+		require.Equal(t, columns, oldColumns)
+
+		_, err = old.InsertRow("users", row(4, "user-4"))
+		require.ErrorIs(t, err, ErrWriteConflict)
+	})
+
+	t.Run("переименования колонки и таблицы переживают перезапуск вместе с индексом", func(t *testing.T) {
+		cfg := &config.Config{DBPath: t.TempDir()}
+		dm := newTable(t, cfg, 5, 3, 9)
+
+		tx := dm.Begin()
+		require.NoError(t, tx.CreateIndex(Index{Name: "users_id", Table: "users", Columns: []string{"id"}, Unique: true}))
+		require.NoError(t, tx.Commit())
+
+		renamed := keep(columns)
+		renamed[0].Name = "user_id"
+		alter(t, dm, "users", Alteration{Name: "people", Columns: renamed})
+		require.NoError(t, dm.log.Close())
+
+		dm, err := newDiskManager(cfg, wal.OpenOSFile)
+		require.NoError(t, err)
+
+		tx = dm.Begin()
+		defer tx.Rollback()
+
+		_, err = tx.GetTableColumns("users")
+		require.ErrorIs(t, err, errTableDoesNotExist)
+
+		indexes, err := tx.GetTableIndexes("people")
+		require.NoError(t, err)
+		require.Equal(t, []Index{{Name: "users_id", Table: "people", Columns: []string{"user_id"}, Unique: true}}, indexes)
+
+		it, err := tx.IndexScan("users_id", nil, nil, false)
+		require.NoError(t, err)
+		ids := []int32{}
+		for it.Next() {
+			ids = append(ids, it.Row().Row[0].Value.(int32))
+		}
+		require.NoError(t, it.Err())
+		require.Equal(t, []int32{3, 5, 9}, ids)
+
+		_, err = tx.InsertRow("people", row(5, "again"))
+		var violation *ConstraintError
+		require.ErrorAs(t, err, &violation)
+		require.Contains(t, violation.Message, "key (user_id)=(5) already exists")
+	})
+
+	t.Run("после переименования старое имя можно занять новой таблицей", func(t *testing.T) {
+		cfg := &config.Config{DBPath: t.TempDir()}
+		dm := newTable(t, cfg, 1)
+
+		alter(t, dm, "users", Alteration{Name: "people", Columns: keep(columns)})
+		require.NoError(t, dm.CreateTable("users", columns, nil))
+		_, err := dm.InsertRow("users", row(2, "new"))
+		require.NoError(t, err)
+
+		tx := dm.Begin()
+		err = tx.CreateTable("people", columns, nil)
+		require.ErrorContains(t, err, "table already exists: people")
+		require.NoError(t, tx.Rollback())
+		require.NoError(t, dm.log.Close())
+
+		dm, err = newDiskManager(cfg, wal.OpenOSFile)
+		require.NoError(t, err)
+
+		tx = dm.Begin()
+		defer tx.Rollback()
+		require.Equal(t, [][]interface{}{{int32(1), "user-1"}}, scan(t, tx, "people"))
+		require.Equal(t, [][]interface{}{{int32(2), "new"}}, scan(t, tx, "users"))
+	})
+
+	t.Run("смена типа переписывает строки, а откат возвращает старую схему", func(t *testing.T) {
+		dm := newTable(t, &config.Config{DBPath: t.TempDir()})
+		for _, name := range []string{"10", "20"} {
+			_, err := dm.InsertRow("users", []data.DataCell{{Value: int32(1), Type: data.TypeInt32}, {Value: name, Type: data.TypeText}})
+			require.NoError(t, err)
+		}
+
+		altered := keep(columns)
+		altered[1].Type = data.TypeInt64
+		altered[1].Convert = func(cell data.DataCell) (data.DataCell, error) {
+			if cell.IsNull {
+				return data.DataCell{Type: data.TypeInt64, IsNull: true}, nil
+			}
+			value, err := strconv.ParseInt(cell.Value.(string), 10, 64)
+			return data.DataCell{Value: value, Type: data.TypeInt64}, err
+		}
+
+		tx := dm.Begin()
+		require.NoError(t, tx.AlterTable("users", Alteration{Name: "users", Columns: altered}))
+		require.Equal(t, [][]interface{}{{int32(1), int64(10)}, {int32(1), int64(20)}}, scan(t, tx, "users"))
+		require.NoError(t, tx.Rollback())
+
+		tx = dm.Begin()
+		defer tx.Rollback()
+		require.Equal(t, [][]interface{}{{int32(1), "10"}, {int32(1), "20"}}, scan(t, tx, "users"))
+
+		alter(t, dm, "users", Alteration{Name: "users", Columns: altered})
+		other := dm.Begin()
+		defer other.Rollback()
+		require.Equal(t, [][]interface{}{{int32(1), int64(10)}, {int32(1), int64(20)}}, scan(t, other, "users"))
+		require.NoError(t, dm.Vacuum())
+		require.Equal(t, [][]interface{}{{int32(1), int64(10)}, {int32(1), int64(20)}}, scan(t, other, "users"))
+	})
+
+	t.Run("колонку индекса нельзя удалить, а параллельная запись мешает ALTER TABLE", func(t *testing.T) {
+		dm := newTable(t, &config.Config{DBPath: t.TempDir()}, 1)
+
+		tx := dm.Begin()
+		require.NoError(t, tx.CreateIndex(Index{Name: "users_name", Table: "users", Columns: []string{"name"}}))
+		require.NoError(t, tx.Commit())
+
+		tx = dm.Begin()
+		err := tx.AlterTable("users", Alteration{Name: "users", Columns: keep(columns)[:1]})
+		require.ErrorContains(t, err, "column name of table users is used by index users_name")
+		require.NoError(t, tx.Rollback())
+
+		writer := dm.Begin()
+		_, err = writer.InsertRow("users", row(2, "user-2"))
+		require.NoError(t, err)
+
+		tx = dm.Begin()
+		err = tx.AlterTable("users", addAge(dm))
+		require.ErrorIs(t, err, ErrWriteConflict)
+		require.NoError(t, tx.Rollback())
+		require.NoError(t, writer.Commit())
+	})
+}
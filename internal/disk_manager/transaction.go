@@ -0,0 +1,405 @@
+package disk_manager
+
+import (
+	"custom-database/internal/disk_manager/buffer_pool"
+	"custom-database/internal/disk_manager/wal"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// BLOCK_SIZE - размер участка файла таблицы, которым изменения копятся в транзакции,
+// записываются в журнал и кешируются в буферном пуле. Совпадает с размером страницы,
+// но блоки выровнены по началу файла, а не по страницам (перед страницами лежат метаданные)
+const BLOCK_SIZE = buffer_pool.PAGE_SIZE
+
+// CHECKPOINT_LOG_SIZE - размер журнала, после которого файлы таблиц сбрасываются на диск,
+// а журнал очищается
+const CHECKPOINT_LOG_SIZE = 16 << 20
+
+// transaction - физическая транзакция: изменения файлов таблиц одной операции, которые еще не записаны в журнал.
+// Пока транзакция не зафиксирована, файлы таблиц не меняются (no-steal),
+// поэтому при восстановлении незафиксированные транзакции достаточно пропустить.
+// Атомарность транзакций MVCC из нескольких операций обеспечивает файл статусов, а не журнал
+type transaction struct {
+	id     uint64
+	blocks map[string]map[int64][]byte // таблица -> номер блока -> новое содержимое
+	// dropped - таблицы, файлы которых удаляются после фиксации
+	dropped map[string]bool
+	// onAbort - действия вне файлов таблиц, которые нужно откатить, если транзакция не попала в журнал
+	onAbort []func()
+	logged  bool
+}
+
+func newTransaction(id uint64) *transaction {
+	return &transaction{
+		id:      id,
+		blocks:  map[string]map[int64][]byte{},
+		dropped: map[string]bool{},
+	}
+}
+
+// records возвращает записи журнала для всех измененных блоков и удаленных таблиц
+// в детерминированном порядке
+func (tx *transaction) records() []wal.Record {
+	tables := make([]string, 0, len(tx.blocks))
+	for table := range tx.blocks {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	records := []wal.Record{}
+	for _, table := range tables {
+		for _, block := range sortedBlocks(tx.blocks[table]) {
+			records = append(records, wal.Record{
+				Type:   wal.PageRecord,
+				TxID:   tx.id,
+				Table:  table,
+				Offset: block * BLOCK_SIZE,
+				Data:   tx.blocks[table][block],
+			})
+		}
+	}
+
+	dropped := make([]string, 0, len(tx.dropped))
+	for table := range tx.dropped {
+		dropped = append(dropped, table)
+	}
+	sort.Strings(dropped)
+
+	for _, table := range dropped {
+		records = append(records, wal.Record{
+			Type:  wal.DropTableRecord,
+			TxID:  tx.id,
+			Table: table,
+		})
+	}
+
+	return records
+}
+
+func sortedBlocks(blocks map[int64][]byte) []int64 {
+	result := make([]int64, 0, len(blocks))
+	for block := range blocks {
+		result = append(result, block)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+
+	return result
+}
+
+// loggedFile - файл таблицы, через который работает data.Service.
+// Запись попадает в блоки текущей транзакции, чтение видит их поверх блоков из буферного пула
+type loggedFile struct {
+	dm    *diskManager
+	table string
+	file  wal.File
+	// committed - чтение видит только зафиксированные блоки. Через такой файл читают транзакции MVCC,
+	// чтобы не увидеть страницу посреди чужой физической транзакции
+	committed bool
+}
+
+func (lf *loggedFile) ReadAt(p []byte, off int64) (int, error) {
+	lf.dm.txMu.RLock()
+	defer lf.dm.txMu.RUnlock()
+
+	var blocks map[int64][]byte
+	if lf.dm.tx != nil && !lf.committed {
+		blocks = lf.dm.tx.blocks[lf.table]
+	}
+
+	end := off
+	for block := off / BLOCK_SIZE; block*BLOCK_SIZE < off+int64(len(p)); block++ {
+		start := max(off, block*BLOCK_SIZE)
+		stop := min(off+int64(len(p)), (block+1)*BLOCK_SIZE)
+
+		if data, ok := blocks[block]; ok {
+			copy(p[start-off:stop-off], data[start-block*BLOCK_SIZE:stop-block*BLOCK_SIZE])
+			end = max(end, stop)
+			continue
+		}
+
+		id := buffer_pool.PageID{File: lf.table, Page: block}
+		frame, err := lf.dm.pool.FetchPage(lf.file, id)
+		if err != nil {
+			return int(end - off), fmt.Errorf("ReadAt(): %w", err)
+		}
+		copy(p[start-off:stop-off], frame.Data[start-block*BLOCK_SIZE:stop-block*BLOCK_SIZE])
+		// Байты за концом файла в последнем блоке не прочитаны
+		if size := block*BLOCK_SIZE + int64(frame.Size); size > start {
+			end = max(end, min(stop, size))
+		}
+
+		err = lf.dm.pool.UnpinPage(id, false)
+		if err != nil {
+			return int(end - off), fmt.Errorf("ReadAt(): %w", err)
+		}
+	}
+
+	if end < off+int64(len(p)) {
+		return int(end - off), io.EOF
+	}
+
+	return len(p), nil
+}
+
+func (lf *loggedFile) WriteAt(p []byte, off int64) (int, error) {
+	lf.dm.txMu.Lock()
+	defer lf.dm.txMu.Unlock()
+
+	if lf.dm.tx == nil || lf.committed {
+		return 0, fmt.Errorf("WriteAt(): table %s is written outside of a transaction", lf.table)
+	}
+
+	blocks, ok := lf.dm.tx.blocks[lf.table]
+	if !ok {
+		blocks = map[int64][]byte{}
+		lf.dm.tx.blocks[lf.table] = blocks
+	}
+
+	for block := off / BLOCK_SIZE; block*BLOCK_SIZE < off+int64(len(p)); block++ {
+		data, ok := blocks[block]
+		if !ok {
+			// Блок меняется в транзакции впервые - берем его текущее содержимое из пула
+			id := buffer_pool.PageID{File: lf.table, Page: block}
+			frame, err := lf.dm.pool.FetchPage(lf.file, id)
+			if err != nil {
+				return 0, fmt.Errorf("WriteAt(): %w", err)
+			}
+			data = append([]byte{}, frame.Data...)
+
+			err = lf.dm.pool.UnpinPage(id, false)
+			if err != nil {
+				return 0, fmt.Errorf("WriteAt(): %w", err)
+			}
+			blocks[block] = data
+		}
+
+		start := max(off, block*BLOCK_SIZE)
+		stop := min(off+int64(len(p)), (block+1)*BLOCK_SIZE)
+		copy(data[start-block*BLOCK_SIZE:stop-block*BLOCK_SIZE], p[start-off:stop-off])
+	}
+
+	return len(p), nil
+}
+
+// Sync ничего не делает: изменения становятся надежными при фиксации транзакции
+func (lf *loggedFile) Sync() error {
+	return nil
+}
+
+// beginTransaction начинает новую транзакцию, writeMu должен быть захвачен
+func (dm *diskManager) beginTransaction() error {
+	if dm.failed != nil {
+		return fmt.Errorf("disk manager needs a restart to recover: %w", dm.failed)
+	}
+
+	dm.txMu.Lock()
+	dm.nextTxID++
+	dm.tx = newTransaction(dm.nextTxID)
+	dm.txMu.Unlock()
+
+	return nil
+}
+
+// inTransaction выполняет fn как одну физическую транзакцию: либо все ее изменения файлов таблиц
+// попадают в журнал и на диск, либо ни одного
+func (dm *diskManager) inTransaction(fn func() error) error {
+	dm.writeMu.Lock()
+	defer dm.writeMu.Unlock()
+
+	err := dm.beginTransaction()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	if err != nil {
+		dm.abort()
+		return err
+	}
+
+	err = dm.commit()
+	if err != nil {
+		dm.abort()
+		return err
+	}
+
+	return nil
+}
+
+// commit пишет изменения транзакции в журнал с fsync и только потом применяет их к файлам таблиц
+// и удаляет файлы удаленных таблиц.
+// Если процесс упадет во время применения, изменения повторятся из журнала при восстановлении
+func (dm *diskManager) commit() error {
+	records := dm.tx.records()
+	if len(records) == 0 {
+		dm.txMu.Lock()
+		dm.tx = nil
+		dm.txMu.Unlock()
+		return nil
+	}
+
+	records = append(records, wal.Record{Type: wal.CommitRecord, TxID: dm.tx.id})
+	err := dm.log.Append(records)
+	if err != nil {
+		return fmt.Errorf("commit(): log.Append: %w", err)
+	}
+	dm.tx.logged = true
+
+	// Деревья индексов читаются без txMu между страницами, поэтому страницы операции
+	// должны появиться в них все сразу
+	dm.treeMu.Lock()
+	err = dm.applyCommitted()
+	dm.treeMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("commit(): %w", err)
+	}
+
+	if dm.log.Size() >= CHECKPOINT_LOG_SIZE {
+		err = dm.checkpoint()
+		if err != nil {
+			return fmt.Errorf("commit(): %w", err)
+		}
+	}
+
+	return nil
+}
+
+// applyCommitted применяет блоки зафиксированной транзакции и удаляет файлы удаленных таблиц
+func (dm *diskManager) applyCommitted() error {
+	dm.txMu.Lock()
+	err := dm.applyBlocks()
+	if err != nil {
+		// Транзакция уже в журнале: файлы догонят ее при восстановлении
+		dm.failed = err
+		dm.txMu.Unlock()
+		return err
+	}
+	dropped := dm.tx.dropped
+	dm.tx = nil
+	dm.txMu.Unlock()
+
+	for table := range dropped {
+		dm.closeTableFile(table)
+
+		err := os.RemoveAll(filepath.Join(dm.cfg.DBPath, table))
+		if err != nil {
+			// Запись об удалении уже в журнале, восстановление удалит таблицу повторно
+			dm.failed = err
+			return fmt.Errorf("os.RemoveAll: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// applyBlocks переносит блоки зафиксированной транзакции в буферный пул, txMu должен быть захвачен.
+// В файл они пишутся при вытеснении или checkpoint: журнал уже на диске, поэтому вытеснить их можно в любой момент
+func (dm *diskManager) applyBlocks() error {
+	for table, blocks := range dm.tx.blocks {
+		file, err := dm.tableFileForApply(table)
+		if err != nil {
+			return err
+		}
+
+		for _, block := range sortedBlocks(blocks) {
+			id := buffer_pool.PageID{File: table, Page: block}
+			frame, err := dm.pool.FetchPage(file, id)
+			if err != nil {
+				return err
+			}
+			copy(frame.Data, blocks[block])
+			frame.Size = BLOCK_SIZE
+
+			err = dm.pool.UnpinPage(id, true)
+			if err != nil {
+				return err
+			}
+		}
+
+		dm.dirty[table] = true
+	}
+
+	return nil
+}
+
+// abort отбрасывает изменения транзакции. Метаданные таблиц, которые она меняла,
+// перечитываются с диска, чтобы в памяти не остались ее страницы
+func (dm *diskManager) abort() {
+	dm.txMu.Lock()
+	tx := dm.tx
+	dm.tx = nil
+	dm.txMu.Unlock()
+
+	if tx == nil {
+		return
+	}
+
+	tables := []string{}
+	for table := range tx.blocks {
+		tables = append(tables, table)
+	}
+	for table := range tx.dropped {
+		tables = append(tables, table)
+	}
+
+	dm.mu.Lock()
+	for _, table := range tables {
+		delete(dm.tables, table)
+	}
+	dm.mu.Unlock()
+
+	if !tx.logged {
+		for _, undo := range tx.onAbort {
+			undo()
+		}
+	}
+}
+
+// checkpoint записывает измененные страницы из буферного пула в файлы таблиц,
+// сбрасывает файлы на диск и очищает журнал
+func (dm *diskManager) checkpoint() error {
+	for table := range dm.dirty {
+		file, err := dm.tableFileForApply(table)
+		if err != nil {
+			dm.failed = err
+			return fmt.Errorf("checkpoint(): %w", err)
+		}
+
+		err = dm.pool.FlushFile(table)
+		if err != nil {
+			dm.failed = err
+			return fmt.Errorf("checkpoint(): %w", err)
+		}
+
+		err = file.Sync()
+		if err != nil {
+			dm.failed = err
+			return fmt.Errorf("checkpoint(): file.Sync: %w", err)
+		}
+	}
+
+	err := dm.log.Reset()
+	if err != nil {
+		dm.failed = err
+		return fmt.Errorf("checkpoint(): log.Reset: %w", err)
+	}
+	dm.dirty = map[string]bool{}
+
+	return nil
+}
+
+// tableFileForApply возвращает открытый файл таблицы для записи зафиксированных изменений
+func (dm *diskManager) tableFileForApply(table string) (wal.File, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if tf, ok := dm.files[table]; ok {
+		return tf, nil
+	}
+
+	return nil, fmt.Errorf("table file is not open: %s", table)
+}
@@ -0,0 +1,202 @@
+package disk_manager
+
+import (
+	"custom-database/internal/disk_manager/data"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// VACUUM_THRESHOLD - количество мусорных версий строк в таблице, после которого
+// завершение транзакции запускает сборку мусора в этой таблице
+const VACUUM_THRESHOLD = 256
+
+// Vacuum собирает мусор во всех таблицах: удаляет версии строк, которые уже не видит ни одна
+// транзакция, и файлы таблиц, удаление которых видят все снимки
+func (dm *diskManager) Vacuum() error {
+	entries, err := os.ReadDir(dm.cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("Vacuum(): os.ReadDir: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || isSystemTable(entry.Name()) {
+			continue
+		}
+
+		err := dm.vacuumTable(entry.Name())
+		if err != nil && !errors.Is(err, errTableDoesNotExist) {
+			return fmt.Errorf("Vacuum(): %w", err)
+		}
+	}
+
+	return nil
+}
+
+// collectGarbage вызывается после завершения транзакции: удаляет файлы таблиц, удаление которых
+// видят все снимки, и чистит таблицы, в которых накопилось VACUUM_THRESHOLD мусорных версий.
+// Ошибки не возвращаются: транзакция уже завершена, а мусор соберется при следующей попытке
+func (dm *diskManager) collectGarbage() {
+	horizon := dm.horizon()
+
+	dm.xactMu.Lock()
+	dropped := []string{}
+	for table, xmax := range dm.droppedTables {
+		if xmax < horizon {
+			dropped = append(dropped, table)
+		}
+	}
+	tables := []string{}
+	for table, versions := range dm.deadVersions {
+		if versions >= VACUUM_THRESHOLD {
+			tables = append(tables, table)
+			delete(dm.deadVersions, table)
+		}
+	}
+	dm.xactMu.Unlock()
+
+	for _, table := range dropped {
+		dm.removeTable(table)
+	}
+	for _, table := range tables {
+		dm.vacuumTable(table)
+	}
+}
+
+// vacuumTable удаляет из таблицы версии строк, которые уже не видит ни одна транзакция,
+// а если не видна сама таблица - ее файлы. Каждая страница чистится в своей физической транзакции
+func (dm *diskManager) vacuumTable(tableName string) error {
+	dm.vacuumMu.Lock()
+	defer dm.vacuumMu.Unlock()
+
+	table, err := dm.openTable(tableName)
+	if err != nil {
+		return fmt.Errorf("vacuumTable(): %w", err)
+	}
+
+	horizon := dm.horizon()
+	xmin, xmax := dm.versions(table)
+	if dm.isDead(xmin, xmax, horizon) {
+		return dm.removeTable(tableName)
+	}
+
+	it, err := table.reader.Scan()
+	if err != nil {
+		return fmt.Errorf("vacuumTable(): data.Scan: %w", err)
+	}
+
+	dead := map[uint32][]data.DataRow{}
+	for it.Next() {
+		row := it.Row()
+		if dm.isDead(row.Xmin, row.Xmax, horizon) {
+			dead[row.PageId] = append(dead[row.PageId], row)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("vacuumTable(): %w", err)
+	}
+
+	pages := make([]uint32, 0, len(dead))
+	for pageID := range dead {
+		pages = append(pages, pageID)
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i] < pages[j] })
+
+	// Мертвая версия не может снова стать видимой, а ее слот освобождает только сборка мусора,
+	// поэтому между сканом и удалением адреса версий не меняются
+	for _, pageID := range pages {
+		err := dm.inTransaction(func() error {
+			table, err := dm.openTable(tableName)
+			if err != nil {
+				return err
+			}
+
+			for _, row := range dead[pageID] {
+				err := table.data.DeleteDataRow(pageID, row.SlotId)
+				if err != nil {
+					return fmt.Errorf("data.DeleteDataRow: %w", err)
+				}
+			}
+
+			return dm.unindexRows(table, dead[pageID])
+		})
+		if err != nil {
+			return fmt.Errorf("vacuumTable(): %w", err)
+		}
+	}
+
+	return nil
+}
+
+// removeDeadTable удаляет файлы таблицы tableName, если она есть на диске, но ее уже не видит ни одна транзакция
+func (dm *diskManager) removeDeadTable(tableName string) error {
+	if _, err := os.Stat(filepath.Join(dm.cfg.DBPath, tableName)); err != nil {
+		return nil
+	}
+
+	table, err := dm.openTable(tableName)
+	if err != nil {
+		return fmt.Errorf("removeDeadTable(): %w", err)
+	}
+
+	xmin, xmax := dm.versions(table)
+	if !dm.isDead(xmin, xmax, dm.horizon()) {
+		return nil
+	}
+
+	return dm.removeTable(tableName)
+}
+
+// removeTable удаляет файлы таблицы, которую уже не видит ни одна транзакция. Удаление проходит
+// через журнал, чтобы восстановление не вернуло таблицу, повторив ее старые изменения
+func (dm *diskManager) removeTable(tableName string) error {
+	err := dm.inTransaction(func() error {
+		dm.txMu.Lock()
+		dm.tx.dropped[tableName] = true
+		dm.txMu.Unlock()
+
+		err := dm.removeSchemas(tableName)
+		if err != nil {
+			return err
+		}
+
+		return dm.removeIndexes(func(idx *index) bool { return idx.Table == tableName })
+	})
+	if err != nil {
+		return fmt.Errorf("removeTable(): %w", err)
+	}
+
+	dm.xactMu.Lock()
+	delete(dm.writers, tableName)
+	delete(dm.deadVersions, tableName)
+	delete(dm.droppedTables, tableName)
+	dm.xactMu.Unlock()
+
+	return nil
+}
+
+// horizon возвращает номер транзакции, меньше которого нет ни одной транзакции, незавершенной
+// для какого-нибудь открытого снимка: удаление, зафиксированное до горизонта, видят все
+func (dm *diskManager) horizon() uint64 {
+	dm.xactMu.RLock()
+	defer dm.xactMu.RUnlock()
+
+	horizon := dm.nextXid
+	for tx := range dm.active {
+		horizon = min(horizon, tx.snapshot.xmin)
+	}
+
+	return horizon
+}
+
+// isDead сообщает, что версию строки или таблицы не увидит ни одна транзакция:
+// ее создала прерванная транзакция или удалила зафиксированная до горизонта
+func (dm *diskManager) isDead(xmin, xmax, horizon uint64) bool {
+	if dm.status(xmin) == txAborted {
+		return true
+	}
+
+	return xmax != 0 && xmax < horizon && dm.status(xmax) == txCommitted
+}
@@ -0,0 +1,276 @@
+package wal
+
+import (
+	bs "custom-database/internal/disk_manager/binary_serializer"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// Журнал предзаписи (write-ahead log).
+// Формат файла - последовательность записей:
+// [4 байта длина payload][4 байта crc32 payload][payload]
+// payload: [1 байт тип][8 байт id транзакции] и для PageRecord
+// [имя таблицы][8 байт смещение в файле таблицы][4 байта длина данных][данные],
+// для DropTableRecord - [имя таблицы]
+
+type RecordType uint8
+
+const (
+	PageRecord      RecordType = iota + 1 // новое содержимое участка файла таблицы
+	CommitRecord                          // транзакция зафиксирована
+	DropTableRecord                       // таблица удалена вместе с ее файлом
+)
+
+const (
+	RECORD_LENGTH_SIZE   = 4
+	RECORD_CHECKSUM_SIZE = 4
+	RECORD_HEADER_SIZE   = RECORD_LENGTH_SIZE + RECORD_CHECKSUM_SIZE
+
+	RECORD_TYPE_SIZE   = 1
+	RECORD_TX_ID_SIZE  = 8
+	RECORD_OFFSET_SIZE = 8
+	RECORD_DATA_SIZE   = 4
+)
+
+type Record struct {
+	Type   RecordType
+	TxID   uint64
+	Table  string
+	Offset int64
+	Data   []byte
+}
+
+// File - операции над файлом, которые нужны журналу и страницам таблиц.
+// *os.File ему удовлетворяет, а тесты подменяют его, чтобы имитировать падение процесса
+type File interface {
+	io.ReaderAt
+	io.WriterAt
+	Sync() error
+	Truncate(size int64) error
+	Stat() (os.FileInfo, error)
+	Close() error
+}
+
+// OpenFileFunc открывает файл так же, как os.OpenFile
+type OpenFileFunc func(name string, flag int, perm os.FileMode) (File, error)
+
+// OpenOSFile - OpenFileFunc поверх файловой системы
+func OpenOSFile(name string, flag int, perm os.FileMode) (File, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+type LogService interface {
+	// Append дописывает записи в конец журнала одной записью в файл и делает fsync
+	Append(records []Record) error
+	// ReadAll возвращает все целые записи журнала. Чтение останавливается на первой
+	// недописанной или поврежденной записи - это хвост, который не успел попасть на диск
+	ReadAll() ([]Record, error)
+	// Reset очищает журнал, когда все его изменения уже сброшены в файлы таблиц
+	Reset() error
+	Size() int64
+	Close() error
+}
+
+type log struct {
+	file File
+	size int64
+	// err - ошибка записи в журнал. После нее неизвестно, что осталось в файле,
+	// поэтому журнал перестает принимать записи до перезапуска и восстановления
+	err error
+}
+
+func NewLog(file File) (LogService, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("NewLog(): file.Stat: %w", err)
+	}
+
+	return &log{
+		file: file,
+		size: info.Size(),
+	}, nil
+}
+
+func (l *log) Append(records []Record) error {
+	if l.err != nil {
+		return fmt.Errorf("Append(): log is unusable after failed write: %w", l.err)
+	}
+
+	buffer := []byte{}
+	for _, record := range records {
+		buffer = append(buffer, encodeRecord(record)...)
+	}
+
+	_, err := l.file.WriteAt(buffer, l.size)
+	if err != nil {
+		l.err = err
+		return fmt.Errorf("Append(): file.WriteAt: %w", err)
+	}
+
+	err = l.file.Sync()
+	if err != nil {
+		l.err = err
+		return fmt.Errorf("Append(): file.Sync: %w", err)
+	}
+
+	l.size += int64(len(buffer))
+
+	return nil
+}
+
+func (l *log) ReadAll() ([]Record, error) {
+	data, err := io.ReadAll(io.NewSectionReader(l.file, 0, l.size))
+	if err != nil {
+		return nil, fmt.Errorf("ReadAll(): io.ReadAll: %w", err)
+	}
+
+	records := []Record{}
+	offset := 0
+	for offset+RECORD_HEADER_SIZE <= len(data) {
+		length := int(bs.ReadUint32(data, offset))
+		checksum := bs.ReadUint32(data, offset+RECORD_LENGTH_SIZE)
+
+		start := offset + RECORD_HEADER_SIZE
+		if length > len(data)-start {
+			break
+		}
+
+		payload := data[start : start+length]
+		if crc32.ChecksumIEEE(payload) != checksum {
+			break
+		}
+
+		record, err := decodeRecord(payload)
+		if err != nil {
+			break
+		}
+
+		records = append(records, record)
+		offset = start + length
+	}
+
+	return records, nil
+}
+
+func (l *log) Reset() error {
+	if l.err != nil {
+		return fmt.Errorf("Reset(): log is unusable after failed write: %w", l.err)
+	}
+
+	err := l.file.Truncate(0)
+	if err != nil {
+		l.err = err
+		return fmt.Errorf("Reset(): file.Truncate: %w", err)
+	}
+
+	err = l.file.Sync()
+	if err != nil {
+		l.err = err
+		return fmt.Errorf("Reset(): file.Sync: %w", err)
+	}
+
+	l.size = 0
+
+	return nil
+}
+
+func (l *log) Size() int64 {
+	return l.size
+}
+
+func (l *log) Close() error {
+	return l.file.Close()
+}
+
+func encodeRecord(record Record) []byte {
+	payloadSize := RECORD_TYPE_SIZE + RECORD_TX_ID_SIZE
+	switch record.Type {
+	case PageRecord:
+		payloadSize += bs.TEXT_TYPE_HEADER + len(record.Table) + RECORD_OFFSET_SIZE + RECORD_DATA_SIZE + len(record.Data)
+	case DropTableRecord:
+		payloadSize += bs.TEXT_TYPE_HEADER + len(record.Table)
+	}
+
+	buffer := make([]byte, RECORD_HEADER_SIZE+payloadSize)
+	payload := buffer[RECORD_HEADER_SIZE:]
+
+	bs.WriteUint8(payload, 0, uint8(record.Type))
+	bs.WriteUint64(payload, RECORD_TYPE_SIZE, record.TxID)
+
+	if record.Type == PageRecord {
+		offset := RECORD_TYPE_SIZE + RECORD_TX_ID_SIZE
+		offset += bs.WriteString(payload, offset, record.Table)
+		bs.WriteInt64(payload, offset, record.Offset)
+		offset += RECORD_OFFSET_SIZE
+		bs.WriteUint32(payload, offset, uint32(len(record.Data)))
+		offset += RECORD_DATA_SIZE
+		copy(payload[offset:], record.Data)
+	}
+
+	if record.Type == DropTableRecord {
+		bs.WriteString(payload, RECORD_TYPE_SIZE+RECORD_TX_ID_SIZE, record.Table)
+	}
+
+	bs.WriteUint32(buffer, 0, uint32(payloadSize))
+	bs.WriteUint32(buffer, RECORD_LENGTH_SIZE, crc32.ChecksumIEEE(payload))
+
+	return buffer
+}
+
+func decodeRecord(payload []byte) (Record, error) {
+	if len(payload) < RECORD_TYPE_SIZE+RECORD_TX_ID_SIZE {
+		return Record{}, errors.New("record is too short")
+	}
+
+	record := Record{
+		Type: RecordType(bs.ReadUint8(payload, 0)),
+		TxID: bs.ReadUint64(payload, RECORD_TYPE_SIZE),
+	}
+
+	switch record.Type {
+	case CommitRecord:
+		return record, nil
+	case PageRecord:
+		offset := RECORD_TYPE_SIZE + RECORD_TX_ID_SIZE
+		if len(payload) < offset+bs.TEXT_TYPE_HEADER {
+			return Record{}, errors.New("record is too short")
+		}
+		tableNameSize := int(bs.ReadInt32(payload, offset))
+		if tableNameSize < 0 || len(payload) < offset+bs.TEXT_TYPE_HEADER+tableNameSize+RECORD_OFFSET_SIZE+RECORD_DATA_SIZE {
+			return Record{}, errors.New("record is too short")
+		}
+
+		table, n := bs.ReadString(payload, offset)
+		offset += n
+		record.Table = table
+		record.Offset = bs.ReadInt64(payload, offset)
+		offset += RECORD_OFFSET_SIZE
+
+		dataSize := int(bs.ReadUint32(payload, offset))
+		offset += RECORD_DATA_SIZE
+		if len(payload) != offset+dataSize {
+			return Record{}, errors.New("record data size mismatch")
+		}
+		record.Data = append([]byte{}, payload[offset:]...)
+
+		return record, nil
+	case DropTableRecord:
+		offset := RECORD_TYPE_SIZE + RECORD_TX_ID_SIZE
+		if len(payload) < offset+bs.TEXT_TYPE_HEADER {
+			return Record{}, errors.New("record is too short")
+		}
+		tableNameSize := int(bs.ReadInt32(payload, offset))
+		if tableNameSize < 0 || len(payload) != offset+bs.TEXT_TYPE_HEADER+tableNameSize {
+			return Record{}, errors.New("record table name size mismatch")
+		}
+
+		record.Table, _ = bs.ReadString(payload, offset)
+
+		return record, nil
+	}
+
+	return Record{}, fmt.Errorf("unknown record type %d", record.Type)
+}
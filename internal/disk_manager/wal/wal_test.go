@@ -0,0 +1,110 @@
+package wal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	openLog := func(t *testing.T, path string) (LogService, *os.File) {
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+		require.NoError(t, err)
+		t.Cleanup(func() { file.Close() })
+
+		log, err := NewLog(file)
+		require.NoError(t, err)
+
+		return log, file
+	}
+
+	records := []Record{
+		{Type: PageRecord, TxID: 1, Table: "users", Offset: 4096, Data: []byte{1, 2, 3}},
+		{Type: PageRecord, TxID: 1, Table: "orders", Offset: 0, Data: []byte{}},
+		{Type: CommitRecord, TxID: 1},
+	}
+
+	t.Run("записи читаются после переоткрытия", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "wal.log")
+
+		log, _ := openLog(t, path)
+		require.NoError(t, log.Append(records[:2]))
+		require.NoError(t, log.Append(records[2:]))
+
+		reopened, _ := openLog(t, path)
+		assert.Equal(t, log.Size(), reopened.Size())
+
+		got, err := reopened.ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, records, got)
+	})
+
+	t.Run("запись об удалении таблицы", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "wal.log")
+		dropped := []Record{
+			{Type: DropTableRecord, TxID: 2, Table: "users"},
+			{Type: CommitRecord, TxID: 2},
+		}
+
+		log, _ := openLog(t, path)
+		require.NoError(t, log.Append(dropped))
+
+		reopened, _ := openLog(t, path)
+		got, err := reopened.ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, dropped, got)
+	})
+
+	t.Run("недописанный хвост отбрасывается", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "wal.log")
+
+		log, file := openLog(t, path)
+		require.NoError(t, log.Append(records))
+
+		// Обрезаем последнюю запись посередине, как при падении во время записи
+		require.NoError(t, file.Truncate(log.Size()-5))
+
+		reopened, _ := openLog(t, path)
+		got, err := reopened.ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, records[:2], got)
+	})
+
+	t.Run("поврежденная запись и все после нее отбрасываются", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "wal.log")
+
+		log, file := openLog(t, path)
+		require.NoError(t, log.Append(records[:1]))
+		firstSize := log.Size()
+		require.NoError(t, log.Append(records[1:]))
+
+		_, err := file.WriteAt([]byte{0xFF}, firstSize+RECORD_HEADER_SIZE+2)
+		require.NoError(t, err)
+
+		reopened, _ := openLog(t, path)
+		got, err := reopened.ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, records[:1], got)
+	})
+
+	t.Run("reset очищает журнал", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "wal.log")
+
+		log, _ := openLog(t, path)
+		require.NoError(t, log.Append(records))
+		require.NoError(t, log.Reset())
+		assert.Equal(t, int64(0), log.Size())
+
+		got, err := log.ReadAll()
+		require.NoError(t, err)
+		assert.Empty(t, got)
+
+		require.NoError(t, log.Append(records[2:]))
+		got, err = log.ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, records[2:], got)
+	})
+}
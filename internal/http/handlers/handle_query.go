@@ -14,7 +14,9 @@ type SqlQueryRequest struct {
 type SqlQueryResponse struct {
 	Success bool   `json:"success" example:"true"`
 	Result  string `json:"result,omitempty" example:"Query executed successfully"`
-	Error   string `json:"error,omitempty" example:"Invalid SQL syntax"`
+	// RowsAffected - количество строк, затронутых INSERT/UPDATE/DELETE
+	RowsAffected *int   `json:"rows_affected,omitempty" example:"3"`
+	Error        string `json:"error,omitempty" example:"Invalid SQL syntax"`
 }
 
 // HandleSqlQuery обрабатывает HTTP запросы для выполнения SQL запросов
@@ -58,16 +60,21 @@ func (h *handlers) HandleSqlQuery(c *gin.Context) {
 		return
 	}
 
-	if result == nil {
-		c.JSON(200, SqlQueryResponse{
+	if result == nil || result.Table == nil {
+		response := SqlQueryResponse{
 			Success: true,
 			Result:  "Query executed successfully",
-		})
+		}
+		if result != nil && result.Command != "" {
+			response.RowsAffected = &result.RowsAffected
+		}
+
+		c.JSON(200, response)
 		return
 	}
 
 	// Конвертируем результат в JSON строку
-	jsonResult, err := convertToJson(result)
+	jsonResult, err := convertToJson(result.Table)
 	if err != nil {
 		c.JSON(400, SqlQueryResponse{
 			Success: false,
@@ -99,17 +106,18 @@ func convertToJson(table *models.Table) (string, error) {
 		jsonRow := []interface{}{}
 
 		for i, cell := range row {
+			if cell.IsNull() {
+				jsonRow = append(jsonRow, nil)
+				continue
+			}
+
 			typ := table.Columns[i].Type
 			var s interface{}
 			switch typ {
 			case models.IntType:
 				s = cell.AsInt()
 			case models.TextType:
-				if cell.IsNull() {
-					s = nil
-				} else {
-					s = cell.AsText()
-				}
+				s = cell.AsText()
 			case models.BoolType:
 				s = cell.AsBoolean()
 			case models.TimestampType:
@@ -0,0 +1,22 @@
+package handlers
+
+import "github.com/gin-gonic/gin"
+
+type StatsResponse struct {
+	// Stats - счетчики движка хранения, например попадания и промахи буферного пула
+	Stats map[string]uint64 `json:"stats"`
+}
+
+// HandleStats отдает счетчики движка хранения
+// @Summary Счетчики движка хранения
+// @Description Возвращает счетчики движка хранения: попадания, промахи, вытеснения и записи буферного пула disk_manager
+// @Tags stats
+// @Produce json
+// @Success 200 {object} StatsResponse
+// @Router /stats [get]
+
+func (h *handlers) HandleStats(c *gin.Context) {
+	c.JSON(200, StatsResponse{
+		Stats: h.mb.Stats(),
+	})
+}
@@ -9,6 +9,7 @@ import (
 
 type HttpHandlers interface {
 	HandleSqlQuery(c *gin.Context)
+	HandleStats(c *gin.Context)
 }
 
 type handlers struct {
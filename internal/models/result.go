@@ -0,0 +1,16 @@
+package models
+
+// Команды, для которых клиенту возвращается количество затронутых строк
+const (
+	InsertCommand = "INSERT"
+	UpdateCommand = "UPDATE"
+	DeleteCommand = "DELETE"
+)
+
+// Result - результат выполнения запроса: таблица для SELECT
+// или команда и количество затронутых строк для DML
+type Result struct {
+	Table        *Table
+	Command      string
+	RowsAffected int
+}
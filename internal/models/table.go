@@ -5,6 +5,17 @@ type ColumnType uint
 type Column struct {
 	Name string     `json:"name"`
 	Type ColumnType `json:"type"`
+	// NotNull - колонка объявлена с ограничением NOT NULL
+	NotNull bool `json:"not_null,omitempty"`
+	// Default - значение DEFAULT в том виде, как оно записано в запросе, или current_timestamp.
+	// Пустая строка - значение по умолчанию не задано
+	Default string `json:"default,omitempty"`
+}
+
+// Check - ограничение CHECK таблицы. Expression хранится текстом и разбирается заново при проверке строк
+type Check struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
 }
 
 const (
@@ -14,6 +25,9 @@ const (
 	TimestampType
 )
 
+// TimestampFormat - формат, в котором TIMESTAMP приходит из запроса и отдается клиенту
+const TimestampFormat = "2006-01-02 15:04:05"
+
 type Cell interface {
 	AsText() string
 	AsInt() int32
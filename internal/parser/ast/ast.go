@@ -80,6 +80,24 @@ func parseStatement(tokens []*lex.Token, initialCursor uint) (*Statement, uint,
 		}, newCursor, true
 	}
 
+	// Look for a CREATE INDEX statement
+	crtIdx, newCursor, ok := parseCreateIndexStatement(tokens, cursor)
+	if ok {
+		return &Statement{
+			Kind:                 CreateIndexKind,
+			CreateIndexStatement: crtIdx,
+		}, newCursor, true
+	}
+
+	// Look for a DROP INDEX statement
+	dropIdx, newCursor, ok := parseDropIndexStatement(tokens, cursor)
+	if ok {
+		return &Statement{
+			Kind:               DropIndexKind,
+			DropIndexStatement: dropIdx,
+		}, newCursor, true
+	}
+
 	// Look for a DROP statement
 	dropTbl, newCursor, ok := parseDropTableStatement(tokens, cursor)
 	if ok {
@@ -89,5 +107,40 @@ func parseStatement(tokens []*lex.Token, initialCursor uint) (*Statement, uint,
 		}, newCursor, true
 	}
 
+	// Look for a ALTER TABLE statement
+	alterTbl, newCursor, ok := parseAlterTableStatement(tokens, cursor)
+	if ok {
+		return &Statement{
+			Kind:                AlterTableKind,
+			AlterTableStatement: alterTbl,
+		}, newCursor, true
+	}
+
+	// Look for a DELETE statement
+	dlt, newCursor, ok := parseDeleteStatement(tokens, cursor)
+	if ok {
+		return &Statement{
+			Kind:            DeleteKind,
+			DeleteStatement: dlt,
+		}, newCursor, true
+	}
+
+	// Look for a UPDATE statement
+	upd, newCursor, ok := parseUpdateStatement(tokens, cursor)
+	if ok {
+		return &Statement{
+			Kind:            UpdateKind,
+			UpdateStatement: upd,
+		}, newCursor, true
+	}
+
+	// Look for a BEGIN, COMMIT or ROLLBACK statement
+	kind, newCursor, ok := parseTransactionStatement(tokens, cursor)
+	if ok {
+		return &Statement{
+			Kind: kind,
+		}, newCursor, true
+	}
+
 	return nil, initialCursor, false
 }
@@ -47,9 +47,55 @@ outer:
 	return &exps, cursor, true
 }
 
+// parseColumnNames разбирает непустой список имен колонок через запятую до endDelimiter
+func parseColumnNames(tokens []*lex.Token, initialCursor uint, endDelimiter lex.Token) ([]lex.Token, uint, bool) {
+	cursor := initialCursor
+
+	columns := []lex.Token{}
+	for {
+		if cursor >= uint(len(tokens)) {
+			return nil, initialCursor, false
+		}
+
+		// Look for a delimiter
+		if endDelimiter.Equals(tokens[cursor]) && len(columns) > 0 {
+			break
+		}
+
+		// Look for a comma
+		if len(columns) > 0 {
+			if !expectToken(tokens, cursor, tokenFromSymbol(lex.CommaSymbol)) {
+				helpMessage(tokens, cursor, "Expected comma")
+				return nil, initialCursor, false
+			}
+
+			cursor++
+		}
+
+		// Look for a column name
+		column, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected column name")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		columns = append(columns, *column)
+	}
+
+	return columns, cursor, true
+}
+
 func parseExpression(tokens []*lex.Token, initialCursor uint, _ lex.Token) (*Expression, uint, bool) {
 	cursor := initialCursor
 
+	if expectToken(tokens, cursor, tokenFromKeyword(lex.DefaultKeyword)) {
+		return &Expression{
+			Literal: tokens[cursor],
+			Kind:    DefaultKind,
+		}, cursor + 1, true
+	}
+
 	kinds := []lex.TokenKind{lex.DateToken, lex.IdentifierToken, lex.NumericToken, lex.StringToken, lex.BooleanToken, lex.NullToken}
 	for _, kind := range kinds {
 		t, newCursor, ok := parseToken(tokens, cursor, kind)
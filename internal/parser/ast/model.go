@@ -13,6 +13,15 @@ const (
 	CreateTableKind
 	InsertKind
 	DropTableKind
+	DeleteKind
+	UpdateKind
+	// BEGIN, COMMIT и ROLLBACK не имеют тела, поэтому у них нет отдельного поля в Statement
+	BeginKind
+	CommitKind
+	RollbackKind
+	CreateIndexKind
+	DropIndexKind
+	AlterTableKind
 )
 
 type Statement struct {
@@ -20,6 +29,11 @@ type Statement struct {
 	CreateTableStatement *CreateTableStatement
 	InsertStatement      *InsertStatement
 	DropTableStatement   *DropTableStatement
+	DeleteStatement      *DeleteStatement
+	UpdateStatement      *UpdateStatement
+	CreateIndexStatement *CreateIndexStatement
+	DropIndexStatement   *DropIndexStatement
+	AlterTableStatement  *AlterTableStatement
 	Kind                 AstKind
 }
 
@@ -27,37 +41,258 @@ type ExpressionKind uint
 
 const (
 	LiteralKind ExpressionKind = iota
+	// DefaultKind - ключевое слово DEFAULT вместо значения в INSERT и UPDATE
+	DefaultKind
+	// AggregateKind - агрегатная функция в списке SELECT
+	AggregateKind
+	// SubqueryKind - скалярный подзапрос (SELECT ...) в списке SELECT
+	SubqueryKind
+	// ArithmeticKind - операция + - * / над двумя значениями в UPDATE ... SET
+	ArithmeticKind
 )
 
 type Expression struct {
 	Literal *lex.Token
 	Kind    ExpressionKind
+	// Aggregate - вызов функции для AggregateKind
+	Aggregate *AggregateCall
+	// Subquery - подзапрос для SubqueryKind
+	Subquery *SelectStatement
+	// Arithmetic - операция для ArithmeticKind, Literal - ее оператор
+	Arithmetic *ArithmeticExpression
+	// Alias - имя колонки результата из expression AS name или nil
+	Alias *lex.Token
+}
+
+// ArithmeticExpression - Left operator Right, где операнды - литералы, колонки или другие операции
+type ArithmeticExpression struct {
+	Left  *Expression
+	Right *Expression
+}
+
+// AggregateCall - COUNT(*) или COUNT | SUM | AVG | MIN | MAX ([DISTINCT] column)
+type AggregateCall struct {
+	Function lex.Token
+	// Argument - колонка или nil для COUNT(*)
+	Argument *lex.Token
+	Distinct bool
+}
+
+// String печатает вызов в нижнем регистре: count(*), sum(distinct score). Этим текстом вызов
+// называется в условии HAVING
+func (c *AggregateCall) String() string {
+	if c.Argument == nil {
+		return c.Function.Value + "(*)"
+	}
+	if c.Distinct {
+		return c.Function.Value + "(distinct " + c.Argument.Value + ")"
+	}
+
+	return c.Function.Value + "(" + c.Argument.Value + ")"
 }
 
 type CreateTableStatement struct {
 	Name lex.Token
-	Cols *[]*columnDefinition
+	Cols *[]*ColumnDefinition
+	// Constraints - ограничения уровня таблицы: PRIMARY KEY (a, b), UNIQUE (a, b), CHECK (...), FOREIGN KEY (a, b) REFERENCES ...
+	Constraints []*ConstraintDefinition
+	// AsSelect - запрос из CREATE TABLE name AS SELECT ...: колонки таблицы и ее строки берутся из его результата.
+	// Cols и Constraints при этом пустые
+	AsSelect *SelectStatement
+}
+
+type ConstraintKind uint
+
+const (
+	PrimaryKeyConstraint ConstraintKind = iota
+	UniqueConstraint
+	NotNullConstraint
+	DefaultConstraint
+	CheckConstraint
+	ForeignKeyConstraint
+)
+
+// ReferentialAction - действие внешнего ключа при удалении или изменении строки, на которую он ссылается
+type ReferentialAction uint
+
+const (
+	// RestrictAction - действие по умолчанию: удаление или изменение строки завершается ошибкой
+	RestrictAction ReferentialAction = iota
+	CascadeAction
+	SetNullAction
+)
+
+// ConstraintDefinition - [CONSTRAINT name] PRIMARY KEY | UNIQUE | NOT NULL | DEFAULT value | CHECK (condition) |
+// REFERENCES table [(columns)] [ON DELETE action] [ON UPDATE action].
+// У ограничения колонки Columns пустой: оно относится к самой колонке.
+// NOT NULL и DEFAULT бывают только у колонки, у внешнего ключа таблицы колонки задает FOREIGN KEY (columns)
+type ConstraintDefinition struct {
+	// Name - имя из CONSTRAINT name или nil, если имя не задано
+	Name    *lex.Token
+	Kind    ConstraintKind
+	Columns []lex.Token
+	// Default - литерал или CURRENT_TIMESTAMP из DEFAULT
+	Default *lex.Token
+	// Check - условие CHECK, CheckText - оно же текстом, который разбирает ParseCondition
+	Check     *WhereClause
+	CheckText string
+	// References и ReferencedColumns - таблица и колонки, на которые ссылается внешний ключ.
+	// Без списка колонок внешний ключ ссылается на первичный ключ таблицы
+	References        *lex.Token
+	ReferencedColumns []lex.Token
+	OnDelete          ReferentialAction
+	OnUpdate          ReferentialAction
 }
 
+// DropTableStatement - DROP TABLE name [CASCADE]. С CASCADE вместе с таблицей удаляются
+// внешние ключи других таблиц, которые на нее ссылаются
 type DropTableStatement struct {
-	Table lex.Token
+	Table   lex.Token
+	Cascade bool
 }
 
-type InsertStatement struct {
+// AlterAction - изменение таблицы в ALTER TABLE
+type AlterAction uint
+
+const (
+	AddColumnAction AlterAction = iota
+	DropColumnAction
+	RenameColumnAction
+	RenameTableAction
+	AlterColumnTypeAction
+)
+
+// AlterTableStatement - ALTER TABLE table ADD [COLUMN] column type [constraints] | DROP [COLUMN] column |
+// RENAME [COLUMN] column TO name | RENAME TO name | ALTER [COLUMN] column TYPE type
+type AlterTableStatement struct {
 	Table  lex.Token
-	Values *[]*Expression
+	Action AlterAction
+	// Column - добавляемая колонка для ADD
+	Column *ColumnDefinition
+	// ColumnName - колонка, которую удаляют, переименовывают или у которой меняют тип
+	ColumnName lex.Token
+	// NewName - новое имя колонки или таблицы для RENAME
+	NewName lex.Token
+	// Datatype - новый тип колонки для ALTER COLUMN TYPE
+	Datatype lex.Token
+}
+
+// CreateIndexStatement - CREATE INDEX name ON table (column [, ...])
+type CreateIndexStatement struct {
+	Name    lex.Token
+	Table   lex.Token
+	Columns []lex.Token
+}
+
+type DropIndexStatement struct {
+	Name lex.Token
+}
+
+type InsertStatement struct {
+	Table lex.Token
+	// Columns - колонки, которым по порядку соответствуют значения строк, или nil - все колонки таблицы
+	Columns []lex.Token
+	// Rows - строки из VALUES, записываемые одним выражением
+	Rows []*[]*Expression
+	// Select - запрос, строки которого записываются вместо VALUES, или nil
+	Select *SelectStatement
+}
+
+type DeleteStatement struct {
+	Table lex.Token
+	Where *WhereClause
+}
+
+type UpdateStatement struct {
+	Table lex.Token
+	Set   []*SetClause
+	Where *WhereClause
+}
+
+// SetClause - одно присваивание column = value из UPDATE ... SET
+type SetClause struct {
+	Column lex.Token
+	Value  *Expression
 }
 
 type SelectStatement struct {
 	SelectedColumns []*Expression
 	From            lex.Token
-	Where           *WhereClause
-	Limit           int
-	Offset          int
+	// FromAlias - псевдоним таблицы From или nil
+	FromAlias *lex.Token
+	// FromSubquery - подзапрос из FROM (SELECT ...) [AS] alias вместо таблицы From или nil.
+	// Псевдоним у него обязателен
+	FromSubquery *SelectStatement
+	// Joins - таблицы, которые соединяются с From слева направо
+	Joins []*JoinClause
+	Where *WhereClause
+	// GroupBy - колонки GROUP BY, Having - условие HAVING по группам
+	GroupBy []lex.Token
+	Having  *WhereClause
+	OrderBy []*OrderByClause
+	Limit   int
+	Offset  int
+}
+
+type JoinKind uint
+
+const (
+	InnerJoin JoinKind = iota
+	LeftJoin
+	RightJoin
+	FullJoin
+	// CrossJoin - CROSS JOIN или таблица через запятую
+	CrossJoin
+)
+
+// JoinClause - [INNER] JOIN | LEFT [OUTER] JOIN | RIGHT [OUTER] JOIN | FULL [OUTER] JOIN table [[AS] alias]
+// ON condition | USING (column [, ...]), а также CROSS JOIN table [[AS] alias] и , table [[AS] alias] без условия
+type JoinClause struct {
+	Kind  JoinKind
+	Table lex.Token
+	// Alias - псевдоним таблицы или nil
+	Alias *lex.Token
+	// Subquery - подзапрос (SELECT ...) [AS] alias вместо таблицы Table или nil
+	Subquery *SelectStatement
+	// On - условие ON или nil, Using - колонки USING
+	On    *WhereClause
+	Using []lex.Token
+}
+
+// OrderByClause - один ключ ORDER BY column [ASC | DESC] [NULLS FIRST | NULLS LAST]
+type OrderByClause struct {
+	Column lex.Token
+	Desc   bool
+	// NullsFirst - NULL идет перед значениями. Без NULLS FIRST и NULLS LAST NULL считается больше
+	// любого значения: по возрастанию он идет последним, по убыванию - первым
+	NullsFirst bool
 }
 
 type WhereClause struct {
 	Left  *WhereClause
 	Right *WhereClause
 	Token *lex.Token
+	// Aggregate - вызов агрегатной функции в листе условия HAVING. Token такого листа -
+	// идентификатор с текстом вызова из AggregateCall.String
+	Aggregate *AggregateCall
+	// Subquery - подзапрос условия. Token такого узла - ключевое слово SELECT у листа скалярного
+	// подзапроса, EXISTS у EXISTS (SELECT ...) и IN у value IN (SELECT ...), где value - Left
+	Subquery *Subquery
+}
+
+type SubqueryType uint
+
+const (
+	// ScalarSubquery - значение единственной колонки единственной строки подзапроса или NULL без строк
+	ScalarSubquery SubqueryType = iota
+	ExistsSubquery
+	InSubquery
+)
+
+// Subquery - [NOT] EXISTS (SELECT ...), value [NOT] IN (SELECT ...) или скалярный (SELECT ...) в условии
+type Subquery struct {
+	Type   SubqueryType
+	Select *SelectStatement
+	// Not - NOT EXISTS или NOT IN
+	Not bool
 }
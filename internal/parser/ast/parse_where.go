@@ -2,6 +2,9 @@ package ast
 
 import (
 	"custom-database/internal/parser/lex"
+	"errors"
+	"slices"
+	"strings"
 )
 
 type tokenWithPrior struct {
@@ -9,7 +12,8 @@ type tokenWithPrior struct {
 	Priority uint
 }
 
-func parseWhereClause(tokens []*lex.Token, initialCursor uint, delimiter lex.Token) (*WhereClause, uint, bool) {
+// parseWhereClause разбирает WHERE до первого из delimiters
+func parseWhereClause(tokens []*lex.Token, initialCursor uint, delimiters []lex.Token) (*WhereClause, uint, bool) {
 	cursor := initialCursor
 
 	if !expectToken(tokens, cursor, tokenFromKeyword(lex.WhereKeyword)) {
@@ -17,7 +21,7 @@ func parseWhereClause(tokens []*lex.Token, initialCursor uint, delimiter lex.Tok
 	}
 	cursor++
 
-	whereExps, newCursor, ok := parseWhereExpression(tokens, cursor, []lex.Token{delimiter})
+	whereExps, newCursor, ok := parseWhereExpression(tokens, cursor, delimiters)
 	if !ok {
 		return nil, initialCursor, false
 	}
@@ -25,26 +29,178 @@ func parseWhereClause(tokens []*lex.Token, initialCursor uint, delimiter lex.Tok
 	return whereExps, newCursor, true
 }
 
-func parseWhereExpression(tokens []*lex.Token, initialCursor uint, delimiters []lex.Token) (*WhereClause, uint, bool) {
-	// find first delimiter cursor
-	firstDelimiterCursor := uint(0)
-loop:
-	for i := initialCursor; i < uint(len(tokens)); i++ {
-		for _, delimiter := range delimiters {
-			if tokens[i].Value == delimiter.Value {
-				firstDelimiterCursor = i
-				break loop
-			}
+// parseHavingClause разбирает HAVING до первого из delimiters. Условие записывается как в WHERE,
+// но операндами могут быть и агрегатные функции: каждый вызов заменяется листом-идентификатором
+// с текстом вызова, а сам вызов сохраняется в WhereClause.Aggregate
+func parseHavingClause(tokens []*lex.Token, initialCursor uint, delimiters []lex.Token) (*WhereClause, uint, bool) {
+	cursor := initialCursor
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.HavingKeyword)) {
+		return nil, initialCursor, true
+	}
+	cursor++
+
+	collected, subqueries, newCursor, ok := parseConditionTokens(tokens, cursor, delimiters)
+	if !ok {
+		return nil, initialCursor, false
+	}
+
+	conditionTokens := []*lex.Token{}
+	calls := map[*lex.Token]*AggregateCall{}
+	for i := uint(0); i < uint(len(collected)); {
+		call, next, ok := parseAggregateCall(collected, i)
+		if !ok {
+			conditionTokens = append(conditionTokens, collected[i])
+			i++
+			continue
 		}
+
+		token := &lex.Token{Kind: lex.IdentifierToken, Value: call.String(), Loc: collected[i].Loc}
+		calls[token] = call
+		conditionTokens = append(conditionTokens, token)
+		i = next
+	}
+
+	condition, ok := parseCondition(conditionTokens, subqueries)
+	if !ok {
+		return nil, initialCursor, false
 	}
-	if firstDelimiterCursor == 0 {
+	setAggregates(condition, calls)
+
+	return condition, newCursor, true
+}
+
+// parseOnClause разбирает условие ON соединения до первого из delimiters
+func parseOnClause(tokens []*lex.Token, initialCursor uint, delimiters []lex.Token) (*WhereClause, uint, bool) {
+	conditionTokens, _, cursor, ok := parseConditionTokens(tokens, initialCursor, delimiters)
+	if !ok {
 		return nil, initialCursor, false
 	}
 
-	whereTokens := addTokensPriority(tokens[initialCursor:firstDelimiterCursor])
-	whereTree := parseTree(whereTokens)
+	// Подзапросы в ON не поддерживаются: их токены остаются ключевыми словами, и условие не разбирается
+	condition, ok := parseCondition(conditionTokens, nil)
+	if !ok {
+		return nil, initialCursor, false
+	}
 
-	return whereTree, firstDelimiterCursor, true
+	return condition, cursor, true
+}
+
+// parseConditionTokens собирает токены условия до первого из delimiters вне скобок. Подзапрос
+// заменяется одним токеном - ключевым словом SELECT скалярного (SELECT ...), EXISTS из [NOT] EXISTS (SELECT ...)
+// или IN из [NOT] IN (SELECT ...), - а сам подзапрос сохраняется для этого токена в subqueries.
+// Если delimiters не встретились, возвращает false
+func parseConditionTokens(tokens []*lex.Token, initialCursor uint, delimiters []lex.Token) ([]*lex.Token, map[*lex.Token]*Subquery, uint, bool) {
+	cursor := initialCursor
+
+	conditionTokens := []*lex.Token{}
+	subqueries := map[*lex.Token]*Subquery{}
+	depth := 0
+	for cursor < uint(len(tokens)) {
+		if depth == 0 && slices.ContainsFunc(delimiters, func(delimiter lex.Token) bool {
+			return delimiter.Equals(tokens[cursor])
+		}) {
+			return conditionTokens, subqueries, cursor, true
+		}
+
+		token, subquery, newCursor, ok := parseConditionSubquery(tokens, cursor)
+		if !ok {
+			return nil, nil, initialCursor, false
+		}
+		if subquery != nil {
+			subqueries[token] = subquery
+			conditionTokens = append(conditionTokens, token)
+			cursor = newCursor
+			continue
+		}
+
+		switch {
+		case expectToken(tokens, cursor, tokenFromSymbol(lex.LeftparenSymbol)):
+			depth++
+		case expectToken(tokens, cursor, tokenFromSymbol(lex.RightparenSymbol)):
+			depth--
+		}
+		conditionTokens = append(conditionTokens, tokens[cursor])
+		cursor++
+	}
+
+	return nil, nil, initialCursor, false
+}
+
+// parseConditionSubquery разбирает подзапрос условия: (SELECT ...), [NOT] EXISTS (SELECT ...) или
+// [NOT] IN (SELECT ...). Возвращает токен, которым подзапрос заменяется в условии, или nil,
+// если на месте курсора подзапроса нет
+func parseConditionSubquery(tokens []*lex.Token, initialCursor uint) (*lex.Token, *Subquery, uint, bool) {
+	cursor := initialCursor
+
+	if statement, newCursor, ok := parseSubquery(tokens, cursor); ok {
+		return tokens[cursor+1], &Subquery{Type: ScalarSubquery, Select: statement}, newCursor, true
+	}
+
+	subquery := &Subquery{}
+	if expectToken(tokens, cursor, tokenFromKeyword(lex.NotKeyword)) &&
+		(expectToken(tokens, cursor+1, tokenFromKeyword(lex.ExistsKeyword)) || expectToken(tokens, cursor+1, tokenFromKeyword(lex.InKeyword))) {
+		subquery.Not = true
+		cursor++
+	}
+
+	switch {
+	case expectToken(tokens, cursor, tokenFromKeyword(lex.ExistsKeyword)):
+		subquery.Type = ExistsSubquery
+	case expectToken(tokens, cursor, tokenFromKeyword(lex.InKeyword)):
+		subquery.Type = InSubquery
+	default:
+		return nil, nil, initialCursor, true
+	}
+	token := tokens[cursor]
+	cursor++
+
+	statement, newCursor, ok := parseSubquery(tokens, cursor)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected subquery")
+		return nil, nil, initialCursor, false
+	}
+	subquery.Select = statement
+
+	return token, subquery, newCursor, true
+}
+
+// setAggregates отмечает листья условия, которые заменяют вызовы агрегатных функций
+func setAggregates(condition *WhereClause, calls map[*lex.Token]*AggregateCall) {
+	if condition == nil {
+		return
+	}
+
+	condition.Aggregate = calls[condition.Token]
+	setAggregates(condition.Left, calls)
+	setAggregates(condition.Right, calls)
+}
+
+// setSubqueries отмечает узлы условия, которые заменяют подзапросы
+func setSubqueries(condition *WhereClause, subqueries map[*lex.Token]*Subquery) {
+	if condition == nil {
+		return
+	}
+
+	condition.Subquery = subqueries[condition.Token]
+	setSubqueries(condition.Left, subqueries)
+	setSubqueries(condition.Right, subqueries)
+}
+
+// parseWhereExpression строит дерево условия WHERE и проверяет его так же, как ON и HAVING:
+// ключевые слова вне подзапросов, например NOT без EXISTS и IN или DESC без ORDER BY, - синтаксическая ошибка
+func parseWhereExpression(tokens []*lex.Token, initialCursor uint, delimiters []lex.Token) (*WhereClause, uint, bool) {
+	whereTokens, subqueries, cursor, ok := parseConditionTokens(tokens, initialCursor, delimiters)
+	if !ok || len(whereTokens) == 0 {
+		return nil, initialCursor, false
+	}
+
+	whereTree, ok := parseCondition(whereTokens, subqueries)
+	if !ok {
+		return nil, initialCursor, false
+	}
+
+	return whereTree, cursor, true
 }
 
 func parseTree(tokens []*tokenWithPrior) *WhereClause {
@@ -86,7 +242,7 @@ func addTokensPriority(tokens []*lex.Token) []*tokenWithPrior {
 			continue
 		}
 
-		if token.Kind == lex.IdentifierToken || token.Kind == lex.StringToken || token.Kind == lex.NumericToken || token.Kind == lex.BooleanToken || token.Kind == lex.NullToken {
+		if isValueToken(token) {
 			identPrior++
 		}
 
@@ -100,11 +256,14 @@ func addTokensPriority(tokens []*lex.Token) []*tokenWithPrior {
 }
 
 func getPriority(token *lex.Token, subPriority uint, identPrior uint) uint {
-
-	if token.Kind == lex.IdentifierToken || token.Kind == lex.StringToken || token.Kind == lex.NumericToken || token.Kind == lex.BooleanToken || token.Kind == lex.NullToken {
+	if isValueToken(token) {
 		return 40 + identPrior + subPriority
 	}
 
+	if token.Equals(&lex.Token{Kind: lex.KeywordToken, Value: string(lex.InKeyword)}) {
+		return 40 + subPriority
+	}
+
 	switch token.Value {
 	case string(lex.OrOperator):
 		return 20 + subPriority
@@ -116,3 +275,137 @@ func getPriority(token *lex.Token, subPriority uint, identPrior uint) uint {
 
 	return 0
 }
+
+// isValueToken - токен операнда условия: колонка, литерал или подзапрос, который заменен
+// ключевым словом SELECT или EXISTS
+func isValueToken(token *lex.Token) bool {
+	switch token.Kind {
+	case lex.IdentifierToken, lex.StringToken, lex.DateToken, lex.NumericToken, lex.BooleanToken, lex.NullToken:
+		return true
+	case lex.KeywordToken:
+		return token.Value == string(lex.SelectKeyword) || token.Value == string(lex.ExistsKeyword)
+	}
+
+	return false
+}
+
+// ParseCondition разбирает условие, сохраненное текстом, например условие CHECK из метаданных таблицы
+func ParseCondition(source string) (*WhereClause, error) {
+	tokens, err := lex.NewLexer().Lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	condition, ok := parseCondition(tokens, nil)
+	if !ok {
+		return nil, errors.New("Failed to parse condition: " + source)
+	}
+
+	return condition, nil
+}
+
+// RenameConditionColumn переписывает условие, сохраненное текстом, с новым именем колонки
+func RenameConditionColumn(source, columnName, newName string) (string, error) {
+	tokens, err := lex.NewLexer().Lex(source)
+	if err != nil {
+		return "", err
+	}
+
+	for _, token := range tokens {
+		if token.Kind == lex.IdentifierToken && token.Value == columnName {
+			token.Value = newName
+		}
+	}
+
+	return formatCondition(tokens), nil
+}
+
+// parseCondition строит дерево условия из всех токенов и проверяет, что в нем только сравнения
+// значений и колонок, EXISTS и IN, соединенные AND и OR, а скобки парные. subqueries - подзапросы
+// из parseConditionTokens: другие ключевые слова в условии не допускаются
+func parseCondition(tokens []*lex.Token, subqueries map[*lex.Token]*Subquery) (*WhereClause, bool) {
+	depth := 0
+	for _, token := range tokens {
+		switch {
+		case subqueries[token] != nil:
+		case token.Equals(&lex.Token{Kind: lex.SymbolToken, Value: string(lex.LeftparenSymbol)}):
+			depth++
+		case token.Equals(&lex.Token{Kind: lex.SymbolToken, Value: string(lex.RightparenSymbol)}):
+			depth--
+		case token.Kind == lex.KeywordToken || token.Kind == lex.SymbolToken:
+			return nil, false
+		}
+		if depth < 0 {
+			return nil, false
+		}
+	}
+	if depth != 0 {
+		return nil, false
+	}
+
+	condition := parseTree(addTokensPriority(tokens))
+	setSubqueries(condition, subqueries)
+	if !validCondition(condition) {
+		return nil, false
+	}
+
+	return condition, true
+}
+
+func validCondition(condition *WhereClause) bool {
+	if condition == nil {
+		return false
+	}
+
+	if condition.Subquery != nil {
+		switch condition.Subquery.Type {
+		case ExistsSubquery:
+			return condition.Left == nil && condition.Right == nil
+		case InSubquery:
+			return isOperand(condition.Left) && condition.Right == nil
+		}
+		return false
+	}
+
+	switch condition.Token.Kind {
+	case lex.LogicalOperatorToken:
+		return validCondition(condition.Left) && validCondition(condition.Right)
+	case lex.MathOperatorToken:
+		return isOperand(condition.Left) && isOperand(condition.Right)
+	}
+
+	return false
+}
+
+// isOperand - лист со значением: колонка, литерал или скалярный подзапрос
+func isOperand(clause *WhereClause) bool {
+	if clause == nil || clause.Left != nil || clause.Right != nil {
+		return false
+	}
+	if clause.Subquery != nil {
+		return clause.Subquery.Type == ScalarSubquery
+	}
+
+	return clause.Token.Kind != lex.LogicalOperatorToken && clause.Token.Kind != lex.MathOperatorToken &&
+		clause.Token.Kind != lex.KeywordToken
+}
+
+// formatCondition собирает токены условия обратно в текст, который снова разбирается лексером
+func formatCondition(tokens []*lex.Token) string {
+	parts := make([]string, len(tokens))
+	for i, token := range tokens {
+		parts[i] = FormatToken(token)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// FormatToken печатает токен так, как он записывается в запросе: строки и даты - в кавычках.
+// Лексер оставляет в значении строки удвоенные кавычки, поэтому экранировать их заново не нужно
+func FormatToken(token *lex.Token) string {
+	if token.Kind == lex.StringToken || token.Kind == lex.DateToken {
+		return "'" + token.Value + "'"
+	}
+
+	return token.Value
+}
@@ -46,6 +46,27 @@ func TestMapWhereTokens(t *testing.T) {
 		}
 	})
 
+	t.Run("сравнение с датой", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.IdentifierToken, Value: "created_at"},
+			{Kind: lex.MathOperatorToken, Value: ">"},
+			{Kind: lex.DateToken, Value: "2024-05-01 10:00:00"},
+		}
+		expected := []*tokenWithPrior{
+			{Token: &lex.Token{Kind: lex.IdentifierToken, Value: "created_at"}, Priority: 41},
+			{Token: &lex.Token{Kind: lex.MathOperatorToken, Value: ">"}, Priority: 40},
+			{Token: &lex.Token{Kind: lex.DateToken, Value: "2024-05-01 10:00:00"}, Priority: 42},
+		}
+
+		result := addTokensPriority(tokens)
+		require.Len(t, result, len(expected))
+		for i, expected := range expected {
+			require.Equal(t, expected.Token.Kind, result[i].Token.Kind)
+			require.Equal(t, expected.Token.Value, result[i].Token.Value)
+			require.Equal(t, expected.Priority, result[i].Priority)
+		}
+	})
+
 	t.Run("выражение со скобками", func(t *testing.T) {
 		tokens := []*lex.Token{
 			{Kind: lex.SymbolToken, Value: "("},
@@ -330,3 +351,73 @@ func compareTrees(a, b *WhereClause) bool {
 
 	return compareTrees(a.Left, b.Left) && compareTrees(a.Right, b.Right)
 }
+
+func TestParseCondition(t *testing.T) {
+	t.Run("условие из текста ограничения", func(t *testing.T) {
+		result, err := ParseCondition("( age > 17 ) or name != 'child'")
+		require.NoError(t, err)
+
+		expected := &WhereClause{
+			Token: &lex.Token{Kind: lex.LogicalOperatorToken, Value: "or"},
+			Left: &WhereClause{
+				Token: &lex.Token{Kind: lex.MathOperatorToken, Value: ">"},
+				Left:  &WhereClause{Token: &lex.Token{Kind: lex.IdentifierToken, Value: "age"}},
+				Right: &WhereClause{Token: &lex.Token{Kind: lex.NumericToken, Value: "17"}},
+			},
+			Right: &WhereClause{
+				Token: &lex.Token{Kind: lex.MathOperatorToken, Value: "!="},
+				Left:  &WhereClause{Token: &lex.Token{Kind: lex.IdentifierToken, Value: "name"}},
+				Right: &WhereClause{Token: &lex.Token{Kind: lex.StringToken, Value: "child"}},
+			},
+		}
+		require.True(t, compareTrees(result, expected))
+	})
+
+	t.Run("непарные скобки", func(t *testing.T) {
+		_, err := ParseCondition("( age > 17")
+		require.Error(t, err)
+	})
+
+	t.Run("колонка без сравнения", func(t *testing.T) {
+		_, err := ParseCondition("age")
+		require.Error(t, err)
+	})
+
+	t.Run("переименование колонки в тексте условия", func(t *testing.T) {
+		result, err := RenameConditionColumn("( age > 17 ) or name != 'age'", "age", "years")
+		require.NoError(t, err)
+		require.Equal(t, "( years > 17 ) or name != 'age'", result)
+	})
+}
+
+func TestParseWhereClause(t *testing.T) {
+	parse := func(t *testing.T, source string) (*WhereClause, bool) {
+		tokens, err := lex.NewLexer().Lex(source)
+		require.NoError(t, err)
+
+		where, _, ok := parseWhereClause(tokens, 0, []lex.Token{tokenFromSymbol(lex.SemicolonSymbol)})
+		return where, ok
+	}
+
+	t.Run("условие без подзапросов", func(t *testing.T) {
+		where, ok := parse(t, "WHERE id = 1 AND name != 'a';")
+		require.True(t, ok)
+		require.Equal(t, "and", where.Token.Value)
+		require.Equal(t, "=", where.Left.Token.Value)
+		require.Equal(t, "!=", where.Right.Token.Value)
+	})
+
+	t.Run("условие без подзапросов проверяется так же, как с ними", func(t *testing.T) {
+		for _, source := range []string{
+			"WHERE id;",
+			"WHERE id = 1 2;",
+			"WHERE id = 1 AND;",
+			"WHERE ( id = 1;",
+			"WHERE true;",
+		} {
+			where, ok := parse(t, source)
+			require.False(t, ok, source)
+			require.Nil(t, where, source)
+		}
+	})
+}
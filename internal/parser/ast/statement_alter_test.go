@@ -0,0 +1,153 @@
+package ast
+
+import (
+	"custom-database/internal/parser/lex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAlterTableStatement(t *testing.T) {
+	t.Run("valid ADD COLUMN statement with DEFAULT", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "alter"},
+			{Kind: lex.KeywordToken, Value: "table"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "add"},
+			{Kind: lex.KeywordToken, Value: "column"},
+			{Kind: lex.IdentifierToken, Value: "age"},
+			{Kind: lex.KeywordToken, Value: "int"},
+			{Kind: lex.KeywordToken, Value: "not"},
+			{Kind: lex.NullToken, Value: "null"},
+			{Kind: lex.KeywordToken, Value: "default"},
+			{Kind: lex.NumericToken, Value: "18"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseAlterTableStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(11), cursor)
+		require.Equal(t, "users", result.Table.Value)
+		require.Equal(t, AddColumnAction, result.Action)
+		require.Equal(t, "age", result.Column.Name.Value)
+		require.Equal(t, "int", result.Column.Datatype.Value)
+		require.Len(t, result.Column.Constraints, 2)
+		require.Equal(t, NotNullConstraint, result.Column.Constraints[0].Kind)
+		require.Equal(t, DefaultConstraint, result.Column.Constraints[1].Kind)
+		require.Equal(t, "18", result.Column.Constraints[1].Default.Value)
+	})
+
+	t.Run("valid DROP statement without COLUMN", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "alter"},
+			{Kind: lex.KeywordToken, Value: "table"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "drop"},
+			{Kind: lex.IdentifierToken, Value: "age"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseAlterTableStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(5), cursor)
+		require.Equal(t, DropColumnAction, result.Action)
+		require.Equal(t, "age", result.ColumnName.Value)
+	})
+
+	t.Run("valid RENAME COLUMN and RENAME TO statements", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "alter"},
+			{Kind: lex.KeywordToken, Value: "table"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "rename"},
+			{Kind: lex.IdentifierToken, Value: "name"},
+			{Kind: lex.KeywordToken, Value: "to"},
+			{Kind: lex.IdentifierToken, Value: "login"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseAlterTableStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(7), cursor)
+		require.Equal(t, RenameColumnAction, result.Action)
+		require.Equal(t, "name", result.ColumnName.Value)
+		require.Equal(t, "login", result.NewName.Value)
+
+		tokens = []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "alter"},
+			{Kind: lex.KeywordToken, Value: "table"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "rename"},
+			{Kind: lex.KeywordToken, Value: "to"},
+			{Kind: lex.IdentifierToken, Value: "people"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok = parseAlterTableStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(6), cursor)
+		require.Equal(t, RenameTableAction, result.Action)
+		require.Equal(t, "people", result.NewName.Value)
+	})
+
+	t.Run("valid ALTER COLUMN TYPE statement", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "alter"},
+			{Kind: lex.KeywordToken, Value: "table"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "alter"},
+			{Kind: lex.KeywordToken, Value: "column"},
+			{Kind: lex.IdentifierToken, Value: "age"},
+			{Kind: lex.KeywordToken, Value: "type"},
+			{Kind: lex.KeywordToken, Value: "text"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseAlterTableStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(8), cursor)
+		require.Equal(t, AlterColumnTypeAction, result.Action)
+		require.Equal(t, "age", result.ColumnName.Value)
+		require.Equal(t, "text", result.Datatype.Value)
+	})
+
+	t.Run("invalid RENAME COLUMN statement - missing TO", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "alter"},
+			{Kind: lex.KeywordToken, Value: "table"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "rename"},
+			{Kind: lex.KeywordToken, Value: "column"},
+			{Kind: lex.IdentifierToken, Value: "name"},
+			{Kind: lex.IdentifierToken, Value: "login"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseAlterTableStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid ALTER TABLE statement - unknown action", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "alter"},
+			{Kind: lex.KeywordToken, Value: "table"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "set"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseAlterTableStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+}
@@ -87,6 +87,49 @@ func TestParseCreateTableStatement(t *testing.T) {
 }
 
 func TestParseColumnDefinitions(t *testing.T) {
+	t.Run("valid CREATE TABLE AS SELECT statement", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "create"},
+			{Kind: lex.KeywordToken, Value: "table"},
+			{Kind: lex.IdentifierToken, Value: "admins"},
+			{Kind: lex.KeywordToken, Value: "as"},
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.IdentifierToken, Value: "name"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseCreateTableStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(10), cursor)
+		require.Equal(t, "admins", result.Name.Value)
+		require.Nil(t, result.Cols)
+		require.NotNil(t, result.AsSelect)
+		require.Equal(t, "users", result.AsSelect.From.Value)
+		require.Len(t, result.AsSelect.SelectedColumns, 2)
+	})
+
+	t.Run("invalid CREATE TABLE AS statement - missing SELECT", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "create"},
+			{Kind: lex.KeywordToken, Value: "table"},
+			{Kind: lex.IdentifierToken, Value: "admins"},
+			{Kind: lex.KeywordToken, Value: "as"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseCreateTableStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
 	t.Run("valid column definitions", func(t *testing.T) {
 		tokens := []*lex.Token{
 			{Kind: lex.IdentifierToken, Value: "id"},
@@ -98,7 +141,7 @@ func TestParseColumnDefinitions(t *testing.T) {
 		}
 		endDelimiter := lex.Token{Kind: lex.SymbolToken, Value: ")"}
 
-		cols, cursor, ok := parseColumnDefinitions(tokens, 0, endDelimiter)
+		cols, constraints, cursor, ok := parseColumnDefinitions(tokens, 0, endDelimiter)
 
 		require.True(t, ok)
 		require.Equal(t, uint(5), cursor)
@@ -107,6 +150,173 @@ func TestParseColumnDefinitions(t *testing.T) {
 		require.Equal(t, "int", (*cols)[0].Datatype.Value)
 		require.Equal(t, "name", (*cols)[1].Name.Value)
 		require.Equal(t, "text", (*cols)[1].Datatype.Value)
+		require.Empty(t, constraints)
+	})
+
+	t.Run("column and table constraints", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.KeywordToken, Value: "int"},
+			{Kind: lex.KeywordToken, Value: "primary"},
+			{Kind: lex.KeywordToken, Value: "key"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.IdentifierToken, Value: "email"},
+			{Kind: lex.KeywordToken, Value: "text"},
+			{Kind: lex.KeywordToken, Value: "constraint"},
+			{Kind: lex.IdentifierToken, Value: "users_email"},
+			{Kind: lex.KeywordToken, Value: "unique"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.IdentifierToken, Value: "tenant_id"},
+			{Kind: lex.KeywordToken, Value: "int"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.KeywordToken, Value: "unique"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.IdentifierToken, Value: "tenant_id"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.IdentifierToken, Value: "email"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ")"},
+		}
+		endDelimiter := lex.Token{Kind: lex.SymbolToken, Value: ")"}
+
+		cols, constraints, cursor, ok := parseColumnDefinitions(tokens, 0, endDelimiter)
+
+		require.True(t, ok)
+		require.Equal(t, uint(20), cursor)
+		require.Len(t, *cols, 3)
+
+		require.Len(t, (*cols)[0].Constraints, 1)
+		require.Equal(t, PrimaryKeyConstraint, (*cols)[0].Constraints[0].Kind)
+		require.Nil(t, (*cols)[0].Constraints[0].Name)
+
+		require.Len(t, (*cols)[1].Constraints, 1)
+		require.Equal(t, UniqueConstraint, (*cols)[1].Constraints[0].Kind)
+		require.Equal(t, "users_email", (*cols)[1].Constraints[0].Name.Value)
+
+		require.Empty(t, (*cols)[2].Constraints)
+
+		require.Len(t, constraints, 1)
+		require.Equal(t, UniqueConstraint, constraints[0].Kind)
+		require.Len(t, constraints[0].Columns, 2)
+		require.Equal(t, "tenant_id", constraints[0].Columns[0].Value)
+		require.Equal(t, "email", constraints[0].Columns[1].Value)
+	})
+
+	t.Run("NOT NULL, DEFAULT and CHECK constraints", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.IdentifierToken, Value: "age"},
+			{Kind: lex.KeywordToken, Value: "int"},
+			{Kind: lex.KeywordToken, Value: "not"},
+			{Kind: lex.NullToken, Value: "null"},
+			{Kind: lex.KeywordToken, Value: "default"},
+			{Kind: lex.NumericToken, Value: "18"},
+			{Kind: lex.KeywordToken, Value: "check"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.IdentifierToken, Value: "age"},
+			{Kind: lex.MathOperatorToken, Value: ">"},
+			{Kind: lex.NumericToken, Value: "0"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.IdentifierToken, Value: "created_at"},
+			{Kind: lex.KeywordToken, Value: "timestamp"},
+			{Kind: lex.KeywordToken, Value: "default"},
+			{Kind: lex.KeywordToken, Value: "current_timestamp"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.KeywordToken, Value: "constraint"},
+			{Kind: lex.IdentifierToken, Value: "adult_or_named"},
+			{Kind: lex.KeywordToken, Value: "check"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.IdentifierToken, Value: "age"},
+			{Kind: lex.MathOperatorToken, Value: ">"},
+			{Kind: lex.NumericToken, Value: "17"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.LogicalOperatorToken, Value: "or"},
+			{Kind: lex.IdentifierToken, Value: "name"},
+			{Kind: lex.MathOperatorToken, Value: "!="},
+			{Kind: lex.StringToken, Value: "child"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ")"},
+		}
+		endDelimiter := lex.Token{Kind: lex.SymbolToken, Value: ")"}
+
+		cols, constraints, cursor, ok := parseColumnDefinitions(tokens, 0, endDelimiter)
+
+		require.True(t, ok)
+		require.Equal(t, uint(32), cursor)
+		require.Len(t, *cols, 2)
+
+		age := (*cols)[0].Constraints
+		require.Len(t, age, 3)
+		require.Equal(t, NotNullConstraint, age[0].Kind)
+		require.Equal(t, DefaultConstraint, age[1].Kind)
+		require.Equal(t, "18", age[1].Default.Value)
+		require.Equal(t, CheckConstraint, age[2].Kind)
+		require.Equal(t, ">", age[2].Check.Token.Value)
+		require.Equal(t, "age > 0", age[2].CheckText)
+
+		require.Equal(t, "current_timestamp", (*cols)[1].Constraints[0].Default.Value)
+
+		require.Len(t, constraints, 1)
+		require.Equal(t, "adult_or_named", constraints[0].Name.Value)
+		require.Equal(t, "or", constraints[0].Check.Token.Value)
+		require.Equal(t, "( age > 17 ) or name != 'child'", constraints[0].CheckText)
+	})
+
+	t.Run("invalid constraint - CHECK without condition", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.IdentifierToken, Value: "age"},
+			{Kind: lex.KeywordToken, Value: "int"},
+			{Kind: lex.KeywordToken, Value: "check"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.IdentifierToken, Value: "age"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ")"},
+		}
+		endDelimiter := lex.Token{Kind: lex.SymbolToken, Value: ")"}
+
+		cols, constraints, cursor, ok := parseColumnDefinitions(tokens, 0, endDelimiter)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, cols)
+		require.Nil(t, constraints)
+	})
+
+	t.Run("invalid constraint - PRIMARY without KEY", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.KeywordToken, Value: "int"},
+			{Kind: lex.KeywordToken, Value: "primary"},
+			{Kind: lex.SymbolToken, Value: ")"},
+		}
+		endDelimiter := lex.Token{Kind: lex.SymbolToken, Value: ")"}
+
+		cols, constraints, cursor, ok := parseColumnDefinitions(tokens, 0, endDelimiter)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, cols)
+		require.Nil(t, constraints)
+	})
+
+	t.Run("invalid table constraint - missing column list", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.KeywordToken, Value: "int"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.KeywordToken, Value: "primary"},
+			{Kind: lex.KeywordToken, Value: "key"},
+			{Kind: lex.SymbolToken, Value: ")"},
+		}
+		endDelimiter := lex.Token{Kind: lex.SymbolToken, Value: ")"}
+
+		cols, constraints, cursor, ok := parseColumnDefinitions(tokens, 0, endDelimiter)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, cols)
+		require.Nil(t, constraints)
 	})
 
 	t.Run("invalid column definition - missing column type", func(t *testing.T) {
@@ -116,11 +326,12 @@ func TestParseColumnDefinitions(t *testing.T) {
 		}
 		endDelimiter := lex.Token{Kind: lex.SymbolToken, Value: ")"}
 
-		cols, cursor, ok := parseColumnDefinitions(tokens, 0, endDelimiter)
+		cols, constraints, cursor, ok := parseColumnDefinitions(tokens, 0, endDelimiter)
 
 		require.False(t, ok)
 		require.Equal(t, uint(0), cursor)
 		require.Nil(t, cols)
+		require.Nil(t, constraints)
 	})
 
 	t.Run("invalid column definition - missing comma between columns", func(t *testing.T) {
@@ -132,10 +343,11 @@ func TestParseColumnDefinitions(t *testing.T) {
 		}
 		endDelimiter := lex.Token{Kind: lex.SymbolToken, Value: ")"}
 
-		cols, cursor, ok := parseColumnDefinitions(tokens, 0, endDelimiter)
+		cols, constraints, cursor, ok := parseColumnDefinitions(tokens, 0, endDelimiter)
 
 		require.False(t, ok)
 		require.Equal(t, uint(0), cursor)
 		require.Nil(t, cols)
+		require.Nil(t, constraints)
 	})
 }
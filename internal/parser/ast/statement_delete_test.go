@@ -0,0 +1,77 @@
+package ast
+
+import (
+	"custom-database/internal/parser/lex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDeleteStatement(t *testing.T) {
+	t.Run("valid DELETE statement without WHERE", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "delete"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseDeleteStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(3), cursor)
+		require.Equal(t, "users", result.Table.Value)
+		require.Nil(t, result.Where)
+	})
+
+	t.Run("valid DELETE statement with WHERE", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "delete"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "where"},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.MathOperatorToken, Value: ">"},
+			{Kind: lex.NumericToken, Value: "1"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseDeleteStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(7), cursor)
+		require.Equal(t, "users", result.Table.Value)
+		require.NotNil(t, result.Where)
+		require.Equal(t, ">", result.Where.Token.Value)
+		require.Equal(t, "id", result.Where.Left.Token.Value)
+		require.Equal(t, "1", result.Where.Right.Token.Value)
+	})
+
+	t.Run("invalid DELETE statement - missing FROM keyword", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "delete"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseDeleteStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid DELETE statement - missing table name", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "delete"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseDeleteStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+}
@@ -0,0 +1,176 @@
+package ast
+
+import (
+	"custom-database/internal/parser/lex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCreateIndexStatement(t *testing.T) {
+	t.Run("valid CREATE INDEX statement", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "create"},
+			{Kind: lex.KeywordToken, Value: "index"},
+			{Kind: lex.IdentifierToken, Value: "users_id"},
+			{Kind: lex.KeywordToken, Value: "on"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseCreateIndexStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(8), cursor)
+		require.Equal(t, "users_id", result.Name.Value)
+		require.Equal(t, "users", result.Table.Value)
+		require.Len(t, result.Columns, 1)
+		require.Equal(t, "id", result.Columns[0].Value)
+	})
+
+	t.Run("valid CREATE INDEX statement with several columns", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "create"},
+			{Kind: lex.KeywordToken, Value: "index"},
+			{Kind: lex.IdentifierToken, Value: "events_tenant_time"},
+			{Kind: lex.KeywordToken, Value: "on"},
+			{Kind: lex.IdentifierToken, Value: "events"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.IdentifierToken, Value: "tenant_id"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.IdentifierToken, Value: "created_at"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseCreateIndexStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(10), cursor)
+		require.Len(t, result.Columns, 2)
+		require.Equal(t, "tenant_id", result.Columns[0].Value)
+		require.Equal(t, "created_at", result.Columns[1].Value)
+	})
+
+	t.Run("invalid CREATE INDEX statement - trailing comma", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "create"},
+			{Kind: lex.KeywordToken, Value: "index"},
+			{Kind: lex.IdentifierToken, Value: "users_id"},
+			{Kind: lex.KeywordToken, Value: "on"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseCreateIndexStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("CREATE TABLE is not a CREATE INDEX statement", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "create"},
+			{Kind: lex.KeywordToken, Value: "table"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+		}
+
+		result, cursor, ok := parseCreateIndexStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid CREATE INDEX statement - missing ON", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "create"},
+			{Kind: lex.KeywordToken, Value: "index"},
+			{Kind: lex.IdentifierToken, Value: "users_id"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseCreateIndexStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid CREATE INDEX statement - missing column", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "create"},
+			{Kind: lex.KeywordToken, Value: "index"},
+			{Kind: lex.IdentifierToken, Value: "users_id"},
+			{Kind: lex.KeywordToken, Value: "on"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseCreateIndexStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+}
+
+func TestParseDropIndexStatement(t *testing.T) {
+	t.Run("valid DROP INDEX statement", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "drop"},
+			{Kind: lex.KeywordToken, Value: "index"},
+			{Kind: lex.IdentifierToken, Value: "users_id"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseDropIndexStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(3), cursor)
+		require.Equal(t, "users_id", result.Name.Value)
+	})
+
+	t.Run("DROP TABLE is not a DROP INDEX statement", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "drop"},
+			{Kind: lex.KeywordToken, Value: "table"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseDropIndexStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid DROP INDEX statement - missing semicolon", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "drop"},
+			{Kind: lex.KeywordToken, Value: "index"},
+			{Kind: lex.IdentifierToken, Value: "users_id"},
+		}
+
+		result, cursor, ok := parseDropIndexStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+}
@@ -35,13 +35,13 @@ func TestParseInsertStatement(t *testing.T) {
 		require.True(t, ok)
 		require.Equal(t, uint(17), cursor)
 		require.Equal(t, "users", result.Table.Value)
-		require.Len(t, *result.Values, 6)
-		require.Equal(t, "1", (*result.Values)[0].Literal.Value)
-		require.Equal(t, "John", (*result.Values)[1].Literal.Value)
-		require.Equal(t, "true", (*result.Values)[2].Literal.Value)
-		require.Equal(t, "false", (*result.Values)[3].Literal.Value)
-		require.Equal(t, "null", (*result.Values)[4].Literal.Value)
-		require.Equal(t, "2024-03-20 15:30:45", (*result.Values)[5].Literal.Value)
+		require.Len(t, *result.Rows[0], 6)
+		require.Equal(t, "1", (*result.Rows[0])[0].Literal.Value)
+		require.Equal(t, "John", (*result.Rows[0])[1].Literal.Value)
+		require.Equal(t, "true", (*result.Rows[0])[2].Literal.Value)
+		require.Equal(t, "false", (*result.Rows[0])[3].Literal.Value)
+		require.Equal(t, "null", (*result.Rows[0])[4].Literal.Value)
+		require.Equal(t, "2024-03-20 15:30:45", (*result.Rows[0])[5].Literal.Value)
 	})
 
 	t.Run("invalid INSERT statement - missing INTO keyword", func(t *testing.T) {
@@ -117,4 +117,135 @@ func TestParseInsertStatement(t *testing.T) {
 		require.Equal(t, uint(0), cursor)
 		require.Nil(t, result)
 	})
+
+	t.Run("valid INSERT statement with column list and several rows", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "insert"},
+			{Kind: lex.KeywordToken, Value: "into"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.IdentifierToken, Value: "name"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.KeywordToken, Value: "values"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.StringToken, Value: "John"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.NumericToken, Value: "1"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.StringToken, Value: "Jane"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.KeywordToken, Value: "default"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseInsertStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(20), cursor)
+		require.Len(t, result.Columns, 2)
+		require.Equal(t, "name", result.Columns[0].Value)
+		require.Equal(t, "id", result.Columns[1].Value)
+		require.Len(t, result.Rows, 2)
+		require.Equal(t, "John", (*result.Rows[0])[0].Literal.Value)
+		require.Equal(t, "1", (*result.Rows[0])[1].Literal.Value)
+		require.Equal(t, "Jane", (*result.Rows[1])[0].Literal.Value)
+		require.Equal(t, DefaultKind, (*result.Rows[1])[1].Kind)
+	})
+
+	t.Run("invalid INSERT statement - comma after the last row", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "insert"},
+			{Kind: lex.KeywordToken, Value: "into"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "values"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.NumericToken, Value: "1"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseInsertStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid INSERT statement - empty column list", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "insert"},
+			{Kind: lex.KeywordToken, Value: "into"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.KeywordToken, Value: "values"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.NumericToken, Value: "1"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseInsertStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("valid INSERT statement with SELECT", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "insert"},
+			{Kind: lex.KeywordToken, Value: "into"},
+			{Kind: lex.IdentifierToken, Value: "archive"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "where"},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.MathOperatorToken, Value: ">"},
+			{Kind: lex.NumericToken, Value: "10"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseInsertStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(14), cursor)
+		require.Equal(t, "archive", result.Table.Value)
+		require.Len(t, result.Columns, 1)
+		require.Nil(t, result.Rows)
+		require.NotNil(t, result.Select)
+		require.Equal(t, "users", result.Select.From.Value)
+		require.Len(t, result.Select.SelectedColumns, 1)
+		require.Equal(t, ">", result.Select.Where.Token.Value)
+	})
+
+	t.Run("invalid INSERT statement - SELECT without semicolon", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "insert"},
+			{Kind: lex.KeywordToken, Value: "into"},
+			{Kind: lex.IdentifierToken, Value: "archive"},
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+		}
+
+		result, cursor, ok := parseInsertStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
 }
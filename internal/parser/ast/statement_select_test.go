@@ -168,6 +168,138 @@ func TestParseSelectStatement(t *testing.T) {
 		require.Equal(t, "true", result.Where.Right.Right.Right.Token.Value)
 	})
 
+	t.Run("valid SELECT statement with WHERE, ORDER BY and LIMIT", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "where"},
+			{Kind: lex.IdentifierToken, Value: "age"},
+			{Kind: lex.MathOperatorToken, Value: ">"},
+			{Kind: lex.NumericToken, Value: "18"},
+			{Kind: lex.KeywordToken, Value: "order"},
+			{Kind: lex.KeywordToken, Value: "by"},
+			{Kind: lex.IdentifierToken, Value: "age"},
+			{Kind: lex.KeywordToken, Value: "desc"},
+			{Kind: lex.KeywordToken, Value: "limit"},
+			{Kind: lex.NumericToken, Value: "3"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(14), cursor)
+		require.Equal(t, ">", result.Where.Token.Value)
+		require.Equal(t, "18", result.Where.Right.Token.Value)
+		require.Len(t, result.OrderBy, 1)
+		require.Equal(t, "age", result.OrderBy[0].Column.Value)
+		require.True(t, result.OrderBy[0].Desc)
+		require.Equal(t, 3, result.Limit)
+	})
+
+	t.Run("valid SELECT statement with ORDER BY ASC", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "order"},
+			{Kind: lex.KeywordToken, Value: "by"},
+			{Kind: lex.IdentifierToken, Value: "name"},
+			{Kind: lex.KeywordToken, Value: "asc"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(8), cursor)
+		require.Nil(t, result.Where)
+		require.Equal(t, "name", result.OrderBy[0].Column.Value)
+		require.False(t, result.OrderBy[0].Desc)
+	})
+
+	t.Run("valid SELECT statement with several ORDER BY keys and NULLS", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "order"},
+			{Kind: lex.KeywordToken, Value: "by"},
+			{Kind: lex.IdentifierToken, Value: "age"},
+			{Kind: lex.KeywordToken, Value: "desc"},
+			{Kind: lex.KeywordToken, Value: "nulls"},
+			{Kind: lex.KeywordToken, Value: "last"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.IdentifierToken, Value: "name"},
+			{Kind: lex.KeywordToken, Value: "nulls"},
+			{Kind: lex.KeywordToken, Value: "first"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.KeywordToken, Value: "desc"},
+			{Kind: lex.KeywordToken, Value: "limit"},
+			{Kind: lex.NumericToken, Value: "2"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(19), cursor)
+		require.Len(t, result.OrderBy, 3)
+		require.Equal(t, "age", result.OrderBy[0].Column.Value)
+		require.True(t, result.OrderBy[0].Desc)
+		require.False(t, result.OrderBy[0].NullsFirst)
+		require.Equal(t, "name", result.OrderBy[1].Column.Value)
+		require.False(t, result.OrderBy[1].Desc)
+		require.True(t, result.OrderBy[1].NullsFirst)
+		// Без NULLS по убыванию NULL идет первым
+		require.True(t, result.OrderBy[2].Desc)
+		require.True(t, result.OrderBy[2].NullsFirst)
+		require.Equal(t, 2, result.Limit)
+	})
+
+	t.Run("invalid SELECT statement - NULLS without FIRST or LAST", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "order"},
+			{Kind: lex.KeywordToken, Value: "by"},
+			{Kind: lex.IdentifierToken, Value: "name"},
+			{Kind: lex.KeywordToken, Value: "nulls"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid SELECT statement - ORDER without BY", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "order"},
+			{Kind: lex.IdentifierToken, Value: "name"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
 	t.Run("invalid SELECT statement - missing SELECT keyword", func(t *testing.T) {
 		tokens := []*lex.Token{
 			{Kind: lex.IdentifierToken, Value: "id"},
@@ -226,4 +358,490 @@ func TestParseSelectStatement(t *testing.T) {
 		require.Equal(t, uint(0), cursor)
 		require.Nil(t, result)
 	})
+
+	t.Run("valid SELECT statement with aggregates, GROUP BY and HAVING", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.IdentifierToken, Value: "team"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.KeywordToken, Value: "count"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.KeywordToken, Value: "sum"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.KeywordToken, Value: "distinct"},
+			{Kind: lex.IdentifierToken, Value: "score"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.KeywordToken, Value: "as"},
+			{Kind: lex.IdentifierToken, Value: "total"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "games"},
+			{Kind: lex.KeywordToken, Value: "where"},
+			{Kind: lex.IdentifierToken, Value: "score"},
+			{Kind: lex.MathOperatorToken, Value: ">"},
+			{Kind: lex.NumericToken, Value: "0"},
+			{Kind: lex.KeywordToken, Value: "group"},
+			{Kind: lex.KeywordToken, Value: "by"},
+			{Kind: lex.IdentifierToken, Value: "team"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.IdentifierToken, Value: "season"},
+			{Kind: lex.KeywordToken, Value: "having"},
+			{Kind: lex.KeywordToken, Value: "count"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.MathOperatorToken, Value: ">"},
+			{Kind: lex.NumericToken, Value: "1"},
+			{Kind: lex.LogicalOperatorToken, Value: "and"},
+			{Kind: lex.IdentifierToken, Value: "team"},
+			{Kind: lex.MathOperatorToken, Value: "!="},
+			{Kind: lex.StringToken, Value: "x"},
+			{Kind: lex.KeywordToken, Value: "order"},
+			{Kind: lex.KeywordToken, Value: "by"},
+			{Kind: lex.IdentifierToken, Value: "total"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(40), cursor)
+		require.Len(t, result.SelectedColumns, 3)
+		require.Equal(t, LiteralKind, result.SelectedColumns[0].Kind)
+		require.Equal(t, AggregateKind, result.SelectedColumns[1].Kind)
+		require.Equal(t, "count(*)", result.SelectedColumns[1].Aggregate.String())
+		require.Nil(t, result.SelectedColumns[1].Aggregate.Argument)
+		require.Equal(t, "sum(distinct score)", result.SelectedColumns[2].Aggregate.String())
+		require.True(t, result.SelectedColumns[2].Aggregate.Distinct)
+		require.Equal(t, "total", result.SelectedColumns[2].Alias.Value)
+		require.Equal(t, ">", result.Where.Token.Value)
+		require.Len(t, result.GroupBy, 2)
+		require.Equal(t, "season", result.GroupBy[1].Value)
+		require.Equal(t, "and", result.Having.Token.Value)
+		require.Equal(t, "count(*)", result.Having.Left.Left.Token.Value)
+		require.Equal(t, "count(*)", result.Having.Left.Left.Aggregate.String())
+		require.Nil(t, result.Having.Right.Left.Aggregate)
+		require.Equal(t, "total", result.OrderBy[0].Column.Value)
+	})
+
+	t.Run("invalid SELECT statement - SUM of *", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.KeywordToken, Value: "sum"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "games"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid SELECT statement - GROUP without BY", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.IdentifierToken, Value: "team"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "games"},
+			{Kind: lex.KeywordToken, Value: "group"},
+			{Kind: lex.IdentifierToken, Value: "team"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid SELECT statement - HAVING without comparison", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.IdentifierToken, Value: "team"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "games"},
+			{Kind: lex.KeywordToken, Value: "group"},
+			{Kind: lex.KeywordToken, Value: "by"},
+			{Kind: lex.IdentifierToken, Value: "team"},
+			{Kind: lex.KeywordToken, Value: "having"},
+			{Kind: lex.KeywordToken, Value: "count"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("valid SELECT statement with joins", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.IdentifierToken, Value: "u"},
+			{Kind: lex.KeywordToken, Value: "full"},
+			{Kind: lex.KeywordToken, Value: "join"},
+			{Kind: lex.IdentifierToken, Value: "orders"},
+			{Kind: lex.KeywordToken, Value: "as"},
+			{Kind: lex.IdentifierToken, Value: "o"},
+			{Kind: lex.KeywordToken, Value: "on"},
+			{Kind: lex.IdentifierToken, Value: "o.user_id"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.IdentifierToken, Value: "u.id"},
+			{Kind: lex.KeywordToken, Value: "right"},
+			{Kind: lex.KeywordToken, Value: "join"},
+			{Kind: lex.IdentifierToken, Value: "teams"},
+			{Kind: lex.KeywordToken, Value: "using"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.IdentifierToken, Value: "team"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.IdentifierToken, Value: "season"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.KeywordToken, Value: "cross"},
+			{Kind: lex.KeywordToken, Value: "join"},
+			{Kind: lex.IdentifierToken, Value: "tags"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.IdentifierToken, Value: "notes"},
+			{Kind: lex.IdentifierToken, Value: "n"},
+			{Kind: lex.KeywordToken, Value: "order"},
+			{Kind: lex.KeywordToken, Value: "by"},
+			{Kind: lex.IdentifierToken, Value: "u.id"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(32), cursor)
+		require.Equal(t, "users", result.From.Value)
+		require.Equal(t, "u", result.FromAlias.Value)
+		require.Len(t, result.Joins, 4)
+		require.Equal(t, FullJoin, result.Joins[0].Kind)
+		require.Equal(t, "orders", result.Joins[0].Table.Value)
+		require.Equal(t, "o", result.Joins[0].Alias.Value)
+		require.Equal(t, "=", result.Joins[0].On.Token.Value)
+		require.Equal(t, "u.id", result.Joins[0].On.Right.Token.Value)
+		require.Equal(t, RightJoin, result.Joins[1].Kind)
+		require.Nil(t, result.Joins[1].Alias)
+		require.Nil(t, result.Joins[1].On)
+		require.Len(t, result.Joins[1].Using, 2)
+		require.Equal(t, "season", result.Joins[1].Using[1].Value)
+		require.Equal(t, CrossJoin, result.Joins[2].Kind)
+		require.Equal(t, "tags", result.Joins[2].Table.Value)
+		require.Equal(t, CrossJoin, result.Joins[3].Kind)
+		require.Equal(t, "n", result.Joins[3].Alias.Value)
+		require.Nil(t, result.Where)
+		require.Equal(t, "u.id", result.OrderBy[0].Column.Value)
+	})
+
+	t.Run("invalid SELECT statement - JOIN without condition", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "join"},
+			{Kind: lex.IdentifierToken, Value: "orders"},
+			{Kind: lex.KeywordToken, Value: "where"},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.NumericToken, Value: "1"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid SELECT statement - LEFT without JOIN", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "left"},
+			{Kind: lex.IdentifierToken, Value: "orders"},
+			{Kind: lex.KeywordToken, Value: "using"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid SELECT statement - ON without comparison", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "join"},
+			{Kind: lex.IdentifierToken, Value: "orders"},
+			{Kind: lex.KeywordToken, Value: "on"},
+			{Kind: lex.IdentifierToken, Value: "user_id"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("valid SELECT statement with subqueries", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.IdentifierToken, Value: "name"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.KeywordToken, Value: "count"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "purchases"},
+			{Kind: lex.IdentifierToken, Value: "p"},
+			{Kind: lex.KeywordToken, Value: "where"},
+			{Kind: lex.IdentifierToken, Value: "p.user_id"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.IdentifierToken, Value: "u.id"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.KeywordToken, Value: "as"},
+			{Kind: lex.IdentifierToken, Value: "total"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.IdentifierToken, Value: "u"},
+			{Kind: lex.KeywordToken, Value: "where"},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.KeywordToken, Value: "not"},
+			{Kind: lex.KeywordToken, Value: "in"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.IdentifierToken, Value: "user_id"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "bans"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.LogicalOperatorToken, Value: "and"},
+			{Kind: lex.KeywordToken, Value: "exists"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "purchases"},
+			{Kind: lex.KeywordToken, Value: "where"},
+			{Kind: lex.IdentifierToken, Value: "purchases.user_id"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.IdentifierToken, Value: "u.id"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.LogicalOperatorToken, Value: "and"},
+			{Kind: lex.IdentifierToken, Value: "score"},
+			{Kind: lex.MathOperatorToken, Value: ">"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.KeywordToken, Value: "min"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.IdentifierToken, Value: "score"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(56), cursor)
+		require.Len(t, result.SelectedColumns, 2)
+		require.Equal(t, SubqueryKind, result.SelectedColumns[1].Kind)
+		require.Equal(t, "purchases", result.SelectedColumns[1].Subquery.From.Value)
+		require.Equal(t, "u.id", result.SelectedColumns[1].Subquery.Where.Right.Token.Value)
+		require.Equal(t, "total", result.SelectedColumns[1].Alias.Value)
+
+		in := result.Where.Left
+		require.Equal(t, InSubquery, in.Subquery.Type)
+		require.True(t, in.Subquery.Not)
+		require.Equal(t, "id", in.Left.Token.Value)
+		require.Nil(t, in.Right)
+		require.Equal(t, "bans", in.Subquery.Select.From.Value)
+
+		exists := result.Where.Right.Left
+		require.Equal(t, ExistsSubquery, exists.Subquery.Type)
+		require.False(t, exists.Subquery.Not)
+		require.Nil(t, exists.Left)
+		require.Equal(t, "purchases.user_id", exists.Subquery.Select.Where.Left.Token.Value)
+
+		scalar := result.Where.Right.Right
+		require.Equal(t, ">", scalar.Token.Value)
+		require.Nil(t, scalar.Left.Subquery)
+		require.Equal(t, ScalarSubquery, scalar.Right.Subquery.Type)
+		require.Equal(t, "min(score)", scalar.Right.Subquery.Select.SelectedColumns[0].Aggregate.String())
+	})
+
+	t.Run("valid SELECT statement with subqueries in FROM and JOIN", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "limit"},
+			{Kind: lex.NumericToken, Value: "5"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.KeywordToken, Value: "as"},
+			{Kind: lex.IdentifierToken, Value: "top"},
+			{Kind: lex.KeywordToken, Value: "join"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.IdentifierToken, Value: "user_id"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "purchases"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.IdentifierToken, Value: "p"},
+			{Kind: lex.KeywordToken, Value: "on"},
+			{Kind: lex.IdentifierToken, Value: "p.user_id"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.IdentifierToken, Value: "top.id"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(25), cursor)
+		require.Equal(t, "users", result.FromSubquery.From.Value)
+		require.Equal(t, 5, result.FromSubquery.Limit)
+		require.Equal(t, "top", result.FromAlias.Value)
+		require.Len(t, result.Joins, 1)
+		require.Equal(t, InnerJoin, result.Joins[0].Kind)
+		require.Equal(t, "purchases", result.Joins[0].Subquery.From.Value)
+		require.Equal(t, "p", result.Joins[0].Alias.Value)
+		require.Equal(t, "top.id", result.Joins[0].On.Right.Token.Value)
+	})
+
+	t.Run("invalid SELECT statement - subquery in FROM without alias", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid SELECT statement - IN without subquery", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "where"},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.KeywordToken, Value: "in"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.NumericToken, Value: "1"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.NumericToken, Value: "2"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid SELECT statement - EXISTS compared with value", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "where"},
+			{Kind: lex.KeywordToken, Value: "exists"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "bans"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.NumericToken, Value: "1"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid SELECT statement - subquery in ON", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "join"},
+			{Kind: lex.IdentifierToken, Value: "bans"},
+			{Kind: lex.KeywordToken, Value: "on"},
+			{Kind: lex.KeywordToken, Value: "exists"},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.KeywordToken, Value: "from"},
+			{Kind: lex.IdentifierToken, Value: "purchases"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseSelectStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
 }
@@ -0,0 +1,77 @@
+package ast
+
+import (
+	"custom-database/internal/parser/lex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTransactionStatement(t *testing.T) {
+	t.Run("valid BEGIN statement", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "begin"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		kind, cursor, ok := parseTransactionStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(1), cursor)
+		require.Equal(t, BeginKind, kind)
+	})
+
+	t.Run("valid BEGIN TRANSACTION statement", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "begin"},
+			{Kind: lex.KeywordToken, Value: "transaction"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		kind, cursor, ok := parseTransactionStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(2), cursor)
+		require.Equal(t, BeginKind, kind)
+	})
+
+	t.Run("valid COMMIT and ROLLBACK statements", func(t *testing.T) {
+		for value, expected := range map[string]AstKind{"commit": CommitKind, "rollback": RollbackKind} {
+			tokens := []*lex.Token{
+				{Kind: lex.KeywordToken, Value: value},
+				{Kind: lex.SymbolToken, Value: ";"},
+			}
+
+			kind, cursor, ok := parseTransactionStatement(tokens, 0)
+
+			require.True(t, ok)
+			require.Equal(t, uint(1), cursor)
+			require.Equal(t, expected, kind)
+		}
+	})
+
+	t.Run("invalid COMMIT statement - extra token", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "commit"},
+			{Kind: lex.KeywordToken, Value: "transaction"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		_, cursor, ok := parseTransactionStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+	})
+
+	t.Run("not a transaction statement", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "select"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		_, cursor, ok := parseTransactionStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+	})
+}
@@ -0,0 +1,222 @@
+package ast
+
+import (
+	"custom-database/internal/parser/lex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUpdateStatement(t *testing.T) {
+	t.Run("valid UPDATE statement without WHERE", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "update"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "set"},
+			{Kind: lex.IdentifierToken, Value: "name"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.StringToken, Value: "Bob"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseUpdateStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(6), cursor)
+		require.Equal(t, "users", result.Table.Value)
+		require.Len(t, result.Set, 1)
+		require.Equal(t, "name", result.Set[0].Column.Value)
+		require.Equal(t, "Bob", result.Set[0].Value.Literal.Value)
+		require.Nil(t, result.Where)
+	})
+
+	t.Run("valid UPDATE statement with several columns and WHERE", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "update"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "set"},
+			{Kind: lex.IdentifierToken, Value: "name"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.StringToken, Value: "Bob"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.IdentifierToken, Value: "is_admin"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.NullToken, Value: "null"},
+			{Kind: lex.KeywordToken, Value: "where"},
+			{Kind: lex.IdentifierToken, Value: "id"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.NumericToken, Value: "1"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseUpdateStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(14), cursor)
+		require.Len(t, result.Set, 2)
+		require.Equal(t, "is_admin", result.Set[1].Column.Value)
+		require.Equal(t, lex.NullToken, result.Set[1].Value.Literal.Kind)
+		require.NotNil(t, result.Where)
+		require.Equal(t, "=", result.Where.Token.Value)
+		require.Equal(t, "id", result.Where.Left.Token.Value)
+	})
+
+	t.Run("valid UPDATE statement with arithmetic", func(t *testing.T) {
+		// score = (score + 1) * 2 - bonus / 3
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "update"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "set"},
+			{Kind: lex.IdentifierToken, Value: "score"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.SymbolToken, Value: "("},
+			{Kind: lex.IdentifierToken, Value: "score"},
+			{Kind: lex.SymbolToken, Value: "+"},
+			{Kind: lex.NumericToken, Value: "1"},
+			{Kind: lex.SymbolToken, Value: ")"},
+			{Kind: lex.SymbolToken, Value: "*"},
+			{Kind: lex.NumericToken, Value: "2"},
+			{Kind: lex.SymbolToken, Value: "-"},
+			{Kind: lex.IdentifierToken, Value: "bonus"},
+			{Kind: lex.SymbolToken, Value: "/"},
+			{Kind: lex.NumericToken, Value: "3"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseUpdateStatement(tokens, 0)
+
+		require.True(t, ok)
+		require.Equal(t, uint(16), cursor)
+		value := result.Set[0].Value
+		require.Equal(t, ArithmeticKind, value.Kind)
+		require.Equal(t, "-", value.Literal.Value)
+
+		product := value.Arithmetic.Left
+		require.Equal(t, "*", product.Literal.Value)
+		require.Equal(t, "+", product.Arithmetic.Left.Literal.Value)
+		require.Equal(t, "score", product.Arithmetic.Left.Arithmetic.Left.Literal.Value)
+		require.Equal(t, "2", product.Arithmetic.Right.Literal.Value)
+
+		quotient := value.Arithmetic.Right
+		require.Equal(t, "/", quotient.Literal.Value)
+		require.Equal(t, "bonus", quotient.Arithmetic.Left.Literal.Value)
+		require.Equal(t, "3", quotient.Arithmetic.Right.Literal.Value)
+	})
+
+	t.Run("valid UPDATE statement with left-associative subtraction", func(t *testing.T) {
+		// score = score - 1 - 2
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "update"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "set"},
+			{Kind: lex.IdentifierToken, Value: "score"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.IdentifierToken, Value: "score"},
+			{Kind: lex.SymbolToken, Value: "-"},
+			{Kind: lex.NumericToken, Value: "1"},
+			{Kind: lex.SymbolToken, Value: "-"},
+			{Kind: lex.NumericToken, Value: "2"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, _, ok := parseUpdateStatement(tokens, 0)
+
+		require.True(t, ok)
+		value := result.Set[0].Value
+		require.Equal(t, "2", value.Arithmetic.Right.Literal.Value)
+		require.Equal(t, "score", value.Arithmetic.Left.Arithmetic.Left.Literal.Value)
+		require.Equal(t, "1", value.Arithmetic.Left.Arithmetic.Right.Literal.Value)
+	})
+
+	t.Run("invalid UPDATE statement - missing operand", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "update"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "set"},
+			{Kind: lex.IdentifierToken, Value: "score"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.IdentifierToken, Value: "score"},
+			{Kind: lex.SymbolToken, Value: "+"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseUpdateStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid UPDATE statement - DEFAULT in arithmetic", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "update"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "set"},
+			{Kind: lex.IdentifierToken, Value: "score"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.KeywordToken, Value: "default"},
+			{Kind: lex.SymbolToken, Value: "+"},
+			{Kind: lex.NumericToken, Value: "1"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseUpdateStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid UPDATE statement - missing SET", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "update"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.IdentifierToken, Value: "name"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.StringToken, Value: "Bob"},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseUpdateStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid UPDATE statement - missing value", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "update"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "set"},
+			{Kind: lex.IdentifierToken, Value: "name"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseUpdateStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid UPDATE statement - trailing comma", func(t *testing.T) {
+		tokens := []*lex.Token{
+			{Kind: lex.KeywordToken, Value: "update"},
+			{Kind: lex.IdentifierToken, Value: "users"},
+			{Kind: lex.KeywordToken, Value: "set"},
+			{Kind: lex.IdentifierToken, Value: "name"},
+			{Kind: lex.MathOperatorToken, Value: "="},
+			{Kind: lex.StringToken, Value: "Bob"},
+			{Kind: lex.SymbolToken, Value: ","},
+			{Kind: lex.SymbolToken, Value: ";"},
+		}
+
+		result, cursor, ok := parseUpdateStatement(tokens, 0)
+
+		require.False(t, ok)
+		require.Equal(t, uint(0), cursor)
+		require.Nil(t, result)
+	})
+}
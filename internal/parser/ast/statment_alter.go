@@ -0,0 +1,132 @@
+package ast
+
+import "custom-database/internal/parser/lex"
+
+// parseAlterTableStatement разбирает ALTER TABLE table и одно изменение таблицы:
+// ADD [COLUMN] column type [constraints] | DROP [COLUMN] column | RENAME [COLUMN] column TO name |
+// RENAME TO name | ALTER [COLUMN] column TYPE type
+func parseAlterTableStatement(tokens []*lex.Token, initialCursor uint) (*AlterTableStatement, uint, bool) {
+	cursor := initialCursor
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.AlterKeyword)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.TableKeyword)) {
+		helpMessage(tokens, cursor, "Expected TABLE")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	table, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected table name")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	statement := &AlterTableStatement{Table: *table}
+	switch {
+	case expectToken(tokens, cursor, tokenFromKeyword(lex.AddKeyword)):
+		cursor = skipColumnKeyword(tokens, cursor+1)
+
+		column, newCursor, ok := parseColumnDefinition(tokens, cursor)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		statement.Action = AddColumnAction
+		statement.Column = column
+	case expectToken(tokens, cursor, tokenFromKeyword(lex.DropKeyword)):
+		cursor = skipColumnKeyword(tokens, cursor+1)
+
+		column, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected column name")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		statement.Action = DropColumnAction
+		statement.ColumnName = *column
+	case expectToken(tokens, cursor, tokenFromKeyword(lex.RenameKeyword)):
+		cursor++
+
+		statement.Action = RenameTableAction
+		if !expectToken(tokens, cursor, tokenFromKeyword(lex.ToKeyword)) {
+			cursor = skipColumnKeyword(tokens, cursor)
+
+			column, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+			if !ok {
+				helpMessage(tokens, cursor, "Expected column name or TO")
+				return nil, initialCursor, false
+			}
+			cursor = newCursor
+
+			if !expectToken(tokens, cursor, tokenFromKeyword(lex.ToKeyword)) {
+				helpMessage(tokens, cursor, "Expected TO")
+				return nil, initialCursor, false
+			}
+
+			statement.Action = RenameColumnAction
+			statement.ColumnName = *column
+		}
+		cursor++
+
+		name, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected new name")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		statement.NewName = *name
+	case expectToken(tokens, cursor, tokenFromKeyword(lex.AlterKeyword)):
+		cursor = skipColumnKeyword(tokens, cursor+1)
+
+		column, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected column name")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		if !expectToken(tokens, cursor, tokenFromKeyword(lex.TypeKeyword)) {
+			helpMessage(tokens, cursor, "Expected TYPE")
+			return nil, initialCursor, false
+		}
+		cursor++
+
+		datatype, newCursor, ok := parseToken(tokens, cursor, lex.KeywordToken)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected column type")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		statement.Action = AlterColumnTypeAction
+		statement.ColumnName = *column
+		statement.Datatype = *datatype
+	default:
+		helpMessage(tokens, cursor, "Expected ADD, DROP, RENAME or ALTER")
+		return nil, initialCursor, false
+	}
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.SemicolonSymbol)) {
+		helpMessage(tokens, cursor, "Expected semicolon")
+		return nil, initialCursor, false
+	}
+
+	return statement, cursor, true
+}
+
+// skipColumnKeyword пропускает необязательное COLUMN
+func skipColumnKeyword(tokens []*lex.Token, cursor uint) uint {
+	if expectToken(tokens, cursor, tokenFromKeyword(lex.ColumnKeyword)) {
+		return cursor + 1
+	}
+
+	return cursor
+}
@@ -2,9 +2,11 @@ package ast
 
 import "custom-database/internal/parser/lex"
 
-type columnDefinition struct {
+type ColumnDefinition struct {
 	Name     lex.Token
 	Datatype lex.Token
+	// Constraints - ограничения, записанные после типа колонки
+	Constraints []*ConstraintDefinition
 }
 
 func parseCreateTableStatement(tokens []*lex.Token, initialCursor uint) (*CreateTableStatement, uint, bool) {
@@ -27,13 +29,34 @@ func parseCreateTableStatement(tokens []*lex.Token, initialCursor uint) (*Create
 	}
 	cursor = newCursor
 
+	if expectToken(tokens, cursor, tokenFromKeyword(lex.AsKeyword)) {
+		cursor++
+
+		slct, newCursor, ok := parseSelectStatement(tokens, cursor)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected SELECT")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		if !expectToken(tokens, cursor, tokenFromSymbol(lex.SemicolonSymbol)) {
+			helpMessage(tokens, cursor, "Expected semicolon")
+			return nil, initialCursor, false
+		}
+
+		return &CreateTableStatement{
+			Name:     *tableName,
+			AsSelect: slct,
+		}, cursor, true
+	}
+
 	if !expectToken(tokens, cursor, tokenFromSymbol(lex.LeftparenSymbol)) {
-		helpMessage(tokens, cursor, "Expected left parenthesis")
+		helpMessage(tokens, cursor, "Expected left parenthesis or AS")
 		return nil, initialCursor, false
 	}
 	cursor++
 
-	cols, newCursor, ok := parseColumnDefinitions(tokens, cursor, tokenFromSymbol(lex.RightparenSymbol))
+	cols, constraints, newCursor, ok := parseColumnDefinitions(tokens, cursor, tokenFromSymbol(lex.RightparenSymbol))
 	if !ok {
 		return nil, initialCursor, false
 	}
@@ -51,18 +74,22 @@ func parseCreateTableStatement(tokens []*lex.Token, initialCursor uint) (*Create
 	}
 
 	return &CreateTableStatement{
-		Name: *tableName,
-		Cols: cols,
+		Name:        *tableName,
+		Cols:        cols,
+		Constraints: constraints,
 	}, cursor, true
 }
 
-func parseColumnDefinitions(tokens []*lex.Token, initialCursor uint, endDelimiter lex.Token) (*[]*columnDefinition, uint, bool) {
+// parseColumnDefinitions разбирает список колонок и ограничений уровня таблицы, которые можно перемешивать:
+// (id int PRIMARY KEY, email text, UNIQUE (email))
+func parseColumnDefinitions(tokens []*lex.Token, initialCursor uint, endDelimiter lex.Token) (*[]*ColumnDefinition, []*ConstraintDefinition, uint, bool) {
 	cursor := initialCursor
 
-	cds := []*columnDefinition{}
+	cds := []*ColumnDefinition{}
+	constraints := []*ConstraintDefinition{}
 	for {
 		if cursor >= uint(len(tokens)) {
-			return nil, initialCursor, false
+			return nil, nil, initialCursor, false
 		}
 
 		// Look for a delimiter
@@ -72,36 +99,337 @@ func parseColumnDefinitions(tokens []*lex.Token, initialCursor uint, endDelimite
 		}
 
 		// Look for a comma
-		if len(cds) > 0 {
+		if len(cds) > 0 || len(constraints) > 0 {
 			if !expectToken(tokens, cursor, tokenFromSymbol(lex.CommaSymbol)) {
 				helpMessage(tokens, cursor, "Expected comma")
-				return nil, initialCursor, false
+				return nil, nil, initialCursor, false
 			}
 
 			cursor++
 		}
 
-		// Look for a column name
-		columnName, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+		// Look for a table constraint
+		if isConstraintStart(tokens, cursor) {
+			constraint, newCursor, ok := parseConstraint(tokens, cursor, true)
+			if !ok {
+				return nil, nil, initialCursor, false
+			}
+			cursor = newCursor
+
+			constraints = append(constraints, constraint)
+			continue
+		}
+
+		cd, newCursor, ok := parseColumnDefinition(tokens, cursor)
+		if !ok {
+			return nil, nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		cds = append(cds, cd)
+	}
+
+	return &cds, constraints, cursor, true
+}
+
+// parseColumnDefinition разбирает колонку: name type [constraints]
+func parseColumnDefinition(tokens []*lex.Token, initialCursor uint) (*ColumnDefinition, uint, bool) {
+	cursor := initialCursor
+
+	// Look for a column name
+	columnName, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected column name")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	// Look for a column type
+	columnType, newCursor, ok := parseToken(tokens, cursor, lex.KeywordToken)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected column type")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	cd := &ColumnDefinition{
+		Name:     *columnName,
+		Datatype: *columnType,
+	}
+
+	// Look for column constraints
+	for isConstraintStart(tokens, cursor) {
+		constraint, newCursor, ok := parseConstraint(tokens, cursor, false)
 		if !ok {
-			helpMessage(tokens, cursor, "Expected column name")
 			return nil, initialCursor, false
 		}
 		cursor = newCursor
 
-		// Look for a column type
-		columnType, newCursor, ok := parseToken(tokens, cursor, lex.KeywordToken)
+		cd.Constraints = append(cd.Constraints, constraint)
+	}
+
+	return cd, cursor, true
+}
+
+func isConstraintStart(tokens []*lex.Token, cursor uint) bool {
+	for _, keyword := range []lex.Keyword{lex.ConstraintKeyword, lex.PrimaryKeyword, lex.UniqueKeyword, lex.NotKeyword, lex.DefaultKeyword, lex.CheckKeyword, lex.ForeignKeyword, lex.ReferencesKeyword} {
+		if expectToken(tokens, cursor, tokenFromKeyword(keyword)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseConstraint разбирает [CONSTRAINT name] PRIMARY KEY | UNIQUE | NOT NULL | DEFAULT value | CHECK (condition) |
+// REFERENCES table [(columns)] [ON DELETE action] [ON UPDATE action].
+// У PRIMARY KEY и UNIQUE уровня таблицы после ключевых слов идет список колонок в скобках,
+// а внешний ключ таблицы начинается с FOREIGN KEY (columns)
+func parseConstraint(tokens []*lex.Token, initialCursor uint, tableLevel bool) (*ConstraintDefinition, uint, bool) {
+	cursor := initialCursor
+	constraint := &ConstraintDefinition{}
+
+	if expectToken(tokens, cursor, tokenFromKeyword(lex.ConstraintKeyword)) {
+		cursor++
+
+		name, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
 		if !ok {
-			helpMessage(tokens, cursor, "Expected column type")
+			helpMessage(tokens, cursor, "Expected constraint name")
 			return nil, initialCursor, false
 		}
 		cursor = newCursor
+		constraint.Name = name
+	}
+
+	switch {
+	case expectToken(tokens, cursor, tokenFromKeyword(lex.PrimaryKeyword)):
+		cursor++
+		if !expectToken(tokens, cursor, tokenFromKeyword(lex.KeyKeyword)) {
+			helpMessage(tokens, cursor, "Expected KEY")
+			return nil, initialCursor, false
+		}
+		cursor++
+		constraint.Kind = PrimaryKeyConstraint
+	case expectToken(tokens, cursor, tokenFromKeyword(lex.UniqueKeyword)):
+		cursor++
+		constraint.Kind = UniqueConstraint
+	case expectToken(tokens, cursor, tokenFromKeyword(lex.CheckKeyword)):
+		cursor++
+		check, text, newCursor, ok := parseCheckCondition(tokens, cursor)
+		if !ok {
+			return nil, initialCursor, false
+		}
 
-		cds = append(cds, &columnDefinition{
-			Name:     *columnName,
-			Datatype: *columnType,
-		})
+		constraint.Kind = CheckConstraint
+		constraint.Check, constraint.CheckText = check, text
+		return constraint, newCursor, true
+	case tableLevel && expectToken(tokens, cursor, tokenFromKeyword(lex.ForeignKeyword)):
+		cursor++
+		if !expectToken(tokens, cursor, tokenFromKeyword(lex.KeyKeyword)) {
+			helpMessage(tokens, cursor, "Expected KEY")
+			return nil, initialCursor, false
+		}
+		cursor++
+
+		columns, newCursor, ok := parseParenColumnNames(tokens, cursor)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+		constraint.Columns = columns
+
+		if !expectToken(tokens, cursor, tokenFromKeyword(lex.ReferencesKeyword)) {
+			helpMessage(tokens, cursor, "Expected REFERENCES")
+			return nil, initialCursor, false
+		}
+
+		newCursor, ok = parseReferences(tokens, cursor, constraint)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		return constraint, newCursor, true
+	case !tableLevel && expectToken(tokens, cursor, tokenFromKeyword(lex.ReferencesKeyword)):
+		newCursor, ok := parseReferences(tokens, cursor, constraint)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		return constraint, newCursor, true
+	case !tableLevel && expectToken(tokens, cursor, tokenFromKeyword(lex.NotKeyword)):
+		cursor++
+		if _, newCursor, ok := parseToken(tokens, cursor, lex.NullToken); ok {
+			constraint.Kind = NotNullConstraint
+			return constraint, newCursor, true
+		}
+
+		helpMessage(tokens, cursor, "Expected NULL")
+		return nil, initialCursor, false
+	case !tableLevel && expectToken(tokens, cursor, tokenFromKeyword(lex.DefaultKeyword)):
+		cursor++
+		if expectToken(tokens, cursor, tokenFromKeyword(lex.CurrentTimestampKeyword)) {
+			constraint.Kind = DefaultConstraint
+			constraint.Default = tokens[cursor]
+			return constraint, cursor + 1, true
+		}
+
+		value, newCursor, ok := parseExpression(tokens, cursor, tokenFromSymbol(lex.CommaSymbol))
+		if !ok || value.Kind != LiteralKind || value.Literal.Kind == lex.IdentifierToken {
+			helpMessage(tokens, cursor, "Expected default value")
+			return nil, initialCursor, false
+		}
+
+		constraint.Kind = DefaultConstraint
+		constraint.Default = value.Literal
+		return constraint, newCursor, true
+	default:
+		helpMessage(tokens, cursor, "Expected PRIMARY KEY, UNIQUE, NOT NULL, DEFAULT, CHECK or REFERENCES")
+		return nil, initialCursor, false
+	}
+
+	if !tableLevel {
+		return constraint, cursor, true
+	}
+
+	columns, newCursor, ok := parseParenColumnNames(tokens, cursor)
+	if !ok {
+		return nil, initialCursor, false
+	}
+	constraint.Columns = columns
+
+	return constraint, newCursor, true
+}
+
+// parseParenColumnNames разбирает список колонок в скобках: (a, b)
+func parseParenColumnNames(tokens []*lex.Token, initialCursor uint) ([]lex.Token, uint, bool) {
+	cursor := initialCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.LeftparenSymbol)) {
+		helpMessage(tokens, cursor, "Expected left parenthesis")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	columns, newCursor, ok := parseColumnNames(tokens, cursor, tokenFromSymbol(lex.RightparenSymbol))
+	if !ok {
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.RightparenSymbol)) {
+		helpMessage(tokens, cursor, "Expected right parenthesis")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	return columns, cursor, true
+}
+
+// parseReferences разбирает REFERENCES table [(columns)] [ON DELETE action] [ON UPDATE action]
+// и записывает их во внешний ключ constraint
+func parseReferences(tokens []*lex.Token, initialCursor uint, constraint *ConstraintDefinition) (uint, bool) {
+	cursor := initialCursor
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.ReferencesKeyword)) {
+		helpMessage(tokens, cursor, "Expected REFERENCES")
+		return initialCursor, false
+	}
+	cursor++
+
+	table, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected referenced table name")
+		return initialCursor, false
+	}
+	cursor = newCursor
+
+	constraint.Kind = ForeignKeyConstraint
+	constraint.References = table
+
+	if expectToken(tokens, cursor, tokenFromSymbol(lex.LeftparenSymbol)) {
+		columns, newCursor, ok := parseParenColumnNames(tokens, cursor)
+		if !ok {
+			return initialCursor, false
+		}
+		cursor = newCursor
+		constraint.ReferencedColumns = columns
+	}
+
+	seenDelete, seenUpdate := false, false
+	for expectToken(tokens, cursor, tokenFromKeyword(lex.OnKeyword)) {
+		cursor++
+
+		var action *ReferentialAction
+		switch {
+		case !seenDelete && expectToken(tokens, cursor, tokenFromKeyword(lex.DeleteKeyword)):
+			seenDelete = true
+			action = &constraint.OnDelete
+		case !seenUpdate && expectToken(tokens, cursor, tokenFromKeyword(lex.UpdateKeyword)):
+			seenUpdate = true
+			action = &constraint.OnUpdate
+		default:
+			helpMessage(tokens, cursor, "Expected DELETE or UPDATE")
+			return initialCursor, false
+		}
+		cursor++
+
+		switch {
+		case expectToken(tokens, cursor, tokenFromKeyword(lex.RestrictKeyword)):
+			*action = RestrictAction
+			cursor++
+		case expectToken(tokens, cursor, tokenFromKeyword(lex.CascadeKeyword)):
+			*action = CascadeAction
+			cursor++
+		case expectToken(tokens, cursor, tokenFromKeyword(lex.SetKeyword)):
+			cursor++
+			_, newCursor, ok := parseToken(tokens, cursor, lex.NullToken)
+			if !ok {
+				helpMessage(tokens, cursor, "Expected NULL")
+				return initialCursor, false
+			}
+			*action = SetNullAction
+			cursor = newCursor
+		default:
+			helpMessage(tokens, cursor, "Expected RESTRICT, CASCADE or SET NULL")
+			return initialCursor, false
+		}
+	}
+
+	return cursor, true
+}
+
+// parseCheckCondition разбирает условие CHECK в скобках. Внутри условия могут быть свои скобки,
+// поэтому конец ищется по парной скобке, а не по первой закрывающей
+func parseCheckCondition(tokens []*lex.Token, initialCursor uint) (*WhereClause, string, uint, bool) {
+	cursor := initialCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.LeftparenSymbol)) {
+		helpMessage(tokens, cursor, "Expected left parenthesis")
+		return nil, "", initialCursor, false
+	}
+	cursor++
+
+	start := cursor
+	depth := 1
+	for ; cursor < uint(len(tokens)); cursor++ {
+		if expectToken(tokens, cursor, tokenFromSymbol(lex.LeftparenSymbol)) {
+			depth++
+		}
+		if expectToken(tokens, cursor, tokenFromSymbol(lex.RightparenSymbol)) {
+			depth--
+			if depth == 0 {
+				break
+			}
+		}
+	}
+	if depth != 0 {
+		helpMessage(tokens, cursor, "Expected right parenthesis")
+		return nil, "", initialCursor, false
+	}
+
+	condition, ok := parseCondition(tokens[start:cursor], nil)
+	if !ok {
+		helpMessage(tokens, start, "Expected check condition")
+		return nil, "", initialCursor, false
 	}
 
-	return &cds, cursor, true
+	return condition, formatCondition(tokens[start:cursor]), cursor + 1, true
 }
@@ -0,0 +1,46 @@
+package ast
+
+import "custom-database/internal/parser/lex"
+
+func parseDeleteStatement(tokens []*lex.Token, initialCursor uint) (*DeleteStatement, uint, bool) {
+	cursor := initialCursor
+
+	// Look for DELETE
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.DeleteKeyword)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	// Look for FROM
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.FromKeyword)) {
+		helpMessage(tokens, cursor, "Expected from")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	// Look for table name
+	table, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected table name")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	// Look for WHERE (optional)
+	where, newCursor, ok := parseWhereClause(tokens, cursor, []lex.Token{tokenFromSymbol(lex.SemicolonSymbol)})
+	if !ok {
+		helpMessage(tokens, cursor, "Invalid WHERE clause")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.SemicolonSymbol)) {
+		helpMessage(tokens, cursor, "Expected semicolon")
+		return nil, initialCursor, false
+	}
+
+	return &DeleteStatement{
+		Table: *table,
+		Where: where,
+	}, cursor, true
+}
@@ -26,12 +26,19 @@ func parseDropTableStatement(tokens []*lex.Token, initialCursor uint) (*DropTabl
 	}
 	cursor = newCursor
 
+	// Look for CASCADE
+	cascade := expectToken(tokens, cursor, tokenFromKeyword(lex.CascadeKeyword))
+	if cascade {
+		cursor++
+	}
+
 	if !expectToken(tokens, cursor, tokenFromSymbol(lex.SemicolonSymbol)) {
 		helpMessage(tokens, cursor, "Expected semicolon")
 		return nil, initialCursor, false
 	}
 
 	return &DropTableStatement{
-		Table: *table,
+		Table:   *table,
+		Cascade: cascade,
 	}, cursor, true
 }
@@ -0,0 +1,98 @@
+package ast
+
+import "custom-database/internal/parser/lex"
+
+// parseCreateIndexStatement разбирает CREATE INDEX name ON table (column [, ...])
+func parseCreateIndexStatement(tokens []*lex.Token, initialCursor uint) (*CreateIndexStatement, uint, bool) {
+	cursor := initialCursor
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.CreateKeyword)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.IndexKeyword)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	name, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected index name")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.OnKeyword)) {
+		helpMessage(tokens, cursor, "Expected ON")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	table, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected table name")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.LeftparenSymbol)) {
+		helpMessage(tokens, cursor, "Expected left parenthesis")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	columns, newCursor, ok := parseColumnNames(tokens, cursor, tokenFromSymbol(lex.RightparenSymbol))
+	if !ok {
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.RightparenSymbol)) {
+		helpMessage(tokens, cursor, "Expected right parenthesis")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.SemicolonSymbol)) {
+		helpMessage(tokens, cursor, "Expected semicolon")
+		return nil, initialCursor, false
+	}
+
+	return &CreateIndexStatement{
+		Name:    *name,
+		Table:   *table,
+		Columns: columns,
+	}, cursor, true
+}
+
+// parseDropIndexStatement разбирает DROP INDEX name
+func parseDropIndexStatement(tokens []*lex.Token, initialCursor uint) (*DropIndexStatement, uint, bool) {
+	cursor := initialCursor
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.DropKeyword)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.IndexKeyword)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	name, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected index name")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.SemicolonSymbol)) {
+		helpMessage(tokens, cursor, "Expected semicolon")
+		return nil, initialCursor, false
+	}
+
+	return &DropIndexStatement{
+		Name: *name,
+	}, cursor, true
+}
@@ -2,6 +2,8 @@ package ast
 
 import "custom-database/internal/parser/lex"
 
+// parseInsertStatement разбирает INSERT INTO table [(column [, ...])] VALUES (value [, ...]) [, (...)]
+// или INSERT INTO table [(column [, ...])] SELECT ...
 func parseInsertStatement(tokens []*lex.Token, initialCursor uint) (*InsertStatement, uint, bool) {
 	cursor := initialCursor
 
@@ -26,33 +28,74 @@ func parseInsertStatement(tokens []*lex.Token, initialCursor uint) (*InsertState
 	}
 	cursor = newCursor
 
+	// Look for column list
+	var columns []lex.Token
+	if expectToken(tokens, cursor, tokenFromSymbol(lex.LeftparenSymbol)) {
+		columns, newCursor, ok = parseParenColumnNames(tokens, cursor)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+	}
+
+	// Look for SELECT
+	if expectToken(tokens, cursor, tokenFromKeyword(lex.SelectKeyword)) {
+		slct, newCursor, ok := parseSelectStatement(tokens, cursor)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		if !expectToken(tokens, cursor, tokenFromSymbol(lex.SemicolonSymbol)) {
+			helpMessage(tokens, cursor, "Expected semicolon")
+			return nil, initialCursor, false
+		}
+
+		return &InsertStatement{
+			Table:   *table,
+			Columns: columns,
+			Select:  slct,
+		}, cursor, true
+	}
+
 	// Look for VALUES
 	if !expectToken(tokens, cursor, tokenFromKeyword(lex.ValuesKeyword)) {
-		helpMessage(tokens, cursor, "Expected VALUES")
+		helpMessage(tokens, cursor, "Expected VALUES or SELECT")
 		return nil, initialCursor, false
 	}
 	cursor++
 
-	// Look for left paren
-	if !expectToken(tokens, cursor, tokenFromSymbol(lex.LeftparenSymbol)) {
-		helpMessage(tokens, cursor, "Expected left paren")
-		return nil, initialCursor, false
-	}
-	cursor++
+	rows := []*[]*Expression{}
+	for {
+		// Look for left paren
+		if !expectToken(tokens, cursor, tokenFromSymbol(lex.LeftparenSymbol)) {
+			helpMessage(tokens, cursor, "Expected left paren")
+			return nil, initialCursor, false
+		}
+		cursor++
 
-	// Look for expression list
-	values, newCursor, ok := parseExpressions(tokens, cursor, []lex.Token{tokenFromSymbol(lex.RightparenSymbol)})
-	if !ok {
-		return nil, initialCursor, false
-	}
-	cursor = newCursor
+		// Look for expression list
+		values, newCursor, ok := parseExpressions(tokens, cursor, []lex.Token{tokenFromSymbol(lex.RightparenSymbol)})
+		if !ok {
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
 
-	// Look for right paren
-	if !expectToken(tokens, cursor, tokenFromSymbol(lex.RightparenSymbol)) {
-		helpMessage(tokens, cursor, "Expected right paren")
-		return nil, initialCursor, false
+		// Look for right paren
+		if !expectToken(tokens, cursor, tokenFromSymbol(lex.RightparenSymbol)) {
+			helpMessage(tokens, cursor, "Expected right paren")
+			return nil, initialCursor, false
+		}
+		cursor++
+
+		rows = append(rows, values)
+
+		// Look for comma before the next row
+		if !expectToken(tokens, cursor, tokenFromSymbol(lex.CommaSymbol)) {
+			break
+		}
+		cursor++
 	}
-	cursor++
 
 	if !expectToken(tokens, cursor, tokenFromSymbol(lex.SemicolonSymbol)) {
 		helpMessage(tokens, cursor, "Expected semicolon")
@@ -60,7 +103,8 @@ func parseInsertStatement(tokens []*lex.Token, initialCursor uint) (*InsertState
 	}
 
 	return &InsertStatement{
-		Table:  *table,
-		Values: values,
+		Table:   *table,
+		Columns: columns,
+		Rows:    rows,
 	}, cursor, true
 }
@@ -2,10 +2,43 @@ package ast
 
 import (
 	"custom-database/internal/parser/lex"
+	"slices"
 	"strconv"
 )
 
 func parseSelectStatement(tokens []*lex.Token, initialCursor uint) (*SelectStatement, uint, bool) {
+	return parseSelect(tokens, initialCursor, tokenFromSymbol(lex.SemicolonSymbol))
+}
+
+// parseSubquery разбирает подзапрос (SELECT ...) и возвращает курсор за закрывающей скобкой.
+// Если на месте курсора нет скобки и SELECT, возвращает false без сообщения
+func parseSubquery(tokens []*lex.Token, initialCursor uint) (*SelectStatement, uint, bool) {
+	cursor := initialCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.LeftparenSymbol)) ||
+		!expectToken(tokens, cursor+1, tokenFromKeyword(lex.SelectKeyword)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	statement, newCursor, ok := parseSelect(tokens, cursor, tokenFromSymbol(lex.RightparenSymbol))
+	if !ok {
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.RightparenSymbol)) {
+		helpMessage(tokens, cursor, "Expected right paren")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	return statement, cursor, true
+}
+
+// parseSelect разбирает SELECT, который заканчивается токеном end: точкой с запятой у запроса
+// и закрывающей скобкой у подзапроса. Курсор возвращается на end
+func parseSelect(tokens []*lex.Token, initialCursor uint, end lex.Token) (*SelectStatement, uint, bool) {
 	statement := &SelectStatement{
 		SelectedColumns: []*Expression{},
 		Where:           &WhereClause{},
@@ -22,12 +55,12 @@ func parseSelectStatement(tokens []*lex.Token, initialCursor uint) (*SelectState
 		statement.SelectedColumns = []*Expression{}
 		cursor++
 	} else {
-		exps, newCursor, ok := parseExpressions(tokens, cursor, []lex.Token{tokenFromKeyword(lex.FromKeyword), tokenFromSymbol(lex.SemicolonSymbol)})
+		exps, newCursor, ok := parseSelectExpressions(tokens, cursor)
 		if !ok {
 			return nil, initialCursor, false
 		}
 		cursor = newCursor
-		statement.SelectedColumns = *exps
+		statement.SelectedColumns = exps
 	}
 
 	// Парсим FROM (опционально)
@@ -36,16 +69,30 @@ func parseSelectStatement(tokens []*lex.Token, initialCursor uint) (*SelectState
 	}
 	cursor++
 
-	from, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+	from, subquery, alias, newCursor, ok := parseFromItem(tokens, cursor)
 	if !ok {
 		helpMessage(tokens, cursor, "Expected table name after FROM")
 		return nil, initialCursor, false
 	}
-	statement.From = *from
+	statement.From, statement.FromSubquery, statement.FromAlias = from, subquery, alias
+	cursor = newCursor
+
+	joins, newCursor, ok := parseJoins(tokens, cursor, end)
+	if !ok {
+		return nil, initialCursor, false
+	}
+	statement.Joins = joins
 	cursor = newCursor
 
-	// Парсим WHERE (опционально)
-	where, newCursor, ok := parseWhereClause(tokens, cursor, tokenFromSymbol(lex.SemicolonSymbol))
+	// Парсим WHERE (опционально). Условие заканчивается на следующей части запроса
+	where, newCursor, ok := parseWhereClause(tokens, cursor, []lex.Token{
+		end,
+		tokenFromKeyword(lex.GroupKeyword),
+		tokenFromKeyword(lex.HavingKeyword),
+		tokenFromKeyword(lex.OrderKeyword),
+		tokenFromKeyword(lex.LimitKeyword),
+		tokenFromKeyword(lex.OffsetKeyword),
+	})
 	if !ok {
 		helpMessage(tokens, cursor, "Invalid WHERE clause")
 		return nil, initialCursor, false
@@ -53,6 +100,33 @@ func parseSelectStatement(tokens []*lex.Token, initialCursor uint) (*SelectState
 	statement.Where = where
 	cursor = newCursor
 
+	groupBy, newCursor, ok := parseGroupBy(tokens, cursor)
+	if !ok {
+		return nil, initialCursor, false
+	}
+	statement.GroupBy = groupBy
+	cursor = newCursor
+
+	having, newCursor, ok := parseHavingClause(tokens, cursor, []lex.Token{
+		end,
+		tokenFromKeyword(lex.OrderKeyword),
+		tokenFromKeyword(lex.LimitKeyword),
+		tokenFromKeyword(lex.OffsetKeyword),
+	})
+	if !ok {
+		helpMessage(tokens, cursor, "Invalid HAVING clause")
+		return nil, initialCursor, false
+	}
+	statement.Having = having
+	cursor = newCursor
+
+	orderBy, newCursor, ok := parseOrderBy(tokens, cursor)
+	if !ok {
+		return nil, initialCursor, false
+	}
+	statement.OrderBy = orderBy
+	cursor = newCursor
+
 	limit, newCursor, ok := parseLimit(tokens, cursor)
 	if !ok {
 		helpMessage(tokens, cursor, "Expected limit value")
@@ -69,14 +143,380 @@ func parseSelectStatement(tokens []*lex.Token, initialCursor uint) (*SelectState
 	statement.Offset = offset
 	cursor = newCursor
 
-	if !expectToken(tokens, cursor, tokenFromSymbol(lex.SemicolonSymbol)) {
-		helpMessage(tokens, cursor, "Expected semicolon")
+	if !expectToken(tokens, cursor, end) {
+		if end.Value == string(lex.SemicolonSymbol) {
+			helpMessage(tokens, cursor, "Expected semicolon")
+		} else {
+			helpMessage(tokens, cursor, "Expected right paren")
+		}
 		return nil, initialCursor, false
 	}
 
 	return statement, cursor, true
 }
 
+// parseSelectExpressions разбирает список SELECT до FROM или точки с запятой: колонки, значения,
+// агрегатные функции и скалярные подзапросы, каждое - с необязательным AS name
+func parseSelectExpressions(tokens []*lex.Token, initialCursor uint) ([]*Expression, uint, bool) {
+	cursor := initialCursor
+
+	exps := []*Expression{}
+	for {
+		var exp *Expression
+		subquery, newCursor, ok := parseSubquery(tokens, cursor)
+		if ok {
+			exp = &Expression{Kind: SubqueryKind, Subquery: subquery}
+		} else if call, callCursor, ok := parseAggregateCall(tokens, cursor); ok {
+			exp, newCursor = &Expression{Kind: AggregateKind, Aggregate: call}, callCursor
+		} else {
+			exp, newCursor, ok = parseExpression(tokens, cursor, tokenFromSymbol(lex.CommaSymbol))
+			if !ok || exp.Kind != LiteralKind {
+				helpMessage(tokens, cursor, "Expected expression")
+				return nil, initialCursor, false
+			}
+		}
+		cursor = newCursor
+
+		if expectToken(tokens, cursor, tokenFromKeyword(lex.AsKeyword)) {
+			cursor++
+
+			alias, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+			if !ok {
+				helpMessage(tokens, cursor, "Expected alias")
+				return nil, initialCursor, false
+			}
+			cursor = newCursor
+			exp.Alias = alias
+		}
+
+		exps = append(exps, exp)
+
+		if !expectToken(tokens, cursor, tokenFromSymbol(lex.CommaSymbol)) {
+			break
+		}
+		cursor++
+	}
+
+	return exps, cursor, true
+}
+
+// parseAggregateCall разбирает COUNT(*) или COUNT | SUM | AVG | MIN | MAX ([DISTINCT] column).
+// Если на месте курсора нет агрегатной функции, возвращает false без сообщения
+func parseAggregateCall(tokens []*lex.Token, initialCursor uint) (*AggregateCall, uint, bool) {
+	cursor := initialCursor
+
+	functions := []lex.Keyword{lex.CountKeyword, lex.SumKeyword, lex.AvgKeyword, lex.MinKeyword, lex.MaxKeyword}
+	if !slices.ContainsFunc(functions, func(function lex.Keyword) bool {
+		return expectToken(tokens, cursor, tokenFromKeyword(function))
+	}) {
+		return nil, initialCursor, false
+	}
+	call := &AggregateCall{Function: *tokens[cursor]}
+	cursor++
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.LeftparenSymbol)) {
+		helpMessage(tokens, cursor, "Expected left paren")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	if call.Function.Value == string(lex.CountKeyword) && expectToken(tokens, cursor, tokenFromSymbol(lex.AsteriskSymbol)) {
+		cursor++
+	} else {
+		if expectToken(tokens, cursor, tokenFromKeyword(lex.DistinctKeyword)) {
+			call.Distinct = true
+			cursor++
+		}
+
+		argument, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected column name")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+		call.Argument = argument
+	}
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.RightparenSymbol)) {
+		helpMessage(tokens, cursor, "Expected right paren")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	return call, cursor, true
+}
+
+// parseFromItem разбирает таблицу FROM или JOIN: table [[AS] alias] или (SELECT ...) [AS] alias
+func parseFromItem(tokens []*lex.Token, initialCursor uint) (lex.Token, *SelectStatement, *lex.Token, uint, bool) {
+	cursor := initialCursor
+
+	subquery, newCursor, ok := parseSubquery(tokens, cursor)
+	if !ok {
+		table, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+		if !ok {
+			return lex.Token{}, nil, nil, initialCursor, false
+		}
+
+		alias, newCursor, ok := parseTableAlias(tokens, newCursor)
+		if !ok {
+			return lex.Token{}, nil, nil, initialCursor, false
+		}
+
+		return *table, nil, alias, newCursor, true
+	}
+	cursor = newCursor
+
+	alias, newCursor, ok := parseTableAlias(tokens, cursor)
+	if !ok {
+		return lex.Token{}, nil, nil, initialCursor, false
+	}
+	if alias == nil {
+		helpMessage(tokens, cursor, "Subquery in FROM must have an alias")
+		return lex.Token{}, nil, nil, initialCursor, false
+	}
+
+	return lex.Token{}, subquery, alias, newCursor, true
+}
+
+// parseTableAlias разбирает псевдоним таблицы: AS alias или alias (опционально)
+func parseTableAlias(tokens []*lex.Token, initialCursor uint) (*lex.Token, uint, bool) {
+	cursor := initialCursor
+
+	if expectToken(tokens, cursor, tokenFromKeyword(lex.AsKeyword)) {
+		cursor++
+
+		alias, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected alias")
+			return nil, initialCursor, false
+		}
+
+		return alias, newCursor, true
+	}
+
+	alias, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+	if !ok {
+		return nil, initialCursor, true
+	}
+
+	return alias, newCursor, true
+}
+
+// parseJoins разбирает таблицы, которые идут после первой таблицы FROM: через запятую
+// и с JOIN (опционально). end - токен, которым заканчивается запрос
+func parseJoins(tokens []*lex.Token, initialCursor uint, end lex.Token) ([]*JoinClause, uint, bool) {
+	cursor := initialCursor
+
+	var joins []*JoinClause
+	for {
+		join := &JoinClause{}
+
+		if expectToken(tokens, cursor, tokenFromSymbol(lex.CommaSymbol)) {
+			join.Kind = CrossJoin
+		} else {
+			switch {
+			case expectToken(tokens, cursor, tokenFromKeyword(lex.JoinKeyword)):
+				join.Kind = InnerJoin
+			case expectToken(tokens, cursor, tokenFromKeyword(lex.InnerKeyword)):
+				join.Kind = InnerJoin
+				cursor++
+			case expectToken(tokens, cursor, tokenFromKeyword(lex.CrossKeyword)):
+				join.Kind = CrossJoin
+				cursor++
+			case expectToken(tokens, cursor, tokenFromKeyword(lex.LeftKeyword)),
+				expectToken(tokens, cursor, tokenFromKeyword(lex.RightKeyword)),
+				expectToken(tokens, cursor, tokenFromKeyword(lex.FullKeyword)):
+				join.Kind = map[string]JoinKind{
+					string(lex.LeftKeyword):  LeftJoin,
+					string(lex.RightKeyword): RightJoin,
+					string(lex.FullKeyword):  FullJoin,
+				}[tokens[cursor].Value]
+				cursor++
+
+				if expectToken(tokens, cursor, tokenFromKeyword(lex.OuterKeyword)) {
+					cursor++
+				}
+			default:
+				return joins, cursor, true
+			}
+
+			if !expectToken(tokens, cursor, tokenFromKeyword(lex.JoinKeyword)) {
+				helpMessage(tokens, cursor, "Expected JOIN")
+				return nil, initialCursor, false
+			}
+		}
+		cursor++
+
+		table, subquery, alias, newCursor, ok := parseFromItem(tokens, cursor)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected table name")
+			return nil, initialCursor, false
+		}
+		join.Table, join.Subquery, join.Alias = table, subquery, alias
+		cursor = newCursor
+
+		if join.Kind != CrossJoin {
+			newCursor, ok := parseJoinCondition(tokens, cursor, join, end)
+			if !ok {
+				return nil, initialCursor, false
+			}
+			cursor = newCursor
+		}
+
+		joins = append(joins, join)
+	}
+}
+
+// parseJoinCondition разбирает ON condition или USING (column [, ...]) соединения
+func parseJoinCondition(tokens []*lex.Token, initialCursor uint, join *JoinClause, end lex.Token) (uint, bool) {
+	cursor := initialCursor
+
+	if expectToken(tokens, cursor, tokenFromKeyword(lex.UsingKeyword)) {
+		cursor++
+
+		if !expectToken(tokens, cursor, tokenFromSymbol(lex.LeftparenSymbol)) {
+			helpMessage(tokens, cursor, "Expected left paren")
+			return initialCursor, false
+		}
+		cursor++
+
+		columns, newCursor, ok := parseColumnNames(tokens, cursor, tokenFromSymbol(lex.RightparenSymbol))
+		if !ok {
+			helpMessage(tokens, cursor, "Expected column names")
+			return initialCursor, false
+		}
+		join.Using = columns
+		cursor = newCursor + 1
+
+		return cursor, true
+	}
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.OnKeyword)) {
+		helpMessage(tokens, cursor, "Expected ON or USING")
+		return initialCursor, false
+	}
+	cursor++
+
+	// Условие заканчивается на следующем соединении или следующей части запроса
+	on, newCursor, ok := parseOnClause(tokens, cursor, []lex.Token{
+		end,
+		tokenFromSymbol(lex.CommaSymbol),
+		tokenFromKeyword(lex.JoinKeyword),
+		tokenFromKeyword(lex.InnerKeyword),
+		tokenFromKeyword(lex.LeftKeyword),
+		tokenFromKeyword(lex.RightKeyword),
+		tokenFromKeyword(lex.FullKeyword),
+		tokenFromKeyword(lex.CrossKeyword),
+		tokenFromKeyword(lex.WhereKeyword),
+		tokenFromKeyword(lex.GroupKeyword),
+		tokenFromKeyword(lex.HavingKeyword),
+		tokenFromKeyword(lex.OrderKeyword),
+		tokenFromKeyword(lex.LimitKeyword),
+		tokenFromKeyword(lex.OffsetKeyword),
+	})
+	if !ok {
+		helpMessage(tokens, cursor, "Invalid ON clause")
+		return initialCursor, false
+	}
+	join.On = on
+
+	return newCursor, true
+}
+
+// parseGroupBy разбирает GROUP BY column [, ...] (опционально)
+func parseGroupBy(tokens []*lex.Token, initialCursor uint) ([]lex.Token, uint, bool) {
+	cursor := initialCursor
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.GroupKeyword)) {
+		return nil, initialCursor, true
+	}
+	cursor++
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.ByKeyword)) {
+		helpMessage(tokens, cursor, "Expected BY")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	groupBy := []lex.Token{}
+	for {
+		column, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected column name")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		groupBy = append(groupBy, *column)
+
+		if !expectToken(tokens, cursor, tokenFromSymbol(lex.CommaSymbol)) {
+			break
+		}
+		cursor++
+	}
+
+	return groupBy, cursor, true
+}
+
+// parseOrderBy разбирает ORDER BY column [ASC | DESC] [NULLS FIRST | NULLS LAST] [, ...] (опционально)
+func parseOrderBy(tokens []*lex.Token, initialCursor uint) ([]*OrderByClause, uint, bool) {
+	cursor := initialCursor
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.OrderKeyword)) {
+		return nil, initialCursor, true
+	}
+	cursor++
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.ByKeyword)) {
+		helpMessage(tokens, cursor, "Expected BY")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	orderBy := []*OrderByClause{}
+	for {
+		column, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected column name")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		key := &OrderByClause{Column: *column}
+		if expectToken(tokens, cursor, tokenFromKeyword(lex.AscKeyword)) {
+			cursor++
+		} else if expectToken(tokens, cursor, tokenFromKeyword(lex.DescKeyword)) {
+			key.Desc = true
+			cursor++
+		}
+
+		key.NullsFirst = key.Desc
+		if expectToken(tokens, cursor, tokenFromKeyword(lex.NullsKeyword)) {
+			cursor++
+
+			switch {
+			case expectToken(tokens, cursor, tokenFromKeyword(lex.FirstKeyword)):
+				key.NullsFirst = true
+			case expectToken(tokens, cursor, tokenFromKeyword(lex.LastKeyword)):
+				key.NullsFirst = false
+			default:
+				helpMessage(tokens, cursor, "Expected FIRST or LAST")
+				return nil, initialCursor, false
+			}
+			cursor++
+		}
+
+		orderBy = append(orderBy, key)
+
+		if !expectToken(tokens, cursor, tokenFromSymbol(lex.CommaSymbol)) {
+			break
+		}
+		cursor++
+	}
+
+	return orderBy, cursor, true
+}
+
 func parseLimit(tokens []*lex.Token, initialCursor uint) (int, uint, bool) {
 	limit := 0
 
@@ -0,0 +1,33 @@
+package ast
+
+import "custom-database/internal/parser/lex"
+
+// parseTransactionStatement разбирает BEGIN [TRANSACTION], COMMIT и ROLLBACK
+func parseTransactionStatement(tokens []*lex.Token, initialCursor uint) (AstKind, uint, bool) {
+	cursor := initialCursor
+
+	var kind AstKind
+	switch {
+	case expectToken(tokens, cursor, tokenFromKeyword(lex.BeginKeyword)):
+		kind = BeginKind
+	case expectToken(tokens, cursor, tokenFromKeyword(lex.CommitKeyword)):
+		kind = CommitKind
+	case expectToken(tokens, cursor, tokenFromKeyword(lex.RollbackKeyword)):
+		kind = RollbackKind
+	default:
+		return 0, initialCursor, false
+	}
+	cursor++
+
+	// TRANSACTION необязателен: BEGIN TRANSACTION; то же самое, что BEGIN;
+	if kind == BeginKind && expectToken(tokens, cursor, tokenFromKeyword(lex.TransactionKeyword)) {
+		cursor++
+	}
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.SemicolonSymbol)) {
+		helpMessage(tokens, cursor, "Expected semicolon")
+		return 0, initialCursor, false
+	}
+
+	return kind, cursor, true
+}
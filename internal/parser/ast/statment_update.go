@@ -0,0 +1,178 @@
+package ast
+
+import "custom-database/internal/parser/lex"
+
+func parseUpdateStatement(tokens []*lex.Token, initialCursor uint) (*UpdateStatement, uint, bool) {
+	cursor := initialCursor
+
+	// Look for UPDATE
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.UpdateKeyword)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	// Look for table name
+	table, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected table name")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	// Look for SET
+	if !expectToken(tokens, cursor, tokenFromKeyword(lex.SetKeyword)) {
+		helpMessage(tokens, cursor, "Expected SET")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	// Look for column = value list
+	set, newCursor, ok := parseSetClauses(tokens, cursor)
+	if !ok {
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	// Look for WHERE (optional)
+	where, newCursor, ok := parseWhereClause(tokens, cursor, []lex.Token{tokenFromSymbol(lex.SemicolonSymbol)})
+	if !ok {
+		helpMessage(tokens, cursor, "Invalid WHERE clause")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.SemicolonSymbol)) {
+		helpMessage(tokens, cursor, "Expected semicolon")
+		return nil, initialCursor, false
+	}
+
+	return &UpdateStatement{
+		Table: *table,
+		Set:   set,
+		Where: where,
+	}, cursor, true
+}
+
+// parseSetClauses разбирает присваивания column = value через запятую до WHERE или ;
+func parseSetClauses(tokens []*lex.Token, initialCursor uint) ([]*SetClause, uint, bool) {
+	cursor := initialCursor
+
+	set := []*SetClause{}
+	for {
+		// Look for column name
+		column, newCursor, ok := parseToken(tokens, cursor, lex.IdentifierToken)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected column name")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		// Look for =
+		if !expectToken(tokens, cursor, lex.Token{Kind: lex.MathOperatorToken, Value: string(lex.EqualOperator)}) {
+			helpMessage(tokens, cursor, "Expected =")
+			return nil, initialCursor, false
+		}
+		cursor++
+
+		// Look for value
+		value, newCursor, ok := parseSetValue(tokens, cursor)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected expression")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		set = append(set, &SetClause{
+			Column: *column,
+			Value:  value,
+		})
+
+		// Look for comma
+		if !expectToken(tokens, cursor, tokenFromSymbol(lex.CommaSymbol)) {
+			break
+		}
+		cursor++
+	}
+
+	return set, cursor, true
+}
+
+// parseSetValue разбирает значение SET: DEFAULT или арифметическое выражение из литералов и колонок
+func parseSetValue(tokens []*lex.Token, initialCursor uint) (*Expression, uint, bool) {
+	if expectToken(tokens, initialCursor, tokenFromKeyword(lex.DefaultKeyword)) {
+		return parseExpression(tokens, initialCursor, tokenFromSymbol(lex.CommaSymbol))
+	}
+
+	return parseArithmetic(tokens, initialCursor, 0)
+}
+
+// arithmeticLevels - операторы по возрастанию приоритета: сначала + и -, затем * и /
+var arithmeticLevels = [][]lex.Symbol{
+	{lex.PlusSymbol, lex.MinusSymbol},
+	{lex.AsteriskSymbol, lex.SlashSymbol},
+}
+
+// parseArithmetic разбирает операнды через операторы уровня level и выше.
+// Операции одного уровня выполняются слева направо: a - b - c = (a - b) - c
+func parseArithmetic(tokens []*lex.Token, initialCursor uint, level int) (*Expression, uint, bool) {
+	if level == len(arithmeticLevels) {
+		return parseArithmeticOperand(tokens, initialCursor)
+	}
+
+	left, cursor, ok := parseArithmetic(tokens, initialCursor, level+1)
+	if !ok {
+		return nil, initialCursor, false
+	}
+
+outer:
+	for {
+		for _, operator := range arithmeticLevels[level] {
+			if !expectToken(tokens, cursor, tokenFromSymbol(operator)) {
+				continue
+			}
+
+			right, newCursor, ok := parseArithmetic(tokens, cursor+1, level+1)
+			if !ok {
+				helpMessage(tokens, cursor+1, "Expected expression")
+				return nil, initialCursor, false
+			}
+
+			left = &Expression{
+				Literal:    tokens[cursor],
+				Kind:       ArithmeticKind,
+				Arithmetic: &ArithmeticExpression{Left: left, Right: right},
+			}
+			cursor = newCursor
+			continue outer
+		}
+
+		return left, cursor, true
+	}
+}
+
+// parseArithmeticOperand разбирает литерал, колонку или выражение в скобках
+func parseArithmeticOperand(tokens []*lex.Token, initialCursor uint) (*Expression, uint, bool) {
+	cursor := initialCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.LeftparenSymbol)) {
+		if expectToken(tokens, cursor, tokenFromKeyword(lex.DefaultKeyword)) {
+			return nil, initialCursor, false
+		}
+
+		return parseExpression(tokens, cursor, tokenFromSymbol(lex.CommaSymbol))
+	}
+	cursor++
+
+	exp, newCursor, ok := parseArithmetic(tokens, cursor, 0)
+	if !ok {
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(lex.RightparenSymbol)) {
+		helpMessage(tokens, cursor, "Expected right parenthesis")
+		return nil, initialCursor, false
+	}
+
+	return exp, cursor + 1, true
+}
@@ -8,6 +8,14 @@ const (
 	DropKeyword   Keyword = "drop"
 	SelectKeyword Keyword = "select"
 	InsertKeyword Keyword = "insert"
+	DeleteKeyword Keyword = "delete"
+	UpdateKeyword Keyword = "update"
+	AlterKeyword  Keyword = "alter"
+	// Transactions
+	BeginKeyword       Keyword = "begin"
+	CommitKeyword      Keyword = "commit"
+	RollbackKeyword    Keyword = "rollback"
+	TransactionKeyword Keyword = "transaction"
 	// Keywords
 	FromKeyword   Keyword = "from"
 	TableKeyword  Keyword = "table"
@@ -17,6 +25,57 @@ const (
 	WhereKeyword  Keyword = "where"
 	LimitKeyword  Keyword = "limit"
 	OffsetKeyword Keyword = "offset"
+	SetKeyword    Keyword = "set"
+	IndexKeyword  Keyword = "index"
+	OnKeyword     Keyword = "on"
+	OrderKeyword  Keyword = "order"
+	ByKeyword     Keyword = "by"
+	AscKeyword    Keyword = "asc"
+	DescKeyword   Keyword = "desc"
+	NullsKeyword  Keyword = "nulls"
+	FirstKeyword  Keyword = "first"
+	LastKeyword   Keyword = "last"
+	GroupKeyword  Keyword = "group"
+	HavingKeyword Keyword = "having"
+	// Aggregates
+	CountKeyword    Keyword = "count"
+	SumKeyword      Keyword = "sum"
+	AvgKeyword      Keyword = "avg"
+	MinKeyword      Keyword = "min"
+	MaxKeyword      Keyword = "max"
+	DistinctKeyword Keyword = "distinct"
+	// Joins
+	JoinKeyword  Keyword = "join"
+	InnerKeyword Keyword = "inner"
+	LeftKeyword  Keyword = "left"
+	RightKeyword Keyword = "right"
+	FullKeyword  Keyword = "full"
+	OuterKeyword Keyword = "outer"
+	CrossKeyword Keyword = "cross"
+	UsingKeyword Keyword = "using"
+	// Subqueries
+	InKeyword     Keyword = "in"
+	ExistsKeyword Keyword = "exists"
+	// ALTER TABLE
+	AddKeyword    Keyword = "add"
+	ColumnKeyword Keyword = "column"
+	RenameKeyword Keyword = "rename"
+	ToKeyword     Keyword = "to"
+	TypeKeyword   Keyword = "type"
+	// Constraints
+	ConstraintKeyword Keyword = "constraint"
+	PrimaryKeyword    Keyword = "primary"
+	KeyKeyword        Keyword = "key"
+	UniqueKeyword     Keyword = "unique"
+	NotKeyword        Keyword = "not"
+	DefaultKeyword    Keyword = "default"
+	CheckKeyword      Keyword = "check"
+	ForeignKeyword    Keyword = "foreign"
+	ReferencesKeyword Keyword = "references"
+	RestrictKeyword   Keyword = "restrict"
+	CascadeKeyword    Keyword = "cascade"
+	// Functions
+	CurrentTimestampKeyword Keyword = "current_timestamp"
 	// Datatypes
 	IntKeyword           Keyword = "int"
 	TextKeyword          Keyword = "text"
@@ -30,6 +89,14 @@ var keywords = []Keyword{
 	InsertKeyword,
 	CreateKeyword,
 	DropKeyword,
+	DeleteKeyword,
+	UpdateKeyword,
+	AlterKeyword,
+	// Transactions
+	BeginKeyword,
+	CommitKeyword,
+	RollbackKeyword,
+	TransactionKeyword,
 	// Keywords
 	ValuesKeyword,
 	TableKeyword,
@@ -38,6 +105,58 @@ var keywords = []Keyword{
 	WhereKeyword,
 	LimitKeyword,
 	OffsetKeyword,
+	SetKeyword,
+	IndexKeyword,
+	OnKeyword,
+	OrderKeyword,
+	ByKeyword,
+	AscKeyword,
+	DescKeyword,
+	NullsKeyword,
+	FirstKeyword,
+	LastKeyword,
+	AsKeyword,
+	GroupKeyword,
+	HavingKeyword,
+	// Aggregates
+	CountKeyword,
+	SumKeyword,
+	AvgKeyword,
+	MinKeyword,
+	MaxKeyword,
+	DistinctKeyword,
+	// Joins
+	JoinKeyword,
+	InnerKeyword,
+	LeftKeyword,
+	RightKeyword,
+	FullKeyword,
+	OuterKeyword,
+	CrossKeyword,
+	UsingKeyword,
+	// Subqueries
+	InKeyword,
+	ExistsKeyword,
+	// ALTER TABLE
+	AddKeyword,
+	ColumnKeyword,
+	RenameKeyword,
+	ToKeyword,
+	TypeKeyword,
+	// Constraints
+	ConstraintKeyword,
+	PrimaryKeyword,
+	KeyKeyword,
+	UniqueKeyword,
+	NotKeyword,
+	DefaultKeyword,
+	CheckKeyword,
+	ForeignKeyword,
+	ReferencesKeyword,
+	RestrictKeyword,
+	CascadeKeyword,
+	// Functions
+	CurrentTimestampKeyword,
 	// Datatypes
 	IntKeyword,
 	TextKeyword,
@@ -53,6 +172,10 @@ const (
 	CommaSymbol      Symbol = ","
 	LeftparenSymbol  Symbol = "("
 	RightparenSymbol Symbol = ")"
+	// Арифметика в UPDATE ... SET, умножение - AsteriskSymbol
+	PlusSymbol  Symbol = "+"
+	MinusSymbol Symbol = "-"
+	SlashSymbol Symbol = "/"
 )
 
 var symbols = []Symbol{
@@ -61,6 +184,9 @@ var symbols = []Symbol{
 	RightparenSymbol,
 	SemicolonSymbol,
 	AsteriskSymbol,
+	PlusSymbol,
+	MinusSymbol,
+	SlashSymbol,
 }
 
 type BooleanKeyword string
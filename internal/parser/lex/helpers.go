@@ -51,3 +51,18 @@ func longestMatch(source string, ic Cursor, options []string) string {
 
 	return match
 }
+
+// isWordEnd проверяет, что слово, закончившееся перед позицией end,
+// не продолжается буквой, цифрой или '_'. Иначе "deleted_at" распознался бы
+// как ключевое слово "delete" и идентификатор "d_at"
+func isWordEnd(source string, end uint) bool {
+	if end >= uint(len(source)) {
+		return true
+	}
+
+	c := source[end]
+	isAlphabetical := (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+	isNumeric := c >= '0' && c <= '9'
+
+	return !isAlphabetical && !isNumeric && c != '_' && c != '$'
+}
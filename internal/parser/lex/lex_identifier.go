@@ -22,6 +22,7 @@ func lexIdentifier(source string, ic Cursor) (*Token, Cursor, bool) {
 	cur.Loc.Col++
 
 	value := []byte{c}
+	qualified := false
 	for ; cur.Pointer < uint(len(source)); cur.Pointer++ {
 		c = source[cur.Pointer]
 
@@ -34,6 +35,14 @@ func lexIdentifier(source string, ic Cursor) (*Token, Cursor, bool) {
 			continue
 		}
 
+		// Колонка с именем таблицы (users.id) - один идентификатор: точка, за которой идет буква
+		if c == '.' && !qualified && isIdentifierStart(source, cur.Pointer+1) {
+			qualified = true
+			value = append(value, c)
+			cur.Loc.Col++
+			continue
+		}
+
 		break
 	}
 
@@ -44,3 +53,12 @@ func lexIdentifier(source string, ic Cursor) (*Token, Cursor, bool) {
 		Kind:  IdentifierToken,
 	}, cur, true
 }
+
+func isIdentifierStart(source string, pointer uint) bool {
+	if pointer >= uint(len(source)) {
+		return false
+	}
+
+	c := source[pointer]
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
@@ -19,6 +19,29 @@ func TestLexIdentifier(t *testing.T) {
 		require.NotEqual(t, cursor, newCursor)
 	})
 
+	t.Run("valid qualified identifier", func(t *testing.T) {
+		input := "Users.Id = 1"
+		want := "users.id"
+		cursor := Cursor{}
+
+		got, newCursor, isValid := lexIdentifier(input, cursor)
+
+		require.True(t, isValid)
+		require.Equal(t, want, got.Value)
+		require.Equal(t, uint(8), newCursor.Pointer)
+	})
+
+	t.Run("identifier followed by period without name", func(t *testing.T) {
+		input := "users. id"
+		cursor := Cursor{}
+
+		got, newCursor, isValid := lexIdentifier(input, cursor)
+
+		require.True(t, isValid)
+		require.Equal(t, "users", got.Value)
+		require.Equal(t, uint(5), newCursor.Pointer)
+	})
+
 	t.Run("invalid identifier", func(t *testing.T) {
 		input := "123table"
 		cursor := Cursor{}
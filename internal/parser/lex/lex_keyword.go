@@ -9,7 +9,7 @@ func lexKeyword(source string, ic Cursor) (*Token, Cursor, bool) {
 	}
 
 	match := longestMatch(source, ic, options)
-	if match == "" {
+	if match == "" || !isWordEnd(source, ic.Pointer+uint(len(match))) {
 		return nil, ic, false
 	}
 
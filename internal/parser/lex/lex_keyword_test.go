@@ -20,7 +20,7 @@ func TestLexKeyword(t *testing.T) {
 	})
 
 	t.Run("invalid keyword", func(t *testing.T) {
-		input := "not a keyword"
+		input := "nothing here"
 		cursor := Cursor{}
 
 		_, _, isValid := lexKeyword(input, cursor)
@@ -36,4 +36,25 @@ func TestLexKeyword(t *testing.T) {
 
 		require.False(t, isValid)
 	})
+
+	t.Run("keyword prefix of identifier", func(t *testing.T) {
+		inputs := []string{"deleted_at", "integer", "tables", "values1", "inbox", "existsflag"}
+
+		for _, input := range inputs {
+			_, _, isValid := lexKeyword(input, Cursor{})
+
+			require.False(t, isValid, input)
+		}
+	})
+
+	t.Run("keyword followed by delimiter", func(t *testing.T) {
+		input := "delete from"
+		cursor := Cursor{}
+
+		got, newCursor, isValid := lexKeyword(input, cursor)
+
+		require.True(t, isValid)
+		require.Equal(t, string(DeleteKeyword), got.Value)
+		require.Equal(t, uint(6), newCursor.Pointer)
+	})
 }
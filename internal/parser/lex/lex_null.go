@@ -4,7 +4,7 @@ func lexNull(source string, ic Cursor) (*Token, Cursor, bool) {
 	cur := ic
 
 	match := longestMatch(source, ic, []string{string(NullValueKeyword)})
-	if match == "" {
+	if match == "" || !isWordEnd(source, ic.Pointer+uint(len(match))) {
 		return nil, ic, false
 	}
 
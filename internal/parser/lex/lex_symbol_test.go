@@ -19,6 +19,16 @@ func TestLexSymbol(t *testing.T) {
 		require.NotEqual(t, cursor, newCursor)
 	})
 
+	t.Run("arithmetic symbols", func(t *testing.T) {
+		for _, input := range []string{"+", "-", "*", "/"} {
+			got, _, isValid := lexSymbol(input, Cursor{})
+
+			require.True(t, isValid)
+			require.Equal(t, input, got.Value)
+			require.Equal(t, SymbolToken, got.Kind)
+		}
+	})
+
 	t.Run("invalid symbol", func(t *testing.T) {
 		input := "not a symbol"
 		cursor := Cursor{}
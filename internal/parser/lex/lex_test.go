@@ -208,6 +208,65 @@ func TestLex(t *testing.T) {
 		}
 	})
 
+	t.Run("DELETE command", func(t *testing.T) {
+		input := "DELETE FROM users WHERE deleted_at = null;"
+		want := []*Token{
+			{Kind: KeywordToken, Value: "delete"},
+			{Kind: KeywordToken, Value: "from"},
+			{Kind: IdentifierToken, Value: "users"},
+			{Kind: KeywordToken, Value: "where"},
+			{Kind: IdentifierToken, Value: "deleted_at"},
+			{Kind: MathOperatorToken, Value: "="},
+			{Kind: NullToken, Value: "null"},
+			{Kind: SymbolToken, Value: ";"},
+		}
+
+		got, err := NewLexer().Lex(input)
+
+		require.NoError(t, err)
+		require.Len(t, got, len(want))
+
+		for i, token := range want {
+			if token.Kind != got[i].Kind || token.Value != got[i].Value {
+				t.Errorf("\nОшибка в токене %d:\nОжидалось: {Kind: %v, Value: %q}\nПолучено:  {Kind: %v, Value: %q}",
+					i, token.Kind, token.Value, got[i].Kind, got[i].Value)
+			}
+		}
+	})
+
+	t.Run("UPDATE command", func(t *testing.T) {
+		input := "UPDATE users SET name = 'Bob', settings = null WHERE id = 1;"
+		want := []*Token{
+			{Kind: KeywordToken, Value: "update"},
+			{Kind: IdentifierToken, Value: "users"},
+			{Kind: KeywordToken, Value: "set"},
+			{Kind: IdentifierToken, Value: "name"},
+			{Kind: MathOperatorToken, Value: "="},
+			{Kind: StringToken, Value: "Bob"},
+			{Kind: SymbolToken, Value: ","},
+			{Kind: IdentifierToken, Value: "settings"},
+			{Kind: MathOperatorToken, Value: "="},
+			{Kind: NullToken, Value: "null"},
+			{Kind: KeywordToken, Value: "where"},
+			{Kind: IdentifierToken, Value: "id"},
+			{Kind: MathOperatorToken, Value: "="},
+			{Kind: NumericToken, Value: "1"},
+			{Kind: SymbolToken, Value: ";"},
+		}
+
+		got, err := NewLexer().Lex(input)
+
+		require.NoError(t, err)
+		require.Len(t, got, len(want))
+
+		for i, token := range want {
+			if token.Kind != got[i].Kind || token.Value != got[i].Value {
+				t.Errorf("\nОшибка в токене %d:\nОжидалось: {Kind: %v, Value: %q}\nПолучено:  {Kind: %v, Value: %q}",
+					i, token.Kind, token.Value, got[i].Kind, got[i].Value)
+			}
+		}
+	})
+
 	t.Run("invalid SQL", func(t *testing.T) {
 		input := "SELECT #;"
 
@@ -27,6 +27,114 @@ func TestParse(t *testing.T) {
 		require.Equal(t, "boolean", (*result.Statements[0].CreateTableStatement.Cols)[2].Datatype.Value)
 	})
 
+	t.Run("valid CREATE TABLE statement with constraints", func(t *testing.T) {
+		source := "CREATE TABLE users (id INT PRIMARY KEY, email TEXT UNIQUE, tenant_id INT, CONSTRAINT users_tenant_email UNIQUE (tenant_id, email));"
+		parser := NewParser()
+
+		result, err := parser.Parse(source)
+
+		require.NoError(t, err)
+		require.Len(t, result.Statements, 1)
+		statement := result.Statements[0].CreateTableStatement
+		require.Len(t, *statement.Cols, 3)
+		require.Equal(t, ast.PrimaryKeyConstraint, (*statement.Cols)[0].Constraints[0].Kind)
+		require.Equal(t, ast.UniqueConstraint, (*statement.Cols)[1].Constraints[0].Kind)
+		require.Len(t, statement.Constraints, 1)
+		require.Equal(t, "users_tenant_email", statement.Constraints[0].Name.Value)
+		require.Len(t, statement.Constraints[0].Columns, 2)
+	})
+
+	t.Run("valid CREATE TABLE statement with NOT NULL, DEFAULT and CHECK", func(t *testing.T) {
+		source := "CREATE TABLE users (id INT NOT NULL, age INT DEFAULT 18 CHECK (age > 0), created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, CHECK (age < 150));"
+		parser := NewParser()
+
+		result, err := parser.Parse(source)
+
+		require.NoError(t, err)
+		statement := result.Statements[0].CreateTableStatement
+		require.Len(t, *statement.Cols, 3)
+		require.Equal(t, ast.NotNullConstraint, (*statement.Cols)[0].Constraints[0].Kind)
+		require.Equal(t, "18", (*statement.Cols)[1].Constraints[0].Default.Value)
+		require.Equal(t, "age > 0", (*statement.Cols)[1].Constraints[1].CheckText)
+		require.Equal(t, "current_timestamp", (*statement.Cols)[2].Constraints[0].Default.Value)
+		require.Len(t, statement.Constraints, 1)
+		require.Equal(t, "age < 150", statement.Constraints[0].CheckText)
+	})
+
+	t.Run("valid CREATE TABLE statement with foreign keys", func(t *testing.T) {
+		source := "CREATE TABLE purchases (id INT, user_id INT REFERENCES users ON DELETE CASCADE, tenant_id INT, login TEXT, " +
+			"CONSTRAINT purchases_login_fkey FOREIGN KEY (tenant_id, login) REFERENCES accounts (tenant_id, login) ON UPDATE SET NULL ON DELETE RESTRICT);"
+		parser := NewParser()
+
+		result, err := parser.Parse(source)
+
+		require.NoError(t, err)
+		statement := result.Statements[0].CreateTableStatement
+		userID := (*statement.Cols)[1].Constraints[0]
+		require.Equal(t, ast.ForeignKeyConstraint, userID.Kind)
+		require.Equal(t, "users", userID.References.Value)
+		require.Empty(t, userID.ReferencedColumns)
+		require.Equal(t, ast.CascadeAction, userID.OnDelete)
+		require.Equal(t, ast.RestrictAction, userID.OnUpdate)
+
+		require.Len(t, statement.Constraints, 1)
+		login := statement.Constraints[0]
+		require.Equal(t, "purchases_login_fkey", login.Name.Value)
+		require.Len(t, login.Columns, 2)
+		require.Equal(t, "accounts", login.References.Value)
+		require.Len(t, login.ReferencedColumns, 2)
+		require.Equal(t, ast.RestrictAction, login.OnDelete)
+		require.Equal(t, ast.SetNullAction, login.OnUpdate)
+	})
+
+	t.Run("invalid foreign key action", func(t *testing.T) {
+		parser := NewParser()
+
+		_, err := parser.Parse("CREATE TABLE purchases (user_id INT REFERENCES users ON DELETE SET DEFAULT);")
+		require.Error(t, err)
+
+		_, err = parser.Parse("CREATE TABLE purchases (user_id INT REFERENCES users ON DELETE CASCADE ON DELETE RESTRICT);")
+		require.Error(t, err)
+	})
+
+	t.Run("valid DROP TABLE statement with CASCADE", func(t *testing.T) {
+		parser := NewParser()
+
+		result, err := parser.Parse("DROP TABLE users; DROP TABLE accounts CASCADE;")
+
+		require.NoError(t, err)
+		require.Len(t, result.Statements, 2)
+		require.False(t, result.Statements[0].DropTableStatement.Cascade)
+		require.Equal(t, "accounts", result.Statements[1].DropTableStatement.Table.Value)
+		require.True(t, result.Statements[1].DropTableStatement.Cascade)
+	})
+
+	t.Run("valid ALTER TABLE statements", func(t *testing.T) {
+		parser := NewParser()
+
+		result, err := parser.Parse("ALTER TABLE users ADD COLUMN age INT DEFAULT 18; ALTER TABLE users RENAME TO people;")
+
+		require.NoError(t, err)
+		require.Len(t, result.Statements, 2)
+		require.Equal(t, ast.AlterTableKind, result.Statements[0].Kind)
+		require.Equal(t, ast.AddColumnAction, result.Statements[0].AlterTableStatement.Action)
+		require.Equal(t, "age", result.Statements[0].AlterTableStatement.Column.Name.Value)
+		require.Equal(t, "18", result.Statements[0].AlterTableStatement.Column.Constraints[0].Default.Value)
+		require.Equal(t, ast.RenameTableAction, result.Statements[1].AlterTableStatement.Action)
+		require.Equal(t, "people", result.Statements[1].AlterTableStatement.NewName.Value)
+	})
+
+	t.Run("valid INSERT and UPDATE with DEFAULT", func(t *testing.T) {
+		parser := NewParser()
+
+		result, err := parser.Parse("INSERT INTO users VALUES (1, DEFAULT); UPDATE users SET age = DEFAULT;")
+
+		require.NoError(t, err)
+		require.Len(t, result.Statements, 2)
+		require.Equal(t, ast.DefaultKind, (*result.Statements[0].InsertStatement.Rows[0])[1].Kind)
+		require.Equal(t, ast.DefaultKind, result.Statements[1].UpdateStatement.Set[0].Value.Kind)
+	})
+
 	t.Run("valid INSERT statement", func(t *testing.T) {
 		source := "INSERT INTO users VALUES (1, 'Phil', true);"
 		parser := NewParser()
@@ -37,10 +145,25 @@ func TestParse(t *testing.T) {
 		require.Len(t, result.Statements, 1)
 		require.Equal(t, ast.InsertKind, result.Statements[0].Kind)
 		require.Equal(t, "users", result.Statements[0].InsertStatement.Table.Value)
-		require.Len(t, *result.Statements[0].InsertStatement.Values, 3)
-		require.Equal(t, "1", (*result.Statements[0].InsertStatement.Values)[0].Literal.Value)
-		require.Equal(t, "Phil", (*result.Statements[0].InsertStatement.Values)[1].Literal.Value)
-		require.Equal(t, "true", (*result.Statements[0].InsertStatement.Values)[2].Literal.Value)
+		require.Len(t, *result.Statements[0].InsertStatement.Rows[0], 3)
+		require.Equal(t, "1", (*result.Statements[0].InsertStatement.Rows[0])[0].Literal.Value)
+		require.Equal(t, "Phil", (*result.Statements[0].InsertStatement.Rows[0])[1].Literal.Value)
+		require.Equal(t, "true", (*result.Statements[0].InsertStatement.Rows[0])[2].Literal.Value)
+	})
+
+	t.Run("valid INSERT ... SELECT and CREATE TABLE ... AS SELECT statements", func(t *testing.T) {
+		parser := NewParser()
+
+		result, err := parser.Parse("CREATE TABLE admins AS SELECT id, name FROM users WHERE is_admin = true; INSERT INTO admins (name, id) SELECT name, id FROM users LIMIT 5;")
+
+		require.NoError(t, err)
+		require.Len(t, result.Statements, 2)
+		require.Equal(t, ast.CreateTableKind, result.Statements[0].Kind)
+		require.Equal(t, "admins", result.Statements[0].CreateTableStatement.Name.Value)
+		require.Equal(t, "users", result.Statements[0].CreateTableStatement.AsSelect.From.Value)
+		require.Equal(t, ast.InsertKind, result.Statements[1].Kind)
+		require.Len(t, result.Statements[1].InsertStatement.Columns, 2)
+		require.Equal(t, 5, result.Statements[1].InsertStatement.Select.Limit)
 	})
 
 	t.Run("valid SELECT statement", func(t *testing.T) {
@@ -59,6 +182,137 @@ func TestParse(t *testing.T) {
 		require.Equal(t, "is_active", result.Statements[0].SelectStatement.SelectedColumns[2].Literal.Value)
 	})
 
+	t.Run("valid SELECT statement with WHERE, ORDER BY and LIMIT", func(t *testing.T) {
+		source := "SELECT id FROM users WHERE name = 'order' AND id > 1 ORDER BY id DESC LIMIT 2 OFFSET 1;"
+		parser := NewParser()
+
+		result, err := parser.Parse(source)
+
+		require.NoError(t, err)
+		require.Len(t, result.Statements, 1)
+		statement := result.Statements[0].SelectStatement
+		require.Equal(t, "and", statement.Where.Token.Value)
+		require.Equal(t, "id", statement.OrderBy[0].Column.Value)
+		require.True(t, statement.OrderBy[0].Desc)
+		require.Equal(t, 2, statement.Limit)
+		require.Equal(t, 1, statement.Offset)
+	})
+
+	t.Run("valid SELECT statement with aggregates, GROUP BY and HAVING", func(t *testing.T) {
+		parser := NewParser()
+
+		result, err := parser.Parse("SELECT team, count(*), AVG(score) AS average FROM games GROUP BY team HAVING MAX(score) > 10 ORDER BY average DESC;")
+
+		require.NoError(t, err)
+		require.Len(t, result.Statements, 1)
+		statement := result.Statements[0].SelectStatement
+		require.Len(t, statement.SelectedColumns, 3)
+		require.Equal(t, "count(*)", statement.SelectedColumns[1].Aggregate.String())
+		require.Equal(t, "avg(score)", statement.SelectedColumns[2].Aggregate.String())
+		require.Equal(t, "average", statement.SelectedColumns[2].Alias.Value)
+		require.Equal(t, "team", statement.GroupBy[0].Value)
+		require.Equal(t, "max(score)", statement.Having.Left.Aggregate.String())
+		require.Equal(t, "average", statement.OrderBy[0].Column.Value)
+	})
+
+	t.Run("valid SELECT statement with joins", func(t *testing.T) {
+		parser := NewParser()
+
+		result, err := parser.Parse("SELECT u.name, o.total FROM users u LEFT OUTER JOIN purchases AS o ON o.user_id = u.id AND o.total > 5 JOIN teams USING (team), tags WHERE u.id = 1;")
+
+		require.NoError(t, err)
+		require.Len(t, result.Statements, 1)
+		statement := result.Statements[0].SelectStatement
+		require.Equal(t, "u.name", statement.SelectedColumns[0].Literal.Value)
+		require.Equal(t, "users", statement.From.Value)
+		require.Equal(t, "u", statement.FromAlias.Value)
+		require.Len(t, statement.Joins, 3)
+		require.Equal(t, ast.LeftJoin, statement.Joins[0].Kind)
+		require.Equal(t, "o", statement.Joins[0].Alias.Value)
+		require.Equal(t, "and", statement.Joins[0].On.Token.Value)
+		require.Equal(t, "o.user_id", statement.Joins[0].On.Left.Left.Token.Value)
+		require.Equal(t, ast.InnerJoin, statement.Joins[1].Kind)
+		require.Equal(t, "team", statement.Joins[1].Using[0].Value)
+		require.Equal(t, ast.CrossJoin, statement.Joins[2].Kind)
+		require.Equal(t, "tags", statement.Joins[2].Table.Value)
+		require.Equal(t, "u.id", statement.Where.Left.Token.Value)
+	})
+
+	t.Run("valid SELECT statement with subqueries", func(t *testing.T) {
+		parser := NewParser()
+
+		result, err := parser.Parse("SELECT name, (SELECT MAX(total) FROM purchases p WHERE p.user_id = u.id) AS best FROM (SELECT * FROM users) u " +
+			"WHERE id NOT IN (SELECT user_id FROM bans) OR EXISTS (SELECT * FROM purchases WHERE total > 100);")
+
+		require.NoError(t, err)
+		statement := result.Statements[0].SelectStatement
+		require.Equal(t, ast.SubqueryKind, statement.SelectedColumns[1].Kind)
+		require.Equal(t, "best", statement.SelectedColumns[1].Alias.Value)
+		require.Equal(t, "users", statement.FromSubquery.From.Value)
+		require.Equal(t, "u", statement.FromAlias.Value)
+		require.Equal(t, "or", statement.Where.Token.Value)
+		require.Equal(t, ast.InSubquery, statement.Where.Left.Subquery.Type)
+		require.True(t, statement.Where.Left.Subquery.Not)
+		require.Equal(t, ast.ExistsSubquery, statement.Where.Right.Subquery.Type)
+		require.Equal(t, ">", statement.Where.Right.Subquery.Select.Where.Token.Value)
+	})
+
+	t.Run("invalid SELECT statement - unclosed subquery", func(t *testing.T) {
+		parser := NewParser()
+
+		result, err := parser.Parse("SELECT * FROM users WHERE id IN (SELECT user_id FROM bans;")
+
+		require.Error(t, err)
+		require.Nil(t, result)
+	})
+
+	t.Run("invalid statements - NOT without EXISTS or IN in WHERE", func(t *testing.T) {
+		parser := NewParser()
+
+		for _, source := range []string{
+			"SELECT * FROM t WHERE NOT a = 5;",
+			"SELECT * FROM t WHERE id = 1 AND NOT id = 2;",
+			"DELETE FROM ck WHERE NOT a = 1;",
+			"DELETE FROM ck WHERE a > 0 AND NOT a = 1;",
+			"UPDATE ck SET a = 2 WHERE NOT a = 1;",
+			"UPDATE ck SET a = 2 WHERE a > 0 AND NOT a = 1;",
+		} {
+			result, err := parser.Parse(source)
+
+			require.Error(t, err, source)
+			require.Nil(t, result, source)
+		}
+	})
+
+	t.Run("invalid SELECT statement - ASC or DESC without ORDER BY", func(t *testing.T) {
+		parser := NewParser()
+
+		for _, source := range []string{
+			"SELECT * FROM t WHERE a = 1 DESC;",
+			"SELECT * FROM t WHERE a = 1 ASC;",
+			"SELECT * FROM t WHERE a = 1 DESC LIMIT 1;",
+		} {
+			result, err := parser.Parse(source)
+
+			require.Error(t, err, source)
+			require.Nil(t, result, source)
+		}
+	})
+
+	t.Run("valid SELECT statement - WHERE stops at ORDER BY", func(t *testing.T) {
+		parser := NewParser()
+
+		result, err := parser.Parse("SELECT * FROM t WHERE a = 1 ORDER BY a DESC;")
+
+		require.NoError(t, err)
+		statement := result.Statements[0].SelectStatement
+		require.Equal(t, "=", statement.Where.Token.Value)
+		require.Nil(t, statement.Where.Left.Left)
+		require.Equal(t, "1", statement.Where.Right.Token.Value)
+		require.Len(t, statement.OrderBy, 1)
+		require.True(t, statement.OrderBy[0].Desc)
+	})
+
 	t.Run("valid DROP TABLE statement", func(t *testing.T) {
 		source := "DROP TABLE users;"
 		parser := NewParser()
@@ -71,6 +325,64 @@ func TestParse(t *testing.T) {
 		require.Equal(t, "users", result.Statements[0].DropTableStatement.Table.Value)
 	})
 
+	t.Run("valid DELETE statement", func(t *testing.T) {
+		source := "DELETE FROM users WHERE id = 1;"
+		parser := NewParser()
+
+		result, err := parser.Parse(source)
+
+		require.NoError(t, err)
+		require.Len(t, result.Statements, 1)
+		require.Equal(t, ast.DeleteKind, result.Statements[0].Kind)
+		require.Equal(t, "users", result.Statements[0].DeleteStatement.Table.Value)
+		require.NotNil(t, result.Statements[0].DeleteStatement.Where)
+	})
+
+	t.Run("valid UPDATE statement", func(t *testing.T) {
+		source := "UPDATE users SET name = 'Bob', age = 30 WHERE id = 1;"
+		parser := NewParser()
+
+		result, err := parser.Parse(source)
+
+		require.NoError(t, err)
+		require.Len(t, result.Statements, 1)
+		require.Equal(t, ast.UpdateKind, result.Statements[0].Kind)
+		require.Equal(t, "users", result.Statements[0].UpdateStatement.Table.Value)
+		require.Len(t, result.Statements[0].UpdateStatement.Set, 2)
+		require.NotNil(t, result.Statements[0].UpdateStatement.Where)
+	})
+
+	t.Run("valid CREATE INDEX and DROP INDEX statements", func(t *testing.T) {
+		source := "CREATE INDEX users_id ON users (id); DROP INDEX users_id;"
+		parser := NewParser()
+
+		result, err := parser.Parse(source)
+
+		require.NoError(t, err)
+		require.Len(t, result.Statements, 2)
+		require.Equal(t, ast.CreateIndexKind, result.Statements[0].Kind)
+		require.Equal(t, "users_id", result.Statements[0].CreateIndexStatement.Name.Value)
+		require.Equal(t, "users", result.Statements[0].CreateIndexStatement.Table.Value)
+		require.Equal(t, "id", result.Statements[0].CreateIndexStatement.Columns[0].Value)
+		require.Equal(t, ast.DropIndexKind, result.Statements[1].Kind)
+		require.Equal(t, "users_id", result.Statements[1].DropIndexStatement.Name.Value)
+	})
+
+	t.Run("valid transaction statements", func(t *testing.T) {
+		source := "BEGIN; INSERT INTO users VALUES (1, 'Phil'); COMMIT; BEGIN TRANSACTION; ROLLBACK;"
+		parser := NewParser()
+
+		result, err := parser.Parse(source)
+
+		require.NoError(t, err)
+		require.Len(t, result.Statements, 5)
+		require.Equal(t, ast.BeginKind, result.Statements[0].Kind)
+		require.Equal(t, ast.InsertKind, result.Statements[1].Kind)
+		require.Equal(t, ast.CommitKind, result.Statements[2].Kind)
+		require.Equal(t, ast.BeginKind, result.Statements[3].Kind)
+		require.Equal(t, ast.RollbackKind, result.Statements[4].Kind)
+	})
+
 	t.Run("valid multiple statements", func(t *testing.T) {
 		source := "CREATE TABLE users (id INT, name TEXT, is_active BOOLEAN); INSERT INTO users VALUES (1, 'Phil', true);"
 		parser := NewParser()
@@ -88,7 +400,7 @@ func TestParse(t *testing.T) {
 		// Проверяем INSERT
 		require.Equal(t, ast.InsertKind, result.Statements[1].Kind)
 		require.Equal(t, "users", result.Statements[1].InsertStatement.Table.Value)
-		require.Len(t, *result.Statements[1].InsertStatement.Values, 3)
+		require.Len(t, *result.Statements[1].InsertStatement.Rows[0], 3)
 	})
 
 	t.Run("invalid statement - missing semicolon", func(t *testing.T) {
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"custom-database/internal/models"
+	"fmt"
+	"slices"
+)
+
+// AlterTable строит таблицу tableName по новой схеме alteration и переводит на нее индексы и внешние ключи
+// таблиц tables, которые на нее ссылаются. Возвращает новые версии всех измененных таблиц, сами tables
+// не меняются. Для движков, которые держат таблицы целиком (json, memory)
+func AlterTable(tables []*Table, tableName string, alteration Alteration) ([]*Table, error) {
+	var table *Table
+	for _, candidate := range tables {
+		if candidate.Name == tableName {
+			table = candidate
+		} else if candidate.Name == alteration.Name {
+			return nil, fmt.Errorf("AlterTable(): table already exists: %s", alteration.Name)
+		}
+	}
+	if table == nil {
+		return nil, fmt.Errorf("AlterTable(): table does not exist")
+	}
+
+	// renamed - новое имя каждой колонки, которая остается в таблице с прежними значениями
+	renamed := map[string]string{}
+	columns := make([]models.Column, len(alteration.Columns))
+	for i, column := range alteration.Columns {
+		columns[i] = column.Column
+		if column.Source >= 0 && column.Convert == nil {
+			renamed[table.Columns[column.Source].Name] = column.Name
+		}
+	}
+
+	rows, err := alterRows(table.Rows, alteration.Columns)
+	if err != nil {
+		return nil, fmt.Errorf("AlterTable(): %w", err)
+	}
+
+	altered := &Table{Name: alteration.Name, Columns: columns, Checks: alteration.Checks, Rows: rows}
+	result := []*Table{altered}
+	for _, other := range tables {
+		indexes := make([]Index, len(other.Indexes))
+		changed := false
+		for i, index := range other.Indexes {
+			indexes[i] = index
+			if other == table {
+				indexes[i].Columns, err = renameColumns(index.Columns, renamed, tableName, index.Name)
+				if err != nil {
+					return nil, fmt.Errorf("AlterTable(): %w", err)
+				}
+			}
+
+			if index.ForeignKey != nil && index.ForeignKey.RefTable == tableName {
+				foreignKey := *index.ForeignKey
+				foreignKey.RefTable = alteration.Name
+				foreignKey.RefColumns, err = renameColumns(foreignKey.RefColumns, renamed, tableName, index.Name)
+				if err != nil {
+					return nil, fmt.Errorf("AlterTable(): %w", err)
+				}
+				indexes[i].ForeignKey = &foreignKey
+				changed = true
+			}
+		}
+
+		if other == table {
+			altered.Indexes = indexes
+		} else if changed {
+			copied := *other
+			copied.Indexes = indexes
+			result = append(result, &copied)
+		}
+	}
+
+	return result, nil
+}
+
+// alterRows переводит строки в колонки новой схемы
+func alterRows(rows [][]interface{}, columns []AlteredColumn) ([][]interface{}, error) {
+	result := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		result[i] = make([]interface{}, len(columns))
+		for j, column := range columns {
+			if column.Source < 0 {
+				result[i][j] = column.Missing
+				continue
+			}
+
+			value := row[column.Source]
+			if column.Convert != nil {
+				var err error
+				value, err = column.Convert(value)
+				if err != nil {
+					return nil, err
+				}
+			}
+			result[i][j] = value
+		}
+	}
+
+	return result, nil
+}
+
+// renameColumns возвращает новые имена колонок индекса. Колонку, которая удаляется или меняет тип, индекс потерял бы
+func renameColumns(columnNames []string, renamed map[string]string, tableName, indexName string) ([]string, error) {
+	result := slices.Clone(columnNames)
+	for i, columnName := range columnNames {
+		newName, ok := renamed[columnName]
+		if !ok {
+			return nil, fmt.Errorf("column %s of table %s is used by index %s", columnName, tableName, indexName)
+		}
+		result[i] = newName
+	}
+
+	return result, nil
+}
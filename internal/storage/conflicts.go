@@ -0,0 +1,11 @@
+package storage
+
+// SerializationError - конфликт записи с параллельной транзакцией: транзакцию нужно начать заново.
+// Его текст, как и текст ConstraintError, показывается пользователю без цепочки имен функций
+type SerializationError struct {
+	Message string
+}
+
+func (e *SerializationError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConstraintError - нарушение ограничения целостности. Его текст показывается пользователю как есть,
+// без цепочки имен функций, через которые прошла ошибка
+type ConstraintError struct {
+	// Constraint - имя нарушенного ограничения
+	Constraint string
+	Message    string
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Message
+}
+
+// UniqueViolation - ошибка повтора значений колонок уникального индекса
+func UniqueViolation(index Index, values []interface{}) *ConstraintError {
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		formatted[i] = FormatValue(value)
+	}
+
+	return &ConstraintError{
+		Constraint: index.Name,
+		Message: fmt.Sprintf("duplicate key value violates unique constraint %q: key (%s)=(%s) already exists",
+			index.Name, strings.Join(index.Columns, ", "), strings.Join(formatted, ", ")),
+	}
+}
+
+// PrimaryKeyNullViolation - ошибка NULL в колонке первичного ключа
+func PrimaryKeyNullViolation(index Index, columnName string) *ConstraintError {
+	return &ConstraintError{
+		Constraint: index.Name,
+		Message:    fmt.Sprintf("null value in column %q violates primary key constraint %q", columnName, index.Name),
+	}
+}
+
+// FormatValue печатает значение строки для сообщений об ошибках
+func FormatValue(value interface{}) string {
+	if value == nil {
+		return "NULL"
+	}
+
+	return fmt.Sprint(value)
+}
+
+// CheckUnique проверяет, что строку values можно записать в таблицу, не нарушив ее уникальные индексы.
+// Строка с номером skip не учитывается: это старая версия обновляемой строки, для вставки skip = -1.
+// Строки с NULL в колонках индекса не конфликтуют друг с другом, а в первичном ключе NULL запрещен.
+// Для движков, которые держат таблицу целиком (json, memory)
+func CheckUnique(table *Table, values []interface{}, skip int) error {
+	for _, index := range table.Indexes {
+		if !index.Unique {
+			continue
+		}
+
+		columns, err := IndexColumns(table.Columns, index.Columns)
+		if err != nil {
+			return fmt.Errorf("CheckUnique(): %w", err)
+		}
+
+		key, ok, err := uniqueKey(index, columns, values)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		for i, row := range table.Rows {
+			if i != skip && compareKey(row, columns, key) == 0 {
+				return UniqueViolation(index, key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateUnique проверяет, что уже записанные строки таблицы не нарушают уникальный индекс index
+func ValidateUnique(table *Table, index Index) error {
+	columns, err := IndexColumns(table.Columns, index.Columns)
+	if err != nil {
+		return fmt.Errorf("ValidateUnique(): %w", err)
+	}
+
+	// После сортировки по колонкам индекса одинаковые значения стоят рядом
+	var previous []interface{}
+	it := NewIndexIterator(table.Rows, columns, nil, nil, false)
+	for it.Next() {
+		key, ok, err := uniqueKey(index, columns, it.Row())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// NULL сортируется после значений колонки, поэтому одинаковые значения без NULL все равно стоят рядом
+			continue
+		}
+
+		if previous != nil && compareKey(it.Row(), columns, previous) == 0 {
+			return UniqueViolation(index, key)
+		}
+		previous = key
+	}
+
+	return nil
+}
+
+// uniqueKey возвращает значения колонок индекса в строке row. false - в значениях есть NULL,
+// и строку не нужно проверять на повторы
+func uniqueKey(index Index, columns []int, row []interface{}) ([]interface{}, bool, error) {
+	key := make([]interface{}, len(columns))
+	for i, column := range columns {
+		if row[column] == nil {
+			if index.Primary {
+				return nil, false, PrimaryKeyNullViolation(index, index.Columns[i])
+			}
+			return nil, false, nil
+		}
+		key[i] = row[column]
+	}
+
+	return key, true, nil
+}
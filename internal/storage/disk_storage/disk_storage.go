@@ -0,0 +1,542 @@
+package disk_storage
+
+import (
+	"custom-database/config"
+	"custom-database/internal/disk_manager"
+	"custom-database/internal/disk_manager/data"
+	"custom-database/internal/models"
+	"custom-database/internal/storage"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// diskStorage - движок хранения поверх страничного disk_manager.
+// Переводит models.Column и значения backend в data.Column/data.DataCell и обратно
+type diskStorage struct {
+	diskManager disk_manager.DiskManagerService
+}
+
+func NewDiskStorage(cfg *config.Config) (storage.StorageService, error) {
+	diskManager, err := disk_manager.NewDiskManager(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("NewDiskStorage(): %w", err)
+	}
+
+	return &diskStorage{
+		diskManager: diskManager,
+	}, nil
+}
+
+func (ds *diskStorage) Begin() (storage.Transaction, error) {
+	return &transaction{tx: ds.diskManager.Begin()}, nil
+}
+
+// Stats возвращает счетчики буферного пула, по ним подбирается BUFFER_POOL_SIZE
+func (ds *diskStorage) Stats() map[string]uint64 {
+	stats := ds.diskManager.BufferPoolStats()
+
+	return map[string]uint64{
+		"buffer_pool_hits":      stats.Hits,
+		"buffer_pool_misses":    stats.Misses,
+		"buffer_pool_evictions": stats.Evictions,
+		"buffer_pool_flushes":   stats.Flushes,
+	}
+}
+
+// transaction - транзакция disk_manager, видящая таблицы через свой снимок
+type transaction struct {
+	tx disk_manager.Transaction
+}
+
+func (t *transaction) CreateTable(tableName string, columns []models.Column, checks []models.Check) error {
+	dataColumns := make([]data.Column, len(columns))
+	for i, column := range columns {
+		var err error
+		dataColumns[i], err = toDataColumn(column)
+		if err != nil {
+			return fmt.Errorf("CreateTable(): %w", err)
+		}
+	}
+
+	return toStorageError(t.tx.CreateTable(tableName, dataColumns, toDataChecks(checks)))
+}
+
+// AlterTable переводит новую схему в колонки disk_manager. Convert работает со значениями backend,
+// поэтому ячейки старого типа переводятся в них и обратно
+func (t *transaction) AlterTable(tableName string, alteration storage.Alteration) error {
+	columns := make([]disk_manager.AlteredColumn, len(alteration.Columns))
+	for i, altered := range alteration.Columns {
+		column, err := toDataColumn(altered.Column)
+		if err != nil {
+			return fmt.Errorf("AlterTable(): %w", err)
+		}
+
+		missing, err := toDataCell(column.Type, altered.Missing)
+		if err != nil {
+			return fmt.Errorf("AlterTable(): column %s: %w", column.Name, err)
+		}
+
+		columns[i] = disk_manager.AlteredColumn{Column: column, Source: altered.Source, Missing: missing}
+		if convert := altered.Convert; convert != nil {
+			columns[i].Convert = func(cell data.DataCell) (data.DataCell, error) {
+				value, err := convert(fromDataCell(cell))
+				if err != nil {
+					return data.DataCell{}, err
+				}
+
+				return toDataCell(column.Type, value)
+			}
+		}
+	}
+
+	err := t.tx.AlterTable(tableName, disk_manager.Alteration{
+		Name:    alteration.Name,
+		Columns: columns,
+		Checks:  toDataChecks(alteration.Checks),
+	})
+	if err != nil {
+		return fmt.Errorf("AlterTable(): %w", toStorageError(err))
+	}
+
+	return nil
+}
+
+func (t *transaction) DropTable(tableName string) error {
+	return toStorageError(t.tx.DropTable(tableName))
+}
+
+func (t *transaction) Insert(tableName string, values []interface{}) error {
+	columns, err := t.tx.GetTableColumns(tableName)
+	if err != nil {
+		return fmt.Errorf("Insert(): %w", err)
+	}
+
+	row, err := toDataRow(columns, values)
+	if err != nil {
+		return fmt.Errorf("Insert(): %w", err)
+	}
+
+	_, err = t.tx.InsertRow(tableName, row)
+	if err != nil {
+		return fmt.Errorf("Insert(): %w", toStorageError(err))
+	}
+
+	return nil
+}
+
+func (t *transaction) Delete(tableName string, rowIDs []storage.RowID) error {
+	for _, rowID := range rowIDs {
+		pageID, slotID := fromRowID(rowID)
+
+		err := t.tx.DeleteRow(tableName, pageID, slotID)
+		if err != nil {
+			return fmt.Errorf("Delete(): %w", toStorageError(err))
+		}
+	}
+
+	return nil
+}
+
+// Update возвращает RowID новой версии строки
+func (t *transaction) Update(tableName string, rowID storage.RowID, values []interface{}) (storage.RowID, error) {
+	columns, err := t.tx.GetTableColumns(tableName)
+	if err != nil {
+		return 0, fmt.Errorf("Update(): %w", err)
+	}
+
+	row, err := toDataRow(columns, values)
+	if err != nil {
+		return 0, fmt.Errorf("Update(): %w", err)
+	}
+
+	pageID, slotID := fromRowID(rowID)
+	result, err := t.tx.UpdateRow(tableName, pageID, slotID, row)
+	if err != nil {
+		return 0, fmt.Errorf("Update(): %w", toStorageError(err))
+	}
+
+	return toRowID(result.PageID, result.SlotID), nil
+}
+
+func (t *transaction) Scan(tableName string) (storage.RowIterator, error) {
+	it, err := t.tx.ScanRows(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("Scan(): %w", err)
+	}
+
+	return &rowIterator{it: it}, nil
+}
+
+func (t *transaction) GetTableColumns(tableName string) ([]models.Column, error) {
+	dataColumns, err := t.tx.GetTableColumns(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("GetTableColumns(): %w", err)
+	}
+
+	columns := make([]models.Column, len(dataColumns))
+	for i, column := range dataColumns {
+		columnType, err := fromDataType(column.Type)
+		if err != nil {
+			return nil, fmt.Errorf("GetTableColumns(): %w", err)
+		}
+
+		columns[i] = models.Column{
+			Name:    column.Name,
+			Type:    columnType,
+			NotNull: !column.IsNullable,
+			Default: column.Default,
+		}
+	}
+
+	return columns, nil
+}
+
+func (t *transaction) GetTableChecks(tableName string) ([]models.Check, error) {
+	dataChecks, err := t.tx.GetTableChecks(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("GetTableChecks(): %w", err)
+	}
+
+	checks := make([]models.Check, len(dataChecks))
+	for i, check := range dataChecks {
+		checks[i] = models.Check{Name: check.Name, Expression: check.Expression}
+	}
+
+	return checks, nil
+}
+
+func (t *transaction) CreateIndex(tableName string, index storage.Index) error {
+	err := t.tx.CreateIndex(disk_manager.Index{
+		Name:       index.Name,
+		Table:      tableName,
+		Columns:    index.Columns,
+		Unique:     index.Unique,
+		Primary:    index.Primary,
+		ForeignKey: toDiskForeignKey(index.ForeignKey),
+	})
+	if err != nil {
+		return fmt.Errorf("CreateIndex(): %w", toStorageError(err))
+	}
+
+	return nil
+}
+
+func (t *transaction) DropIndex(indexName string) error {
+	return toStorageError(t.tx.DropIndex(indexName))
+}
+
+func (t *transaction) DropForeignKey(indexName string) error {
+	return toStorageError(t.tx.DropForeignKey(indexName))
+}
+
+func (t *transaction) GetTableIndexes(tableName string) ([]storage.Index, error) {
+	indexes, err := t.tx.GetTableIndexes(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("GetTableIndexes(): %w", err)
+	}
+
+	result := make([]storage.Index, len(indexes))
+	for i, index := range indexes {
+		result[i] = toStorageIndex(index)
+	}
+
+	return result, nil
+}
+
+func (t *transaction) GetReferences(tableName string) ([]storage.Reference, error) {
+	indexes, err := t.tx.GetReferences(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("GetReferences(): %w", err)
+	}
+
+	result := make([]storage.Reference, len(indexes))
+	for i, index := range indexes {
+		result[i] = storage.Reference{Table: index.Table, Index: toStorageIndex(index)}
+	}
+
+	return result, nil
+}
+
+func toStorageIndex(index disk_manager.Index) storage.Index {
+	result := storage.Index{Name: index.Name, Columns: index.Columns, Unique: index.Unique, Primary: index.Primary}
+	if fk := index.ForeignKey; fk != nil {
+		result.ForeignKey = &storage.ForeignKey{
+			RefTable:   fk.RefTable,
+			RefColumns: fk.RefColumns,
+			OnDelete:   storage.ReferentialAction(fk.OnDelete),
+			OnUpdate:   storage.ReferentialAction(fk.OnUpdate),
+		}
+	}
+
+	return result
+}
+
+func toDiskForeignKey(fk *storage.ForeignKey) *disk_manager.ForeignKey {
+	if fk == nil {
+		return nil
+	}
+
+	return &disk_manager.ForeignKey{
+		RefTable:   fk.RefTable,
+		RefColumns: fk.RefColumns,
+		OnDelete:   uint8(fk.OnDelete),
+		OnUpdate:   uint8(fk.OnUpdate),
+	}
+}
+
+// IndexScan переводит границы в значения колонок индекса и читает строки через B+ дерево disk_manager
+func (t *transaction) IndexScan(tableName, indexName string, from, to *storage.IndexBound, reverse bool) (storage.RowIterator, error) {
+	indexes, err := t.tx.GetTableIndexes(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("IndexScan(): %w", err)
+	}
+
+	columns, err := t.tx.GetTableColumns(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("IndexScan(): %w", err)
+	}
+
+	for _, index := range indexes {
+		if index.Name != indexName {
+			continue
+		}
+
+		columnTypes := make([]data.ColumnType, len(index.Columns))
+		for i, columnName := range index.Columns {
+			for _, column := range columns {
+				if column.Name == columnName {
+					columnTypes[i] = column.Type
+				}
+			}
+		}
+
+		fromBound, err := toIndexBound(columnTypes, from)
+		if err != nil {
+			return nil, fmt.Errorf("IndexScan(): %w", err)
+		}
+		toBound, err := toIndexBound(columnTypes, to)
+		if err != nil {
+			return nil, fmt.Errorf("IndexScan(): %w", err)
+		}
+
+		it, err := t.tx.IndexScan(indexName, fromBound, toBound, reverse)
+		if err != nil {
+			return nil, fmt.Errorf("IndexScan(): %w", err)
+		}
+
+		return &rowIterator{it: it}, nil
+	}
+
+	return nil, fmt.Errorf("IndexScan(): index does not exist: %s", indexName)
+}
+
+// toIndexBound переводит значения границы в ячейки с типами первых колонок индекса columnTypes
+func toIndexBound(columnTypes []data.ColumnType, bound *storage.IndexBound) (*disk_manager.IndexBound, error) {
+	if bound == nil {
+		return nil, nil
+	}
+	if len(bound.Values) > len(columnTypes) {
+		return nil, fmt.Errorf("index has %d columns, got %d values", len(columnTypes), len(bound.Values))
+	}
+
+	cells := make([]data.DataCell, len(bound.Values))
+	for i, value := range bound.Values {
+		cell, err := toDataCell(columnTypes[i], value)
+		if err != nil {
+			return nil, err
+		}
+		cells[i] = cell
+	}
+
+	return &disk_manager.IndexBound{Values: cells, Inclusive: bound.Inclusive}, nil
+}
+
+func (t *transaction) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *transaction) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// toRowID упаковывает адрес строки (PageId, SlotId) в storage.RowID
+func toRowID(pageID uint32, slotID uint16) storage.RowID {
+	return storage.RowID(uint64(pageID)<<16 | uint64(slotID))
+}
+
+func fromRowID(rowID storage.RowID) (uint32, uint16) {
+	return uint32(rowID >> 16), uint16(rowID)
+}
+
+func toDataType(columnType models.ColumnType) (data.ColumnType, error) {
+	switch columnType {
+	case models.IntType:
+		return data.TypeInt32, nil
+	case models.TextType:
+		return data.TypeText, nil
+	case models.BoolType:
+		return data.TypeBoolean, nil
+	case models.TimestampType:
+		return data.TypeTimestamp, nil
+	}
+
+	return 0, fmt.Errorf("unsupported column type: %d", columnType)
+}
+
+func fromDataType(columnType data.ColumnType) (models.ColumnType, error) {
+	switch columnType {
+	case data.TypeInt32:
+		return models.IntType, nil
+	case data.TypeText:
+		return models.TextType, nil
+	case data.TypeBoolean:
+		return models.BoolType, nil
+	case data.TypeTimestamp:
+		return models.TimestampType, nil
+	}
+
+	return 0, fmt.Errorf("unsupported data type: %d", columnType)
+}
+
+func toDataColumn(column models.Column) (data.Column, error) {
+	columnType, err := toDataType(column.Type)
+	if err != nil {
+		return data.Column{}, err
+	}
+
+	return data.Column{
+		Name:       column.Name,
+		Type:       columnType,
+		IsNullable: !column.NotNull,
+		Default:    column.Default,
+	}, nil
+}
+
+func toDataChecks(checks []models.Check) []data.Check {
+	dataChecks := make([]data.Check, len(checks))
+	for i, check := range checks {
+		dataChecks[i] = data.Check{Name: check.Name, Expression: check.Expression}
+	}
+
+	return dataChecks
+}
+
+func toDataRow(columns []data.Column, values []interface{}) ([]data.DataCell, error) {
+	if len(values) != len(columns) {
+		return nil, fmt.Errorf("expected %d values, got %d", len(columns), len(values))
+	}
+
+	row := make([]data.DataCell, len(columns))
+	for i, column := range columns {
+		cell, err := toDataCell(column.Type, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", column.Name, err)
+		}
+		row[i] = cell
+	}
+
+	return row, nil
+}
+
+func toDataCell(columnType data.ColumnType, value interface{}) (data.DataCell, error) {
+	if value == nil {
+		return data.DataCell{Type: columnType, IsNull: true}, nil
+	}
+
+	if columnType == data.TypeTimestamp {
+		text, ok := value.(string)
+		if !ok {
+			return data.DataCell{}, fmt.Errorf("expected timestamp string, got %T", value)
+		}
+
+		timestamp, err := time.Parse(models.TimestampFormat, text)
+		if err != nil {
+			return data.DataCell{}, fmt.Errorf("invalid timestamp %q: %w", text, err)
+		}
+		return data.DataCell{Value: timestamp, Type: columnType}, nil
+	}
+
+	switch value.(type) {
+	case int32:
+		if columnType != data.TypeInt32 {
+			return data.DataCell{}, fmt.Errorf("unexpected int value")
+		}
+	case string:
+		if columnType != data.TypeText {
+			return data.DataCell{}, fmt.Errorf("unexpected text value")
+		}
+	case bool:
+		if columnType != data.TypeBoolean {
+			return data.DataCell{}, fmt.Errorf("unexpected boolean value")
+		}
+	default:
+		return data.DataCell{}, fmt.Errorf("unsupported value type %T", value)
+	}
+
+	return data.DataCell{Value: value, Type: columnType}, nil
+}
+
+func fromDataRow(row []data.DataCell) []interface{} {
+	values := make([]interface{}, len(row))
+	for i, cell := range row {
+		values[i] = fromDataCell(cell)
+	}
+
+	return values
+}
+
+func fromDataCell(cell data.DataCell) interface{} {
+	if cell.IsNull {
+		return nil
+	}
+
+	if cell.Type == data.TypeTimestamp {
+		return cell.Value.(time.Time).Format(models.TimestampFormat)
+	}
+
+	return cell.Value
+}
+
+// rowIterator переводит строки страничного итератора в значения backend
+type rowIterator struct {
+	it data.RowIterator
+}
+
+func (ri *rowIterator) Next() bool {
+	return ri.it.Next()
+}
+
+func (ri *rowIterator) Row() []interface{} {
+	return fromDataRow(ri.it.Row().Row)
+}
+
+func (ri *rowIterator) RowID() storage.RowID {
+	row := ri.it.Row()
+	return toRowID(row.PageId, row.SlotId)
+}
+
+func (ri *rowIterator) Err() error {
+	return ri.it.Err()
+}
+
+// toStorageError заменяет нарушение ограничения, конфликт записи и слишком длинный ключ индекса disk_manager
+// на storage.ConstraintError, storage.SerializationError и storage.LimitError, которые backend показывает
+// пользователю. Остальные ошибки, в том числе nil,
+// возвращаются как есть
+func toStorageError(err error) error {
+	var constraintErr *disk_manager.ConstraintError
+	if errors.As(err, &constraintErr) {
+		return &storage.ConstraintError{Constraint: constraintErr.Constraint, Message: constraintErr.Message}
+	}
+	var conflictErr *disk_manager.WriteConflictError
+	if errors.As(err, &conflictErr) {
+		return &storage.SerializationError{Message: conflictErr.Error()}
+	}
+	var keyErr *disk_manager.KeyTooLongError
+	if errors.As(err, &keyErr) {
+		return &storage.LimitError{Message: keyErr.Error()}
+	}
+
+	return err
+}
@@ -0,0 +1,11 @@
+package storage
+
+// LimitError - значение не помещается в ограничение движка, например строка в ключ индекса.
+// Его текст, как и текст ConstraintError, показывается пользователю без цепочки имен функций
+type LimitError struct {
+	Message string
+}
+
+func (e *LimitError) Error() string {
+	return e.Message
+}
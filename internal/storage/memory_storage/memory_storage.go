@@ -2,51 +2,67 @@ package memory_storage
 
 import (
 	"custom-database/internal/models"
+	"custom-database/internal/storage"
 	"fmt"
+	"sync"
 )
 
-type MemoryStorageService interface {
-	CreateTable(tableName string, columns []models.Column) error
-	DropTable(tableName string) error
-	Insert(tableName string, newValues []models.Cell) error
-	Select(tableName string) (*models.Table, error)
-}
-
 type memoryStorage struct {
-	tables map[string]*models.Table
+	// txMu выполняет транзакции по одной: его держит открытая транзакция до Commit или Rollback
+	txMu   sync.Mutex
+	tables map[string]*storage.Table
 }
 
-func NewMemoryStorage() MemoryStorageService {
+func NewMemoryStorage() storage.StorageService {
 	return &memoryStorage{
-		tables: map[string]*models.Table{},
+		tables: map[string]*storage.Table{},
 	}
 }
 
-func (ms *memoryStorage) CreateTable(tableName string, columns []models.Column) error {
-	if _, ok := ms.tables[tableName]; ok {
+// Begin ждет завершения открытой транзакции и открывает новую
+func (ms *memoryStorage) Begin() (storage.Transaction, error) {
+	ms.txMu.Lock()
+
+	return &transaction{ms: ms}, nil
+}
+
+// transaction меняет таблицы сразу и запоминает, как отменить каждое изменение
+type transaction struct {
+	ms *memoryStorage
+	// undo - действия, отменяющие изменения транзакции, в порядке изменений
+	undo     []func()
+	finished bool
+}
+
+func (t *transaction) CreateTable(tableName string, columns []models.Column, checks []models.Check) error {
+	if _, ok := t.ms.tables[tableName]; ok {
 		return fmt.Errorf("CreateTable(): table already exists")
 	}
 
-	ms.tables[tableName] = &models.Table{
+	t.ms.tables[tableName] = &storage.Table{
 		Name:    tableName,
 		Columns: columns,
+		Checks:  checks,
+		Rows:    [][]interface{}{},
 	}
+	t.onRollback(func() { delete(t.ms.tables, tableName) })
 
 	return nil
 }
 
-func (ms *memoryStorage) DropTable(tableName string) error {
-	_, ok := ms.tables[tableName]
+func (t *transaction) DropTable(tableName string) error {
+	table, ok := t.ms.tables[tableName]
 	if !ok {
 		return fmt.Errorf("DropTable(): table does not exist")
 	}
 
-	delete(ms.tables, tableName)
+	delete(t.ms.tables, tableName)
+	t.onRollback(func() { t.ms.tables[tableName] = table })
 	return nil
 }
 
-func (ms *memoryStorage) Insert(tableName string, newValues []models.Cell) error {
-	table, ok := ms.tables[tableName]
+func (t *transaction) Insert(tableName string, newValues []interface{}) error {
+	table, ok := t.ms.tables[tableName]
 	if !ok {
 		return fmt.Errorf("Insert(): table does not exist")
 	}
@@ -55,15 +71,251 @@ func (ms *memoryStorage) Insert(tableName string, newValues []models.Cell) error
 		return fmt.Errorf("Insert(): missing values")
 	}
 
+	if err := storage.CheckUnique(table, newValues, -1); err != nil {
+		return fmt.Errorf("Insert(): %w", err)
+	}
+
+	rowsAmount := len(table.Rows)
 	table.Rows = append(table.Rows, newValues)
+	t.onRollback(func() { table.Rows = table.Rows[:rowsAmount] })
+	return nil
+}
+
+func (t *transaction) Delete(tableName string, rowIDs []storage.RowID) error {
+	table, ok := t.ms.tables[tableName]
+	if !ok {
+		return fmt.Errorf("Delete(): table does not exist")
+	}
+
+	rows, err := storage.DeleteRowsByIndex(table.Rows, rowIDs)
+	if err != nil {
+		return fmt.Errorf("Delete(): %w", err)
+	}
+
+	// DeleteRowsByIndex строит новый срез, поэтому старый можно просто вернуть при откате
+	oldRows := table.Rows
+	table.Rows = rows
+	t.onRollback(func() { table.Rows = oldRows })
+	return nil
+}
+
+func (t *transaction) Update(tableName string, rowID storage.RowID, values []interface{}) (storage.RowID, error) {
+	table, ok := t.ms.tables[tableName]
+	if !ok {
+		return 0, fmt.Errorf("Update(): table does not exist")
+	}
+
+	if len(values) != len(table.Columns) {
+		return 0, fmt.Errorf("Update(): missing values")
+	}
+
+	if int(rowID) >= len(table.Rows) {
+		return 0, fmt.Errorf("Update(): row %d does not exist", rowID)
+	}
+
+	if err := storage.CheckUnique(table, values, int(rowID)); err != nil {
+		return 0, fmt.Errorf("Update(): %w", err)
+	}
+
+	// Заменяем строку целиком: уже выданные сканы держат ссылку на старую
+	oldRow := table.Rows[rowID]
+	table.Rows[rowID] = values
+	t.onRollback(func() { table.Rows[rowID] = oldRow })
+	return rowID, nil
+}
+
+func (t *transaction) Scan(tableName string) (storage.RowIterator, error) {
+	table, ok := t.ms.tables[tableName]
+	if !ok {
+		return nil, fmt.Errorf("Scan(): table does not exist")
+	}
+
+	// Итерируемся по копии среза, чтобы изменения таблицы в той же транзакции не влияли на скан
+	return storage.NewSliceIterator(append([][]interface{}{}, table.Rows...)), nil
+}
+
+func (t *transaction) GetTableColumns(tableName string) ([]models.Column, error) {
+	table, ok := t.ms.tables[tableName]
+	if !ok {
+		return nil, fmt.Errorf("GetTableColumns(): table does not exist")
+	}
+
+	return table.Columns, nil
+}
+
+func (t *transaction) GetTableChecks(tableName string) ([]models.Check, error) {
+	table, ok := t.ms.tables[tableName]
+	if !ok {
+		return nil, fmt.Errorf("GetTableChecks(): table does not exist")
+	}
+
+	return table.Checks, nil
+}
+
+func (t *transaction) CreateIndex(tableName string, index storage.Index) error {
+	if _, _, ok := t.findIndex(index.Name); ok {
+		return fmt.Errorf("CreateIndex(): index already exists: %s", index.Name)
+	}
+
+	table, ok := t.ms.tables[tableName]
+	if !ok {
+		return fmt.Errorf("CreateIndex(): table does not exist")
+	}
+
+	if _, err := storage.IndexColumns(table.Columns, index.Columns); err != nil {
+		return fmt.Errorf("CreateIndex(): %w", err)
+	}
+
+	if index.Unique {
+		if err := storage.ValidateUnique(table, index); err != nil {
+			return fmt.Errorf("CreateIndex(): %w", err)
+		}
+	}
+
+	index.Columns = append([]string{}, index.Columns...)
+	oldIndexes := table.Indexes
+	table.Indexes = append(append([]storage.Index{}, table.Indexes...), index)
+	t.onRollback(func() { table.Indexes = oldIndexes })
+	return nil
+}
+
+func (t *transaction) DropIndex(indexName string) error {
+	table, i, ok := t.findIndex(indexName)
+	if !ok {
+		return fmt.Errorf("DropIndex(): index does not exist: %s", indexName)
+	}
+
+	if table.Indexes[i].Unique || table.Indexes[i].ForeignKey != nil {
+		return fmt.Errorf("DropIndex(): index %s belongs to a constraint of table %s", indexName, table.Name)
+	}
+
+	t.removeIndex(table, i)
 	return nil
 }
 
-func (ms *memoryStorage) Select(tableName string) (*models.Table, error) {
-	table, ok := ms.tables[tableName]
+func (t *transaction) DropForeignKey(indexName string) error {
+	table, i, ok := t.findIndex(indexName)
+	if !ok || table.Indexes[i].ForeignKey == nil {
+		return fmt.Errorf("DropForeignKey(): foreign key does not exist: %s", indexName)
+	}
+
+	t.removeIndex(table, i)
+	return nil
+}
+
+func (t *transaction) removeIndex(table *storage.Table, i int) {
+	oldIndexes := table.Indexes
+	table.Indexes = append(append([]storage.Index{}, table.Indexes[:i]...), table.Indexes[i+1:]...)
+	t.onRollback(func() { table.Indexes = oldIndexes })
+}
+
+func (t *transaction) GetTableIndexes(tableName string) ([]storage.Index, error) {
+	table, ok := t.ms.tables[tableName]
 	if !ok {
-		return nil, fmt.Errorf("Select(): table does not exist")
+		return nil, fmt.Errorf("GetTableIndexes(): table does not exist")
+	}
+
+	return append([]storage.Index{}, table.Indexes...), nil
+}
+
+func (t *transaction) GetReferences(tableName string) ([]storage.Reference, error) {
+	if _, ok := t.ms.tables[tableName]; !ok {
+		return nil, fmt.Errorf("GetReferences(): table does not exist")
+	}
+
+	tables := make([]*storage.Table, 0, len(t.ms.tables))
+	for _, table := range t.ms.tables {
+		tables = append(tables, table)
+	}
+
+	return storage.FindReferences(tables, tableName), nil
+}
+
+// IndexScan отбирает и сортирует строки при каждом вызове: таблица и так целиком в памяти
+func (t *transaction) IndexScan(tableName, indexName string, from, to *storage.IndexBound, reverse bool) (storage.RowIterator, error) {
+	table, i, ok := t.findIndex(indexName)
+	if !ok || table.Name != tableName {
+		return nil, fmt.Errorf("IndexScan(): index does not exist: %s", indexName)
+	}
+
+	columns, err := storage.IndexColumns(table.Columns, table.Indexes[i].Columns)
+	if err != nil {
+		return nil, fmt.Errorf("IndexScan(): %w", err)
+	}
+
+	return storage.NewIndexIterator(table.Rows, columns, from, to, reverse), nil
+}
+
+// AlterTable заменяет измененные таблицы новыми версиями: уже выданные сканы держат ссылки на старые строки
+func (t *transaction) AlterTable(tableName string, alteration storage.Alteration) error {
+	tables := make([]*storage.Table, 0, len(t.ms.tables))
+	for _, table := range t.ms.tables {
+		tables = append(tables, table)
+	}
+
+	altered, err := storage.AlterTable(tables, tableName, alteration)
+	if err != nil {
+		return err
+	}
+
+	oldTables := t.ms.tables
+	t.ms.tables = make(map[string]*storage.Table, len(oldTables))
+	for name, table := range oldTables {
+		if name != tableName {
+			t.ms.tables[name] = table
+		}
+	}
+	for _, table := range altered {
+		t.ms.tables[table.Name] = table
+	}
+	t.onRollback(func() { t.ms.tables = oldTables })
+
+	return nil
+}
+
+// findIndex ищет индекс по имени во всех таблицах
+func (t *transaction) findIndex(indexName string) (*storage.Table, int, bool) {
+	for _, table := range t.ms.tables {
+		for i, index := range table.Indexes {
+			if index.Name == indexName {
+				return table, i, true
+			}
+		}
+	}
+
+	return nil, 0, false
+}
+
+func (t *transaction) Commit() error {
+	if t.finished {
+		return fmt.Errorf("Commit(): transaction is already finished")
+	}
+
+	t.finish()
+	return nil
+}
+
+// Rollback выполняет отмену изменений в обратном порядке
+func (t *transaction) Rollback() error {
+	if t.finished {
+		return fmt.Errorf("Rollback(): transaction is already finished")
 	}
 
-	return table, nil
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		t.undo[i]()
+	}
+
+	t.finish()
+	return nil
+}
+
+func (t *transaction) finish() {
+	t.finished = true
+	t.undo = nil
+	t.ms.txMu.Unlock()
+}
+
+// onRollback запоминает отмену изменения
+func (t *transaction) onRollback(undo func()) {
+	t.undo = append(t.undo, undo)
 }
@@ -3,25 +3,37 @@ package persistent_storage
 import (
 	"custom-database/config"
 	"custom-database/internal/models"
+	"custom-database/internal/storage"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 )
 
-type PersistentStorageService interface {
-	CreateTable(tableName string, columns []models.Column) error
-	DropTable(tableName string) error
-	Insert(tableName string, values []interface{}) error
-	Select(tableName string) (*table, error)
-	GetTableColumns(tableName string) ([]models.Column, error)
-}
-
+// persistentStorage - legacy движок хранения: каждая таблица лежит целиком в <table>.json
+// и переписывается при каждой вставке
 type persistentStorage struct {
 	dir string
+	// txMu выполняет транзакции по одной: его держит открытая транзакция до Commit или Rollback
+	txMu sync.Mutex
+}
+
+// transaction переписывает файлы таблиц сразу, а для отката хранит их исходное содержимое
+type transaction struct {
+	ps *persistentStorage
+	// originals - содержимое файлов таблиц до первого изменения в транзакции; nil - транзакция завершена
+	originals map[string]*tableFile
 }
 
-func NewPersistentStorage(cfg *config.Config) (PersistentStorageService, error) {
+// tableFile - сохраненное для отката состояние файла таблицы
+type tableFile struct {
+	data    []byte
+	existed bool
+}
+
+func NewPersistentStorage(cfg *config.Config) (storage.StorageService, error) {
 	if err := os.MkdirAll(cfg.DBPath, 0755); err != nil {
 		return nil, fmt.Errorf("NewPersistentStorage(): failed to create persistent storage: %w", err)
 	}
@@ -31,13 +43,17 @@ func NewPersistentStorage(cfg *config.Config) (PersistentStorageService, error)
 	}, nil
 }
 
-func (ps *persistentStorage) CreateTable(tableName string, columns []models.Column) error {
-	filename := filepath.Join(ps.dir, tableName+".json")
+func (t *transaction) CreateTable(tableName string, columns []models.Column, checks []models.Check) error {
+	filename := filepath.Join(t.ps.dir, tableName+".json")
 
 	if _, err := os.Stat(filename); err == nil {
 		return fmt.Errorf("CreateTable(): table already exists: %w", err)
 	}
 
+	if err := t.saveOriginal(filename); err != nil {
+		return fmt.Errorf("CreateTable(): %w", err)
+	}
+
 	file, err := os.Create(filename)
 	if err != nil {
 		return fmt.Errorf("CreateTable(): failed to create table file: %w", err)
@@ -46,10 +62,11 @@ func (ps *persistentStorage) CreateTable(tableName string, columns []models.Colu
 
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(models.Table{
+	if err := encoder.Encode(storage.Table{
 		Name:    tableName,
 		Columns: columns,
-		Rows:    [][]models.Cell{},
+		Checks:  checks,
+		Rows:    [][]interface{}{},
 	}); err != nil {
 		return fmt.Errorf("CreateTable(): failed to encode table: %w", err)
 	}
@@ -57,13 +74,17 @@ func (ps *persistentStorage) CreateTable(tableName string, columns []models.Colu
 	return nil
 }
 
-func (ps *persistentStorage) DropTable(tableName string) error {
-	filename := filepath.Join(ps.dir, tableName+".json")
+func (t *transaction) DropTable(tableName string) error {
+	filename := filepath.Join(t.ps.dir, tableName+".json")
 
 	if _, err := os.Stat(filename); err != nil {
 		return fmt.Errorf("DropTable(): table does not exist: %w", err)
 	}
 
+	if err := t.saveOriginal(filename); err != nil {
+		return fmt.Errorf("DropTable(): %w", err)
+	}
+
 	if err := os.Remove(filename); err != nil {
 		return fmt.Errorf("DropTable(): failed to remove table file: %w", err)
 	}
@@ -71,34 +92,40 @@ func (ps *persistentStorage) DropTable(tableName string) error {
 	return nil
 }
 
-type table struct {
-	Name    string          `json:"name"`
-	Columns []models.Column `json:"columns"`
-	Rows    [][]interface{} `json:"rows"`
-}
-
-func (ps *persistentStorage) Insert(tableName string, values []interface{}) error {
-	filename := filepath.Join(ps.dir, tableName+".json")
+func (t *transaction) Insert(tableName string, values []interface{}) error {
+	filename := filepath.Join(t.ps.dir, tableName+".json")
 
 	if _, err := os.Stat(filename); err != nil {
 		return fmt.Errorf("Insert(): table does not exist: %w", err)
 	}
 
+	if err := t.saveOriginal(filename); err != nil {
+		return fmt.Errorf("Insert(): %w", err)
+	}
+
 	file, err := os.OpenFile(filename, os.O_RDWR, 0644)
 	if err != nil {
 		return fmt.Errorf("Insert(): failed to open table file: %w", err)
 	}
 	defer file.Close()
 
-	var tableData table
+	var tableData storage.Table
 	decoder := json.NewDecoder(file)
 	if err := decoder.Decode(&tableData); err != nil {
 		return fmt.Errorf("failed to decode table data: %w", err)
 	}
 
+	normalizeRows(tableData.Columns, tableData.Rows)
+	if err := storage.CheckUnique(&tableData, values, -1); err != nil {
+		return fmt.Errorf("Insert(): %w", err)
+	}
+
 	tableData.Rows = append(tableData.Rows, values)
 
 	file.Seek(0, 0)
+	if err := file.Truncate(0); err != nil {
+		return fmt.Errorf("Insert(): failed to truncate table file: %w", err)
+	}
 
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
@@ -109,30 +136,124 @@ func (ps *persistentStorage) Insert(tableName string, values []interface{}) erro
 	return nil
 }
 
-func (ps *persistentStorage) Select(tableName string) (*table, error) {
-	filename := filepath.Join(ps.dir, tableName+".json")
+// Delete переписывает файл таблицы без удаленных строк. RowID - индекс строки в массиве rows
+func (t *transaction) Delete(tableName string, rowIDs []storage.RowID) error {
+	filename := filepath.Join(t.ps.dir, tableName+".json")
 
 	if _, err := os.Stat(filename); err != nil {
-		return nil, fmt.Errorf("Select(): table does not exist: %w", err)
+		return fmt.Errorf("Delete(): table does not exist: %w", err)
+	}
+
+	if err := t.saveOriginal(filename); err != nil {
+		return fmt.Errorf("Delete(): %w", err)
+	}
+
+	file, err := os.OpenFile(filename, os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("Delete(): failed to open table file: %w", err)
+	}
+	defer file.Close()
+
+	var tableData storage.Table
+	decoder := json.NewDecoder(file)
+	if err := decoder.Decode(&tableData); err != nil {
+		return fmt.Errorf("Delete(): failed to decode table data: %w", err)
+	}
+
+	tableData.Rows, err = storage.DeleteRowsByIndex(tableData.Rows, rowIDs)
+	if err != nil {
+		return fmt.Errorf("Delete(): %w", err)
+	}
+
+	file.Seek(0, 0)
+	if err := file.Truncate(0); err != nil {
+		return fmt.Errorf("Delete(): failed to truncate table file: %w", err)
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(tableData); err != nil {
+		return fmt.Errorf("Delete(): failed to encode table data: %w", err)
+	}
+
+	return nil
+}
+
+// Update переписывает файл таблицы с замененной строкой, RowID строки не меняется
+func (t *transaction) Update(tableName string, rowID storage.RowID, values []interface{}) (storage.RowID, error) {
+	filename := filepath.Join(t.ps.dir, tableName+".json")
+
+	if _, err := os.Stat(filename); err != nil {
+		return 0, fmt.Errorf("Update(): table does not exist: %w", err)
+	}
+
+	if err := t.saveOriginal(filename); err != nil {
+		return 0, fmt.Errorf("Update(): %w", err)
+	}
+
+	file, err := os.OpenFile(filename, os.O_RDWR, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("Update(): failed to open table file: %w", err)
+	}
+	defer file.Close()
+
+	var tableData storage.Table
+	decoder := json.NewDecoder(file)
+	if err := decoder.Decode(&tableData); err != nil {
+		return 0, fmt.Errorf("Update(): failed to decode table data: %w", err)
+	}
+
+	if int(rowID) >= len(tableData.Rows) {
+		return 0, fmt.Errorf("Update(): row %d does not exist", rowID)
+	}
+
+	normalizeRows(tableData.Columns, tableData.Rows)
+	if err := storage.CheckUnique(&tableData, values, int(rowID)); err != nil {
+		return 0, fmt.Errorf("Update(): %w", err)
+	}
+	tableData.Rows[rowID] = values
+
+	file.Seek(0, 0)
+	if err := file.Truncate(0); err != nil {
+		return 0, fmt.Errorf("Update(): failed to truncate table file: %w", err)
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(tableData); err != nil {
+		return 0, fmt.Errorf("Update(): failed to encode table data: %w", err)
+	}
+
+	return rowID, nil
+}
+
+// Scan читает таблицу целиком: формат JSON не позволяет читать строки по одной
+func (t *transaction) Scan(tableName string) (storage.RowIterator, error) {
+	filename := filepath.Join(t.ps.dir, tableName+".json")
+
+	if _, err := os.Stat(filename); err != nil {
+		return nil, fmt.Errorf("Scan(): table does not exist: %w", err)
 	}
 
 	file, err := os.Open(filename)
 	if err != nil {
-		return nil, fmt.Errorf("Select(): failed to open table file: %w", err)
+		return nil, fmt.Errorf("Scan(): failed to open table file: %w", err)
 	}
 	defer file.Close()
 
-	var tableData table
+	var tableData storage.Table
 	decoder := json.NewDecoder(file)
 	if err := decoder.Decode(&tableData); err != nil {
 		return nil, fmt.Errorf("failed to decode table data: %w", err)
 	}
 
-	return &tableData, nil
+	normalizeRows(tableData.Columns, tableData.Rows)
+
+	return storage.NewSliceIterator(tableData.Rows), nil
 }
 
-func (ps *persistentStorage) GetTableColumns(tableName string) ([]models.Column, error) {
-	filename := filepath.Join(ps.dir, tableName+".json")
+func (t *transaction) GetTableColumns(tableName string) ([]models.Column, error) {
+	filename := filepath.Join(t.ps.dir, tableName+".json")
 
 	if _, err := os.Stat(filename); err != nil {
 		return nil, fmt.Errorf("GetTableColumns(): table does not exist: %w", err)
@@ -144,7 +265,7 @@ func (ps *persistentStorage) GetTableColumns(tableName string) ([]models.Column,
 	}
 	defer file.Close()
 
-	var tableData table
+	var tableData storage.Table
 	decoder := json.NewDecoder(file)
 	if err := decoder.Decode(&tableData); err != nil {
 		return nil, fmt.Errorf("GetTableColumns(): failed to decode table data: %w", err)
@@ -152,3 +273,303 @@ func (ps *persistentStorage) GetTableColumns(tableName string) ([]models.Column,
 
 	return tableData.Columns, nil
 }
+
+func (t *transaction) GetTableChecks(tableName string) ([]models.Check, error) {
+	tableData, err := t.readTable(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("GetTableChecks(): %w", err)
+	}
+
+	return tableData.Checks, nil
+}
+
+func (t *transaction) CreateIndex(tableName string, index storage.Index) error {
+	if _, _, err := t.findIndex(index.Name); err == nil {
+		return fmt.Errorf("CreateIndex(): index already exists: %s", index.Name)
+	}
+
+	tableData, err := t.readTable(tableName)
+	if err != nil {
+		return fmt.Errorf("CreateIndex(): %w", err)
+	}
+
+	if _, err := storage.IndexColumns(tableData.Columns, index.Columns); err != nil {
+		return fmt.Errorf("CreateIndex(): %w", err)
+	}
+
+	if index.Unique {
+		if err := storage.ValidateUnique(tableData, index); err != nil {
+			return fmt.Errorf("CreateIndex(): %w", err)
+		}
+	}
+
+	tableData.Indexes = append(tableData.Indexes, index)
+	if err := t.writeTable(tableData); err != nil {
+		return fmt.Errorf("CreateIndex(): %w", err)
+	}
+
+	return nil
+}
+
+func (t *transaction) DropIndex(indexName string) error {
+	tableData, i, err := t.findIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("DropIndex(): %w", err)
+	}
+
+	if tableData.Indexes[i].Unique || tableData.Indexes[i].ForeignKey != nil {
+		return fmt.Errorf("DropIndex(): index %s belongs to a constraint of table %s", indexName, tableData.Name)
+	}
+
+	tableData.Indexes = append(tableData.Indexes[:i], tableData.Indexes[i+1:]...)
+	if err := t.writeTable(tableData); err != nil {
+		return fmt.Errorf("DropIndex(): %w", err)
+	}
+
+	return nil
+}
+
+func (t *transaction) DropForeignKey(indexName string) error {
+	tableData, i, err := t.findIndex(indexName)
+	if err != nil || tableData.Indexes[i].ForeignKey == nil {
+		return fmt.Errorf("DropForeignKey(): foreign key does not exist: %s", indexName)
+	}
+
+	tableData.Indexes = append(tableData.Indexes[:i], tableData.Indexes[i+1:]...)
+	if err := t.writeTable(tableData); err != nil {
+		return fmt.Errorf("DropForeignKey(): %w", err)
+	}
+
+	return nil
+}
+
+func (t *transaction) GetReferences(tableName string) ([]storage.Reference, error) {
+	if _, err := t.readTable(tableName); err != nil {
+		return nil, fmt.Errorf("GetReferences(): %w", err)
+	}
+
+	tables, err := t.readTables()
+	if err != nil {
+		return nil, fmt.Errorf("GetReferences(): %w", err)
+	}
+
+	return storage.FindReferences(tables, tableName), nil
+}
+
+func (t *transaction) GetTableIndexes(tableName string) ([]storage.Index, error) {
+	tableData, err := t.readTable(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("GetTableIndexes(): %w", err)
+	}
+
+	return tableData.Indexes, nil
+}
+
+// IndexScan читает таблицу целиком и сортирует подходящие строки: в JSON индекс хранится только описанием
+func (t *transaction) IndexScan(tableName, indexName string, from, to *storage.IndexBound, reverse bool) (storage.RowIterator, error) {
+	tableData, err := t.readTable(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("IndexScan(): %w", err)
+	}
+
+	for _, index := range tableData.Indexes {
+		if index.Name == indexName {
+			columns, err := storage.IndexColumns(tableData.Columns, index.Columns)
+			if err != nil {
+				return nil, fmt.Errorf("IndexScan(): %w", err)
+			}
+
+			return storage.NewIndexIterator(tableData.Rows, columns, from, to, reverse), nil
+		}
+	}
+
+	return nil, fmt.Errorf("IndexScan(): index does not exist: %s", indexName)
+}
+
+// AlterTable переписывает файлы измененных таблиц. При переименовании строки переезжают в файл с новым именем
+func (t *transaction) AlterTable(tableName string, alteration storage.Alteration) error {
+	tables, err := t.readTables()
+	if err != nil {
+		return fmt.Errorf("AlterTable(): %w", err)
+	}
+
+	altered, err := storage.AlterTable(tables, tableName, alteration)
+	if err != nil {
+		return err
+	}
+
+	for _, tableData := range altered {
+		if err := t.writeTable(tableData); err != nil {
+			return fmt.Errorf("AlterTable(): %w", err)
+		}
+	}
+
+	if alteration.Name != tableName {
+		filename := filepath.Join(t.ps.dir, tableName+".json")
+		if err := t.saveOriginal(filename); err != nil {
+			return fmt.Errorf("AlterTable(): %w", err)
+		}
+
+		if err := os.Remove(filename); err != nil {
+			return fmt.Errorf("AlterTable(): failed to remove table file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// findIndex ищет индекс по имени в файлах всех таблиц
+func (t *transaction) findIndex(indexName string) (*storage.Table, int, error) {
+	tables, err := t.readTables()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, tableData := range tables {
+		for i, index := range tableData.Indexes {
+			if index.Name == indexName {
+				return tableData, i, nil
+			}
+		}
+	}
+
+	return nil, 0, fmt.Errorf("index does not exist: %s", indexName)
+}
+
+// readTables читает файлы всех таблиц в порядке имен
+func (t *transaction) readTables() ([]*storage.Table, error) {
+	filenames, err := filepath.Glob(filepath.Join(t.ps.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list table files: %w", err)
+	}
+
+	tables := make([]*storage.Table, 0, len(filenames))
+	for _, filename := range filenames {
+		tableData, err := t.readTable(strings.TrimSuffix(filepath.Base(filename), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, tableData)
+	}
+
+	return tables, nil
+}
+
+// readTable читает файл таблицы целиком и приводит значения строк к типам backend
+func (t *transaction) readTable(tableName string) (*storage.Table, error) {
+	content, err := os.ReadFile(filepath.Join(t.ps.dir, tableName+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("table does not exist: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table file: %w", err)
+	}
+
+	var tableData storage.Table
+	if err := json.Unmarshal(content, &tableData); err != nil {
+		return nil, fmt.Errorf("failed to decode table data: %w", err)
+	}
+	normalizeRows(tableData.Columns, tableData.Rows)
+
+	return &tableData, nil
+}
+
+// writeTable переписывает файл таблицы, сохранив его содержимое для отката
+func (t *transaction) writeTable(tableData *storage.Table) error {
+	filename := filepath.Join(t.ps.dir, tableData.Name+".json")
+
+	if err := t.saveOriginal(filename); err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(tableData, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode table data: %w", err)
+	}
+
+	if err := os.WriteFile(filename, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write table file: %w", err)
+	}
+
+	return nil
+}
+
+// normalizeRows приводит значения, прочитанные из JSON, к типам backend:
+// encoding/json декодирует все числа как float64, а INT хранится как int32
+func normalizeRows(columns []models.Column, rows [][]interface{}) {
+	for _, row := range rows {
+		for i, column := range columns {
+			if i >= len(row) {
+				break
+			}
+
+			if number, ok := row[i].(float64); ok && column.Type == models.IntType {
+				row[i] = int32(number)
+			}
+		}
+	}
+}
+
+// Begin ждет завершения открытой транзакции и открывает новую
+func (ps *persistentStorage) Begin() (storage.Transaction, error) {
+	ps.txMu.Lock()
+
+	return &transaction{ps: ps, originals: map[string]*tableFile{}}, nil
+}
+
+func (t *transaction) Commit() error {
+	if t.originals == nil {
+		return fmt.Errorf("Commit(): transaction is already finished")
+	}
+
+	t.finish()
+	return nil
+}
+
+// Rollback возвращает файлы таблиц, измененные в транзакции, к сохраненному содержимому
+func (t *transaction) Rollback() error {
+	if t.originals == nil {
+		return fmt.Errorf("Rollback(): transaction is already finished")
+	}
+	originals := t.originals
+	defer t.finish()
+
+	for filename, original := range originals {
+		if !original.existed {
+			if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("Rollback(): failed to remove table file: %w", err)
+			}
+			continue
+		}
+
+		if err := os.WriteFile(filename, original.data, 0644); err != nil {
+			return fmt.Errorf("Rollback(): failed to restore table file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (t *transaction) finish() {
+	t.originals = nil
+	t.ps.txMu.Unlock()
+}
+
+// saveOriginal запоминает содержимое файла таблицы перед его первым изменением в транзакции
+func (t *transaction) saveOriginal(filename string) error {
+	if _, ok := t.originals[filename]; ok {
+		return nil
+	}
+
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		t.originals[filename] = &tableFile{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save table file for rollback: %w", err)
+	}
+
+	t.originals[filename] = &tableFile{data: data, existed: true}
+	return nil
+}
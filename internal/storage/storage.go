@@ -0,0 +1,445 @@
+package storage
+
+import (
+	"custom-database/internal/models"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Движки хранения, которые можно выбрать через STORAGE_ENGINE
+const (
+	DiskEngine   = "disk"   // страничное хранилище disk_manager
+	JsonEngine   = "json"   // legacy: одна таблица = один <table>.json
+	MemoryEngine = "memory" // данные живут только в памяти процесса
+)
+
+// StorageService - общий интерфейс движков хранения, с которым работает backend.
+// Вся работа с данными идет внутри транзакций
+type StorageService interface {
+	// Begin открывает транзакцию. Движок disk изолирует транзакции снимками (MVCC) и пускает их
+	// параллельно, движки json и memory выполняют транзакции по одной
+	Begin() (Transaction, error)
+}
+
+// Transaction - транзакция движка хранения. Изменения всех таблиц применяются вместе при Commit,
+// а Rollback отменяет их все. После Commit или Rollback транзакцию использовать нельзя.
+// Значения строк передаются как Go-типы: int32 для INT, string для TEXT,
+// bool для BOOLEAN, string в формате "2006-01-02 15:04:05" для TIMESTAMP и nil для NULL
+type Transaction interface {
+	// CreateTable создает таблицу с ограничениями CHECK. Движок хранит NOT NULL, DEFAULT и CHECK,
+	// а проверяет их при записи backend
+	CreateTable(tableName string, columns []models.Column, checks []models.Check) error
+	DropTable(tableName string) error
+	Insert(tableName string, values []interface{}) error
+	Delete(tableName string, rowIDs []RowID) error
+	Update(tableName string, rowID RowID, values []interface{}) (RowID, error)
+	Scan(tableName string) (RowIterator, error)
+	GetTableColumns(tableName string) ([]models.Column, error)
+	GetTableChecks(tableName string) ([]models.Check, error)
+	// CreateIndex строит индекс по колонкам таблицы в заданном порядке. Имена индексов уникальны во всей базе.
+	// Уникальный индекс проверяет уже записанные строки и дальше не пускает повторы значений своих колонок
+	CreateIndex(tableName string, index Index) error
+	// DropIndex удаляет индекс. Индекс ограничения PRIMARY KEY, UNIQUE или FOREIGN KEY удалить нельзя
+	DropIndex(indexName string) error
+	// DropForeignKey удаляет ограничение FOREIGN KEY вместе с его индексом
+	DropForeignKey(indexName string) error
+	GetTableIndexes(tableName string) ([]Index, error)
+	// GetReferences возвращает внешние ключи всех таблиц, в том числе самой tableName, которые ссылаются на tableName
+	GetReferences(tableName string) ([]Reference, error)
+	// IndexScan возвращает строки, у которых значения колонок индекса лежат между from и to,
+	// в порядке этих значений: по первой колонке, при равенстве - по второй и т.д., NULL - после всех значений.
+	// При reverse строки идут в обратном порядке. nil или пустая граница - диапазон с этой стороны не ограничен
+	IndexScan(tableName, indexName string, from, to *IndexBound, reverse bool) (RowIterator, error)
+	// AlterTable заменяет схему таблицы: переименовывает ее, добавляет, удаляет, переименовывает колонки
+	// и меняет их тип. Индексы и внешние ключи следуют за новыми именами, а удалить колонку индекса
+	// или сменить ее тип нельзя
+	AlterTable(tableName string, alteration Alteration) error
+	Commit() error
+	Rollback() error
+}
+
+// StatsProvider реализуют движки, которые отдают счетчики для мониторинга,
+// например попадания и промахи буферного пула disk_manager
+type StatsProvider interface {
+	Stats() map[string]uint64
+}
+
+// RowID - идентификатор строки внутри движка. Значение непрозрачно для backend:
+// его можно только получить из RowIterator и передать обратно в тот же движок
+type RowID uint64
+
+// RowIterator построчно отдает строки таблицы, не загружая всю таблицу в память
+type RowIterator interface {
+	Next() bool
+	Row() []interface{}
+	RowID() RowID
+	Err() error
+}
+
+type Table struct {
+	Name    string          `json:"name"`
+	Columns []models.Column `json:"columns"`
+	Indexes []Index         `json:"indexes,omitempty"`
+	Checks  []models.Check  `json:"checks,omitempty"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// Index - вторичный индекс по одной или нескольким колонкам таблицы.
+// Unique-индекс поддерживает ограничение UNIQUE, Primary - ограничение PRIMARY KEY (он тоже Unique).
+// Индекс с ForeignKey поддерживает ограничение FOREIGN KEY по своим колонкам: по нему ищутся строки,
+// которые ссылаются на удаляемую или изменяемую строку
+type Index struct {
+	Name       string      `json:"name"`
+	Columns    []string    `json:"columns"`
+	Unique     bool        `json:"unique,omitempty"`
+	Primary    bool        `json:"primary,omitempty"`
+	ForeignKey *ForeignKey `json:"foreign_key,omitempty"`
+}
+
+// ReferentialAction - действие внешнего ключа при удалении или изменении строки, на которую он ссылается
+type ReferentialAction uint8
+
+const (
+	// RestrictAction запрещает удалять и менять строку, на которую ссылаются
+	RestrictAction ReferentialAction = iota
+	// CascadeAction удаляет ссылающиеся строки или меняет в них значения ключа
+	CascadeAction
+	// SetNullAction записывает NULL в колонки ключа ссылающихся строк
+	SetNullAction
+)
+
+// ForeignKey - ссылка колонок индекса на колонки RefColumns таблицы RefTable.
+// На RefColumns в RefTable должен быть уникальный индекс
+type ForeignKey struct {
+	RefTable   string            `json:"ref_table"`
+	RefColumns []string          `json:"ref_columns"`
+	OnDelete   ReferentialAction `json:"on_delete,omitempty"`
+	OnUpdate   ReferentialAction `json:"on_update,omitempty"`
+}
+
+// Alteration - новая схема таблицы для AlterTable
+type Alteration struct {
+	// Name - имя таблицы в новой схеме
+	Name    string
+	Columns []AlteredColumn
+	Checks  []models.Check
+}
+
+// AlteredColumn - колонка новой схемы. Source - номер колонки в текущей схеме, -1 - колонка добавляется,
+// и в уже записанных строках получает значение Missing. Если Convert не nil, значения колонки
+// во всех строках заменяются результатом Convert
+type AlteredColumn struct {
+	models.Column
+	Source  int
+	Missing interface{}
+	Convert func(value interface{}) (interface{}, error)
+}
+
+// Reference - внешний ключ таблицы Table, который ссылается на другую таблицу
+type Reference struct {
+	Table string
+	Index Index
+}
+
+// UnmarshalJSON читает и старый формат описания индекса с одной колонкой {"name": ..., "column": ...}
+func (index *Index) UnmarshalJSON(data []byte) error {
+	var stored struct {
+		Name       string      `json:"name"`
+		Column     string      `json:"column"`
+		Columns    []string    `json:"columns"`
+		Unique     bool        `json:"unique"`
+		Primary    bool        `json:"primary"`
+		ForeignKey *ForeignKey `json:"foreign_key"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("UnmarshalJSON(): %w", err)
+	}
+
+	index.Name, index.Columns = stored.Name, stored.Columns
+	index.Unique, index.Primary, index.ForeignKey = stored.Unique, stored.Primary, stored.ForeignKey
+	if len(index.Columns) == 0 && stored.Column != "" {
+		index.Columns = []string{stored.Column}
+	}
+
+	return nil
+}
+
+// FindReferences возвращает внешние ключи таблиц tables, которые ссылаются на tableName, в порядке имен таблиц и индексов.
+// Для движков, которые держат таблицы целиком (json, memory)
+func FindReferences(tables []*Table, tableName string) []Reference {
+	references := []Reference{}
+	for _, table := range tables {
+		for _, index := range table.Indexes {
+			if index.ForeignKey != nil && index.ForeignKey.RefTable == tableName {
+				references = append(references, Reference{Table: table.Name, Index: index})
+			}
+		}
+	}
+	sort.Slice(references, func(i, j int) bool {
+		if references[i].Table != references[j].Table {
+			return references[i].Table < references[j].Table
+		}
+		return references[i].Index.Name < references[j].Index.Name
+	})
+
+	return references
+}
+
+// IndexBound - граница диапазона IndexScan: значения первых колонок индекса в том же виде, что и значения строк.
+// Граница из части колонок включает или исключает все строки с этими значениями, например
+// {Values: [1], Inclusive: false} для индекса (tenant_id, created_at) - все строки с tenant_id > 1.
+// NULL в границе не равен ничему, и диапазон с ним пуст
+type IndexBound struct {
+	Values    []interface{}
+	Inclusive bool
+}
+
+// IndexColumns возвращает номера колонок индекса в строке таблицы. Колонки должны существовать и не повторяться
+func IndexColumns(columns []models.Column, columnNames []string) ([]int, error) {
+	if len(columnNames) == 0 {
+		return nil, fmt.Errorf("IndexColumns(): index must have at least one column")
+	}
+
+	result := make([]int, len(columnNames))
+	for i, columnName := range columnNames {
+		result[i] = ColumnIndex(columns, columnName)
+		if result[i] == -1 {
+			return nil, fmt.Errorf("IndexColumns(): column %s does not exist", columnName)
+		}
+		for _, previous := range columnNames[:i] {
+			if previous == columnName {
+				return nil, fmt.Errorf("IndexColumns(): column %s is repeated", columnName)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// ColumnIndex возвращает номер колонки по имени или -1
+func ColumnIndex(columns []models.Column, columnName string) int {
+	for i, column := range columns {
+		if column.Name == columnName {
+			return i
+		}
+	}
+
+	return -1
+}
+
+type sliceIterator struct {
+	rows  [][]interface{}
+	index int
+}
+
+// NewSliceIterator оборачивает уже загруженные строки в RowIterator
+// для движков, которые держат таблицу целиком (json, memory)
+func NewSliceIterator(rows [][]interface{}) RowIterator {
+	return &sliceIterator{rows: rows}
+}
+
+func (it *sliceIterator) Next() bool {
+	if it.index >= len(it.rows) {
+		return false
+	}
+	it.index++
+	return true
+}
+
+func (it *sliceIterator) Row() []interface{} {
+	return it.rows[it.index-1]
+}
+
+// RowID для движков со срезом строк - индекс строки в таблице
+func (it *sliceIterator) RowID() RowID {
+	return RowID(it.index - 1)
+}
+
+func (it *sliceIterator) Err() error {
+	return nil
+}
+
+// DeleteRowsByIndex возвращает строки без тех, чьи индексы перечислены в rowIDs.
+// Используется движками, у которых RowID - индекс строки в срезе
+func DeleteRowsByIndex(rows [][]interface{}, rowIDs []RowID) ([][]interface{}, error) {
+	deleted := make(map[RowID]bool, len(rowIDs))
+	for _, rowID := range rowIDs {
+		if int(rowID) >= len(rows) {
+			return nil, fmt.Errorf("DeleteRowsByIndex(): row %d does not exist", rowID)
+		}
+		deleted[rowID] = true
+	}
+
+	result := make([][]interface{}, 0, len(rows)-len(deleted))
+	for i, row := range rows {
+		if !deleted[RowID(i)] {
+			result = append(result, row)
+		}
+	}
+
+	return result, nil
+}
+
+// CompareValues сравнивает два значения одного типа backend: -1, 0 или 1.
+// TIMESTAMP хранится строкой фиксированного формата, поэтому сравнивается как строка
+func CompareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case int32:
+		b := b.(int32)
+		if a < b {
+			return -1
+		}
+		if a > b {
+			return 1
+		}
+		return 0
+	case string:
+		b := b.(string)
+		if a < b {
+			return -1
+		}
+		if a > b {
+			return 1
+		}
+		return 0
+	case bool:
+		b := b.(bool)
+		if a == b {
+			return 0
+		}
+		if !a {
+			return -1
+		}
+		return 1
+	}
+
+	panic(fmt.Sprintf("CompareValues(): unsupported value type %T", a))
+}
+
+// compareKey сравнивает значения колонок columns строки row со значениями values по колонкам слева направо.
+// Сравниваются только первые len(values) колонок, NULL больше любого значения
+func compareKey(row []interface{}, columns []int, values []interface{}) int {
+	for i, value := range values {
+		a := row[columns[i]]
+		switch {
+		case a == nil && value == nil:
+			continue
+		case a == nil:
+			return 1
+		case value == nil:
+			return -1
+		}
+
+		if cmp := CompareValues(a, value); cmp != 0 {
+			return cmp
+		}
+	}
+
+	return 0
+}
+
+// indexIterator отдает строки из среза в порядке значений колонок индекса, сохраняя их RowID
+type indexIterator struct {
+	rows    [][]interface{}
+	rowIDs  []RowID
+	columns []int
+	index   int
+}
+
+// NewIndexIterator выполняет IndexScan для движков, которые держат таблицу целиком (json, memory):
+// отбирает строки со значениями колонок columns между from и to и сортирует их по этим значениям
+func NewIndexIterator(rows [][]interface{}, columns []int, from, to *IndexBound, reverse bool) RowIterator {
+	it := &indexIterator{columns: columns}
+	if hasNull(from) || hasNull(to) {
+		return it
+	}
+
+	for i, row := range rows {
+		if !aboveBound(row, columns, from) || !belowBound(row, columns, to) {
+			continue
+		}
+
+		it.rows = append(it.rows, row)
+		it.rowIDs = append(it.rowIDs, RowID(i))
+	}
+
+	sort.Stable(it)
+	if reverse {
+		slices.Reverse(it.rows)
+		slices.Reverse(it.rowIDs)
+	}
+
+	return it
+}
+
+func hasNull(bound *IndexBound) bool {
+	if bound == nil {
+		return false
+	}
+	for _, value := range bound.Values {
+		if value == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func aboveBound(row []interface{}, columns []int, from *IndexBound) bool {
+	if from == nil || len(from.Values) == 0 {
+		return true
+	}
+
+	cmp := compareKey(row, columns, from.Values)
+	return cmp > 0 || (cmp == 0 && from.Inclusive)
+}
+
+func belowBound(row []interface{}, columns []int, to *IndexBound) bool {
+	if to == nil || len(to.Values) == 0 {
+		return true
+	}
+
+	cmp := compareKey(row, columns, to.Values)
+	return cmp < 0 || (cmp == 0 && to.Inclusive)
+}
+
+func (it *indexIterator) Len() int {
+	return len(it.rows)
+}
+
+func (it *indexIterator) Less(i, j int) bool {
+	values := make([]interface{}, len(it.columns))
+	for k, column := range it.columns {
+		values[k] = it.rows[j][column]
+	}
+
+	return compareKey(it.rows[i], it.columns, values) < 0
+}
+
+func (it *indexIterator) Swap(i, j int) {
+	it.rows[i], it.rows[j] = it.rows[j], it.rows[i]
+	it.rowIDs[i], it.rowIDs[j] = it.rowIDs[j], it.rowIDs[i]
+}
+
+func (it *indexIterator) Next() bool {
+	if it.index >= len(it.rows) {
+		return false
+	}
+	it.index++
+	return true
+}
+
+func (it *indexIterator) Row() []interface{} {
+	return it.rows[it.index-1]
+}
+
+// RowID - индекс строки в таблице, как у sliceIterator
+func (it *indexIterator) RowID() RowID {
+	return it.rowIDs[it.index-1]
+}
+
+func (it *indexIterator) Err() error {
+	return nil
+}